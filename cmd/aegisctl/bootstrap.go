@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mvaleed/aegis/internal/auth"
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage/postgres"
+)
+
+func runCreateAdmin(ctx context.Context, args []string) error {
+	// The password comes from the environment or stdin rather than a flag,
+	// which would leave it in the shell history and process list
+	fs := newFlagSet("create-admin", "< password")
+	email := fs.String("email", "", "admin's email (required)")
+	username := fs.String("username", "admin", "admin's username")
+	fullName := fs.String("name", "Administrator", "admin's full name")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		fs.Usage()
+		return errors.New("-email is required")
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+	if err := auth.ValidatePasswordStrength(password); err != nil {
+		return err
+	}
+
+	pool, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	users := postgres.NewUserRepository(pool)
+	roles := postgres.NewRoleRepository(pool)
+
+	user, err := domain.NewUser(*email, *username, *fullName, domain.UserTypeAdmin)
+	if err != nil {
+		return err
+	}
+	if user.PasswordHash, err = auth.HashPassword(password); err != nil {
+		return err
+	}
+	user.VerifyEmail()
+	if err := user.Activate(); err != nil {
+		return err
+	}
+
+	err = postgres.NewTransactor(pool).WithTransaction(ctx, func(ctx context.Context) error {
+		role, err := roles.GetByName(ctx, "admin")
+		if errors.Is(err, domain.ErrNotFound) {
+			return errors.New("no admin role; run 'aegisctl seed' first")
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := users.Create(ctx, user); err != nil {
+			if errors.Is(err, domain.ErrAlreadyExists) {
+				return errors.New("a user with this email or username already exists")
+			}
+			return err
+		}
+		return roles.AssignRole(ctx, user.ID, role.ID, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("created admin %s (%s)\n", user.Email, user.ID)
+	return nil
+}
+
+// readPassword returns $AEGIS_ADMIN_PASSWORD or the first line of stdin.
+func readPassword() (string, error) {
+	if password := os.Getenv("AEGIS_ADMIN_PASSWORD"); password != "" {
+		return password, nil
+	}
+
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(os.Stderr, "Password: ")
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", errors.New("no password on stdin")
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
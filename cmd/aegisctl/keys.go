@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mvaleed/aegis/internal/config"
+	"github.com/mvaleed/aegis/internal/secrets"
+)
+
+func runRotateJWTKey(ctx context.Context, args []string) error {
+	fs := newFlagSet("rotate-jwt-key", "")
+	printOnly := fs.Bool("print", false, "print the new key instead of writing it to the secrets provider")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// Two rand.Text values: about 260 bits, above the 32 byte minimum
+	key := rand.Text() + rand.Text()
+
+	if *printOnly {
+		fmt.Println(key)
+		return nil
+	}
+
+	cfg := config.Load()
+	if cfg.SecretsProvider != "vault" {
+		return errors.New("only the vault provider can be written to; rerun with -print and store the key as JWT_SECRET_KEY yourself")
+	}
+	if err := cfg.Vault().Patch(ctx, map[string]string{secrets.JWTSecretKey: key}); err != nil {
+		return err
+	}
+
+	// Servers sign with the new key and keep accepting the old one from
+	// their next secrets refresh
+	fmt.Fprintf(os.Stderr, "wrote a new %s; servers pick it up within SECRETS_REFRESH_INTERVAL (%s)\n",
+		secrets.JWTSecretKey, cfg.SecretsRefreshInterval)
+	return nil
+}
@@ -0,0 +1,156 @@
+// Command aegisctl operates an aegis deployment.
+//
+// Bootstrap commands (migrate, create-admin, seed), reencrypt, export and
+// import connect to the database directly, using the same environment as
+// the server, so they work before anyone can log in. rotate-jwt-key writes
+// to the secrets provider, and revoke-sessions goes through the gRPC API
+// with an admin's access token.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/auth"
+	"github.com/mvaleed/aegis/internal/config"
+	"github.com/mvaleed/aegis/internal/encryption"
+	"github.com/mvaleed/aegis/internal/secrets"
+	"github.com/mvaleed/aegis/internal/storage/postgres"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"migrate", "apply or roll back database migrations", runMigrate},
+	{"create-admin", "create an active admin user", runCreateAdmin},
+	{"seed", "create missing roles and permissions", runSeed},
+	{"sync-rbac", "make roles and permissions match a YAML or JSON file", runSyncRBAC},
+	{"rotate-jwt-key", "generate a new JWT signing key", runRotateJWTKey},
+	{"revoke-sessions", "revoke a user's refresh tokens", runRevokeSessions},
+	{"reencrypt", "move encrypted columns to the current key", runReencrypt},
+	{"export", "write users, roles and organizations to a JSON file", runExport},
+	{"import", "load a file written by export", runImport},
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "help" || os.Args[1] == "-h" || os.Args[1] == "--help" {
+		usage()
+		os.Exit(2)
+	}
+
+	i := slices.IndexFunc(commands, func(c command) bool { return c.name == os.Args[1] })
+	if i < 0 {
+		fmt.Fprintf(os.Stderr, "aegisctl: unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	cmd := commands[i]
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := cmd.run(ctx, os.Args[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "aegisctl %s: %v\n", cmd.name, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: aegisctl <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run 'aegisctl <command> -h' for a command's flags. Database and secrets")
+	fmt.Fprintln(os.Stderr, "settings are read from the same environment as the server.")
+}
+
+// newFlagSet creates the flag set for a command, with usage naming its
+// positional arguments.
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: aegisctl %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// loadEnvironment loads the server's configuration and secrets, and hands
+// the password peppers and column encryption keys to the packages using
+// them so hashes and ciphertexts match the server's.
+func loadEnvironment(ctx context.Context) (*config.Config, *secrets.Store, error) {
+	cfg := config.Load()
+
+	store, err := cfg.NewSecretStore(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("secrets: %w", err)
+	}
+
+	auth.SetPeppers(store.Get(secrets.PasswordPepper), splitKeys(store.Get(secrets.PreviousPeppers))...)
+	encryption.SetKeys(store.Get(secrets.ColumnEncryptionKey), splitKeys(store.Get(secrets.PreviousColumnKeys))...)
+
+	return cfg, store, nil
+}
+
+// splitKeys splits a comma-separated list of previous keys.
+func splitKeys(list string) []string {
+	var keys []string
+	for _, key := range strings.Split(list, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// connect opens a pool on the server's database. Connections use the
+// public schema, or the sandbox one for a context in the sandbox
+// environment.
+func connect(ctx context.Context) (*pgxpool.Pool, error) {
+	cfg, store, err := loadEnvironment(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	poolConfig, err := pgxpool.ParseConfig(cfg.DatabaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse database URL: %w", err)
+	}
+	poolConfig.BeforeConnect = func(_ context.Context, conn *pgx.ConnConfig) error {
+		if password := store.Get(secrets.DatabasePassword); password != "" {
+			conn.Password = password
+		}
+		return nil
+	}
+	postgres.IsolateEnvironments(poolConfig)
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
+	return pool, nil
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+
+	"github.com/mvaleed/aegis/internal/schema"
+	"github.com/mvaleed/aegis/internal/secrets"
+	"github.com/mvaleed/aegis/internal/storage/postgres"
+)
+
+func runMigrate(ctx context.Context, args []string) error {
+	fs := newFlagSet("migrate", "up [N] | expand | contract | down [N] | version | force VERSION | check")
+	path := fs.String("path", "migrations", "directory holding the migration files")
+	sandbox := fs.Bool("sandbox", false, "migrate the sandbox schema instead of public (after public is up to date)")
+	since := fs.Uint("since", 0, "check: only check migrations above this version")
+	src := fs.String("src", "internal", "check: Go source whose SQL must not use dropped or renamed columns")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		return errors.New("expected an action")
+	}
+
+	action := fs.Arg(0)
+	n := 0
+	if fs.NArg() == 2 {
+		var err error
+		if n, err = strconv.Atoi(fs.Arg(1)); err != nil || n < 0 {
+			return fmt.Errorf("invalid count %q", fs.Arg(1))
+		}
+	}
+
+	migrations, err := schema.Load(os.DirFS(*path))
+	if err != nil {
+		return err
+	}
+	if action == "check" {
+		return checkMigrations(migrations, *since, *src)
+	}
+
+	databaseURL, err := migrationURL(ctx, *sandbox)
+	if err != nil {
+		return err
+	}
+
+	m, err := migrate.New("file://"+*path, databaseURL)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	m.Log = migrateLogger{}
+
+	// Stop between migrations on SIGINT instead of leaving one half done
+	go func() {
+		<-ctx.Done()
+		m.GracefulStop <- true
+	}()
+
+	switch action {
+	case "up":
+		if n > 0 {
+			err = m.Steps(n)
+		} else {
+			err = m.Up()
+		}
+	case "expand":
+		// Everything the new binary needs while the previous one still runs
+		var current uint
+		if current, _, err = m.Version(); errors.Is(err, migrate.ErrNilVersion) {
+			err = nil
+		}
+		if err != nil {
+			return err
+		}
+		if n = schema.ExpandSteps(migrations, current); n > 0 {
+			err = m.Steps(n)
+		} else {
+			err = migrate.ErrNoChange
+		}
+		if pending := schema.Pending(migrations, current); len(pending) > n {
+			fmt.Fprintf(os.Stderr, "%d contract migration(s) left from %s; run contract once the previous version is gone\n", len(pending)-n, pending[n].Name)
+		}
+	case "contract":
+		err = m.Up()
+	case "down":
+		// Rolling everything back takes an explicit count
+		if n == 0 {
+			n = 1
+		}
+		err = m.Steps(-n)
+	case "version":
+	case "force":
+		if fs.NArg() != 2 {
+			return errors.New("force needs a version")
+		}
+		err = m.Force(n)
+	default:
+		return fmt.Errorf("unknown action %q", action)
+	}
+	if errors.Is(err, migrate.ErrNoChange) {
+		err = nil
+		fmt.Fprintln(os.Stderr, "no change")
+	}
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		fmt.Println("version: none")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("version: %d", version)
+	if dirty {
+		fmt.Print(" (dirty)")
+	}
+	fmt.Println()
+
+	if action != "version" && !dirty {
+		if err := recordPhases(ctx, *sandbox, migrations, version); err != nil {
+			return fmt.Errorf("recording migration phases: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkMigrations prints what would break the previous binary, or this one,
+// in the migrations above since.
+func checkMigrations(migrations []schema.Migration, since uint, src string) error {
+	findings, err := schema.Check(migrations, since, os.DirFS(src))
+	if err != nil {
+		return err
+	}
+	for _, f := range findings {
+		fmt.Println(f)
+	}
+	if len(findings) > 0 {
+		return fmt.Errorf("%d incompatible change(s)", len(findings))
+	}
+	fmt.Fprintf(os.Stderr, "%d migration(s) checked\n", len(schema.Pending(migrations, since)))
+	return nil
+}
+
+// recordPhases brings schema_migration_phases in line with version, which
+// /readyz reads to tell which contract migrations have been applied.
+func recordPhases(ctx context.Context, sandbox bool, migrations []schema.Migration, version uint) error {
+	pool, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	ctx = withEnvironment(ctx, sandbox)
+	repo := postgres.NewSchemaRepository(pool)
+	return postgres.NewTransactor(pool).WithTransaction(ctx, func(ctx context.Context) error {
+		return repo.Record(ctx, migrations, version)
+	})
+}
+
+// migrationURL returns DATABASE_URL for the pgx5 migrate driver, with the
+// password from the secrets provider and, for the sandbox, its own
+// search_path and with it its own schema_migrations. public stays on the
+// path for the uuid-ossp functions.
+func migrationURL(ctx context.Context, sandbox bool) (string, error) {
+	cfg, store, err := loadEnvironment(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(cfg.DatabaseURL)
+	if err != nil || u.Host == "" {
+		return "", errors.New("DATABASE_URL must be a postgres:// URL to run migrations")
+	}
+	u.Scheme = "pgx5"
+
+	if password := store.Get(secrets.DatabasePassword); password != "" {
+		u.User = url.UserPassword(u.User.Username(), password)
+	}
+	if sandbox {
+		q := u.Query()
+		q.Set("search_path", "sandbox,public")
+		u.RawQuery = q.Encode()
+	}
+	return u.String(), nil
+}
+
+type migrateLogger struct{}
+
+func (migrateLogger) Printf(format string, v ...any) {
+	fmt.Fprintf(os.Stderr, format, v...)
+}
+
+func (migrateLogger) Verbose() bool {
+	return false
+}
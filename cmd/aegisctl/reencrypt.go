@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mvaleed/aegis/internal/service"
+	"github.com/mvaleed/aegis/internal/storage/postgres"
+)
+
+func runReencrypt(ctx context.Context, args []string) error {
+	fs := newFlagSet("reencrypt", "")
+	batch := fs.Int("batch", 100, "rows rewritten per batch")
+	status := fs.Bool("status", false, "only count secrets per key ID")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *batch <= 0 {
+		fs.Usage()
+		return errors.New("-batch must be positive")
+	}
+
+	pool, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	webhooks := service.NewWebhookService(postgres.NewWebhookRepository(pool))
+
+	var p service.ReencryptProgress
+	if *status {
+		p, err = webhooks.SecretKeyStatus(ctx)
+	} else {
+		// Interrupting is safe: rewritten rows are marked with the current
+		// key, so running again carries on with the rest
+		p, err = webhooks.ReencryptSecrets(ctx, *batch, func(p service.ReencryptProgress) {
+			fmt.Fprintf(os.Stderr, "reencrypted %d webhook secrets, %d failed\n", p.Reencrypted, p.Failed)
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+
+	if !p.Done() {
+		return errors.New("some webhook secrets are not under the current key")
+	}
+	return nil
+}
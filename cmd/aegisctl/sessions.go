@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	userv1 "github.com/mvaleed/aegis/api/proto/user/v1"
+)
+
+func runRevokeSessions(ctx context.Context, args []string) error {
+	fs := newFlagSet("revoke-sessions", "")
+	userID := fs.String("user", "", "ID of the user whose sessions to revoke (required)")
+	addr := fs.String("addr", envOr("AEGIS_GRPC_ADDR", "localhost:9090"), "gRPC API address, or $AEGIS_GRPC_ADDR")
+	useTLS := fs.Bool("tls", false, "connect with TLS")
+	timeout := fs.Duration("timeout", 10*time.Second, "request timeout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *userID == "" {
+		fs.Usage()
+		return errors.New("-user is required")
+	}
+
+	// The token is read from the environment only, to keep it out of the
+	// shell history
+	token := os.Getenv("AEGIS_TOKEN")
+	if token == "" {
+		return errors.New("AEGIS_TOKEN must hold an access token with users:admin")
+	}
+
+	creds := insecure.NewCredentials()
+	if *useTLS {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+	conn, err := grpc.NewClient(*addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+
+	client := userv1.NewAuthServiceClient(conn)
+	if _, err := client.LogoutAll(ctx, &userv1.LogoutAllRequest{UserId: *userID}); err != nil {
+		return err
+	}
+
+	fmt.Printf("revoked sessions of %s\n", *userID)
+	return nil
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
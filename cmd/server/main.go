@@ -2,58 +2,125 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"flag"
 	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 
 	"github.com/mvaleed/aegis/internal/auth"
+	"github.com/mvaleed/aegis/internal/authz"
+	"github.com/mvaleed/aegis/internal/cache"
+	"github.com/mvaleed/aegis/internal/certs"
+	"github.com/mvaleed/aegis/internal/clock"
 	"github.com/mvaleed/aegis/internal/config"
+	"github.com/mvaleed/aegis/internal/disposable"
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/encryption"
 	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/i18n"
+	"github.com/mvaleed/aegis/internal/jobs"
+	"github.com/mvaleed/aegis/internal/ldap"
+	"github.com/mvaleed/aegis/internal/logging"
+	"github.com/mvaleed/aegis/internal/notify"
+	"github.com/mvaleed/aegis/internal/readmodel"
+	"github.com/mvaleed/aegis/internal/risk"
+	"github.com/mvaleed/aegis/internal/schema"
+	"github.com/mvaleed/aegis/internal/secrets"
 	"github.com/mvaleed/aegis/internal/service"
 	"github.com/mvaleed/aegis/internal/storage/postgres"
+	"github.com/mvaleed/aegis/internal/tracing"
+	"github.com/mvaleed/aegis/internal/transport/graphql"
 	grpcTransport "github.com/mvaleed/aegis/internal/transport/grpc"
 	httpTransport "github.com/mvaleed/aegis/internal/transport/http"
+	"github.com/mvaleed/aegis/internal/transport/scim"
+	"github.com/mvaleed/aegis/internal/webhook"
+	"github.com/mvaleed/aegis/migrations"
 )
 
 func main() {
+	selfTest := flag.Bool("selftest", false, "check configuration, database, schema, signing key, broker and seed data, print a JSON report and exit")
+	flag.Parse()
+
 	// Load configuration
 	cfg := config.Load()
+	if *selfTest {
+		os.Exit(runSelfTest(cfg))
+	}
+	if err := cfg.Validate(); err != nil {
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
 
 	// Setup structured logging
-	logLevel := slog.LevelInfo
-	if cfg.Environment == "dev" {
-		logLevel = slog.LevelDebug
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(parseLogLevel(cfg))
+	logger, err := newLogger(cfg, logLevel)
+	if err != nil {
+		slog.Error("invalid logging configuration", "error", err)
+		os.Exit(1)
 	}
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: logLevel,
-	}))
 	slog.SetDefault(logger)
 
-	logger.Debug(fmt.Sprintf("Config: %v", cfg))
+	logger.Debug("config loaded", slog.String("environment", cfg.Environment))
+
+	// Selected settings can be changed without a restart by editing
+	// CONFIG_FILE; changes are picked up on SIGHUP or by the file watcher
+	live := config.NewLive(cfg)
+	live.OnReload(func(cfg *config.Config) {
+		logLevel.Set(parseLogLevel(cfg))
+	})
 
 	// Run the application
-	if err := run(cfg, logger); err != nil {
+	if err := run(live, logger); err != nil {
 		logger.Error("application error", "error", err)
 		os.Exit(1)
 	}
 }
 
-func run(cfg *config.Config, logger *slog.Logger) error {
+func run(live *config.Live, logger *slog.Logger) error {
+	cfg := live.Load()
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	secretStore, err := cfg.NewSecretStore(ctx)
+	if err != nil {
+		return fmt.Errorf("secrets: %w", err)
+	}
+
+	jwtSecret := secretStore.Get(secrets.JWTSecretKey)
+	if jwtSecret == "" {
+		if !cfg.IsDevelopment() {
+			return fmt.Errorf("JWT_SECRET_KEY is required outside development")
+		}
+		jwtSecret = rand.Text()
+		logger.Warn("no JWT secret configured, using a random one; tokens won't survive a restart")
+	}
+
+	peppers := newKeyRing(secrets.PasswordPepper, secrets.PreviousPeppers, auth.SetPeppers)
+	peppers.apply(secretStore)
+	columnKeys := newKeyRing(secrets.ColumnEncryptionKey, secrets.PreviousColumnKeys, encryption.SetKeys)
+	columnKeys.apply(secretStore)
+
 	logger.Info("connecting to database")
-	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	pool, err := newPool(ctx, cfg, secretStore)
 	if err != nil {
-		return fmt.Errorf("connect to database: %w", err)
+		return err
 	}
 	defer pool.Close()
 
@@ -67,58 +134,405 @@ func run(cfg *config.Config, logger *slog.Logger) error {
 	roleRepo := postgres.NewRoleRepository(pool)
 	permissionRepo := postgres.NewPermissionRepository(pool)
 	tokenRepo := postgres.NewTokenRepository(pool)
+	historyRepo := postgres.NewHistoryRepository(pool)
+	webhookRepo := postgres.NewWebhookRepository(pool)
+	directoryRepo := postgres.NewUserDirectoryRepository(pool)
+	orgRepo := postgres.NewOrganizationRepository(pool)
+	groupRepo := postgres.NewGroupRepository(pool)
+	profileRepo := postgres.NewProfileRepository(pool)
+	apiKeyRepo := postgres.NewAPIKeyRepository(pool)
+	consentRepo := postgres.NewConsentRepository(pool)
+	invitationRepo := postgres.NewInvitationRepository(pool)
+	actionTokenRepo := postgres.NewActionTokenRepository(pool)
+	idempotencyRepo := postgres.NewIdempotencyRepository(pool)
+	impersonationRepo := postgres.NewImpersonationRepository(pool)
+	attributeRepo := postgres.NewAttributeDefinitionRepository(pool)
+	deviceRepo := postgres.NewDeviceRepository(pool)
+	loginAttemptRepo := postgres.NewLoginAttemptRepository(pool)
+	passwordHistoryRepo := postgres.NewPasswordHistoryRepository(pool)
+	usernameHistoryRepo := postgres.NewUsernameHistoryRepository(pool)
+	resourceGrantRepo := postgres.NewResourceGrantRepository(pool)
+	accessTokenRepo := postgres.NewAccessTokenRepository(pool)
+
+	// Everything time-dependent reads from one clock; tests swap in a
+	// clock.Fake
+	clk := clock.System
+	domain.SetClock(clk)
+
+	idGenerator, err := domain.ParseIDGenerator(cfg.IDGenerator)
+	if err != nil {
+		return err
+	}
+	domain.SetIDGenerator(idGenerator)
+
+	opaqueStore, closeOpaqueStore, err := newOpaqueStore(cfg, accessTokenRepo)
+	if err != nil {
+		return fmt.Errorf("access token store: %w", err)
+	}
+	defer closeOpaqueStore()
 
 	jwtConfig := auth.JWTConfig{
-		SecretKey:       cfg.JWTSecretKey,
+		SecretKey:       jwtSecret,
 		AccessTokenTTL:  cfg.AccessTokenTTL,
 		RefreshTokenTTL: cfg.RefreshTokenTTL,
 		Issuer:          "mvaleed",
-		Audience:        []string{},
+		Audience:        cfg.JWTAudience,
+		Leeway:          cfg.JWTClockSkew,
+		MinimalClaims:   cfg.JWTMinimalClaims,
+		OmitPermissions: cfg.JWTOmitPermissions,
+		Clock:           clk,
+
+		OpaqueStore:        opaqueStore,
+		OpaqueEnvironments: cfg.OpaqueTokenEnvironments(),
 	}
 	jwtManager := auth.NewJWTManager(
 		jwtConfig,
 	)
 
+	live.OnReload(func(cfg *config.Config) {
+		jwtManager.SetTTLs(cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	})
+
+	secretStore.OnChange(func(s *secrets.Store) {
+		if key := s.Get(secrets.JWTSecretKey); key != "" {
+			jwtManager.SetSecretKey(key)
+		}
+		peppers.apply(s)
+		columnKeys.apply(s)
+		logger.Info("secrets reloaded")
+	})
+
 	// Initialize event publisher
-	var publisher event.Publisher
-	if cfg.IsDevelopment() {
-		publisher = event.NewLoggingPublisher(logger)
-	} else {
-		// TODO: Real message broker
-		publisher = event.NewLoggingPublisher(logger)
+	broker, err := newBroker(cfg, logger)
+	if err != nil {
+		return fmt.Errorf("event broker: %w", err)
 	}
+
+	// The broker is only reached from a background loop, so an outage
+	// delays events instead of failing or losing them
+	brokerQueue, err := event.NewResilient(broker, event.ResilientConfig{
+		BufferSize:       cfg.EventBufferSize,
+		SpoolDir:         cfg.EventSpoolDir,
+		SpoolSize:        cfg.EventSpoolSize,
+		Timeout:          cfg.EventPublishTimeout,
+		BreakerThreshold: cfg.EventBreakerThreshold,
+		BreakerCooldown:  cfg.EventBreakerCooldown,
+	}, logger)
+	if err != nil {
+		return fmt.Errorf("event spool: %w", err)
+	}
+
+	// As events are published the user directory read model is refreshed
+	// and webhook deliveries are queued; the bus then fans events out to
+	// in-process subscribers (gRPC streams, SSE dashboards) in addition to
+	// the broker.
+	projector := readmodel.NewProjector(event.NewProductionOnly(brokerQueue), directoryRepo, logger)
+	publisher := event.NewBus(webhook.NewPublisher(projector, webhookRepo, logger))
 	defer publisher.Close()
 
-	userService := service.NewUserService(userRepo, roleRepo, publisher)
-	authService := service.NewAuthService(userRepo, roleRepo, tokenRepo, jwtManager, publisher)
-	rbacService := service.NewRBACService(userRepo, roleRepo, permissionRepo, publisher)
+	if cfg.TracingEnabled {
+		shutdownTracing, err := newTracing(ctx, cfg)
+		if err != nil {
+			return fmt.Errorf("tracing: %w", err)
+		}
+		defer func() {
+			if err := shutdownTracing(context.Background()); err != nil {
+				logger.Error("tracing shutdown failed", "error", err)
+			}
+		}()
+	}
+
+	riskEngine, err := newRiskEngine(cfg)
+	if err != nil {
+		return fmt.Errorf("risk engine: %w", err)
+	}
+
+	// In development every notification is also captured for /dev/outbox
+	var outbox *notify.Outbox
+	if cfg.IsDevelopment() {
+		outbox = notify.NewOutbox(cfg.DevOutboxSize)
+	}
+
+	notifications, err := newNotificationQueue(cfg, logger, outbox)
+	if err != nil {
+		return fmt.Errorf("notifications: %w", err)
+	}
+	notificationPreferenceService := service.NewNotificationPreferenceService(postgres.NewNotificationPreferenceRepository(pool), postgres.NewTransactor(pool))
+	dispatcher := notify.NewFilter(notifications, notificationPreferenceService)
+
+	usernamePolicy, err := newUsernamePolicy(cfg)
+	if err != nil {
+		return fmt.Errorf("username policy: %w", err)
+	}
+	domain.SetUsernamePolicy(usernamePolicy)
+
+	disposableDomains := disposable.NewDomains()
+	emailScreener, err := newEmailScreener(cfg, disposableDomains)
+	if err != nil {
+		return fmt.Errorf("disposable email policy: %w", err)
+	}
+
+	usernameSuggester := service.NewUsernameSuggester(userRepo)
+	passwordHistory := service.NewPasswordHistory(passwordHistoryRepo, cfg.PasswordHistorySize)
+	userService := service.NewUserService(userRepo, roleRepo, historyRepo, directoryRepo, publisher, riskEngine, usernameSuggester, emailScreener, passwordHistory, usernameHistoryRepo, service.UsernameChangePolicy{
+		Cooldown:    cfg.UsernameChangeCooldown,
+		Reservation: cfg.UsernameReservationPeriod,
+	})
+	actionTokenService := service.NewActionTokenService(actionTokenRepo, postgres.NewTransactor(pool))
+	deviceService := service.NewDeviceService(deviceRepo, actionTokenService, publisher, dispatcher, cfg.LoginDeviceConfirmation, cfg.LoginDeviceConfirmationTTL)
+	live.OnReload(func(cfg *config.Config) {
+		deviceService.SetRequireConfirmation(cfg.LoginDeviceConfirmation)
+	})
+	profileService := service.NewProfileService(profileRepo, userRepo, roleRepo, publisher)
+	authService := service.NewAuthService(userRepo, roleRepo, tokenRepo, orgRepo, groupRepo, profileRepo, impersonationRepo, loginAttemptRepo, jwtManager, publisher, riskEngine, deviceService, cfg.ImpersonationTTL)
+	sessionPolicy := func(cfg *config.Config) service.SessionPolicy {
+		return service.SessionPolicy{
+			Sliding:         cfg.RefreshTokenSliding,
+			MaxLifetime:     cfg.RefreshTokenMaxLifetime,
+			ShortTTL:        cfg.RefreshTokenShortTTL,
+			MaxSessions:     cfg.MaxSessionsPerUser,
+			RejectOverLimit: cfg.SessionLimitPolicy == "reject",
+		}
+	}
+	authService.SetSessionPolicy(sessionPolicy(cfg))
+	authService.SetReactivateOnLogin(cfg.ReactivateOnLogin)
+	live.OnReload(func(cfg *config.Config) {
+		authService.SetSessionPolicy(sessionPolicy(cfg))
+		authService.SetReactivateOnLogin(cfg.ReactivateOnLogin)
+	})
+	if len(cfg.JWTAttributeClaims) > 0 {
+		attributeClaims, err := service.ParseAttributeClaims(cfg.JWTAttributeClaims)
+		if err != nil {
+			return err
+		}
+		authService.AddClaimsEnricher(attributeClaims)
+	}
+	rbacService := service.NewRBACService(userRepo, roleRepo, permissionRepo, orgRepo, groupRepo, resourceGrantRepo, publisher)
+	if cfg.SeedRBAC {
+		if err := seedRBAC(ctx, rbacService, cfg.SandboxEnabled, logger); err != nil {
+			return fmt.Errorf("seed roles and permissions: %w", err)
+		}
+	}
+
+	// Logins are checked against the directory first, its users
+	// provisioned as they sign in and kept linked to their accounts
+	identityRepo := postgres.NewFederatedIdentityRepository(pool)
+	var ldapService *service.LDAPService
+	if cfg.LDAPEnabled {
+		directory := newLDAPDirectory(cfg, secretStore)
+		ldapService = service.NewLDAPService(directory, postgres.NewLDAPMappingRepository(pool), userRepo, roleRepo, identityRepo, rbacService, usernameSuggester, publisher, domain.UserType(cfg.LDAPUserType), cfg.LDAPEmailDomains)
+		authService.UseLDAP(ldapService)
+	}
+
+	// Users sign in through the SAML identity providers configured over
+	// the API, provisioned as they do
+	var samlService *service.SAMLService
+	if cfg.SAMLEnabled {
+		samlService = service.NewSAMLService(postgres.NewSAMLProviderRepository(pool), userRepo, roleRepo, identityRepo, rbacService, authService, usernameSuggester, publisher, domain.UserType(cfg.SAMLUserType), cfg.PublicURL+"/api/v1/auth/saml", cfg.SAMLClockSkew)
+	}
+	// CLIs sign in by the device authorization grant
+	deviceVerificationURL := cfg.DeviceVerificationURL
+	if deviceVerificationURL == "" {
+		deviceVerificationURL = cfg.PublicURL + "/device"
+	}
+	deviceAuthService := service.NewDeviceAuthService(postgres.NewDeviceAuthorizationRepository(pool), authService, deviceVerificationURL, cfg.DeviceCodeTTL, cfg.DevicePollInterval)
+	oauthClientService := service.NewOAuthClientService(postgres.NewOAuthClientRepository(pool), roleRepo, jwtManager)
+	accountDeletionService := service.NewAccountDeletionService(postgres.NewAccountDeletionRepository(pool), userRepo, userService, authService, publisher, cfg.AccountDeletionGracePeriod)
+	tokenExchangeService := service.NewTokenExchangeService(postgres.NewTokenExchangeRepository(pool), oauthClientService, authService, jwtManager, publisher, cfg.TokenExchangeTTL, cfg.TokenExchangeAudiences)
+	orgService := service.NewOrganizationService(orgRepo, userRepo, publisher)
+	groupService := service.NewGroupService(groupRepo, userRepo, roleRepo, publisher)
+	invitationService := service.NewInvitationService(invitationRepo, actionTokenService, userRepo, roleRepo, publisher, dispatcher, usernameSuggester, passwordHistory, cfg.InvitationTTL)
+	webhookService := service.NewWebhookService(webhookRepo)
+	webhookService.UseSender(webhook.NewSender(cfg.WebhookTimeout, cfg.WebhookAllowPrivateNetworks))
+	portalService := service.NewPortalService(apiKeyRepo, userRepo, webhookRepo, webhookService)
+	consentService := service.NewConsentService(consentRepo, userRepo, profileRepo, publisher)
+	emailVerificationService := service.NewEmailVerificationService(userRepo, actionTokenService, publisher, dispatcher, emailScreener, cfg.EmailVerificationTTL)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, cfg.IdempotencyKeyTTL)
+	availabilityService := service.NewAvailabilityService(userRepo, usernameSuggester, emailScreener, cfg.AvailabilityMinLatency)
+
+	// /readyz compares the schema with the migrations built into the binary
+	knownMigrations, err := schema.Load(migrations.FS)
+	if err != nil {
+		return fmt.Errorf("loading migrations: %w", err)
+	}
+	schemaService := service.NewSchemaService(postgres.NewSchemaRepository(pool), knownMigrations)
+	attributeService := service.NewAttributeService(attributeRepo, userRepo, publisher)
+	policyService := service.NewPolicyService(postgres.NewDatasetRepository(pool))
+	supportService := service.NewSupportService(postgres.NewSupportLookupRepository(pool))
+	orgAdminService := service.NewOrgAdminService(orgRepo, userRepo, roleRepo, orgService, userService, invitationService, authService)
+
+	// Permission checks are also evaluated under a candidate role model,
+	// without enforcing it, when one is configured
+	var shadow *authz.Shadow
+	if cfg.AuthzShadowPolicy != "" {
+		policy, err := authz.LoadPolicy(cfg.AuthzShadowPolicy)
+		if err != nil {
+			return fmt.Errorf("authorization shadow policy: %w", err)
+		}
+		shadow = authz.NewShadow(policy, roleRepo, cfg.AuthzShadowConcurrency, logger)
+		logger.Info("shadowing authorization decisions", slog.String("policy", policy.Name))
+	}
+
+	// Hot reads are cached when CACHE_BACKEND is set, and invalidated as
+	// the events changing them are published
+	responseCache, closeCache, err := newResponseCache(cfg)
+	if err != nil {
+		return fmt.Errorf("response cache: %w", err)
+	}
+	defer closeCache()
+	if responseCache != nil {
+		go responseCache.Watch(ctx, publisher, httpTransport.CacheInvalidations, logger)
+		if cfg.SandboxEnabled {
+			go responseCache.Watch(domain.WithEnvironment(ctx, domain.EnvironmentSandbox), publisher, httpTransport.CacheInvalidations, logger)
+		}
+	}
+
+	// Cleanup jobs run on whichever replica holds the job leader lock
+	scheduler := jobs.NewScheduler(postgres.NewAdvisoryElector(pool, 0), cfg.JobsLeaderRetryInterval, logger)
+	jobs.AddCleanup(scheduler, jobs.CleanupConfig{
+		Interval:              cfg.CleanupInterval,
+		LoginHistoryRetention: cfg.LoginHistoryRetention,
+	}, authService, actionTokenService, idempotencyService, deviceAuthService)
+	if opaqueStore != nil && cfg.AccessTokenStore == "postgres" {
+		jobs.AddAccessTokenCleanup(scheduler, cfg.CleanupInterval, accessTokenRepo)
+	}
+	jobs.AddRoleExpiry(scheduler, cfg.RoleExpiryInterval, rbacService)
+	jobs.AddAccountDeletion(scheduler, cfg.CleanupInterval, accountDeletionService)
+	if cfg.ReencryptInterval > 0 {
+		jobs.AddReencryption(scheduler, cfg.ReencryptInterval, cfg.ReencryptBatchSize, webhookService)
+	}
+
+	// /readyz fails while the event pipeline is backed up past these
+	pipelineService := service.NewPipelineService(brokerQueue, webhookRepo, service.PipelineThresholds{
+		MaxEventBacklog: cfg.EventMaxBacklog,
+		MaxEventAge:     cfg.EventMaxAge,
+		MaxWebhookDue:   int64(cfg.WebhookMaxDue),
+		MaxWebhookDelay: cfg.WebhookMaxDelay,
+	})
+
+	messages := i18n.NewCatalog()
+	if cfg.I18NBundlesDir != "" {
+		if err := messages.LoadDir(os.DirFS(cfg.I18NBundlesDir)); err != nil {
+			return fmt.Errorf("i18n bundles: %w", err)
+		}
+	}
 
 	errChan := make(chan error, 2)
 
 	httpServer := httpTransport.NewServer(
-		cfg,
+		live,
 		userService,
 		authService,
 		rbacService,
+		webhookService,
+		orgService,
+		groupService,
+		invitationService,
+		idempotencyService,
+		availabilityService,
+		attributeService,
+		deviceService,
+		profileService,
+		portalService,
+		consentService,
+		emailVerificationService,
+		schemaService,
+		policyService,
+		supportService,
+		orgAdminService,
+		ldapService,
+		samlService,
+		deviceAuthService,
+		oauthClientService,
+		pipelineService,
+		tokenExchangeService,
+		accountDeletionService,
+		notificationPreferenceService,
+		shadow,
+		responseCache,
+		outbox,
+		publisher,
+		brokerQueue,
+		scheduler,
+		messages,
 		jwtManager,
 		logger,
 	)
-	go func() {
-		addr := fmt.Sprintf(":%d", cfg.HTTPPort)
-		logger.Info("starting HTTP server", "addr", addr)
-		if err := httpServer.ListenAndServe(addr); err != nil && err != http.ErrServerClosed {
-			errChan <- fmt.Errorf("HTTP server: %w", err)
+	httpCerts, httpTLS, err := newServerTLS(cfg.HTTPTLSCertFile, cfg.HTTPTLSKeyFile, cfg.HTTPTLSClientCAFile, cfg.HTTPTLSClientAuth, "h2", "http/1.1")
+	if err != nil {
+		return fmt.Errorf("HTTP TLS: %w", err)
+	}
+	grpcCerts, grpcTLS, err := newServerTLS(cfg.GRPCTLSCertFile, cfg.GRPCTLSKeyFile, cfg.GRPCTLSClientCAFile, cfg.GRPCTLSClientAuth, "h2")
+	if err != nil {
+		return fmt.Errorf("gRPC TLS: %w", err)
+	}
+	var gatewayCerts *certs.Reloader
+	if cfg.GatewayEnabled && cfg.GRPCTLSGatewayCertFile != "" {
+		gatewayCerts, err = certs.NewReloader(cfg.GRPCTLSGatewayCertFile, cfg.GRPCTLSGatewayKeyFile, "")
+		if err != nil {
+			return fmt.Errorf("gateway TLS: %w", err)
 		}
-	}()
+	}
+	var reloaders []*certs.Reloader
+	for _, r := range []*certs.Reloader{httpCerts, grpcCerts, gatewayCerts} {
+		if r != nil {
+			reloaders = append(reloaders, r)
+		}
+	}
 
-	// Start gRPC server
+	// The gRPC server, served below; the gateway connects to it in process
 	grpcServer := grpcTransport.NewServer(
 		userService,
 		authService,
 		rbacService,
+		orgService,
+		groupService,
+		idempotencyService,
+		attributeService,
+		consentService,
+		shadow,
+		publisher,
 		jwtManager,
+		cfg.SandboxEnabled,
+		grpcTLS,
 		logger,
 	)
+
+	if cfg.GatewayEnabled {
+		// The gateway trusts only the gRPC server's certificate, and
+		// presents its own client certificate should it ask for one
+		gatewayCreds := insecure.NewCredentials()
+		if grpcCerts != nil {
+			gatewayCreds = credentials.NewTLS(grpcCerts.ClientConfig(gatewayCerts))
+		}
+		gateway, err := grpcTransport.NewGateway(ctx, grpcServer, gatewayCreds)
+		if err != nil {
+			return fmt.Errorf("gRPC gateway: %w", err)
+		}
+		httpServer.Mount("/v1", gateway)
+	}
+	httpServer.Mount("/grpc/service-config", grpcTransport.ServiceConfigHandler())
+	if cfg.GraphQLEnabled {
+		resolver := graphql.NewResolver(userService, rbacService, authService)
+		httpServer.MountGraphQL(graphql.NewHandler(resolver, logger))
+	}
+	if cfg.SCIMEnabled {
+		if secretStore.Get(secrets.SCIMToken) == "" {
+			logger.Warn("SCIM is enabled without a SCIM_TOKEN; provisioning requests are refused until one is set")
+		}
+		scimService := service.NewSCIMService(postgres.NewSCIMRepository(pool), userRepo, roleRepo, groupRepo, userService, groupService, authService, usernameSuggester, publisher, postgres.NewTransactor(pool))
+		scimToken := func() string { return secretStore.Get(secrets.SCIMToken) }
+		httpServer.Mount("/scim/v2", scim.NewHandler(scimService, scimToken, cfg.PublicURL+"/scim/v2", logger))
+	}
+
+	go func() {
+		addr := fmt.Sprintf(":%d", cfg.HTTPPort)
+		logger.Info("starting HTTP server", "addr", addr, "tls", httpTLS != nil)
+		if err := httpServer.ListenAndServe(addr, httpTLS); err != nil && err != http.ErrServerClosed {
+			errChan <- fmt.Errorf("HTTP server: %w", err)
+		}
+	}()
+
 	go func() {
 		addr := fmt.Sprintf(":%d", cfg.GRPCPort)
 		listener, err := net.Listen("tcp", addr)
@@ -126,37 +540,103 @@ func run(cfg *config.Config, logger *slog.Logger) error {
 			errChan <- fmt.Errorf("gRPC listen: %w", err)
 			return
 		}
-		logger.Info("starting gRPC server", "addr", addr)
+		logger.Info("starting gRPC server", "addr", addr, "tls", grpcTLS != nil)
 		if err := grpcServer.Serve(listener); err != nil && err != grpc.ErrServerStopped {
 			errChan <- fmt.Errorf("gRPC server: %w", err)
 		}
 	}()
 
-	// Token cleanup routine
-	go func() {
-		ticker := time.NewTicker(1 * time.Hour)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				if _, err := authService.CleanupExpiredTokens(ctx); err != nil {
-					logger.Error("token cleanup failed", "error", err)
-				}
+	go scheduler.Run(ctx)
+	go projector.Reconcile(ctx, cfg.DirectoryReconcileInterval)
+	go notifications.Run(ctx)
+	go brokerQueue.Run(ctx)
+
+	if len(cfg.RabbitMQInboundExchanges) > 0 {
+		subscriber, err := event.NewRabbitMQSubscriber(event.RabbitMQSubscriberConfig{
+			URL:       cfg.RabbitMQURL,
+			Queue:     cfg.RabbitMQInboundQueue,
+			Exchanges: cfg.RabbitMQInboundExchanges,
+			Prefetch:  cfg.EventConsumerConcurrency,
+		}, logger)
+		if err != nil {
+			return fmt.Errorf("event subscriber: %w", err)
+		}
+		defer subscriber.Close()
+
+		consumer := event.NewConsumer(subscriber, event.ConsumerConfig{
+			Concurrency: cfg.EventConsumerConcurrency,
+			MaxAttempts: cfg.EventConsumerMaxAttempts,
+		}, logger)
+		service.NewInboundHandlers(userService).Register(consumer)
+		go func() {
+			if err := consumer.Run(ctx); err != nil {
+				logger.Error("event consumer stopped", "error", err)
 			}
+		}()
+	}
+
+	if cfg.DisposableEmailListURL != "" && cfg.DisposableEmailRefreshInterval > 0 {
+		go disposableDomains.RunRefresh(ctx, cfg.DisposableEmailListURL, cfg.DisposableEmailRefreshInterval, logger)
+	}
+
+	if cfg.SecretsProvider != "env" && cfg.SecretsRefreshInterval > 0 {
+		go secretStore.RunRefresh(ctx, cfg.SecretsRefreshInterval, logger)
+	}
+
+	if cfg.WebhookWorkerEnabled {
+		worker := webhook.NewWorker(webhookRepo, webhook.WorkerConfig{
+			PollInterval: cfg.WebhookPollInterval,
+			Timeout:      cfg.WebhookTimeout,
+			MaxAttempts:  cfg.WebhookMaxAttempts,
+			Concurrency:  cfg.WebhookConcurrency,
+
+			AllowPrivateNetworks: cfg.WebhookAllowPrivateNetworks,
+		}, logger)
+		go worker.Run(ctx)
+
+		// Sandbox webhooks are queued in the sandbox schema and need a
+		// worker of their own
+		if cfg.SandboxEnabled {
+			go worker.Run(domain.WithEnvironment(ctx, domain.EnvironmentSandbox))
 		}
-	}()
+	}
+
+	if cfg.ConfigFile != "" && cfg.ConfigWatchInterval > 0 {
+		go live.WatchFile(ctx, cfg.ConfigWatchInterval, logger)
+	}
+
+	if cfg.TLSReloadInterval > 0 {
+		for _, r := range reloaders {
+			go r.Watch(ctx, cfg.TLSReloadInterval, logger)
+		}
+	}
 
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
-	select {
-	case sig := <-sigChan:
-		logger.Info("received shutdown signal", "signal", sig)
-	case err := <-errChan:
-		logger.Error("server error", "error", err)
-		return err
+wait:
+	for {
+		select {
+		case sig := <-sigChan:
+			if sig == syscall.SIGHUP {
+				if err := live.Reload(); err != nil {
+					logger.Error("config reload failed", "error", err)
+				} else {
+					logger.Info("config reloaded")
+				}
+				for _, r := range reloaders {
+					if err := r.Reload(); err != nil {
+						logger.Error("certificate reload failed", "error", err)
+					}
+				}
+				continue
+			}
+			logger.Info("received shutdown signal", "signal", sig)
+			break wait
+		case err := <-errChan:
+			logger.Error("server error", "error", err)
+			return err
+		}
 	}
 
 	logger.Info("initiating graceful shutdown")
@@ -175,3 +655,341 @@ func run(cfg *config.Config, logger *slog.Logger) error {
 	logger.Info("shutdown complete")
 	return nil
 }
+
+// newPool opens the database pool. Connections aren't made until first
+// use.
+func newPool(ctx context.Context, cfg *config.Config, secretStore *secrets.Store) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(cfg.DatabaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse database URL: %w", err)
+	}
+	// New connections pick up a rotated database password
+	poolConfig.BeforeConnect = func(_ context.Context, conn *pgx.ConnConfig) error {
+		if password := secretStore.Get(secrets.DatabasePassword); password != "" {
+			conn.Password = password
+		}
+		return nil
+	}
+	// Sandbox requests only see the sandbox schema
+	postgres.IsolateEnvironments(poolConfig)
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
+	return pool, nil
+}
+
+// parseLogLevel returns LOG_LEVEL, defaulting to debug in dev and info
+// elsewhere. Validate has already rejected unknown levels.
+func parseLogLevel(cfg *config.Config) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err == nil {
+		return level
+	}
+	if cfg.Environment == "dev" {
+		return slog.LevelDebug
+	}
+	return slog.LevelInfo
+}
+
+// newLogger builds the root logger. Everything logs through it, so its
+// redaction applies to every transport and service.
+func newLogger(cfg *config.Config, logLevel slog.Leveler) (*slog.Logger, error) {
+	redaction := logging.LevelFull
+	if cfg.IsDevelopment() {
+		redaction = logging.LevelPartial
+	}
+	if cfg.LogRedaction != "" {
+		var err error
+		if redaction, err = logging.ParseLevel(cfg.LogRedaction); err != nil {
+			return nil, err
+		}
+	}
+
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: logLevel,
+	})
+	return slog.New(logging.NewHandler(handler, redaction)), nil
+}
+
+// seedRBAC creates the default roles and permissions that are missing,
+// in the sandbox schema too when it's in use.
+func seedRBAC(ctx context.Context, rbac *service.RBACService, sandbox bool, logger *slog.Logger) error {
+	envs := []domain.Environment{domain.EnvironmentProduction}
+	if sandbox {
+		envs = append(envs, domain.EnvironmentSandbox)
+	}
+
+	for _, env := range envs {
+		result, err := rbac.Seed(domain.WithEnvironment(ctx, env), service.DefaultRBACSeed)
+		if err != nil {
+			return err
+		}
+		if result.Changed() {
+			logger.Info("seeded roles and permissions",
+				slog.String("environment", string(env)),
+				slog.Int("permissions_created", result.PermissionsCreated),
+				slog.Int("roles_created", result.RolesCreated),
+				slog.Int("permissions_granted", result.PermissionsGranted),
+			)
+		}
+	}
+	return nil
+}
+
+// keyRing hands a rotated secret, such as the password peppers or the
+// column encryption keys, to the package that uses it. A key replaced by a
+// refresh stays accepted for the life of the process, so data made with it
+// keeps verifying or decrypting even if the secret manager doesn't list it
+// among the previous keys.
+type keyRing struct {
+	name, previousName string
+	set                func(current string, previous ...string)
+
+	current string
+	retired []string
+}
+
+func newKeyRing(name, previousName string, set func(string, ...string)) *keyRing {
+	return &keyRing{name: name, previousName: previousName, set: set}
+}
+
+func (r *keyRing) apply(s *secrets.Store) {
+	current := s.Get(r.name)
+	if r.current != "" && r.current != current && !slices.Contains(r.retired, r.current) {
+		r.retired = append(r.retired, r.current)
+	}
+	r.current = current
+
+	previous := slices.Clone(r.retired)
+	for _, key := range strings.Split(s.Get(r.previousName), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			previous = append(previous, key)
+		}
+	}
+	r.set(current, previous...)
+}
+
+// newRiskEngine builds the risk engine selected by configuration.
+func newRiskEngine(cfg *config.Config) (risk.Engine, error) {
+	thresholds := risk.Thresholds{
+		Challenge: cfg.RiskChallengeThreshold,
+		Block:     cfg.RiskBlockThreshold,
+	}
+
+	switch cfg.RiskEngine {
+	case "none":
+		return risk.AllowAll{}, nil
+	case "http":
+		if cfg.RiskServiceURL == "" {
+			return nil, fmt.Errorf("RISK_SERVICE_URL is required for the http risk engine")
+		}
+		return risk.NewHTTPEngine(cfg.RiskServiceURL, cfg.RiskServiceTimeout, thresholds, cfg.RiskFailOpen), nil
+	case "rules":
+		blocked, err := risk.NewBlockedNetworks(cfg.RiskBlockedNetworks, 100)
+		if err != nil {
+			return nil, fmt.Errorf("parsing blocked networks: %w", err)
+		}
+		return risk.NewRulesEngine(thresholds,
+			blocked,
+			risk.NewMissingUserAgent(20),
+			risk.NewDisposableEmail(cfg.DisposableEmailRiskScore),
+		), nil
+	default:
+		return nil, fmt.Errorf("unknown risk engine %q", cfg.RiskEngine)
+	}
+}
+
+// newLDAPDirectory returns the directory configured by the LDAP_ settings.
+// The bind password is read from secretStore on each connection.
+func newLDAPDirectory(cfg *config.Config, secretStore *secrets.Store) *ldap.Directory {
+	return ldap.New(ldap.Config{
+		URL:            cfg.LDAPURL,
+		StartTLS:       cfg.LDAPStartTLS,
+		BindDN:         cfg.LDAPBindDN,
+		BindPassword:   func() string { return secretStore.Get(secrets.LDAPBindPassword) },
+		BaseDN:         cfg.LDAPBaseDN,
+		UserFilter:     cfg.LDAPUserFilter,
+		EmailAttribute: cfg.LDAPEmailAttribute,
+		NameAttribute:  cfg.LDAPNameAttribute,
+		GroupAttribute: cfg.LDAPGroupAttribute,
+		Timeout:        cfg.LDAPTimeout,
+	})
+}
+
+func newBroker(cfg *config.Config, logger *slog.Logger) (event.Publisher, error) {
+	switch cfg.EventBroker {
+	case "log":
+		return event.NewLoggingPublisher(logger), nil
+	case "none":
+		return event.NewNoopPublisher(), nil
+	case "rabbitmq":
+		routes, err := event.ParseRoutes(cfg.RabbitMQRoutes)
+		if err != nil {
+			return nil, err
+		}
+		return event.NewRabbitMQPublisher(event.RabbitMQConfig{
+			URL:      cfg.RabbitMQURL,
+			Exchange: cfg.RabbitMQExchange,
+			Routes:   routes,
+		}, logger)
+	default:
+		return nil, fmt.Errorf("unknown event broker %q", cfg.EventBroker)
+	}
+}
+
+// newServerTLS loads a server's certificate, returning it with a TLS
+// configuration serving it, or nils when certFile is empty.
+func newServerTLS(certFile, keyFile, clientCAFile, clientAuth string, nextProtos ...string) (*certs.Reloader, *tls.Config, error) {
+	if certFile == "" {
+		return nil, nil, nil
+	}
+	mode, err := certs.ParseClientAuth(clientAuth)
+	if err != nil {
+		return nil, nil, err
+	}
+	reloader, err := certs.NewReloader(certFile, keyFile, clientCAFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reloader, reloader.ServerConfig(mode, nextProtos...), nil
+}
+
+// newResponseCache builds the cache selected by CACHE_BACKEND, or returns
+// nil for none. The returned func releases it.
+func newResponseCache(cfg *config.Config) (*cache.Cache, func(), error) {
+	switch cfg.CacheBackend {
+	case "memory":
+		return cache.New(cache.NewMemory(cfg.CacheMaxEntries), cfg.CacheTTL), func() {}, nil
+	case "redis":
+		store, err := cache.NewRedis(cfg.RedisURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		return cache.New(store, cfg.CacheTTL), func() { _ = store.Close() }, nil
+	default:
+		return nil, func() {}, nil
+	}
+}
+
+// newOpaqueStore builds the store selected by ACCESS_TOKEN_STORE when some
+// environment has opaque access tokens, or returns nil. The returned func
+// releases it.
+func newOpaqueStore(cfg *config.Config, accessTokens *postgres.AccessTokenRepository) (auth.OpaqueStore, func(), error) {
+	if len(cfg.OpaqueTokenEnvironments()) == 0 {
+		return nil, func() {}, nil
+	}
+	if cfg.AccessTokenStore == "redis" {
+		store, err := cache.NewRedis(cfg.RedisURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		return store.Tokens(), func() { _ = store.Close() }, nil
+	}
+	return accessTokens, func() {}, nil
+}
+
+func newTracing(ctx context.Context, cfg *config.Config) (func(context.Context) error, error) {
+	rules, err := tracing.ParseRules(cfg.TraceSampleRules)
+	if err != nil {
+		return nil, err
+	}
+
+	return tracing.Setup(ctx, tracing.Config{
+		ServiceName:  "aegis",
+		DefaultRate:  cfg.TraceSampleRate,
+		Rules:        rules,
+		TailSampling: cfg.TraceTailSampling,
+		Keep:         []tracing.KeepFunc{tracing.KeepErrors, tracing.KeepAuthFailures},
+	})
+}
+
+func newEmailScreener(cfg *config.Config, domains *disposable.Domains) (*service.EmailScreener, error) {
+	policy := service.DisposableEmailPolicy(cfg.DisposableEmailPolicy)
+
+	switch policy {
+	case service.DisposableEmailAllow, service.DisposableEmailFlag, service.DisposableEmailBlock:
+	default:
+		return nil, fmt.Errorf("unknown disposable email policy %q", cfg.DisposableEmailPolicy)
+	}
+
+	return service.NewEmailScreener(domains, policy), nil
+}
+
+func newUsernamePolicy(cfg *config.Config) (domain.UsernamePolicy, error) {
+	policy := domain.UsernamePolicy{
+		MinLength: cfg.UsernameMinLength,
+		MaxLength: cfg.UsernameMaxLength,
+	}
+
+	switch cfg.UsernamePolicy {
+	case "ascii":
+	case "unicode":
+		policy.Unicode = true
+	default:
+		return policy, fmt.Errorf("unknown username policy %q", cfg.UsernamePolicy)
+	}
+
+	if policy.MinLength < 1 || policy.MaxLength > 50 || policy.MinLength > policy.MaxLength {
+		return policy, fmt.Errorf("username length must be within 1-50, got %d-%d", policy.MinLength, policy.MaxLength)
+	}
+
+	return policy, nil
+}
+
+func newNotificationQueue(cfg *config.Config, logger *slog.Logger, outbox *notify.Outbox) (*notify.Queue, error) {
+	templates, err := notify.NewTemplates(cfg.PublicURL)
+	if err != nil {
+		return nil, err
+	}
+
+	router := notify.Router{}
+
+	switch cfg.NotifyEmailProvider {
+	case "log":
+		router[notify.ChannelEmail] = notify.NewLoggingNotifier(logger)
+	case "smtp":
+		smtpNotifier, err := notify.NewSMTPNotifier(notify.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+		})
+		if err != nil {
+			return nil, err
+		}
+		router[notify.ChannelEmail] = smtpNotifier
+	default:
+		return nil, fmt.Errorf("unknown email provider %q", cfg.NotifyEmailProvider)
+	}
+
+	switch cfg.NotifySMSProvider {
+	case "log":
+		router[notify.ChannelSMS] = notify.NewLoggingNotifier(logger)
+	case "http":
+		smsNotifier, err := notify.NewHTTPSMSNotifier(notify.SMSConfig{
+			URL:    cfg.SMSProviderURL,
+			APIKey: cfg.SMSProviderAPIKey,
+			From:   cfg.SMSFrom,
+		})
+		if err != nil {
+			return nil, err
+		}
+		router[notify.ChannelSMS] = smsNotifier
+	default:
+		return nil, fmt.Errorf("unknown SMS provider %q", cfg.NotifySMSProvider)
+	}
+
+	if outbox != nil {
+		for channel, notifier := range router {
+			router[channel] = outbox.Capture(notifier)
+		}
+	}
+
+	return notify.NewQueue(router, templates, notify.QueueConfig{
+		Size:        cfg.NotifyQueueSize,
+		Workers:     cfg.NotifyWorkers,
+		MaxAttempts: cfg.NotifyMaxAttempts,
+	}, logger), nil
+}
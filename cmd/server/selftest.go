@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/auth"
+	"github.com/mvaleed/aegis/internal/cache"
+	"github.com/mvaleed/aegis/internal/config"
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/schema"
+	"github.com/mvaleed/aegis/internal/secrets"
+	"github.com/mvaleed/aegis/internal/service"
+	"github.com/mvaleed/aegis/internal/storage/postgres"
+	"github.com/mvaleed/aegis/migrations"
+)
+
+// selfTestTimeout bounds the whole self-test, so an unreachable database
+// fails it rather than stalling a CI job or an init container.
+const selfTestTimeout = 30 * time.Second
+
+// selfTestReport is what --selftest prints to stdout.
+type selfTestReport struct {
+	OK     bool            `json:"ok"`
+	Checks []selfTestCheck `json:"checks"`
+}
+
+type selfTestCheck struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"` // pass, fail or skip
+	Detail     string `json:"detail,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// skipped is returned by a check that couldn't run, giving the reason;
+// usually a check it depends on failed.
+type skipped string
+
+func (s skipped) Error() string { return string(s) }
+
+// errRollback rolls back the seed check's transaction.
+var errRollback = errors.New("rollback")
+
+// runSelfTest checks that the server could start with cfg and prints the
+// report. It returns the exit code: 1 if any check failed. Every check
+// runs, in order, those depending on a failed one being skipped.
+func runSelfTest(cfg *config.Config) int {
+	ctx, cancel := context.WithTimeout(context.Background(), selfTestTimeout)
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Anything logged goes to stderr, leaving stdout to the report
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	report := selfTestReport{OK: true}
+	check := func(name string, fn func() (string, error)) bool {
+		start := time.Now()
+		detail, err := fn()
+		c := selfTestCheck{Name: name, Status: "pass", Detail: detail}
+		var skip skipped
+		switch {
+		case errors.As(err, &skip):
+			c.Status, c.Detail = "skip", skip.Error()
+		case err != nil:
+			c.Status, c.Detail = "fail", err.Error()
+			report.OK = false
+		}
+		c.DurationMS = time.Since(start).Milliseconds()
+		report.Checks = append(report.Checks, c)
+		return c.Status == "pass"
+	}
+
+	check("config", func() (string, error) {
+		return "environment " + cfg.Environment, cfg.Validate()
+	})
+
+	var secretStore *secrets.Store
+	check("signing_key", func() (string, error) {
+		store, err := cfg.NewSecretStore(ctx)
+		if err != nil {
+			return "", fmt.Errorf("secrets: %w", err)
+		}
+		secretStore = store
+		return checkSigningKey(cfg, store)
+	})
+
+	var pool *pgxpool.Pool
+	check("database", func() (string, error) {
+		if secretStore == nil {
+			return "", skipped("secrets unavailable")
+		}
+		p, err := newPool(ctx, cfg, secretStore)
+		if err != nil {
+			return "", err
+		}
+		if err := p.Ping(ctx); err != nil {
+			p.Close()
+			return "", fmt.Errorf("ping database: %w", err)
+		}
+		pool = p
+		return "", nil
+	})
+	if pool != nil {
+		defer pool.Close()
+	}
+
+	schemaReady := check("schema", func() (string, error) {
+		if pool == nil {
+			return "", skipped("database unavailable")
+		}
+		return checkSchema(ctx, pool)
+	})
+
+	check("broker", func() (string, error) {
+		if cfg.EventBroker != "rabbitmq" {
+			return "", skipped("EVENT_BROKER is " + cfg.EventBroker + "; nothing to reach")
+		}
+		broker, err := newBroker(cfg, logger)
+		if err != nil {
+			return "", err
+		}
+		defer broker.Close()
+		return "", broker.(*event.RabbitMQPublisher).Ping()
+	})
+
+	check("cache", func() (string, error) {
+		if cfg.CacheBackend != "redis" {
+			return "", skipped("CACHE_BACKEND is " + cfg.CacheBackend + "; nothing to reach")
+		}
+		store, err := cache.NewRedis(cfg.RedisURL)
+		if err != nil {
+			return "", err
+		}
+		defer store.Close()
+		return "", store.Ping(ctx)
+	})
+
+	check("ldap", func() (string, error) {
+		if !cfg.LDAPEnabled {
+			return "", skipped("LDAP_ENABLED is false; nothing to reach")
+		}
+		if secretStore == nil {
+			return "", skipped("secrets unavailable")
+		}
+		return "", newLDAPDirectory(cfg, secretStore).Ping(ctx)
+	})
+
+	check("seed", func() (string, error) {
+		if !schemaReady {
+			return "", skipped("schema not ready")
+		}
+		return checkSeed(ctx, pool, cfg)
+	})
+
+	if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+		return 1
+	}
+	if !report.OK {
+		return 1
+	}
+	return 0
+}
+
+// checkSigningKey signs and validates a token with the JWT secret.
+func checkSigningKey(cfg *config.Config, store *secrets.Store) (string, error) {
+	key := store.Get(secrets.JWTSecretKey)
+	if key == "" {
+		if !cfg.IsDevelopment() {
+			return "", errors.New("JWT_SECRET_KEY is required outside development")
+		}
+		return "none configured; development uses a random key", nil
+	}
+
+	jwt := auth.NewJWTManager(auth.JWTConfig{
+		SecretKey:      key,
+		AccessTokenTTL: time.Minute,
+	})
+	token, _, err := jwt.GenerateAccessToken(context.Background(), auth.TokenPayload{UserID: uuid.New()})
+	if err != nil {
+		return "", fmt.Errorf("signing a token: %w", err)
+	}
+	if _, err := jwt.ValidateAccessToken(context.Background(), token); err != nil {
+		return "", fmt.Errorf("validating a token: %w", err)
+	}
+	return "provider " + cfg.SecretsProvider, nil
+}
+
+// checkSchema compares the schema version with the migrations built into
+// the binary, as /readyz does.
+func checkSchema(ctx context.Context, pool *pgxpool.Pool) (string, error) {
+	known, err := schema.Load(migrations.FS)
+	if err != nil {
+		return "", fmt.Errorf("loading migrations: %w", err)
+	}
+
+	r, err := service.NewSchemaService(postgres.NewSchemaRepository(pool), known).Readiness(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	detail := fmt.Sprintf("version %d, requires %d, latest %d", r.Version, r.Required, r.Latest)
+	if !r.Ready {
+		return "", fmt.Errorf("%s (%s)", r.Reason, detail)
+	}
+	return detail, nil
+}
+
+// checkSeed looks for default roles, permissions and grants that are
+// missing by seeding them in a transaction that's rolled back. They're
+// only required when the server won't seed them itself (RBAC_SEED=false).
+func checkSeed(ctx context.Context, pool *pgxpool.Pool, cfg *config.Config) (string, error) {
+	rbac := service.NewRBACService(
+		postgres.NewUserRepository(pool),
+		postgres.NewRoleRepository(pool),
+		postgres.NewPermissionRepository(pool),
+		postgres.NewOrganizationRepository(pool),
+		postgres.NewGroupRepository(pool),
+		postgres.NewResourceGrantRepository(pool),
+		event.NewNoopPublisher(),
+	)
+
+	envs := []domain.Environment{domain.EnvironmentProduction}
+	if cfg.SandboxEnabled {
+		envs = append(envs, domain.EnvironmentSandbox)
+	}
+
+	var missing service.SyncResult
+	for _, env := range envs {
+		ctx := domain.WithEnvironment(ctx, env)
+		err := postgres.NewTransactor(pool).WithTransaction(ctx, func(ctx context.Context) error {
+			result, err := rbac.Seed(ctx, service.DefaultRBACSeed)
+			if err != nil {
+				return err
+			}
+			missing.PermissionsCreated += result.PermissionsCreated
+			missing.RolesCreated += result.RolesCreated
+			missing.PermissionsGranted += result.PermissionsGranted
+			return errRollback
+		})
+		if err != nil && !errors.Is(err, errRollback) {
+			return "", fmt.Errorf("%s: %w", env, err)
+		}
+	}
+
+	if !missing.Changed() {
+		return "default roles and permissions present", nil
+	}
+
+	detail := fmt.Sprintf("%d permissions, %d roles and %d grants of the defaults missing",
+		missing.PermissionsCreated, missing.RolesCreated, missing.PermissionsGranted)
+	if !cfg.SeedRBAC {
+		return "", errors.New(detail + " and RBAC_SEED is off")
+	}
+	return detail + "; seeded at startup", nil
+}
@@ -1,11 +1,19 @@
 package auth
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/clock"
 )
 
 var (
@@ -17,10 +25,191 @@ var (
 type Claims struct {
 	jwt.RegisteredClaims
 	UserID      uuid.UUID `json:"uid"`
-	Email       string    `json:"email"`
-	Username    string    `json:"username"`
+	Email       string    `json:"email,omitempty"`    // Empty with MinimalClaims
+	Username    string    `json:"username,omitempty"` // Empty with MinimalClaims
 	UserType    string    `json:"user_type"`
 	Permissions []string  `json:"permissions,omitempty"`
+
+	// Locale and Timezone are the user's, as the OpenID Connect locale
+	// and zoneinfo claims; empty when they haven't set them.
+	Locale   string `json:"locale,omitempty"`
+	Timezone string `json:"zoneinfo,omitempty"`
+
+	// Denied lists permissions the subject's roles deny. They override
+	// Permissions, wildcards included.
+	Denied []string `json:"denied,omitempty"`
+
+	// Organizations lists the user's memberships with the permissions
+	// granted by roles scoped to each organization.
+	Organizations []OrganizationClaim `json:"orgs,omitempty"`
+
+	// Groups lists the names of the groups the user belongs to. Permissions
+	// inherited from them are already folded into Permissions.
+	Groups []string `json:"groups,omitempty"`
+
+	// Actor is set on impersonation tokens and identifies the support user
+	// acting as the subject (RFC 8693 act claim).
+	Actor *ActorClaim `json:"act,omitempty"`
+
+	// Profile is set on tokens issued for one of the subject's profiles.
+	// UserType and Permissions are then the profile's.
+	Profile *ProfileClaim `json:"profile,omitempty"`
+
+	// Environment is "sandbox" on tokens for sandbox users; requests made
+	// with them only see sandbox data. Empty is production.
+	Environment string `json:"env,omitempty"`
+
+	// Scope is the space-separated OpenID Connect scope requested at login,
+	// limiting the claims userinfo releases. Empty is unrestricted.
+	Scope string `json:"scope,omitempty"`
+
+	// ClientID is set on tokens an OAuth client got for itself with the
+	// client credentials grant. The subject is then the client, not a
+	// user, and Permissions are those of its roles.
+	ClientID string `json:"client_id,omitempty"`
+
+	// Delegation is set on tokens an OAuth client got for the subject by
+	// token exchange. Permissions are narrowed to those it asked for, and
+	// the audience to the services it calls on the subject's behalf.
+	Delegation *DelegationClaim `json:"dlg,omitempty"`
+
+	// PermissionsOmitted is set on user tokens issued with OmitPermissions:
+	// Permissions, Denied and the organizations' permissions were left out,
+	// and are looked up when the token is validated.
+	PermissionsOmitted bool `json:"perms_omitted,omitempty"`
+
+	// Extra holds the custom claims a deployment adds to the token, at its
+	// top level beside the others. Names the claims above use are ignored.
+	Extra map[string]any `json:"-"`
+}
+
+// standardClaims are the names of the claims Claims has fields for.
+var standardClaims = func() map[string]bool {
+	names := make(map[string]bool)
+	for _, t := range []reflect.Type{reflect.TypeFor[jwt.RegisteredClaims](), reflect.TypeFor[Claims]()} {
+		for i := range t.NumField() {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			if name != "" && name != "-" {
+				names[name] = true
+			}
+		}
+	}
+	return names
+}()
+
+// IsStandardClaim reports whether name is taken by one of the claims aegis
+// sets, so can't be used for a custom one.
+func IsStandardClaim(name string) bool {
+	return standardClaims[name]
+}
+
+// MarshalJSON writes the claims with Extra at the top level.
+func (c Claims) MarshalJSON() ([]byte, error) {
+	type plain Claims
+	data, err := json.Marshal(plain(c))
+	if err != nil || len(c.Extra) == 0 {
+		return data, err
+	}
+
+	merged := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return nil, err
+	}
+	for name, value := range c.Extra {
+		if standardClaims[name] {
+			continue
+		}
+		if merged[name], err = json.Marshal(value); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(merged)
+}
+
+// UnmarshalJSON reads the claims, collecting those it has no field for in
+// Extra.
+func (c *Claims) UnmarshalJSON(data []byte) error {
+	type plain Claims
+	if err := json.Unmarshal(data, (*plain)(c)); err != nil {
+		return err
+	}
+
+	var all map[string]any
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	for name, value := range all {
+		if standardClaims[name] {
+			continue
+		}
+		if c.Extra == nil {
+			c.Extra = make(map[string]any)
+		}
+		c.Extra[name] = value
+	}
+	return nil
+}
+
+// IsClient reports whether the token was issued to an OAuth client rather
+// than a user.
+func (c *Claims) IsClient() bool {
+	return c.ClientID != ""
+}
+
+// IsDelegation reports whether the token was obtained by token exchange.
+func (c *Claims) IsDelegation() bool {
+	return c.Delegation != nil
+}
+
+// DelegationClaim identifies the OAuth client acting on behalf of a
+// token's subject, and the exchange it got the token by.
+type DelegationClaim struct {
+	ClientID   string    `json:"client_id"`
+	ExchangeID uuid.UUID `json:"xid"`
+}
+
+// ProfileClaim identifies the profile a token was issued for.
+type ProfileClaim struct {
+	ID   uuid.UUID `json:"id"`
+	Type string    `json:"type"`
+}
+
+// ActorClaim identifies who is acting on behalf of the token's subject.
+type ActorClaim struct {
+	UserID    uuid.UUID `json:"sub"`
+	Username  string    `json:"username,omitempty"`
+	SessionID uuid.UUID `json:"sid"` // Impersonation session, checked on every request
+}
+
+// IsImpersonation reports whether the token was issued for impersonation.
+func (c *Claims) IsImpersonation() bool {
+	return c.Actor != nil
+}
+
+// OrganizationClaim is a single organization membership in an access token.
+type OrganizationClaim struct {
+	ID          uuid.UUID `json:"id"`
+	Permissions []string  `json:"permissions,omitempty"`
+	Denied      []string  `json:"denied,omitempty"`
+}
+
+// OrganizationIDs returns the IDs of the organizations the subject belongs to.
+func (c *Claims) OrganizationIDs() []uuid.UUID {
+	ids := make([]uuid.UUID, len(c.Organizations))
+	for i, o := range c.Organizations {
+		ids[i] = o.ID
+	}
+	return ids
+}
+
+// Organization returns the membership claim for orgID, if any.
+func (c *Claims) Organization(orgID uuid.UUID) (OrganizationClaim, bool) {
+	for _, o := range c.Organizations {
+		if o.ID == orgID {
+			return o, true
+		}
+	}
+	return OrganizationClaim{}, false
 }
 
 // JWTConfig holds configuration for JWT token generation.
@@ -29,7 +218,33 @@ type JWTConfig struct {
 	AccessTokenTTL  time.Duration
 	RefreshTokenTTL time.Duration
 	Issuer          string
-	Audience        []string
+
+	// Audience is the aud of the tokens issued. Tokens naming none of it
+	// are rejected; when empty, aud isn't checked.
+	Audience []string
+
+	// Leeway tolerates clock drift between the services issuing and
+	// validating tokens when checking exp, nbf and iat.
+	Leeway time.Duration
+
+	// MinimalClaims leaves personal data (email, usernames) out of access
+	// tokens, so the subject ID is all that identifies the user. Services
+	// needing more ask the userinfo endpoint.
+	MinimalClaims bool
+
+	// OmitPermissions leaves the permission lists out of users' access
+	// tokens, which can grow large, marking them PermissionsOmitted.
+	// Profile, client and delegation tokens keep theirs.
+	OmitPermissions bool
+
+	// OpaqueStore, with OpaqueEnvironments naming "production", "sandbox"
+	// or both, has access tokens for users in those environments issued as
+	// opaque references to claims kept in the store, rather than JWTs.
+	OpaqueStore        OpaqueStore
+	OpaqueEnvironments []string
+
+	// Clock issues and validates tokens; nil uses the system clock.
+	Clock clock.Clock
 }
 
 // DefaultJWTConfig returns sensible defaults for JWT configuration.
@@ -39,49 +254,160 @@ func DefaultJWTConfig() JWTConfig {
 		RefreshTokenTTL: 7 * 24 * time.Hour, // 7 days
 		Issuer:          "user-service",
 		Audience:        []string{"user-service"},
+		Leeway:          5 * time.Second,
 	}
 }
 
 type JWTManager struct {
 	config JWTConfig
+	keys   atomic.Pointer[signingKeys]
+	ttls   atomic.Pointer[tokenTTLs]
+
+	skewTolerated atomic.Int64
+	skewRejected  atomic.Int64
+}
+
+// TokenStats counts, since startup, access tokens whose validation came down
+// to clock skew. A steady rate of either points at drifting clocks.
+type TokenStats struct {
+	Leeway        string `json:"leeway"`
+	SkewTolerated int64  `json:"skew_tolerated"` // Accepted only thanks to the leeway
+	SkewRejected  int64  `json:"skew_rejected"`  // Rejected as issued in the future (nbf or iat), beyond the leeway
+}
+
+type tokenTTLs struct {
+	access  time.Duration
+	refresh time.Duration
+}
+
+// signingKeys are the key tokens are signed with and, after a rotation, the
+// one before it, still accepted until tokens signed with it expire.
+type signingKeys struct {
+	current  []byte
+	previous []byte
 }
 
 func NewJWTManager(config JWTConfig) *JWTManager {
-	return &JWTManager{config: config}
+	if config.Clock == nil {
+		config.Clock = clock.System
+	}
+	m := &JWTManager{config: config}
+	m.keys.Store(&signingKeys{current: []byte(config.SecretKey)})
+	m.ttls.Store(&tokenTTLs{access: config.AccessTokenTTL, refresh: config.RefreshTokenTTL})
+	return m
+}
+
+// SetTTLs changes the lifetime of tokens issued from now on.
+func (m *JWTManager) SetTTLs(access, refresh time.Duration) {
+	m.ttls.Store(&tokenTTLs{access: access, refresh: refresh})
+}
+
+// SetSecretKey rotates the signing key. Tokens signed with the replaced key
+// keep validating until the next rotation, so sessions survive a secret
+// refresh.
+func (m *JWTManager) SetSecretKey(key string) {
+	old := m.keys.Load()
+	if string(old.current) == key {
+		return
+	}
+	m.keys.Store(&signingKeys{current: []byte(key), previous: old.current})
 }
 
 // TokenPayload contains the information needed to generate tokens.
 type TokenPayload struct {
-	UserID      uuid.UUID
-	Email       string
-	Username    string
-	UserType    string
-	Permissions []string
+	UserID        uuid.UUID
+	Email         string
+	Username      string
+	UserType      string
+	Locale        string
+	Timezone      string
+	Permissions   []string
+	Denied        []string
+	Organizations []OrganizationClaim
+	Groups        []string
+	Actor         *ActorClaim
+	Profile       *ProfileClaim
+	Environment   string           // Empty for production
+	Scope         string           // Empty for unrestricted
+	ClientID      string           // Set for OAuth clients, with UserID their ID
+	Delegation    *DelegationClaim // Set on tokens obtained by token exchange
+	Audience      []string         // Nil uses the configured audience
+	Extra         map[string]any   // Custom claims
+	TTL           time.Duration    // Zero uses AccessTokenTTL
 }
 
-func (m *JWTManager) GenerateAccessToken(payload TokenPayload) (string, time.Time, error) {
-	now := time.Now().UTC()
-	expiresAt := now.Add(m.config.AccessTokenTTL)
+// GenerateAccessToken issues an access token for payload, a JWT or, in the
+// environments configured, an opaque token, and returns its expiry.
+func (m *JWTManager) GenerateAccessToken(ctx context.Context, payload TokenPayload) (string, time.Time, error) {
+	ttl := m.ttls.Load().access
+	if payload.TTL > 0 {
+		ttl = payload.TTL
+	}
+
+	audience := m.config.Audience
+	if payload.Audience != nil {
+		audience = payload.Audience
+	}
+
+	now := m.config.Clock.Now().UTC()
+	expiresAt := now.Add(ttl)
 
 	claims := Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        uuid.New().String(),
 			Subject:   payload.UserID.String(),
 			Issuer:    m.config.Issuer,
-			Audience:  m.config.Audience,
+			Audience:  audience,
 			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			NotBefore: jwt.NewNumericDate(now),
 		},
-		UserID:      payload.UserID,
-		Email:       payload.Email,
-		Username:    payload.Username,
-		UserType:    payload.UserType,
-		Permissions: payload.Permissions,
+		UserID:        payload.UserID,
+		Email:         payload.Email,
+		Username:      payload.Username,
+		UserType:      payload.UserType,
+		Locale:        payload.Locale,
+		Timezone:      payload.Timezone,
+		Permissions:   payload.Permissions,
+		Denied:        payload.Denied,
+		Organizations: payload.Organizations,
+		Groups:        payload.Groups,
+		Actor:         payload.Actor,
+		Profile:       payload.Profile,
+		Environment:   payload.Environment,
+		Scope:         payload.Scope,
+		ClientID:      payload.ClientID,
+		Delegation:    payload.Delegation,
+		Extra:         payload.Extra,
+	}
+	if m.config.OmitPermissions && payload.Profile == nil && payload.ClientID == "" && payload.Delegation == nil {
+		claims.Permissions, claims.Denied = nil, nil
+		orgs := make([]OrganizationClaim, len(claims.Organizations))
+		for i, org := range claims.Organizations {
+			orgs[i] = OrganizationClaim{ID: org.ID}
+		}
+		claims.Organizations = orgs
+		claims.PermissionsOmitted = true
+	}
+	if m.config.MinimalClaims {
+		claims.Email, claims.Username = "", ""
+		if claims.Actor != nil {
+			actor := *claims.Actor
+			actor.Username = ""
+			claims.Actor = &actor
+		}
+	}
+
+	if m.opaqueFor(payload.Environment) {
+		token, err := m.issueOpaque(ctx, &claims)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		return token, expiresAt, nil
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(m.config.SecretKey))
+	tokenString, err := token.SignedString(m.keys.Load().current)
 	if err != nil {
 		return "", time.Time{}, err
 	}
@@ -89,17 +415,47 @@ func (m *JWTManager) GenerateAccessToken(payload TokenPayload) (string, time.Tim
 	return tokenString, expiresAt, nil
 }
 
-func (m *JWTManager) ValidateAccessToken(tokenString string) (*Claims, error) {
+// ValidateAccessToken returns the claims of an access token meant for us.
+func (m *JWTManager) ValidateAccessToken(ctx context.Context, tokenString string) (*Claims, error) {
+	claims, err := m.InspectAccessToken(ctx, tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tokens are for the services named in their audience, delegation
+	// tokens often for others than us
+	if len(m.config.Audience) > 0 && !m.intendedForUs(claims.Audience) {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// InspectAccessToken returns the claims of an access token we issued,
+// whatever its audience, for introspection on behalf of other services.
+func (m *JWTManager) InspectAccessToken(ctx context.Context, tokenString string) (*Claims, error) {
+	if IsOpaqueToken(tokenString) {
+		return m.lookupOpaque(ctx, tokenString)
+	}
+
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (any, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, ErrInvalidToken
 		}
-		return []byte(m.config.SecretKey), nil
-	})
+		keys := m.keys.Load()
+		if keys.previous == nil {
+			return keys.current, nil
+		}
+		return jwt.VerificationKeySet{Keys: []jwt.VerificationKey{keys.current, keys.previous}}, nil
+	}, jwt.WithTimeFunc(m.config.Clock.Now), jwt.WithLeeway(m.config.Leeway), jwt.WithIssuedAt())
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
 			return nil, ErrExpiredToken
 		}
+		// Claims are only checked once the signature is, so these are
+		// genuine tokens from an issuer whose clock is ahead of ours
+		if errors.Is(err, jwt.ErrTokenNotValidYet) || errors.Is(err, jwt.ErrTokenUsedBeforeIssued) {
+			m.skewRejected.Add(1)
+		}
 		return nil, ErrInvalidToken
 	}
 
@@ -108,13 +464,57 @@ func (m *JWTManager) ValidateAccessToken(tokenString string) (*Claims, error) {
 		return nil, ErrInvalidToken
 	}
 
+	if m.withinLeeway(claims) {
+		m.skewTolerated.Add(1)
+	}
+
 	return claims, nil
 }
 
+// intendedForUs reports whether audience names one of ours.
+func (m *JWTManager) intendedForUs(audience jwt.ClaimStrings) bool {
+	for _, aud := range audience {
+		if slices.Contains(m.config.Audience, aud) {
+			return true
+		}
+	}
+	return false
+}
+
+// withinLeeway reports whether a valid token would have been rejected
+// without the leeway.
+func (m *JWTManager) withinLeeway(claims *Claims) bool {
+	now := m.config.Clock.Now()
+	if exp := claims.ExpiresAt; exp != nil && now.After(exp.Time) {
+		return true
+	}
+	if nbf := claims.NotBefore; nbf != nil && now.Before(nbf.Time) {
+		return true
+	}
+	if iat := claims.IssuedAt; iat != nil && now.Before(iat.Time) {
+		return true
+	}
+	return false
+}
+
+// Stats returns the clock skew counters.
+func (m *JWTManager) Stats() TokenStats {
+	return TokenStats{
+		Leeway:        m.config.Leeway.String(),
+		SkewTolerated: m.skewTolerated.Load(),
+		SkewRejected:  m.skewRejected.Load(),
+	}
+}
+
 func (m *JWTManager) RefreshTokenTTL() time.Duration {
-	return m.config.RefreshTokenTTL
+	return m.ttls.Load().refresh
 }
 
 func (m *JWTManager) AccessTokenTTL() time.Duration {
-	return m.config.AccessTokenTTL
+	return m.ttls.Load().access
+}
+
+// Audience returns the audience of the tokens we issue for ourselves.
+func (m *JWTManager) Audience() []string {
+	return m.config.Audience
 }
@@ -16,22 +16,43 @@ const bcryptCost = 12
 // ErrInvalidPassword is returned when password validation fails.
 var ErrInvalidPassword = errors.New("invalid password")
 
+// HashPassword hashes a password with bcrypt, peppered with the current
+// pepper if one is set (see SetPeppers).
 func HashPassword(password string) (string, error) {
 	if password == "" {
 		return "", ErrInvalidPassword
 	}
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+	p := loadPeppers()
+	if p.currentID == "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hash), nil
+	}
+
+	hash, err := bcrypt.GenerateFromPassword(applyPepper(p.byID[p.currentID], password), bcryptCost)
 	if err != nil {
 		return "", err
 	}
 
-	return string(hash), nil
+	return pepperPrefix + p.currentID + "$" + string(hash), nil
 }
 
-// CheckPassword verifies a password against its hash.
+// CheckPassword verifies a password against its hash, peppered or not.
 func CheckPassword(password, hash string) error {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	secret := []byte(password)
+	id, bcryptHash, peppered := splitPepperedHash(hash)
+	if peppered {
+		pepper, ok := loadPeppers().byID[id]
+		if !ok {
+			return ErrUnknownPepper
+		}
+		secret = applyPepper(pepper, password)
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(bcryptHash), secret)
 	if err != nil {
 		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
 			return ErrInvalidPassword
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync/atomic"
+)
+
+// A pepper is a secret mixed into passwords before they are hashed and kept
+// out of the database, so a leaked table of hashes can't be attacked
+// offline without it. The password is keyed with HMAC-SHA256 and the
+// result, which fits bcrypt's 72 byte limit, is what gets hashed.
+//
+// Peppered hashes are stored as "$pepper$<id>$<bcrypt hash>", where id
+// identifies the pepper, so the pepper can be rotated: the current pepper
+// hashes new passwords, previous ones still verify old hashes, and
+// NeedsRehash tells callers when a hash should be upgraded. Hashes without
+// the prefix predate the pepper and are checked with plain bcrypt.
+
+const pepperPrefix = "$pepper$"
+
+// ErrUnknownPepper is returned when a hash was made with a pepper that is
+// no longer configured.
+var ErrUnknownPepper = errors.New("password hash uses an unknown pepper")
+
+type peppers struct {
+	currentID string
+	byID      map[string][]byte
+}
+
+var currentPeppers atomic.Pointer[peppers]
+
+// SetPeppers sets the pepper used to hash passwords and the previous ones
+// still accepted when checking them. An empty current pepper turns
+// peppering off for new hashes. Safe to call while hashing, so peppers
+// loaded from a secret manager can be refreshed.
+func SetPeppers(current string, previous ...string) {
+	p := &peppers{byID: make(map[string][]byte, len(previous)+1)}
+	for _, pepper := range previous {
+		if pepper != "" {
+			p.byID[pepperID(pepper)] = []byte(pepper)
+		}
+	}
+	if current != "" {
+		p.currentID = pepperID(current)
+		p.byID[p.currentID] = []byte(current)
+	}
+	currentPeppers.Store(p)
+}
+
+func loadPeppers() *peppers {
+	if p := currentPeppers.Load(); p != nil {
+		return p
+	}
+	return &peppers{}
+}
+
+// pepperID is a short fingerprint of a pepper, safe to store next to hashes.
+func pepperID(pepper string) string {
+	sum := sha256.Sum256([]byte("aegis-pepper-id:" + pepper))
+	return hex.EncodeToString(sum[:4])
+}
+
+func applyPepper(pepper []byte, password string) []byte {
+	mac := hmac.New(sha256.New, pepper)
+	mac.Write([]byte(password))
+	return []byte(base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+// splitPepperedHash returns the pepper ID and bcrypt hash of a peppered
+// hash, or ok false for a plain bcrypt hash.
+func splitPepperedHash(hash string) (id, bcryptHash string, ok bool) {
+	rest, ok := strings.CutPrefix(hash, pepperPrefix)
+	if !ok {
+		return "", hash, false
+	}
+	id, bcryptHash, ok = strings.Cut(rest, "$")
+	return id, bcryptHash, ok
+}
+
+// NeedsRehash reports whether hash wasn't made with the current pepper and
+// should be replaced, after a successful CheckPassword, by HashPassword.
+func NeedsRehash(hash string) bool {
+	id, _, ok := splitPepperedHash(hash)
+	current := loadPeppers().currentID
+	if !ok {
+		return current != ""
+	}
+	return id != current
+}
@@ -0,0 +1,36 @@
+package authz
+
+// FieldPermission is the permission a caller needs to see a field.
+type FieldPermission struct {
+	Resource string
+	Action   string
+}
+
+// FieldPermissions maps the names of response fields to the permission a
+// caller needs to see them; fields it doesn't list are visible to anyone
+// allowed the response. Names are JSON keys, which are also the fields'
+// proto names.
+type FieldPermissions map[string]FieldPermission
+
+// UserFields keeps users' contact details from callers who may only read
+// users with users:read_basic. Users always see their own.
+var UserFields = FieldPermissions{
+	"email": {Resource: "users", Action: "read"},
+	"phone": {Resource: "users", Action: "read"},
+}
+
+// Hidden returns the fields a caller can't see, can reporting whether they
+// hold a permission. It returns nil when they can see every field.
+func (f FieldPermissions) Hidden(can func(resource, action string) bool) map[string]bool {
+	var hidden map[string]bool
+	for field, p := range f {
+		if can(p.Resource, p.Action) {
+			continue
+		}
+		if hidden == nil {
+			hidden = make(map[string]bool)
+		}
+		hidden[field] = true
+	}
+	return hidden
+}
@@ -0,0 +1,91 @@
+// Package authz holds authorization beyond checking a route's permission.
+//
+// Candidate role models can be evaluated in shadow mode: the decisions the
+// server enforces are recomputed under the candidate, off the request path,
+// and the requests where the two disagree are logged and counted, so a role
+// refactor can be checked against production traffic before it's applied.
+//
+// FieldPermissions guard individual fields of responses, which the
+// transports strip for callers lacking the permission.
+package authz
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// Policy is a candidate model for the global roles: the permissions each
+// role would grant and deny. Roles it doesn't list would grant nothing, as
+// after `aegisctl sync-rbac -prune` with the same file.
+type Policy struct {
+	Name  string // Where the policy was read from
+	roles map[string][]domain.Permission
+}
+
+// policyFile is the part of an RBAC seed file (service.RBACSeed) a policy
+// needs, so the file given to sync-rbac can be shadowed as is.
+type policyFile struct {
+	Roles []struct {
+		Name        string   `yaml:"name"`
+		Permissions []string `yaml:"permissions"`
+		Deny        []string `yaml:"deny"`
+	} `yaml:"roles"`
+}
+
+// LoadPolicy reads a policy from a YAML or JSON RBAC seed file.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file policyFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	policy := &Policy{Name: path, roles: make(map[string][]domain.Permission)}
+	for _, role := range file.Roles {
+		name := strings.TrimSpace(role.Name)
+		if name == "" {
+			return nil, fmt.Errorf("%s: role without a name", path)
+		}
+		if _, dup := policy.roles[name]; dup {
+			return nil, fmt.Errorf("%s: role %q listed twice", path, name)
+		}
+
+		var perms []domain.Permission
+		for _, list := range []struct {
+			names []string
+			deny  bool
+		}{{role.Permissions, false}, {role.Deny, true}} {
+			for _, s := range list.names {
+				resource, action, ok := strings.Cut(strings.ToLower(strings.TrimSpace(s)), ":")
+				if !ok || resource == "" || action == "" {
+					return nil, fmt.Errorf("%s: role %q: permission %q is not resource:action", path, name, s)
+				}
+				perms = append(perms, domain.Permission{Resource: resource, Action: action, Deny: list.deny})
+			}
+		}
+		policy.roles[name] = perms
+	}
+	return policy, nil
+}
+
+// Permissions returns what the global roles among roles would grant and
+// deny under the policy. Organization roles are left out, as they are from
+// the decisions the policy is compared with.
+func (p *Policy) Permissions(roles []domain.Role) []domain.Permission {
+	var perms []domain.Permission
+	for i := range roles {
+		if roles[i].IsGlobal() {
+			perms = append(perms, p.roles[roles[i].Name]...)
+		}
+	}
+	return perms
+}
@@ -0,0 +1,197 @@
+package authz
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// recentDivergences is how many divergences a Report lists.
+const recentDivergences = 100
+
+// Decision is an authorization decision the server enforced.
+type Decision struct {
+	UserID   uuid.UUID
+	Resource string
+	Action   string
+	Allowed  bool   // What was enforced, from the caller's token
+	Source   string // e.g. "http GET /api/v1/users/{id}" or "grpc /aegis.user.v1.UserService/GetUser"
+}
+
+// Divergence is a decision the policy would have made differently.
+type Divergence struct {
+	At       time.Time `json:"at"`
+	UserID   uuid.UUID `json:"user_id"`
+	Resource string    `json:"resource"`
+	Action   string    `json:"action"`
+	Source   string    `json:"source"`
+	Roles    []string  `json:"roles"`
+	Current  bool      `json:"current"`  // Under the roles as they are
+	Shadow   bool      `json:"shadow"`   // Under the policy
+	Enforced bool      `json:"enforced"` // Differs from Current when the token is stale
+}
+
+// Report summarizes the shadow evaluations since the last reset.
+type Report struct {
+	Policy         string       `json:"policy"`
+	Since          time.Time    `json:"since"`
+	Evaluated      int64        `json:"evaluated"`
+	Divergences    int64        `json:"divergences"`
+	NewlyAllowed   int64        `json:"newly_allowed"` // Refused now, allowed by the policy
+	NewlyDenied    int64        `json:"newly_denied"`  // Allowed now, refused by the policy
+	DivergenceRate float64      `json:"divergence_rate"`
+	Skipped        int64        `json:"skipped"` // Dropped while all workers were busy
+	Errors         int64        `json:"errors"`
+	Recent         []Divergence `json:"recent"` // Newest first
+}
+
+// Shadow compares enforced decisions with the ones a candidate Policy
+// would make. Each decision is recomputed in the background from the
+// user's current roles, held directly or through groups, under both the
+// current role model and the policy, so token staleness doesn't count as
+// divergence. A nil *Shadow does nothing.
+type Shadow struct {
+	policy  *Policy
+	roles   storage.RoleRepository
+	logger  *slog.Logger
+	timeout time.Duration
+	workers chan struct{}
+
+	mu     sync.Mutex
+	report Report
+}
+
+// NewShadow evaluates policy with up to concurrency lookups at a time;
+// decisions arriving while they're all busy are skipped.
+func NewShadow(policy *Policy, roles storage.RoleRepository, concurrency int, logger *slog.Logger) *Shadow {
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	s := &Shadow{
+		policy:  policy,
+		roles:   roles,
+		logger:  logger,
+		timeout: 5 * time.Second,
+		workers: make(chan struct{}, concurrency),
+	}
+	s.Reset()
+	return s
+}
+
+// Observe schedules d for shadow evaluation and returns at once. ctx is
+// only used for its values, such as the environment.
+func (s *Shadow) Observe(ctx context.Context, d Decision) {
+	if s == nil {
+		return
+	}
+
+	select {
+	case s.workers <- struct{}{}:
+	default:
+		s.mu.Lock()
+		s.report.Skipped++
+		s.mu.Unlock()
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.timeout)
+	go func() {
+		defer func() {
+			cancel()
+			<-s.workers
+		}()
+		s.evaluate(ctx, d)
+	}()
+}
+
+func (s *Shadow) evaluate(ctx context.Context, d Decision) {
+	roles, err := s.roles.GetEffectiveUserRoles(ctx, d.UserID)
+	if err != nil {
+		s.mu.Lock()
+		s.report.Errors++
+		s.mu.Unlock()
+		s.logger.Error("authorization shadow evaluation failed",
+			slog.String("user_id", d.UserID.String()),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	user := domain.User{Roles: roles}
+	current := domain.Allows(user.AllPermissions(), d.Resource, d.Action)
+	shadow := domain.Allows(s.policy.Permissions(roles), d.Resource, d.Action)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.report.Evaluated++
+	if current == shadow {
+		return
+	}
+
+	div := Divergence{
+		At:       domain.Now(),
+		UserID:   d.UserID,
+		Resource: d.Resource,
+		Action:   d.Action,
+		Source:   d.Source,
+		Current:  current,
+		Shadow:   shadow,
+		Enforced: d.Allowed,
+	}
+	for i := range roles {
+		if roles[i].IsGlobal() {
+			div.Roles = append(div.Roles, roles[i].Name)
+		}
+	}
+
+	s.report.Divergences++
+	if shadow {
+		s.report.NewlyAllowed++
+	} else {
+		s.report.NewlyDenied++
+	}
+	s.report.Recent = append([]Divergence{div}, s.report.Recent...)
+	if len(s.report.Recent) > recentDivergences {
+		s.report.Recent = s.report.Recent[:recentDivergences]
+	}
+
+	s.logger.Warn("authorization shadow divergence",
+		slog.String("policy", s.policy.Name),
+		slog.String("user_id", d.UserID.String()),
+		slog.String("permission", d.Resource+":"+d.Action),
+		slog.String("source", d.Source),
+		slog.Any("roles", div.Roles),
+		slog.Bool("current", current),
+		slog.Bool("shadow", shadow),
+		slog.Bool("enforced", d.Allowed),
+	)
+}
+
+// Report returns the counts so far and the most recent divergences.
+func (s *Shadow) Report() Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := s.report
+	report.Recent = append(make([]Divergence, 0, len(s.report.Recent)), s.report.Recent...)
+	if report.Evaluated > 0 {
+		report.DivergenceRate = float64(report.Divergences) / float64(report.Evaluated)
+	}
+	return report
+}
+
+// Reset clears the counts, e.g. once the divergences seen so far have been
+// dealt with by changing the current roles.
+func (s *Shadow) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.report = Report{Policy: s.policy.Name, Since: domain.Now()}
+}
@@ -0,0 +1,122 @@
+// Package cache keeps rendered responses of hot, rarely changing reads,
+// such as role lists and the permission catalog, in process memory or in
+// Redis, so high-QPS internal consumers don't reach Postgres for each one.
+//
+// Entries are grouped in namespaces. A change doesn't delete entries: it
+// bumps its namespace's generation, which is part of every key, so entries
+// written before the change are never read again and age out with their
+// TTL. With Redis the generation is shared, so a change made through one
+// replica is seen by all of them.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+)
+
+// Store holds cached values and namespace generations.
+type Store interface {
+	// Get returns the value stored under key, or false if there's none or
+	// it has expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Set stores value under key for ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// Generation returns the current generation of namespace, zero until
+	// it's first bumped.
+	Generation(ctx context.Context, namespace string) (uint64, error)
+
+	// Bump advances the generation of namespace.
+	Bump(ctx context.Context, namespace string) error
+}
+
+// Cache stores values in namespaces, separately for each environment.
+type Cache struct {
+	store Store
+	ttl   time.Duration
+}
+
+// New returns a cache keeping values in store for ttl.
+func New(store Store, ttl time.Duration) *Cache {
+	return &Cache{store: store, ttl: ttl}
+}
+
+// TTL returns how long values are kept.
+func (c *Cache) TTL() time.Duration {
+	return c.ttl
+}
+
+// Key returns the key of name in namespace's current generation, in the
+// environment of ctx. Taking the key before reading the value to store
+// under it means a change in between leaves the value under an outdated
+// generation, where it's never served.
+func (c *Cache) Key(ctx context.Context, namespace, name string) (string, error) {
+	ns := namespaceIn(ctx, namespace)
+	gen, err := c.store.Generation(ctx, ns)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s:%d:%s", ns, gen, name), nil
+}
+
+// Get returns the value under key, if any.
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	return c.store.Get(ctx, key)
+}
+
+// Set stores value under key.
+func (c *Cache) Set(ctx context.Context, key string, value []byte) error {
+	return c.store.Set(ctx, key, value, c.ttl)
+}
+
+// Invalidate drops every value in namespace, in the environment of ctx.
+func (c *Cache) Invalidate(ctx context.Context, namespace string) error {
+	return c.store.Bump(ctx, namespaceIn(ctx, namespace))
+}
+
+func namespaceIn(ctx context.Context, namespace string) string {
+	return string(domain.EnvironmentFromContext(ctx)) + ":" + namespace
+}
+
+// Invalidations maps event types to the namespaces they change.
+type Invalidations map[string][]string
+
+// Watch invalidates namespaces as events changing them are published on
+// bus in the environment of ctx, until ctx is done or the bus closes.
+// Events the subscription drops while it falls behind leave values stale
+// until their TTL runs out.
+func (c *Cache) Watch(ctx context.Context, bus *event.Bus, inv Invalidations, logger *slog.Logger) {
+	types := make([]string, 0, len(inv))
+	for t := range inv {
+		types = append(types, t)
+	}
+
+	sub := bus.Subscribe(ctx, 256, types...)
+	defer sub.Cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			for _, namespace := range inv[e.Type] {
+				if err := c.Invalidate(ctx, namespace); err != nil {
+					logger.Warn("cache invalidation failed",
+						slog.String("namespace", namespace),
+						slog.String("event", e.Type),
+						slog.String("error", err.Error()),
+					)
+				}
+			}
+		}
+	}
+}
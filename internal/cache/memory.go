@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// Memory is a Store in process memory. Each replica has its own, so a
+// change only invalidates the entries of the replica it went through;
+// the others serve theirs until the TTL runs out.
+type Memory struct {
+	mu          sync.Mutex
+	maxEntries  int
+	entries     map[string]memoryEntry
+	generations map[string]uint64
+}
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemory returns a store holding up to maxEntries values.
+func NewMemory(maxEntries int) *Memory {
+	return &Memory{
+		maxEntries:  maxEntries,
+		entries:     make(map[string]memoryEntry),
+		generations: make(map[string]uint64),
+	}
+}
+
+func (m *Memory) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !domain.Now().Before(e.expiresAt) {
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	return e.value, true, nil
+}
+
+// Set stores value, making room when the store is full by dropping the
+// expired entries or, failing that, arbitrary ones.
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := domain.Now()
+	if _, ok := m.entries[key]; !ok && len(m.entries) >= m.maxEntries {
+		for k, e := range m.entries {
+			if !now.Before(e.expiresAt) {
+				delete(m.entries, k)
+			}
+		}
+		for k := range m.entries {
+			if len(m.entries) < m.maxEntries {
+				break
+			}
+			delete(m.entries, k)
+		}
+	}
+
+	m.entries[key] = memoryEntry{value: value, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (m *Memory) Generation(_ context.Context, namespace string) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.generations[namespace], nil
+}
+
+func (m *Memory) Bump(_ context.Context, namespace string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.generations[namespace]++
+	return nil
+}
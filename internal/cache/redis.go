@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisPrefix keeps cache keys apart from anything else in the database.
+const redisPrefix = "aegis:cache:"
+
+// Redis is a Store shared by every replica pointing at the same Redis.
+type Redis struct {
+	client *redis.Client
+}
+
+// NewRedis connects to the Redis at url, e.g. redis://:password@host:6379/0.
+// Connections are made on first use.
+func NewRedis(url string) (*Redis, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("parse REDIS_URL: %w", err)
+	}
+	return &Redis{client: redis.NewClient(opts)}, nil
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := r.client.Get(ctx, redisPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, redisPrefix+key, value, ttl).Err()
+}
+
+func (r *Redis) Generation(ctx context.Context, namespace string) (uint64, error) {
+	gen, err := r.client.Get(ctx, redisPrefix+"gen:"+namespace).Uint64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return gen, err
+}
+
+func (r *Redis) Bump(ctx context.Context, namespace string) error {
+	return r.client.Incr(ctx, redisPrefix+"gen:"+namespace).Err()
+}
+
+// Ping reports whether Redis answers.
+func (r *Redis) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+// Close closes the connections.
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
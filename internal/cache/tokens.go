@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenPrefix keeps opaque access tokens apart from cached responses.
+const tokenPrefix = "aegis:token:"
+
+// Tokens keeps the claims of opaque access tokens in Redis, which expires
+// them, shared by every replica. It implements auth.OpaqueStore.
+type Tokens struct {
+	client *redis.Client
+}
+
+// Tokens returns a token store sharing r's connections.
+func (r *Redis) Tokens() *Tokens {
+	return &Tokens{client: r.client}
+}
+
+func (t *Tokens) Put(ctx context.Context, tokenHash string, userID uuid.UUID, claims []byte, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	// The user's set of token hashes, for DeleteForUser, lives as long as
+	// their longest-lived token
+	userKey := tokenPrefix + "user:" + userID.String()
+	_, err := t.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, tokenPrefix+tokenHash, claims, ttl)
+		p.SAdd(ctx, userKey, tokenHash)
+		p.ExpireGT(ctx, userKey, ttl)
+		p.ExpireNX(ctx, userKey, ttl)
+		return nil
+	})
+	return err
+}
+
+func (t *Tokens) Get(ctx context.Context, tokenHash string) ([]byte, bool, error) {
+	claims, err := t.client.Get(ctx, tokenPrefix+tokenHash).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return claims, true, nil
+}
+
+func (t *Tokens) DeleteForUser(ctx context.Context, userID uuid.UUID) error {
+	userKey := tokenPrefix + "user:" + userID.String()
+	hashes, err := t.client.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := []string{userKey}
+	for _, hash := range hashes {
+		keys = append(keys, tokenPrefix+hash)
+	}
+	return t.client.Del(ctx, keys...).Err()
+}
@@ -0,0 +1,207 @@
+// Package certs serves TLS certificates from files that can be replaced
+// while the servers run.
+//
+// A Reloader holds a certificate and key, and optionally the CAs client
+// certificates are verified against, loaded from PEM files. Reloading reads
+// them again and swaps them in for new connections; connections already
+// established keep the certificate they were made with. A failed reload,
+// such as a certificate written before its key, keeps the files already
+// loaded.
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ClientAuthModes are the client certificate modes ParseClientAuth accepts.
+var ClientAuthModes = []string{"none", "request", "require", "verify_if_given", "require_and_verify"}
+
+// ParseClientAuth returns the tls.ClientAuthType of a client certificate
+// mode: none, request (asked for, not required or verified), require
+// (required, not verified), verify_if_given (verified if presented) or
+// require_and_verify (mutual TLS).
+func ParseClientAuth(mode string) (tls.ClientAuthType, error) {
+	switch mode {
+	case "", "none":
+		return tls.NoClientCert, nil
+	case "request":
+		return tls.RequestClientCert, nil
+	case "require":
+		return tls.RequireAnyClientCert, nil
+	case "verify_if_given":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require_and_verify":
+		return tls.RequireAndVerifyClientCert, nil
+	}
+	return tls.NoClientCert, fmt.Errorf("unknown client certificate mode %q", mode)
+}
+
+// VerifiesClients reports whether a client certificate mode verifies the
+// certificates clients present, and so needs CAs to verify them against.
+func VerifiesClients(mode string) bool {
+	return mode == "verify_if_given" || mode == "require_and_verify"
+}
+
+// bundle is what a Reloader has loaded.
+type bundle struct {
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool // Nil without a client CA file
+}
+
+// Reloader serves the certificate in its files, safe for concurrent use.
+type Reloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	current atomic.Pointer[bundle]
+
+	mu       sync.Mutex // Serializes reloads
+	modTimes [3]time.Time
+}
+
+// NewReloader loads a certificate and its key, and the CAs in clientCAFile
+// unless it's empty.
+func NewReloader(certFile, keyFile, clientCAFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile, clientCAFile: clientCAFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the files again.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	modTimes := r.stat()
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load certificate: %w", err)
+	}
+
+	b := &bundle{cert: &cert}
+	if r.clientCAFile != "" {
+		pem, err := os.ReadFile(r.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("load client CAs: %w", err)
+		}
+		b.clientCAs = x509.NewCertPool()
+		if !b.clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("load client CAs: no certificates in %s", r.clientCAFile)
+		}
+	}
+
+	r.current.Store(b)
+	r.modTimes = modTimes
+	return nil
+}
+
+// stat returns the modification times of the files, zero for those
+// missing.
+func (r *Reloader) stat() [3]time.Time {
+	var modTimes [3]time.Time
+	for i, file := range []string{r.certFile, r.keyFile, r.clientCAFile} {
+		if file == "" {
+			continue
+		}
+		if info, err := os.Stat(file); err == nil {
+			modTimes[i] = info.ModTime()
+		}
+	}
+	return modTimes
+}
+
+// Watch reloads whenever one of the files' modification time changes,
+// checking every interval until ctx is done. Failures are logged and tried
+// again on the next change.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		r.mu.Lock()
+		changed := r.stat() != r.modTimes
+		r.mu.Unlock()
+		if !changed {
+			continue
+		}
+
+		if err := r.Reload(); err != nil {
+			// Don't retry until the files change again
+			r.mu.Lock()
+			r.modTimes = r.stat()
+			r.mu.Unlock()
+			logger.Error("certificate reload failed", slog.String("file", r.certFile), slog.String("error", err.Error()))
+			continue
+		}
+		logger.Info("certificate reloaded", slog.String("file", r.certFile))
+	}
+}
+
+// ServerConfig returns a TLS configuration serving the certificate loaded
+// at the time of each handshake, and verifying client certificates as
+// clientAuth says against the client CAs. nextProtos are the ALPN
+// protocols to offer, which the configuration has to carry itself: servers
+// add theirs to a copy of it that handshakes don't see.
+func (r *Reloader) ServerConfig(clientAuth tls.ClientAuthType, nextProtos ...string) *tls.Config {
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: clientAuth,
+		NextProtos: nextProtos,
+	}
+
+	cfg := base.Clone()
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		b := r.current.Load()
+		handshake := base.Clone()
+		handshake.Certificates = []tls.Certificate{*b.cert}
+		handshake.ClientCAs = b.clientCAs
+		return handshake, nil
+	}
+	return cfg
+}
+
+// ClientConfig returns a TLS configuration for connecting to a server using
+// this certificate, such as the gateway to the gRPC server: the server is
+// trusted if it presents the certificate loaded. client holds the
+// certificate presented should the server ask for one; with a nil client
+// none is.
+func (r *Reloader) ClientConfig(client *Reloader) *tls.Config {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// The server is checked by VerifyPeerCertificate against the
+		// certificate itself instead of against CAs
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			leaf := r.current.Load().cert.Certificate[0]
+			if len(rawCerts) == 0 || !bytes.Equal(rawCerts[0], leaf) {
+				return errors.New("certs: server certificate isn't the one loaded")
+			}
+			return nil
+		},
+	}
+	if client != nil {
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return client.current.Load().cert, nil
+		}
+	}
+	return cfg
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Live holds the running configuration as a snapshot that is swapped
+// atomically on Reload. Only these settings change on reload; the rest
+// need a restart and keep their startup values:
+//
+//   - LOG_LEVEL
+//   - ACCESS_TOKEN_TTL, REFRESH_TOKEN_TTL
+//   - AVAILABILITY_RATE_PER_MINUTE, SUPPORT_LOOKUP_RATE_PER_MINUTE
+//   - COST_QUOTA_ENFORCE, COST_QUOTA_CAPACITY, COST_QUOTA_REFILL_PER_MINUTE
+//   - LOGIN_DEVICE_CONFIRMATION
+type Live struct {
+	current atomic.Pointer[Config]
+
+	mu       sync.Mutex
+	onReload []func(*Config)
+}
+
+// NewLive starts from cfg.
+func NewLive(cfg *Config) *Live {
+	l := &Live{}
+	l.current.Store(cfg)
+	return l
+}
+
+// Load returns the current snapshot. Callers must not modify it.
+func (l *Live) Load() *Config {
+	return l.current.Load()
+}
+
+// OnReload registers fn to be called with the new snapshot after each
+// successful reload.
+func (l *Live) OnReload(fn func(*Config)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onReload = append(l.onReload, fn)
+}
+
+// Reload reads the configuration again and applies its reloadable
+// settings. An invalid configuration is rejected as a whole and the
+// current one kept.
+func (l *Live) Reload() error {
+	fresh := Load()
+	if err := fresh.Validate(); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	next := *l.current.Load()
+	next.LogLevel = fresh.LogLevel
+	next.AccessTokenTTL = fresh.AccessTokenTTL
+	next.RefreshTokenTTL = fresh.RefreshTokenTTL
+	next.RefreshTokenSliding = fresh.RefreshTokenSliding
+	next.RefreshTokenMaxLifetime = fresh.RefreshTokenMaxLifetime
+	next.RefreshTokenShortTTL = fresh.RefreshTokenShortTTL
+	next.MaxSessionsPerUser = fresh.MaxSessionsPerUser
+	next.SessionLimitPolicy = fresh.SessionLimitPolicy
+	next.AvailabilityRatePerMinute = fresh.AvailabilityRatePerMinute
+	next.SupportLookupRatePerMinute = fresh.SupportLookupRatePerMinute
+	next.CostQuotaEnforce = fresh.CostQuotaEnforce
+	next.CostQuotaCapacity = fresh.CostQuotaCapacity
+	next.CostQuotaRefillPerMinute = fresh.CostQuotaRefillPerMinute
+	next.LoginDeviceConfirmation = fresh.LoginDeviceConfirmation
+	next.ReactivateOnLogin = fresh.ReactivateOnLogin
+
+	// The merged snapshot mixes startup and reloaded values, so check the
+	// combination too (e.g. token TTLs against each other)
+	if err := next.Validate(); err != nil {
+		return err
+	}
+
+	l.current.Store(&next)
+	for _, fn := range l.onReload {
+		fn(&next)
+	}
+	return nil
+}
+
+// WatchFile reloads whenever ConfigFile's modification time changes,
+// checking every interval until ctx is done. Failures are logged.
+func (l *Live) WatchFile(ctx context.Context, interval time.Duration, logger *slog.Logger) {
+	file := l.Load().ConfigFile
+	if file == "" {
+		return
+	}
+
+	modTime := func() time.Time {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}
+		}
+		return info.ModTime()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := modTime()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if mt := modTime(); !mt.Equal(last) {
+			last = mt
+			if err := l.Reload(); err != nil {
+				logger.Error("config reload failed", slog.String("file", file), slog.String("error", err.Error()))
+				continue
+			}
+			logger.Info("config reloaded", slog.String("file", file))
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mvaleed/aegis/internal/secrets"
+)
+
+// NewSecretStore loads the secrets from the configured provider, falling
+// back to the values in c for any it doesn't have.
+func (c *Config) NewSecretStore(ctx context.Context) (*secrets.Store, error) {
+	env := secrets.Static{
+		secrets.JWTSecretKey:        c.JWTSecretKey,
+		secrets.PasswordPepper:      c.PasswordPepper,
+		secrets.PreviousPeppers:     strings.Join(c.PasswordPreviousPeppers, ","),
+		secrets.DatabasePassword:    c.DatabasePassword,
+		secrets.ColumnEncryptionKey: c.ColumnEncryptionKey,
+		secrets.PreviousColumnKeys:  strings.Join(c.ColumnEncryptionPreviousKeys, ","),
+		secrets.SCIMToken:           c.SCIMToken,
+		secrets.LDAPBindPassword:    c.LDAPBindPassword,
+	}
+
+	var provider secrets.Provider
+	switch c.SecretsProvider {
+	case "env":
+		provider = env
+	case "vault":
+		provider = secrets.Chain{c.Vault(), env}
+	case "aws":
+		credentials := secrets.AWSCredentials{
+			AccessKeyID:     c.AWSAccessKeyID,
+			SecretAccessKey: c.AWSSecretAccessKey,
+			SessionToken:    c.AWSSessionToken,
+		}
+		provider = secrets.Chain{
+			secrets.NewAWS(c.AWSRegion, c.AWSSecretID, c.AWSEndpoint, credentials, c.SecretsTimeout),
+			env,
+		}
+	default:
+		return nil, fmt.Errorf("unknown secrets provider %q", c.SecretsProvider)
+	}
+
+	store := secrets.NewStore(provider, secrets.Names...)
+	if err := store.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Vault returns a client for the configured Vault secret.
+func (c *Config) Vault() *secrets.Vault {
+	return secrets.NewVault(c.VaultAddr, c.VaultToken, c.VaultMount, c.VaultPath, c.SecretsTimeout)
+}
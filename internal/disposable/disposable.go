@@ -0,0 +1,145 @@
+// Package disposable detects throwaway email addresses.
+//
+// Domains starts from a list embedded at build time and can replace it with
+// a fresh copy of a maintained list fetched over HTTP, so new throwaway
+// domains are picked up without a release. A failed refresh keeps the list
+// already loaded.
+package disposable
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+//go:embed domains.txt
+var embeddedDomains string
+
+// maxListSize bounds the size of a fetched list.
+const maxListSize = 10 << 20
+
+// ErrEmptyList is returned when a fetched list has no domains, which more
+// likely means a broken download than that no disposable domains exist.
+var ErrEmptyList = errors.New("disposable domain list is empty")
+
+// Domains is a set of disposable email domains, safe for concurrent use.
+type Domains struct {
+	set atomic.Pointer[map[string]struct{}]
+}
+
+// NewDomains returns the embedded list.
+func NewDomains() *Domains {
+	d := &Domains{}
+	set, _ := parse(strings.NewReader(embeddedDomains))
+	d.set.Store(&set)
+	return d
+}
+
+// Len returns the number of domains in the list.
+func (d *Domains) Len() int {
+	return len(*d.set.Load())
+}
+
+// IsDisposable reports whether an email address, or a bare domain, belongs
+// to a disposable domain or a subdomain of one.
+func (d *Domains) IsDisposable(email string) bool {
+	domain := email
+	if i := strings.LastIndexByte(email, '@'); i >= 0 {
+		domain = email[i+1:]
+	}
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if domain == "" {
+		return false
+	}
+
+	set := *d.set.Load()
+	for {
+		if _, ok := set[domain]; ok {
+			return true
+		}
+		i := strings.IndexByte(domain, '.')
+		if i < 0 {
+			return false
+		}
+		domain = domain[i+1:]
+	}
+}
+
+// Refresh replaces the list with the one at url: plain text, one domain per
+// line, with # comments.
+func (d *Domains) Refresh(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching disposable domain list: status %d", resp.StatusCode)
+	}
+
+	set, err := parse(io.LimitReader(resp.Body, maxListSize))
+	if err != nil {
+		return err
+	}
+	if len(set) == 0 {
+		return ErrEmptyList
+	}
+
+	d.set.Store(&set)
+	return nil
+}
+
+// RunRefresh refreshes the list from url every interval until ctx is done,
+// starting immediately. Failures are logged.
+func (d *Domains) RunRefresh(ctx context.Context, url string, interval time.Duration, logger *slog.Logger) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.Refresh(ctx, client, url); err != nil && ctx.Err() == nil {
+			logger.Warn("disposable domain list refresh failed",
+				slog.String("url", url),
+				slog.String("error", err.Error()),
+			)
+		} else if err == nil {
+			logger.Debug("disposable domain list refreshed", slog.Int("domains", d.Len()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func parse(r io.Reader) (map[string]struct{}, error) {
+	set := make(map[string]struct{})
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(line)), ".")
+		if line != "" {
+			set[line] = struct{}{}
+		}
+	}
+	return set, scanner.Err()
+}
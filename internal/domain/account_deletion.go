@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountDeletion is a user's request to delete their own account. Until
+// DeleteAfter they can still sign in and cancel it; after, the account is
+// deleted.
+type AccountDeletion struct {
+	UserID      uuid.UUID
+	RequestedAt time.Time
+	DeleteAfter time.Time
+}
+
+// NewAccountDeletion schedules userID's account for deletion after grace.
+func NewAccountDeletion(userID uuid.UUID, grace time.Duration) *AccountDeletion {
+	now := Now()
+	return &AccountDeletion{
+		UserID:      userID,
+		RequestedAt: now,
+		DeleteAfter: now.Add(grace),
+	}
+}
+
+// IsDue reports whether the grace period is over.
+func (d *AccountDeletion) IsDue() bool {
+	return !Now().Before(d.DeleteAfter)
+}
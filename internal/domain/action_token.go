@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ActionPurpose is what an ActionToken authorizes. A token only works for
+// the purpose it was issued for.
+type ActionPurpose string
+
+const (
+	ActionAcceptInvitation ActionPurpose = "accept_invitation" // Subject: the invitation
+	ActionConfirmLogin     ActionPurpose = "confirm_login"     // Subject: the known device
+	ActionVerifyEmail      ActionPurpose = "verify_email"      // Subject: the user
+	ActionChangeEmail      ActionPurpose = "change_email"      // Subject: the user
+)
+
+// ActionToken is a single-use, expiring token emailed or handed to a user to
+// authorize one action on a subject, such as accepting an invitation. Only
+// a hash of the raw token is stored.
+type ActionToken struct {
+	ID        uuid.UUID
+	Purpose   ActionPurpose
+	SubjectID uuid.UUID
+	Payload   map[string]any // Facts fixed when the token was issued
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+// NewActionToken creates a token for purpose on subjectID, valid for ttl.
+func NewActionToken(purpose ActionPurpose, subjectID uuid.UUID, payload map[string]any, tokenHash string, ttl time.Duration) *ActionToken {
+	if payload == nil {
+		payload = make(map[string]any)
+	}
+	now := Now()
+	return &ActionToken{
+		ID:        NewID(),
+		Purpose:   purpose,
+		SubjectID: subjectID,
+		Payload:   payload,
+		TokenHash: tokenHash,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+}
+
+func (t *ActionToken) IsExpired() bool {
+	return Now().After(t.ExpiresAt)
+}
+
+func (t *ActionToken) IsUsed() bool {
+	return t.UsedAt != nil
+}
+
+func (t *ActionToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// IsValid returns true if the token can still be used.
+func (t *ActionToken) IsValid() bool {
+	return !t.IsExpired() && !t.IsUsed() && !t.IsRevoked()
+}
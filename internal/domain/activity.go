@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// ActivityCounters is a snapshot of sign-in activity across all replicas,
+// taken at At.
+type ActivityCounters struct {
+	ActiveSessions    int64 // Refresh tokens neither revoked nor expired
+	ActiveUsers       int64 // Users holding at least one active session
+	LoginsPerMinute   int64 // Successful logins in the minute before At
+	FailuresPerMinute int64 // Failed logins in the minute before At
+	At                time.Time
+}
@@ -0,0 +1,175 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKeyEnvironment separates keys partners test with from keys they go
+// live with.
+type APIKeyEnvironment string
+
+const (
+	APIKeySandbox    APIKeyEnvironment = "sandbox"
+	APIKeyProduction APIKeyEnvironment = "production"
+)
+
+// Valid returns true if the APIKeyEnvironment is recognized.
+func (e APIKeyEnvironment) Valid() bool {
+	return e == APIKeySandbox || e == APIKeyProduction
+}
+
+// DataEnvironment is the environment requests made with such a key work
+// in: sandbox keys only see sandbox data.
+func (e APIKeyEnvironment) DataEnvironment() Environment {
+	if e == APIKeySandbox {
+		return EnvironmentSandbox
+	}
+	return EnvironmentProduction
+}
+
+// apiKeyTag is the environment marker in a raw key, so a leaked key's kind
+// is obvious at a glance.
+func (e APIKeyEnvironment) apiKeyTag() string {
+	if e == APIKeyProduction {
+		return "live"
+	}
+	return "test"
+}
+
+// APIKey lets a partner's servers call the API as the partner, limited to
+// the key's scopes. The raw key is "ak_<test|live>_<prefix>_<secret>":
+// the prefix identifies the key and only a hash of the secret is stored.
+type APIKey struct {
+	ID          uuid.UUID
+	OwnerID     uuid.UUID
+	Name        string
+	Environment APIKeyEnvironment
+	Prefix      string
+	SecretHash  string
+	Scopes      []string // Permissions in resource:action form
+	LastUsedAt  *time.Time
+	CreatedAt   time.Time
+	RotatedAt   *time.Time
+	RevokedAt   *time.Time
+}
+
+// NewAPIKey creates a validated key without a secret; the caller sets
+// SecretHash.
+func NewAPIKey(ownerID uuid.UUID, name string, env APIKeyEnvironment, scopes []string) (*APIKey, error) {
+	prefix := make([]byte, 8)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, err
+	}
+
+	k := &APIKey{
+		ID:          NewID(),
+		OwnerID:     ownerID,
+		Name:        strings.TrimSpace(name),
+		Environment: env,
+		Prefix:      hex.EncodeToString(prefix),
+		Scopes:      normalizeScopes(scopes),
+		CreatedAt:   Now(),
+	}
+
+	if err := k.Validate(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func (k *APIKey) Validate() error {
+	var errs ValidationErrors
+
+	if k.Name == "" {
+		errs = append(errs, ValidationError{Field: "name", Message: "required"})
+	} else if len(k.Name) > 100 {
+		errs = append(errs, ValidationError{Field: "name", Message: "must be at most 100 characters"})
+	}
+
+	if !k.Environment.Valid() {
+		errs = append(errs, ValidationError{Field: "environment", Message: "must be sandbox or production"})
+	}
+
+	if err := validateScopes(k.Scopes); err != nil {
+		errs = append(errs, *err)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Token returns the raw key for secret.
+func (k *APIKey) Token(secret string) string {
+	return "ak_" + k.Environment.apiKeyTag() + "_" + k.Prefix + "_" + secret
+}
+
+// IsRevoked reports whether the key was revoked.
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+// Revoke marks the key as revoked.
+func (k *APIKey) Revoke() {
+	if k.RevokedAt == nil {
+		now := Now()
+		k.RevokedAt = &now
+	}
+}
+
+// ParseAPIKey splits a raw key into its prefix and secret. ok is false if
+// raw doesn't look like an API key.
+func ParseAPIKey(raw string) (prefix, secret string, ok bool) {
+	parts := strings.SplitN(raw, "_", 4)
+	if len(parts) != 4 || parts[0] != "ak" || (parts[1] != "test" && parts[1] != "live") {
+		return "", "", false
+	}
+	if parts[2] == "" || parts[3] == "" {
+		return "", "", false
+	}
+	return parts[2], parts[3], true
+}
+
+// IsAPIKey reports whether s has the shape of a raw API key, as opposed to
+// an access token.
+func IsAPIKey(s string) bool {
+	return strings.HasPrefix(s, "ak_")
+}
+
+// APIKeyUsage is the number of requests made with a key on one day (UTC).
+type APIKeyUsage struct {
+	Day      time.Time
+	Requests int64
+}
+
+// validateScopes requires at least one scope, each in resource:action form.
+func validateScopes(scopes []string) *ValidationError {
+	if len(scopes) == 0 {
+		return &ValidationError{Field: "scopes", Message: "at least one scope is required"}
+	}
+	for _, s := range scopes {
+		resource, action, ok := strings.Cut(s, ":")
+		if !ok || resource == "" || action == "" {
+			return &ValidationError{Field: "scopes", Message: "scopes must be resource:action"}
+		}
+	}
+	return nil
+}
+
+func normalizeScopes(scopes []string) []string {
+	result := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s != "" && !slices.Contains(result, s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -0,0 +1,204 @@
+package domain
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// AttributeType is the type of value a custom user attribute holds.
+type AttributeType string
+
+const (
+	AttributeTypeString     AttributeType = "string"
+	AttributeTypeNumber     AttributeType = "number"
+	AttributeTypeBoolean    AttributeType = "boolean"
+	AttributeTypeStringList AttributeType = "string_list"
+)
+
+// Valid returns true if the AttributeType is recognized.
+func (t AttributeType) Valid() bool {
+	switch t {
+	case AttributeTypeString, AttributeTypeNumber, AttributeTypeBoolean, AttributeTypeStringList:
+		return true
+	}
+	return false
+}
+
+// Limits on attribute values, so attributes stay small enough to load with
+// every user.
+const (
+	MaxAttributeStringLength = 1024
+	MaxAttributeListLength   = 100
+)
+
+var attributeKeyRegex = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// AttributeDefinition registers a custom attribute key and the type of its
+// values. Users can only hold attributes that have a definition.
+type AttributeDefinition struct {
+	Key         string
+	Type        AttributeType
+	Description string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// NewAttributeDefinition creates a validated attribute definition.
+func NewAttributeDefinition(key string, attrType AttributeType, description string) (*AttributeDefinition, error) {
+	d := &AttributeDefinition{
+		Key:         strings.TrimSpace(key),
+		Type:        attrType,
+		Description: strings.TrimSpace(description),
+		CreatedAt:   Now(),
+		UpdatedAt:   Now(),
+	}
+
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *AttributeDefinition) Validate() error {
+	var errs ValidationErrors
+
+	if d.Key == "" {
+		errs = append(errs, ValidationError{Field: "key", Message: "required"})
+	} else if !attributeKeyRegex.MatchString(d.Key) {
+		errs = append(errs, ValidationError{
+			Field:   "key",
+			Message: "must start with a lowercase letter and contain only lowercase letters, digits and underscores (max 64)",
+		})
+	}
+
+	if !d.Type.Valid() {
+		errs = append(errs, ValidationError{Field: "type", Message: "must be one of string, number, boolean, string_list"})
+	}
+
+	if len(d.Description) > 500 {
+		errs = append(errs, ValidationError{Field: "description", Message: "must be at most 500 characters"})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// CheckValue validates a value decoded from JSON against the definition's
+// type. String lists are normalized to []any, the shape they have when read
+// back from storage.
+func (d *AttributeDefinition) CheckValue(value any) (any, *ValidationError) {
+	field := "attributes." + d.Key
+
+	switch d.Type {
+	case AttributeTypeString:
+		s, ok := value.(string)
+		if !ok {
+			return nil, &ValidationError{Field: field, Message: "must be a string"}
+		}
+		if utf8.RuneCountInString(s) > MaxAttributeStringLength {
+			return nil, &ValidationError{Field: field, Message: "must be at most 1024 characters"}
+		}
+		return s, nil
+
+	case AttributeTypeNumber:
+		switch n := value.(type) {
+		case float64:
+			return n, nil
+		case int:
+			return float64(n), nil
+		case int64:
+			return float64(n), nil
+		}
+		return nil, &ValidationError{Field: field, Message: "must be a number"}
+
+	case AttributeTypeBoolean:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, &ValidationError{Field: field, Message: "must be a boolean"}
+		}
+		return b, nil
+
+	case AttributeTypeStringList:
+		var list []string
+		switch v := value.(type) {
+		case []string:
+			list = v
+		case []any:
+			list = make([]string, 0, len(v))
+			for _, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return nil, &ValidationError{Field: field, Message: "must be a list of strings"}
+				}
+				list = append(list, s)
+			}
+		default:
+			return nil, &ValidationError{Field: field, Message: "must be a list of strings"}
+		}
+		if len(list) > MaxAttributeListLength {
+			return nil, &ValidationError{Field: field, Message: "must have at most 100 items"}
+		}
+		for _, s := range list {
+			if utf8.RuneCountInString(s) > MaxAttributeStringLength {
+				return nil, &ValidationError{Field: field, Message: "items must be at most 1024 characters"}
+			}
+		}
+		out := make([]any, len(list))
+		for i, s := range list {
+			out[i] = s
+		}
+		return out, nil
+	}
+
+	return nil, &ValidationError{Field: field, Message: "unsupported attribute type"}
+}
+
+// PatchAttributes applies a partial update to the user's attributes: a nil
+// value removes the key, any other value replaces it. Every key must be in
+// definitions. It returns the keys that changed.
+func (u *User) PatchAttributes(patch map[string]any, definitions map[string]AttributeDefinition) ([]string, error) {
+	var errs ValidationErrors
+	updated := make(map[string]any, len(u.Attributes)+len(patch))
+	for k, v := range u.Attributes {
+		updated[k] = v
+	}
+
+	var changed []string
+	for key, value := range patch {
+		if value == nil {
+			if _, ok := updated[key]; ok {
+				delete(updated, key)
+				changed = append(changed, key)
+			}
+			continue
+		}
+
+		def, ok := definitions[key]
+		if !ok {
+			errs = append(errs, ValidationError{Field: "attributes." + key, Message: "unknown attribute"})
+			continue
+		}
+
+		v, verr := def.CheckValue(value)
+		if verr != nil {
+			errs = append(errs, *verr)
+			continue
+		}
+		updated[key] = v
+		changed = append(changed, key)
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	slices.Sort(changed)
+	u.Attributes = updated
+	u.UpdatedAt = Now()
+	return changed, nil
+}
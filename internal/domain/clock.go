@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/mvaleed/aegis/internal/clock"
+)
+
+var currentClock atomic.Pointer[clock.Clock]
+
+// SetClock replaces the clock that entities, and the services built on
+// them, read the time from. Tests use a clock.Fake to control expiry.
+func SetClock(c clock.Clock) {
+	currentClock.Store(&c)
+}
+
+// Now returns the current time in UTC according to the clock set with
+// SetClock, or the system clock.
+func Now() time.Time {
+	if c := currentClock.Load(); c != nil {
+		return (*c).Now().UTC()
+	}
+	return time.Now().UTC()
+}
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Consent records which scopes a customer lets a partner use on their
+// data. Partners are only allowed to act on a customer within the scopes
+// of the customer's active consent, checked on every request, so revoking
+// it takes effect for tokens the partner already holds.
+type Consent struct {
+	ID         uuid.UUID
+	CustomerID uuid.UUID
+	PartnerID  uuid.UUID
+	Scopes     []string // Permissions in resource:action form
+	GrantedAt  time.Time
+	UpdatedAt  time.Time
+	RevokedAt  *time.Time
+}
+
+// NewConsent creates a validated consent of customerID for partnerID.
+func NewConsent(customerID, partnerID uuid.UUID, scopes []string) (*Consent, error) {
+	now := Now()
+	c := &Consent{
+		ID:         NewID(),
+		CustomerID: customerID,
+		PartnerID:  partnerID,
+		Scopes:     normalizeScopes(scopes),
+		GrantedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Consent) Validate() error {
+	var errs ValidationErrors
+
+	if c.CustomerID == c.PartnerID {
+		errs = append(errs, ValidationError{Field: "partner_id", Message: "can't consent to yourself"})
+	}
+	if err := validateScopes(c.Scopes); err != nil {
+		errs = append(errs, *err)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// SetScopes replaces the consented scopes.
+func (c *Consent) SetScopes(scopes []string) error {
+	normalized := normalizeScopes(scopes)
+	if err := validateScopes(normalized); err != nil {
+		return *err
+	}
+	c.Scopes = normalized
+	c.UpdatedAt = Now()
+	return nil
+}
+
+// Allows reports whether the consent covers scope (resource:action). A
+// consented "resource:*" covers every action on the resource.
+func (c *Consent) Allows(scope string) bool {
+	if c.IsRevoked() {
+		return false
+	}
+	resource, _, _ := strings.Cut(scope, ":")
+	return slices.Contains(c.Scopes, scope) || slices.Contains(c.Scopes, resource+":*")
+}
+
+// IsRevoked reports whether the consent was revoked.
+func (c *Consent) IsRevoked() bool {
+	return c.RevokedAt != nil
+}
+
+// Revoke marks the consent as revoked.
+func (c *Consent) Revoke() {
+	if c.RevokedAt == nil {
+		now := Now()
+		c.RevokedAt = &now
+		c.UpdatedAt = now
+	}
+}
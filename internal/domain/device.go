@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"net"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxDeviceNetworks caps the networks remembered per device. The oldest is
+// dropped first.
+const MaxDeviceNetworks = 20
+
+// KnownDevice is a device a user has signed in from. A login from a device
+// the user hasn't used, or from a network the device hasn't been seen on, is
+// treated as suspicious.
+type KnownDevice struct {
+	ID            uuid.UUID
+	UserID        uuid.UUID
+	Fingerprint   string // Hash of the client's device ID, or of its user agent
+	UserAgent     string
+	LastIPAddress string
+	Networks      []string // See DeviceNetwork
+
+	// Trusted devices can sign in from new networks without confirmation.
+	Trusted bool
+
+	// A device is confirmed once the user has approved a login from it, or
+	// if it was the first device they used. Logins awaiting approval hold
+	// an ActionConfirmLogin token.
+	ConfirmedAt *time.Time
+
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+}
+
+// NewKnownDevice creates an unconfirmed device seen now from ipAddress.
+func NewKnownDevice(userID uuid.UUID, fingerprint, ipAddress, userAgent string) *KnownDevice {
+	now := Now()
+	return &KnownDevice{
+		ID:            NewID(),
+		UserID:        userID,
+		Fingerprint:   fingerprint,
+		UserAgent:     userAgent,
+		LastIPAddress: ipAddress,
+		FirstSeenAt:   now,
+		LastSeenAt:    now,
+	}
+}
+
+// IsConfirmed returns true if the user has approved the device.
+func (d *KnownDevice) IsConfirmed() bool {
+	return d.ConfirmedAt != nil
+}
+
+// HasNetwork returns true if the device has been used from network.
+func (d *KnownDevice) HasNetwork(network string) bool {
+	return slices.Contains(d.Networks, network)
+}
+
+// Seen records a login from the device, adding the IP address's network to
+// the ones it's known on.
+func (d *KnownDevice) Seen(ipAddress, userAgent string) {
+	d.LastIPAddress = ipAddress
+	d.UserAgent = userAgent
+	d.LastSeenAt = Now()
+	d.addNetwork(DeviceNetwork(ipAddress))
+}
+
+// Confirm marks the device as approved by the user, and remembers network,
+// the network of the login they approved, if any.
+func (d *KnownDevice) Confirm(network string) {
+	if d.ConfirmedAt == nil {
+		now := Now()
+		d.ConfirmedAt = &now
+	}
+	d.addNetwork(network)
+}
+
+func (d *KnownDevice) addNetwork(network string) {
+	if network == "" || d.HasNetwork(network) {
+		return
+	}
+	d.Networks = append(d.Networks, network)
+	if len(d.Networks) > MaxDeviceNetworks {
+		d.Networks = d.Networks[len(d.Networks)-MaxDeviceNetworks:]
+	}
+}
+
+// DeviceNetwork returns the network an IP address belongs to: its /24 for
+// IPv4 and /48 for IPv6. Networks stand in for location, since addresses
+// within one usually change between logins from the same place. It returns
+// "" for an unparseable address.
+func DeviceNetwork(ipAddress string) string {
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return ""
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: ip.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
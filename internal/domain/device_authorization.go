@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Errors a device polling for its tokens gets, named after the OAuth 2.0
+// device authorization grant's (RFC 8628) error codes.
+var (
+	ErrAuthorizationPending = errors.New("the user hasn't approved the device yet")
+	ErrSlowDown             = errors.New("the device is polling too often")
+	ErrAccessDenied         = errors.New("the user denied the device")
+	ErrDeviceCodeExpired    = errors.New("the device code expired")
+)
+
+// DeviceAuthorizationStatus is where a device authorization is in the flow.
+type DeviceAuthorizationStatus string
+
+const (
+	DeviceAuthorizationPending  DeviceAuthorizationStatus = "pending"
+	DeviceAuthorizationApproved DeviceAuthorizationStatus = "approved"
+	DeviceAuthorizationDenied   DeviceAuthorizationStatus = "denied"
+)
+
+// DeviceAuthorization is a device, such as a CLI, asking to sign in as
+// whichever user approves it by its user code. The device polls with its
+// device code, of which only a hash is stored, until the user approves or
+// denies it or it expires.
+type DeviceAuthorization struct {
+	ID             uuid.UUID
+	DeviceCodeHash string
+	UserCode       string // Normalized; see NormalizeUserCode
+	ClientID       string // What the device says it is; there's no client registry
+	Scope          string // Normalized; see NormalizeScope
+	Status         DeviceAuthorizationStatus
+	UserID         *uuid.UUID // Who approved or denied it
+	Interval       time.Duration
+	LastPolledAt   *time.Time
+	ExpiresAt      time.Time
+	CreatedAt      time.Time
+}
+
+// NewDeviceAuthorization creates a pending authorization with a fresh user
+// code, valid for ttl, that the device may poll every interval.
+func NewDeviceAuthorization(clientID, scope, deviceCodeHash string, ttl, interval time.Duration) (*DeviceAuthorization, error) {
+	a := &DeviceAuthorization{
+		ID:             NewID(),
+		DeviceCodeHash: deviceCodeHash,
+		UserCode:       newUserCode(),
+		ClientID:       strings.TrimSpace(clientID),
+		Scope:          scope,
+		Status:         DeviceAuthorizationPending,
+		Interval:       interval,
+		ExpiresAt:      Now().Add(ttl),
+		CreatedAt:      Now(),
+	}
+
+	if a.ClientID == "" {
+		return nil, ValidationError{Field: "client_id", Message: "required"}
+	}
+	if len(a.ClientID) > 100 {
+		return nil, ValidationError{Field: "client_id", Message: "must be at most 100 characters"}
+	}
+	return a, nil
+}
+
+func (a *DeviceAuthorization) IsExpired() bool {
+	return Now().After(a.ExpiresAt)
+}
+
+// Decide records the user's approval or denial. Only pending, unexpired
+// authorizations can be decided.
+func (a *DeviceAuthorization) Decide(userID uuid.UUID, approve bool) error {
+	if a.Status != DeviceAuthorizationPending || a.IsExpired() {
+		return ErrNotFound
+	}
+	a.UserID = &userID
+	a.Status = DeviceAuthorizationDenied
+	if approve {
+		a.Status = DeviceAuthorizationApproved
+	}
+	return nil
+}
+
+// Poll records a poll by the device. Polling sooner than the interval
+// returns ErrSlowDown and adds 5 seconds to it, as RFC 8628 asks.
+func (a *DeviceAuthorization) Poll() error {
+	now := Now()
+	last := a.LastPolledAt
+	a.LastPolledAt = &now
+	if last != nil && now.Sub(*last) < a.Interval {
+		a.Interval += 5 * time.Second
+		return ErrSlowDown
+	}
+	return nil
+}
+
+// userCodeAlphabet has no vowels, so codes don't spell words, and no
+// characters easily mistaken for one another.
+const userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+// newUserCode returns 8 random characters of userCodeAlphabet, about 34
+// bits.
+func newUserCode() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	for i := range b {
+		// 256 isn't a multiple of 20, so the first 16 letters are very
+		// slightly likelier; it doesn't matter for a short-lived code
+		b[i] = userCodeAlphabet[int(b[i])%len(userCodeAlphabet)]
+	}
+	return string(b)
+}
+
+// NormalizeUserCode uppercases a user code as typed and drops the hyphen
+// and spaces it may be written with.
+func NormalizeUserCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(code)))
+}
+
+// FormatUserCode writes a normalized user code as shown to users,
+// XXXX-XXXX.
+func FormatUserCode(code string) string {
+	if len(code) != 8 {
+		return code
+	}
+	return code[:4] + "-" + code[4:]
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserSummary is the denormalized, read-only view of a user used by admin
+// listing and reporting. It is eventually consistent with User.
+type UserSummary struct {
+	ID              uuid.UUID
+	Email           string
+	Phone           *string
+	Username        string
+	FullName        string
+	Type            UserType
+	Status          UserStatus
+	EmailVerified   bool
+	PhoneVerified   bool
+	RoleNames       []string
+	OrganizationIDs []uuid.UUID
+	LastLoginAt     *time.Time
+	ActiveSessions  int
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       *time.Time
+	RefreshedAt     time.Time // When the row was last rebuilt from source tables
+}
@@ -0,0 +1,45 @@
+package domain
+
+import "context"
+
+// Environment is the data namespace a request works in. Sandbox users,
+// roles and tokens are stored apart from production ones and never show
+// up in production listings or events.
+type Environment string
+
+const (
+	EnvironmentProduction Environment = "production"
+	EnvironmentSandbox    Environment = "sandbox"
+)
+
+// ParseEnvironment parses s, treating an empty string as production.
+func ParseEnvironment(s string) (Environment, bool) {
+	switch Environment(s) {
+	case "", EnvironmentProduction:
+		return EnvironmentProduction, true
+	case EnvironmentSandbox:
+		return EnvironmentSandbox, true
+	}
+	return "", false
+}
+
+// IsSandbox reports whether e is the sandbox.
+func (e Environment) IsSandbox() bool {
+	return e == EnvironmentSandbox
+}
+
+type environmentKey struct{}
+
+// WithEnvironment returns a context whose storage and events belong to env.
+func WithEnvironment(ctx context.Context, env Environment) context.Context {
+	return context.WithValue(ctx, environmentKey{}, env)
+}
+
+// EnvironmentFromContext returns the environment set by WithEnvironment,
+// or production.
+func EnvironmentFromContext(ctx context.Context) Environment {
+	if env, ok := ctx.Value(environmentKey{}).(Environment); ok {
+		return env
+	}
+	return EnvironmentProduction
+}
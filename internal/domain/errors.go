@@ -21,6 +21,17 @@ var (
 	ErrVersionMismatch        = errors.New("version mismatch")
 	ErrInvalidStatus          = errors.New("invalid status")
 	ErrConcurrentModification = errors.New("ErrConcurrentModification")
+	ErrChallengeRequired      = errors.New("additional verification required")
+	ErrIdempotencyKeyReused   = errors.New("idempotency key was used for a different request")
+	ErrRequestInProgress      = errors.New("a request with this idempotency key is in progress")
+
+	// ErrDeviceConfirmationRequired is returned when a login from a new
+	// device or network must be confirmed by email before tokens are issued.
+	ErrDeviceConfirmationRequired = errors.New("login from a new device must be confirmed")
+
+	// ErrTooManySessions is returned when a sign-in would take a user past
+	// their limit of active sessions and the policy is to reject it.
+	ErrTooManySessions = errors.New("too many active sessions")
 )
 
 // ValidationError represents one or more validation failures.
@@ -33,6 +44,11 @@ func (e ValidationError) Error() string {
 	return fmt.Sprintf("validation error on %s: %s", e.Field, e.Message)
 }
 
+// Is makes validation errors match ErrInvalidInput.
+func (e ValidationError) Is(target error) bool {
+	return target == ErrInvalidInput
+}
+
 // ValidationErrors is a collection of validation errors.
 type ValidationErrors []ValidationError
 
@@ -42,3 +58,23 @@ func (e ValidationErrors) Error() string {
 	}
 	return fmt.Sprintf("%d validation errors", len(e))
 }
+
+// Is makes validation errors match ErrInvalidInput.
+func (e ValidationErrors) Is(target error) bool {
+	return target == ErrInvalidInput
+}
+
+// UsernameTakenError is returned when a username is held by another user,
+// with available alternatives the caller can offer instead.
+type UsernameTakenError struct {
+	Username    string
+	Suggestions []string
+}
+
+func (e UsernameTakenError) Error() string {
+	return fmt.Sprintf("username %q already taken", e.Username)
+}
+
+func (e UsernameTakenError) Unwrap() error {
+	return ErrAlreadyExists
+}
@@ -14,6 +14,21 @@ type Event struct {
 	Timestamp time.Time
 	UserID    uuid.UUID
 	Data      map[string]any
+
+	// SchemaVersion is the version of Data's layout for Type, from the
+	// schema registry (see EventSchemas).
+	SchemaVersion int
+
+	// CorrelationID ties the event to the request that caused it, and
+	// TraceParent/TraceState to its trace (W3C trace context). They are
+	// filled in when the event is published.
+	CorrelationID string
+	TraceParent   string
+	TraceState    string
+
+	// Environment is where the event happened; sandbox events only reach
+	// sandbox subscribers and webhooks, never the broker.
+	Environment Environment
 }
 
 // Event type constants
@@ -30,8 +45,56 @@ const (
 	EventUserLoggedOut     = "user.logged_out"
 	EventUserRoleAssigned  = "user.role_assigned"
 	EventUserRoleRemoved   = "user.role_removed"
+	EventUserRoleExpired   = "user.role_expired"
 	EventPasswordChanged   = "user.password_changed"
 	EventPasswordReset     = "user.password_reset"
+	EventUserRiskFlagged   = "user.risk_flagged"
+	EventUserInvited       = "user.invited"
+	EventInvitationRevoked = "user.invitation_revoked"
+
+	EventUserAttributesUpdated = "user.attributes_updated"
+
+	EventUserEmailChangeRequested = "user.email_change_requested"
+	EventUserEmailChanged         = "user.email_changed"
+	EventUserUsernameChanged      = "user.username_changed"
+	EventUserDeletionScheduled    = "user.deletion_scheduled"
+	EventUserDeletionCancelled    = "user.deletion_cancelled"
+
+	EventUserLoginNewDevice = "user.login_new_device"
+
+	EventOrganizationMemberAdded   = "organization.member_added"
+	EventOrganizationMemberRemoved = "organization.member_removed"
+
+	EventGroupMemberAdded   = "group.member_added"
+	EventGroupMemberRemoved = "group.member_removed"
+
+	EventProfileCreated  = "profile.created"
+	EventProfileDetached = "profile.detached"
+
+	EventConsentGranted = "consent.granted"
+	EventConsentRevoked = "consent.revoked"
+
+	EventImpersonationStarted = "impersonation.started"
+	EventImpersonationEnded   = "impersonation.ended"
+
+	EventTokenExchanged     = "token.exchanged"
+	EventRefreshTokenReused = "token.refresh_reused"
+
+	// Role and permission catalog changes carry no user
+	EventRoleCreated       = "role.created"
+	EventRoleUpdated       = "role.updated" // Renamed, or its permissions changed
+	EventRoleDeleted       = "role.deleted"
+	EventPermissionCreated = "permission.created"
+	EventPermissionDeleted = "permission.deleted"
+
+	// Sent to one webhook on request, to test its receiver
+	EventWebhookTest = "webhook.test"
+)
+
+// Event types consumed from other services
+const (
+	// EventBillingSubscriptionCancelled suspends the event's user.
+	EventBillingSubscriptionCancelled = "billing.subscription_cancelled"
 )
 
 // NewEvent creates a new domain event.
@@ -40,19 +103,57 @@ func NewEvent(eventType string, userID uuid.UUID, data map[string]any) Event {
 		data = make(map[string]any)
 	}
 	return Event{
-		ID:        uuid.New(),
-		Type:      eventType,
-		Timestamp: time.Now().UTC(),
-		UserID:    userID,
-		Data:      data,
+		ID:            NewID(),
+		Type:          eventType,
+		Timestamp:     Now(),
+		UserID:        userID,
+		Data:          data,
+		SchemaVersion: EventSchemaVersion(eventType),
 	}
 }
 
 func UserCreatedEvent(u *User) Event {
 	return NewEvent(EventUserCreated, u.ID, map[string]any{
+		"email":      u.Email,
+		"username":   u.Username,
+		"user_type":  string(u.Type),
+		"attributes": u.Attributes,
+	})
+}
+
+// UserAttributesUpdatedEvent carries the changed keys and the full attribute
+// set after the change.
+func UserAttributesUpdatedEvent(u *User, changed []string) Event {
+	return NewEvent(EventUserAttributesUpdated, u.ID, map[string]any{
+		"changed":    changed,
+		"attributes": u.Attributes,
+	})
+}
+
+// UserEmailChangeRequestedEvent is published when a user asks to move to a
+// new address, before they confirm it.
+func UserEmailChangeRequestedEvent(u *User, newEmail string) Event {
+	return NewEvent(EventUserEmailChangeRequested, u.ID, map[string]any{
 		"email":     u.Email,
-		"username":  u.Username,
-		"user_type": string(u.Type),
+		"new_email": newEmail,
+	})
+}
+
+// UserEmailChangedEvent is published once a new address is confirmed and
+// applied.
+func UserEmailChangedEvent(u *User, oldEmail string) Event {
+	return NewEvent(EventUserEmailChanged, u.ID, map[string]any{
+		"old_email": oldEmail,
+		"email":     u.Email,
+	})
+}
+
+// UserUsernameChangedEvent is published when a user moves to a new
+// username; the former one stays reserved for them for a while.
+func UserUsernameChangedEvent(u *User, formerUsername string) Event {
+	return NewEvent(EventUserUsernameChanged, u.ID, map[string]any{
+		"former_username": formerUsername,
+		"username":        u.Username,
 	})
 }
 
@@ -82,9 +183,16 @@ func UserLoggedInEvent(userID uuid.UUID, ipAddress, userAgent string) Event {
 	})
 }
 
-func RoleAssignedEvent(userID uuid.UUID, roleName string) Event {
+// RoleAssignedEvent carries the assignment's expiry, or nil for one held
+// until removed.
+func RoleAssignedEvent(userID uuid.UUID, roleName string, expiresAt *time.Time) Event {
+	var expires any
+	if expiresAt != nil {
+		expires = expiresAt.Format(time.RFC3339)
+	}
 	return NewEvent(EventUserRoleAssigned, userID, map[string]any{
-		"role": roleName,
+		"role":       roleName,
+		"expires_at": expires,
 	})
 }
 
@@ -93,3 +201,192 @@ func RoleRemovedEvent(userID uuid.UUID, roleName string) Event {
 		"role": roleName,
 	})
 }
+
+// RoleExpiredEvent is published when a time-bound assignment lapses and is
+// removed.
+func RoleExpiredEvent(e RoleExpiry) Event {
+	return NewEvent(EventUserRoleExpired, e.UserID, map[string]any{
+		"role_id":    e.RoleID.String(),
+		"role":       e.RoleName,
+		"expires_at": e.ExpiresAt.Format(time.RFC3339),
+	})
+}
+
+// RoleChangedEvent is published with EventRoleCreated, EventRoleUpdated or
+// EventRoleDeleted.
+func RoleChangedEvent(eventType string, role *Role) Event {
+	var orgID any
+	if role.OrganizationID != nil {
+		orgID = role.OrganizationID.String()
+	}
+	return NewEvent(eventType, uuid.Nil, map[string]any{
+		"role_id":         role.ID.String(),
+		"role":            role.Name,
+		"organization_id": orgID,
+	})
+}
+
+// PermissionChangedEvent is published with EventPermissionCreated or
+// EventPermissionDeleted.
+func PermissionChangedEvent(eventType string, perm *Permission) Event {
+	return NewEvent(eventType, uuid.Nil, map[string]any{
+		"permission_id": perm.ID.String(),
+		"permission":    perm.String(),
+	})
+}
+
+func OrganizationMemberAddedEvent(userID uuid.UUID, org *Organization) Event {
+	return NewEvent(EventOrganizationMemberAdded, userID, map[string]any{
+		"organization_id":   org.ID.String(),
+		"organization_slug": org.Slug,
+	})
+}
+
+func OrganizationMemberRemovedEvent(userID uuid.UUID, org *Organization) Event {
+	return NewEvent(EventOrganizationMemberRemoved, userID, map[string]any{
+		"organization_id":   org.ID.String(),
+		"organization_slug": org.Slug,
+	})
+}
+
+// UserInvitedEvent is published when an invitation is created or resent.
+// It never carries the token.
+func UserInvitedEvent(inv *Invitation) Event {
+	return NewEvent(EventUserInvited, inv.UserID, map[string]any{
+		"invitation_id": inv.ID.String(),
+		"email":         inv.Email,
+		"invited_by":    inv.InvitedBy.String(),
+		"expires_at":    inv.ExpiresAt.Format(time.RFC3339),
+		"send_count":    inv.SendCount,
+	})
+}
+
+func InvitationRevokedEvent(inv *Invitation) Event {
+	return NewEvent(EventInvitationRevoked, inv.UserID, map[string]any{
+		"invitation_id": inv.ID.String(),
+		"email":         inv.Email,
+	})
+}
+
+func GroupMemberAddedEvent(userID uuid.UUID, group *Group) Event {
+	return NewEvent(EventGroupMemberAdded, userID, map[string]any{
+		"group_id":   group.ID.String(),
+		"group_name": group.Name,
+	})
+}
+
+func GroupMemberRemovedEvent(userID uuid.UUID, group *Group) Event {
+	return NewEvent(EventGroupMemberRemoved, userID, map[string]any{
+		"group_id":   group.ID.String(),
+		"group_name": group.Name,
+	})
+}
+
+func ProfileCreatedEvent(p *Profile) Event {
+	return NewEvent(EventProfileCreated, p.UserID, map[string]any{
+		"profile_id":   p.ID.String(),
+		"profile_type": string(p.Type),
+	})
+}
+
+func ProfileDetachedEvent(p *Profile) Event {
+	return NewEvent(EventProfileDetached, p.UserID, map[string]any{
+		"profile_id":   p.ID.String(),
+		"profile_type": string(p.Type),
+	})
+}
+
+// ConsentGrantedEvent is published for the customer when a consent is
+// given or its scopes change.
+func ConsentGrantedEvent(c *Consent) Event {
+	return NewEvent(EventConsentGranted, c.CustomerID, map[string]any{
+		"consent_id": c.ID.String(),
+		"partner_id": c.PartnerID.String(),
+		"scopes":     c.Scopes,
+	})
+}
+
+func ConsentRevokedEvent(c *Consent) Event {
+	return NewEvent(EventConsentRevoked, c.CustomerID, map[string]any{
+		"consent_id": c.ID.String(),
+		"partner_id": c.PartnerID.String(),
+	})
+}
+
+// ImpersonationStartedEvent is published for the impersonated user.
+func ImpersonationStartedEvent(s *ImpersonationSession) Event {
+	return NewEvent(EventImpersonationStarted, s.TargetID, map[string]any{
+		"session_id": s.ID.String(),
+		"actor_id":   s.ActorID.String(),
+		"reason":     s.Reason,
+		"ip_address": s.IPAddress,
+		"user_agent": s.UserAgent,
+		"expires_at": s.ExpiresAt.Format(time.RFC3339),
+	})
+}
+
+// ImpersonationEndedEvent is published for the impersonated user.
+func ImpersonationEndedEvent(s *ImpersonationSession, endedBy uuid.UUID) Event {
+	return NewEvent(EventImpersonationEnded, s.TargetID, map[string]any{
+		"session_id": s.ID.String(),
+		"actor_id":   s.ActorID.String(),
+		"ended_by":   endedBy.String(),
+	})
+}
+
+// TokenExchangedEvent is published for the user an OAuth client got a
+// delegation token for.
+func TokenExchangedEvent(e *TokenExchange) Event {
+	return NewEvent(EventTokenExchanged, e.UserID, map[string]any{
+		"exchange_id": e.ID.String(),
+		"client_id":   e.ClientID.String(),
+		"audience":    e.Audience,
+		"permissions": e.Permissions,
+		"ip_address":  e.IPAddress,
+		"expires_at":  e.ExpiresAt.Format(time.RFC3339),
+	})
+}
+
+// RefreshTokenReusedEvent is published when a revoked refresh token is
+// presented again, from ipAddress; revoked counts the tokens of its family
+// that were still active and now aren't.
+func RefreshTokenReusedEvent(t *RefreshToken, ipAddress string, revoked int64) Event {
+	return NewEvent(EventRefreshTokenReused, t.UserID, map[string]any{
+		"token_id":   t.ID.String(),
+		"family_id":  t.FamilyID.String(),
+		"ip_address": ipAddress,
+		"revoked":    revoked,
+	})
+}
+
+// LoginNewDeviceEvent is published when a user signs in from a device, or a
+// network, they haven't used before. reason is "new_device" or
+// "new_network".
+func LoginNewDeviceEvent(d *KnownDevice, ipAddress, reason string, confirmationRequired bool) Event {
+	return NewEvent(EventUserLoginNewDevice, d.UserID, map[string]any{
+		"device_id":             d.ID.String(),
+		"reason":                reason,
+		"ip_address":            ipAddress,
+		"network":               DeviceNetwork(ipAddress),
+		"user_agent":            d.UserAgent,
+		"confirmation_required": confirmationRequired,
+	})
+}
+
+func RiskFlaggedEvent(userID uuid.UUID, operation, action string, score int, reasons []string) Event {
+	return NewEvent(EventUserRiskFlagged, userID, map[string]any{
+		"operation": operation,
+		"action":    action,
+		"score":     score,
+		"reasons":   reasons,
+	})
+}
+
+// WebhookTestEvent is the event a webhook is sent to test its receiver. It
+// is about no user.
+func WebhookTestEvent(w *Webhook) Event {
+	return NewEvent(EventWebhookTest, uuid.Nil, map[string]any{
+		"webhook_id": w.ID.String(),
+		"url":        w.URL,
+	})
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+)
+
+// EventSchema describes the Data of one event type. Version is bumped
+// whenever a field is removed, renamed or changes meaning; adding a field
+// doesn't change it, so consumers should ignore fields they don't know.
+type EventSchema struct {
+	Type    string
+	Version int
+	Fields  []string
+}
+
+// eventSchemas is the registry of event types this service publishes. A
+// new type is listed here with the keys it puts in Data.
+var eventSchemas = map[string]EventSchema{}
+
+func registerEventSchema(eventType string, version int, fields ...string) {
+	eventSchemas[eventType] = EventSchema{Type: eventType, Version: version, Fields: fields}
+}
+
+func init() {
+	registerEventSchema(EventUserCreated, 1, "email", "username", "user_type", "attributes", "email_disposable", "provisioned")
+	registerEventSchema(EventUserUpdated, 1)
+	registerEventSchema(EventUserDeleted, 1)
+	registerEventSchema(EventUserActivated, 1, "email", "username")
+	registerEventSchema(EventUserSuspended, 1, "email", "username", "reason")
+	registerEventSchema(EventUserDeactivated, 1)
+	registerEventSchema(EventUserEmailVerified, 1)
+	registerEventSchema(EventUserPhoneVerified, 1)
+	registerEventSchema(EventUserLoggedIn, 1, "ip_address", "user_agent")
+	registerEventSchema(EventUserLoggedOut, 1)
+	registerEventSchema(EventUserRoleAssigned, 1, "role", "expires_at")
+	registerEventSchema(EventUserRoleRemoved, 1, "role")
+	registerEventSchema(EventUserRoleExpired, 1, "role_id", "role", "expires_at")
+	registerEventSchema(EventPasswordChanged, 1)
+	registerEventSchema(EventPasswordReset, 1)
+	registerEventSchema(EventUserRiskFlagged, 1, "operation", "action", "score", "reasons")
+	registerEventSchema(EventUserInvited, 1, "invitation_id", "email", "invited_by", "expires_at", "send_count")
+	registerEventSchema(EventInvitationRevoked, 1, "invitation_id", "email")
+	registerEventSchema(EventUserAttributesUpdated, 1, "changed", "attributes")
+	registerEventSchema(EventUserEmailChangeRequested, 1, "email", "new_email")
+	registerEventSchema(EventUserEmailChanged, 1, "old_email", "email")
+	registerEventSchema(EventUserUsernameChanged, 1, "former_username", "username")
+	registerEventSchema(EventUserDeletionScheduled, 1, "delete_after")
+	registerEventSchema(EventUserDeletionCancelled, 1)
+	registerEventSchema(EventUserLoginNewDevice, 1, "device_id", "reason", "ip_address", "network", "user_agent", "confirmation_required")
+
+	registerEventSchema(EventOrganizationMemberAdded, 1, "organization_id", "organization_slug")
+	registerEventSchema(EventOrganizationMemberRemoved, 1, "organization_id", "organization_slug")
+
+	registerEventSchema(EventGroupMemberAdded, 1, "group_id", "group_name")
+	registerEventSchema(EventGroupMemberRemoved, 1, "group_id", "group_name")
+
+	registerEventSchema(EventProfileCreated, 1, "profile_id", "profile_type")
+	registerEventSchema(EventProfileDetached, 1, "profile_id", "profile_type")
+
+	registerEventSchema(EventConsentGranted, 1, "consent_id", "partner_id", "scopes")
+	registerEventSchema(EventConsentRevoked, 1, "consent_id", "partner_id")
+
+	registerEventSchema(EventImpersonationStarted, 1, "session_id", "actor_id", "reason", "ip_address", "user_agent", "expires_at")
+	registerEventSchema(EventImpersonationEnded, 1, "session_id", "actor_id", "ended_by")
+
+	registerEventSchema(EventTokenExchanged, 1, "exchange_id", "client_id", "audience", "permissions", "ip_address", "expires_at")
+	registerEventSchema(EventRefreshTokenReused, 1, "token_id", "family_id", "ip_address", "revoked")
+
+	registerEventSchema(EventRoleCreated, 1, "role_id", "role", "organization_id")
+	registerEventSchema(EventRoleUpdated, 1, "role_id", "role", "organization_id")
+	registerEventSchema(EventRoleDeleted, 1, "role_id", "role", "organization_id")
+	registerEventSchema(EventPermissionCreated, 1, "permission_id", "permission")
+	registerEventSchema(EventPermissionDeleted, 1, "permission_id", "permission")
+
+	registerEventSchema(EventWebhookTest, 1, "webhook_id", "url")
+}
+
+// EventSchemaVersion returns the current schema version of eventType, or 1
+// for a type missing from the registry.
+func EventSchemaVersion(eventType string) int {
+	if schema, ok := eventSchemas[eventType]; ok {
+		return schema.Version
+	}
+	return 1
+}
+
+// EventSchemas returns the registry, ordered by type.
+func EventSchemas() []EventSchema {
+	schemas := make([]EventSchema, 0, len(eventSchemas))
+	for _, schema := range eventSchemas {
+		schemas = append(schemas, schema)
+	}
+	slices.SortFunc(schemas, func(a, b EventSchema) int {
+		return strings.Compare(a.Type, b.Type)
+	})
+	return schemas
+}
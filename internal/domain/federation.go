@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LDAPIdentityProvider is the FederatedIdentity provider of directory
+// entries.
+const LDAPIdentityProvider = "ldap"
+
+// SAMLIdentityProvider returns the FederatedIdentity provider of the SAML
+// identity provider with the ID.
+func SAMLIdentityProvider(id uuid.UUID) string {
+	return "saml:" + id.String()
+}
+
+// FederatedIdentity links an external identity, a directory entry or the
+// subject of an identity provider's assertions, to the account it signs in
+// to. Sign-ins follow links: an account that merely has the asserted email
+// isn't signed in to until it's linked.
+type FederatedIdentity struct {
+	ID        uuid.UUID
+	Provider  string // LDAPIdentityProvider or a SAMLIdentityProvider
+	Subject   string // The entry's DN or the assertion's NameID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+// NewFederatedIdentity creates a validated link of provider's subject to
+// a user.
+func NewFederatedIdentity(provider, subject string, userID uuid.UUID) (*FederatedIdentity, error) {
+	if provider == LDAPIdentityProvider {
+		subject = NormalizeDN(subject)
+	}
+	fi := &FederatedIdentity{
+		ID:        NewID(),
+		Provider:  provider,
+		Subject:   strings.TrimSpace(subject),
+		UserID:    userID,
+		CreatedAt: Now(),
+	}
+
+	if fi.Subject == "" {
+		return nil, ValidationError{Field: "subject", Message: "required"}
+	}
+	if len(fi.Subject) > 1000 {
+		return nil, ValidationError{Field: "subject", Message: "must be at most 1000 characters"}
+	}
+	if fi.UserID == uuid.Nil {
+		return nil, ValidationError{Field: "user_id", Message: "required"}
+	}
+	return fi, nil
+}
+
+// EmailInDomains reports whether email's domain is one of domains. Domains
+// are matched exactly, not by their subdomains.
+func EmailInDomains(email string, domains []string) bool {
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[at+1:])
+	for _, d := range domains {
+		if strings.EqualFold(strings.TrimPrefix(strings.TrimSpace(d), "@"), domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeEmailDomains lowercases and trims domains, dropping empty ones
+// and a leading "@", and validates them.
+func NormalizeEmailDomains(field string, domains []string) ([]string, error) {
+	normalized := make([]string, 0, len(domains))
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(d), "@"))
+		if d == "" {
+			continue
+		}
+		if len(d) > 253 || strings.ContainsAny(d, "@ /") || !strings.Contains(d, ".") {
+			return nil, ValidationError{Field: field, Message: "invalid domain " + d}
+		}
+		normalized = append(normalized, d)
+	}
+	return normalized, nil
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Group is a named set of users. Roles assigned to a group are inherited by
+// every member, so a user's effective roles are their own roles plus the
+// roles of their groups.
+type Group struct {
+	ID          uuid.UUID
+	Name        string
+	Description string
+	Roles       []Role // Roles assigned to the group (loaded separately)
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// GroupMember links a user to a group.
+type GroupMember struct {
+	GroupID  uuid.UUID
+	UserID   uuid.UUID
+	JoinedAt time.Time
+}
+
+// NewGroup creates a validated group.
+func NewGroup(name, description string) (*Group, error) {
+	g := &Group{
+		ID:          NewID(),
+		Name:        strings.ToLower(strings.TrimSpace(name)),
+		Description: strings.TrimSpace(description),
+		CreatedAt:   Now(),
+		UpdatedAt:   Now(),
+	}
+
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (g *Group) Validate() error {
+	var errs ValidationErrors
+
+	if g.Name == "" {
+		errs = append(errs, ValidationError{Field: "name", Message: "required"})
+	} else if len(g.Name) > 100 {
+		errs = append(errs, ValidationError{Field: "name", Message: "must be at most 100 characters"})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
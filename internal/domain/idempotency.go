@@ -0,0 +1,55 @@
+package domain
+
+import "time"
+
+// IdempotencyRecord remembers the outcome of a mutation sent with an
+// idempotency key, so a retry of the same request gets the same response
+// instead of repeating the mutation.
+//
+// Keys are scoped to the caller (user ID, or "anonymous" for public
+// endpoints) so two clients can't collide on or read each other's keys.
+type IdempotencyRecord struct {
+	Scope       string
+	Key         string
+	Operation   string // e.g. "POST /api/v1/roles" or "/user.v1.RBACService/CreateRole"
+	RequestHash string // SHA-256 of the request, to detect a key reused for another request
+
+	// Set once the request completes
+	StatusCode  int
+	Response    []byte
+	CompletedAt *time.Time
+
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// NewIdempotencyRecord creates a record for a request that is about to run.
+func NewIdempotencyRecord(scope, key, operation, requestHash string, ttl time.Duration) *IdempotencyRecord {
+	now := Now()
+	return &IdempotencyRecord{
+		Scope:       scope,
+		Key:         key,
+		Operation:   operation,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+}
+
+// IsCompleted reports whether the response has been stored.
+func (r *IdempotencyRecord) IsCompleted() bool {
+	return r.CompletedAt != nil
+}
+
+// Matches reports whether the record was created for the same request.
+func (r *IdempotencyRecord) Matches(operation, requestHash string) bool {
+	return r.Operation == operation && r.RequestHash == requestHash
+}
+
+// Complete stores the response.
+func (r *IdempotencyRecord) Complete(statusCode int, response []byte) {
+	now := Now()
+	r.StatusCode = statusCode
+	r.Response = response
+	r.CompletedAt = &now
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ImpersonationSession records a support user acting as another user. The
+// session ID is carried in the impersonation token's act claim, so ending
+// the session invalidates the token before it expires.
+type ImpersonationSession struct {
+	ID        uuid.UUID
+	ActorID   uuid.UUID // The support user
+	TargetID  uuid.UUID // The impersonated user
+	Reason    string
+	IPAddress string
+	UserAgent string
+	StartedAt time.Time
+	ExpiresAt time.Time
+	EndedAt   *time.Time
+}
+
+// NewImpersonationSession creates a session lasting ttl.
+func NewImpersonationSession(actorID, targetID uuid.UUID, reason, ipAddress, userAgent string, ttl time.Duration) (*ImpersonationSession, error) {
+	now := Now()
+	s := &ImpersonationSession{
+		ID:        NewID(),
+		ActorID:   actorID,
+		TargetID:  targetID,
+		Reason:    strings.TrimSpace(reason),
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+		StartedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *ImpersonationSession) Validate() error {
+	var errs ValidationErrors
+
+	if s.ActorID == s.TargetID {
+		errs = append(errs, ValidationError{Field: "id", Message: "cannot impersonate yourself"})
+	}
+
+	if s.Reason == "" {
+		errs = append(errs, ValidationError{Field: "reason", Message: "required"})
+	} else if len(s.Reason) > 500 {
+		errs = append(errs, ValidationError{Field: "reason", Message: "must be at most 500 characters"})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// IsActive reports whether the session has neither ended nor expired.
+func (s *ImpersonationSession) IsActive() bool {
+	return s.EndedAt == nil && Now().Before(s.ExpiresAt)
+}
+
+// End marks the session as ended. Ending it again is a no-op.
+func (s *ImpersonationSession) End() {
+	if s.EndedAt == nil {
+		now := Now()
+		s.EndedAt = &now
+	}
+}
@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InvitationStatus is the lifecycle state of an invitation.
+type InvitationStatus string
+
+const (
+	InvitationStatusPending  InvitationStatus = "pending"
+	InvitationStatusAccepted InvitationStatus = "accepted"
+	InvitationStatusRevoked  InvitationStatus = "revoked"
+	// InvitationStatusExpired is never stored; a pending invitation past its
+	// expiry reports it.
+	InvitationStatusExpired InvitationStatus = "expired"
+)
+
+// Valid returns true if the InvitationStatus is recognized.
+func (s InvitationStatus) Valid() bool {
+	switch s {
+	case InvitationStatusPending, InvitationStatusAccepted, InvitationStatusRevoked, InvitationStatusExpired:
+		return true
+	}
+	return false
+}
+
+// Invitation lets an admin onboard a user by email. The invited account is
+// created up front in UserStatusPending and becomes active when the invitee
+// accepts with the invitation's ActionAcceptInvitation token and sets a
+// password.
+type Invitation struct {
+	ID        uuid.UUID
+	Email     string
+	UserID    uuid.UUID  // The pending user created for the invitee
+	RoleID    *uuid.UUID // Role granted on acceptance
+	InvitedBy uuid.UUID
+	Status    InvitationStatus
+	SendCount int
+	ExpiresAt time.Time
+
+	AcceptedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// NewInvitation creates a pending invitation that expires after ttl.
+func NewInvitation(email string, userID uuid.UUID, roleID *uuid.UUID, invitedBy uuid.UUID, ttl time.Duration) *Invitation {
+	now := Now()
+	return &Invitation{
+		ID:        NewID(),
+		Email:     strings.ToLower(strings.TrimSpace(email)),
+		UserID:    userID,
+		RoleID:    roleID,
+		InvitedBy: invitedBy,
+		Status:    InvitationStatusPending,
+		SendCount: 1,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Reissue extends the expiry for a resent token. Expired invitations can be
+// reissued; accepted or revoked ones cannot.
+func (i *Invitation) Reissue(ttl time.Duration) error {
+	if i.Status != InvitationStatusPending {
+		return ValidationError{Field: "status", Message: "invitation is " + string(i.Status)}
+	}
+
+	now := Now()
+	i.ExpiresAt = now.Add(ttl)
+	i.SendCount++
+	i.UpdatedAt = now
+	return nil
+}
+
+// CurrentStatus reports the status, accounting for expiry.
+func (i *Invitation) CurrentStatus() InvitationStatus {
+	if i.Status == InvitationStatusPending && Now().After(i.ExpiresAt) {
+		return InvitationStatusExpired
+	}
+	return i.Status
+}
+
+// Accept marks the invitation as accepted.
+func (i *Invitation) Accept() error {
+	if s := i.CurrentStatus(); s != InvitationStatusPending {
+		return ValidationError{Field: "token", Message: "invitation is " + string(s)}
+	}
+
+	now := Now()
+	i.Status = InvitationStatusAccepted
+	i.AcceptedAt = &now
+	i.UpdatedAt = now
+	return nil
+}
+
+// Revoke withdraws an invitation that has not been accepted.
+func (i *Invitation) Revoke() error {
+	switch i.Status {
+	case InvitationStatusRevoked:
+		return nil // Already revoked, idempotent
+	case InvitationStatusAccepted:
+		return ValidationError{Field: "status", Message: "invitation already accepted"}
+	}
+
+	now := Now()
+	i.Status = InvitationStatusRevoked
+	i.RevokedAt = &now
+	i.UpdatedAt = now
+	return nil
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LDAPGroupMapping gives the members of an LDAP group a role while they
+// sign in with their directory password.
+type LDAPGroupMapping struct {
+	ID        uuid.UUID
+	GroupDN   string // Normalized; see NormalizeDN
+	RoleID    uuid.UUID
+	RoleName  string // Loaded with the mapping
+	CreatedAt time.Time
+}
+
+// NewLDAPGroupMapping creates a validated mapping of groupDN to a role.
+func NewLDAPGroupMapping(groupDN string, roleID uuid.UUID) (*LDAPGroupMapping, error) {
+	m := &LDAPGroupMapping{
+		ID:        NewID(),
+		GroupDN:   NormalizeDN(groupDN),
+		RoleID:    roleID,
+		CreatedAt: Now(),
+	}
+
+	if m.GroupDN == "" {
+		return nil, ValidationError{Field: "group_dn", Message: "required"}
+	}
+	if len(m.GroupDN) > 500 {
+		return nil, ValidationError{Field: "group_dn", Message: "must be at most 500 characters"}
+	}
+	if m.RoleID == uuid.Nil {
+		return nil, ValidationError{Field: "role_id", Message: "required"}
+	}
+	return m, nil
+}
+
+// NormalizeDN lowercases a distinguished name, since directories compare
+// attribute names and the usual values ignoring case.
+func NormalizeDN(dn string) string {
+	return strings.ToLower(strings.TrimSpace(dn))
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	// Timezones are checked against the IANA database built into the
+	// binary, so validation doesn't depend on the host having one
+	_ "time/tzdata"
+
+	"golang.org/x/text/language"
+)
+
+// NormalizeLocale returns a BCP 47 language tag in its canonical form,
+// e.g. "en-US" for "en_us", or the tag trimmed if it doesn't parse.
+func NormalizeLocale(locale string) string {
+	locale = strings.TrimSpace(locale)
+	if tag, err := language.Parse(locale); err == nil {
+		return tag.String()
+	}
+	return locale
+}
+
+// ValidateLocale checks a BCP 47 language tag, returning nil if it is
+// valid.
+func ValidateLocale(locale string) *ValidationError {
+	tag, err := language.Parse(locale)
+	if err != nil || tag == language.Und {
+		return &ValidationError{Field: "locale", Message: "must be a BCP 47 language tag, e.g. en-US"}
+	}
+	return nil
+}
+
+// ValidateTimezone checks an IANA time zone name, returning nil if it is
+// valid.
+func ValidateTimezone(timezone string) *ValidationError {
+	// LoadLocation takes "" for UTC and "Local" for the host's zone
+	if timezone == "" || timezone == "Local" {
+		return &ValidationError{Field: "timezone", Message: "must be an IANA time zone, e.g. Europe/Berlin"}
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return &ValidationError{Field: "timezone", Message: "must be an IANA time zone, e.g. Europe/Berlin"}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reasons a login attempt failed.
+const (
+	LoginFailureInvalidCredentials = "invalid_credentials"
+	LoginFailureInactive           = "inactive"
+	LoginFailureBlocked            = "blocked"
+	LoginFailureChallenge          = "challenge_required"
+	LoginFailureDeviceConfirmation = "device_confirmation_required"
+	LoginFailureError              = "error"
+)
+
+// LoginAttempt records one attempt to sign in to an existing account.
+type LoginAttempt struct {
+	ID            uuid.UUID
+	UserID        uuid.UUID
+	Success       bool
+	FailureReason string // Empty on success
+	IPAddress     string
+	UserAgent     string
+	CreatedAt     time.Time
+}
+
+// NewLoginAttempt creates an attempt made now. An empty failureReason
+// means it succeeded.
+func NewLoginAttempt(userID uuid.UUID, failureReason, ipAddress, userAgent string) *LoginAttempt {
+	return &LoginAttempt{
+		ID:            NewID(),
+		UserID:        userID,
+		Success:       failureReason == "",
+		FailureReason: failureReason,
+		IPAddress:     ipAddress,
+		UserAgent:     userAgent,
+		CreatedAt:     Now(),
+	}
+}
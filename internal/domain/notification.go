@@ -0,0 +1,10 @@
+package domain
+
+// NotificationPreference is whether a user wants notifications of one
+// category over one channel. Users without one for a category and channel
+// get those notifications.
+type NotificationPreference struct {
+	Category string
+	Channel  string
+	Enabled  bool
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Organization is a tenant. Users belong to zero or more organizations and
+// roles may be scoped to a single organization.
+type Organization struct {
+	ID        uuid.UUID
+	Name      string
+	Slug      string // URL-safe unique handle
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// OrganizationMember links a user to an organization.
+type OrganizationMember struct {
+	OrganizationID uuid.UUID
+	UserID         uuid.UUID
+	JoinedAt       time.Time
+}
+
+// NewOrganization creates a validated organization.
+func NewOrganization(name, slug string) (*Organization, error) {
+	o := &Organization{
+		ID:        NewID(),
+		Name:      strings.TrimSpace(name),
+		Slug:      strings.ToLower(strings.TrimSpace(slug)),
+		CreatedAt: Now(),
+		UpdatedAt: Now(),
+	}
+
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+var slugRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*[a-z0-9]$`)
+
+func (o *Organization) Validate() error {
+	var errs ValidationErrors
+
+	if o.Name == "" {
+		errs = append(errs, ValidationError{Field: "name", Message: "required"})
+	} else if len(o.Name) > 200 {
+		errs = append(errs, ValidationError{Field: "name", Message: "must be at most 200 characters"})
+	}
+
+	if o.Slug == "" {
+		errs = append(errs, ValidationError{Field: "slug", Message: "required"})
+	} else if len(o.Slug) > 63 {
+		errs = append(errs, ValidationError{Field: "slug", Message: "must be at most 63 characters"})
+	} else if !slugRegex.MatchString(o.Slug) {
+		errs = append(errs, ValidationError{Field: "slug", Message: "must contain only lowercase letters, digits and inner hyphens"})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Profile is a typed persona of a user. One person signs in once and can
+// then act as their customer or partner profile, each with its own roles.
+// Tokens issued for a profile carry its type and only its permissions.
+type Profile struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Type        UserType // UserTypeCustomer or UserTypePartner
+	DisplayName string
+	Roles       []Role // Roles assigned to the profile (loaded separately)
+	CreatedAt   time.Time
+}
+
+// NewProfile creates a validated profile of profileType for the user.
+func NewProfile(userID uuid.UUID, profileType UserType, displayName string) (*Profile, error) {
+	p := &Profile{
+		ID:          NewID(),
+		UserID:      userID,
+		Type:        profileType,
+		DisplayName: strings.TrimSpace(displayName),
+		CreatedAt:   Now(),
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Profile) Validate() error {
+	var errs ValidationErrors
+
+	if p.Type != UserTypeCustomer && p.Type != UserTypePartner {
+		errs = append(errs, ValidationError{Field: "type", Message: "must be customer or partner"})
+	}
+	if len(p.DisplayName) > 200 {
+		errs = append(errs, ValidationError{Field: "display_name", Message: "must be at most 200 characters"})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// AllPermissions returns the unique permissions granted or denied by the
+// profile's roles.
+func (p *Profile) AllPermissions() []Permission {
+	seen := make(map[string]bool)
+	var perms []Permission
+	for _, role := range p.Roles {
+		for _, perm := range role.Permissions {
+			key := perm.String()
+			if perm.Deny {
+				key = "!" + key
+			}
+			if !seen[key] {
+				seen[key] = true
+				perms = append(perms, perm)
+			}
+		}
+	}
+	return perms
+}
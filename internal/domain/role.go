@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"slices"
 	"strings"
 	"time"
 
@@ -14,16 +15,21 @@ type Permission struct {
 	Action      string // e.g., "read", "write", "delete", "admin"
 	Description string
 	CreatedAt   time.Time
+
+	// Deny is set on a role's permission that the role takes away rather
+	// than grants. A denial overrides grants from the same or any other
+	// role.
+	Deny bool
 }
 
 // NewPermission creates a validated permission.
 func NewPermission(resource, action, description string) (*Permission, error) {
 	p := &Permission{
-		ID:          uuid.New(),
+		ID:          NewID(),
 		Resource:    strings.ToLower(strings.TrimSpace(resource)),
 		Action:      strings.ToLower(strings.TrimSpace(action)),
 		Description: strings.TrimSpace(description),
-		CreatedAt:   time.Now().UTC(),
+		CreatedAt:   Now(),
 	}
 
 	if err := p.Validate(); err != nil {
@@ -59,24 +65,118 @@ func (p *Permission) String() string {
 	return p.Resource + ":" + p.Action
 }
 
+// Covers reports whether the permission, with its wildcards, matches
+// resource:action. "*" stands for any resource or action.
+func (p *Permission) Covers(resource, action string) bool {
+	return (p.Resource == resource || p.Resource == "*") && (p.Action == action || p.Action == "*")
+}
+
+// Allows evaluates perms with deny-overrides-allow precedence: resource:action
+// is allowed if a grant covers it and no denial does.
+func Allows(perms []Permission, resource, action string) bool {
+	granted := false
+	for i := range perms {
+		if !perms[i].Covers(resource, action) {
+			continue
+		}
+		if perms[i].Deny {
+			return false
+		}
+		granted = true
+	}
+	return granted
+}
+
+// Denies reports whether one of perms denies resource:action.
+func Denies(perms []Permission, resource, action string) bool {
+	return slices.ContainsFunc(perms, func(p Permission) bool {
+		return p.Deny && p.Covers(resource, action)
+	})
+}
+
+// PermissionClaims splits perms into the resource:action strings carried
+// in access tokens. Grants a denial fully covers are dropped, so checks
+// that only read granted still honor exact denials; denied is needed for
+// carve-outs from wildcard grants, e.g. users:delete out of users:*.
+func PermissionClaims(perms []Permission) (granted, denied []string) {
+	granted, denied = []string{}, []string{}
+	for _, p := range perms {
+		if p.Deny {
+			denied = append(denied, p.String())
+			continue
+		}
+		overridden := slices.ContainsFunc(perms, func(d Permission) bool {
+			return d.Deny && d.Covers(p.Resource, p.Action)
+		})
+		if !overridden {
+			granted = append(granted, p.String())
+		}
+	}
+	return granted, denied
+}
+
+// PermissionGroup holds permissions on the same resource.
+type PermissionGroup struct {
+	Resource    string
+	Permissions []Permission
+}
+
+// GroupPermissions groups permissions by resource, keeping the order in
+// which each resource first appears.
+func GroupPermissions(perms []Permission) []PermissionGroup {
+	var groups []PermissionGroup
+	index := make(map[string]int)
+	for _, p := range perms {
+		i, ok := index[p.Resource]
+		if !ok {
+			i = len(groups)
+			index[p.Resource] = i
+			groups = append(groups, PermissionGroup{Resource: p.Resource})
+		}
+		groups[i].Permissions = append(groups[i].Permissions, p)
+	}
+	return groups
+}
+
 // Role represents a named collection of permissions.
+// Roles with an OrganizationID only grant their permissions within that
+// organization; other roles are global.
 type Role struct {
-	ID          uuid.UUID
-	Name        string
-	Description string
-	Permissions []Permission
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID             uuid.UUID
+	Name           string
+	Description    string
+	OrganizationID *uuid.UUID
+	Permissions    []Permission
+	Assignments    *RoleAssignments // Loaded by GetRole and ListRoles only
+	ExpiresAt      *time.Time       // When a user's assignment lapses, in a user's roles
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// RoleAssignments counts who holds a role directly. Users inheriting it
+// through a group are counted in Groups only.
+type RoleAssignments struct {
+	Users    int64 // Not soft-deleted
+	Groups   int64
+	Profiles int64
+}
+
+// RoleExpiry is a user's time-bound role assignment that has lapsed.
+type RoleExpiry struct {
+	UserID    uuid.UUID
+	RoleID    uuid.UUID
+	RoleName  string
+	ExpiresAt time.Time
 }
 
 // NewRole creates a validated role.
 func NewRole(name, description string) (*Role, error) {
 	r := &Role{
-		ID:          uuid.New(),
+		ID:          NewID(),
 		Name:        strings.ToLower(strings.TrimSpace(name)),
 		Description: strings.TrimSpace(description),
-		CreatedAt:   time.Now().UTC(),
-		UpdatedAt:   time.Now().UTC(),
+		CreatedAt:   Now(),
+		UpdatedAt:   Now(),
 	}
 
 	if err := r.Validate(); err != nil {
@@ -100,36 +200,48 @@ func (r *Role) Validate() error {
 	return nil
 }
 
-func (r *Role) HasPermission(resource, action string) bool {
+// IsGlobal reports whether the role applies outside any organization.
+func (r *Role) IsGlobal() bool {
+	return r.OrganizationID == nil
+}
+
+// IsPrivileged reports whether the role grants administrative reach: any
+// action on every resource, admin or every action on a resource, or
+// anything on roles or permissions, with which its holders could grant
+// themselves more.
+func (r *Role) IsPrivileged() bool {
 	for _, p := range r.Permissions {
-		if p.Resource == resource && p.Action == action {
-			return true
-		}
-		// Support wildcard action
-		if p.Resource == resource && p.Action == "*" {
-			return true
-		}
-		// Support wildcard resource
-		if p.Resource == "*" && p.Action == action {
-			return true
+		if p.Deny {
+			continue
 		}
-		// Super admin: *:*
-		if p.Resource == "*" && p.Action == "*" {
+		if p.Resource == "*" || p.Action == "*" || p.Action == "admin" ||
+			p.Resource == "roles" || p.Resource == "permissions" {
 			return true
 		}
 	}
 	return false
 }
 
-// AddPermission adds a permission to the role if not already present.
+// HasPermission reports whether the role grants resource:action and doesn't
+// also deny it.
+func (r *Role) HasPermission(resource, action string) bool {
+	return Allows(r.Permissions, resource, action)
+}
+
+// AddPermission adds a permission to the role if not already present, or
+// switches it between grant and denial.
 func (r *Role) AddPermission(p Permission) {
-	for _, existing := range r.Permissions {
+	for i, existing := range r.Permissions {
 		if existing.ID == p.ID {
-			return // Already has this permission
+			if existing.Deny != p.Deny {
+				r.Permissions[i].Deny = p.Deny
+				r.UpdatedAt = Now()
+			}
+			return
 		}
 	}
 	r.Permissions = append(r.Permissions, p)
-	r.UpdatedAt = time.Now().UTC()
+	r.UpdatedAt = Now()
 }
 
 // RemovePermission removes a permission from the role.
@@ -137,7 +249,7 @@ func (r *Role) RemovePermission(permissionID uuid.UUID) {
 	for i, p := range r.Permissions {
 		if p.ID == permissionID {
 			r.Permissions = append(r.Permissions[:i], r.Permissions[i+1:]...)
-			r.UpdatedAt = time.Now().UTC()
+			r.UpdatedAt = Now()
 			return
 		}
 	}
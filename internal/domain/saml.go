@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SAMLProvider is an identity provider users sign in through with SAML.
+// Accounts are provisioned on first sign-in, and the roles their groups
+// map to are brought in line with the assertion on each one.
+type SAMLProvider struct {
+	ID          uuid.UUID
+	Slug        string // URL-safe unique handle, in the provider's SAML endpoints
+	Name        string
+	EntityID    string // The identity provider's issuer
+	SSOURL      string // Where AuthnRequests are sent
+	Certificate string // PEM certificates responses may be signed with
+
+	// The attributes the email, full name and groups are read from. An
+	// empty EmailAttribute means the NameID is the email.
+	EmailAttribute  string
+	NameAttribute   string
+	GroupsAttribute string
+
+	// EmailDomains are the domains of the emails the provider may assert.
+	// Existing accounts are only linked to the provider on sign-in if
+	// their email is in one of them; without any, new accounts of any
+	// domain are provisioned but existing ones are linked by an admin.
+	EmailDomains []string
+
+	Enabled   bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// NewSAMLProvider creates a validated, enabled provider.
+func NewSAMLProvider(slug, name, entityID, ssoURL, certificate string) (*SAMLProvider, error) {
+	p := &SAMLProvider{
+		ID:           NewID(),
+		Slug:         strings.ToLower(strings.TrimSpace(slug)),
+		Name:         strings.TrimSpace(name),
+		EntityID:     strings.TrimSpace(entityID),
+		SSOURL:       strings.TrimSpace(ssoURL),
+		Certificate:  strings.TrimSpace(certificate),
+		EmailDomains: []string{},
+		Enabled:      true,
+		CreatedAt:    Now(),
+		UpdatedAt:    Now(),
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *SAMLProvider) Validate() error {
+	var errs ValidationErrors
+
+	if p.Slug == "" {
+		errs = append(errs, ValidationError{Field: "slug", Message: "required"})
+	} else if len(p.Slug) > 63 {
+		errs = append(errs, ValidationError{Field: "slug", Message: "must be at most 63 characters"})
+	} else if !slugRegex.MatchString(p.Slug) {
+		errs = append(errs, ValidationError{Field: "slug", Message: "must contain only lowercase letters, digits and inner hyphens"})
+	}
+
+	if p.Name == "" {
+		errs = append(errs, ValidationError{Field: "name", Message: "required"})
+	} else if len(p.Name) > 255 {
+		errs = append(errs, ValidationError{Field: "name", Message: "must be at most 255 characters"})
+	}
+
+	if p.EntityID == "" {
+		errs = append(errs, ValidationError{Field: "entity_id", Message: "required"})
+	} else if len(p.EntityID) > 500 {
+		errs = append(errs, ValidationError{Field: "entity_id", Message: "must be at most 500 characters"})
+	}
+
+	if p.SSOURL == "" {
+		errs = append(errs, ValidationError{Field: "sso_url", Message: "required"})
+	} else if u, err := url.Parse(p.SSOURL); err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		errs = append(errs, ValidationError{Field: "sso_url", Message: "must be an absolute http(s) URL"})
+	}
+
+	if p.Certificate == "" {
+		errs = append(errs, ValidationError{Field: "certificate", Message: "required"})
+	}
+
+	for field, attr := range map[string]string{
+		"email_attribute":  p.EmailAttribute,
+		"name_attribute":   p.NameAttribute,
+		"groups_attribute": p.GroupsAttribute,
+	} {
+		if len(attr) > 255 {
+			errs = append(errs, ValidationError{Field: field, Message: "must be at most 255 characters"})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// SAMLGroupRole gives the users whose assertion from a provider lists a
+// group a role.
+type SAMLGroupRole struct {
+	ID         uuid.UUID
+	ProviderID uuid.UUID
+	Group      string
+	RoleID     uuid.UUID
+	RoleName   string // Loaded with the mapping
+	CreatedAt  time.Time
+}
+
+// NewSAMLGroupRole creates a validated mapping of a provider's group to a
+// role.
+func NewSAMLGroupRole(providerID uuid.UUID, group string, roleID uuid.UUID) (*SAMLGroupRole, error) {
+	m := &SAMLGroupRole{
+		ID:         NewID(),
+		ProviderID: providerID,
+		Group:      strings.TrimSpace(group),
+		RoleID:     roleID,
+		CreatedAt:  Now(),
+	}
+
+	if m.Group == "" {
+		return nil, ValidationError{Field: "group", Message: "required"}
+	}
+	if len(m.Group) > 500 {
+		return nil, ValidationError{Field: "group", Message: "must be at most 500 characters"}
+	}
+	if m.RoleID == uuid.Nil {
+		return nil, ValidationError{Field: "role_id", Message: "required"}
+	}
+	return m, nil
+}
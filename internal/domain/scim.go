@@ -0,0 +1,10 @@
+package domain
+
+// SCIMResource is a type of resource identity providers provision over
+// SCIM.
+type SCIMResource string
+
+const (
+	SCIMUser  SCIMResource = "User"
+	SCIMGroup SCIMResource = "Group"
+)
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+)
+
+// OpenID Connect scopes a client can request at login. Each releases a set
+// of standard claims from the userinfo endpoint.
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile" // name, preferred_username, updated_at
+	ScopeEmail   = "email"   // email, email_verified
+	ScopePhone   = "phone"   // phone_number, phone_number_verified
+)
+
+// API scopes a client can request at login, limiting which routes the
+// token may call on top of the permissions it carries.
+const (
+	ScopeAPIRead  = "api.read"  // Reads: GET, HEAD and OPTIONS requests
+	ScopeAPIWrite = "api.write" // Everything, reads included
+)
+
+var knownScopes = []string{ScopeOpenID, ScopeProfile, ScopeEmail, ScopePhone, ScopeAPIRead, ScopeAPIWrite}
+
+// NormalizeScope validates a space-separated scope and returns it sorted
+// and without duplicates. An empty scope stays empty: such tokens are
+// unrestricted.
+func NormalizeScope(scope string) (string, error) {
+	scopes := strings.Fields(scope)
+	for _, s := range scopes {
+		if !slices.Contains(knownScopes, s) {
+			return "", ValidationError{Field: "scope", Message: "unknown scope " + s}
+		}
+	}
+	slices.Sort(scopes)
+	return strings.Join(slices.Compact(scopes), " "), nil
+}
+
+// ScopeGrants reports whether a token issued with scope was granted s.
+// Tokens issued without a scope are granted everything.
+func ScopeGrants(scope, s string) bool {
+	return scope == "" || slices.Contains(strings.Fields(scope), s)
+}
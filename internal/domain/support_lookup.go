@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SupportLookup records one run of a predefined support lookup, for the
+// audit trail.
+type SupportLookup struct {
+	ID          uuid.UUID
+	ActorID     uuid.UUID // The support user; uuid.Nil once their account is gone
+	Query       string
+	Params      map[string]string
+	Reason      string
+	ResultCount int
+	IPAddress   string
+	UserAgent   string
+	CreatedAt   time.Time
+}
+
+// NewSupportLookup records a lookup run now.
+func NewSupportLookup(actorID uuid.UUID, query string, params map[string]string, reason, ipAddress, userAgent string) (*SupportLookup, error) {
+	l := &SupportLookup{
+		ID:        NewID(),
+		ActorID:   actorID,
+		Query:     query,
+		Params:    params,
+		Reason:    strings.TrimSpace(reason),
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+		CreatedAt: Now(),
+	}
+
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (l *SupportLookup) Validate() error {
+	var errs ValidationErrors
+
+	if l.Reason == "" {
+		errs = append(errs, ValidationError{Field: "reason", Message: "required"})
+	} else if len(l.Reason) > 500 {
+		errs = append(errs, ValidationError{Field: "reason", Message: "must be at most 500 characters"})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// TimelineKind identifies what a timeline entry records.
+type TimelineKind string
+
+// Timeline entry kinds, with the keys of their Data.
+const (
+	TimelineUserCreated          TimelineKind = "user.created"
+	TimelineUserUpdated          TimelineKind = "user.updated"          // fields
+	TimelineStatusChanged        TimelineKind = "user.status_changed"   // from, to
+	TimelineLoginSucceeded       TimelineKind = "login.succeeded"       // ip_address, user_agent
+	TimelineLoginFailed          TimelineKind = "login.failed"          // ip_address, user_agent, failure_reason
+	TimelineRoleAssigned         TimelineKind = "role.assigned"         // role_id, role
+	TimelineRoleRemoved          TimelineKind = "role.removed"          // role_id, role
+	TimelinePasswordChanged      TimelineKind = "password.changed"      //
+	TimelineDeviceAdded          TimelineKind = "device.added"          // device_id, user_agent
+	TimelineImpersonationStarted TimelineKind = "impersonation.started" // session_id, actor_id, reason
+	TimelineImpersonationEnded   TimelineKind = "impersonation.ended"   // session_id, actor_id
+)
+
+// TimelineKinds lists every timeline entry kind.
+var TimelineKinds = []TimelineKind{
+	TimelineUserCreated,
+	TimelineUserUpdated,
+	TimelineStatusChanged,
+	TimelineLoginSucceeded,
+	TimelineLoginFailed,
+	TimelineRoleAssigned,
+	TimelineRoleRemoved,
+	TimelinePasswordChanged,
+	TimelineDeviceAdded,
+	TimelineImpersonationStarted,
+	TimelineImpersonationEnded,
+}
+
+// ParseTimelineKind checks that s names a timeline entry kind.
+func ParseTimelineKind(s string) (TimelineKind, bool) {
+	kind := TimelineKind(s)
+	return kind, slices.Contains(TimelineKinds, kind)
+}
+
+// TimelineEntry is one thing that happened to an account, as recorded in
+// the history, login and session tables.
+type TimelineEntry struct {
+	Kind       TimelineKind
+	OccurredAt time.Time
+	Data       map[string]any
+}
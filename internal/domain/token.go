@@ -19,10 +19,32 @@ type RefreshToken struct {
 
 	IPAddress string
 	UserAgent string
+
+	// ProfileID is the profile the token was issued for; nil for the user
+	// themselves. Refreshing keeps the profile.
+	ProfileID *uuid.UUID
+
+	// Scope is the OpenID Connect scope the token was issued with; empty
+	// is unrestricted. Refreshing keeps it.
+	Scope string
+
+	// FamilyID is the ID of the token issued at sign-in, shared by every
+	// token rotated from it. ReplacedByID is set, with RevokedAt, on
+	// tokens that were rotated, to their replacement.
+	FamilyID     uuid.UUID
+	ReplacedByID *uuid.UUID
+
+	// SessionStartedAt is when the family's first token was issued, which
+	// a maximum session lifetime counts from.
+	SessionStartedAt time.Time
+
+	// RememberMe is whether the user asked to stay signed in at sign-in,
+	// which gives the family the long refresh TTL rather than the short.
+	RememberMe bool
 }
 
 func (t *RefreshToken) IsExpired() bool {
-	return time.Now().UTC().After(t.ExpiresAt)
+	return Now().After(t.ExpiresAt)
 }
 
 func (t *RefreshToken) IsRevoked() bool {
@@ -36,7 +58,7 @@ func (t *RefreshToken) IsValid() bool {
 // Revoke marks the token as revoked.
 func (t *RefreshToken) Revoke() {
 	if t.RevokedAt == nil {
-		now := time.Now().UTC()
+		now := Now()
 		t.RevokedAt = &now
 	}
 }
@@ -55,5 +77,6 @@ func GenerateTokenString() (string, error) {
 type TokenPair struct {
 	AccessToken  string
 	RefreshToken string
-	ExpiresIn    int64 // Seconds until access token expires
+	ExpiresIn    int64  // Seconds until access token expires
+	Scope        string // Granted scope; empty is unrestricted
 }
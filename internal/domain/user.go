@@ -75,6 +75,14 @@ type User struct {
 	EmailVerified bool
 	PhoneVerified bool
 
+	// Locale is a BCP 47 language tag and Timezone an IANA time zone name,
+	// for formatting; empty when the user hasn't set them
+	Locale   string
+	Timezone string
+
+	// Custom attributes, keyed by AttributeDefinition.Key
+	Attributes map[string]any
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt *time.Time
@@ -88,15 +96,16 @@ type User struct {
 
 func NewUser(email, username, fullName string, userType UserType) (*User, error) {
 	u := &User{
-		ID:        uuid.New(),
-		Email:     strings.ToLower(strings.TrimSpace(email)),
-		Username:  strings.TrimSpace(username),
-		FullName:  strings.TrimSpace(fullName),
-		Type:      userType,
-		Status:    UserStatusPending,
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
-		Version:   1,
+		ID:         NewID(),
+		Email:      NormalizeEmail(email),
+		Username:   NormalizeUsername(username),
+		FullName:   strings.TrimSpace(fullName),
+		Type:       userType,
+		Status:     UserStatusPending,
+		Attributes: map[string]any{},
+		CreatedAt:  Now(),
+		UpdatedAt:  Now(),
+		Version:    1,
 	}
 
 	if err := u.Validate(); err != nil {
@@ -108,20 +117,12 @@ func NewUser(email, username, fullName string, userType UserType) (*User, error)
 func (u *User) Validate() error {
 	var errs ValidationErrors
 
-	// Email validation
-	if u.Email == "" {
-		errs = append(errs, ValidationError{Field: "email", Message: "required"})
-	} else if _, err := mail.ParseAddress(u.Email); err != nil {
-		errs = append(errs, ValidationError{Field: "email", Message: "invalid format"})
+	if err := ValidateEmail(u.Email); err != nil {
+		errs = append(errs, *err)
 	}
 
-	// Username validation
-	if u.Username == "" {
-		errs = append(errs, ValidationError{Field: "username", Message: "required"})
-	} else if len(u.Username) < 3 || len(u.Username) > 50 {
-		errs = append(errs, ValidationError{Field: "username", Message: "must be 3-50 characters"})
-	} else if !isValidUsername(u.Username) {
-		errs = append(errs, ValidationError{Field: "username", Message: "can only contain letters, numbers, underscores, and hyphens"})
+	if err := ValidateUsername(u.Username); err != nil {
+		errs = append(errs, *err)
 	}
 
 	// Full name validation
@@ -148,6 +149,18 @@ func (u *User) Validate() error {
 		}
 	}
 
+	if u.Locale != "" {
+		if err := ValidateLocale(u.Locale); err != nil {
+			errs = append(errs, *err)
+		}
+	}
+
+	if u.Timezone != "" {
+		if err := ValidateTimezone(u.Timezone); err != nil {
+			errs = append(errs, *err)
+		}
+	}
+
 	if len(errs) > 0 {
 		return errs
 	}
@@ -166,7 +179,33 @@ func (u *User) SetPhone(phone string) error {
 	}
 	u.Phone = &phone
 	u.PhoneVerified = false
-	u.UpdatedAt = time.Now().UTC()
+	u.UpdatedAt = Now()
+	return nil
+}
+
+// SetLocale sets the user's locale, normalized, or clears it if empty.
+func (u *User) SetLocale(locale string) error {
+	locale = NormalizeLocale(locale)
+	if locale != "" {
+		if err := ValidateLocale(locale); err != nil {
+			return *err
+		}
+	}
+	u.Locale = locale
+	u.UpdatedAt = Now()
+	return nil
+}
+
+// SetTimezone sets the user's time zone, or clears it if empty.
+func (u *User) SetTimezone(timezone string) error {
+	timezone = strings.TrimSpace(timezone)
+	if timezone != "" {
+		if err := ValidateTimezone(timezone); err != nil {
+			return *err
+		}
+	}
+	u.Timezone = timezone
+	u.UpdatedAt = Now()
 	return nil
 }
 
@@ -181,7 +220,7 @@ func (u *User) ChangeStatus(newStatus UserStatus) error {
 		}
 	}
 	u.Status = newStatus
-	u.UpdatedAt = time.Now().UTC()
+	u.UpdatedAt = Now()
 	return nil
 }
 
@@ -199,14 +238,35 @@ func (u *User) Suspend() error {
 	return u.ChangeStatus(UserStatusSuspended)
 }
 
+// Deactivate makes an active account inactive at its user's request. Unlike
+// suspension, which is an administrator's decision, the user can undo it.
+func (u *User) Deactivate() error {
+	if u.Status != UserStatusActive {
+		return ValidationError{Field: "status", Message: "only active accounts can be deactivated"}
+	}
+	return u.ChangeStatus(UserStatusInactive)
+}
+
 func (u *User) VerifyEmail() {
 	u.EmailVerified = true
-	u.UpdatedAt = time.Now().UTC()
+	u.UpdatedAt = Now()
+}
+
+// ChangeEmail sets a new email address, which is verified: it's only
+// applied once confirmed from a link sent to it.
+func (u *User) ChangeEmail(email string) error {
+	email = NormalizeEmail(email)
+	if err := ValidateEmail(email); err != nil {
+		return *err
+	}
+	u.Email = email
+	u.VerifyEmail()
+	return nil
 }
 
 func (u *User) VerifyPhone() {
 	u.PhoneVerified = true
-	u.UpdatedAt = time.Now().UTC()
+	u.UpdatedAt = Now()
 }
 
 func (u *User) IsActive() bool {
@@ -218,7 +278,7 @@ func (u *User) IsDeleted() bool {
 }
 
 func (u *User) Delete() {
-	now := time.Now().UTC()
+	now := Now()
 	u.DeletedAt = &now
 	u.UpdatedAt = now
 }
@@ -232,21 +292,46 @@ func (u *User) HasRole(roleName string) bool {
 	return false
 }
 
+// HasPermission checks the user's global roles: one of them has to grant
+// resource:action and none may deny it. Organization-scoped roles are
+// checked with HasOrganizationPermission.
 func (u *User) HasPermission(resource, action string) bool {
-	for _, role := range u.Roles {
-		if role.HasPermission(resource, action) {
-			return true
-		}
-	}
-	return false
+	return Allows(u.AllPermissions(), resource, action)
+}
+
+// HasOrganizationPermission checks the user's roles scoped to orgID, with
+// the same deny-overrides-allow precedence.
+func (u *User) HasOrganizationPermission(orgID uuid.UUID, resource, action string) bool {
+	return Allows(u.OrganizationPermissions(orgID), resource, action)
 }
 
+// AllPermissions returns the distinct permissions granted or denied by
+// global roles.
 func (u *User) AllPermissions() []Permission {
+	return u.permissionsWhere(func(r *Role) bool { return r.IsGlobal() })
+}
+
+// OrganizationPermissions returns the distinct permissions granted or
+// denied by roles scoped to orgID.
+func (u *User) OrganizationPermissions(orgID uuid.UUID) []Permission {
+	return u.permissionsWhere(func(r *Role) bool {
+		return r.OrganizationID != nil && *r.OrganizationID == orgID
+	})
+}
+
+func (u *User) permissionsWhere(include func(*Role) bool) []Permission {
 	seen := make(map[string]bool)
 	var perms []Permission
-	for _, role := range u.Roles {
+	for i := range u.Roles {
+		role := &u.Roles[i]
+		if !include(role) {
+			continue
+		}
 		for _, p := range role.Permissions {
-			key := p.Resource + ":" + p.Action
+			key := p.String()
+			if p.Deny {
+				key = "!" + key
+			}
 			if !seen[key] {
 				seen[key] = true
 				perms = append(perms, p)
@@ -256,10 +341,26 @@ func (u *User) AllPermissions() []Permission {
 	return perms
 }
 
-var usernameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+// NormalizeEmail returns email as stored: trimmed and lowercased.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// ValidateEmail checks an email address, returning nil if it is valid.
+func ValidateEmail(email string) *ValidationError {
+	if email == "" {
+		return &ValidationError{Field: "email", Message: "required"}
+	}
+	if _, err := mail.ParseAddress(email); err != nil {
+		return &ValidationError{Field: "email", Message: "invalid format"}
+	}
+	return nil
+}
 
-func isValidUsername(s string) bool {
-	return usernameRegex.MatchString(s)
+// ValidateUsername checks a username against the current UsernamePolicy,
+// returning nil if it is valid.
+func ValidateUsername(username string) *ValidationError {
+	return CurrentUsernamePolicy().Validate(username)
 }
 
 var phoneRegex = regexp.MustCompile(`^\+?[\d\s\-()]+$`)
@@ -0,0 +1,213 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"golang.org/x/text/unicode/norm"
+)
+
+// UsernamePolicy decides which usernames are allowed.
+//
+// By default usernames are ASCII letters, digits, underscores and hyphens.
+// With Unicode enabled they may use letters from any script, but to prevent
+// spoofing:
+//   - usernames are NFKC-normalized, so compatibility forms such as
+//     fullwidth letters and ligatures collapse to one spelling;
+//   - letters must come from a single script (Latin may be combined with
+//     Han, Hiragana, Katakana, Bopomofo or Hangul, as in Japanese, Chinese
+//     and Korean names), which rules out mixing e.g. Cyrillic "а" into a
+//     Latin name;
+//   - a name written only in lookalikes of Latin letters, such as Cyrillic
+//     "раура", is rejected as confusable;
+//   - digits must be ASCII, and invisible formatting characters are rejected.
+type UsernamePolicy struct {
+	Unicode   bool
+	MinLength int // In characters
+	MaxLength int // In characters; the users.username column holds at most 50
+}
+
+// DefaultUsernamePolicy is the ASCII-only policy.
+var DefaultUsernamePolicy = UsernamePolicy{
+	MinLength: 3,
+	MaxLength: 50,
+}
+
+var usernamePolicy atomic.Pointer[UsernamePolicy]
+
+// SetUsernamePolicy replaces the policy used by ValidateUsername.
+func SetUsernamePolicy(p UsernamePolicy) {
+	usernamePolicy.Store(&p)
+}
+
+// CurrentUsernamePolicy returns the policy used by ValidateUsername.
+func CurrentUsernamePolicy() UsernamePolicy {
+	if p := usernamePolicy.Load(); p != nil {
+		return *p
+	}
+	return DefaultUsernamePolicy
+}
+
+// NormalizeUsername trims a username and converts it to NFKC. Usernames
+// are normalized before they are validated or stored.
+func NormalizeUsername(username string) string {
+	return norm.NFKC.String(strings.TrimSpace(username))
+}
+
+var asciiUsernameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Validate checks a username, returning nil if it is valid.
+func (p UsernamePolicy) Validate(username string) *ValidationError {
+	if username == "" {
+		return &ValidationError{Field: "username", Message: "required"}
+	}
+
+	if n := utf8.RuneCountInString(username); n < p.MinLength || n > p.MaxLength {
+		return &ValidationError{Field: "username", Message: fmt.Sprintf("must be %d-%d characters", p.MinLength, p.MaxLength)}
+	}
+
+	if !p.Unicode {
+		if !asciiUsernameRegex.MatchString(username) {
+			return &ValidationError{Field: "username", Message: "can only contain letters, numbers, underscores, and hyphens"}
+		}
+		return nil
+	}
+
+	if !norm.NFKC.IsNormalString(username) {
+		return &ValidationError{Field: "username", Message: "must be NFKC normalized"}
+	}
+
+	scripts := make(map[string]bool)
+	lookalikes := true
+	prevLetter := false
+	for _, r := range username {
+		switch {
+		case unicode.IsLetter(r):
+			scripts[scriptOf(r)] = true
+			if _, ok := latinConfusables[r]; !ok {
+				lookalikes = false
+			}
+			prevLetter = true
+			continue
+		case unicode.Is(unicode.Mn, r) && prevLetter:
+			// Combining marks are only allowed on letters
+			continue
+		case r >= '0' && r <= '9', r == '_', r == '-':
+		default:
+			return &ValidationError{Field: "username", Message: "can only contain letters, numbers, underscores, and hyphens"}
+		}
+		prevLetter = false
+	}
+
+	if !singleScript(scripts) {
+		return &ValidationError{Field: "username", Message: "cannot mix letters from different scripts"}
+	}
+
+	if !scripts["Latin"] && len(scripts) > 0 && lookalikes {
+		return &ValidationError{Field: "username", Message: "is confusable with a Latin username"}
+	}
+
+	return nil
+}
+
+// scriptOf returns the name of the Unicode script of a letter.
+func scriptOf(r rune) string {
+	if r < utf8.RuneSelf {
+		return "Latin"
+	}
+	for name, table := range unicode.Scripts {
+		if name == "Common" || name == "Inherited" {
+			continue
+		}
+		if unicode.Is(table, r) {
+			return name
+		}
+	}
+	return "Unknown"
+}
+
+// cjkScripts are the scripts that may be combined with each other and with
+// Latin, following the UTS #39 "highly restrictive" level.
+var cjkScripts = map[string]bool{
+	"Han":      true,
+	"Hiragana": true,
+	"Katakana": true,
+	"Bopomofo": true,
+	"Hangul":   true,
+}
+
+func singleScript(scripts map[string]bool) bool {
+	if len(scripts) <= 1 {
+		return true
+	}
+
+	for name := range scripts {
+		if name != "Latin" && !cjkScripts[name] {
+			return false
+		}
+	}
+
+	// Han may combine with one of the Japanese, Chinese or Korean scripts
+	switch {
+	case scripts["Bopomofo"]:
+		return !scripts["Hiragana"] && !scripts["Katakana"] && !scripts["Hangul"]
+	case scripts["Hangul"]:
+		return !scripts["Hiragana"] && !scripts["Katakana"]
+	}
+	return true
+}
+
+// latinConfusables holds non-Latin letters that render like a Latin letter,
+// from the Unicode confusables data (confusables.txt).
+var latinConfusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'е': 'e', 'о': 'o', 'р': 'p', 'с': 'c', 'у': 'y', 'х': 'x', 'ѕ': 's',
+	'і': 'i', 'ј': 'j', 'ԁ': 'd', 'һ': 'h', 'ӏ': 'l', 'ԛ': 'q', 'ԝ': 'w', 'ү': 'y',
+	'А': 'A', 'В': 'B', 'Е': 'E', 'К': 'K', 'М': 'M', 'Н': 'H', 'О': 'O', 'Р': 'P',
+	'С': 'C', 'Т': 'T', 'У': 'Y', 'Х': 'X', 'Ѕ': 'S', 'І': 'I', 'Ј': 'J', 'Ү': 'Y',
+	'Ԛ': 'Q', 'Ԝ': 'W',
+
+	// Greek
+	'α': 'a', 'ι': 'i', 'ν': 'v', 'ο': 'o', 'ρ': 'p', 'υ': 'u', 'γ': 'y',
+	'Α': 'A', 'Β': 'B', 'Ε': 'E', 'Ζ': 'Z', 'Η': 'H', 'Ι': 'I', 'Κ': 'K', 'Μ': 'M',
+	'Ν': 'N', 'Ο': 'O', 'Ρ': 'P', 'Τ': 'T', 'Υ': 'Y', 'Χ': 'X',
+
+	// Armenian
+	'օ': 'o', 'ս': 'u', 'հ': 'h', 'ո': 'n', 'ց': 'g', 'զ': 'q',
+}
+
+// UsernameChange records a user moving away from a username. Until
+// ReservedUntil only they can take it back, and lookups by it can still be
+// resolved to them.
+type UsernameChange struct {
+	ID            uuid.UUID
+	UserID        uuid.UUID
+	Username      string // The former username
+	ChangedAt     time.Time
+	ReservedUntil time.Time
+}
+
+// NewUsernameChange records userID leaving username, reserved for them for
+// reservation.
+func NewUsernameChange(userID uuid.UUID, username string, reservation time.Duration) *UsernameChange {
+	now := Now()
+	return &UsernameChange{
+		ID:            NewID(),
+		UserID:        userID,
+		Username:      username,
+		ChangedAt:     now,
+		ReservedUntil: now.Add(reservation),
+	}
+}
+
+// IsReserved reports whether the former username is still held for its
+// user.
+func (c *UsernameChange) IsReserved() bool {
+	return Now().Before(c.ReservedUntil)
+}
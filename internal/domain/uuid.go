@@ -4,7 +4,15 @@ import (
 	"github.com/google/uuid"
 )
 
-func UUIDFromString(uuidStr string) uuid.UUID {
-	id, _ := uuid.Parse(uuidStr)
-	return id
+// ParseID parses the ID in field. A malformed ID, or the nil UUID, which
+// no entity has, is a ValidationError.
+func ParseID(field, s string) (uuid.UUID, error) {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, ValidationError{Field: field, Message: "must be a valid UUID"}
+	}
+	if id == uuid.Nil {
+		return uuid.Nil, ValidationError{Field: field, Message: "must not be the nil UUID"}
+	}
+	return id, nil
 }
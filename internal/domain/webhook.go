@@ -0,0 +1,285 @@
+package domain
+
+import (
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook is an HTTP endpoint that receives signed domain events.
+type Webhook struct {
+	ID          uuid.UUID
+	URL         string
+	Description string
+	EventTypes  []string // Empty means every event
+	Secret      string   // Shared HMAC secret; only shown to the client on creation
+	Active      bool
+	CreatedBy   uuid.UUID
+	OwnerID     *uuid.UUID // Set for a partner's webhook, which only receives events about the partner
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// NewWebhook creates a validated webhook with a freshly generated secret.
+// ownerID is set for a partner's webhook.
+func NewWebhook(rawURL, description string, eventTypes []string, createdBy uuid.UUID, ownerID *uuid.UUID) (*Webhook, error) {
+	w := &Webhook{
+		ID:          NewID(),
+		URL:         strings.TrimSpace(rawURL),
+		Description: strings.TrimSpace(description),
+		EventTypes:  normalizeEventTypes(eventTypes),
+		Active:      true,
+		CreatedBy:   createdBy,
+		OwnerID:     ownerID,
+		CreatedAt:   Now(),
+		UpdatedAt:   Now(),
+	}
+
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := w.RotateSecret(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Webhook) Validate() error {
+	var errs ValidationErrors
+
+	if w.URL == "" {
+		errs = append(errs, ValidationError{Field: "url", Message: "required"})
+	} else if u, err := url.Parse(w.URL); err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		errs = append(errs, ValidationError{Field: "url", Message: "must be an absolute http(s) URL"})
+	} else if w.OwnerID != nil {
+		if msg := partnerURLError(u); msg != "" {
+			errs = append(errs, ValidationError{Field: "url", Message: msg})
+		}
+	}
+
+	if len(w.Description) > 500 {
+		errs = append(errs, ValidationError{Field: "description", Message: "must be at most 500 characters"})
+	}
+
+	for _, t := range w.EventTypes {
+		if len(t) > 100 {
+			errs = append(errs, ValidationError{Field: "event_types", Message: "event type must be at most 100 characters"})
+			break
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// internalHostSuffixes are names only resolvable inside a network.
+var internalHostSuffixes = []string{".localhost", ".local", ".internal", ".intranet", ".corp", ".lan", ".home.arpa"}
+
+// partnerURLError checks the URL of a partner's webhook, which anyone with
+// a portal account chooses: it has to use https and name a public host. It
+// returns why u is refused, or "" if it isn't. What the host resolves to is
+// checked apart from this, since it can change.
+func partnerURLError(u *url.URL) string {
+	if u.Scheme != "https" {
+		return "must use https"
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if _, err := netip.ParseAddr(host); err == nil {
+		return "must name a host, not an IP address"
+	}
+	if host == "localhost" || !strings.Contains(host, ".") {
+		return "must name a public host"
+	}
+	for _, suffix := range internalHostSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return "must name a public host"
+		}
+	}
+	return ""
+}
+
+// SetEventTypes replaces the event filter.
+func (w *Webhook) SetEventTypes(eventTypes []string) {
+	w.EventTypes = normalizeEventTypes(eventTypes)
+	w.UpdatedAt = Now()
+}
+
+// Subscribes reports whether the webhook wants events of the given type.
+func (w *Webhook) Subscribes(eventType string) bool {
+	if len(w.EventTypes) == 0 {
+		return true
+	}
+	return slices.Contains(w.EventTypes, eventType) || slices.Contains(w.EventTypes, "*")
+}
+
+// Receives reports whether the webhook should be sent e.
+func (w *Webhook) Receives(e Event) bool {
+	if w.OwnerID != nil && *w.OwnerID != e.UserID {
+		return false
+	}
+	return w.Subscribes(e.Type)
+}
+
+// RotateSecret replaces the signing secret.
+func (w *Webhook) RotateSecret() error {
+	secret, err := GenerateTokenString()
+	if err != nil {
+		return err
+	}
+	w.Secret = "whsec_" + secret
+	w.UpdatedAt = Now()
+	return nil
+}
+
+func normalizeEventTypes(eventTypes []string) []string {
+	result := make([]string, 0, len(eventTypes))
+	for _, t := range eventTypes {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t != "" && !slices.Contains(result, t) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// nonPublicPrefixes are the special-purpose ranges that netip's
+// predicates don't cover.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // This network
+	netip.MustParsePrefix("100.64.0.0/10"),  // Carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // Benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // Reserved
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64, which reaches any IPv4 address
+	netip.MustParsePrefix("64:ff9b:1::/48"), // Local-use NAT64
+}
+
+// PublicAddress reports whether webhooks may be sent to addr: a unicast
+// address on the internet, not a loopback, private, link-local (such as
+// cloud metadata at 169.254.169.254) or otherwise reserved one. IPv4
+// addresses mapped into IPv6 are judged as IPv4.
+func PublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, p := range nonPublicPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// WebhookDeliveryStatus represents the state of a delivery.
+type WebhookDeliveryStatus string
+
+const (
+	DeliveryPending   WebhookDeliveryStatus = "pending"
+	DeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	DeliveryDead      WebhookDeliveryStatus = "dead" // Gave up after max attempts
+)
+
+// Valid returns true if the WebhookDeliveryStatus is recognized.
+func (s WebhookDeliveryStatus) Valid() bool {
+	switch s {
+	case DeliveryPending, DeliverySucceeded, DeliveryDead:
+		return true
+	}
+	return false
+}
+
+// WebhookDelivery is one event queued for one webhook, with its attempt history.
+type WebhookDelivery struct {
+	ID             uuid.UUID
+	WebhookID      uuid.UUID
+	EventID        uuid.UUID
+	EventType      string
+	Payload        []byte
+	Status         WebhookDeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastAttemptAt  *time.Time
+	ResponseStatus *int
+	ResponseBody   string
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+// NewWebhookDelivery queues payload for immediate delivery.
+func NewWebhookDelivery(webhookID uuid.UUID, event Event, payload []byte) *WebhookDelivery {
+	now := Now()
+	return &WebhookDelivery{
+		ID:            NewID(),
+		WebhookID:     webhookID,
+		EventID:       event.ID,
+		EventType:     event.Type,
+		Payload:       payload,
+		Status:        DeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+}
+
+// RecordSuccess marks the delivery as delivered.
+func (d *WebhookDelivery) RecordSuccess(statusCode int, body string) {
+	now := Now()
+	d.Attempts++
+	d.LastAttemptAt = &now
+	d.ResponseStatus = &statusCode
+	d.ResponseBody = body
+	d.LastError = ""
+	d.Status = DeliverySucceeded
+	d.DeliveredAt = &now
+}
+
+// RecordFailure records a failed attempt. The delivery is rescheduled after
+// backoff(attempts), or dead-lettered once maxAttempts is reached.
+// statusCode is 0 when no response was received.
+func (d *WebhookDelivery) RecordFailure(statusCode int, body, errMsg string, maxAttempts int, backoff func(attempt int) time.Duration) {
+	now := Now()
+	d.Attempts++
+	d.LastAttemptAt = &now
+	d.ResponseStatus = nil
+	if statusCode != 0 {
+		d.ResponseStatus = &statusCode
+	}
+	d.ResponseBody = body
+	d.LastError = errMsg
+
+	if d.Attempts >= maxAttempts {
+		d.Status = DeliveryDead
+		return
+	}
+	d.NextAttemptAt = now.Add(backoff(d.Attempts))
+}
+
+// Requeue schedules a delivery for another round of attempts, starting
+// now: a dead or failing one is retried, and a delivered one sent again
+// for receivers being debugged. Its last attempt is kept until the next.
+func (d *WebhookDelivery) Requeue() {
+	d.Status = DeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = Now()
+	d.DeliveredAt = nil
+}
+
+// WebhookQueueStats is a snapshot of the pending deliveries across all
+// webhooks.
+type WebhookQueueStats struct {
+	Pending int64 `json:"pending"`
+	Due     int64 `json:"due"` // Pending and past their next attempt
+
+	// OldestDue is the next attempt time of the longest-waiting due
+	// delivery; nil when none is due.
+	OldestDue *time.Time `json:"oldest_due,omitempty"`
+}
@@ -0,0 +1,131 @@
+// Package encryption encrypts sensitive columns with AES-256-GCM.
+//
+// Values are encrypted with the current key and stored next to its key ID,
+// a short fingerprint of the key, so they still decrypt after a rotation as
+// long as the old key is listed among the previous ones. Without a current
+// key, values are stored as they are under the empty key ID, which keeps
+// encryption opt-in and lets deployments turn it on (or off) by
+// re-encrypting their rows.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+)
+
+// ErrUnknownKey is returned when decrypting a value whose key isn't loaded.
+var ErrUnknownKey = errors.New("encrypted with an unknown key")
+
+type keyring struct {
+	currentID string
+	byID      map[string]cipher.AEAD
+}
+
+var currentKeys atomic.Pointer[keyring]
+
+// SetKeys sets the key new values are encrypted with and the previous ones
+// still used to decrypt. An empty current key stores new values in the
+// clear. Safe to call concurrently, so keys loaded from a secret manager
+// can be refreshed.
+func SetKeys(current string, previous ...string) {
+	k := &keyring{byID: make(map[string]cipher.AEAD, len(previous)+1)}
+	for _, key := range previous {
+		if key != "" {
+			k.byID[KeyID(key)] = newAEAD(key)
+		}
+	}
+	if current != "" {
+		k.currentID = KeyID(current)
+		k.byID[k.currentID] = newAEAD(current)
+	}
+	currentKeys.Store(k)
+}
+
+func loadKeys() *keyring {
+	if k := currentKeys.Load(); k != nil {
+		return k
+	}
+	return &keyring{}
+}
+
+// KeyID is a short fingerprint of a key, safe to store next to the values
+// encrypted with it.
+func KeyID(key string) string {
+	sum := sha256.Sum256([]byte("aegis-column-key-id:" + key))
+	return hex.EncodeToString(sum[:4])
+}
+
+// CurrentKeyID returns the ID of the key new values are encrypted with, or
+// "" if they're stored in the clear.
+func CurrentKeyID() string {
+	return loadKeys().currentID
+}
+
+// KnownKeyIDs returns the IDs values can be decrypted from, including ""
+// for values stored in the clear.
+func KnownKeyIDs() []string {
+	k := loadKeys()
+	ids := []string{""}
+	for id := range k.byID {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// Encrypt encrypts a value of column with the current key and returns it
+// with the key's ID. Binding the column keeps a value copied to another
+// column from decrypting there.
+func Encrypt(column, plaintext string) (ciphertext, keyID string, err error) {
+	k := loadKeys()
+	if k.currentID == "" {
+		return plaintext, "", nil
+	}
+	aead := k.byID[k.currentID]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(column))
+	return base64.StdEncoding.EncodeToString(sealed), k.currentID, nil
+}
+
+// Decrypt decrypts a value of column encrypted with the key keyID.
+func Decrypt(column, ciphertext, keyID string) (string, error) {
+	if keyID == "" {
+		return ciphertext, nil
+	}
+	aead, ok := loadKeys().byID[keyID]
+	if !ok {
+		return "", fmt.Errorf("%s: %w %s", column, ErrUnknownKey, keyID)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("%s: malformed ciphertext", column)
+	}
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, []byte(column))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", column, err)
+	}
+	return string(plaintext), nil
+}
+
+// newAEAD derives an AES-256-GCM cipher from a key of any length.
+func newAEAD(key string) cipher.AEAD {
+	sum := sha256.Sum256([]byte("aegis-column-key:" + key))
+	// Neither fails with a 32-byte key
+	block, _ := aes.NewCipher(sum[:])
+	aead, _ := cipher.NewGCM(block)
+	return aead
+}
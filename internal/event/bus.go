@@ -0,0 +1,138 @@
+package event
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// Bus is a Publisher that forwards events to an underlying publisher and
+// also fans them out to in-process subscribers (gRPC streams, dashboards).
+//
+// Delivery to subscribers is best-effort: each subscription has a bounded
+// buffer and events are dropped for subscribers that fall behind, so a slow
+// consumer can never block the service layer.
+type Bus struct {
+	next Publisher
+
+	mu     sync.RWMutex
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// NewBus wraps next with in-process fan-out.
+func NewBus(next Publisher) *Bus {
+	return &Bus{
+		next: next,
+		subs: make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscription receives events published on a Bus.
+type Subscription struct {
+	bus    *Bus
+	env    domain.Environment
+	types  map[string]bool
+	events chan domain.Event
+	once   sync.Once
+}
+
+// Events returns the channel on which events are delivered.
+// The channel is closed when the subscription is cancelled or the bus closes.
+func (s *Subscription) Events() <-chan domain.Event {
+	return s.events
+}
+
+// Cancel stops delivery and releases the subscription.
+func (s *Subscription) Cancel() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.closeLocked()
+}
+
+func (s *Subscription) closeLocked() {
+	s.once.Do(func() {
+		delete(s.bus.subs, s)
+		close(s.events)
+	})
+}
+
+func (s *Subscription) wants(event domain.Event) bool {
+	return event.Environment == s.env && (len(s.types) == 0 || s.types[event.Type])
+}
+
+// Subscribe registers a subscriber for the given event types in the
+// environment of ctx. An empty types list subscribes to every event.
+func (b *Bus) Subscribe(ctx context.Context, buffer int, types ...string) *Subscription {
+	if buffer <= 0 {
+		buffer = 64
+	}
+
+	sub := &Subscription{
+		bus:    b,
+		env:    domain.EnvironmentFromContext(ctx),
+		events: make(chan domain.Event, buffer),
+	}
+	if len(types) > 0 {
+		sub.types = make(map[string]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(sub.events)
+		return sub
+	}
+	b.subs[sub] = struct{}{}
+	return sub
+}
+
+// Publish stamps the event with the correlation ID and trace context of ctx
+// before passing it on, as later publishers may not see ctx.
+func (b *Bus) Publish(ctx context.Context, event domain.Event) error {
+	event = Stamp(ctx, event)
+	b.broadcast(event)
+	return b.next.Publish(ctx, event)
+}
+
+func (b *Bus) PublishBatch(ctx context.Context, events []domain.Event) error {
+	stamped := make([]domain.Event, len(events))
+	for i, e := range events {
+		stamped[i] = Stamp(ctx, e)
+		b.broadcast(stamped[i])
+	}
+	events = stamped
+	return b.next.PublishBatch(ctx, events)
+}
+
+// Close closes all subscriptions and the underlying publisher.
+func (b *Bus) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	for sub := range b.subs {
+		sub.closeLocked()
+	}
+	b.mu.Unlock()
+
+	return b.next.Close()
+}
+
+func (b *Bus) broadcast(event domain.Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subs {
+		if !sub.wants(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// Subscriber is too slow; drop rather than block publishers.
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// EnvelopeVersion is the version of the Envelope layout itself, as opposed
+// to the schema version of an event's data.
+const EnvelopeVersion = 1
+
+// Source identifies this service as the producer of events.
+const Source = "aegis"
+
+// Envelope is the wire form of an event, shared by every broker and by
+// webhooks. Consumers should check Type and SchemaVersion before reading
+// Data, and ignore fields they don't know.
+type Envelope struct {
+	EnvelopeVersion int            `json:"envelope_version"`
+	ID              uuid.UUID      `json:"id"`
+	Type            string         `json:"type"`
+	SchemaVersion   int            `json:"schema_version"`
+	Source          string         `json:"source"`
+	Timestamp       time.Time      `json:"timestamp"`
+	UserID          uuid.UUID      `json:"user_id"`
+	CorrelationID   string         `json:"correlation_id,omitempty"`
+	TraceParent     string         `json:"traceparent,omitempty"`
+	TraceState      string         `json:"tracestate,omitempty"`
+	Environment     string         `json:"environment,omitempty"` // "sandbox"; empty is production
+	Data            map[string]any `json:"data"`
+}
+
+// NewEnvelope wraps e for the wire.
+func NewEnvelope(e domain.Event) Envelope {
+	version := e.SchemaVersion
+	if version == 0 {
+		version = domain.EventSchemaVersion(e.Type)
+	}
+	env := Envelope{
+		EnvelopeVersion: EnvelopeVersion,
+		ID:              e.ID,
+		Type:            e.Type,
+		SchemaVersion:   version,
+		Source:          Source,
+		Timestamp:       e.Timestamp,
+		UserID:          e.UserID,
+		CorrelationID:   e.CorrelationID,
+		TraceParent:     e.TraceParent,
+		TraceState:      e.TraceState,
+		Data:            e.Data,
+	}
+	if e.Environment.IsSandbox() {
+		env.Environment = string(e.Environment)
+	}
+	return env
+}
+
+// Event unwraps the envelope.
+func (env Envelope) Event() domain.Event {
+	environment, _ := domain.ParseEnvironment(env.Environment)
+	return domain.Event{
+		ID:            env.ID,
+		Type:          env.Type,
+		Timestamp:     env.Timestamp,
+		UserID:        env.UserID,
+		Data:          env.Data,
+		SchemaVersion: env.SchemaVersion,
+		CorrelationID: env.CorrelationID,
+		TraceParent:   env.TraceParent,
+		TraceState:    env.TraceState,
+		Environment:   environment,
+	}
+}
+
+// Codec serializes envelopes. JSON is the only codec for now; a binary one
+// (protobuf, Avro) would implement this too.
+type Codec interface {
+	ContentType() string
+	Encode(env Envelope) ([]byte, error)
+	Decode(data []byte) (Envelope, error)
+}
+
+// JSONCodec encodes envelopes as JSON.
+type JSONCodec struct{}
+
+func (JSONCodec) ContentType() string {
+	return "application/json"
+}
+
+func (JSONCodec) Encode(env Envelope) ([]byte, error) {
+	return json.Marshal(env)
+}
+
+func (JSONCodec) Decode(data []byte) (Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return Envelope{}, err
+	}
+	if env.EnvelopeVersion > EnvelopeVersion {
+		return Envelope{}, fmt.Errorf("unsupported envelope version %d", env.EnvelopeVersion)
+	}
+	return env, nil
+}
+
+// DefaultCodec is the codec brokers and webhooks use.
+var DefaultCodec Codec = JSONCodec{}
+
+type correlationKey struct{}
+
+// WithCorrelationID returns a context whose published events carry id.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationID returns the correlation ID set by WithCorrelationID.
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// Stamp fills in e's correlation ID, trace context and environment from
+// ctx, keeping any already set.
+func Stamp(ctx context.Context, e domain.Event) domain.Event {
+	if e.Environment == "" {
+		e.Environment = domain.EnvironmentFromContext(ctx)
+	}
+	if e.CorrelationID == "" {
+		e.CorrelationID = CorrelationID(ctx)
+	}
+	if e.TraceParent == "" {
+		carrier := propagation.MapCarrier{}
+		propagation.TraceContext{}.Inject(ctx, carrier)
+		e.TraceParent = carrier.Get("traceparent")
+		e.TraceState = carrier.Get("tracestate")
+	}
+	if e.SchemaVersion == 0 {
+		e.SchemaVersion = domain.EventSchemaVersion(e.Type)
+	}
+	return e
+}
@@ -0,0 +1,42 @@
+package event
+
+import (
+	"context"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// ProductionOnly is a Publisher that drops sandbox events, so they never
+// reach the broker behind it.
+type ProductionOnly struct {
+	next Publisher
+}
+
+// NewProductionOnly wraps next so it only receives production events.
+func NewProductionOnly(next Publisher) *ProductionOnly {
+	return &ProductionOnly{next: next}
+}
+
+func (p *ProductionOnly) Publish(ctx context.Context, event domain.Event) error {
+	if event.Environment.IsSandbox() {
+		return nil
+	}
+	return p.next.Publish(ctx, event)
+}
+
+func (p *ProductionOnly) PublishBatch(ctx context.Context, events []domain.Event) error {
+	production := make([]domain.Event, 0, len(events))
+	for _, e := range events {
+		if !e.Environment.IsSandbox() {
+			production = append(production, e)
+		}
+	}
+	if len(production) == 0 {
+		return nil
+	}
+	return p.next.PublishBatch(ctx, production)
+}
+
+func (p *ProductionOnly) Close() error {
+	return p.next.Close()
+}
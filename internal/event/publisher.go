@@ -5,8 +5,8 @@
 // (the service layer doesn't change when you swap brokers).
 //
 // IMPLEMENTATION NOTE:
-// Currently, only the logging publisher is implemented. When
-// Kafka, NATS, RabbitMQ, or another broker is needed:
+// The logging publisher and RabbitMQ (rabbitmq.go) are implemented. When
+// Kafka, NATS, or another broker is needed:
 //
 // 1. Create a new file (e.g., kafka.go) implementing the Publisher interface
 // 2. Add configuration for your broker
@@ -52,7 +52,9 @@ func (p *LoggingPublisher) Publish(ctx context.Context, event domain.Event) erro
 	p.logger.Info("event published",
 		slog.String("event_id", event.ID.String()),
 		slog.String("event_type", event.Type),
+		slog.Int("schema_version", event.SchemaVersion),
 		slog.String("user_id", event.UserID.String()),
+		slog.String("correlation_id", event.CorrelationID),
 		slog.String("data", string(data)),
 	)
 	return nil
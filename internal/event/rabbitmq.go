@@ -0,0 +1,294 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// RabbitMQConfig configures a RabbitMQPublisher.
+type RabbitMQConfig struct {
+	URL string
+
+	// Exchange receives events no route matches, with the event type as
+	// routing key. Exchanges are declared as durable topic exchanges.
+	Exchange string
+	Routes   []Route
+
+	// DialTimeout bounds connecting to the broker.
+	DialTimeout time.Duration
+}
+
+// Route sends events whose type matches Pattern to Exchange with
+// RoutingKey. A pattern ending in "*" matches types with that prefix. An
+// empty Exchange is the configured default exchange and an empty
+// RoutingKey is the event type.
+type Route struct {
+	Pattern    string
+	Exchange   string
+	RoutingKey string
+}
+
+func (r Route) matches(eventType string) bool {
+	if prefix, ok := strings.CutSuffix(r.Pattern, "*"); ok {
+		return strings.HasPrefix(eventType, prefix)
+	}
+	return eventType == r.Pattern
+}
+
+// ParseRoutes parses "pattern=exchange/routing_key" items, e.g.
+// "user.*=users/" or "consent.granted=/partners.consent". Either side of
+// the slash may be empty; without a slash the value is the exchange.
+func ParseRoutes(items []string) ([]Route, error) {
+	routes := make([]Route, 0, len(items))
+	for _, item := range items {
+		pattern, value, ok := strings.Cut(item, "=")
+		pattern = strings.TrimSpace(pattern)
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("event route %q: want pattern=exchange/routing_key", item)
+		}
+
+		exchange, key, _ := strings.Cut(strings.TrimSpace(value), "/")
+		routes = append(routes, Route{
+			Pattern:    pattern,
+			Exchange:   strings.TrimSpace(exchange),
+			RoutingKey: strings.TrimSpace(key),
+		})
+	}
+	return routes, nil
+}
+
+// RabbitMQPublisher publishes events to RabbitMQ as persistent messages
+// holding their Envelope, and waits for the broker to confirm each one, so
+// a returned nil means the broker has taken responsibility for the event.
+//
+// The connection is opened on first use and reopened on the next publish
+// after it or the channel closes; the failed publish returns an error for
+// the caller (normally a Resilient publisher) to retry.
+type RabbitMQPublisher struct {
+	cfg    RabbitMQConfig
+	logger *slog.Logger
+
+	mu       sync.Mutex
+	conn     *amqp.Connection
+	ch       *amqp.Channel
+	declared map[string]bool // Exchanges declared on ch
+	closed   bool
+}
+
+// NewRabbitMQPublisher creates a publisher for cfg. It tries to connect
+// right away to surface misconfiguration in the logs, but an unreachable
+// broker isn't an error: events are held by the caller until it's back.
+func NewRabbitMQPublisher(cfg RabbitMQConfig, logger *slog.Logger) (*RabbitMQPublisher, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("rabbitmq: URL is required")
+	}
+	if cfg.Exchange == "" {
+		return nil, errors.New("rabbitmq: exchange is required")
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = 10 * time.Second
+	}
+
+	p := &RabbitMQPublisher{cfg: cfg, logger: logger}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := p.channel(); err != nil {
+		logger.Warn("rabbitmq unavailable, will retry on publish", slog.String("error", err.Error()))
+	}
+	return p, nil
+}
+
+func (p *RabbitMQPublisher) Publish(ctx context.Context, event domain.Event) error {
+	return p.PublishBatch(ctx, []domain.Event{event})
+}
+
+// PublishBatch publishes all events before waiting for their confirms. On
+// error some of the events may have been delivered; retrying delivers them
+// again, so consumers should deduplicate by message ID.
+func (p *RabbitMQPublisher) PublishBatch(ctx context.Context, events []domain.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return errors.New("rabbitmq: publisher is closed")
+	}
+
+	ch, err := p.channel()
+	if err != nil {
+		return err
+	}
+
+	confirms := make([]*amqp.DeferredConfirmation, 0, len(events))
+	for _, e := range events {
+		exchange, key := p.route(e.Type)
+		if err := p.declare(ch, exchange); err != nil {
+			p.reset()
+			return err
+		}
+
+		env := NewEnvelope(e)
+		body, err := DefaultCodec.Encode(env)
+		if err != nil {
+			return fmt.Errorf("encoding event %s: %w", e.ID, err)
+		}
+
+		confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, exchange, key, false, false, amqp.Publishing{
+			ContentType:   DefaultCodec.ContentType(),
+			DeliveryMode:  amqp.Persistent,
+			MessageId:     e.ID.String(),
+			CorrelationId: e.CorrelationID,
+			Type:          e.Type,
+			Timestamp:     e.Timestamp,
+			AppId:         Source,
+			Headers:       amqp.Table{"schema_version": int32(env.SchemaVersion)},
+			Body:          body,
+		})
+		if err != nil {
+			p.reset()
+			return fmt.Errorf("rabbitmq: publishing %s: %w", e.Type, err)
+		}
+		confirms = append(confirms, confirm)
+	}
+
+	for i, confirm := range confirms {
+		acked, err := confirm.WaitContext(ctx)
+		if err != nil {
+			// The confirm may still arrive; a fresh channel keeps it from
+			// being matched to a later publish.
+			p.reset()
+			return fmt.Errorf("rabbitmq: waiting for confirm of %s: %w", events[i].Type, err)
+		}
+		if !acked {
+			return fmt.Errorf("rabbitmq: broker rejected %s", events[i].Type)
+		}
+	}
+
+	return nil
+}
+
+// Ping connects to the broker, unless already connected, and declares the
+// default exchange, reporting whether events could be published now.
+func (p *RabbitMQPublisher) Ping() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return errors.New("rabbitmq: publisher is closed")
+	}
+
+	ch, err := p.channel()
+	if err != nil {
+		return err
+	}
+	if err := p.declare(ch, p.cfg.Exchange); err != nil {
+		p.reset()
+		return err
+	}
+	return nil
+}
+
+// Close closes the connection. Publishing afterwards fails.
+func (p *RabbitMQPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn, p.ch = nil, nil
+	if errors.Is(err, amqp.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+// route returns the exchange and routing key of eventType: the first
+// matching route's, or the default exchange and the type itself.
+func (p *RabbitMQPublisher) route(eventType string) (exchange, key string) {
+	exchange, key = p.cfg.Exchange, eventType
+	for _, r := range p.cfg.Routes {
+		if !r.matches(eventType) {
+			continue
+		}
+		if r.Exchange != "" {
+			exchange = r.Exchange
+		}
+		if r.RoutingKey != "" {
+			key = r.RoutingKey
+		}
+		break
+	}
+	return exchange, key
+}
+
+// channel returns the open confirm-mode channel, connecting first if there
+// is none or the previous one closed. p.mu must be held.
+func (p *RabbitMQPublisher) channel() (*amqp.Channel, error) {
+	if p.ch != nil && !p.ch.IsClosed() && !p.conn.IsClosed() {
+		return p.ch, nil
+	}
+	p.reset()
+
+	conn, err := amqp.DialConfig(p.cfg.URL, amqp.Config{
+		Dial:       amqp.DefaultDial(p.cfg.DialTimeout),
+		Properties: amqp.Table{"connection_name": "aegis"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("rabbitmq: connecting: %w", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("rabbitmq: opening channel: %w", err)
+	}
+	if err := ch.Confirm(false); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("rabbitmq: enabling confirms: %w", err)
+	}
+
+	closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	go func() {
+		if err, ok := <-closed; ok && err != nil {
+			p.logger.Warn("rabbitmq connection closed", slog.String("error", err.Error()))
+		}
+	}()
+
+	p.conn, p.ch = conn, ch
+	p.declared = make(map[string]bool)
+	p.logger.Info("rabbitmq connected", slog.String("exchange", p.cfg.Exchange))
+	return ch, nil
+}
+
+// declare declares exchange once per channel. p.mu must be held.
+func (p *RabbitMQPublisher) declare(ch *amqp.Channel, exchange string) error {
+	if p.declared[exchange] {
+		return nil
+	}
+	if err := ch.ExchangeDeclare(exchange, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("rabbitmq: declaring exchange %q: %w", exchange, err)
+	}
+	p.declared[exchange] = true
+	return nil
+}
+
+// reset drops the connection so the next publish reconnects. p.mu must be
+// held.
+func (p *RabbitMQPublisher) reset() {
+	if p.conn != nil {
+		_ = p.conn.Close()
+	}
+	p.conn, p.ch, p.declared = nil, nil, nil
+}
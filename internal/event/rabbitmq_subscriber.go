@@ -0,0 +1,197 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// RabbitMQSubscriberConfig configures a RabbitMQSubscriber.
+type RabbitMQSubscriberConfig struct {
+	URL string
+
+	// Queue receives the subscribed events from Exchanges, bound by event
+	// type. Dead-lettered events go to Queue+".dead" through the
+	// Queue+".dlx" exchange; all of them are declared durable.
+	Queue     string
+	Exchanges []string
+
+	Prefetch       int           // Unacknowledged events held at once
+	DialTimeout    time.Duration // Bounds connecting to the broker
+	ReconnectDelay time.Duration // Wait before reconnecting after a failure
+}
+
+// RabbitMQSubscriber receives events from RabbitMQ. Events are acknowledged
+// only once handled, so those in flight when the connection drops are
+// delivered again after reconnecting.
+type RabbitMQSubscriber struct {
+	cfg    RabbitMQSubscriberConfig
+	logger *slog.Logger
+}
+
+// NewRabbitMQSubscriber creates a subscriber for cfg. It doesn't connect
+// until Subscribe.
+func NewRabbitMQSubscriber(cfg RabbitMQSubscriberConfig, logger *slog.Logger) (*RabbitMQSubscriber, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("rabbitmq: URL is required")
+	}
+	if cfg.Queue == "" {
+		return nil, errors.New("rabbitmq: queue is required")
+	}
+	if len(cfg.Exchanges) == 0 {
+		return nil, errors.New("rabbitmq: at least one exchange is required")
+	}
+	if cfg.Prefetch <= 0 {
+		cfg.Prefetch = 16
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = 10 * time.Second
+	}
+	if cfg.ReconnectDelay <= 0 {
+		cfg.ReconnectDelay = 5 * time.Second
+	}
+
+	return &RabbitMQSubscriber{cfg: cfg, logger: logger}, nil
+}
+
+// Subscribe binds the queue to types on every exchange and delivers its
+// events until ctx is done, reconnecting whenever the connection fails.
+func (s *RabbitMQSubscriber) Subscribe(ctx context.Context, types []string) (<-chan Delivery, error) {
+	out := make(chan Delivery)
+
+	go func() {
+		defer close(out)
+		for {
+			err := s.consume(ctx, types, out)
+			if ctx.Err() != nil {
+				return
+			}
+			s.logger.Warn("rabbitmq subscription interrupted, reconnecting",
+				slog.String("queue", s.cfg.Queue),
+				slog.String("error", err.Error()),
+			)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(s.cfg.ReconnectDelay):
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// Close is a no-op: the connection is closed when the subscription's
+// context is done.
+func (s *RabbitMQSubscriber) Close() error {
+	return nil
+}
+
+// consume runs one connection's worth of the subscription. It returns nil
+// once ctx is done, or the error that broke the connection.
+func (s *RabbitMQSubscriber) consume(ctx context.Context, types []string, out chan<- Delivery) error {
+	conn, err := amqp.DialConfig(s.cfg.URL, amqp.Config{
+		Dial:       amqp.DefaultDial(s.cfg.DialTimeout),
+		Properties: amqp.Table{"connection_name": "aegis-consumer"},
+	})
+	if err != nil {
+		return fmt.Errorf("connecting: %w", err)
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("opening channel: %w", err)
+	}
+	if err := ch.Qos(s.cfg.Prefetch, 0, false); err != nil {
+		return fmt.Errorf("setting prefetch: %w", err)
+	}
+	if err := s.declare(ch, types); err != nil {
+		return err
+	}
+
+	messages, err := ch.ConsumeWithContext(ctx, s.cfg.Queue, "", false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("consuming %q: %w", s.cfg.Queue, err)
+	}
+	s.logger.Info("rabbitmq subscription started", slog.String("queue", s.cfg.Queue))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case m, ok := <-messages:
+			if !ok {
+				return errors.New("channel closed")
+			}
+			select {
+			case out <- rabbitMQDelivery{m}:
+			case <-ctx.Done():
+				_ = m.Nack(false, true)
+				return nil
+			}
+		}
+	}
+}
+
+// declare sets up the queue, its dead-letter exchange and queue, and its
+// bindings.
+func (s *RabbitMQSubscriber) declare(ch *amqp.Channel, types []string) error {
+	dlx := s.cfg.Queue + ".dlx"
+	dead := s.cfg.Queue + ".dead"
+
+	if err := ch.ExchangeDeclare(dlx, amqp.ExchangeFanout, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("declaring exchange %q: %w", dlx, err)
+	}
+	if _, err := ch.QueueDeclare(dead, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("declaring queue %q: %w", dead, err)
+	}
+	if err := ch.QueueBind(dead, "", dlx, false, nil); err != nil {
+		return fmt.Errorf("binding queue %q: %w", dead, err)
+	}
+
+	args := amqp.Table{"x-dead-letter-exchange": dlx}
+	if _, err := ch.QueueDeclare(s.cfg.Queue, true, false, false, false, args); err != nil {
+		return fmt.Errorf("declaring queue %q: %w", s.cfg.Queue, err)
+	}
+
+	for _, exchange := range s.cfg.Exchanges {
+		if err := ch.ExchangeDeclare(exchange, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+			return fmt.Errorf("declaring exchange %q: %w", exchange, err)
+		}
+		for _, t := range types {
+			if err := ch.QueueBind(s.cfg.Queue, t, exchange, false, nil); err != nil {
+				return fmt.Errorf("binding %q on %q: %w", t, exchange, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// rabbitMQDelivery adapts an AMQP delivery. Dead-lettering rejects the
+// message without requeueing, which routes it to the dead-letter exchange.
+type rabbitMQDelivery struct {
+	m amqp.Delivery
+}
+
+func (d rabbitMQDelivery) Body() []byte {
+	return d.m.Body
+}
+
+func (d rabbitMQDelivery) Ack() error {
+	return d.m.Ack(false)
+}
+
+func (d rabbitMQDelivery) Requeue() error {
+	return d.m.Nack(false, true)
+}
+
+func (d rabbitMQDelivery) DeadLetter(reason error) error {
+	return d.m.Reject(false)
+}
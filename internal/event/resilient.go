@@ -0,0 +1,536 @@
+package event
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// ErrBufferFull is returned by Resilient.Publish when both the memory
+// buffer and the spool are full and the event was dropped.
+var ErrBufferFull = errors.New("event buffer is full")
+
+// spoolFile is the name of the spool inside ResilientConfig.SpoolDir.
+const spoolFile = "events.jsonl"
+
+// ResilientConfig controls buffering and retries of a Resilient publisher.
+type ResilientConfig struct {
+	BufferSize int    // Events held in memory
+	SpoolDir   string // Directory for events that overflow the buffer; empty disables the spool
+	SpoolSize  int    // Events held in the spool
+
+	Timeout    time.Duration // Per-attempt timeout
+	Backoff    time.Duration // Delay after the first failure; doubles each attempt
+	MaxBackoff time.Duration // Upper bound of the delay
+
+	// After BreakerThreshold consecutive failures the breaker opens and no
+	// attempt is made for BreakerCooldown; then a single attempt decides
+	// whether it closes again.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// DefaultResilientConfig rides out a broker outage of a few minutes at
+// moderate traffic without a spool.
+var DefaultResilientConfig = ResilientConfig{
+	BufferSize:       10000,
+	SpoolSize:        100000,
+	Timeout:          5 * time.Second,
+	Backoff:          500 * time.Millisecond,
+	MaxBackoff:       30 * time.Second,
+	BreakerThreshold: 5,
+	BreakerCooldown:  30 * time.Second,
+}
+
+// BreakerState is the state of a Resilient publisher's circuit breaker.
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // Events are delivered
+	BreakerOpen     BreakerState = "open"      // The broker is failing; delivery is paused
+	BreakerHalfOpen BreakerState = "half_open" // A single attempt is probing the broker
+)
+
+// PublisherStats is a snapshot of a Resilient publisher's backlog and
+// counters since startup.
+type PublisherStats struct {
+	Buffered  int          `json:"buffered"`  // Events waiting in memory
+	Spooled   int          `json:"spooled"`   // Events waiting on disk
+	Published int64        `json:"published"` // Events delivered
+	Failures  int64        `json:"failures"`  // Failed delivery attempts
+	Dropped   int64        `json:"dropped"`   // Events lost to a full buffer
+	Breaker   BreakerState `json:"breaker"`
+	LastError string       `json:"last_error,omitempty"`
+
+	// Oldest is when the oldest event still waiting was published; nil
+	// when none is.
+	Oldest *time.Time `json:"oldest,omitempty"`
+}
+
+// Resilient is a Publisher that decouples callers from the broker: Publish
+// only queues the event, and Run delivers queued events in order, retrying
+// failures with backoff behind a circuit breaker. Events are only lost when
+// both the buffer and the spool are full, or, without a spool, when the
+// process stops during an outage.
+//
+// A delivery that keeps failing is retried until it succeeds, so one event
+// the broker always rejects holds back the ones behind it.
+type Resilient struct {
+	next   Publisher
+	cfg    ResilientConfig
+	logger *slog.Logger
+	wake   chan struct{}
+
+	mu        sync.Mutex
+	buffer    []domain.Event
+	spooled   int
+	spoolHead time.Time // Timestamp of the first spooled event
+	published int64
+	failures  int64
+	dropped   int64
+	lastError string
+
+	// Breaker state, only changed by Run
+	consecutive int
+	openUntil   time.Time
+	halfOpen    bool
+}
+
+// NewResilient wraps next. Zero fields in cfg fall back to
+// DefaultResilientConfig. Events left in the spool by a previous run are
+// delivered first. Call Run to start delivering.
+func NewResilient(next Publisher, cfg ResilientConfig, logger *slog.Logger) (*Resilient, error) {
+	def := DefaultResilientConfig
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = def.BufferSize
+	}
+	if cfg.SpoolSize <= 0 {
+		cfg.SpoolSize = def.SpoolSize
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = def.Timeout
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = def.Backoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = def.MaxBackoff
+	}
+	if cfg.BreakerThreshold <= 0 {
+		cfg.BreakerThreshold = def.BreakerThreshold
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = def.BreakerCooldown
+	}
+
+	p := &Resilient{
+		next:   next,
+		cfg:    cfg,
+		logger: logger,
+		wake:   make(chan struct{}, 1),
+	}
+
+	if cfg.SpoolDir != "" {
+		if err := os.MkdirAll(cfg.SpoolDir, 0o700); err != nil {
+			return nil, err
+		}
+		events, err := p.readSpool()
+		if err != nil {
+			return nil, err
+		}
+		p.spooled = len(events)
+		if p.spooled > 0 {
+			p.spoolHead = events[0].Timestamp
+			logger.Info("event spool found", slog.Int("events", p.spooled))
+		}
+	}
+
+	return p, nil
+}
+
+// Publish queues the event and returns immediately. It only fails with
+// ErrBufferFull.
+func (p *Resilient) Publish(ctx context.Context, event domain.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.enqueueLocked(event)
+}
+
+// PublishBatch queues the events in order. Events that don't fit are
+// dropped and ErrBufferFull returned.
+func (p *Resilient) PublishBatch(ctx context.Context, events []domain.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var err error
+	for _, e := range events {
+		if qerr := p.enqueueLocked(e); qerr != nil {
+			err = qerr
+		}
+	}
+	return err
+}
+
+func (p *Resilient) enqueueLocked(event domain.Event) error {
+	defer p.signal()
+
+	// Once events are spooled new ones go behind them to keep the order
+	if p.spooled == 0 && len(p.buffer) < p.cfg.BufferSize {
+		p.buffer = append(p.buffer, event)
+		return nil
+	}
+
+	if p.cfg.SpoolDir != "" && p.spooled < p.cfg.SpoolSize {
+		err := p.appendSpool([]domain.Event{event})
+		if err == nil {
+			if p.spooled == 0 {
+				p.spoolHead = event.Timestamp
+			}
+			p.spooled++
+			return nil
+		}
+		p.logger.Error("event spool write failed", slog.String("error", err.Error()))
+	}
+
+	p.dropped++
+	p.logger.Error("event dropped",
+		slog.String("event_id", event.ID.String()),
+		slog.String("event_type", event.Type),
+		slog.String("reason", "buffer full"),
+	)
+	return ErrBufferFull
+}
+
+func (p *Resilient) signal() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Stats returns the current backlog and counters.
+func (p *Resilient) Stats() PublisherStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state := BreakerClosed
+	switch {
+	case p.halfOpen:
+		state = BreakerHalfOpen
+	case !p.openUntil.IsZero():
+		state = BreakerOpen
+	}
+
+	// The buffer is delivered before the spool
+	var oldest *time.Time
+	switch {
+	case len(p.buffer) > 0:
+		t := p.buffer[0].Timestamp
+		oldest = &t
+	case p.spooled > 0:
+		t := p.spoolHead
+		oldest = &t
+	}
+
+	return PublisherStats{
+		Buffered:  len(p.buffer),
+		Spooled:   p.spooled,
+		Published: p.published,
+		Failures:  p.failures,
+		Dropped:   p.dropped,
+		Breaker:   state,
+		LastError: p.lastError,
+		Oldest:    oldest,
+	}
+}
+
+// Run delivers queued events until ctx is cancelled. Events still buffered
+// then are moved to the spool, or logged as lost without one.
+func (p *Resilient) Run(ctx context.Context) {
+	backoff := p.cfg.Backoff
+
+	for {
+		if ctx.Err() != nil {
+			p.shutdown()
+			return
+		}
+
+		event, ok := p.next1()
+		if !ok {
+			select {
+			case <-ctx.Done():
+				p.shutdown()
+				return
+			case <-p.wake:
+				continue
+			}
+		}
+
+		if wait := p.breakerWait(); wait > 0 {
+			if !sleep(ctx, wait) {
+				p.shutdown()
+				return
+			}
+			p.mu.Lock()
+			p.halfOpen = true
+			p.mu.Unlock()
+		}
+
+		sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), p.cfg.Timeout)
+		err := p.next.Publish(sendCtx, event)
+		cancel()
+
+		if err == nil {
+			p.delivered(event)
+			backoff = p.cfg.Backoff
+			continue
+		}
+
+		p.failed(event, err)
+		if p.breakerWait() == 0 && !sleep(ctx, backoff) {
+			p.shutdown()
+			return
+		}
+		backoff = min(backoff*2, p.cfg.MaxBackoff)
+	}
+}
+
+// next1 returns the oldest queued event, refilling the buffer from the
+// spool when it runs empty.
+func (p *Resilient) next1() (domain.Event, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.buffer) == 0 && p.spooled > 0 {
+		if err := p.refillLocked(); err != nil {
+			p.logger.Error("event spool read failed", slog.String("error", err.Error()))
+		}
+	}
+	if len(p.buffer) == 0 {
+		return domain.Event{}, false
+	}
+	return p.buffer[0], true
+}
+
+func (p *Resilient) delivered(event domain.Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Close may have spooled the buffer during the attempt
+	if len(p.buffer) > 0 && p.buffer[0].ID == event.ID {
+		p.buffer[0] = domain.Event{}
+		p.buffer = p.buffer[1:]
+	}
+	p.published++
+
+	if p.halfOpen || !p.openUntil.IsZero() {
+		p.logger.Info("event broker recovered", slog.Int("backlog", len(p.buffer)+p.spooled))
+	}
+	p.consecutive = 0
+	p.openUntil = time.Time{}
+	p.halfOpen = false
+}
+
+func (p *Resilient) failed(event domain.Event, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.failures++
+	p.consecutive++
+	p.lastError = err.Error()
+
+	if p.halfOpen || p.consecutive >= p.cfg.BreakerThreshold {
+		if p.openUntil.IsZero() || p.halfOpen {
+			p.logger.Error("event broker unavailable, pausing delivery",
+				slog.Int("failures", p.consecutive),
+				slog.Int("backlog", len(p.buffer)+p.spooled),
+				slog.Duration("cooldown", p.cfg.BreakerCooldown),
+				slog.String("error", err.Error()),
+			)
+		}
+		p.openUntil = domain.Now().Add(p.cfg.BreakerCooldown)
+		p.halfOpen = false
+		return
+	}
+
+	p.logger.Warn("event publish attempt failed",
+		slog.String("event_id", event.ID.String()),
+		slog.String("event_type", event.Type),
+		slog.Int("attempt", p.consecutive),
+		slog.String("error", err.Error()),
+	)
+}
+
+// breakerWait returns how long the open breaker still pauses delivery.
+func (p *Resilient) breakerWait() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.openUntil.IsZero() {
+		return 0
+	}
+	return max(p.openUntil.Sub(domain.Now()), 0)
+}
+
+func (p *Resilient) shutdown() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.spillLocked()
+}
+
+// Close spools the buffered events and closes the underlying publisher.
+func (p *Resilient) Close() error {
+	p.mu.Lock()
+	p.spillLocked()
+	p.mu.Unlock()
+
+	return p.next.Close()
+}
+
+// spillLocked moves the buffer in front of the spool so the next run
+// delivers it first.
+func (p *Resilient) spillLocked() {
+	if len(p.buffer) == 0 {
+		return
+	}
+	if p.cfg.SpoolDir == "" {
+		p.logger.Warn("events not published before shutdown", slog.Int("count", len(p.buffer)))
+		p.buffer = nil
+		return
+	}
+
+	rest, err := p.readSpool()
+	if err == nil {
+		err = p.writeSpool(append(p.buffer, rest...))
+	}
+	if err != nil {
+		p.logger.Error("events not spooled before shutdown",
+			slog.Int("count", len(p.buffer)),
+			slog.String("error", err.Error()),
+		)
+		p.buffer = nil
+		return
+	}
+	p.spooled += len(p.buffer)
+	p.spoolHead = p.buffer[0].Timestamp
+	p.buffer = nil
+}
+
+// refillLocked moves as many spooled events as fit into the buffer.
+func (p *Resilient) refillLocked() error {
+	events, err := p.readSpool()
+	if err != nil {
+		return err
+	}
+
+	n := min(len(events), p.cfg.BufferSize)
+	if err := p.writeSpool(events[n:]); err != nil {
+		return err
+	}
+	p.buffer = append(p.buffer, events[:n]...)
+	p.spooled = len(events) - n
+	if p.spooled > 0 {
+		p.spoolHead = events[n].Timestamp
+	}
+	return nil
+}
+
+func (p *Resilient) spoolPath() string {
+	return filepath.Join(p.cfg.SpoolDir, spoolFile)
+}
+
+// readSpool returns the spooled events, oldest first. Lines that don't
+// decode (e.g. one cut short by a crash) are skipped.
+func (p *Resilient) readSpool() ([]domain.Event, error) {
+	f, err := os.Open(p.spoolPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []domain.Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var e domain.Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			p.logger.Warn("skipping corrupt spooled event", slog.String("error", err.Error()))
+			continue
+		}
+		events = append(events, e)
+	}
+	return events, scanner.Err()
+}
+
+func (p *Resilient) appendSpool(events []domain.Event) error {
+	f, err := os.OpenFile(p.spoolPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := encodeEvents(f, events); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeSpool replaces the spool with events, atomically so a crash leaves
+// either the old or the new spool.
+func (p *Resilient) writeSpool(events []domain.Event) error {
+	if len(events) == 0 {
+		err := os.Remove(p.spoolPath())
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	tmp, err := os.CreateTemp(p.cfg.SpoolDir, spoolFile+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := encodeEvents(tmp, events); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p.spoolPath())
+}
+
+func encodeEvents(f *os.File, events []domain.Event) error {
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// sleep waits for d, returning false if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,249 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// Delivery is one message received from a broker. Exactly one of Ack,
+// Requeue and DeadLetter must be called.
+type Delivery interface {
+	Body() []byte
+
+	// Ack removes the message from the broker.
+	Ack() error
+
+	// Requeue returns the message to the broker to be delivered again,
+	// e.g. because the consumer is shutting down.
+	Requeue() error
+
+	// DeadLetter moves the message aside for inspection; it won't be
+	// delivered again.
+	DeadLetter(reason error) error
+}
+
+// Subscriber receives events other services publish.
+type Subscriber interface {
+	// Subscribe starts receiving events of the given types. The channel is
+	// closed when ctx is done; implementations reconnect on their own
+	// until then.
+	Subscribe(ctx context.Context, types []string) (<-chan Delivery, error)
+
+	// Close cleanly shuts down the subscriber.
+	Close() error
+}
+
+// Handler reacts to an inbound event. Returning an error retries the event;
+// errors wrapped with Permanent dead-letter it right away.
+type Handler func(ctx context.Context, env Envelope) error
+
+// permanentError marks a handler error that retrying can't fix.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the consumer dead-letters the event instead of
+// retrying it.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// ConsumerConfig controls how a Consumer runs handlers.
+type ConsumerConfig struct {
+	Concurrency int           // Events handled at once
+	MaxAttempts int           // Attempts before an event is dead-lettered
+	Backoff     time.Duration // Delay after the first failure; doubles each attempt
+	Timeout     time.Duration // Per-attempt timeout
+}
+
+// DefaultConsumerConfig retries an event for about 15 seconds.
+var DefaultConsumerConfig = ConsumerConfig{
+	Concurrency: 4,
+	MaxAttempts: 5,
+	Backoff:     time.Second,
+	Timeout:     30 * time.Second,
+}
+
+// Consumer dispatches events from a Subscriber to the handlers registered
+// for their types, a few at a time. Failed events are retried with backoff
+// and dead-lettered after MaxAttempts; so are events that can't be decoded.
+//
+// Handlers run in the environment the event came from, and events they
+// publish carry its correlation ID.
+type Consumer struct {
+	sub    Subscriber
+	codec  Codec
+	cfg    ConsumerConfig
+	logger *slog.Logger
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+// NewConsumer creates a consumer reading from sub. Zero fields in cfg fall
+// back to DefaultConsumerConfig.
+func NewConsumer(sub Subscriber, cfg ConsumerConfig, logger *slog.Logger) *Consumer {
+	def := DefaultConsumerConfig
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = def.Concurrency
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = def.MaxAttempts
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = def.Backoff
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = def.Timeout
+	}
+
+	return &Consumer{
+		sub:      sub,
+		codec:    DefaultCodec,
+		cfg:      cfg,
+		logger:   logger,
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Handle registers h for events of eventType, replacing any previous
+// handler. Handlers must be registered before Run.
+func (c *Consumer) Handle(eventType string, h Handler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers[eventType] = h
+}
+
+// Run subscribes to the registered event types and handles events until
+// ctx is done.
+func (c *Consumer) Run(ctx context.Context) error {
+	c.mu.RLock()
+	types := make([]string, 0, len(c.handlers))
+	for t := range c.handlers {
+		types = append(types, t)
+	}
+	c.mu.RUnlock()
+
+	if len(types) == 0 {
+		return errors.New("no event handlers registered")
+	}
+
+	deliveries, err := c.sub.Subscribe(ctx, types)
+	if err != nil {
+		return fmt.Errorf("subscribing: %w", err)
+	}
+
+	var wg sync.WaitGroup
+	for range c.cfg.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range deliveries {
+				c.dispatch(ctx, d)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return nil
+}
+
+func (c *Consumer) dispatch(ctx context.Context, d Delivery) {
+	env, err := c.codec.Decode(d.Body())
+	if err != nil {
+		c.deadLetter(d, Envelope{}, fmt.Errorf("decoding event: %w", err))
+		return
+	}
+
+	c.mu.RLock()
+	handler, ok := c.handlers[env.Type]
+	c.mu.RUnlock()
+	if !ok {
+		// Not ours; the subscription shouldn't deliver these
+		c.settle(d.Ack(), env)
+		return
+	}
+
+	environment, ok := domain.ParseEnvironment(env.Environment)
+	if !ok {
+		c.deadLetter(d, env, fmt.Errorf("unknown environment %q", env.Environment))
+		return
+	}
+	handlerCtx := domain.WithEnvironment(ctx, environment)
+	if env.CorrelationID != "" {
+		handlerCtx = WithCorrelationID(handlerCtx, env.CorrelationID)
+	}
+
+	backoff := c.cfg.Backoff
+	for attempt := 1; ; attempt++ {
+		err = c.attempt(handlerCtx, handler, env)
+		if err == nil {
+			c.settle(d.Ack(), env)
+			return
+		}
+
+		var permanent permanentError
+		if errors.As(err, &permanent) || attempt >= c.cfg.MaxAttempts {
+			c.deadLetter(d, env, err)
+			return
+		}
+
+		c.logger.Warn("inbound event failed, retrying",
+			slog.String("event_id", env.ID.String()),
+			slog.String("event_type", env.Type),
+			slog.Int("attempt", attempt),
+			slog.String("error", err.Error()),
+		)
+
+		select {
+		case <-ctx.Done():
+			c.settle(d.Requeue(), env)
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// attempt runs handler once, turning a panic into an error.
+func (c *Consumer) attempt(ctx context.Context, handler Handler, env Envelope) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+
+	return handler(ctx, env)
+}
+
+func (c *Consumer) deadLetter(d Delivery, env Envelope, reason error) {
+	c.logger.Error("inbound event dead-lettered",
+		slog.String("event_id", env.ID.String()),
+		slog.String("event_type", env.Type),
+		slog.String("error", reason.Error()),
+	)
+	c.settle(d.DeadLetter(reason), env)
+}
+
+// settle logs a failed acknowledgement; the broker will redeliver the
+// event.
+func (c *Consumer) settle(err error, env Envelope) {
+	if err != nil {
+		c.logger.Warn("acknowledging inbound event failed",
+			slog.String("event_id", env.ID.String()),
+			slog.String("error", err.Error()),
+		)
+	}
+}
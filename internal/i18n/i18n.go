@@ -0,0 +1,128 @@
+// Package i18n translates the messages of API errors.
+//
+// Messages are written in English, which is also their key: a bundle maps
+// the English text of each message it translates, as it appears in error
+// responses, to its translation. Messages a bundle lacks stay in English.
+// Formats such as "validation error on %s: %s" are translated before their
+// arguments are filled in. Bundles for some languages are built in; more,
+// or overrides of the built-in ones, are loaded from a directory, so
+// products can ship their own.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+//go:embed bundles/*.json
+var bundleFS embed.FS
+
+// Bundle maps English messages to their translation in one language.
+type Bundle map[string]string
+
+// Catalog holds the bundles of every language messages are translated to.
+// Bundles are added at startup; a Catalog is then safe for concurrent use.
+type Catalog struct {
+	tags    []language.Tag // English first, the fallback
+	bundles map[language.Tag]Bundle
+	matcher language.Matcher
+}
+
+// NewCatalog returns a catalog holding the built-in bundles.
+func NewCatalog() *Catalog {
+	c := &Catalog{
+		tags:    []language.Tag{language.English},
+		bundles: make(map[language.Tag]Bundle),
+	}
+	sub, _ := fs.Sub(bundleFS, "bundles")
+	if err := c.LoadDir(sub); err != nil {
+		panic(fmt.Sprintf("i18n: built-in bundles: %v", err))
+	}
+	return c
+}
+
+// Add adds translations for a language, replacing those it already has
+// for the same messages.
+func (c *Catalog) Add(tag language.Tag, bundle Bundle) {
+	existing, ok := c.bundles[tag]
+	if !ok {
+		existing = make(Bundle, len(bundle))
+		c.bundles[tag] = existing
+		if !slices.Contains(c.tags, tag) {
+			c.tags = append(c.tags, tag)
+		}
+	}
+	for msg, translation := range bundle {
+		existing[msg] = translation
+	}
+	c.matcher = language.NewMatcher(c.tags)
+}
+
+// LoadDir adds the bundles in the top level of fsys: JSON objects mapping
+// English messages to translations, in files named after their language,
+// e.g. de.json or pt-BR.json.
+func (c *Catalog) LoadDir(fsys fs.FS) error {
+	names, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		tag, err := language.Parse(strings.TrimSuffix(path.Base(name), ".json"))
+		if err != nil {
+			return fmt.Errorf("%s: not named after a language: %w", name, err)
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		var bundle Bundle
+		if err := json.Unmarshal(data, &bundle); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		c.Add(tag, bundle)
+	}
+
+	return nil
+}
+
+// Languages returns the languages messages are translated to, English
+// first.
+func (c *Catalog) Languages() []language.Tag {
+	return c.tags
+}
+
+// Match returns the language to answer in: the first of prefs, in order,
+// that the catalog has a bundle for or is close to one (en-GB for English,
+// de-AT for German), and English if none is.
+func (c *Catalog) Match(prefs ...language.Tag) language.Tag {
+	if c.matcher == nil || len(prefs) == 0 {
+		return language.English
+	}
+	_, i, confidence := c.matcher.Match(prefs...)
+	if confidence == language.No {
+		return language.English
+	}
+	return c.tags[i]
+}
+
+// Translate returns msg in the language, or msg itself if its bundle lacks
+// it.
+func (c *Catalog) Translate(tag language.Tag, msg string) string {
+	if translation, ok := c.bundles[tag][msg]; ok && translation != "" {
+		return translation
+	}
+	return msg
+}
+
+// Sprintf translates format, then formats it with args.
+func (c *Catalog) Sprintf(tag language.Tag, format string, args ...any) string {
+	return fmt.Sprintf(c.Translate(tag, format), args...)
+}
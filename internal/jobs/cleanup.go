@@ -0,0 +1,47 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/mvaleed/aegis/internal/service"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// CleanupConfig controls the cleanup jobs.
+type CleanupConfig struct {
+	Interval time.Duration
+
+	// LoginHistoryRetention is how long login attempts are kept; 0 keeps
+	// them forever.
+	LoginHistoryRetention time.Duration
+}
+
+// AddCleanup adds the jobs deleting expired refresh tokens, action tokens,
+// idempotency keys and device authorizations, and login attempts past
+// retention.
+func AddCleanup(
+	s *Scheduler,
+	cfg CleanupConfig,
+	authService *service.AuthService,
+	actionTokens *service.ActionTokenService,
+	idempotency *service.IdempotencyService,
+	deviceAuths *service.DeviceAuthService,
+) {
+	s.Add(Job{Name: "cleanup.refresh_tokens", Interval: cfg.Interval, Run: authService.CleanupExpiredTokens})
+	s.Add(Job{Name: "cleanup.action_tokens", Interval: cfg.Interval, Run: actionTokens.CleanupExpired})
+	s.Add(Job{Name: "cleanup.idempotency_keys", Interval: cfg.Interval, Run: idempotency.CleanupExpired})
+	s.Add(Job{Name: "cleanup.device_authorizations", Interval: cfg.Interval, Run: deviceAuths.CleanupExpired})
+
+	if cfg.LoginHistoryRetention > 0 {
+		s.Add(Job{Name: "cleanup.login_history", Interval: cfg.Interval, Run: func(ctx context.Context) (int64, error) {
+			return authService.CleanupLoginHistory(ctx, cfg.LoginHistoryRetention)
+		}})
+	}
+}
+
+// AddAccessTokenCleanup adds the job deleting expired opaque access tokens
+// kept in Postgres. Redis expires them itself.
+func AddAccessTokenCleanup(s *Scheduler, interval time.Duration, tokens storage.AccessTokenRepository) {
+	s.Add(Job{Name: "cleanup.access_tokens", Interval: interval, Run: tokens.DeleteExpired})
+}
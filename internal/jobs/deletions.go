@@ -0,0 +1,13 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/mvaleed/aegis/internal/service"
+)
+
+// AddAccountDeletion adds the job deleting accounts whose scheduled
+// deletion's grace period has ended.
+func AddAccountDeletion(s *Scheduler, interval time.Duration, deletions *service.AccountDeletionService) {
+	s.Add(Job{Name: "users.finalize_deletions", Interval: interval, Run: deletions.FinalizeDue})
+}
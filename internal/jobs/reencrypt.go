@@ -0,0 +1,23 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mvaleed/aegis/internal/service"
+)
+
+// AddReencryption adds the job moving webhook secrets still encrypted under
+// a previous column key to the current one, batchSize rows at a time.
+// Secrets that fail to decrypt are left in place and fail the run, so they
+// show up in the job's logs until they're dealt with.
+func AddReencryption(s *Scheduler, interval time.Duration, batchSize int, webhooks *service.WebhookService) {
+	s.Add(Job{Name: "reencrypt.webhook_secrets", Interval: interval, Run: func(ctx context.Context) (int64, error) {
+		p, err := webhooks.ReencryptSecrets(ctx, batchSize, nil)
+		if err == nil && p.Failed > 0 {
+			err = fmt.Errorf("%d webhook secrets could not be decrypted", p.Failed)
+		}
+		return int64(p.Reencrypted), err
+	}})
+}
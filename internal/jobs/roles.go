@@ -0,0 +1,13 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/mvaleed/aegis/internal/service"
+)
+
+// AddRoleExpiry adds the job removing lapsed time-bound role assignments
+// and publishing their expiry events.
+func AddRoleExpiry(s *Scheduler, interval time.Duration, rbac *service.RBACService) {
+	s.Add(Job{Name: "roles.expire_assignments", Interval: interval, Run: rbac.ExpireRoles})
+}
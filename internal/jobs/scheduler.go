@@ -0,0 +1,212 @@
+// Package jobs runs periodic background jobs, such as deleting expired
+// tokens, on exactly one replica at a time.
+//
+// Replicas elect a leader through an Elector (a Postgres advisory lock in
+// production); only the leader runs jobs, and another replica takes over
+// when it stops or loses its database connection.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// LeaderLock is the name replicas elect the job leader under.
+const LeaderLock = "aegis.jobs"
+
+// Job is a unit of periodic work. Run returns the number of rows it
+// affected, e.g. deleted, for the job's stats.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) (int64, error)
+}
+
+// Lease is held leadership.
+type Lease interface {
+	// Lost is closed if leadership is lost before Release.
+	Lost() <-chan struct{}
+
+	// Release gives up leadership.
+	Release()
+}
+
+// Elector grants leadership to one holder at a time.
+type Elector interface {
+	// TryAcquire returns a lease on name if no one else holds it, or false
+	// without waiting.
+	TryAcquire(ctx context.Context, name string) (Lease, bool, error)
+}
+
+// JobStats is a snapshot of a job's counters since startup.
+type JobStats struct {
+	Name         string     `json:"name"`
+	Interval     string     `json:"interval"`
+	Runs         int64      `json:"runs"`
+	Failures     int64      `json:"failures"`
+	Rows         int64      `json:"rows"` // Affected by all runs, e.g. deleted
+	LastRunAt    *time.Time `json:"last_run_at,omitempty"`
+	LastDuration string     `json:"last_duration,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+}
+
+// SchedulerStats is a snapshot of a Scheduler.
+type SchedulerStats struct {
+	Leader bool       `json:"leader"` // Whether this replica runs the jobs
+	Jobs   []JobStats `json:"jobs"`
+}
+
+// Scheduler runs jobs on their intervals while this replica is the leader.
+type Scheduler struct {
+	elector    Elector
+	retryEvery time.Duration
+	logger     *slog.Logger
+
+	mu     sync.Mutex
+	jobs   []Job
+	stats  map[string]*JobStats
+	leader bool
+}
+
+// NewScheduler creates a scheduler that tries to become the leader every
+// retryEvery while another replica is.
+func NewScheduler(elector Elector, retryEvery time.Duration, logger *slog.Logger) *Scheduler {
+	if retryEvery <= 0 {
+		retryEvery = 30 * time.Second
+	}
+	return &Scheduler{
+		elector:    elector,
+		retryEvery: retryEvery,
+		logger:     logger,
+		stats:      make(map[string]*JobStats),
+	}
+}
+
+// Add registers a job. Jobs must be added before Run.
+func (s *Scheduler) Add(job Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, job)
+	s.stats[job.Name] = &JobStats{Name: job.Name, Interval: job.Interval.String()}
+}
+
+// Run competes for leadership and runs the jobs while leading, until ctx is
+// done. A job's first run is one interval after leadership is gained.
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		lease, ok, err := s.elector.TryAcquire(ctx, LeaderLock)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			s.logger.Warn("job leader election failed", slog.String("error", err.Error()))
+		case ok:
+			s.lead(ctx, lease)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.retryEvery):
+		}
+	}
+}
+
+// lead runs the jobs until ctx is done or the lease is lost.
+func (s *Scheduler) lead(ctx context.Context, lease Lease) {
+	defer lease.Release()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s.setLeader(true)
+	defer s.setLeader(false)
+	s.logger.Info("became job leader")
+
+	s.mu.Lock()
+	jobs := append([]Job(nil), s.jobs...)
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.schedule(ctx, job)
+		}()
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-lease.Lost():
+		s.logger.Warn("lost job leadership")
+		cancel()
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) schedule(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.run(ctx, job)
+		}
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	start := time.Now()
+	rows, err := job.Run(ctx)
+	duration := time.Since(start)
+
+	if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+		return // Stopped, not failed
+	}
+
+	s.mu.Lock()
+	st := s.stats[job.Name]
+	st.Runs++
+	st.Rows += rows
+	st.LastRunAt = &start
+	st.LastDuration = duration.String()
+	st.LastError = ""
+	if err != nil {
+		st.Failures++
+		st.LastError = err.Error()
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		s.logger.Error("job failed", slog.String("job", job.Name), slog.String("error", err.Error()))
+		return
+	}
+	s.logger.Debug("job completed",
+		slog.String("job", job.Name),
+		slog.Int64("rows", rows),
+		slog.Duration("duration", duration),
+	)
+}
+
+func (s *Scheduler) setLeader(leader bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.leader = leader
+}
+
+// Stats returns whether this replica is the leader and each job's counters.
+func (s *Scheduler) Stats() SchedulerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := SchedulerStats{Leader: s.leader, Jobs: make([]JobStats, len(s.jobs))}
+	for i, job := range s.jobs {
+		stats.Jobs[i] = *s.stats[job.Name]
+	}
+	return stats
+}
@@ -0,0 +1,198 @@
+// Package ldap verifies passwords against an LDAP directory, such as
+// Active Directory, and reads who the user is and which groups they're in.
+//
+// Each check opens its own connection: it searches for the user's entry,
+// bound as the service account (or anonymously without one), then binds
+// as the entry with the password given.
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+var (
+	// ErrNoEntry is returned when no entry in the directory matches a login.
+	ErrNoEntry = errors.New("ldap: no entry for login")
+
+	// ErrInvalidCredentials is returned when the password is wrong.
+	ErrInvalidCredentials = errors.New("ldap: invalid credentials")
+)
+
+// Config configures a Directory.
+type Config struct {
+	// URL of the server, ldap:// or ldaps://.
+	URL string
+
+	// StartTLS upgrades ldap:// connections to TLS before binding.
+	StartTLS bool
+
+	// BindDN is the service account searching for entries; empty searches
+	// anonymously. BindPassword is called on each connection, so a rotated
+	// password is picked up.
+	BindDN       string
+	BindPassword func() string
+
+	// BaseDN is where entries are searched for, UserFilter what they must
+	// match, with each %s replaced by the escaped login, e.g.
+	// (|(mail=%s)(userPrincipalName=%s)).
+	BaseDN     string
+	UserFilter string
+
+	// Attributes holding the email, full name and group DNs of an entry
+	EmailAttribute string
+	NameAttribute  string
+	GroupAttribute string
+
+	// Timeout bounds connecting and each request.
+	Timeout time.Duration
+}
+
+// Entry is what a Directory reads about a user.
+type Entry struct {
+	DN       string
+	Email    string
+	FullName string
+	Groups   []string // DNs
+}
+
+// Directory checks passwords against an LDAP server.
+type Directory struct {
+	cfg Config
+}
+
+// New returns a directory for cfg.
+func New(cfg Config) *Directory {
+	return &Directory{cfg: cfg}
+}
+
+// Authenticate looks the login up and binds as its entry with password.
+// It returns ErrNoEntry if no entry matches and ErrInvalidCredentials if
+// the password is wrong.
+func (d *Directory) Authenticate(ctx context.Context, login, password string) (*Entry, error) {
+	// Servers treat a bind without a password as anonymous, which succeeds
+	if password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := d.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	entry, err := d.find(conn, login)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("ldap: bind as %s: %w", entry.DN, err)
+	}
+
+	return entry, nil
+}
+
+// Ping connects and binds as the service account.
+func (d *Directory) Ping(ctx context.Context) error {
+	conn, err := d.connect(ctx)
+	if err != nil {
+		return err
+	}
+	conn.Close()
+	return nil
+}
+
+// connect dials the server, upgrades the connection if configured to and
+// binds as the service account. The timeout is cut short by ctx's deadline.
+func (d *Directory) connect(ctx context.Context) (*ldap.Conn, error) {
+	timeout := d.cfg.Timeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = min(timeout, time.Until(deadline))
+	}
+
+	u, err := url.Parse(d.cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: parse URL: %w", err)
+	}
+	tlsConfig := &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}
+
+	conn, err := ldap.DialURL(d.cfg.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: timeout}),
+		ldap.DialWithTLSConfig(tlsConfig),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: connect: %w", err)
+	}
+	conn.SetTimeout(timeout)
+
+	if d.cfg.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("ldap: start TLS: %w", err)
+		}
+	}
+
+	if d.cfg.BindDN != "" {
+		if err := conn.Bind(d.cfg.BindDN, d.cfg.BindPassword()); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("ldap: bind as service account: %w", err)
+		}
+	}
+
+	return conn, nil
+}
+
+// find searches for the one entry matching login.
+func (d *Directory) find(conn *ldap.Conn, login string) (*Entry, error) {
+	filter := strings.ReplaceAll(d.cfg.UserFilter, "%s", ldap.EscapeFilter(login))
+
+	result, err := conn.Search(ldap.NewSearchRequest(
+		d.cfg.BaseDN,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		2, // One more than needed, to tell when the login is ambiguous
+		int(d.cfg.Timeout.Seconds()),
+		false,
+		filter,
+		[]string{d.cfg.EmailAttribute, d.cfg.NameAttribute, d.cfg.GroupAttribute},
+		nil,
+	))
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, fmt.Errorf("ldap: %q matches more than one entry", login)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ldap: search: %w", err)
+	}
+
+	switch len(result.Entries) {
+	case 0:
+		return nil, ErrNoEntry
+	case 1:
+	default:
+		return nil, fmt.Errorf("ldap: %q matches more than one entry", login)
+	}
+
+	e := result.Entries[0]
+	entry := &Entry{
+		DN:       e.DN,
+		Email:    strings.ToLower(strings.TrimSpace(e.GetAttributeValue(d.cfg.EmailAttribute))),
+		FullName: strings.TrimSpace(e.GetAttributeValue(d.cfg.NameAttribute)),
+		Groups:   e.GetAttributeValues(d.cfg.GroupAttribute),
+	}
+	if entry.Email == "" {
+		return nil, fmt.Errorf("ldap: entry %s has no %s", e.DN, d.cfg.EmailAttribute)
+	}
+	return entry, nil
+}
@@ -0,0 +1,187 @@
+// Package logging keeps personal data and secrets out of logs.
+//
+// Handler wraps another slog.Handler and rewrites every record before it is
+// written: attributes whose keys name a secret (passwords, tokens, API keys,
+// cookies) are replaced outright, emails and phone numbers are masked or
+// replaced depending on the Level, and the same patterns are scrubbed from
+// messages, string values and errors so data that ends up in free text is
+// caught too. Installing it on the root logger covers every transport and
+// service, since they all log through loggers derived from it.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Level is how much personal data is redacted. Secrets are redacted at
+// every level but LevelNone.
+type Level string
+
+const (
+	LevelNone    Level = "none"    // Log everything as is; local debugging only
+	LevelPartial Level = "partial" // Mask emails and phone numbers, keeping enough to tell them apart
+	LevelFull    Level = "full"    // Replace emails and phone numbers entirely
+)
+
+// ParseLevel parses a redaction level name.
+func ParseLevel(s string) (Level, error) {
+	switch l := Level(strings.ToLower(strings.TrimSpace(s))); l {
+	case LevelNone, LevelPartial, LevelFull:
+		return l, nil
+	default:
+		return "", fmt.Errorf("unknown redaction level %q (want none, partial or full)", s)
+	}
+}
+
+// Redacted replaces values that can't be shown at all.
+const Redacted = "[REDACTED]"
+
+// secretKeys are substrings of attribute keys whose string and arbitrary
+// values are always redacted; numbers and the like (a token count) are not.
+var secretKeys = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"authorization",
+	"cookie",
+	"api_key",
+	"apikey",
+	"private_key",
+}
+
+var (
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	phonePattern  = regexp.MustCompile(`\+[1-9]\d{7,14}\b`)
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`)
+	bearerPattern = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/\-]+=*`)
+	paramPattern  = regexp.MustCompile(`(?i)\b(token|code|key|secret|password)=[^&\s"']+`)
+)
+
+// Handler is a slog.Handler that redacts records before passing them on.
+type Handler struct {
+	next  slog.Handler
+	level Level
+}
+
+// NewHandler redacts records at level and writes them to next.
+func NewHandler(next slog.Handler, level Level) *Handler {
+	return &Handler{next: next, level: level}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	if h.level == LevelNone {
+		return h.next.Handle(ctx, r)
+	}
+
+	redacted := slog.NewRecord(r.Time, r.Level, h.redactString(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.redactAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if h.level != LevelNone {
+		redacted := make([]slog.Attr, len(attrs))
+		for i, a := range attrs {
+			redacted[i] = h.redactAttr(a)
+		}
+		attrs = redacted
+	}
+	return &Handler{next: h.next.WithAttrs(attrs), level: h.level}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name), level: h.level}
+}
+
+func (h *Handler) redactAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	key := strings.ToLower(a.Key)
+
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		redacted := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			redacted[i] = h.redactAttr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(redacted...)}
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		s := a.Value.String()
+		switch {
+		case isSecretKey(key):
+			return slog.String(a.Key, Redacted)
+		case strings.Contains(key, "email"):
+			return slog.String(a.Key, h.maskEmail(s))
+		case strings.Contains(key, "phone"):
+			return slog.String(a.Key, h.maskPhone(s))
+		}
+		return slog.String(a.Key, h.redactString(s))
+	case slog.KindAny:
+		if isSecretKey(key) {
+			return slog.String(a.Key, Redacted)
+		}
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, h.redactString(err.Error()))
+		}
+	}
+
+	return a
+}
+
+// redactString scrubs secrets, emails and phone numbers out of free text.
+func (h *Handler) redactString(s string) string {
+	s = jwtPattern.ReplaceAllString(s, Redacted)
+	s = bearerPattern.ReplaceAllString(s, "$1 "+Redacted)
+	s = paramPattern.ReplaceAllString(s, "$1="+Redacted)
+	s = emailPattern.ReplaceAllStringFunc(s, h.maskEmail)
+	s = phonePattern.ReplaceAllStringFunc(s, h.maskPhone)
+	return s
+}
+
+// maskEmail keeps the first character and the domain under LevelPartial:
+// "jane@example.com" becomes "j***@example.com".
+func (h *Handler) maskEmail(email string) string {
+	if email == "" {
+		return email
+	}
+	local, domain, ok := strings.Cut(email, "@")
+	if h.level != LevelPartial || !ok || local == "" {
+		return Redacted
+	}
+	return local[:1] + "***@" + domain
+}
+
+// maskPhone keeps the last four digits under LevelPartial.
+func (h *Handler) maskPhone(phone string) string {
+	if phone == "" {
+		return phone
+	}
+	if h.level != LevelPartial || len(phone) <= 4 {
+		return Redacted
+	}
+	return "***" + phone[len(phone)-4:]
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ReplaceAll(key, "-", "_")
+	for _, s := range secretKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+// Package notify delivers user notifications (email, SMS).
+//
+// Services describe what to send as a Notification: a template name, a
+// channel, a recipient and template data. A Dispatcher renders it and hands
+// the Message to the Notifier for its channel. Queue is the Dispatcher used
+// in production; it renders synchronously, so template mistakes surface to
+// the caller, and delivers asynchronously with retries.
+//
+// Adding a provider means implementing Notifier and wiring it up in
+// main.go, like event publishers.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+// Channel is the medium a notification is delivered over.
+type Channel string
+
+const (
+	ChannelEmail Channel = "email"
+	ChannelSMS   Channel = "sms"
+	ChannelPush  Channel = "push"
+)
+
+// Template names. Each has a file per channel it supports in templates/.
+const (
+	TemplateInvitation        = "invitation"
+	TemplatePasswordReset     = "password_reset"
+	TemplateEmailVerification = "email_verification"
+	TemplateSuspiciousLogin   = "suspicious_login"
+	TemplateLoginConfirmation = "login_confirmation"
+	TemplateEmailChange       = "email_change"
+	TemplateEmailChangeNotice = "email_change_notice"
+)
+
+// ErrNoNotifier is returned when no Notifier is configured for a channel.
+var ErrNoNotifier = errors.New("no notifier for channel")
+
+// Notification is a request to notify someone using a template.
+type Notification struct {
+	Channel  Channel
+	To       string // Email address or E.164 phone number
+	Template string
+	Data     map[string]any
+
+	// UserID is the user notified, whose preferences apply; nil for
+	// recipients who aren't users yet, such as invitees.
+	UserID uuid.UUID
+}
+
+// Message is a rendered notification, ready to deliver.
+type Message struct {
+	Channel  Channel
+	To       string
+	Template string // Kept for logging
+	Subject  string // Email only
+	Text     string
+	HTML     string // Email only; optional
+
+	// Data is the template data, kept so the dev outbox can show tokens and
+	// codes without parsing them out of the text.
+	Data map[string]any
+}
+
+// Notifier delivers rendered messages over one channel.
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Dispatcher accepts notifications for delivery. Services depend on this
+// rather than on a Notifier so delivery can be queued.
+type Dispatcher interface {
+	Dispatch(ctx context.Context, n Notification) error
+}
+
+// Router sends each message with the Notifier registered for its channel.
+type Router map[Channel]Notifier
+
+func (r Router) Send(ctx context.Context, msg Message) error {
+	n, ok := r[msg.Channel]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrNoNotifier, msg.Channel)
+	}
+	return n.Send(ctx, msg)
+}
+
+// LoggingNotifier implements Notifier by logging messages. Bodies, which
+// can carry tokens, are only logged at debug level.
+type LoggingNotifier struct {
+	logger *slog.Logger
+}
+
+func NewLoggingNotifier(logger *slog.Logger) *LoggingNotifier {
+	return &LoggingNotifier{logger: logger}
+}
+
+func (n *LoggingNotifier) Send(ctx context.Context, msg Message) error {
+	n.logger.Info("notification sent",
+		slog.String("channel", string(msg.Channel)),
+		slog.String("to", msg.To),
+		slog.String("template", msg.Template),
+		slog.String("subject", msg.Subject),
+	)
+	n.logger.Debug("notification body",
+		slog.String("template", msg.Template),
+		slog.String("text", msg.Text),
+	)
+	return nil
+}
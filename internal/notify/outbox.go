@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// CapturedMessage is a message the Outbox has seen.
+type CapturedMessage struct {
+	ID     int64
+	SentAt time.Time
+	Message
+}
+
+// OutboxFilter narrows Outbox.Messages. Empty fields match everything.
+type OutboxFilter struct {
+	Channel  Channel
+	To       string
+	Template string
+}
+
+func (f OutboxFilter) matches(m CapturedMessage) bool {
+	return (f.Channel == "" || m.Channel == f.Channel) &&
+		(f.To == "" || m.To == f.To) &&
+		(f.Template == "" || m.Template == f.Template)
+}
+
+// Outbox keeps the most recent messages in memory so they can be read back
+// through the dev API, letting integration tests and frontend developers
+// pick up verification links and codes without real email or SMS. It is
+// for development and sandbox environments only.
+type Outbox struct {
+	mu       sync.Mutex
+	size     int
+	nextID   int64
+	messages []CapturedMessage // Oldest first
+}
+
+// NewOutbox keeps up to size messages, dropping the oldest beyond that.
+func NewOutbox(size int) *Outbox {
+	if size <= 0 {
+		size = 100
+	}
+	return &Outbox{size: size}
+}
+
+// Capture returns a Notifier that records each message in the outbox and
+// then sends it with next, if next isn't nil.
+func (o *Outbox) Capture(next Notifier) Notifier {
+	return NotifierFunc(func(ctx context.Context, msg Message) error {
+		o.add(msg)
+		if next == nil {
+			return nil
+		}
+		return next.Send(ctx, msg)
+	})
+}
+
+func (o *Outbox) add(msg Message) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.nextID++
+	o.messages = append(o.messages, CapturedMessage{
+		ID:      o.nextID,
+		SentAt:  time.Now().UTC(),
+		Message: msg,
+	})
+	if len(o.messages) > o.size {
+		o.messages = append([]CapturedMessage(nil), o.messages[len(o.messages)-o.size:]...)
+	}
+}
+
+// Messages returns the captured messages matching filter, newest first.
+func (o *Outbox) Messages(filter OutboxFilter) []CapturedMessage {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	matched := make([]CapturedMessage, 0)
+	for i := len(o.messages) - 1; i >= 0; i-- {
+		if filter.matches(o.messages[i]) {
+			matched = append(matched, o.messages[i])
+		}
+	}
+	return matched
+}
+
+// Get returns the captured message with the given ID.
+func (o *Outbox) Get(id string) (CapturedMessage, bool) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return CapturedMessage{}, false
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, m := range o.messages {
+		if m.ID == n {
+			return m, true
+		}
+	}
+	return CapturedMessage{}, false
+}
+
+// Clear removes every captured message.
+func (o *Outbox) Clear() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = nil
+}
+
+// NotifierFunc adapts a function to a Notifier.
+type NotifierFunc func(ctx context.Context, msg Message) error
+
+func (f NotifierFunc) Send(ctx context.Context, msg Message) error {
+	return f(ctx, msg)
+}
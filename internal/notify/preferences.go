@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Category groups templates for users' notification preferences.
+type Category string
+
+const (
+	// CategoryAccount is links and codes the user asked for, such as
+	// verification and confirmation emails. They are always sent.
+	CategoryAccount Category = "account"
+
+	// CategorySecurity is alerts about the account: sign-ins from new
+	// devices and email changes.
+	CategorySecurity Category = "security"
+)
+
+// Categories users can set preferences for.
+var Categories = []Category{CategorySecurity}
+
+// Channels users can set preferences for. No Notifier ships for push yet,
+// but preferences for it can be kept ahead of one.
+var Channels = []Channel{ChannelEmail, ChannelSMS, ChannelPush}
+
+var templateCategories = map[string]Category{
+	TemplateSuspiciousLogin:   CategorySecurity,
+	TemplateEmailChangeNotice: CategorySecurity,
+}
+
+// CategoryOf returns the category of a template; templates not listed are
+// account notifications.
+func CategoryOf(template string) Category {
+	if c, ok := templateCategories[template]; ok {
+		return c
+	}
+	return CategoryAccount
+}
+
+// Preferences reports whether a user wants notifications of a category over
+// a channel.
+type Preferences interface {
+	Allows(ctx context.Context, userID uuid.UUID, category Category, channel Channel) (bool, error)
+}
+
+// Filter is a Dispatcher dropping notifications their user has opted out
+// of before passing the rest on. Account notifications and ones not meant
+// for a user, such as invitations, always pass, as do notifications whose
+// preferences can't be read: an alert sent anyway beats one lost.
+type Filter struct {
+	next  Dispatcher
+	prefs Preferences
+}
+
+func NewFilter(next Dispatcher, prefs Preferences) *Filter {
+	return &Filter{next: next, prefs: prefs}
+}
+
+func (f *Filter) Dispatch(ctx context.Context, n Notification) error {
+	category := CategoryOf(n.Template)
+	if n.UserID != uuid.Nil && category != CategoryAccount {
+		if allowed, err := f.prefs.Allows(ctx, n.UserID, category, n.Channel); err == nil && !allowed {
+			return nil
+		}
+	}
+	return f.next.Dispatch(ctx, n)
+}
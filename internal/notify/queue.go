@@ -0,0 +1,151 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrQueueFull is returned by Queue.Dispatch when the queue is at capacity.
+var ErrQueueFull = errors.New("notification queue is full")
+
+// QueueConfig controls asynchronous delivery.
+type QueueConfig struct {
+	Size        int           // Messages buffered before Dispatch fails
+	Workers     int           // Messages sent in parallel
+	MaxAttempts int           // Attempts per message before it is dropped
+	Backoff     time.Duration // Delay after the first failure; doubles each attempt
+	Timeout     time.Duration // Per-attempt timeout
+}
+
+// DefaultQueueConfig retries a failing message for about half a minute.
+var DefaultQueueConfig = QueueConfig{
+	Size:        1000,
+	Workers:     4,
+	MaxAttempts: 4,
+	Backoff:     2 * time.Second,
+	Timeout:     30 * time.Second,
+}
+
+// Queue is a Dispatcher that renders notifications immediately and
+// delivers them in the background. The queue is in memory: messages still
+// queued when the process stops are lost.
+type Queue struct {
+	notifier  Notifier
+	templates *Templates
+	cfg       QueueConfig
+	logger    *slog.Logger
+	messages  chan Message
+}
+
+// NewQueue creates a queue. Zero fields in cfg fall back to
+// DefaultQueueConfig. Call Run to start delivering.
+func NewQueue(notifier Notifier, templates *Templates, cfg QueueConfig, logger *slog.Logger) *Queue {
+	def := DefaultQueueConfig
+	if cfg.Size <= 0 {
+		cfg.Size = def.Size
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = def.Workers
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = def.MaxAttempts
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = def.Backoff
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = def.Timeout
+	}
+
+	return &Queue{
+		notifier:  notifier,
+		templates: templates,
+		cfg:       cfg,
+		logger:    logger,
+		messages:  make(chan Message, cfg.Size),
+	}
+}
+
+// Dispatch renders the notification and queues it. It never blocks.
+func (q *Queue) Dispatch(ctx context.Context, n Notification) error {
+	msg, err := q.templates.Render(n)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case q.messages <- msg:
+		return nil
+	default:
+		q.logger.Error("notification dropped",
+			slog.String("template", msg.Template),
+			slog.String("channel", string(msg.Channel)),
+			slog.String("reason", "queue full"),
+		)
+		return ErrQueueFull
+	}
+}
+
+// Run delivers queued messages until ctx is cancelled.
+func (q *Queue) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range q.cfg.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case msg := <-q.messages:
+					q.deliver(ctx, msg)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := len(q.messages); n > 0 {
+		q.logger.Warn("notifications not delivered before shutdown", slog.Int("count", n))
+	}
+}
+
+func (q *Queue) deliver(ctx context.Context, msg Message) {
+	backoff := q.cfg.Backoff
+
+	for attempt := 1; ; attempt++ {
+		sendCtx, cancel := context.WithTimeout(ctx, q.cfg.Timeout)
+		err := q.notifier.Send(sendCtx, msg)
+		cancel()
+		if err == nil {
+			return
+		}
+
+		if attempt >= q.cfg.MaxAttempts || errors.Is(err, ErrNoNotifier) {
+			q.logger.Error("notification failed",
+				slog.String("template", msg.Template),
+				slog.String("channel", string(msg.Channel)),
+				slog.Int("attempts", attempt),
+				slog.String("error", err.Error()),
+			)
+			return
+		}
+
+		q.logger.Warn("notification attempt failed",
+			slog.String("template", msg.Template),
+			slog.String("channel", string(msg.Channel)),
+			slog.Int("attempt", attempt),
+			slog.String("error", err.Error()),
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// SMSConfig configures the HTTP SMS provider notifier.
+type SMSConfig struct {
+	URL     string // Provider endpoint messages are POSTed to
+	APIKey  string // Sent as a bearer token
+	From    string // Sender ID or number
+	Timeout time.Duration
+}
+
+// HTTPSMSNotifier sends SMS through a provider's HTTP API. Each message is
+// POSTed as JSON {"from", "to", "body"}; any 2xx response is success.
+// Providers with a different API can be fronted by a small adapter.
+type HTTPSMSNotifier struct {
+	cfg    SMSConfig
+	client *http.Client
+}
+
+func NewHTTPSMSNotifier(cfg SMSConfig) (*HTTPSMSNotifier, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("SMS provider URL is required")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	return &HTTPSMSNotifier{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+type smsRequest struct {
+	From string `json:"from,omitempty"`
+	To   string `json:"to"`
+	Body string `json:"body"`
+}
+
+func (n *HTTPSMSNotifier) Send(ctx context.Context, msg Message) error {
+	if msg.Channel != ChannelSMS {
+		return fmt.Errorf("%w %q", ErrNoNotifier, msg.Channel)
+	}
+
+	payload, err := json.Marshal(smsRequest{From: n.cfg.From, To: msg.To, Body: msg.Text})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.cfg.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+n.cfg.APIKey)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("SMS provider returned %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+
+	return nil
+}
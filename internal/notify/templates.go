@@ -0,0 +1,107 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Templates renders notifications from the embedded templates.
+//
+// Templates live in templates/<name>.<channel>.tmpl. Email templates define
+// "subject", "text" and optionally "html"; SMS templates define "text". The
+// data passed to them also carries BaseURL, the public URL links point to.
+type Templates struct {
+	baseURL string
+	text    map[string]*template.Template
+	html    map[string]*htmltemplate.Template
+}
+
+// NewTemplates parses the embedded templates.
+func NewTemplates(baseURL string) (*Templates, error) {
+	t := &Templates{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		text:    make(map[string]*template.Template),
+		html:    make(map[string]*htmltemplate.Template),
+	}
+
+	entries, err := templateFS.ReadDir("templates")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		key := strings.TrimSuffix(e.Name(), ".tmpl")
+		content, err := templateFS.ReadFile("templates/" + e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		text, err := template.New(key).Option("missingkey=error").Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", e.Name(), err)
+		}
+		t.text[key] = text
+
+		if text.Lookup("html") != nil {
+			html, err := htmltemplate.New(key).Option("missingkey=error").Parse(string(content))
+			if err != nil {
+				return nil, fmt.Errorf("parsing %s: %w", e.Name(), err)
+			}
+			t.html[key] = html
+		}
+	}
+
+	return t, nil
+}
+
+// Render renders a notification into a message.
+func (t *Templates) Render(n Notification) (Message, error) {
+	key := n.Template + "." + string(n.Channel)
+	text, ok := t.text[key]
+	if !ok {
+		return Message{}, fmt.Errorf("no %s template %q", n.Channel, n.Template)
+	}
+
+	data := make(map[string]any, len(n.Data)+1)
+	for k, v := range n.Data {
+		data[k] = v
+	}
+	data["BaseURL"] = t.baseURL
+
+	msg := Message{Channel: n.Channel, To: n.To, Template: n.Template, Data: n.Data}
+
+	var buf bytes.Buffer
+	if text.Lookup("subject") != nil {
+		if err := text.ExecuteTemplate(&buf, "subject", data); err != nil {
+			return Message{}, fmt.Errorf("rendering %s subject: %w", key, err)
+		}
+		msg.Subject = strings.TrimSpace(buf.String())
+		buf.Reset()
+	}
+
+	if err := text.ExecuteTemplate(&buf, "text", data); err != nil {
+		return Message{}, fmt.Errorf("rendering %s text: %w", key, err)
+	}
+	msg.Text = strings.TrimSpace(buf.String())
+
+	if html, ok := t.html[key]; ok {
+		buf.Reset()
+		if err := html.ExecuteTemplate(&buf, "html", data); err != nil {
+			return Message{}, fmt.Errorf("rendering %s html: %w", key, err)
+		}
+		msg.HTML = strings.TrimSpace(buf.String())
+	}
+
+	if n.Channel == ChannelEmail && msg.Subject == "" {
+		return Message{}, fmt.Errorf("%s has no subject", key)
+	}
+
+	return msg, nil
+}
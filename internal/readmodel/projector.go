@@ -0,0 +1,105 @@
+// Package readmodel keeps denormalized read models in step with the
+// source tables.
+//
+// The user directory (storage.UserDirectoryRepository) is refreshed for the
+// affected user whenever an event is published, and reconciled in full on an
+// interval to pick up changes that don't emit user events (role renames,
+// session expiry) or whose refresh failed.
+package readmodel
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// Projector is an event.Publisher that refreshes the user directory for
+// each event's user before forwarding the event to next. Projection failures
+// are logged, not returned: the directory is a derived view and Reconcile
+// repairs it.
+type Projector struct {
+	next      event.Publisher
+	directory storage.UserDirectoryRepository
+	logger    *slog.Logger
+}
+
+// NewProjector wraps next with read model projection.
+func NewProjector(next event.Publisher, directory storage.UserDirectoryRepository, logger *slog.Logger) *Projector {
+	return &Projector{next: next, directory: directory, logger: logger}
+}
+
+func (p *Projector) Publish(ctx context.Context, e domain.Event) error {
+	p.apply(ctx, e)
+	return p.next.Publish(ctx, e)
+}
+
+func (p *Projector) PublishBatch(ctx context.Context, events []domain.Event) error {
+	for _, e := range events {
+		p.apply(ctx, e)
+	}
+	return p.next.PublishBatch(ctx, events)
+}
+
+func (p *Projector) Close() error {
+	return p.next.Close()
+}
+
+func (p *Projector) apply(ctx context.Context, e domain.Event) {
+	if err := p.project(ctx, e); err != nil {
+		p.logger.Error("user directory projection failed",
+			slog.String("event_id", e.ID.String()),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+func (p *Projector) project(ctx context.Context, e domain.Event) error {
+	if e.UserID == uuid.Nil {
+		return nil
+	}
+
+	if err := p.directory.Refresh(ctx, e.UserID); err != nil {
+		return fmt.Errorf("refreshing user directory for %s: %w", e.UserID, err)
+	}
+
+	if e.Type == domain.EventUserLoggedIn {
+		if err := p.directory.RecordLogin(ctx, e.UserID, e.Timestamp); err != nil {
+			return fmt.Errorf("recording login for %s: %w", e.UserID, err)
+		}
+	}
+
+	return nil
+}
+
+// Reconcile rebuilds the whole directory every interval until ctx is cancelled.
+func (p *Projector) Reconcile(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := time.Now()
+			n, err := p.directory.RefreshAll(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					p.logger.Error("user directory reconciliation failed", slog.String("error", err.Error()))
+				}
+				continue
+			}
+			p.logger.Debug("user directory reconciled",
+				slog.Int64("rows", n),
+				slog.Duration("duration", time.Since(start)),
+			)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Package risk provides risk assessment for authentication and other
+// sensitive operations.
+//
+// The Engine interface is invoked by the service layer before it issues tokens
+// or applies security-relevant changes. Implementations return a score and a
+// recommended action; the service decides how to enforce it. Two
+// implementations are provided: a local rules engine and a client for an
+// external scoring service.
+package risk
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Operation identifies what the caller is trying to do.
+type Operation string
+
+const (
+	OperationRegister       Operation = "register"
+	OperationLogin          Operation = "login"
+	OperationRefresh        Operation = "refresh"
+	OperationPasswordChange Operation = "password_change"
+)
+
+// Action is the recommended response to an assessment.
+type Action string
+
+const (
+	ActionAllow     Action = "allow"
+	ActionChallenge Action = "challenge" // require a second factor before proceeding
+	ActionBlock     Action = "block"
+)
+
+// Signal describes the operation being assessed.
+type Signal struct {
+	Operation Operation
+	UserID    uuid.UUID
+	Email     string
+	IPAddress string
+	UserAgent string
+	Timestamp time.Time
+
+	// Attributes carries additional signals (e.g. device fingerprint,
+	// email reputation) that specific rules may look at.
+	Attributes map[string]string
+}
+
+// Attribute keys set by the service layer.
+const (
+	AttributeEmailDisposable = "email_disposable" // "true" for throwaway addresses
+)
+
+// Assessment is the result of evaluating a Signal.
+type Assessment struct {
+	Score   int // 0 (no risk) to 100 (certain abuse)
+	Action  Action
+	Reasons []string
+}
+
+// Engine evaluates the risk of an operation.
+type Engine interface {
+	Evaluate(ctx context.Context, signal Signal) (Assessment, error)
+}
+
+// AllowAll is an Engine that never flags anything.
+// Use it when risk assessment is disabled.
+type AllowAll struct{}
+
+func (AllowAll) Evaluate(ctx context.Context, signal Signal) (Assessment, error) {
+	return Assessment{Action: ActionAllow}, nil
+}
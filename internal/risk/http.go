@@ -0,0 +1,101 @@
+package risk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HTTPEngine delegates assessment to an external scoring service.
+//
+// The service receives the signal as JSON via POST and must respond with
+// {"score": int, "action": "allow"|"challenge"|"block", "reasons": [...]}.
+// If action is omitted it is derived from score using the thresholds.
+type HTTPEngine struct {
+	endpoint   string
+	client     *http.Client
+	thresholds Thresholds
+	failOpen   bool
+}
+
+// NewHTTPEngine creates an engine that calls endpoint. When failOpen is true,
+// errors talking to the service result in ActionAllow instead of an error.
+func NewHTTPEngine(endpoint string, timeout time.Duration, thresholds Thresholds, failOpen bool) *HTTPEngine {
+	return &HTTPEngine{
+		endpoint:   endpoint,
+		client:     &http.Client{Timeout: timeout},
+		thresholds: thresholds,
+		failOpen:   failOpen,
+	}
+}
+
+type httpSignal struct {
+	Operation  string            `json:"operation"`
+	UserID     string            `json:"user_id"`
+	Email      string            `json:"email,omitempty"`
+	IPAddress  string            `json:"ip_address,omitempty"`
+	UserAgent  string            `json:"user_agent,omitempty"`
+	Timestamp  time.Time         `json:"timestamp"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+type httpAssessment struct {
+	Score   int      `json:"score"`
+	Action  string   `json:"action"`
+	Reasons []string `json:"reasons"`
+}
+
+func (e *HTTPEngine) Evaluate(ctx context.Context, signal Signal) (Assessment, error) {
+	a, err := e.evaluate(ctx, signal)
+	if err != nil && e.failOpen {
+		return Assessment{Action: ActionAllow, Reasons: []string{"risk_service_unavailable"}}, nil
+	}
+	return a, err
+}
+
+func (e *HTTPEngine) evaluate(ctx context.Context, signal Signal) (Assessment, error) {
+	body, err := json.Marshal(httpSignal{
+		Operation:  string(signal.Operation),
+		UserID:     signal.UserID.String(),
+		Email:      signal.Email,
+		IPAddress:  signal.IPAddress,
+		UserAgent:  signal.UserAgent,
+		Timestamp:  signal.Timestamp,
+		Attributes: signal.Attributes,
+	})
+	if err != nil {
+		return Assessment{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return Assessment{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return Assessment{}, fmt.Errorf("calling risk service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Assessment{}, fmt.Errorf("risk service returned status %d", resp.StatusCode)
+	}
+
+	var out httpAssessment
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return Assessment{}, fmt.Errorf("decoding risk service response: %w", err)
+	}
+
+	a := Assessment{Score: out.Score, Action: Action(out.Action), Reasons: out.Reasons}
+	switch a.Action {
+	case ActionAllow, ActionChallenge, ActionBlock:
+	default:
+		a.Action = e.thresholds.actionFor(a.Score)
+	}
+	return a, nil
+}
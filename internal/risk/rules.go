@@ -0,0 +1,146 @@
+package risk
+
+import (
+	"context"
+	"net"
+	"strings"
+)
+
+// Rule contributes to the score of a Signal.
+// A rule returns 0 when it doesn't apply.
+type Rule interface {
+	Name() string
+	Score(ctx context.Context, signal Signal) int
+}
+
+// Thresholds map a score to an action.
+type Thresholds struct {
+	Challenge int
+	Block     int
+}
+
+// DefaultThresholds returns the thresholds configuration starts from, for
+// RISK_CHALLENGE_THRESHOLD and RISK_BLOCK_THRESHOLD left unset.
+func DefaultThresholds() Thresholds {
+	return Thresholds{Challenge: 50, Block: 90}
+}
+
+// RulesEngine is the built-in Engine. It sums the scores of its rules and
+// maps the total onto an action using the configured thresholds.
+type RulesEngine struct {
+	rules      []Rule
+	thresholds Thresholds
+}
+
+func NewRulesEngine(thresholds Thresholds, rules ...Rule) *RulesEngine {
+	return &RulesEngine{rules: rules, thresholds: thresholds}
+}
+
+func (e *RulesEngine) Evaluate(ctx context.Context, signal Signal) (Assessment, error) {
+	var a Assessment
+	for _, rule := range e.rules {
+		if score := rule.Score(ctx, signal); score > 0 {
+			a.Score += score
+			a.Reasons = append(a.Reasons, rule.Name())
+		}
+	}
+	if a.Score > 100 {
+		a.Score = 100
+	}
+	a.Action = e.thresholds.actionFor(a.Score)
+	return a, nil
+}
+
+func (t Thresholds) actionFor(score int) Action {
+	switch {
+	case t.Block > 0 && score >= t.Block:
+		return ActionBlock
+	case t.Challenge > 0 && score >= t.Challenge:
+		return ActionChallenge
+	default:
+		return ActionAllow
+	}
+}
+
+// BlockedNetworks scores requests originating from any of the given networks.
+type BlockedNetworks struct {
+	networks []*net.IPNet
+	score    int
+}
+
+// NewBlockedNetworks parses CIDRs (or bare IPs) into a rule.
+func NewBlockedNetworks(cidrs []string, score int) (*BlockedNetworks, error) {
+	r := &BlockedNetworks{score: score}
+	for _, c := range cidrs {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		if !strings.Contains(c, "/") {
+			if strings.Contains(c, ":") {
+				c += "/128"
+			} else {
+				c += "/32"
+			}
+		}
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			return nil, err
+		}
+		r.networks = append(r.networks, n)
+	}
+	return r, nil
+}
+
+func (r *BlockedNetworks) Name() string { return "blocked_network" }
+
+func (r *BlockedNetworks) Score(ctx context.Context, signal Signal) int {
+	ip := net.ParseIP(signal.IPAddress)
+	if ip == nil {
+		return 0
+	}
+	for _, n := range r.networks {
+		if n.Contains(ip) {
+			return r.score
+		}
+	}
+	return 0
+}
+
+// MissingUserAgent scores requests that don't send a User-Agent.
+// Scripts and credential-stuffing tools often omit it.
+type MissingUserAgent struct {
+	score int
+}
+
+func NewMissingUserAgent(score int) *MissingUserAgent {
+	return &MissingUserAgent{score: score}
+}
+
+func (r *MissingUserAgent) Name() string { return "missing_user_agent" }
+
+func (r *MissingUserAgent) Score(ctx context.Context, signal Signal) int {
+	if strings.TrimSpace(signal.UserAgent) == "" {
+		return r.score
+	}
+	return 0
+}
+
+// DisposableEmail scores operations on throwaway email addresses, which
+// are common in fake signups.
+type DisposableEmail struct {
+	score int
+}
+
+func NewDisposableEmail(score int) *DisposableEmail {
+	return &DisposableEmail{score: score}
+}
+
+func (r *DisposableEmail) Name() string { return "disposable_email" }
+
+func (r *DisposableEmail) Score(ctx context.Context, signal Signal) int {
+	if signal.Attributes[AttributeEmailDisposable] == "true" {
+		return r.score
+	}
+	return 0
+}
@@ -0,0 +1,328 @@
+// Package saml implements the service provider side of SAML 2.0 Web
+// Browser SSO: metadata for identity providers to import, AuthnRequests
+// sent with the HTTP-Redirect binding, and validation of the signed
+// responses they post back.
+//
+// Only what's signed is read: the assertion's own enveloped signature, or
+// the response's when the assertion has none, must verify against the
+// identity provider's certificate, and the assertion is read from the
+// canonical form that was verified. Signatures use exclusive
+// canonicalization and RSA with SHA-256 or SHA-512. Encrypted assertions
+// aren't supported.
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SAML namespaces, bindings and formats
+const (
+	nsAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+	nsProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	nsMetadata  = "urn:oasis:names:tc:SAML:2.0:metadata"
+
+	bindingHTTPPost   = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	statusSuccess     = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	confirmBearer     = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	nameIDUnspecified = "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+)
+
+// ErrInvalidResponse is returned for responses that aren't a successful,
+// current assertion meant for the service provider.
+var ErrInvalidResponse = errors.New("saml: invalid response")
+
+// ServiceProvider is aegis as the service provider of one identity
+// provider.
+type ServiceProvider struct {
+	// EntityID identifies the service provider to the identity provider,
+	// and ACSURL is where responses are posted.
+	EntityID string
+	ACSURL   string
+
+	// IdPEntityID is the identity provider's issuer, IdPSSOURL where
+	// AuthnRequests are sent, and IdPCertificates what its responses may
+	// be signed with.
+	IdPEntityID     string
+	IdPSSOURL       string
+	IdPCertificates []*x509.Certificate
+
+	// ClockSkew is allowed either way on the assertion's validity.
+	ClockSkew time.Duration
+}
+
+// Assertion is what a verified response says about the user.
+type Assertion struct {
+	ID           string
+	NameID       string
+	Attributes   map[string][]string // By Name and by FriendlyName
+	NotOnOrAfter time.Time           // After which the assertion can't be replayed
+}
+
+// Attribute returns the first value of the attribute name.
+func (a *Assertion) Attribute(name string) string {
+	if values := a.Attributes[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Metadata returns the service provider's metadata.
+func (sp *ServiceProvider) Metadata() []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	fmt.Fprintf(&b, `<md:EntityDescriptor xmlns:md="%s" entityID="%s">`, nsMetadata, escape(sp.EntityID))
+	fmt.Fprintf(&b, `<md:SPSSODescriptor AuthnRequestsSigned="false" WantAssertionsSigned="true" protocolSupportEnumeration="%s">`, nsProtocol)
+	fmt.Fprintf(&b, `<md:NameIDFormat>%s</md:NameIDFormat>`, nameIDUnspecified)
+	fmt.Fprintf(&b, `<md:AssertionConsumerService Binding="%s" Location="%s" index="0" isDefault="true"/>`, bindingHTTPPost, escape(sp.ACSURL))
+	b.WriteString(`</md:SPSSODescriptor></md:EntityDescriptor>`)
+	return b.Bytes()
+}
+
+// AuthnRequestURL returns the identity provider's SSO URL with an
+// AuthnRequest, by the HTTP-Redirect binding. relayState is posted back
+// with the response.
+func (sp *ServiceProvider) AuthnRequestURL(relayState string, now time.Time) (string, error) {
+	var req bytes.Buffer
+	fmt.Fprintf(&req, `<samlp:AuthnRequest xmlns:samlp="%s" xmlns:saml="%s" ID="%s" Version="2.0" IssueInstant="%s" Destination="%s" AssertionConsumerServiceURL="%s" ProtocolBinding="%s">`,
+		nsProtocol, nsAssertion, newID(), now.UTC().Format(time.RFC3339), escape(sp.IdPSSOURL), escape(sp.ACSURL), bindingHTTPPost)
+	fmt.Fprintf(&req, `<saml:Issuer>%s</saml:Issuer>`, escape(sp.EntityID))
+	fmt.Fprintf(&req, `<samlp:NameIDPolicy Format="%s" AllowCreate="true"/>`, nameIDUnspecified)
+	req.WriteString(`</samlp:AuthnRequest>`)
+
+	var deflated bytes.Buffer
+	w, err := flate.NewWriter(&deflated, flate.DefaultCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(req.Bytes()); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(sp.IdPSSOURL)
+	if err != nil {
+		return "", fmt.Errorf("saml: parse SSO URL: %w", err)
+	}
+	query := u.Query()
+	query.Set("SAMLRequest", base64.StdEncoding.EncodeToString(deflated.Bytes()))
+	if relayState != "" {
+		query.Set("RelayState", relayState)
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// ParseResponse verifies a base64-encoded response, as posted in the
+// SAMLResponse form field, and returns its assertion. It returns
+// ErrInvalidSignature or ErrInvalidResponse, wrapped with the reason.
+func (sp *ServiceProvider) ParseResponse(encoded string, now time.Time) (*Assertion, error) {
+	data, err := decodeBase64(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not base64", ErrInvalidResponse)
+	}
+	response, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+	if response.space != nsProtocol || response.local != "Response" {
+		return nil, fmt.Errorf("%w: not a Response", ErrInvalidResponse)
+	}
+
+	// Signatures find what they sign by ID, so IDs must be unique
+	ids := make(map[string]int)
+	response.ids(ids)
+	for id, n := range ids {
+		if n > 1 {
+			return nil, fmt.Errorf("%w: ID %q is used %d times", ErrInvalidResponse, id, n)
+		}
+	}
+
+	if dest := response.attr("Destination"); dest != "" && dest != sp.ACSURL {
+		return nil, fmt.Errorf("%w: destination %q isn't this service provider", ErrInvalidResponse, dest)
+	}
+	if code := responseStatus(response); code != statusSuccess {
+		return nil, fmt.Errorf("%w: status %s", ErrInvalidResponse, code)
+	}
+
+	if len(response.elements(nsAssertion, "EncryptedAssertion")) > 0 {
+		return nil, fmt.Errorf("%w: encrypted assertions aren't supported", ErrInvalidResponse)
+	}
+	assertion := response.element(nsAssertion, "Assertion")
+	if assertion == nil {
+		return nil, fmt.Errorf("%w: expected one assertion", ErrInvalidResponse)
+	}
+
+	var signed []byte
+	switch {
+	case assertion.element(nsDSig, "Signature") != nil:
+		if signed, err = verify(assertion, sp.IdPCertificates); err != nil {
+			return nil, err
+		}
+	case response.element(nsDSig, "Signature") != nil:
+		if _, err := verify(response, sp.IdPCertificates); err != nil {
+			return nil, err
+		}
+		signed = canonicalize(assertion, nil, nil)
+	default:
+		return nil, fmt.Errorf("%w: the assertion isn't signed", ErrInvalidSignature)
+	}
+
+	var a assertionXML
+	if err := xml.Unmarshal(signed, &a); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+	return sp.validate(&a, now)
+}
+
+// responseStatus returns the top-level status code of a response.
+func responseStatus(response *element) string {
+	status := response.element(nsProtocol, "Status")
+	if status == nil {
+		return "missing"
+	}
+	code := status.element(nsProtocol, "StatusCode")
+	if code == nil {
+		return "missing"
+	}
+	return code.attr("Value")
+}
+
+// validate checks that a verified assertion was issued by the identity
+// provider, for this service provider, and is current.
+func (sp *ServiceProvider) validate(a *assertionXML, now time.Time) (*Assertion, error) {
+	if strings.TrimSpace(a.Issuer) != sp.IdPEntityID {
+		return nil, fmt.Errorf("%w: issued by %q", ErrInvalidResponse, a.Issuer)
+	}
+
+	early, late := now.Add(sp.ClockSkew), now.Add(-sp.ClockSkew)
+	c := a.Conditions
+	if c == nil {
+		return nil, fmt.Errorf("%w: no conditions", ErrInvalidResponse)
+	}
+	if !c.NotBefore.IsZero() && early.Before(c.NotBefore) {
+		return nil, fmt.Errorf("%w: not valid before %s", ErrInvalidResponse, c.NotBefore)
+	}
+	if !c.NotOnOrAfter.IsZero() && !late.Before(c.NotOnOrAfter) {
+		return nil, fmt.Errorf("%w: expired at %s", ErrInvalidResponse, c.NotOnOrAfter)
+	}
+	if !c.forAudience(sp.EntityID) {
+		return nil, fmt.Errorf("%w: not meant for %q", ErrInvalidResponse, sp.EntityID)
+	}
+
+	// The bearer confirmation is what lets whoever posts the assertion
+	// use it, so it must name this service provider and be current
+	var expires time.Time
+	for _, sc := range a.Subject.Confirmations {
+		d := sc.Data
+		if sc.Method != confirmBearer || d == nil || d.Recipient != sp.ACSURL || d.NotOnOrAfter.IsZero() || !late.Before(d.NotOnOrAfter) {
+			continue
+		}
+		if !d.NotBefore.IsZero() && early.Before(d.NotBefore) {
+			continue
+		}
+		expires = d.NotOnOrAfter
+		break
+	}
+	if expires.IsZero() {
+		return nil, fmt.Errorf("%w: no current bearer confirmation for %q", ErrInvalidResponse, sp.ACSURL)
+	}
+	if !c.NotOnOrAfter.IsZero() && c.NotOnOrAfter.Before(expires) {
+		expires = c.NotOnOrAfter
+	}
+
+	assertion := &Assertion{
+		ID:           a.ID,
+		NameID:       strings.TrimSpace(a.Subject.NameID),
+		Attributes:   make(map[string][]string),
+		NotOnOrAfter: expires.Add(sp.ClockSkew),
+	}
+	for _, attr := range a.Attributes {
+		var values []string
+		for _, v := range attr.Values {
+			values = append(values, strings.TrimSpace(v))
+		}
+		assertion.Attributes[attr.Name] = append(assertion.Attributes[attr.Name], values...)
+		if attr.FriendlyName != "" && attr.FriendlyName != attr.Name {
+			assertion.Attributes[attr.FriendlyName] = append(assertion.Attributes[attr.FriendlyName], values...)
+		}
+	}
+	return assertion, nil
+}
+
+// assertionXML is the part of an assertion the service provider reads.
+type assertionXML struct {
+	XMLName xml.Name `xml:"urn:oasis:names:tc:SAML:2.0:assertion Assertion"`
+	ID      string   `xml:"ID,attr"`
+	Issuer  string   `xml:"urn:oasis:names:tc:SAML:2.0:assertion Issuer"`
+	Subject struct {
+		NameID        string `xml:"urn:oasis:names:tc:SAML:2.0:assertion NameID"`
+		Confirmations []struct {
+			Method string `xml:"Method,attr"`
+			Data   *struct {
+				Recipient    string    `xml:"Recipient,attr"`
+				NotBefore    time.Time `xml:"NotBefore,attr"`
+				NotOnOrAfter time.Time `xml:"NotOnOrAfter,attr"`
+			} `xml:"urn:oasis:names:tc:SAML:2.0:assertion SubjectConfirmationData"`
+		} `xml:"urn:oasis:names:tc:SAML:2.0:assertion SubjectConfirmation"`
+	} `xml:"urn:oasis:names:tc:SAML:2.0:assertion Subject"`
+	Conditions *conditionsXML `xml:"urn:oasis:names:tc:SAML:2.0:assertion Conditions"`
+	Attributes []struct {
+		Name         string   `xml:"Name,attr"`
+		FriendlyName string   `xml:"FriendlyName,attr"`
+		Values       []string `xml:"urn:oasis:names:tc:SAML:2.0:assertion AttributeValue"`
+	} `xml:"urn:oasis:names:tc:SAML:2.0:assertion AttributeStatement>Attribute"`
+}
+
+type conditionsXML struct {
+	NotBefore    time.Time `xml:"NotBefore,attr"`
+	NotOnOrAfter time.Time `xml:"NotOnOrAfter,attr"`
+	Audiences    []struct {
+		Audience []string `xml:"urn:oasis:names:tc:SAML:2.0:assertion Audience"`
+	} `xml:"urn:oasis:names:tc:SAML:2.0:assertion AudienceRestriction"`
+}
+
+// forAudience reports whether every audience restriction lists entityID;
+// there must be at least one.
+func (c *conditionsXML) forAudience(entityID string) bool {
+	if len(c.Audiences) == 0 {
+		return false
+	}
+	for _, restriction := range c.Audiences {
+		found := false
+		for _, audience := range restriction.Audience {
+			if strings.TrimSpace(audience) == entityID {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// newID returns a random XML ID for a request.
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return "_" + hex.EncodeToString(b)
+}
+
+func escape(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
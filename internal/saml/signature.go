@@ -0,0 +1,188 @@
+package saml
+
+import (
+	"crypto"
+	"crypto/rsa"
+	_ "crypto/sha256" // For the digest and signature methods
+	_ "crypto/sha512"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// XML signature namespaces and the algorithms verify supports. SHA-1 is
+// refused.
+const (
+	nsDSig   = "http://www.w3.org/2000/09/xmldsig#"
+	nsExcC14 = "http://www.w3.org/2001/10/xml-exc-c14n#"
+
+	algExcC14N       = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	algEnveloped     = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	algSHA256        = "http://www.w3.org/2001/04/xmlenc#sha256"
+	algSHA512        = "http://www.w3.org/2001/04/xmlenc#sha512"
+	algRSAWithSHA256 = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	algRSAWithSHA512 = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha512"
+)
+
+var digestMethods = map[string]crypto.Hash{
+	algSHA256: crypto.SHA256,
+	algSHA512: crypto.SHA512,
+}
+
+var signatureMethods = map[string]crypto.Hash{
+	algRSAWithSHA256: crypto.SHA256,
+	algRSAWithSHA512: crypto.SHA512,
+}
+
+// ErrInvalidSignature is returned when a response's signature is missing,
+// malformed or doesn't verify.
+var ErrInvalidSignature = errors.New("saml: invalid signature")
+
+// ParseCertificates parses the PEM certificates an identity provider signs
+// with. Each one must hold an RSA key.
+func ParseCertificates(data string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("saml: parse certificate: %w", err)
+		}
+		if _, ok := cert.PublicKey.(*rsa.PublicKey); !ok {
+			return nil, fmt.Errorf("saml: certificate %q doesn't hold an RSA key", cert.Subject)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("saml: no PEM certificate found")
+	}
+	return certs, nil
+}
+
+// verify checks the enveloped signature of e, a direct child of it that
+// references e by its ID, against certs. It returns e canonicalized without
+// the signature: exactly what was signed, so callers read nothing else.
+// Keys in the signature itself are ignored.
+func verify(e *element, certs []*x509.Certificate) ([]byte, error) {
+	sig := e.element(nsDSig, "Signature")
+	if sig == nil {
+		return nil, fmt.Errorf("%w: no signature", ErrInvalidSignature)
+	}
+	signedInfo := sig.element(nsDSig, "SignedInfo")
+	if signedInfo == nil {
+		return nil, fmt.Errorf("%w: no SignedInfo", ErrInvalidSignature)
+	}
+
+	c14n := signedInfo.element(nsDSig, "CanonicalizationMethod")
+	if c14n == nil || c14n.attr("Algorithm") != algExcC14N {
+		return nil, fmt.Errorf("%w: unsupported canonicalization", ErrInvalidSignature)
+	}
+	method := signedInfo.element(nsDSig, "SignatureMethod")
+	if method == nil {
+		return nil, fmt.Errorf("%w: no SignatureMethod", ErrInvalidSignature)
+	}
+	hash, ok := signatureMethods[method.attr("Algorithm")]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported signature method %q", ErrInvalidSignature, method.attr("Algorithm"))
+	}
+
+	// The one reference must be to e; anything else is a wrapping attempt
+	ref := signedInfo.element(nsDSig, "Reference")
+	id := e.attr("ID")
+	if ref == nil || id == "" || ref.attr("URI") != "#"+id {
+		return nil, fmt.Errorf("%w: signature doesn't reference the signed element", ErrInvalidSignature)
+	}
+
+	var (
+		enveloped bool
+		inclusive []string
+		canonical bool
+	)
+	if transforms := ref.element(nsDSig, "Transforms"); transforms != nil {
+		for _, t := range transforms.elements(nsDSig, "Transform") {
+			switch t.attr("Algorithm") {
+			case algEnveloped:
+				enveloped = true
+			case algExcC14N:
+				canonical = true
+				inclusive = prefixList(t)
+			default:
+				return nil, fmt.Errorf("%w: unsupported transform %q", ErrInvalidSignature, t.attr("Algorithm"))
+			}
+		}
+	}
+	if !enveloped || !canonical {
+		return nil, fmt.Errorf("%w: signature must be enveloped and exclusively canonicalized", ErrInvalidSignature)
+	}
+
+	digestMethod := ref.element(nsDSig, "DigestMethod")
+	if digestMethod == nil {
+		return nil, fmt.Errorf("%w: no DigestMethod", ErrInvalidSignature)
+	}
+	digestHash, ok := digestMethods[digestMethod.attr("Algorithm")]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported digest method %q", ErrInvalidSignature, digestMethod.attr("Algorithm"))
+	}
+	digestValue := ref.element(nsDSig, "DigestValue")
+	if digestValue == nil {
+		return nil, fmt.Errorf("%w: no DigestValue", ErrInvalidSignature)
+	}
+	want, err := decodeBase64(digestValue.text())
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed DigestValue", ErrInvalidSignature)
+	}
+
+	signed := canonicalize(e, inclusive, sig)
+	if subtle.ConstantTimeCompare(digest(digestHash, signed), want) != 1 {
+		return nil, fmt.Errorf("%w: digest mismatch", ErrInvalidSignature)
+	}
+
+	value := sig.element(nsDSig, "SignatureValue")
+	if value == nil {
+		return nil, fmt.Errorf("%w: no SignatureValue", ErrInvalidSignature)
+	}
+	signature, err := decodeBase64(value.text())
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed SignatureValue", ErrInvalidSignature)
+	}
+
+	hashed := digest(hash, canonicalize(signedInfo, prefixList(c14n), nil))
+	for _, cert := range certs {
+		if key, ok := cert.PublicKey.(*rsa.PublicKey); ok && rsa.VerifyPKCS1v15(key, hash, hashed, signature) == nil {
+			return signed, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: not signed by the identity provider's certificate", ErrInvalidSignature)
+}
+
+// prefixList returns the PrefixList of the InclusiveNamespaces in a
+// canonicalization method or transform.
+func prefixList(e *element) []string {
+	if ns := e.element(nsExcC14, "InclusiveNamespaces"); ns != nil {
+		return strings.Fields(ns.attr("PrefixList"))
+	}
+	return nil
+}
+
+func digest(hash crypto.Hash, data []byte) []byte {
+	h := hash.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// decodeBase64 decodes base64 that may be wrapped across lines.
+func decodeBase64(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+}
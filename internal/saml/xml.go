@@ -0,0 +1,357 @@
+package saml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// nsXML is the namespace the xml prefix is bound to without a declaration.
+const nsXML = "http://www.w3.org/XML/1998/namespace"
+
+// element is a parsed XML element. Unlike encoding/xml's tokens it keeps
+// the prefixes and namespace declarations as written, which signatures are
+// computed over.
+type element struct {
+	prefix, local string
+	space         string // Namespace URI of prefix
+	decls         []namespace
+	attrs         []attribute
+	children      []any // *element, string or procInst
+	parent        *element
+}
+
+type namespace struct {
+	prefix string // Empty for the default namespace
+	uri    string
+}
+
+type attribute struct {
+	prefix, local string
+	space         string
+	value         string
+}
+
+type procInst struct {
+	target, inst string
+}
+
+// parse reads a document into its root element. Comments are dropped, as
+// canonicalization without comments does, and DTDs are refused.
+func parse(data []byte) (*element, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+
+	var root, cur *element
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("saml: parse XML: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			e := &element{prefix: t.Name.Space, local: t.Name.Local, parent: cur}
+			for _, a := range t.Attr {
+				switch {
+				case a.Name.Space == "" && a.Name.Local == "xmlns":
+					e.decls = append(e.decls, namespace{uri: a.Value})
+				case a.Name.Space == "xmlns":
+					e.decls = append(e.decls, namespace{prefix: a.Name.Local, uri: a.Value})
+				default:
+					e.attrs = append(e.attrs, attribute{prefix: a.Name.Space, local: a.Name.Local, value: a.Value})
+				}
+			}
+
+			switch {
+			case cur != nil:
+				cur.children = append(cur.children, e)
+			case root != nil:
+				return nil, errors.New("saml: parse XML: more than one root element")
+			default:
+				root = e
+			}
+			cur = e
+
+		case xml.EndElement:
+			if cur == nil || t.Name.Space != cur.prefix || t.Name.Local != cur.local {
+				return nil, fmt.Errorf("saml: parse XML: unexpected </%s>", t.Name.Local)
+			}
+			cur = cur.parent
+
+		case xml.CharData:
+			if cur != nil {
+				cur.children = append(cur.children, string(t))
+			}
+
+		case xml.ProcInst:
+			if cur != nil {
+				cur.children = append(cur.children, procInst{target: t.Target, inst: string(t.Inst)})
+			}
+
+		case xml.Directive:
+			return nil, errors.New("saml: parse XML: DTDs are not allowed")
+		}
+	}
+	if root == nil || cur != nil {
+		return nil, errors.New("saml: parse XML: incomplete document")
+	}
+
+	if err := root.resolve(); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// resolve sets the namespace URIs of e, its attributes and descendants.
+func (e *element) resolve() error {
+	var ok bool
+	if e.space, ok = e.lookup(e.prefix); !ok {
+		return fmt.Errorf("saml: parse XML: undeclared prefix %q", e.prefix)
+	}
+	for i := range e.attrs {
+		a := &e.attrs[i]
+		if a.prefix == "" {
+			continue
+		}
+		if a.space, ok = e.lookup(a.prefix); !ok {
+			return fmt.Errorf("saml: parse XML: undeclared prefix %q", a.prefix)
+		}
+	}
+	for _, c := range e.children {
+		if child, ok := c.(*element); ok {
+			if err := child.resolve(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// lookup returns the namespace prefix is bound to in the scope of e. The
+// default namespace is always bound, to "" if undeclared.
+func (e *element) lookup(prefix string) (string, bool) {
+	if prefix == "xml" {
+		return nsXML, true
+	}
+	for s := e; s != nil; s = s.parent {
+		for _, d := range s.decls {
+			if d.prefix == prefix {
+				return d.uri, true
+			}
+		}
+	}
+	return "", prefix == ""
+}
+
+// attr returns the value of the unqualified attribute name.
+func (e *element) attr(name string) string {
+	for _, a := range e.attrs {
+		if a.prefix == "" && a.local == name {
+			return a.value
+		}
+	}
+	return ""
+}
+
+// elements returns the child elements named space and local.
+func (e *element) elements(space, local string) []*element {
+	var found []*element
+	for _, c := range e.children {
+		if child, ok := c.(*element); ok && child.space == space && child.local == local {
+			found = append(found, child)
+		}
+	}
+	return found
+}
+
+// element returns the only child element named space and local, or nil if
+// there's none or more than one.
+func (e *element) element(space, local string) *element {
+	if found := e.elements(space, local); len(found) == 1 {
+		return found[0]
+	}
+	return nil
+}
+
+// text returns the character data directly in e.
+func (e *element) text() string {
+	var b strings.Builder
+	for _, c := range e.children {
+		if s, ok := c.(string); ok {
+			b.WriteString(s)
+		}
+	}
+	return b.String()
+}
+
+// ids counts the ID attributes in e and its descendants, by value.
+func (e *element) ids(counts map[string]int) {
+	if id := e.attr("ID"); id != "" {
+		counts[id]++
+	}
+	for _, c := range e.children {
+		if child, ok := c.(*element); ok {
+			child.ids(counts)
+		}
+	}
+}
+
+// canonicalize serializes e by Exclusive XML Canonicalization without
+// comments, leaving exclude out. The prefixes in inclusive, "#default" for
+// the default namespace, are rendered wherever they're in scope, as the
+// InclusiveNamespaces PrefixList asks.
+func canonicalize(e *element, inclusive []string, exclude *element) []byte {
+	c := &canonicalizer{exclude: exclude, inclusive: make(map[string]bool, len(inclusive))}
+	for _, p := range inclusive {
+		if p == "#default" {
+			p = ""
+		}
+		c.inclusive[p] = true
+	}
+	c.element(e, map[string]string{})
+	return c.buf.Bytes()
+}
+
+type canonicalizer struct {
+	buf       bytes.Buffer
+	inclusive map[string]bool
+	exclude   *element
+}
+
+// element writes e, given the namespaces its output ancestors rendered.
+func (c *canonicalizer) element(e *element, rendered map[string]string) {
+	// The namespaces visibly utilized by e and its attributes, and the
+	// inclusive ones in scope, unless an output ancestor rendered them
+	needed := map[string]bool{e.prefix: true}
+	for _, a := range e.attrs {
+		if a.prefix != "" {
+			needed[a.prefix] = true
+		}
+	}
+	for p := range c.inclusive {
+		if _, ok := e.lookup(p); ok {
+			needed[p] = true
+		}
+	}
+
+	var decls []namespace
+	for p := range needed {
+		if p == "xml" {
+			continue
+		}
+		uri, _ := e.lookup(p)
+		prev, ok := rendered[p]
+		if ok && prev == uri || !ok && p == "" && uri == "" {
+			continue
+		}
+		decls = append(decls, namespace{prefix: p, uri: uri})
+	}
+	slices.SortFunc(decls, func(a, b namespace) int { return strings.Compare(a.prefix, b.prefix) })
+
+	if len(decls) > 0 {
+		rendered = maps.Clone(rendered)
+		for _, d := range decls {
+			rendered[d.prefix] = d.uri
+		}
+	}
+
+	attrs := slices.Clone(e.attrs)
+	slices.SortFunc(attrs, func(a, b attribute) int {
+		if n := strings.Compare(a.space, b.space); n != 0 {
+			return n
+		}
+		return strings.Compare(a.local, b.local)
+	})
+
+	name := qualified(e.prefix, e.local)
+	c.buf.WriteString("<" + name)
+	for _, d := range decls {
+		c.buf.WriteString(" " + qualified("xmlns", d.prefix) + `="`)
+		escapeAttr(&c.buf, d.uri)
+		c.buf.WriteByte('"')
+	}
+	for _, a := range attrs {
+		c.buf.WriteString(" " + qualified(a.prefix, a.local) + `="`)
+		escapeAttr(&c.buf, a.value)
+		c.buf.WriteByte('"')
+	}
+	c.buf.WriteByte('>')
+
+	for _, child := range e.children {
+		switch child := child.(type) {
+		case *element:
+			if child != c.exclude {
+				c.element(child, rendered)
+			}
+		case string:
+			escapeText(&c.buf, child)
+		case procInst:
+			c.buf.WriteString("<?" + child.target)
+			if child.inst != "" {
+				c.buf.WriteString(" " + child.inst)
+			}
+			c.buf.WriteString("?>")
+		}
+	}
+
+	c.buf.WriteString("</" + name + ">")
+}
+
+// qualified joins a prefix and local name, either of which may be empty
+// (the default namespace is declared as xmlns with no local name).
+func qualified(prefix, local string) string {
+	switch {
+	case prefix == "":
+		return local
+	case local == "":
+		return prefix
+	default:
+		return prefix + ":" + local
+	}
+}
+
+func escapeText(b *bytes.Buffer, s string) {
+	for _, r := range s {
+		switch r {
+		case '&':
+			b.WriteString("&amp;")
+		case '<':
+			b.WriteString("&lt;")
+		case '>':
+			b.WriteString("&gt;")
+		case '\r':
+			b.WriteString("&#xD;")
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
+
+func escapeAttr(b *bytes.Buffer, s string) {
+	for _, r := range s {
+		switch r {
+		case '&':
+			b.WriteString("&amp;")
+		case '<':
+			b.WriteString("&lt;")
+		case '"':
+			b.WriteString("&quot;")
+		case '\t':
+			b.WriteString("&#x9;")
+		case '\n':
+			b.WriteString("&#xA;")
+		case '\r':
+			b.WriteString("&#xD;")
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
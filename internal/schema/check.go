@@ -0,0 +1,272 @@
+package schema
+
+import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Change is a schema change that the previous binary may not survive.
+type Change struct {
+	Op     string // "drop table", "drop column", "rename column", ...
+	Table  string
+	Column string // Empty for table changes
+}
+
+func (c Change) String() string {
+	if c.Column == "" {
+		return c.Op + " " + c.Table
+	}
+	return c.Op + " " + c.Table + "." + c.Column
+}
+
+// removes reports whether the change takes away a name code can refer to.
+func (c Change) removes() bool {
+	switch c.Op {
+	case "drop table", "rename table", "drop column", "rename column":
+		return true
+	}
+	return false
+}
+
+// Finding is a backward compatibility problem in a migration.
+type Finding struct {
+	Migration string
+	Change    Change
+	Reason    string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s", f.Migration, f.Change, f.Reason)
+}
+
+// Check reports the migrations above since that would break the binary
+// built from src, or the binary before it:
+//
+//   - expand migrations may not drop, rename, retype or tighten anything,
+//     since the previous binary still runs against them;
+//   - contract migrations may not drop or rename a table or column that a
+//     SQL string in the Go files under src still mentions.
+//
+// Pass a nil src to skip the reference check.
+func Check(migrations []Migration, since uint, src fs.FS) ([]Finding, error) {
+	var refs []reference
+	if src != nil {
+		var err error
+		if refs, err = sqlReferences(src); err != nil {
+			return nil, err
+		}
+	}
+
+	var findings []Finding
+	for _, m := range Pending(migrations, since) {
+		for _, change := range Changes(m.SQL) {
+			switch {
+			case m.Phase == PhaseExpand:
+				findings = append(findings, Finding{
+					Migration: m.Name,
+					Change:    change,
+					Reason:    "not backward compatible; move it to a migration marked \"-- phase: contract\"",
+				})
+			case change.removes():
+				for _, ref := range refs {
+					if ref.mentions(change.Table, change.Column) {
+						findings = append(findings, Finding{
+							Migration: m.Name,
+							Change:    change,
+							Reason:    "still referenced in " + ref.pos,
+						})
+					}
+				}
+			}
+		}
+	}
+	return findings, nil
+}
+
+var (
+	dropTable   = regexp.MustCompile(`^drop table (?:if exists )?(.+?)(?: cascade| restrict)?$`)
+	alterTable  = regexp.MustCompile(`^alter table (?:if exists )?(?:only )?(\S+) (.+)$`)
+	renameTable = regexp.MustCompile(`^rename to (\S+)$`)
+	renameCol   = regexp.MustCompile(`^rename (?:column )?(\S+) to (\S+)$`)
+	dropCol     = regexp.MustCompile(`^drop (?:column )?(?:if exists )?(\S+)`)
+	alterType   = regexp.MustCompile(`^alter (?:column )?(\S+) (?:set data )?type (\S+)`)
+	setNotNull  = regexp.MustCompile(`^alter (?:column )?(\S+) set not null`)
+	addCol      = regexp.MustCompile(`^add (?:column )?(?:if not exists )?(\S+) (.+)$`)
+)
+
+// Changes lists the breaking changes in a migration's SQL. Widening a
+// column to TEXT is not one of them.
+func Changes(sql string) []Change {
+	var changes []Change
+	for _, stmt := range statements(sql) {
+		if m := dropTable.FindStringSubmatch(stmt); m != nil {
+			for table := range strings.SplitSeq(m[1], ",") {
+				changes = append(changes, Change{Op: "drop table", Table: unquote(table)})
+			}
+			continue
+		}
+		m := alterTable.FindStringSubmatch(stmt)
+		if m == nil {
+			continue
+		}
+		table := unquote(m[1])
+		for _, action := range splitActions(m[2]) {
+			if m := renameTable.FindStringSubmatch(action); m != nil {
+				changes = append(changes, Change{Op: "rename table", Table: table})
+			} else if m := renameCol.FindStringSubmatch(action); m != nil && m[1] != "constraint" {
+				changes = append(changes, Change{Op: "rename column", Table: table, Column: unquote(m[1])})
+			} else if m := dropCol.FindStringSubmatch(action); m != nil && m[1] != "constraint" && m[1] != "default" {
+				changes = append(changes, Change{Op: "drop column", Table: table, Column: unquote(m[1])})
+			} else if m := alterType.FindStringSubmatch(action); m != nil && m[2] != "text" {
+				changes = append(changes, Change{Op: "change type of", Table: table, Column: unquote(m[1])})
+			} else if m := setNotNull.FindStringSubmatch(action); m != nil {
+				changes = append(changes, Change{Op: "set not null on", Table: table, Column: unquote(m[1])})
+			} else if m := addCol.FindStringSubmatch(action); m != nil && m[1] != "constraint" &&
+				strings.Contains(m[2], "not null") && !strings.Contains(m[2], "default") {
+				changes = append(changes, Change{Op: "add required column", Table: table, Column: unquote(m[1])})
+			}
+		}
+	}
+	return changes
+}
+
+// statements splits SQL into lowercased statements with comments removed
+// and whitespace collapsed. Dollar-quoted bodies are kept whole.
+func statements(sql string) []string {
+	var (
+		stmts   []string
+		current strings.Builder
+	)
+	flush := func() {
+		if stmt := strings.Join(strings.Fields(current.String()), " "); stmt != "" {
+			stmts = append(stmts, strings.ToLower(stmt))
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(sql); i++ {
+		switch {
+		case strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			i += end
+			current.WriteByte(' ')
+		case strings.HasPrefix(sql[i:], "$$"):
+			end := strings.Index(sql[i+2:], "$$")
+			if end < 0 {
+				end = len(sql) - i - 4
+			}
+			current.WriteString(sql[i : i+end+4])
+			i += end + 3
+		case sql[i] == '\'':
+			end := strings.IndexByte(sql[i+1:], '\'')
+			if end < 0 {
+				end = len(sql) - i - 2
+			}
+			current.WriteString(sql[i : i+end+2])
+			i += end + 1
+		case sql[i] == ';':
+			flush()
+		default:
+			current.WriteByte(sql[i])
+		}
+	}
+	flush()
+	return stmts
+}
+
+// splitActions splits the actions of an ALTER TABLE on the commas outside
+// parentheses.
+func splitActions(s string) []string {
+	var (
+		actions []string
+		depth   int
+		start   int
+	)
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				actions = append(actions, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(actions, strings.TrimSpace(s[start:]))
+}
+
+func unquote(name string) string {
+	name = strings.Trim(strings.TrimSpace(name), `"`)
+	if _, table, ok := strings.Cut(name, "."); ok {
+		return table
+	}
+	return name
+}
+
+// reference is a Go string literal that holds SQL.
+type reference struct {
+	pos   string
+	words []string
+}
+
+func (r reference) mentions(table, column string) bool {
+	if !slices.Contains(r.words, table) {
+		return false
+	}
+	return column == "" || slices.Contains(r.words, column)
+}
+
+var (
+	sqlWord    = regexp.MustCompile(`[a-z_][a-z0-9_]*`)
+	sqlKeyword = regexp.MustCompile(`(?i)\b(select|insert|update|delete)\b`)
+)
+
+// sqlReferences collects the string literals in the Go files under src
+// that look like SQL.
+func sqlReferences(src fs.FS) ([]reference, error) {
+	var refs []reference
+	err := fs.WalkDir(src, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(name) != ".go" {
+			return nil
+		}
+		data, err := fs.ReadFile(src, name)
+		if err != nil {
+			return err
+		}
+
+		fset := token.NewFileSet()
+		file := fset.AddFile(name, -1, len(data))
+		var s scanner.Scanner
+		s.Init(file, data, nil, 0)
+		for {
+			pos, tok, lit := s.Scan()
+			if tok == token.EOF {
+				break
+			}
+			if tok != token.STRING || !sqlKeyword.MatchString(lit) {
+				continue
+			}
+			refs = append(refs, reference{
+				pos:   fset.Position(pos).String(),
+				words: sqlWord.FindAllString(strings.ToLower(lit), -1),
+			})
+		}
+		return nil
+	})
+	return refs, err
+}
@@ -0,0 +1,42 @@
+package schema
+
+import "fmt"
+
+// State is what the database says about its schema.
+type State struct {
+	Version uint // Highest applied migration, 0 if none
+	Dirty   bool // A migration failed halfway
+
+	// Contracted is the highest contract migration applied through
+	// aegisctl migrate, 0 if none.
+	Contracted uint
+}
+
+// Required returns the schema version a binary built with migrations needs:
+// every migration but the contract migrations at the end, which only
+// remove what it no longer uses.
+func Required(migrations []Migration) uint {
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if migrations[i].Phase == PhaseExpand {
+			return migrations[i].Version
+		}
+	}
+	return 0
+}
+
+// Ready returns an error saying why a binary built with migrations cannot
+// serve on a database in state. A schema ahead of the binary is fine as
+// long as only expand migrations were applied past it, which is the case
+// while the previous binary is still rolling out.
+func Ready(migrations []Migration, state State) error {
+	if state.Dirty {
+		return fmt.Errorf("schema version %d is dirty", state.Version)
+	}
+	if required := Required(migrations); state.Version < required {
+		return fmt.Errorf("schema version %d is behind %d; run aegisctl migrate expand", state.Version, required)
+	}
+	if latest := Latest(migrations); state.Contracted > latest {
+		return fmt.Errorf("contract migration %d is newer than this binary (%d)", state.Contracted, latest)
+	}
+	return nil
+}
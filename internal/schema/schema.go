@@ -0,0 +1,141 @@
+// Package schema reads the SQL migrations and knows which of them a running
+// binary can live with, so deployments can migrate in expand and contract
+// phases: expand migrations only add to the schema and are applied before
+// the new binary rolls out, while the previous binary keeps working;
+// contract migrations remove what no binary uses any more and are applied
+// once the previous binary is gone.
+package schema
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Phase says when a migration may be applied.
+type Phase string
+
+const (
+	// PhaseExpand migrations are backward compatible: the previous binary
+	// keeps working on the migrated schema. This is the default.
+	PhaseExpand Phase = "expand"
+
+	// PhaseContract migrations break the previous binary, e.g. by
+	// dropping a column it reads. They are marked with a
+	// "-- phase: contract" comment before the first statement.
+	PhaseContract Phase = "contract"
+)
+
+// Migration is one up migration.
+type Migration struct {
+	Version uint
+	Name    string // File name
+	Phase   Phase
+	SQL     string
+}
+
+// Load reads the NNN_name.up.sql migrations in fsys, ordered by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	for _, name := range names {
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("%s: want NNN_name.up.sql", name)
+		}
+		version, err := strconv.ParseUint(prefix, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s: want NNN_name.up.sql", name)
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		phase, err := parsePhase(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		migrations = append(migrations, Migration{
+			Version: uint(version),
+			Name:    path.Base(name),
+			Phase:   phase,
+			SQL:     string(data),
+		})
+	}
+
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("%s and %s have the same version", migrations[i-1].Name, migrations[i].Name)
+		}
+	}
+	return migrations, nil
+}
+
+// parsePhase reads the "-- phase:" comment from the header of a migration,
+// the comment lines before its first statement.
+func parsePhase(sql string) (Phase, error) {
+	scanner := bufio.NewScanner(strings.NewReader(sql))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		comment, ok := strings.CutPrefix(line, "--")
+		if !ok {
+			break
+		}
+		value, ok := strings.CutPrefix(strings.TrimSpace(comment), "phase:")
+		if !ok {
+			continue
+		}
+		switch phase := Phase(strings.TrimSpace(value)); phase {
+		case PhaseExpand, PhaseContract:
+			return phase, nil
+		default:
+			return "", fmt.Errorf("unknown phase %q", phase)
+		}
+	}
+	return PhaseExpand, nil
+}
+
+// Latest returns the highest version of migrations, or 0 if there are none.
+func Latest(migrations []Migration) uint {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// Pending returns the migrations above version.
+func Pending(migrations []Migration, version uint) []Migration {
+	i, _ := slices.BinarySearchFunc(migrations, version+1, func(m Migration, v uint) int {
+		return cmp.Compare(m.Version, v)
+	})
+	return migrations[i:]
+}
+
+// ExpandSteps counts the pending migrations above version that can be
+// applied before the first pending contract migration.
+func ExpandSteps(migrations []Migration, version uint) int {
+	pending := Pending(migrations, version)
+	for i, m := range pending {
+		if m.Phase == PhaseContract {
+			return i
+		}
+	}
+	return len(pending)
+}
@@ -0,0 +1,141 @@
+// Package secrets loads credentials from a secret manager.
+//
+// A Store holds the secrets the service needs, loaded from a Provider
+// (Vault, AWS Secrets Manager, or values already read from the
+// environment) and refreshed periodically, so rotated credentials are
+// picked up without a restart. A failed refresh keeps the secrets already
+// loaded.
+package secrets
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Names of the secrets the service loads. Remote providers look them up as
+// keys of a single secret.
+const (
+	JWTSecretKey        = "jwt_secret_key"
+	PasswordPepper      = "password_pepper"
+	PreviousPeppers     = "password_previous_peppers" // Comma-separated
+	DatabasePassword    = "database_password"
+	ColumnEncryptionKey = "column_encryption_key"
+	PreviousColumnKeys  = "column_encryption_previous_keys" // Comma-separated
+	SCIMToken           = "scim_token"
+	LDAPBindPassword    = "ldap_bind_password"
+)
+
+// Names lists every secret the service loads.
+var Names = []string{
+	JWTSecretKey, PasswordPepper, PreviousPeppers, DatabasePassword,
+	ColumnEncryptionKey, PreviousColumnKeys, SCIMToken, LDAPBindPassword,
+}
+
+// Provider loads secrets by name. Names the provider doesn't have are left
+// out of the result rather than reported as errors.
+type Provider interface {
+	Load(ctx context.Context, names []string) (map[string]string, error)
+}
+
+// Static serves fixed values, typically read from the environment.
+type Static map[string]string
+
+func (s Static) Load(_ context.Context, names []string) (map[string]string, error) {
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		if v := s[name]; v != "" {
+			values[name] = v
+		}
+	}
+	return values, nil
+}
+
+// Chain loads from each provider in turn; the first one to have a secret
+// wins. Any provider failing fails the load.
+type Chain []Provider
+
+func (c Chain) Load(ctx context.Context, names []string) (map[string]string, error) {
+	values := make(map[string]string, len(names))
+	for i := len(c) - 1; i >= 0; i-- {
+		loaded, err := c[i].Load(ctx, names)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(values, loaded)
+	}
+	return values, nil
+}
+
+// Store holds the latest secrets loaded from a provider, safe for
+// concurrent use.
+type Store struct {
+	provider Provider
+	names    []string
+	values   atomic.Pointer[map[string]string]
+
+	mu       sync.Mutex
+	onChange []func(*Store)
+}
+
+// NewStore returns an empty store for names; call Refresh to load them.
+func NewStore(provider Provider, names ...string) *Store {
+	s := &Store{provider: provider, names: names}
+	s.values.Store(&map[string]string{})
+	return s
+}
+
+// Get returns the current value of a secret, or "" if it isn't set.
+func (s *Store) Get(name string) string {
+	return (*s.values.Load())[name]
+}
+
+// OnChange registers fn to be called after a refresh changes any secret.
+func (s *Store) OnChange(fn func(*Store)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = append(s.onChange, fn)
+}
+
+// Refresh loads the secrets from the provider, replacing the current ones
+// and notifying OnChange callbacks if any changed.
+func (s *Store) Refresh(ctx context.Context) error {
+	values, err := s.provider.Load(ctx, s.names)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if maps.Equal(values, *s.values.Load()) {
+		return nil
+	}
+	s.values.Store(&values)
+	for _, fn := range s.onChange {
+		fn(s)
+	}
+	return nil
+}
+
+// RunRefresh refreshes the secrets every interval until ctx is done.
+// Failures are logged.
+func (s *Store) RunRefresh(ctx context.Context, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+			logger.Warn("secrets refresh failed", slog.String("error", err.Error()))
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Vault reads secrets from a single secret in a Vault KV version 2 engine,
+// one key per secret name, authenticating with a token.
+type Vault struct {
+	addr   string
+	token  string
+	mount  string
+	path   string
+	client *http.Client
+}
+
+// NewVault reads the secret at path in the KV engine mounted at mount on
+// the Vault server at addr.
+func NewVault(addr, token, mount, path string, timeout time.Duration) *Vault {
+	return &Vault{
+		addr:   strings.TrimSuffix(addr, "/"),
+		token:  token,
+		mount:  strings.Trim(mount, "/"),
+		path:   strings.Trim(path, "/"),
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+type vaultResponse struct {
+	Data struct {
+		Data map[string]any `json:"data"`
+	} `json:"data"`
+}
+
+func (v *Vault) Load(ctx context.Context, names []string) (map[string]string, error) {
+	endpoint, err := url.JoinPath(v.addr, "v1", v.mount, "data", v.path)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reading vault secret %s/%s: status %d", v.mount, v.path, resp.StatusCode)
+	}
+
+	var body vaultResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding vault secret: %w", err)
+	}
+
+	return pick(body.Data.Data, names), nil
+}
+
+// Patch writes values into the secret as a new version, keeping its other
+// keys.
+func (v *Vault) Patch(ctx context.Context, values map[string]string) error {
+	endpoint, err := url.JoinPath(v.addr, "v1", v.mount, "data", v.path)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(map[string]any{"data": values})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("writing vault secret %s/%s: status %d", v.mount, v.path, resp.StatusCode)
+	}
+	return nil
+}
+
+// pick returns the string values of names in a decoded secret.
+func pick(data map[string]any, names []string) map[string]string {
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		if v, ok := data[name].(string); ok && v != "" {
+			values[name] = v
+		}
+	}
+	return values
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/auth"
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// accountDeletionBatchSize is how many due deletions FinalizeDue carries
+// out per query.
+const accountDeletionBatchSize = 100
+
+// AccountDeletionService lets users delete their own account after a grace
+// period, during which they can still sign in and change their mind.
+type AccountDeletionService struct {
+	deletions storage.AccountDeletionRepository
+	users     storage.UserRepository
+	userSvc   *UserService
+	authSvc   *AuthService
+	publisher event.Publisher
+	grace     time.Duration
+}
+
+func NewAccountDeletionService(
+	deletions storage.AccountDeletionRepository,
+	users storage.UserRepository,
+	userSvc *UserService,
+	authSvc *AuthService,
+	publisher event.Publisher,
+	grace time.Duration,
+) *AccountDeletionService {
+	return &AccountDeletionService{
+		deletions: deletions,
+		users:     users,
+		userSvc:   userSvc,
+		authSvc:   authSvc,
+		publisher: publisher,
+		grace:     grace,
+	}
+}
+
+// Schedule schedules the deletion of a user's account, once they've given
+// their password, for the end of the grace period. Scheduling again keeps
+// the deletion already scheduled.
+func (s *AccountDeletionService) Schedule(ctx context.Context, userID uuid.UUID, password string) (*domain.AccountDeletion, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := auth.CheckPassword(password, user.PasswordHash); err != nil {
+		return nil, domain.ErrInvalidCredential
+	}
+
+	deletion := domain.NewAccountDeletion(user.ID, s.grace)
+	err = s.deletions.Create(ctx, deletion)
+	if errors.Is(err, domain.ErrAlreadyExists) {
+		return s.deletions.Get(ctx, user.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.NewEvent(domain.EventUserDeletionScheduled, user.ID, map[string]any{
+		"delete_after": deletion.DeleteAfter,
+	}))
+
+	return deletion, nil
+}
+
+// Pending returns the user's scheduled deletion, or nil if there is none.
+func (s *AccountDeletionService) Pending(ctx context.Context, userID uuid.UUID) (*domain.AccountDeletion, error) {
+	deletion, err := s.deletions.Get(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil
+	}
+	return deletion, err
+}
+
+// Cancel cancels the user's scheduled deletion. Returns ErrNotFound if
+// there is none.
+func (s *AccountDeletionService) Cancel(ctx context.Context, userID uuid.UUID) error {
+	if err := s.deletions.Delete(ctx, userID); err != nil {
+		return err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.NewEvent(domain.EventUserDeletionCancelled, userID, nil))
+
+	return nil
+}
+
+// FinalizeDue deletes the accounts whose grace period has ended, as
+// DeleteUser does, and ends their sessions. Returns how many were deleted.
+func (s *AccountDeletionService) FinalizeDue(ctx context.Context) (int64, error) {
+	var deleted int64
+	for {
+		due, err := s.deletions.ListDue(ctx, domain.Now(), accountDeletionBatchSize)
+		if err != nil {
+			return deleted, err
+		}
+
+		for _, d := range due {
+			// Already gone if an administrator deleted the account meanwhile
+			if err := s.userSvc.DeleteUser(ctx, d.UserID); err != nil && !errors.Is(err, domain.ErrNotFound) {
+				return deleted, err
+			}
+			if err := s.authSvc.LogoutAll(ctx, d.UserID); err != nil {
+				return deleted, err
+			}
+			if err := s.deletions.Delete(ctx, d.UserID); err != nil && !errors.Is(err, domain.ErrNotFound) {
+				return deleted, err
+			}
+			deleted++
+		}
+
+		if len(due) < accountDeletionBatchSize {
+			return deleted, nil
+		}
+	}
+}
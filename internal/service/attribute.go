@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// AttributeService manages the custom attribute registry and users' values
+// for those attributes.
+type AttributeService struct {
+	definitions storage.AttributeDefinitionRepository
+	users       storage.UserRepository
+	publisher   event.Publisher
+}
+
+func NewAttributeService(
+	definitions storage.AttributeDefinitionRepository,
+	users storage.UserRepository,
+	publisher event.Publisher,
+) *AttributeService {
+	return &AttributeService{
+		definitions: definitions,
+		users:       users,
+		publisher:   publisher,
+	}
+}
+
+func (s *AttributeService) CreateDefinition(ctx context.Context, key string, attrType domain.AttributeType, description string) (*domain.AttributeDefinition, error) {
+	def, err := domain.NewAttributeDefinition(key, attrType, description)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.definitions.Create(ctx, def); err != nil {
+		return nil, err
+	}
+
+	return def, nil
+}
+
+func (s *AttributeService) GetDefinition(ctx context.Context, key string) (*domain.AttributeDefinition, error) {
+	return s.definitions.Get(ctx, key)
+}
+
+func (s *AttributeService) ListDefinitions(ctx context.Context) ([]domain.AttributeDefinition, error) {
+	return s.definitions.List(ctx)
+}
+
+// UpdateDefinition changes a definition's description. Keys and types are
+// fixed once defined.
+func (s *AttributeService) UpdateDefinition(ctx context.Context, key, description string) (*domain.AttributeDefinition, error) {
+	def, err := s.definitions.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	def.Description = description
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.definitions.Update(ctx, def); err != nil {
+		return nil, err
+	}
+
+	return def, nil
+}
+
+// DeleteDefinition removes a definition along with every user's value for it.
+func (s *AttributeService) DeleteDefinition(ctx context.Context, key string) error {
+	return s.definitions.Delete(ctx, key)
+}
+
+// UpdateUserAttributes applies a partial update to a user's attributes. A
+// nil value removes the key; other values must match the key's definition.
+func (s *AttributeService) UpdateUserAttributes(ctx context.Context, userID uuid.UUID, patch map[string]any) (*domain.User, error) {
+	if len(patch) == 0 {
+		return nil, domain.ValidationError{Field: "attributes", Message: "at least one attribute required"}
+	}
+
+	definitions, err := s.definitionsByKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	changed, err := user.PatchAttributes(patch, definitions)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changed) == 0 {
+		return user, nil
+	}
+
+	if err := s.users.UpdateAttributes(ctx, user); err != nil {
+		return nil, err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.UserAttributesUpdatedEvent(user, changed))
+
+	return user, nil
+}
+
+// ParseFilter converts raw attribute filter values, as given in a query
+// string, to typed values for UserFilter.Attributes. A string list filter
+// matches users whose list contains the value.
+func (s *AttributeService) ParseFilter(ctx context.Context, raw map[string]string) (map[string]any, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	definitions, err := s.definitionsByKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := make(map[string]any, len(raw))
+	for key, value := range raw {
+		field := "attr." + key
+
+		def, ok := definitions[key]
+		if !ok {
+			return nil, domain.ValidationError{Field: field, Message: "unknown attribute"}
+		}
+
+		switch def.Type {
+		case domain.AttributeTypeString:
+			filter[key] = value
+		case domain.AttributeTypeNumber:
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, domain.ValidationError{Field: field, Message: "must be a number"}
+			}
+			filter[key] = n
+		case domain.AttributeTypeBoolean:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, domain.ValidationError{Field: field, Message: "must be a boolean"}
+			}
+			filter[key] = b
+		case domain.AttributeTypeStringList:
+			filter[key] = []string{value}
+		}
+	}
+
+	return filter, nil
+}
+
+func (s *AttributeService) definitionsByKey(ctx context.Context) (map[string]domain.AttributeDefinition, error) {
+	defs, err := s.definitions.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]domain.AttributeDefinition, len(defs))
+	for _, d := range defs {
+		byKey[d.Key] = d
+	}
+	return byKey, nil
+}
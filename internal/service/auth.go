@@ -1,7 +1,10 @@
 package service
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,6 +12,7 @@ import (
 	"github.com/mvaleed/aegis/internal/auth"
 	"github.com/mvaleed/aegis/internal/domain"
 	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/risk"
 	"github.com/mvaleed/aegis/internal/storage"
 )
 
@@ -17,24 +21,99 @@ type AuthService struct {
 	users     storage.UserRepository
 	roles     storage.RoleRepository
 	tokens    storage.TokenRepository
+	orgs      storage.OrganizationRepository
+	groups    storage.GroupRepository
+	profiles  storage.ProfileRepository
+	logins    storage.LoginAttemptRepository
 	jwt       *auth.JWTManager
 	publisher event.Publisher
+	risk      risk.Engine
+	devices   *DeviceService
+	ldap      *LDAPService
+	enrichers []ClaimsEnricher
+
+	impersonations   storage.ImpersonationRepository
+	impersonationTTL time.Duration
+
+	sessions atomic.Pointer[SessionPolicy]
+
+	// reactivateOnLogin has signing in undo a user's own deactivation.
+	reactivateOnLogin atomic.Bool
+}
+
+// SessionPolicy governs refresh token sessions.
+//
+// Each token lives for the refresh TTL; with Sliding, each refresh starts a
+// new one, so active sessions go on, and without it tokens keep the expiry
+// of the sign-in's. MaxLifetime, when positive, ends sessions that long
+// after sign-in, however active.
+//
+// ShortTTL, when positive, is the refresh TTL of sessions whose user didn't
+// ask to be remembered at sign-in; the rest get the JWT manager's.
+//
+// MaxSessions, when positive, limits the user's active sessions: a sign-in
+// past it ends their least recently refreshed sessions, or with
+// RejectOverLimit fails with ErrTooManySessions.
+type SessionPolicy struct {
+	Sliding     bool
+	MaxLifetime time.Duration
+	ShortTTL    time.Duration
+
+	MaxSessions     int
+	RejectOverLimit bool
 }
 
 func NewAuthService(
 	users storage.UserRepository,
 	roles storage.RoleRepository,
 	tokens storage.TokenRepository,
+	orgs storage.OrganizationRepository,
+	groups storage.GroupRepository,
+	profiles storage.ProfileRepository,
+	impersonations storage.ImpersonationRepository,
+	logins storage.LoginAttemptRepository,
 	jwt *auth.JWTManager,
 	publisher event.Publisher,
+	riskEngine risk.Engine,
+	devices *DeviceService,
+	impersonationTTL time.Duration,
 ) *AuthService {
-	return &AuthService{
-		users:     users,
-		roles:     roles,
-		tokens:    tokens,
-		jwt:       jwt,
-		publisher: publisher,
+	s := &AuthService{
+		users:            users,
+		roles:            roles,
+		tokens:           tokens,
+		orgs:             orgs,
+		groups:           groups,
+		profiles:         profiles,
+		logins:           logins,
+		jwt:              jwt,
+		publisher:        publisher,
+		risk:             riskEngine,
+		devices:          devices,
+		impersonations:   impersonations,
+		impersonationTTL: impersonationTTL,
 	}
+	s.sessions.Store(&SessionPolicy{Sliding: true})
+	return s
+}
+
+// SetSessionPolicy changes the policy for sessions refreshed or started
+// from now on.
+func (s *AuthService) SetSessionPolicy(p SessionPolicy) {
+	s.sessions.Store(&p)
+}
+
+// SetReactivateOnLogin turns reactivating deactivated accounts when their
+// user signs in on or off. Without it they stay inactive until an
+// administrator activates them.
+func (s *AuthService) SetReactivateOnLogin(reactivate bool) {
+	s.reactivateOnLogin.Store(reactivate)
+}
+
+// UseLDAP has logins check passwords against an LDAP directory first; see
+// Login.
+func (s *AuthService) UseLDAP(ldap *LDAPService) {
+	s.ldap = ldap
 }
 
 // LoginInput contains the credentials for login.
@@ -43,6 +122,11 @@ type LoginInput struct {
 	Password  string
 	IPAddress string
 	UserAgent string
+	DeviceID  string // Optional; see LoginDevice
+	Scope     string // Optional OpenID Connect scope; see domain.NormalizeScope
+
+	// RememberMe asks for a long session; see SessionPolicy.ShortTTL.
+	RememberMe bool
 }
 
 // LoginResult contains the tokens and user info after successful login.
@@ -50,31 +134,145 @@ type LoginResult struct {
 	AccessToken      string
 	RefreshToken     string
 	ExpiresInSeconds int64
+	Scope            string // Granted scope; empty is unrestricted
 	User             *domain.User
 }
 
-// Login authenticates a user and returns tokens.
+// Login authenticates a user and returns tokens. Attempts against existing
+// accounts are recorded in the user's login history.
+//
+// With LDAP in use, the password is checked against the directory first.
+// Logins it has no entry for, and every login while it can't be reached,
+// are checked against the local password instead; a password it rejects
+// is rejected.
 func (s *AuthService) Login(ctx context.Context, input LoginInput) (*LoginResult, error) {
-	user, err := s.users.GetByEmail(ctx, input.Email)
+	scope, err := domain.NormalizeScope(input.Scope)
 	if err != nil {
-		return nil, domain.ErrInvalidCredential
+		return nil, err
+	}
+	input.Scope = scope
+
+	var (
+		user               *domain.User
+		verified, rejected bool
+	)
+	if s.ldap != nil {
+		user, err = s.ldap.Authenticate(ctx, input.Email, input.Password)
+		verified = err == nil
+		rejected = errors.Is(err, domain.ErrInvalidCredential)
+	}
+	if !verified {
+		if user, err = s.users.GetByEmail(ctx, input.Email); err != nil {
+			return nil, domain.ErrInvalidCredential
+		}
+	}
+
+	var result *LoginResult
+	switch {
+	case rejected:
+		err = domain.ErrInvalidCredential
+	case verified:
+		result, err = s.signIn(ctx, user, input)
+	default:
+		result, err = s.login(ctx, user, input)
+	}
+
+	attempt := domain.NewLoginAttempt(user.ID, loginFailureReason(err), input.IPAddress, input.UserAgent)
+	_ = s.logins.Create(ctx, attempt)
+
+	return result, err
+}
+
+// upgradePasswordHash rehashes a verified password whose hash predates the
+// current pepper. Failures are ignored; the next login tries again.
+func (s *AuthService) upgradePasswordHash(ctx context.Context, user *domain.User, password string) {
+	if !auth.NeedsRehash(user.PasswordHash) {
+		return
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return
+	}
+
+	previous := user.PasswordHash
+	user.PasswordHash = hash
+	if err := s.users.Update(ctx, user); err != nil {
+		user.PasswordHash = previous
 	}
+}
 
-	if err = auth.CheckPassword(input.Password, user.PasswordHash); err != nil {
+func (s *AuthService) login(ctx context.Context, user *domain.User, input LoginInput) (*LoginResult, error) {
+	if err := auth.CheckPassword(input.Password, user.PasswordHash); err != nil {
 		return nil, domain.ErrInvalidCredential
 	}
 
-	if !user.IsActive() {
+	s.upgradePasswordHash(ctx, user, input.Password)
+
+	return s.signIn(ctx, user, input)
+}
+
+// LoginAs signs in a user an identity provider has vouched for, as Login
+// does once their password is verified, and records the attempt.
+func (s *AuthService) LoginAs(ctx context.Context, user *domain.User, input LoginInput) (*LoginResult, error) {
+	scope, err := domain.NormalizeScope(input.Scope)
+	if err != nil {
+		return nil, err
+	}
+	input.Scope = scope
+
+	result, err := s.signIn(ctx, user, input)
+
+	attempt := domain.NewLoginAttempt(user.ID, loginFailureReason(err), input.IPAddress, input.UserAgent)
+	_ = s.logins.Create(ctx, attempt)
+
+	return result, err
+}
+
+// signIn issues tokens to a user whose identity has been verified, once
+// the risk engine and device checks let them through. Users who
+// deactivated their account are reactivated, if allowed.
+func (s *AuthService) signIn(ctx context.Context, user *domain.User, input LoginInput) (*LoginResult, error) {
+	reactivate := user.Status == domain.UserStatusInactive && user.DeletedAt == nil && s.reactivateOnLogin.Load()
+	if !user.IsActive() && !reactivate {
 		return nil, domain.ErrUnauthorized
 	}
 
-	roles, err := s.roles.GetUserRoles(ctx, user.ID)
+	if err := enforceRisk(ctx, s.risk, s.publisher, risk.Signal{
+		Operation: risk.OperationLogin,
+		UserID:    user.ID,
+		Email:     user.Email,
+		IPAddress: input.IPAddress,
+		UserAgent: input.UserAgent,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := s.devices.CheckLogin(ctx, user, LoginDevice{
+		DeviceID:  input.DeviceID,
+		IPAddress: input.IPAddress,
+		UserAgent: input.UserAgent,
+	}); err != nil {
+		return nil, err
+	}
+
+	if reactivate {
+		if err := user.Activate(); err != nil {
+			return nil, err
+		}
+		if err := s.users.Update(ctx, user); err != nil {
+			return nil, err
+		}
+		_ = s.publisher.Publish(ctx, domain.UserActivatedEvent(user))
+	}
+
+	roles, err := s.roles.GetEffectiveUserRoles(ctx, user.ID)
 	if err != nil {
 		return nil, err
 	}
 	user.Roles = roles
 
-	tokens, err := s.generateTokens(ctx, user, input.IPAddress, input.UserAgent)
+	tokens, err := s.generateTokens(ctx, user, nil, input.Scope, input.IPAddress, input.UserAgent, input.RememberMe, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -86,7 +284,43 @@ func (s *AuthService) Login(ctx context.Context, input LoginInput) (*LoginResult
 	return &LoginResult{
 		AccessToken:      tokens.AccessToken,
 		RefreshToken:     tokens.RefreshToken,
-		ExpiresInSeconds: int64(s.jwt.AccessTokenTTL().Seconds()),
+		ExpiresInSeconds: tokens.ExpiresIn,
+		Scope:            tokens.Scope,
+		User:             user,
+	}, nil
+}
+
+// IssueFor issues tokens to a client the user authorized to sign in as
+// them from elsewhere, such as a device they approved. The user must still
+// be active; the risk engine and device checks applied when they signed in
+// to approve it.
+func (s *AuthService) IssueFor(ctx context.Context, userID uuid.UUID, scope, ipAddress, userAgent string) (*LoginResult, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive() {
+		return nil, domain.ErrUnauthorized
+	}
+
+	roles, err := s.roles.GetEffectiveUserRoles(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	user.Roles = roles
+
+	tokens, err := s.generateTokens(ctx, user, nil, scope, ipAddress, userAgent, true, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.UserLoggedInEvent(user.ID, ipAddress, userAgent))
+
+	return &LoginResult{
+		AccessToken:      tokens.AccessToken,
+		RefreshToken:     tokens.RefreshToken,
+		ExpiresInSeconds: tokens.ExpiresIn,
+		Scope:            tokens.Scope,
 		User:             user,
 	}, nil
 }
@@ -108,14 +342,20 @@ func (s *AuthService) RefreshToken(ctx context.Context, input RefreshTokenInput)
 	}
 
 	if !storedToken.IsValid() {
-		// Token reuse detection: if a revoked token is used, revoke all tokens for this user
+		// Token reuse detection: a revoked token coming back was copied,
+		// so whoever holds its family's current token may not be the user
 		if storedToken.IsRevoked() {
-			// Potential token theft - revoke all tokens for this user
-			_ = s.tokens.RevokeAllForUser(ctx, storedToken.UserID)
+			s.revokeFamily(ctx, storedToken, input.IPAddress)
 		}
 		return nil, domain.ErrInvalidCredential
 	}
 
+	// The maximum lifetime may have been lowered since the token was issued
+	if limit := s.sessions.Load().MaxLifetime; limit > 0 && !domain.Now().Before(storedToken.SessionStartedAt.Add(limit)) {
+		_ = s.tokens.Revoke(ctx, storedToken.ID)
+		return nil, domain.ErrInvalidCredential
+	}
+
 	user, err := s.users.GetByID(ctx, storedToken.UserID)
 	if err != nil {
 		return nil, domain.ErrInvalidCredential
@@ -126,15 +366,30 @@ func (s *AuthService) RefreshToken(ctx context.Context, input RefreshTokenInput)
 		return nil, domain.ErrUnauthorized
 	}
 
-	roles, err := s.roles.GetUserRoles(ctx, user.ID)
+	var profile *domain.Profile
+	if storedToken.ProfileID != nil {
+		if profile, err = s.profiles.GetByID(ctx, *storedToken.ProfileID); err != nil {
+			return nil, domain.ErrInvalidCredential
+		}
+	}
+
+	if err := enforceRisk(ctx, s.risk, s.publisher, risk.Signal{
+		Operation: risk.OperationRefresh,
+		UserID:    user.ID,
+		Email:     user.Email,
+		IPAddress: input.IPAddress,
+		UserAgent: input.UserAgent,
+	}); err != nil {
+		return nil, err
+	}
+
+	roles, err := s.roles.GetEffectiveUserRoles(ctx, user.ID)
 	if err != nil {
 		return nil, err
 	}
 	user.Roles = roles
 
-	_ = s.tokens.Revoke(ctx, storedToken.ID)
-
-	tokens, err := s.generateTokens(ctx, user, input.IPAddress, input.UserAgent)
+	tokens, err := s.generateTokens(ctx, user, profile, storedToken.Scope, input.IPAddress, input.UserAgent, storedToken.RememberMe, storedToken)
 	if err != nil {
 		return nil, err
 	}
@@ -142,7 +397,8 @@ func (s *AuthService) RefreshToken(ctx context.Context, input RefreshTokenInput)
 	return &LoginResult{
 		AccessToken:      tokens.AccessToken,
 		RefreshToken:     tokens.RefreshToken,
-		ExpiresInSeconds: int64(s.jwt.AccessTokenTTL().Seconds()),
+		ExpiresInSeconds: tokens.ExpiresIn,
+		Scope:            tokens.Scope,
 		User:             user,
 	}, nil
 }
@@ -166,31 +422,134 @@ func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
 	return nil
 }
 
+// revokeFamily revokes every refresh token rotated from the same sign-in
+// as token, after token came back revoked. Access tokens can't be traced to
+// a family, so the user's opaque ones all go.
+func (s *AuthService) revokeFamily(ctx context.Context, token *domain.RefreshToken, ipAddress string) {
+	revoked, _ := s.tokens.RevokeFamily(ctx, token.FamilyID)
+	_ = s.jwt.RevokeAccessTokens(ctx, token.UserID)
+	_ = s.publisher.Publish(ctx, domain.RefreshTokenReusedEvent(token, ipAddress, revoked))
+}
+
+// SessionChain returns the refresh tokens of session's family, from the
+// one issued at sign-in to the current one, for forensics.
+func (s *AuthService) SessionChain(ctx context.Context, session *domain.RefreshToken) ([]domain.RefreshToken, error) {
+	return s.tokens.ListFamily(ctx, session.FamilyID)
+}
+
+// LogoutAll revokes the user's refresh tokens and, when access tokens are
+// opaque, their access tokens too.
 func (s *AuthService) LogoutAll(ctx context.Context, userID uuid.UUID) error {
-	return s.tokens.RevokeAllForUser(ctx, userID)
+	if err := s.tokens.RevokeAllForUser(ctx, userID); err != nil {
+		return err
+	}
+	return s.jwt.RevokeAccessTokens(ctx, userID)
+}
+
+// Deactivate makes a user's active account inactive at their request and
+// ends all their sessions. Signing in again reactivates it if
+// SetReactivateOnLogin allows; otherwise an administrator has to.
+func (s *AuthService) Deactivate(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := user.Deactivate(); err != nil {
+		return err
+	}
+	if err := s.users.Update(ctx, user); err != nil {
+		return err
+	}
+
+	if err := s.LogoutAll(ctx, user.ID); err != nil {
+		return err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.NewEvent(domain.EventUserDeactivated, user.ID, nil))
+
+	return nil
+}
+
+// ListSessions returns the user's active sessions, one per unrevoked,
+// unexpired refresh token, newest first.
+func (s *AuthService) ListSessions(ctx context.Context, userID uuid.UUID) ([]domain.RefreshToken, error) {
+	return s.tokens.ListActiveForUser(ctx, userID, domain.Now())
 }
 
 // ValidateToken validates an access token and returns the claims.
+// Impersonation tokens are only valid while their session is active.
 func (s *AuthService) ValidateToken(ctx context.Context, token string) (*auth.Claims, error) {
-	return s.jwt.ValidateAccessToken(token)
+	claims, err := s.jwt.ValidateAccessToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return s.checkClaims(ctx, claims)
+}
+
+// IntrospectToken is ValidateToken for tokens meant for any audience, such
+// as delegation tokens for other services, which ask us about them.
+func (s *AuthService) IntrospectToken(ctx context.Context, token string) (*auth.Claims, error) {
+	claims, err := s.jwt.InspectAccessToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return s.checkClaims(ctx, claims)
 }
 
-func (s *AuthService) generateTokens(ctx context.Context, user *domain.User, ipAddress, userAgent string) (*domain.TokenPair, error) {
-	// Build permission strings for JWT
-	permissions := make([]string, 0)
-	for _, perm := range user.AllPermissions() {
-		permissions = append(permissions, perm.String())
+// checkClaims checks what a token's claims depend on beyond the token
+// itself, and fills in omitted permissions.
+func (s *AuthService) checkClaims(ctx context.Context, claims *auth.Claims) (*auth.Claims, error) {
+	env, ok := domain.ParseEnvironment(claims.Environment)
+	if !ok {
+		return nil, auth.ErrInvalidToken
 	}
+	ctx = domain.WithEnvironment(ctx, env)
 
-	payload := auth.TokenPayload{
-		UserID:      user.ID,
-		Email:       user.Email,
-		Username:    user.Username,
-		UserType:    string(user.Type),
-		Permissions: permissions,
+	if claims.IsImpersonation() {
+		session, err := s.impersonations.GetByID(ctx, claims.Actor.SessionID)
+		if err != nil || !session.IsActive() || session.TargetID != claims.UserID {
+			return nil, auth.ErrInvalidToken
+		}
+	}
+
+	if claims.PermissionsOmitted {
+		if err := s.loadPermissions(ctx, claims); err != nil {
+			return nil, err
+		}
+	}
+
+	return claims, nil
+}
+
+// generateTokens issues a token pair for the user, or for one of their
+// profiles if profile isn't nil, limited to scope. The refresh token starts
+// a family, or joins that of rotated, which it replaces; rememberMe picks
+// its TTL.
+func (s *AuthService) generateTokens(ctx context.Context, user *domain.User, profile *domain.Profile, scope, ipAddress, userAgent string, rememberMe bool, rotated *domain.RefreshToken) (*domain.TokenPair, error) {
+	policy := s.sessions.Load()
+	if rotated == nil {
+		if err := s.limitSessions(ctx, user.ID, policy); err != nil {
+			return nil, err
+		}
+	}
+
+	var payload auth.TokenPayload
+	var err error
+	if profile != nil {
+		payload = profileTokenPayload(user, profile)
+	} else if payload, err = s.tokenPayload(ctx, user); err != nil {
+		return nil, err
+	} else {
+		payload.TTL = grantTTL(user.Roles, s.jwt.AccessTokenTTL())
+	}
+	payload.Environment = tokenEnvironment(ctx)
+	payload.Scope = scope
+	if payload.Extra, err = s.customClaims(ctx, user); err != nil {
+		return nil, err
 	}
 
-	accessToken, _, err := s.jwt.GenerateAccessToken(payload)
+	accessToken, _, err := s.jwt.GenerateAccessToken(ctx, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -200,24 +559,224 @@ func (s *AuthService) generateTokens(ctx context.Context, user *domain.User, ipA
 		return nil, err
 	}
 
+	refreshTTL := s.jwt.RefreshTokenTTL()
+	if !rememberMe && policy.ShortTTL > 0 {
+		refreshTTL = policy.ShortTTL
+	}
+
+	now := domain.Now()
 	refreshToken := &domain.RefreshToken{
-		ID:        uuid.New(),
-		UserID:    user.ID,
-		TokenHash: auth.HashToken(refreshTokenString),
-		ExpiresAt: time.Now().UTC().Add(s.jwt.RefreshTokenTTL()),
-		CreatedAt: time.Now().UTC(),
-		IPAddress: ipAddress,
-		UserAgent: userAgent,
+		ID:               domain.NewID(),
+		UserID:           user.ID,
+		TokenHash:        auth.HashToken(refreshTokenString),
+		ExpiresAt:        now.Add(refreshTTL),
+		CreatedAt:        now,
+		IPAddress:        ipAddress,
+		UserAgent:        userAgent,
+		Scope:            scope,
+		SessionStartedAt: now,
+		RememberMe:       rememberMe,
+	}
+	refreshToken.FamilyID = refreshToken.ID
+	if rotated != nil {
+		refreshToken.FamilyID = rotated.FamilyID
+		refreshToken.SessionStartedAt = rotated.SessionStartedAt
+		if !policy.Sliding {
+			refreshToken.ExpiresAt = rotated.ExpiresAt
+		}
+	}
+	if policy.MaxLifetime > 0 {
+		if end := refreshToken.SessionStartedAt.Add(policy.MaxLifetime); end.Before(refreshToken.ExpiresAt) {
+			refreshToken.ExpiresAt = end
+		}
+	}
+	if profile != nil {
+		refreshToken.ProfileID = &profile.ID
 	}
 
 	if err := s.tokens.Create(ctx, refreshToken); err != nil {
 		return nil, err
 	}
+	if rotated != nil {
+		if err := s.tokens.MarkReplaced(ctx, rotated.ID, refreshToken.ID); err != nil {
+			// A concurrent refresh rotated it first; only one may
+			_ = s.tokens.Revoke(ctx, refreshToken.ID)
+			if errors.Is(err, domain.ErrNotFound) {
+				return nil, domain.ErrInvalidCredential
+			}
+			return nil, err
+		}
+	}
 
 	return &domain.TokenPair{
 		AccessToken:  accessToken,
 		RefreshToken: refreshTokenString,
-		ExpiresIn:    int64(s.jwt.AccessTokenTTL().Seconds()),
+		ExpiresIn:    int64(cmp.Or(payload.TTL, s.jwt.AccessTokenTTL()).Seconds()),
+		Scope:        scope,
+	}, nil
+}
+
+// limitSessions makes room for a new session of the user's under the
+// policy's limit, ending their least recently refreshed sessions, or
+// returns ErrTooManySessions if the policy is to reject it.
+func (s *AuthService) limitSessions(ctx context.Context, userID uuid.UUID, policy *SessionPolicy) error {
+	if policy.MaxSessions <= 0 {
+		return nil
+	}
+
+	now := domain.Now()
+	active, err := s.tokens.CountActiveForUser(ctx, userID, now)
+	if err != nil {
+		return err
+	}
+	if active < int64(policy.MaxSessions) {
+		return nil
+	}
+	if policy.RejectOverLimit {
+		return domain.ErrTooManySessions
+	}
+
+	sessions, err := s.tokens.ListActiveForUser(ctx, userID, now)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions[min(policy.MaxSessions-1, len(sessions)):] {
+		// Already gone if rotated or revoked meanwhile
+		if err := s.tokens.Revoke(ctx, session.ID); err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// grantTTL shortens an access token's lifetime so it doesn't outlive the
+// time-bound roles it grants: the token carries their permissions, so it
+// has to expire with them.
+func grantTTL(roles []domain.Role, ttl time.Duration) time.Duration {
+	for _, role := range roles {
+		if role.ExpiresAt == nil {
+			continue
+		}
+		if left := role.ExpiresAt.Sub(domain.Now()); left < ttl {
+			ttl = max(left, time.Second)
+		}
+	}
+	return ttl
+}
+
+// tokenPayload builds the access token claims for a user whose Roles hold
+// the effective roles.
+func (s *AuthService) tokenPayload(ctx context.Context, user *domain.User) (auth.TokenPayload, error) {
+	// Build permission strings for JWT. user.Roles holds the effective
+	// roles, so permissions inherited from groups are included, and so are
+	// their denials.
+	permissions, denied := domain.PermissionClaims(user.AllPermissions())
+
+	orgs, err := s.orgs.ListForUser(ctx, user.ID)
+	if err != nil {
+		return auth.TokenPayload{}, err
+	}
+
+	orgClaims := make([]auth.OrganizationClaim, 0, len(orgs))
+	for _, org := range orgs {
+		claim := auth.OrganizationClaim{ID: org.ID}
+		if perms := user.OrganizationPermissions(org.ID); len(perms) > 0 {
+			claim.Permissions, claim.Denied = domain.PermissionClaims(perms)
+		}
+		orgClaims = append(orgClaims, claim)
+	}
+
+	groups, err := s.groups.ListForUser(ctx, user.ID)
+	if err != nil {
+		return auth.TokenPayload{}, err
+	}
+
+	groupNames := make([]string, len(groups))
+	for i, g := range groups {
+		groupNames[i] = g.Name
+	}
+
+	return auth.TokenPayload{
+		UserID:        user.ID,
+		Email:         user.Email,
+		Username:      user.Username,
+		UserType:      string(user.Type),
+		Locale:        user.Locale,
+		Timezone:      user.Timezone,
+		Permissions:   permissions,
+		Denied:        denied,
+		Organizations: orgClaims,
+		Groups:        groupNames,
+	}, nil
+}
+
+// tokenEnvironment is the environment claim of tokens issued in ctx.
+func tokenEnvironment(ctx context.Context) string {
+	if env := domain.EnvironmentFromContext(ctx); env.IsSandbox() {
+		return string(env)
+	}
+	return ""
+}
+
+// profileTokenPayload builds the access token claims for acting as one of
+// the user's profiles: the profile's type and the permissions of its roles
+// only, without the user's own, organization or group permissions.
+func profileTokenPayload(user *domain.User, profile *domain.Profile) auth.TokenPayload {
+	permissions, denied := domain.PermissionClaims(profile.AllPermissions())
+
+	return auth.TokenPayload{
+		UserID:      user.ID,
+		Email:       user.Email,
+		Username:    user.Username,
+		UserType:    string(profile.Type),
+		Locale:      user.Locale,
+		Timezone:    user.Timezone,
+		Permissions: permissions,
+		Denied:      denied,
+		Profile:     &auth.ProfileClaim{ID: profile.ID, Type: string(profile.Type)},
+	}
+}
+
+// SwitchProfile issues a new token pair for the user acting as one of their
+// profiles, or as themselves if profileID is nil. The tokens keep the scope
+// of the ones they replace.
+func (s *AuthService) SwitchProfile(ctx context.Context, userID uuid.UUID, profileID *uuid.UUID, scope, ipAddress, userAgent string) (*LoginResult, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive() {
+		return nil, domain.ErrUnauthorized
+	}
+
+	var profile *domain.Profile
+	if profileID != nil {
+		profile, err = s.profiles.GetByID(ctx, *profileID)
+		if err != nil {
+			return nil, err
+		}
+		if profile.UserID != user.ID {
+			return nil, domain.ErrNotFound
+		}
+	} else {
+		roles, err := s.roles.GetEffectiveUserRoles(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		user.Roles = roles
+	}
+
+	tokens, err := s.generateTokens(ctx, user, profile, scope, ipAddress, userAgent, true, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{
+		AccessToken:      tokens.AccessToken,
+		RefreshToken:     tokens.RefreshToken,
+		ExpiresInSeconds: tokens.ExpiresIn,
+		Scope:            tokens.Scope,
+		User:             user,
 	}, nil
 }
 
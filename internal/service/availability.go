@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// AvailabilityService checks whether an email or username can still be
+// registered.
+//
+// Every check does the same database work whichever fields are given and
+// whether they are taken, and takes at least minLatency, so response times
+// don't reveal which accounts exist.
+type AvailabilityService struct {
+	users      storage.UserRepository
+	suggester  *UsernameSuggester
+	screener   *EmailScreener
+	minLatency time.Duration
+}
+
+func NewAvailabilityService(users storage.UserRepository, suggester *UsernameSuggester, screener *EmailScreener, minLatency time.Duration) *AvailabilityService {
+	return &AvailabilityService{
+		users:      users,
+		suggester:  suggester,
+		screener:   screener,
+		minLatency: minLatency,
+	}
+}
+
+type AvailabilityInput struct {
+	Email    string
+	Username string
+	FullName string // Optional; used for name-based suggestions
+}
+
+// FieldAvailability is the result for one field.
+type FieldAvailability struct {
+	Value       string
+	Available   bool
+	Reason      string // "taken", "invalid" or "disposable" when not available
+	Message     string // Validation message when invalid or disposable
+	Suggestions []string
+}
+
+type AvailabilityResult struct {
+	Email    *FieldAvailability // Nil if no email was given
+	Username *FieldAvailability // Nil if no username was given
+}
+
+// Check reports whether the email and username are available. Soft-deleted
+// accounts still hold theirs, so they count as taken.
+func (s *AvailabilityService) Check(ctx context.Context, input AvailabilityInput) (*AvailabilityResult, error) {
+	defer s.pad(ctx, time.Now())
+
+	email := strings.ToLower(strings.TrimSpace(input.Email))
+	username := domain.NormalizeUsername(input.Username)
+
+	if email == "" && username == "" {
+		return nil, domain.ValidationError{Field: "email", Message: "email or username required"}
+	}
+
+	emailTaken, err := s.users.EmailTaken(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	// Look the username and its alternatives up in one query
+	candidates := s.suggester.Candidates(username, input.FullName)
+	taken, err := s.users.TakenUsernames(ctx, append([]string{username}, candidates...))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AvailabilityResult{}
+
+	if email != "" {
+		result.Email = &FieldAvailability{Value: email, Available: true}
+		if verr := domain.ValidateEmail(email); verr != nil {
+			result.Email.Available = false
+			result.Email.Reason = "invalid"
+			result.Email.Message = verr.Message
+		} else if emailTaken {
+			result.Email.Available = false
+			result.Email.Reason = "taken"
+		} else if _, verr := s.screener.Screen(email); verr != nil {
+			result.Email.Available = false
+			result.Email.Reason = "disposable"
+			result.Email.Message = verr.Message
+		}
+	}
+
+	if username != "" {
+		result.Username = &FieldAvailability{Value: username, Available: true}
+		if verr := domain.ValidateUsername(username); verr != nil {
+			result.Username.Available = false
+			result.Username.Reason = "invalid"
+			result.Username.Message = verr.Message
+		} else if slices.Contains(taken, username) {
+			result.Username.Available = false
+			result.Username.Reason = "taken"
+			result.Username.Suggestions = s.suggester.Pick(candidates, taken)
+		}
+	}
+
+	return result, nil
+}
+
+// pad sleeps until minLatency has passed since start.
+func (s *AvailabilityService) pad(ctx context.Context, start time.Time) {
+	wait := s.minLatency - time.Since(start)
+	if wait <= 0 {
+		return
+	}
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mvaleed/aegis/internal/auth"
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// ClaimsEnricher adds custom claims, such as a tenant ID, locale or plan,
+// to a user's access tokens. It runs whenever tokens are issued for the
+// user, impersonation and profile tokens included. Claims named like those
+// aegis sets are ignored.
+type ClaimsEnricher interface {
+	EnrichClaims(ctx context.Context, user *domain.User) (map[string]any, error)
+}
+
+// ClaimsEnricherFunc adapts a function to ClaimsEnricher.
+type ClaimsEnricherFunc func(ctx context.Context, user *domain.User) (map[string]any, error)
+
+func (f ClaimsEnricherFunc) EnrichClaims(ctx context.Context, user *domain.User) (map[string]any, error) {
+	return f(ctx, user)
+}
+
+// AttributeClaims is a ClaimsEnricher copying user attributes into claims,
+// mapping claim names to attribute names. Attributes the user doesn't have
+// are left out.
+type AttributeClaims map[string]string
+
+// ParseAttributeClaims parses claim=attribute items, such as
+// "tenant_id=tenant", into AttributeClaims.
+func ParseAttributeClaims(items []string) (AttributeClaims, error) {
+	a := make(AttributeClaims, len(items))
+	for _, item := range items {
+		claim, attribute, ok := strings.Cut(item, "=")
+		claim, attribute = strings.TrimSpace(claim), strings.TrimSpace(attribute)
+		if !ok || claim == "" || attribute == "" {
+			return nil, fmt.Errorf("attribute claim %q: want claim=attribute", item)
+		}
+		if auth.IsStandardClaim(claim) {
+			return nil, fmt.Errorf("attribute claim %q: %s is a standard claim", item, claim)
+		}
+		a[claim] = attribute
+	}
+	return a, nil
+}
+
+func (a AttributeClaims) EnrichClaims(_ context.Context, user *domain.User) (map[string]any, error) {
+	claims := make(map[string]any, len(a))
+	for claim, attribute := range a {
+		if value, ok := user.Attributes[attribute]; ok {
+			claims[claim] = value
+		}
+	}
+	return claims, nil
+}
+
+// AddClaimsEnricher has tokens issued from now on carry the claims e adds.
+// Enrichers run in the order added, later ones overriding earlier ones. It
+// must be called before the service is used.
+func (s *AuthService) AddClaimsEnricher(e ClaimsEnricher) {
+	s.enrichers = append(s.enrichers, e)
+}
+
+// customClaims collects the enrichers' claims for user. A failing enricher
+// fails the token: one without the claims could be read as belonging to
+// the wrong tenant or plan.
+func (s *AuthService) customClaims(ctx context.Context, user *domain.User) (map[string]any, error) {
+	if len(s.enrichers) == 0 {
+		return nil, nil
+	}
+
+	claims := make(map[string]any)
+	for _, e := range s.enrichers {
+		extra, err := e.EnrichClaims(ctx, user)
+		if err != nil {
+			return nil, fmt.Errorf("enriching claims: %w", err)
+		}
+		for name, value := range extra {
+			if !auth.IsStandardClaim(name) {
+				claims[name] = value
+			}
+		}
+	}
+	return claims, nil
+}
+
+// loadPermissions fills in the permissions left out of a token issued with
+// OmitPermissions, from the user's current roles.
+func (s *AuthService) loadPermissions(ctx context.Context, claims *auth.Claims) error {
+	roles, err := s.roles.GetEffectiveUserRoles(ctx, claims.UserID)
+	if err != nil {
+		return err
+	}
+	user := &domain.User{ID: claims.UserID, Roles: roles}
+
+	claims.Permissions, claims.Denied = domain.PermissionClaims(user.AllPermissions())
+	for i := range claims.Organizations {
+		org := &claims.Organizations[i]
+		if perms := user.OrganizationPermissions(org.ID); len(perms) > 0 {
+			org.Permissions, org.Denied = domain.PermissionClaims(perms)
+		}
+	}
+	return nil
+}
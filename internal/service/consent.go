@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// ConsentService records customers' consent to partners and answers
+// whether a partner may act on a customer.
+type ConsentService struct {
+	consents  storage.ConsentRepository
+	users     storage.UserRepository
+	profiles  storage.ProfileRepository
+	publisher event.Publisher
+}
+
+func NewConsentService(
+	consents storage.ConsentRepository,
+	users storage.UserRepository,
+	profiles storage.ProfileRepository,
+	publisher event.Publisher,
+) *ConsentService {
+	return &ConsentService{
+		consents:  consents,
+		users:     users,
+		profiles:  profiles,
+		publisher: publisher,
+	}
+}
+
+// GrantConsent lets partnerID use scopes on the customer's data. An
+// existing active consent for the partner has its scopes replaced.
+func (s *ConsentService) GrantConsent(ctx context.Context, customerID, partnerID uuid.UUID, scopes []string) (*domain.Consent, error) {
+	if err := s.requirePartner(ctx, partnerID); err != nil {
+		return nil, err
+	}
+
+	consent, err := s.consents.GetActive(ctx, customerID, partnerID)
+	switch {
+	case err == nil:
+		if err := consent.SetScopes(scopes); err != nil {
+			return nil, err
+		}
+		if err := s.consents.Update(ctx, consent); err != nil {
+			return nil, err
+		}
+	case errors.Is(err, domain.ErrNotFound):
+		if consent, err = domain.NewConsent(customerID, partnerID, scopes); err != nil {
+			return nil, err
+		}
+		if err := s.consents.Create(ctx, consent); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.ConsentGrantedEvent(consent))
+
+	return consent, nil
+}
+
+// ListCustomerConsents returns the consents a customer gave.
+func (s *ConsentService) ListCustomerConsents(ctx context.Context, customerID uuid.UUID, withRevoked bool) ([]domain.Consent, error) {
+	return s.consents.ListForCustomer(ctx, customerID, withRevoked)
+}
+
+// ListPartnerConsents returns the active consents given to a partner.
+func (s *ConsentService) ListPartnerConsents(ctx context.Context, partnerID uuid.UUID) ([]domain.Consent, error) {
+	return s.consents.ListForPartner(ctx, partnerID)
+}
+
+// RevokeConsent withdraws one of the customer's consents. As consent is
+// checked on every partner request, the partner's existing tokens and API
+// keys lose access to the customer immediately.
+func (s *ConsentService) RevokeConsent(ctx context.Context, customerID, consentID uuid.UUID) error {
+	consent, err := s.consents.GetByID(ctx, consentID)
+	if err != nil {
+		return err
+	}
+	if consent.CustomerID != customerID {
+		return domain.ErrNotFound
+	}
+	if consent.IsRevoked() {
+		return nil
+	}
+
+	consent.Revoke()
+	if err := s.consents.Update(ctx, consent); err != nil {
+		return err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.ConsentRevokedEvent(consent))
+
+	return nil
+}
+
+// Allows reports whether the customer's active consent lets partnerID use
+// scope (resource:action) on them.
+func (s *ConsentService) Allows(ctx context.Context, customerID, partnerID uuid.UUID, scope string) (bool, error) {
+	consent, err := s.consents.GetActive(ctx, customerID, partnerID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return consent.Allows(scope), nil
+}
+
+// requirePartner checks that the user is a partner, either by type or
+// through a partner profile.
+func (s *ConsentService) requirePartner(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ValidationError{Field: "partner_id", Message: "partner not found"}
+		}
+		return err
+	}
+	if user.Type == domain.UserTypePartner {
+		return nil
+	}
+
+	profiles, err := s.profiles.ListForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, p := range profiles {
+		if p.Type == domain.UserTypePartner {
+			return nil
+		}
+	}
+	return domain.ValidationError{Field: "partner_id", Message: "partner not found"}
+}
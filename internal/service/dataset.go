@@ -0,0 +1,539 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// DatasetFormat and DatasetVersion identify export files. The version is
+// bumped whenever a field is removed or changes meaning.
+const (
+	DatasetFormat  = "aegis.dataset"
+	DatasetVersion = 1
+)
+
+// Dataset is a logical export of users, roles, permissions and the
+// organization structure, as written to and read from JSON files. Refresh
+// tokens, API keys and other secrets are never included.
+type Dataset struct {
+	Format        string                `json:"format"`
+	Version       int                   `json:"version"`
+	ExportedAt    time.Time             `json:"exported_at"`
+	Permissions   []DatasetPermission   `json:"permissions"`
+	Organizations []DatasetOrganization `json:"organizations"`
+	Roles         []DatasetRole         `json:"roles"`
+	Groups        []DatasetGroup        `json:"groups"`
+	Users         []DatasetUser         `json:"users"`
+}
+
+type DatasetPermission struct {
+	ID          uuid.UUID `json:"id"`
+	Resource    string    `json:"resource"`
+	Action      string    `json:"action"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type DatasetOrganization struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type DatasetRole struct {
+	ID             uuid.UUID   `json:"id"`
+	Name           string      `json:"name"`
+	Description    string      `json:"description"`
+	OrganizationID *uuid.UUID  `json:"organization_id,omitempty"`
+	Permissions    []uuid.UUID `json:"permissions,omitempty"`
+	Denied         []uuid.UUID `json:"denied,omitempty"` // Permissions the role denies
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+type DatasetGroup struct {
+	ID          uuid.UUID   `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Roles       []uuid.UUID `json:"roles,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+type DatasetUser struct {
+	ID            uuid.UUID           `json:"id"`
+	Email         string              `json:"email"`
+	PasswordHash  string              `json:"password_hash,omitempty"` // Empty when exported without passwords
+	Phone         *string             `json:"phone,omitempty"`
+	Username      string              `json:"username"`
+	FullName      string              `json:"full_name"`
+	Type          string              `json:"user_type"`
+	Status        string              `json:"status"`
+	EmailVerified bool                `json:"email_verified"`
+	PhoneVerified bool                `json:"phone_verified"`
+	Attributes    map[string]any      `json:"attributes,omitempty"`
+	Locale        string              `json:"locale,omitempty"`
+	Timezone      string              `json:"timezone,omitempty"`
+	Roles         []DatasetRoleGrant  `json:"roles,omitempty"`
+	Organizations []DatasetMembership `json:"organizations,omitempty"`
+	Groups        []DatasetMembership `json:"groups,omitempty"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+	DeletedAt     *time.Time          `json:"deleted_at,omitempty"`
+	Version       int                 `json:"version"`
+}
+
+// DatasetRoleGrant is a role assigned to a user.
+type DatasetRoleGrant struct {
+	RoleID    uuid.UUID  `json:"role_id"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// DatasetMembership is a user's membership of an organization or group.
+type DatasetMembership struct {
+	ID       uuid.UUID `json:"id"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// ExportOptions controls what Export leaves out.
+type ExportOptions struct {
+	// OmitPasswords leaves password hashes out, e.g. when cloning
+	// production into a test environment. Imported users then have to
+	// reset their password before they can log in.
+	OmitPasswords bool
+}
+
+// ImportOptions controls how Import writes a dataset.
+type ImportOptions struct {
+	// RemapIDs gives every record a new ID, keeping the references between
+	// them, so a dataset can be imported next to the one it was exported
+	// from.
+	RemapIDs bool
+
+	// DryRun rolls the import back once it's done, to report what it
+	// would change.
+	DryRun bool
+}
+
+// errDatasetDryRun rolls back the transaction of a dry run import.
+var errDatasetDryRun = errors.New("dry run")
+
+// ImportResult counts what Import created and reused.
+type ImportResult struct {
+	PermissionsCreated    int      `json:"permissions_created"`
+	PermissionsExisting   int      `json:"permissions_existing"`
+	OrganizationsCreated  int      `json:"organizations_created"`
+	OrganizationsExisting int      `json:"organizations_existing"`
+	RolesCreated          int      `json:"roles_created"`
+	RolesExisting         int      `json:"roles_existing"`
+	GroupsCreated         int      `json:"groups_created"`
+	GroupsExisting        int      `json:"groups_existing"`
+	UsersCreated          int      `json:"users_created"`
+	UsersSkipped          []string `json:"users_skipped,omitempty"` // Emails of users whose ID, email or username was taken
+	LinksCreated          int      `json:"links_created"`           // Grants, role assignments and memberships
+}
+
+// DatasetService exports and imports the auth dataset for disaster
+// recovery drills and environment cloning.
+type DatasetService struct {
+	datasets storage.DatasetRepository
+	tx       storage.Transactor
+}
+
+func NewDatasetService(datasets storage.DatasetRepository, tx storage.Transactor) *DatasetService {
+	return &DatasetService{datasets: datasets, tx: tx}
+}
+
+// Export reads the dataset as of one consistent snapshot.
+func (s *DatasetService) Export(ctx context.Context, opts ExportOptions) (*Dataset, error) {
+	d, err := s.datasets.Export(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Dataset{
+		Format:        DatasetFormat,
+		Version:       DatasetVersion,
+		ExportedAt:    domain.Now().UTC(),
+		Permissions:   make([]DatasetPermission, 0, len(d.Permissions)),
+		Organizations: make([]DatasetOrganization, 0, len(d.Organizations)),
+		Roles:         make([]DatasetRole, 0, len(d.Roles)),
+		Groups:        make([]DatasetGroup, 0, len(d.Groups)),
+		Users:         make([]DatasetUser, 0, len(d.Users)),
+	}
+
+	for _, p := range d.Permissions {
+		out.Permissions = append(out.Permissions, DatasetPermission{
+			ID:          p.ID,
+			Resource:    p.Resource,
+			Action:      p.Action,
+			Description: p.Description,
+			CreatedAt:   p.CreatedAt,
+		})
+	}
+
+	for _, o := range d.Organizations {
+		out.Organizations = append(out.Organizations, DatasetOrganization{
+			ID:        o.ID,
+			Name:      o.Name,
+			Slug:      o.Slug,
+			CreatedAt: o.CreatedAt,
+			UpdatedAt: o.UpdatedAt,
+		})
+	}
+
+	for _, r := range d.Roles {
+		role := DatasetRole{
+			ID:             r.ID,
+			Name:           r.Name,
+			Description:    r.Description,
+			OrganizationID: r.OrganizationID,
+			CreatedAt:      r.CreatedAt,
+			UpdatedAt:      r.UpdatedAt,
+		}
+		for _, p := range r.Permissions {
+			if p.Deny {
+				role.Denied = append(role.Denied, p.ID)
+			} else {
+				role.Permissions = append(role.Permissions, p.ID)
+			}
+		}
+		out.Roles = append(out.Roles, role)
+	}
+
+	for _, g := range d.Groups {
+		group := DatasetGroup{
+			ID:          g.ID,
+			Name:        g.Name,
+			Description: g.Description,
+			CreatedAt:   g.CreatedAt,
+			UpdatedAt:   g.UpdatedAt,
+		}
+		for _, r := range g.Roles {
+			group.Roles = append(group.Roles, r.ID)
+		}
+		out.Groups = append(out.Groups, group)
+	}
+
+	users := make(map[uuid.UUID]int, len(d.Users))
+	for _, u := range d.Users {
+		user := DatasetUser{
+			ID:            u.ID,
+			Email:         u.Email,
+			PasswordHash:  u.PasswordHash,
+			Phone:         u.Phone,
+			Username:      u.Username,
+			FullName:      u.FullName,
+			Type:          string(u.Type),
+			Status:        string(u.Status),
+			EmailVerified: u.EmailVerified,
+			PhoneVerified: u.PhoneVerified,
+			Attributes:    u.Attributes,
+			Locale:        u.Locale,
+			Timezone:      u.Timezone,
+			CreatedAt:     u.CreatedAt,
+			UpdatedAt:     u.UpdatedAt,
+			DeletedAt:     u.DeletedAt,
+			Version:       u.Version,
+		}
+		if opts.OmitPasswords {
+			user.PasswordHash = ""
+		}
+		for _, r := range u.Roles {
+			user.Roles = append(user.Roles, DatasetRoleGrant{RoleID: r.ID, ExpiresAt: r.ExpiresAt})
+		}
+		users[u.ID] = len(out.Users)
+		out.Users = append(out.Users, user)
+	}
+
+	for _, m := range d.OrganizationMembers {
+		user := &out.Users[users[m.UserID]]
+		user.Organizations = append(user.Organizations, DatasetMembership{ID: m.OrganizationID, JoinedAt: m.JoinedAt})
+	}
+	for _, m := range d.GroupMembers {
+		user := &out.Users[users[m.UserID]]
+		user.Groups = append(user.Groups, DatasetMembership{ID: m.GroupID, JoinedAt: m.JoinedAt})
+	}
+
+	return out, nil
+}
+
+// Import writes a dataset in one transaction: either all of it is applied
+// or none. Records that already exist are reused, as described on
+// storage.DatasetRepository. Imported users get no default role and no
+// events are published, since the dataset is copied as it is.
+func (s *DatasetService) Import(ctx context.Context, d *Dataset, opts ImportOptions) (ImportResult, error) {
+	if d.Format != DatasetFormat {
+		return ImportResult{}, domain.ValidationError{Field: "format", Message: fmt.Sprintf("want %q, got %q", DatasetFormat, d.Format)}
+	}
+	if d.Version < 1 || d.Version > DatasetVersion {
+		return ImportResult{}, domain.ValidationError{Field: "version", Message: fmt.Sprintf("unsupported version %d", d.Version)}
+	}
+	if err := d.validate(); err != nil {
+		return ImportResult{}, err
+	}
+	if opts.RemapIDs {
+		d = d.remap()
+	}
+
+	var result storage.DatasetImportResult
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if result, err = s.datasets.Import(ctx, d.toStorage()); err != nil {
+			return err
+		}
+		if opts.DryRun {
+			return errDatasetDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDatasetDryRun) {
+		return ImportResult{}, err
+	}
+
+	return ImportResult{
+		PermissionsCreated:    result.PermissionsCreated,
+		PermissionsExisting:   result.PermissionsExisting,
+		OrganizationsCreated:  result.OrganizationsCreated,
+		OrganizationsExisting: result.OrganizationsExisting,
+		RolesCreated:          result.RolesCreated,
+		RolesExisting:         result.RolesExisting,
+		GroupsCreated:         result.GroupsCreated,
+		GroupsExisting:        result.GroupsExisting,
+		UsersCreated:          result.UsersCreated,
+		UsersSkipped:          result.UsersSkipped,
+		LinksCreated:          result.LinksCreated,
+	}, nil
+}
+
+// validate checks that every reference points at a record of the dataset
+// and that no ID is used twice.
+func (d *Dataset) validate() error {
+	var errs domain.ValidationErrors
+	seen := make(map[uuid.UUID]string)
+	define := func(id uuid.UUID, kind string) {
+		if other, ok := seen[id]; ok {
+			errs = append(errs, domain.ValidationError{Field: kind, Message: fmt.Sprintf("ID %s is already used by a %s", id, other)})
+		}
+		seen[id] = kind
+	}
+	refer := func(id uuid.UUID, kind, from string) {
+		if seen[id] != kind {
+			errs = append(errs, domain.ValidationError{Field: kind, Message: fmt.Sprintf("%s refers to unknown %s %s", from, kind, id)})
+		}
+	}
+
+	for _, p := range d.Permissions {
+		define(p.ID, "permission")
+	}
+	for _, o := range d.Organizations {
+		define(o.ID, "organization")
+	}
+	for _, r := range d.Roles {
+		define(r.ID, "role")
+		if r.OrganizationID != nil {
+			refer(*r.OrganizationID, "organization", "role "+r.Name)
+		}
+		for _, id := range append(r.Permissions, r.Denied...) {
+			refer(id, "permission", "role "+r.Name)
+		}
+	}
+	for _, g := range d.Groups {
+		define(g.ID, "group")
+		for _, id := range g.Roles {
+			refer(id, "role", "group "+g.Name)
+		}
+	}
+	for _, u := range d.Users {
+		define(u.ID, "user")
+		for _, grant := range u.Roles {
+			refer(grant.RoleID, "role", "user "+u.Email)
+		}
+		for _, m := range u.Organizations {
+			refer(m.ID, "organization", "user "+u.Email)
+		}
+		for _, m := range u.Groups {
+			refer(m.ID, "group", "user "+u.Email)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// remap returns a copy of the dataset with new IDs for every record and
+// the references between them rewritten to match.
+func (d *Dataset) remap() *Dataset {
+	ids := make(map[uuid.UUID]uuid.UUID)
+	id := func(old uuid.UUID) uuid.UUID {
+		if id, ok := ids[old]; ok {
+			return id
+		}
+		ids[old] = domain.NewID()
+		return ids[old]
+	}
+	each := func(olds []uuid.UUID) []uuid.UUID {
+		news := make([]uuid.UUID, len(olds))
+		for i, old := range olds {
+			news[i] = id(old)
+		}
+		return news
+	}
+
+	out := *d
+	out.Permissions = make([]DatasetPermission, len(d.Permissions))
+	for i, p := range d.Permissions {
+		p.ID = id(p.ID)
+		out.Permissions[i] = p
+	}
+	out.Organizations = make([]DatasetOrganization, len(d.Organizations))
+	for i, o := range d.Organizations {
+		o.ID = id(o.ID)
+		out.Organizations[i] = o
+	}
+	out.Roles = make([]DatasetRole, len(d.Roles))
+	for i, r := range d.Roles {
+		r.ID = id(r.ID)
+		if r.OrganizationID != nil {
+			orgID := id(*r.OrganizationID)
+			r.OrganizationID = &orgID
+		}
+		r.Permissions = each(r.Permissions)
+		r.Denied = each(r.Denied)
+		out.Roles[i] = r
+	}
+	out.Groups = make([]DatasetGroup, len(d.Groups))
+	for i, g := range d.Groups {
+		g.ID = id(g.ID)
+		g.Roles = each(g.Roles)
+		out.Groups[i] = g
+	}
+	out.Users = make([]DatasetUser, len(d.Users))
+	for i, u := range d.Users {
+		u.ID = id(u.ID)
+		u.Roles = make([]DatasetRoleGrant, len(u.Roles))
+		for j, grant := range d.Users[i].Roles {
+			u.Roles[j] = DatasetRoleGrant{RoleID: id(grant.RoleID), ExpiresAt: grant.ExpiresAt}
+		}
+		u.Organizations = make([]DatasetMembership, len(u.Organizations))
+		for j, m := range d.Users[i].Organizations {
+			u.Organizations[j] = DatasetMembership{ID: id(m.ID), JoinedAt: m.JoinedAt}
+		}
+		u.Groups = make([]DatasetMembership, len(u.Groups))
+		for j, m := range d.Users[i].Groups {
+			u.Groups[j] = DatasetMembership{ID: id(m.ID), JoinedAt: m.JoinedAt}
+		}
+		out.Users[i] = u
+	}
+	return &out
+}
+
+// toStorage converts the file format to the records the repository takes.
+func (d *Dataset) toStorage() *storage.Dataset {
+	out := &storage.Dataset{}
+
+	for _, p := range d.Permissions {
+		out.Permissions = append(out.Permissions, domain.Permission{
+			ID:          p.ID,
+			Resource:    p.Resource,
+			Action:      p.Action,
+			Description: p.Description,
+			CreatedAt:   p.CreatedAt,
+		})
+	}
+
+	for _, o := range d.Organizations {
+		out.Organizations = append(out.Organizations, domain.Organization{
+			ID:        o.ID,
+			Name:      o.Name,
+			Slug:      o.Slug,
+			CreatedAt: o.CreatedAt,
+			UpdatedAt: o.UpdatedAt,
+		})
+	}
+
+	for _, r := range d.Roles {
+		role := domain.Role{
+			ID:             r.ID,
+			Name:           r.Name,
+			Description:    r.Description,
+			OrganizationID: r.OrganizationID,
+			CreatedAt:      r.CreatedAt,
+			UpdatedAt:      r.UpdatedAt,
+		}
+		for _, id := range r.Permissions {
+			role.Permissions = append(role.Permissions, domain.Permission{ID: id})
+		}
+		for _, id := range r.Denied {
+			role.Permissions = append(role.Permissions, domain.Permission{ID: id, Deny: true})
+		}
+		out.Roles = append(out.Roles, role)
+	}
+
+	for _, g := range d.Groups {
+		group := domain.Group{
+			ID:          g.ID,
+			Name:        g.Name,
+			Description: g.Description,
+			CreatedAt:   g.CreatedAt,
+			UpdatedAt:   g.UpdatedAt,
+		}
+		for _, id := range g.Roles {
+			group.Roles = append(group.Roles, domain.Role{ID: id})
+		}
+		out.Groups = append(out.Groups, group)
+	}
+
+	for _, u := range d.Users {
+		user := domain.User{
+			ID:            u.ID,
+			Email:         u.Email,
+			PasswordHash:  u.PasswordHash,
+			Phone:         u.Phone,
+			Username:      u.Username,
+			FullName:      u.FullName,
+			Type:          domain.UserType(u.Type),
+			Status:        domain.UserStatus(u.Status),
+			EmailVerified: u.EmailVerified,
+			PhoneVerified: u.PhoneVerified,
+			Attributes:    u.Attributes,
+			Locale:        u.Locale,
+			Timezone:      u.Timezone,
+			CreatedAt:     u.CreatedAt,
+			UpdatedAt:     u.UpdatedAt,
+			DeletedAt:     u.DeletedAt,
+			Version:       u.Version,
+		}
+		for _, grant := range u.Roles {
+			user.Roles = append(user.Roles, domain.Role{ID: grant.RoleID, ExpiresAt: grant.ExpiresAt})
+		}
+		for _, m := range u.Organizations {
+			out.OrganizationMembers = append(out.OrganizationMembers, domain.OrganizationMember{
+				OrganizationID: m.ID,
+				UserID:         u.ID,
+				JoinedAt:       m.JoinedAt,
+			})
+		}
+		for _, m := range u.Groups {
+			out.GroupMembers = append(out.GroupMembers, domain.GroupMember{
+				GroupID:  m.ID,
+				UserID:   u.ID,
+				JoinedAt: m.JoinedAt,
+			})
+		}
+		out.Users = append(out.Users, user)
+	}
+
+	return out
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/auth"
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/notify"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// Reasons a login is considered suspicious.
+const (
+	loginReasonNewDevice  = "new_device"
+	loginReasonNewNetwork = "new_network"
+)
+
+// DeviceService tracks the devices users sign in from and flags logins from
+// new devices or networks.
+type DeviceService struct {
+	devices   storage.DeviceRepository
+	tokens    *ActionTokenService
+	publisher event.Publisher
+	notifier  notify.Dispatcher
+
+	// requireConfirmation holds suspicious logins until the user confirms
+	// them by email.
+	requireConfirmation atomic.Bool
+	confirmationTTL     time.Duration
+}
+
+func NewDeviceService(
+	devices storage.DeviceRepository,
+	tokens *ActionTokenService,
+	publisher event.Publisher,
+	notifier notify.Dispatcher,
+	requireConfirmation bool,
+	confirmationTTL time.Duration,
+) *DeviceService {
+	s := &DeviceService{
+		devices:         devices,
+		tokens:          tokens,
+		publisher:       publisher,
+		notifier:        notifier,
+		confirmationTTL: confirmationTTL,
+	}
+	s.requireConfirmation.Store(requireConfirmation)
+	return s
+}
+
+// SetRequireConfirmation turns email confirmation of suspicious logins on
+// or off.
+func (s *DeviceService) SetRequireConfirmation(require bool) {
+	s.requireConfirmation.Store(require)
+}
+
+// LoginDevice identifies the device a login comes from.
+type LoginDevice struct {
+	DeviceID  string // Optional client-generated ID; the user agent is used without one
+	IPAddress string
+	UserAgent string
+}
+
+// CheckLogin records a login by user from device. A login from a new device
+// or network publishes a user.login_new_device event and alerts the user.
+// If confirmation is required, it instead emails a confirmation link and
+// returns ErrDeviceConfirmationRequired; trusted devices only need it for a
+// new device, not a new network.
+//
+// A user's first device is recorded as confirmed without an alert.
+func (s *DeviceService) CheckLogin(ctx context.Context, user *domain.User, device LoginDevice) error {
+	fingerprint := deviceFingerprint(device)
+	network := domain.DeviceNetwork(device.IPAddress)
+
+	known, err := s.devices.GetByFingerprint(ctx, user.ID, fingerprint)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+
+	var reason string
+	switch {
+	case known == nil:
+		existing, err := s.devices.ListForUser(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		known = domain.NewKnownDevice(user.ID, fingerprint, device.IPAddress, device.UserAgent)
+		if len(existing) == 0 {
+			known.Confirm("")
+			known.Seen(device.IPAddress, device.UserAgent)
+			return s.devices.Create(ctx, known)
+		}
+
+		if err := s.devices.Create(ctx, known); err != nil {
+			return err
+		}
+		reason = loginReasonNewDevice
+
+	case !known.IsConfirmed():
+		reason = loginReasonNewDevice
+
+	case network != "" && !known.HasNetwork(network):
+		reason = loginReasonNewNetwork
+
+	default:
+		known.Seen(device.IPAddress, device.UserAgent)
+		return s.devices.Update(ctx, known)
+	}
+
+	confirm := s.requireConfirmation.Load() && (reason == loginReasonNewDevice || !known.Trusted)
+
+	_ = s.publisher.Publish(ctx, domain.LoginNewDeviceEvent(known, device.IPAddress, reason, confirm))
+
+	if !confirm {
+		known.Confirm("")
+		known.Seen(device.IPAddress, device.UserAgent)
+		if err := s.devices.Update(ctx, known); err != nil {
+			return err
+		}
+		s.sendAlert(ctx, user, device, notify.TemplateSuspiciousLogin, nil)
+		return nil
+	}
+
+	payload := map[string]any{"network": network}
+	token, issued, err := s.tokens.Issue(ctx, domain.ActionConfirmLogin, known.ID, payload, s.confirmationTTL)
+	if err != nil {
+		return err
+	}
+
+	s.sendAlert(ctx, user, device, notify.TemplateLoginConfirmation, map[string]any{
+		"Token":     token,
+		"ExpiresAt": issued.ExpiresAt.Format(time.RFC1123),
+	})
+
+	return domain.ErrDeviceConfirmationRequired
+}
+
+// sendAlert emails the user about a login. Failures are ignored, like
+// event publishing: the login itself has been recorded.
+func (s *DeviceService) sendAlert(ctx context.Context, user *domain.User, device LoginDevice, template string, extra map[string]any) {
+	data := map[string]any{
+		"FullName":  user.FullName,
+		"IPAddress": device.IPAddress,
+		"UserAgent": device.UserAgent,
+		"Time":      domain.Now().Format(time.RFC1123),
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+
+	_ = s.notifier.Dispatch(ctx, notify.Notification{
+		Channel:  notify.ChannelEmail,
+		To:       user.Email,
+		Template: template,
+		Data:     data,
+		UserID:   user.ID,
+	})
+}
+
+// ConfirmLogin approves the login a confirmation token was issued for. The
+// user signs in again afterwards.
+func (s *DeviceService) ConfirmLogin(ctx context.Context, token string) (*domain.KnownDevice, error) {
+	var device *domain.KnownDevice
+	err := s.tokens.Use(ctx, domain.ActionConfirmLogin, token, func(ctx context.Context, t *domain.ActionToken) error {
+		var err error
+		device, err = s.devices.GetByID(ctx, t.SubjectID)
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrInvalidCredential // Forgotten since
+		}
+		if err != nil {
+			return err
+		}
+
+		network, _ := t.Payload["network"].(string)
+		device.Confirm(network)
+		return s.devices.Update(ctx, device)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return device, nil
+}
+
+// ListDevices returns the user's known devices, most recently seen first.
+func (s *DeviceService) ListDevices(ctx context.Context, userID uuid.UUID) ([]domain.KnownDevice, error) {
+	return s.devices.ListForUser(ctx, userID)
+}
+
+// SetTrusted marks one of the user's devices as trusted or not. Only
+// confirmed devices can be trusted; trusting an unconfirmed one returns
+// ErrConflict.
+func (s *DeviceService) SetTrusted(ctx context.Context, userID, deviceID uuid.UUID, trusted bool) (*domain.KnownDevice, error) {
+	device, err := s.userDevice(ctx, userID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if trusted && !device.IsConfirmed() {
+		return nil, domain.ErrConflict
+	}
+
+	if device.Trusted == trusted {
+		return device, nil
+	}
+
+	device.Trusted = trusted
+	if err := s.devices.Update(ctx, device); err != nil {
+		return nil, err
+	}
+
+	return device, nil
+}
+
+// ForgetDevice removes one of the user's devices, so the next login from it
+// is treated as coming from a new device.
+func (s *DeviceService) ForgetDevice(ctx context.Context, userID, deviceID uuid.UUID) error {
+	if _, err := s.userDevice(ctx, userID, deviceID); err != nil {
+		return err
+	}
+
+	return s.devices.Delete(ctx, deviceID)
+}
+
+// userDevice gets a device, hiding other users' devices as not found.
+func (s *DeviceService) userDevice(ctx context.Context, userID, deviceID uuid.UUID) (*domain.KnownDevice, error) {
+	device, err := s.devices.GetByID(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if device.UserID != userID {
+		return nil, domain.ErrNotFound
+	}
+
+	return device, nil
+}
+
+// deviceFingerprint identifies a device by its client-generated ID when it
+// sends one, and by its user agent otherwise.
+func deviceFingerprint(device LoginDevice) string {
+	if device.DeviceID != "" {
+		return auth.HashToken("id:" + device.DeviceID)
+	}
+	return auth.HashToken("ua:" + device.UserAgent)
+}
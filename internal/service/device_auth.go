@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/auth"
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// DeviceAuthService implements the OAuth 2.0 device authorization grant
+// (RFC 8628), which signs in devices without a browser, such as CLIs: the
+// device gets a device code and a user code, the user enters the user code
+// at the verification URL while signed in and approves it, and the device,
+// polling with its device code meanwhile, gets tokens for the user.
+type DeviceAuthService struct {
+	authorizations  storage.DeviceAuthorizationRepository
+	auth            *AuthService
+	verificationURL string
+	ttl             time.Duration
+	interval        time.Duration
+}
+
+func NewDeviceAuthService(
+	authorizations storage.DeviceAuthorizationRepository,
+	authService *AuthService,
+	verificationURL string,
+	ttl time.Duration,
+	interval time.Duration,
+) *DeviceAuthService {
+	return &DeviceAuthService{
+		authorizations:  authorizations,
+		auth:            authService,
+		verificationURL: verificationURL,
+		ttl:             ttl,
+		interval:        interval,
+	}
+}
+
+// DeviceCode is what a device is told to start the flow.
+type DeviceCode struct {
+	DeviceCode              string
+	UserCode                string // Formatted; see domain.FormatUserCode
+	VerificationURI         string
+	VerificationURIComplete string // With the user code filled in
+	ExpiresIn               time.Duration
+	Interval                time.Duration
+}
+
+// Start begins a device authorization for clientID, which is only shown
+// to the user, asking for scope.
+func (s *DeviceAuthService) Start(ctx context.Context, clientID, scope string) (*DeviceCode, error) {
+	scope, err := domain.NormalizeScope(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceCode, err := domain.GenerateTokenString()
+	if err != nil {
+		return nil, err
+	}
+
+	// User codes are short; retry the rare collision with a pending one
+	var authorization *domain.DeviceAuthorization
+	for attempt := 0; ; attempt++ {
+		authorization, err = domain.NewDeviceAuthorization(clientID, scope, auth.HashToken(deviceCode), s.ttl, s.interval)
+		if err != nil {
+			return nil, err
+		}
+		err = s.authorizations.Create(ctx, authorization)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, domain.ErrAlreadyExists) || attempt == 2 {
+			return nil, err
+		}
+	}
+
+	userCode := domain.FormatUserCode(authorization.UserCode)
+	complete := s.verificationURL + "?" + url.Values{"user_code": {userCode}}.Encode()
+	return &DeviceCode{
+		DeviceCode:              deviceCode,
+		UserCode:                userCode,
+		VerificationURI:         s.verificationURL,
+		VerificationURIComplete: complete,
+		ExpiresIn:               s.ttl,
+		Interval:                s.interval,
+	}, nil
+}
+
+// Pending returns the pending authorization with the user code, as typed,
+// for the user to check before deciding. Returns ErrNotFound if there's
+// none or it expired.
+func (s *DeviceAuthService) Pending(ctx context.Context, userCode string) (*domain.DeviceAuthorization, error) {
+	authorization, err := s.authorizations.GetByUserCode(ctx, domain.NormalizeUserCode(userCode))
+	if err != nil {
+		return nil, err
+	}
+	if authorization.Status != domain.DeviceAuthorizationPending || authorization.IsExpired() {
+		return nil, domain.ErrNotFound
+	}
+	return authorization, nil
+}
+
+// Decide approves or denies the pending authorization with the user code
+// as the user. Approving lets the device sign in as them.
+func (s *DeviceAuthService) Decide(ctx context.Context, userCode string, userID uuid.UUID, approve bool) (*domain.DeviceAuthorization, error) {
+	authorization, err := s.Pending(ctx, userCode)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorization.Decide(userID, approve); err != nil {
+		return nil, err
+	}
+	if err := s.authorizations.Update(ctx, authorization); err != nil {
+		return nil, err
+	}
+	return authorization, nil
+}
+
+// PollInput is a device's request for its tokens.
+type PollInput struct {
+	DeviceCode string
+	ClientID   string
+	IPAddress  string
+	UserAgent  string
+}
+
+// Poll returns the tokens of an approved device, once. Until then it
+// returns ErrAuthorizationPending, or ErrSlowDown when the device polls
+// sooner than its interval; then ErrAccessDenied if the user denied it or
+// ErrDeviceCodeExpired if nobody decided in time. Unknown device codes,
+// and codes presented by another client, return ErrInvalidCredential.
+func (s *DeviceAuthService) Poll(ctx context.Context, input PollInput) (*LoginResult, error) {
+	authorization, err := s.authorizations.GetByDeviceCodeHash(ctx, auth.HashToken(input.DeviceCode))
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, domain.ErrInvalidCredential
+	}
+	if err != nil {
+		return nil, err
+	}
+	if authorization.ClientID != input.ClientID {
+		return nil, domain.ErrInvalidCredential
+	}
+
+	if authorization.IsExpired() {
+		_ = s.authorizations.Delete(ctx, authorization.ID)
+		return nil, domain.ErrDeviceCodeExpired
+	}
+
+	switch authorization.Status {
+	case domain.DeviceAuthorizationDenied:
+		_ = s.authorizations.Delete(ctx, authorization.ID)
+		return nil, domain.ErrAccessDenied
+
+	case domain.DeviceAuthorizationApproved:
+		// Deleting it first means concurrent polls can't both get tokens
+		if err := s.authorizations.Delete(ctx, authorization.ID); err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return nil, domain.ErrInvalidCredential
+			}
+			return nil, err
+		}
+		return s.auth.IssueFor(ctx, *authorization.UserID, authorization.Scope, input.IPAddress, input.UserAgent)
+
+	default:
+		pollErr := authorization.Poll()
+		if err := s.authorizations.Update(ctx, authorization); err != nil {
+			return nil, err
+		}
+		if pollErr != nil {
+			return nil, pollErr
+		}
+		return nil, domain.ErrAuthorizationPending
+	}
+}
+
+// CleanupExpired removes expired authorizations.
+func (s *DeviceAuthService) CleanupExpired(ctx context.Context) (int64, error) {
+	return s.authorizations.DeleteExpired(ctx)
+}
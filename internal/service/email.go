@@ -0,0 +1,41 @@
+package service
+
+import (
+	"github.com/mvaleed/aegis/internal/disposable"
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// DisposableEmailPolicy is what registration does with a disposable email
+// address.
+type DisposableEmailPolicy string
+
+const (
+	DisposableEmailAllow DisposableEmailPolicy = "allow" // Don't check
+	DisposableEmailFlag  DisposableEmailPolicy = "flag"  // Accept, but report it to the risk engine
+	DisposableEmailBlock DisposableEmailPolicy = "block" // Reject
+)
+
+// EmailScreener applies the disposable email policy to addresses being
+// registered. It is shared by registration and availability checks so both
+// give the same answer.
+type EmailScreener struct {
+	domains *disposable.Domains
+	policy  DisposableEmailPolicy
+}
+
+func NewEmailScreener(domains *disposable.Domains, policy DisposableEmailPolicy) *EmailScreener {
+	return &EmailScreener{domains: domains, policy: policy}
+}
+
+// Screen reports whether email is disposable. Under the block policy a
+// disposable address also returns a validation error.
+func (e *EmailScreener) Screen(email string) (bool, *domain.ValidationError) {
+	if e.policy == DisposableEmailAllow || !e.domains.IsDisposable(email) {
+		return false, nil
+	}
+
+	if e.policy == DisposableEmailBlock {
+		return true, &domain.ValidationError{Field: "email", Message: "disposable email addresses are not allowed"}
+	}
+	return true, nil
+}
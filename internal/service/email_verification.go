@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/auth"
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/notify"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// EmailVerificationService lets users prove they own their email address by
+// following an emailed link, and move to a new one the same way.
+type EmailVerificationService struct {
+	users     storage.UserRepository
+	tokens    *ActionTokenService
+	publisher event.Publisher
+	notifier  notify.Dispatcher
+	screener  *EmailScreener
+	ttl       time.Duration
+}
+
+func NewEmailVerificationService(
+	users storage.UserRepository,
+	tokens *ActionTokenService,
+	publisher event.Publisher,
+	notifier notify.Dispatcher,
+	screener *EmailScreener,
+	ttl time.Duration,
+) *EmailVerificationService {
+	return &EmailVerificationService{
+		users:     users,
+		tokens:    tokens,
+		publisher: publisher,
+		notifier:  notifier,
+		screener:  screener,
+		ttl:       ttl,
+	}
+}
+
+// SendVerification emails the user a verification link, replacing any sent
+// before. Returns ErrConflict if the email is already verified.
+func (s *EmailVerificationService) SendVerification(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.EmailVerified {
+		return domain.ErrConflict
+	}
+
+	// The link only verifies the address it was sent to
+	payload := map[string]any{"email": user.Email}
+	token, issued, err := s.tokens.Issue(ctx, domain.ActionVerifyEmail, user.ID, payload, s.ttl)
+	if err != nil {
+		return err
+	}
+
+	_ = s.notifier.Dispatch(ctx, notify.Notification{
+		Channel:  notify.ChannelEmail,
+		To:       user.Email,
+		Template: notify.TemplateEmailVerification,
+		Data: map[string]any{
+			"FullName":  user.FullName,
+			"Token":     token,
+			"ExpiresAt": issued.ExpiresAt.Format(time.RFC1123),
+		},
+		UserID: user.ID,
+	})
+
+	return nil
+}
+
+// VerifyEmail marks the email address a verification token was sent to as
+// verified. Tokens sent to an address the user has since changed are
+// invalid.
+func (s *EmailVerificationService) VerifyEmail(ctx context.Context, token string) (*domain.User, error) {
+	var user *domain.User
+	err := s.tokens.Use(ctx, domain.ActionVerifyEmail, token, func(ctx context.Context, t *domain.ActionToken) error {
+		var err error
+		user, err = s.users.GetByID(ctx, t.SubjectID)
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrInvalidCredential
+		}
+		if err != nil {
+			return err
+		}
+
+		if email, _ := t.Payload["email"].(string); email != user.Email {
+			return domain.ErrInvalidCredential
+		}
+
+		user.VerifyEmail()
+		return s.users.Update(ctx, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.NewEvent(domain.EventUserEmailVerified, user.ID, nil))
+
+	return user, nil
+}
+
+// RequestEmailChange stages a move to newEmail for a user who has given
+// their password: it emails a confirmation link to the new address and a
+// notice to the current one, and marks the current address unverified
+// until the change is confirmed. Requesting again replaces the pending
+// change. The address must be free, and pass the disposable email policy
+// as at registration.
+func (s *EmailVerificationService) RequestEmailChange(ctx context.Context, userID uuid.UUID, password, newEmail string) error {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := auth.CheckPassword(password, user.PasswordHash); err != nil {
+		return domain.ErrInvalidCredential
+	}
+
+	newEmail = domain.NormalizeEmail(newEmail)
+	if verr := domain.ValidateEmail(newEmail); verr != nil {
+		return *verr
+	}
+	if newEmail == user.Email {
+		return domain.ValidationError{Field: "email", Message: "must differ from the current address"}
+	}
+	if _, verr := s.screener.Screen(newEmail); verr != nil {
+		return *verr
+	}
+	if _, err := s.users.GetByEmail(ctx, newEmail); err == nil {
+		return domain.ErrAlreadyExists
+	} else if !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+
+	// The link only applies the change from the address it was requested
+	// for, so it dies with any other change
+	payload := map[string]any{"email": newEmail, "from": user.Email}
+	token, issued, err := s.tokens.Issue(ctx, domain.ActionChangeEmail, user.ID, payload, s.ttl)
+	if err != nil {
+		return err
+	}
+
+	if user.EmailVerified {
+		user.EmailVerified = false
+		user.UpdatedAt = domain.Now()
+		if err := s.users.Update(ctx, user); err != nil {
+			return err
+		}
+	}
+
+	_ = s.notifier.Dispatch(ctx, notify.Notification{
+		Channel:  notify.ChannelEmail,
+		To:       newEmail,
+		Template: notify.TemplateEmailChange,
+		Data: map[string]any{
+			"FullName":  user.FullName,
+			"Email":     newEmail,
+			"Token":     token,
+			"ExpiresAt": issued.ExpiresAt.Format(time.RFC1123),
+		},
+		UserID: user.ID,
+	})
+	_ = s.notifier.Dispatch(ctx, notify.Notification{
+		Channel:  notify.ChannelEmail,
+		To:       user.Email,
+		Template: notify.TemplateEmailChangeNotice,
+		Data: map[string]any{
+			"FullName": user.FullName,
+			"NewEmail": newEmail,
+		},
+		UserID: user.ID,
+	})
+
+	_ = s.publisher.Publish(ctx, domain.UserEmailChangeRequestedEvent(user, newEmail))
+
+	return nil
+}
+
+// ConfirmEmailChange applies the email change a token was sent for, making
+// the new address the user's, verified. Tokens for a change requested from
+// an address the user has since left are invalid, and one for an address
+// taken meanwhile returns ErrAlreadyExists.
+func (s *EmailVerificationService) ConfirmEmailChange(ctx context.Context, token string) (*domain.User, error) {
+	var (
+		user     *domain.User
+		oldEmail string
+	)
+	err := s.tokens.Use(ctx, domain.ActionChangeEmail, token, func(ctx context.Context, t *domain.ActionToken) error {
+		var err error
+		user, err = s.users.GetByID(ctx, t.SubjectID)
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrInvalidCredential
+		}
+		if err != nil {
+			return err
+		}
+
+		if from, _ := t.Payload["from"].(string); from != user.Email {
+			return domain.ErrInvalidCredential
+		}
+
+		oldEmail = user.Email
+		email, _ := t.Payload["email"].(string)
+		if err := user.ChangeEmail(email); err != nil {
+			return err
+		}
+		return s.users.Update(ctx, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.UserEmailChangedEvent(user, oldEmail))
+
+	return user, nil
+}
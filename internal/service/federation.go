@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// federation keeps the accounts of users an external identity source,
+// such as a directory or an identity provider, vouches for: it creates
+// them on first sign-in and brings the roles the source manages in line
+// on each one.
+//
+// Sources sign in to the account their subject is linked to, never merely
+// to the one with the email they assert, or any source could take over any
+// account. New accounts are linked as they're provisioned. An existing
+// account is linked on sign-in only if its email is in one of the source's
+// domains and it holds no privileged role; otherwise an admin links it.
+type federation struct {
+	users      storage.UserRepository
+	roles      storage.RoleRepository
+	identities storage.FederatedIdentityRepository
+	rbac       *RBACService
+	suggester  *UsernameSuggester
+	publisher  event.Publisher
+	userType   domain.UserType // Of provisioned users
+}
+
+// identity is who a source vouches for.
+type identity struct {
+	provider string   // The FederatedIdentity provider
+	subject  string   // The entry's DN or the assertion's NameID
+	domains  []string // Emails the source may assert; any without
+	email    string
+	fullName string
+}
+
+// account returns the account id is linked to, updating its full name if
+// the source has another. Unlinked, it links the account with id's email
+// if it may, or provisions one. Returns ErrInvalidCredential if id may
+// sign in to neither.
+func (f *federation) account(ctx context.Context, id identity) (*domain.User, error) {
+	link, err := f.identities.GetBySubject(ctx, id.provider, id.subject)
+	switch {
+	case err == nil:
+		user, err := f.users.GetByID(ctx, link.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if id.fullName != "" && id.fullName != user.FullName {
+			user.FullName = id.fullName
+			if err := f.users.Update(ctx, user); err != nil {
+				return nil, err
+			}
+		}
+		return user, nil
+	case !errors.Is(err, domain.ErrNotFound):
+		return nil, err
+	}
+
+	if len(id.domains) > 0 && !domain.EmailInDomains(id.email, id.domains) {
+		return nil, fmt.Errorf("%w: %s is outside the provider's email domains", domain.ErrInvalidCredential, id.email)
+	}
+
+	user, err := f.users.GetByEmail(ctx, id.email)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return f.provision(ctx, id)
+	case err != nil:
+		return nil, err
+	}
+
+	if err := f.mayLink(ctx, user, id); err != nil {
+		return nil, err
+	}
+	if err := f.link(ctx, id, user.ID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// mayLink returns ErrInvalidCredential unless the existing account user
+// may be linked to id on sign-in.
+func (f *federation) mayLink(ctx context.Context, user *domain.User, id identity) error {
+	if len(id.domains) == 0 {
+		return fmt.Errorf("%w: %s has an account not linked to the provider", domain.ErrInvalidCredential, id.email)
+	}
+	if user.Type == domain.UserTypeAdmin {
+		return fmt.Errorf("%w: admin accounts are only linked by an admin", domain.ErrInvalidCredential)
+	}
+	roles, err := f.roles.GetEffectiveUserRoles(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	for i := range roles {
+		if roles[i].IsPrivileged() {
+			return fmt.Errorf("%w: accounts with privileged roles are only linked by an admin", domain.ErrInvalidCredential)
+		}
+	}
+	return nil
+}
+
+// link links id to the user.
+func (f *federation) link(ctx context.Context, id identity, userID uuid.UUID) error {
+	fi, err := domain.NewFederatedIdentity(id.provider, id.subject, userID)
+	if err != nil {
+		return err
+	}
+	return f.identities.Create(ctx, fi)
+}
+
+// linkAccount links a provider's subject to a user, for admins to link
+// existing accounts sign-in won't. Returns a ValidationError if the user
+// doesn't exist.
+func (f *federation) linkAccount(ctx context.Context, provider, subject string, userID uuid.UUID) (*domain.FederatedIdentity, error) {
+	fi, err := domain.NewFederatedIdentity(provider, subject, userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.users.GetByID(ctx, userID); errors.Is(err, domain.ErrNotFound) {
+		return nil, domain.ValidationError{Field: "user_id", Message: "user not found"}
+	} else if err != nil {
+		return nil, err
+	}
+	if err := f.identities.Create(ctx, fi); err != nil {
+		return nil, err
+	}
+	return fi, nil
+}
+
+// provision creates an active account linked to id. It has no password of
+// its own, and its email is verified since the source vouches for it.
+func (f *federation) provision(ctx context.Context, id identity) (*domain.User, error) {
+	fullName := id.fullName
+	if fullName == "" {
+		fullName, _, _ = strings.Cut(id.email, "@")
+	}
+
+	username, err := f.suggester.FromEmail(ctx, id.email, fullName)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := domain.NewUser(id.email, username, fullName, f.userType)
+	if err != nil {
+		return nil, err
+	}
+	if err := user.Activate(); err != nil {
+		return nil, err
+	}
+	user.VerifyEmail()
+
+	if err := f.users.Create(ctx, user); err != nil {
+		// Signed in twice at once; the other sign-in created and linked it
+		if errors.Is(err, domain.ErrAlreadyExists) {
+			if link, err := f.identities.GetBySubject(ctx, id.provider, id.subject); err == nil {
+				return f.users.GetByID(ctx, link.UserID)
+			}
+		}
+		return nil, err
+	}
+	if err := f.link(ctx, id, user.ID); err != nil {
+		return nil, err
+	}
+
+	if role, err := f.roles.GetByName(ctx, "user"); err == nil && f.roles.AssignRole(ctx, user.ID, role.ID, nil) == nil {
+		user.Version++ // Assigning bumped it
+	}
+
+	created := domain.UserCreatedEvent(user)
+	created.Data["provisioned"] = true
+	_ = f.publisher.Publish(ctx, created)
+
+	return user, nil
+}
+
+// syncRoles assigns the user the wanted roles and removes the managed ones
+// they no longer want. Roles the source doesn't manage are left alone, as
+// are organization roles the user can't hold because they aren't a member.
+func (f *federation) syncRoles(ctx context.Context, userID uuid.UUID, managed, wanted map[uuid.UUID]bool) error {
+	current, err := f.roles.GetUserRoles(ctx, userID)
+	if err != nil {
+		return err
+	}
+	held := make(map[uuid.UUID]bool, len(current))
+	for _, role := range current {
+		held[role.ID] = true
+		if managed[role.ID] && !wanted[role.ID] {
+			if err := f.rbac.RemoveRole(ctx, userID, role.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	for roleID := range wanted {
+		if held[roleID] {
+			continue
+		}
+		var invalid domain.ValidationError
+		if err := f.rbac.AssignRole(ctx, userID, roleID, nil); err != nil && !errors.As(err, &invalid) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Field is an optional change to one field of an update: the zero Field
+// leaves the value alone, Set replaces it and Clear removes it. It keeps
+// "not sent" apart from "cleared", which a pointer can't when the cleared
+// value is itself empty.
+type Field[T any] struct {
+	value T
+	op    fieldOp
+}
+
+type fieldOp uint8
+
+const (
+	fieldIgnore fieldOp = iota
+	fieldSet
+	fieldClear
+)
+
+// Set returns a Field replacing the value with v.
+func Set[T any](v T) Field[T] {
+	return Field[T]{value: v, op: fieldSet}
+}
+
+// Clear returns a Field removing the value.
+func Clear[T any]() Field[T] {
+	return Field[T]{op: fieldClear}
+}
+
+// IsSet reports whether the field replaces the value.
+func (f Field[T]) IsSet() bool { return f.op == fieldSet }
+
+// IsClear reports whether the field removes the value.
+func (f Field[T]) IsClear() bool { return f.op == fieldClear }
+
+// IsIgnored reports whether the field leaves the value alone.
+func (f Field[T]) IsIgnored() bool { return f.op == fieldIgnore }
+
+// Value returns the new value, or the zero value unless IsSet.
+func (f Field[T]) Value() T { return f.value }
+
+// OptionalValue returns the new value and whether IsSet, for
+// validate.Optional.
+func (f Field[T]) OptionalValue() (any, bool) { return f.value, f.op == fieldSet }
+
+// UnmarshalJSON decodes a member of a JSON merge patch (RFC 7396): null
+// clears the value and anything else sets it. A member missing from the
+// patch is never decoded and so stays ignored.
+func (f *Field[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*f = Clear[T]()
+		return nil
+	}
+
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*f = Set(v)
+	return nil
+}
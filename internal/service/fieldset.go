@@ -0,0 +1,11 @@
+package service
+
+// FieldSet is a sparse fieldset: the fields of a resource a caller wants,
+// by name. Services skip loading what no wanted field needs. A nil FieldSet
+// wants every field.
+type FieldSet map[string]bool
+
+// Has reports whether field is wanted.
+func (f FieldSet) Has(field string) bool {
+	return f == nil || f[field]
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// GroupService manages groups, their members and the roles they grant.
+type GroupService struct {
+	groups    storage.GroupRepository
+	users     storage.UserRepository
+	roles     storage.RoleRepository
+	publisher event.Publisher
+}
+
+func NewGroupService(
+	groups storage.GroupRepository,
+	users storage.UserRepository,
+	roles storage.RoleRepository,
+	publisher event.Publisher,
+) *GroupService {
+	return &GroupService{
+		groups:    groups,
+		users:     users,
+		roles:     roles,
+		publisher: publisher,
+	}
+}
+
+func (s *GroupService) CreateGroup(ctx context.Context, name, description string) (*domain.Group, error) {
+	group, err := domain.NewGroup(name, description)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.groups.Create(ctx, group); err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+func (s *GroupService) GetGroup(ctx context.Context, id uuid.UUID) (*domain.Group, error) {
+	return s.groups.GetByID(ctx, id)
+}
+
+func (s *GroupService) ListGroups(ctx context.Context, offset, limit int) ([]domain.Group, int64, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.groups.List(ctx, offset, limit)
+}
+
+type UpdateGroupInput struct {
+	Name        *string
+	Description *string
+}
+
+func (s *GroupService) UpdateGroup(ctx context.Context, id uuid.UUID, input UpdateGroupInput) (*domain.Group, error) {
+	group, err := s.groups.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Name != nil {
+		group.Name = *input.Name
+	}
+
+	if input.Description != nil {
+		group.Description = *input.Description
+	}
+
+	if err := group.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.groups.Update(ctx, group); err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+func (s *GroupService) DeleteGroup(ctx context.Context, id uuid.UUID) error {
+	return s.groups.Delete(ctx, id)
+}
+
+func (s *GroupService) AddMember(ctx context.Context, groupID, userID uuid.UUID) error {
+	group, err := s.groups.GetByID(ctx, groupID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.users.GetByID(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.groups.AddMember(ctx, groupID, userID); err != nil {
+		return err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.GroupMemberAddedEvent(userID, group))
+
+	return nil
+}
+
+func (s *GroupService) RemoveMember(ctx context.Context, groupID, userID uuid.UUID) error {
+	group, err := s.groups.GetByID(ctx, groupID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.groups.RemoveMember(ctx, groupID, userID); err != nil {
+		return err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.GroupMemberRemovedEvent(userID, group))
+
+	return nil
+}
+
+func (s *GroupService) ListMembers(ctx context.Context, groupID uuid.UUID, offset, limit int) ([]domain.GroupMember, int64, error) {
+	if _, err := s.groups.GetByID(ctx, groupID); err != nil {
+		return nil, 0, err
+	}
+
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.groups.ListMembers(ctx, groupID, offset, limit)
+}
+
+func (s *GroupService) ListUserGroups(ctx context.Context, userID uuid.UUID) ([]domain.Group, error) {
+	return s.groups.ListForUser(ctx, userID)
+}
+
+// AssignRole grants a role to every member of the group. Organization-scoped
+// roles only take effect for members who also belong to that organization.
+func (s *GroupService) AssignRole(ctx context.Context, groupID, roleID uuid.UUID) error {
+	if _, err := s.groups.GetByID(ctx, groupID); err != nil {
+		return err
+	}
+
+	if _, err := s.roles.GetByID(ctx, roleID); err != nil {
+		return err
+	}
+
+	return s.groups.AssignRole(ctx, groupID, roleID)
+}
+
+func (s *GroupService) RemoveRole(ctx context.Context, groupID, roleID uuid.UUID) error {
+	if _, err := s.groups.GetByID(ctx, groupID); err != nil {
+		return err
+	}
+
+	return s.groups.RemoveRole(ctx, groupID, roleID)
+}
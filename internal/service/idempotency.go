@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// MaxIdempotencyKeyLength is the longest idempotency key accepted.
+const MaxIdempotencyKeyLength = 255
+
+// AnonymousIdempotencyScope scopes keys sent to public endpoints.
+const AnonymousIdempotencyScope = "anonymous"
+
+// IdempotencyService makes mutations safe to retry. Both transports use it:
+// HTTP with the Idempotency-Key header and gRPC with idempotency-key metadata.
+type IdempotencyService struct {
+	keys storage.IdempotencyRepository
+	ttl  time.Duration
+}
+
+func NewIdempotencyService(keys storage.IdempotencyRepository, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{
+		keys: keys,
+		ttl:  ttl,
+	}
+}
+
+// IdempotentResponse is the stored outcome of a request.
+type IdempotentResponse struct {
+	StatusCode int
+	Body       []byte
+	Replayed   bool // True if the response was stored by an earlier request
+}
+
+// Do runs fn at most once per scope and key while the key is live. A retry
+// with the same key and request gets the stored response back; a key reused
+// for a different request fails with ErrIdempotencyKeyReused, and a retry
+// that arrives while the first request is still running fails with
+// ErrRequestInProgress. If fn fails nothing is stored, so the request can be
+// retried with the same key.
+func (s *IdempotencyService) Do(
+	ctx context.Context,
+	scope, key, operation string,
+	request []byte,
+	fn func(ctx context.Context) (*IdempotentResponse, error),
+) (*IdempotentResponse, error) {
+	if err := validateIdempotencyKey(key); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(request)
+	rec := domain.NewIdempotencyRecord(scope, key, operation, hex.EncodeToString(sum[:]), s.ttl)
+
+	existing, err := s.keys.Reserve(ctx, rec)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		switch {
+		case !existing.Matches(rec.Operation, rec.RequestHash):
+			return nil, domain.ErrIdempotencyKeyReused
+		case !existing.IsCompleted():
+			return nil, domain.ErrRequestInProgress
+		}
+		return &IdempotentResponse{
+			StatusCode: existing.StatusCode,
+			Body:       existing.Response,
+			Replayed:   true,
+		}, nil
+	}
+
+	// The outcome must be recorded even if the caller goes away
+	storeCtx := context.WithoutCancel(ctx)
+
+	resp, err := fn(ctx)
+	if err != nil {
+		_ = s.keys.Release(storeCtx, scope, key)
+		return nil, err
+	}
+
+	rec.Complete(resp.StatusCode, resp.Body)
+	if err := s.keys.Complete(storeCtx, rec); err != nil {
+		// The mutation went through; only replays are affected
+		_ = s.keys.Release(storeCtx, scope, key)
+	}
+
+	return resp, nil
+}
+
+// CleanupExpired removes expired idempotency keys.
+func (s *IdempotencyService) CleanupExpired(ctx context.Context) (int64, error) {
+	return s.keys.DeleteExpired(ctx)
+}
+
+func validateIdempotencyKey(key string) error {
+	if key == "" || len(key) > MaxIdempotencyKeyLength {
+		return domain.ValidationError{Field: "idempotency_key", Message: "must be 1-255 characters"}
+	}
+	for _, c := range key {
+		if c < 0x21 || c > 0x7e {
+			return domain.ValidationError{Field: "idempotency_key", Message: "must be printable ASCII without spaces"}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/auth"
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// StartImpersonationInput identifies who impersonates whom, and why.
+type StartImpersonationInput struct {
+	ActorID   uuid.UUID
+	TargetID  uuid.UUID
+	Reason    string
+	IPAddress string
+	UserAgent string
+}
+
+// ImpersonationResult carries the impersonation access token. No refresh
+// token is issued; a new session must be started once it expires.
+type ImpersonationResult struct {
+	AccessToken      string
+	ExpiresInSeconds int64
+	Session          *domain.ImpersonationSession
+	User             *domain.User
+}
+
+// StartImpersonation issues a short-lived access token for the target user
+// with an act claim naming the actor. The actor must already hold every
+// permission the target has, so impersonation can't be used to escalate.
+func (s *AuthService) StartImpersonation(ctx context.Context, input StartImpersonationInput) (*ImpersonationResult, error) {
+	session, err := domain.NewImpersonationSession(
+		input.ActorID, input.TargetID, input.Reason, input.IPAddress, input.UserAgent, s.impersonationTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	actor, err := s.users.GetByID(ctx, input.ActorID)
+	if err != nil {
+		return nil, err
+	}
+	actor.Roles, err = s.roles.GetEffectiveUserRoles(ctx, actor.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.users.GetByID(ctx, input.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	if !target.IsActive() {
+		return nil, domain.ValidationError{Field: "id", Message: "user is not active"}
+	}
+	target.Roles, err = s.roles.GetEffectiveUserRoles(ctx, target.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, role := range target.Roles {
+		for _, p := range role.Permissions {
+			if !p.Deny && !actor.HasPermission(p.Resource, p.Action) {
+				return nil, domain.ErrForbidden
+			}
+		}
+	}
+
+	payload, err := s.tokenPayload(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	payload.Actor = &auth.ActorClaim{
+		UserID:    actor.ID,
+		Username:  actor.Username,
+		SessionID: session.ID,
+	}
+	payload.TTL = grantTTL(target.Roles, s.impersonationTTL)
+	payload.Environment = tokenEnvironment(ctx)
+	if payload.Extra, err = s.customClaims(ctx, target); err != nil {
+		return nil, err
+	}
+
+	accessToken, _, err := s.jwt.GenerateAccessToken(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.impersonations.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.ImpersonationStartedEvent(session))
+
+	return &ImpersonationResult{
+		AccessToken:      accessToken,
+		ExpiresInSeconds: int64(payload.TTL.Seconds()),
+		Session:          session,
+		User:             target,
+	}, nil
+}
+
+// EndImpersonation ends a session, invalidating its token. Only the actor
+// can end their session. Ending an ended session is a no-op.
+func (s *AuthService) EndImpersonation(ctx context.Context, sessionID, endedBy uuid.UUID) (*domain.ImpersonationSession, error) {
+	session, err := s.impersonations.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.ActorID != endedBy {
+		return nil, domain.ErrNotFound
+	}
+
+	if session.EndedAt != nil {
+		return session, nil
+	}
+
+	session.End()
+	if err := s.impersonations.End(ctx, session); err != nil {
+		return nil, err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.ImpersonationEndedEvent(session, endedBy))
+
+	return session, nil
+}
+
+// ListImpersonations returns the sessions where the user was the actor or
+// the target.
+func (s *AuthService) ListImpersonations(ctx context.Context, userID uuid.UUID, offset, limit int) ([]domain.ImpersonationSession, int64, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.impersonations.ListForUser(ctx, userID, offset, limit)
+}
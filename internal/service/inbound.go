@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+)
+
+// InboundHandlers react to events published by other services.
+type InboundHandlers struct {
+	users *UserService
+}
+
+// NewInboundHandlers creates the handlers.
+func NewInboundHandlers(users *UserService) *InboundHandlers {
+	return &InboundHandlers{users: users}
+}
+
+// Register registers every handler with c.
+func (h *InboundHandlers) Register(c *event.Consumer) {
+	c.Handle(domain.EventBillingSubscriptionCancelled, h.subscriptionCancelled)
+}
+
+// subscriptionCancelled suspends the user whose subscription billing
+// cancelled. Users that no longer exist are skipped.
+func (h *InboundHandlers) subscriptionCancelled(ctx context.Context, env event.Envelope) error {
+	if env.UserID == uuid.Nil {
+		return event.Permanent(errors.New("event has no user_id"))
+	}
+
+	reason := "subscription cancelled"
+	if r, ok := env.Data["reason"].(string); ok && r != "" {
+		reason = r
+	}
+
+	err := h.users.SuspendUser(ctx, env.UserID, "billing: "+reason)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return nil
+	case errors.Is(err, domain.ErrInvalidStatus):
+		return event.Permanent(err)
+	}
+	return err
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/auth"
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/notify"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// MaxInvitationTTL caps how long an invitation token stays valid.
+const MaxInvitationTTL = 30 * 24 * time.Hour
+
+// InvitationService handles invitation-based onboarding.
+//
+// Inviting an email creates the account up front in UserStatusPending, with
+// no password, so it shows up in the directory and cannot log in. Accepting
+// the invitation sets the password, verifies the email and activates it.
+type InvitationService struct {
+	invitations storage.InvitationRepository
+	tokens      *ActionTokenService
+	users       storage.UserRepository
+	roles       storage.RoleRepository
+	publisher   event.Publisher
+	notifier    notify.Dispatcher
+	suggester   *UsernameSuggester
+	passwords   *PasswordHistory
+	defaultTTL  time.Duration
+}
+
+func NewInvitationService(
+	invitations storage.InvitationRepository,
+	tokens *ActionTokenService,
+	users storage.UserRepository,
+	roles storage.RoleRepository,
+	publisher event.Publisher,
+	notifier notify.Dispatcher,
+	suggester *UsernameSuggester,
+	passwords *PasswordHistory,
+	defaultTTL time.Duration,
+) *InvitationService {
+	return &InvitationService{
+		invitations: invitations,
+		tokens:      tokens,
+		users:       users,
+		roles:       roles,
+		publisher:   publisher,
+		notifier:    notifier,
+		suggester:   suggester,
+		passwords:   passwords,
+		defaultTTL:  defaultTTL,
+	}
+}
+
+type CreateInvitationInput struct {
+	Email     string
+	FullName  string // Optional; the invitee can set it on acceptance
+	Type      domain.UserType
+	RoleID    *uuid.UUID
+	InvitedBy uuid.UUID
+	ExpiresIn time.Duration // Zero uses the default
+}
+
+// CreateInvitation invites an email address and returns the invitation with
+// the raw token, which is only available here and from ResendInvitation.
+// Re-inviting an email whose account is still pending supersedes the
+// outstanding invitation.
+func (s *InvitationService) CreateInvitation(ctx context.Context, input CreateInvitationInput) (*domain.Invitation, string, error) {
+	ttl, err := s.ttl(input.ExpiresIn)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if input.Type == "" {
+		input.Type = domain.UserTypeCustomer
+	}
+
+	if input.RoleID != nil {
+		role, err := s.roles.GetByID(ctx, *input.RoleID)
+		if err != nil {
+			return nil, "", err
+		}
+		if !role.IsGlobal() {
+			return nil, "", domain.ValidationError{Field: "role_id", Message: "organization roles cannot be granted by invitation"}
+		}
+	}
+
+	user, err := s.pendingUser(ctx, input)
+	if err != nil {
+		return nil, "", err
+	}
+
+	inv := domain.NewInvitation(user.Email, user.ID, input.RoleID, input.InvitedBy, ttl)
+
+	if err := s.invitations.Create(ctx, inv); err != nil {
+		return nil, "", err
+	}
+
+	token, _, err := s.tokens.Issue(ctx, domain.ActionAcceptInvitation, inv.ID, nil, ttl)
+	if err != nil {
+		return nil, "", err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.UserInvitedEvent(inv))
+	s.sendInvitation(ctx, inv, user.FullName, token)
+
+	return inv, token, nil
+}
+
+// pendingUser returns the pending account for the invitee, creating it if
+// the email is not registered yet.
+func (s *InvitationService) pendingUser(ctx context.Context, input CreateInvitationInput) (*domain.User, error) {
+	existing, err := s.users.GetByEmail(ctx, input.Email)
+	if err == nil {
+		if existing.Status != domain.UserStatusPending || existing.IsDeleted() {
+			return nil, domain.ValidationError{Field: "email", Message: "already registered"}
+		}
+
+		// Supersede the outstanding invitation, if any
+		prev, err := s.invitations.GetPendingForUser(ctx, existing.ID)
+		if err == nil {
+			if err := prev.Revoke(); err != nil {
+				return nil, err
+			}
+			if err := s.invitations.Update(ctx, prev); err != nil {
+				return nil, err
+			}
+			if err := s.tokens.Revoke(ctx, domain.ActionAcceptInvitation, prev.ID); err != nil {
+				return nil, err
+			}
+		} else if !errors.Is(err, domain.ErrNotFound) {
+			return nil, err
+		}
+
+		return existing, nil
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+
+	fullName := strings.TrimSpace(input.FullName)
+	if fullName == "" {
+		fullName, _, _ = strings.Cut(strings.TrimSpace(input.Email), "@")
+	}
+
+	// The invitee picks their username on acceptance
+	placeholder := "invitee-" + strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+
+	user, err := domain.NewUser(input.Email, placeholder, fullName, input.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.users.Create(ctx, user); err != nil {
+		if errors.Is(err, domain.ErrAlreadyExists) {
+			return nil, domain.ValidationError{Field: "email", Message: "already registered"}
+		}
+		return nil, err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.UserCreatedEvent(user))
+
+	return user, nil
+}
+
+func (s *InvitationService) GetInvitation(ctx context.Context, id uuid.UUID) (*domain.Invitation, error) {
+	return s.invitations.GetByID(ctx, id)
+}
+
+func (s *InvitationService) ListInvitations(ctx context.Context, filter storage.InvitationFilter) ([]domain.Invitation, int64, error) {
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	return s.invitations.List(ctx, filter)
+}
+
+// ResendInvitation issues a fresh token with a new expiry. Tokens sent
+// before stop working.
+func (s *InvitationService) ResendInvitation(ctx context.Context, id uuid.UUID, expiresIn time.Duration) (*domain.Invitation, string, error) {
+	ttl, err := s.ttl(expiresIn)
+	if err != nil {
+		return nil, "", err
+	}
+
+	inv, err := s.invitations.GetByID(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := inv.Reissue(ttl); err != nil {
+		return nil, "", err
+	}
+
+	if err := s.invitations.Update(ctx, inv); err != nil {
+		return nil, "", err
+	}
+
+	token, _, err := s.tokens.Issue(ctx, domain.ActionAcceptInvitation, inv.ID, nil, ttl)
+	if err != nil {
+		return nil, "", err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.UserInvitedEvent(inv))
+
+	fullName := inv.Email
+	if user, err := s.users.GetByID(ctx, inv.UserID); err == nil {
+		fullName = user.FullName
+	}
+	s.sendInvitation(ctx, inv, fullName, token)
+
+	return inv, token, nil
+}
+
+// sendInvitation emails the invitation link. Delivery failures don't fail
+// the request; the token is also returned to the inviter.
+func (s *InvitationService) sendInvitation(ctx context.Context, inv *domain.Invitation, fullName, token string) {
+	_ = s.notifier.Dispatch(ctx, notify.Notification{
+		Channel:  notify.ChannelEmail,
+		To:       inv.Email,
+		Template: notify.TemplateInvitation,
+		Data: map[string]any{
+			"FullName":  fullName,
+			"Token":     token,
+			"ExpiresAt": inv.ExpiresAt.Format(time.RFC1123),
+		},
+	})
+}
+
+// RevokeInvitation withdraws an invitation. The pending account is kept so
+// the email can be invited again.
+func (s *InvitationService) RevokeInvitation(ctx context.Context, id uuid.UUID) (*domain.Invitation, error) {
+	inv, err := s.invitations.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if inv.Status == domain.InvitationStatusRevoked {
+		return inv, nil
+	}
+
+	if err := inv.Revoke(); err != nil {
+		return nil, err
+	}
+
+	if err := s.invitations.Update(ctx, inv); err != nil {
+		return nil, err
+	}
+
+	if err := s.tokens.Revoke(ctx, domain.ActionAcceptInvitation, inv.ID); err != nil {
+		return nil, err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.InvitationRevokedEvent(inv))
+
+	return inv, nil
+}
+
+type AcceptInvitationInput struct {
+	Token    string
+	Username string
+	FullName string // Optional; keeps the name given by the inviter
+	Password string
+}
+
+// AcceptInvitation completes onboarding: it sets the invitee's username and
+// password, marks the email verified, activates the account and grants the
+// invited role. The token is only used up if all of that succeeds, so the
+// invitee can retry with, say, another username.
+func (s *InvitationService) AcceptInvitation(ctx context.Context, input AcceptInvitationInput) (*domain.User, error) {
+	var user *domain.User
+	err := s.tokens.Use(ctx, domain.ActionAcceptInvitation, input.Token, func(ctx context.Context, token *domain.ActionToken) error {
+		var err error
+		user, err = s.accept(ctx, token.SubjectID, input)
+		return err
+	})
+	var taken domain.UsernameTakenError
+	if errors.As(err, &taken) {
+		// Suggested outside the transaction, which the failed update aborted
+		taken.Suggestions, _ = s.suggester.Suggest(ctx, taken.Username, user.FullName)
+		return nil, taken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.UserActivatedEvent(user))
+
+	return user, nil
+}
+
+func (s *InvitationService) accept(ctx context.Context, invitationID uuid.UUID, input AcceptInvitationInput) (*domain.User, error) {
+	inv, err := s.invitations.GetByID(ctx, invitationID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, domain.ErrInvalidCredential
+		}
+		return nil, err
+	}
+
+	if err := inv.Accept(); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(input.Username) == "" {
+		return nil, domain.ValidationError{Field: "username", Message: "required"}
+	}
+
+	if err := auth.ValidatePasswordStrength(input.Password); err != nil {
+		return nil, domain.ValidationError{Field: "password", Message: err.Error()}
+	}
+
+	user, err := s.users.GetByID(ctx, inv.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordHash, err := auth.HashPassword(input.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Username = domain.NormalizeUsername(input.Username)
+	if name := strings.TrimSpace(input.FullName); name != "" {
+		user.FullName = name
+	}
+	user.PasswordHash = passwordHash
+	user.EmailVerified = true
+
+	if err := user.Activate(); err != nil {
+		return nil, err
+	}
+
+	if err := user.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.users.Update(ctx, user); err != nil {
+		if errors.Is(err, domain.ErrAlreadyExists) {
+			// The user comes back too, for its name to base suggestions on
+			return user, domain.UsernameTakenError{Username: user.Username}
+		}
+		return nil, err
+	}
+
+	if err := s.invitations.Update(ctx, inv); err != nil {
+		return nil, err
+	}
+
+	_ = s.passwords.Record(ctx, user.ID, passwordHash)
+
+	// Assigning roles bumps the user's version
+	if defaultRole, err := s.roles.GetByName(ctx, "user"); err == nil && s.roles.AssignRole(ctx, user.ID, defaultRole.ID, nil) == nil {
+		user.Version++
+	}
+
+	if inv.RoleID != nil {
+		if err := s.roles.AssignRole(ctx, user.ID, *inv.RoleID, nil); err != nil {
+			return nil, err
+		}
+		user.Version++
+	}
+
+	return user, nil
+}
+
+func (s *InvitationService) ttl(expiresIn time.Duration) (time.Duration, error) {
+	if expiresIn == 0 {
+		return s.defaultTTL, nil
+	}
+	if expiresIn < 0 || expiresIn > MaxInvitationTTL {
+		return 0, domain.ValidationError{Field: "expires_in_hours", Message: "must be between 1 and 720"}
+	}
+	return expiresIn, nil
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/ldap"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// LDAPService signs users in with their directory password. Their account
+// is created on their first sign-in, and the roles their LDAP groups map
+// to are brought in line with the directory on each one.
+type LDAPService struct {
+	directory *ldap.Directory
+	mappings  storage.LDAPMappingRepository
+	roles     storage.RoleRepository
+	accounts  *federation
+	domains   []string // Of entries whose existing accounts are linked on sign-in
+}
+
+func NewLDAPService(
+	directory *ldap.Directory,
+	mappings storage.LDAPMappingRepository,
+	users storage.UserRepository,
+	roles storage.RoleRepository,
+	identities storage.FederatedIdentityRepository,
+	rbac *RBACService,
+	suggester *UsernameSuggester,
+	publisher event.Publisher,
+	userType domain.UserType,
+	emailDomains []string,
+) *LDAPService {
+	return &LDAPService{
+		directory: directory,
+		mappings:  mappings,
+		roles:     roles,
+		accounts: &federation{
+			users:      users,
+			roles:      roles,
+			identities: identities,
+			rbac:       rbac,
+			suggester:  suggester,
+			publisher:  publisher,
+			userType:   userType,
+		},
+		domains: emailDomains,
+	}
+}
+
+// Authenticate checks the password against the directory and returns the
+// account linked to the entry login matches, linking or provisioning one
+// as federation does if there's none. Returns ErrNotFound if no entry
+// matches and ErrInvalidCredential if the password is wrong or the entry
+// may not sign in to the account with its email.
+func (s *LDAPService) Authenticate(ctx context.Context, login, password string) (*domain.User, error) {
+	entry, err := s.directory.Authenticate(ctx, login, password)
+	switch {
+	case errors.Is(err, ldap.ErrNoEntry):
+		return nil, domain.ErrNotFound
+	case errors.Is(err, ldap.ErrInvalidCredentials):
+		return nil, domain.ErrInvalidCredential
+	case err != nil:
+		return nil, err
+	}
+
+	user, err := s.accounts.account(ctx, identity{
+		provider: domain.LDAPIdentityProvider,
+		subject:  domain.NormalizeDN(entry.DN),
+		domains:  s.domains,
+		email:    entry.Email,
+		fullName: entry.FullName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.syncRoles(ctx, user.ID, entry.Groups); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// syncRoles assigns the user the roles their groups map to and removes the
+// mapped roles they no longer do.
+func (s *LDAPService) syncRoles(ctx context.Context, userID uuid.UUID, groups []string) error {
+	mappings, err := s.mappings.List(ctx)
+	if err != nil || len(mappings) == 0 {
+		return err
+	}
+
+	member := make(map[string]bool, len(groups))
+	for _, dn := range groups {
+		member[domain.NormalizeDN(dn)] = true
+	}
+
+	managed := make(map[uuid.UUID]bool)
+	wanted := make(map[uuid.UUID]bool)
+	for _, m := range mappings {
+		managed[m.RoleID] = true
+		if member[m.GroupDN] {
+			wanted[m.RoleID] = true
+		}
+	}
+
+	return s.accounts.syncRoles(ctx, userID, managed, wanted)
+}
+
+// ListMappings returns every group mapping, ordered by group.
+func (s *LDAPService) ListMappings(ctx context.Context) ([]domain.LDAPGroupMapping, error) {
+	return s.mappings.List(ctx)
+}
+
+// CreateMapping gives the members of the group with the DN groupDN the
+// role, from their next sign-in.
+func (s *LDAPService) CreateMapping(ctx context.Context, groupDN string, roleID uuid.UUID) (*domain.LDAPGroupMapping, error) {
+	mapping, err := domain.NewLDAPGroupMapping(groupDN, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := s.roles.GetByID(ctx, roleID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, domain.ValidationError{Field: "role_id", Message: "role not found"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	mapping.RoleName = role.Name
+
+	if err := s.mappings.Create(ctx, mapping); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+// DeleteMapping removes a group mapping. Members keep the role, which is
+// no longer the directory's to remove, unless another mapping names it.
+func (s *LDAPService) DeleteMapping(ctx context.Context, id uuid.UUID) error {
+	return s.mappings.Delete(ctx, id)
+}
+
+// ListIdentities returns the accounts entries are linked to, ordered by DN.
+func (s *LDAPService) ListIdentities(ctx context.Context) ([]domain.FederatedIdentity, error) {
+	return s.accounts.identities.List(ctx, domain.LDAPIdentityProvider)
+}
+
+// LinkIdentity has the entry with the DN sign in to the user's account,
+// for accounts sign-in won't link itself. Returns ErrAlreadyExists if the
+// entry is linked already.
+func (s *LDAPService) LinkIdentity(ctx context.Context, dn string, userID uuid.UUID) (*domain.FederatedIdentity, error) {
+	return s.accounts.linkAccount(ctx, domain.LDAPIdentityProvider, dn, userID)
+}
+
+// UnlinkIdentity removes a link. The entry's next sign-in is treated as a
+// first one.
+func (s *LDAPService) UnlinkIdentity(ctx context.Context, id uuid.UUID) error {
+	return s.accounts.identities.Delete(ctx, domain.LDAPIdentityProvider, id)
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// ListLoginHistory returns a user's login attempts matching filter, newest
+// first, with the total number of matches.
+func (s *AuthService) ListLoginHistory(ctx context.Context, filter storage.LoginAttemptFilter) ([]domain.LoginAttempt, int64, error) {
+	if filter.Since != nil && filter.Until != nil && !filter.Until.After(*filter.Since) {
+		return nil, 0, domain.ValidationError{Field: "until", Message: "must be after since"}
+	}
+
+	return s.logins.List(ctx, filter)
+}
+
+// ActivityCounters counts active sessions and the logins of the last
+// minute. It reads shared tables, so every replica sees the same numbers.
+func (s *AuthService) ActivityCounters(ctx context.Context) (domain.ActivityCounters, error) {
+	counters := domain.ActivityCounters{At: domain.Now()}
+
+	var err error
+	counters.ActiveSessions, counters.ActiveUsers, err = s.tokens.CountActive(ctx, counters.At)
+	if err != nil {
+		return counters, err
+	}
+
+	counters.LoginsPerMinute, counters.FailuresPerMinute, err = s.logins.CountSince(ctx, counters.At.Add(-time.Minute))
+	if err != nil {
+		return counters, err
+	}
+
+	return counters, nil
+}
+
+// CleanupLoginHistory removes login attempts older than retention.
+func (s *AuthService) CleanupLoginHistory(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.logins.DeleteOlderThan(ctx, domain.Now().Add(-retention))
+}
+
+// loginFailureReason classifies the error a login failed with for the
+// login history. It returns "" for nil.
+func loginFailureReason(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, domain.ErrInvalidCredential):
+		return domain.LoginFailureInvalidCredentials
+	case errors.Is(err, domain.ErrUnauthorized):
+		return domain.LoginFailureInactive
+	case errors.Is(err, domain.ErrForbidden):
+		return domain.LoginFailureBlocked
+	case errors.Is(err, domain.ErrChallengeRequired):
+		return domain.LoginFailureChallenge
+	case errors.Is(err, domain.ErrDeviceConfirmationRequired):
+		return domain.LoginFailureDeviceConfirmation
+	default:
+		return domain.LoginFailureError
+	}
+}
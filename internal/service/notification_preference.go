@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/notify"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// NotificationPreferences is whether a user wants notifications, by
+// category and then channel.
+type NotificationPreferences map[string]map[string]bool
+
+// NotificationPreferenceService manages which notifications users get over
+// which channel. It implements notify.Preferences.
+type NotificationPreferenceService struct {
+	prefs storage.NotificationPreferenceRepository
+	tx    storage.Transactor
+}
+
+func NewNotificationPreferenceService(prefs storage.NotificationPreferenceRepository, tx storage.Transactor) *NotificationPreferenceService {
+	return &NotificationPreferenceService{prefs: prefs, tx: tx}
+}
+
+// Get returns the user's preferences for every category and channel, those
+// they haven't set being enabled.
+func (s *NotificationPreferenceService) Get(ctx context.Context, userID uuid.UUID) (NotificationPreferences, error) {
+	set, err := s.prefs.ListForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs := NotificationPreferences{}
+	for _, category := range notify.Categories {
+		channels := map[string]bool{}
+		for _, channel := range notify.Channels {
+			channels[string(channel)] = true
+		}
+		prefs[string(category)] = channels
+	}
+	for _, p := range set {
+		// Rows for categories or channels since removed are left out
+		if channels, ok := prefs[p.Category]; ok {
+			if _, ok := channels[p.Channel]; ok {
+				channels[p.Channel] = p.Enabled
+			}
+		}
+	}
+
+	return prefs, nil
+}
+
+// Update sets the given preferences of the user, leaving the others, and
+// returns them all.
+func (s *NotificationPreferenceService) Update(ctx context.Context, userID uuid.UUID, update NotificationPreferences) (NotificationPreferences, error) {
+	var prefs []domain.NotificationPreference
+	for category, channels := range update {
+		if !slices.Contains(notify.Categories, notify.Category(category)) {
+			return nil, domain.ValidationError{Field: "notifications", Message: "unknown category " + category}
+		}
+		for channel, enabled := range channels {
+			if !slices.Contains(notify.Channels, notify.Channel(channel)) {
+				return nil, domain.ValidationError{Field: "notifications", Message: "unknown channel " + channel}
+			}
+			prefs = append(prefs, domain.NotificationPreference{Category: category, Channel: channel, Enabled: enabled})
+		}
+	}
+
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		return s.prefs.Set(ctx, userID, prefs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, userID)
+}
+
+// Allows reports whether the user wants notifications of the category over
+// the channel.
+func (s *NotificationPreferenceService) Allows(ctx context.Context, userID uuid.UUID, category notify.Category, channel notify.Channel) (bool, error) {
+	p, err := s.prefs.Get(ctx, userID, string(category), string(channel))
+	if errors.Is(err, domain.ErrNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return p.Enabled, nil
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/auth"
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// OAuthClientService registers machine clients and issues them access
+// tokens with the OAuth 2.0 client credentials grant. The tokens carry the
+// permissions of the client's roles and a client_id claim; there are no
+// refresh tokens, clients ask again once theirs expires.
+type OAuthClientService struct {
+	clients storage.OAuthClientRepository
+	roles   storage.RoleRepository
+	jwt     *auth.JWTManager
+}
+
+func NewOAuthClientService(
+	clients storage.OAuthClientRepository,
+	roles storage.RoleRepository,
+	jwt *auth.JWTManager,
+) *OAuthClientService {
+	return &OAuthClientService{
+		clients: clients,
+		roles:   roles,
+		jwt:     jwt,
+	}
+}
+
+// ClientToken is an access token issued to a client.
+type ClientToken struct {
+	AccessToken      string
+	ExpiresInSeconds int64
+	Scope            string // The permissions granted, space-separated
+}
+
+// IssueToken authenticates a client by its secret and issues it an access
+// token with the permissions in scope, or all its roles grant if scope is
+// empty. Unknown and disabled clients and wrong secrets return
+// ErrInvalidCredential; permissions its roles don't grant return
+// ErrInvalidScope.
+func (s *OAuthClientService) IssueToken(ctx context.Context, clientID, secret, scope string) (*ClientToken, error) {
+	client, err := s.Authenticate(ctx, clientID, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, denied, err := client.ScopePermissions(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, _, err := s.jwt.GenerateAccessToken(ctx, auth.TokenPayload{
+		UserID:      client.ID,
+		Permissions: permissions,
+		Denied:      denied,
+		Environment: tokenEnvironment(ctx),
+		ClientID:    client.ID.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.RecordUse(ctx, client.ID)
+
+	return &ClientToken{
+		AccessToken:      accessToken,
+		ExpiresInSeconds: int64(s.jwt.AccessTokenTTL().Seconds()),
+		Scope:            strings.Join(permissions, " "),
+	}, nil
+}
+
+// Authenticate returns the enabled client with clientID and secret.
+// Anything else returns ErrInvalidCredential.
+func (s *OAuthClientService) Authenticate(ctx context.Context, clientID, secret string) (*domain.OAuthClient, error) {
+	id, err := uuid.Parse(clientID)
+	if err != nil {
+		return nil, domain.ErrInvalidCredential
+	}
+
+	client, err := s.clients.GetByID(ctx, id)
+	if err != nil {
+		return nil, domain.ErrInvalidCredential
+	}
+	if !client.Enabled || subtle.ConstantTimeCompare([]byte(auth.HashToken(secret)), []byte(client.SecretHash)) != 1 {
+		return nil, domain.ErrInvalidCredential
+	}
+	return client, nil
+}
+
+// RecordUse notes that the client just got a token. Last use is
+// informational, so failing to record it is ignored.
+func (s *OAuthClientService) RecordUse(ctx context.Context, clientID uuid.UUID) {
+	_ = s.clients.RecordUse(ctx, clientID, domain.Now())
+}
+
+// ListClients returns every client with its roles, ordered by name.
+func (s *OAuthClientService) ListClients(ctx context.Context) ([]domain.OAuthClient, error) {
+	return s.clients.List(ctx)
+}
+
+func (s *OAuthClientService) GetClient(ctx context.Context, id uuid.UUID) (*domain.OAuthClient, error) {
+	return s.clients.GetByID(ctx, id)
+}
+
+// CreateClient registers a client without roles and returns it with its
+// secret, which is not stored and can't be retrieved again.
+func (s *OAuthClientService) CreateClient(ctx context.Context, name string, createdBy uuid.UUID) (*domain.OAuthClient, string, error) {
+	client, err := domain.NewOAuthClient(name, createdBy)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret, err := issueClientSecret(client)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.clients.Create(ctx, client); err != nil {
+		return nil, "", err
+	}
+	return client, secret, nil
+}
+
+type UpdateOAuthClientInput struct {
+	Name    *string
+	Enabled *bool
+}
+
+// UpdateClient renames, disables or re-enables a client. Tokens already
+// issued to a disabled client stay valid until they expire.
+func (s *OAuthClientService) UpdateClient(ctx context.Context, id uuid.UUID, input UpdateOAuthClientInput) (*domain.OAuthClient, error) {
+	client, err := s.clients.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Name != nil {
+		client.Name = strings.TrimSpace(*input.Name)
+	}
+
+	if input.Enabled != nil {
+		client.Enabled = *input.Enabled
+	}
+
+	if err := client.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.clients.Update(ctx, client); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// RotateSecret replaces a client's secret and returns the new one. The old
+// one stops working at once.
+func (s *OAuthClientService) RotateSecret(ctx context.Context, id uuid.UUID) (*domain.OAuthClient, string, error) {
+	client, err := s.clients.GetByID(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret, err := issueClientSecret(client)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.clients.Update(ctx, client); err != nil {
+		return nil, "", err
+	}
+	return client, secret, nil
+}
+
+func (s *OAuthClientService) DeleteClient(ctx context.Context, id uuid.UUID) error {
+	return s.clients.Delete(ctx, id)
+}
+
+// AssignRole gives a client a global role, from the next token it gets.
+func (s *OAuthClientService) AssignRole(ctx context.Context, clientID, roleID uuid.UUID) (*domain.OAuthClient, error) {
+	if _, err := s.clients.GetByID(ctx, clientID); err != nil {
+		return nil, err
+	}
+
+	role, err := s.roles.GetByID(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+	if !role.IsGlobal() {
+		return nil, domain.ValidationError{Field: "role_id", Message: "clients can only hold global roles"}
+	}
+
+	if err := s.clients.AssignRole(ctx, clientID, roleID); err != nil {
+		return nil, err
+	}
+	return s.clients.GetByID(ctx, clientID)
+}
+
+// RemoveRole takes a role from a client, from the next token it gets.
+func (s *OAuthClientService) RemoveRole(ctx context.Context, clientID, roleID uuid.UUID) error {
+	if _, err := s.clients.GetByID(ctx, clientID); err != nil {
+		return err
+	}
+
+	return s.clients.RemoveRole(ctx, clientID, roleID)
+}
+
+func issueClientSecret(client *domain.OAuthClient) (string, error) {
+	secret, err := domain.GenerateTokenString()
+	if err != nil {
+		return "", err
+	}
+	client.SecretHash = auth.HashToken(secret)
+	return secret, nil
+}
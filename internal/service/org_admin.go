@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// OrgAdminService lets organization admins manage the members of their own
+// organization through the core services, so platform admins don't have to.
+// Callers check the admin's permission in the organization; the service
+// keeps each action inside it:
+//
+//   - invitations only reach emails that aren't registered, or whose
+//     pending account already belongs to the organization;
+//   - account-wide actions (suspending, reactivating, signing out) only
+//     apply to members who belong to no other organization and whose
+//     global roles grant nothing the admin lacks;
+//   - admins can't act on themselves.
+type OrgAdminService struct {
+	orgs          storage.OrganizationRepository
+	users         storage.UserRepository
+	roles         storage.RoleRepository
+	organizations *OrganizationService
+	userService   *UserService
+	invitations   *InvitationService
+	auth          *AuthService
+}
+
+func NewOrgAdminService(
+	orgs storage.OrganizationRepository,
+	users storage.UserRepository,
+	roles storage.RoleRepository,
+	organizations *OrganizationService,
+	userService *UserService,
+	invitations *InvitationService,
+	auth *AuthService,
+) *OrgAdminService {
+	return &OrgAdminService{
+		orgs:          orgs,
+		users:         users,
+		roles:         roles,
+		organizations: organizations,
+		userService:   userService,
+		invitations:   invitations,
+		auth:          auth,
+	}
+}
+
+// InviteMemberInput identifies the invitee and the inviting admin.
+type InviteMemberInput struct {
+	OrganizationID uuid.UUID
+	Email          string
+	FullName       string
+	InvitedBy      uuid.UUID
+}
+
+// InviteMember invites an email address and adds the pending account to
+// the organization. The invitation is emailed; unlike CreateInvitation's
+// callers, organization admins never see its token.
+func (s *OrgAdminService) InviteMember(ctx context.Context, input InviteMemberInput) (*domain.Invitation, error) {
+	if _, err := s.orgs.GetByID(ctx, input.OrganizationID); err != nil {
+		return nil, err
+	}
+
+	// A pending account invited elsewhere can't be taken over
+	existing, err := s.users.GetByEmail(ctx, strings.TrimSpace(input.Email))
+	switch {
+	case err == nil:
+		member, err := s.orgs.IsMember(ctx, input.OrganizationID, existing.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !member || existing.Status != domain.UserStatusPending {
+			return nil, domain.ValidationError{Field: "email", Message: "already registered"}
+		}
+	case !errors.Is(err, domain.ErrNotFound):
+		return nil, err
+	}
+
+	inv, _, err := s.invitations.CreateInvitation(ctx, CreateInvitationInput{
+		Email:     input.Email,
+		FullName:  input.FullName,
+		InvitedBy: input.InvitedBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.organizations.AddMember(ctx, input.OrganizationID, inv.UserID); err != nil {
+		return nil, err
+	}
+
+	return inv, nil
+}
+
+// ListMembers lists a page of the organization's members.
+func (s *OrgAdminService) ListMembers(ctx context.Context, orgID uuid.UUID, offset, limit int) ([]domain.OrganizationMember, int64, error) {
+	return s.organizations.ListMembers(ctx, orgID, offset, limit)
+}
+
+// SuspendMember suspends a member's account.
+func (s *OrgAdminService) SuspendMember(ctx context.Context, actorID, orgID, userID uuid.UUID, reason string) error {
+	if err := s.checkAccountAction(ctx, actorID, orgID, userID); err != nil {
+		return err
+	}
+	return s.userService.SuspendUser(ctx, userID, reason)
+}
+
+// ReactivateMember reactivates a suspended member's account. Pending
+// accounts are activated by accepting their invitation instead.
+func (s *OrgAdminService) ReactivateMember(ctx context.Context, actorID, orgID, userID uuid.UUID) error {
+	if err := s.checkAccountAction(ctx, actorID, orgID, userID); err != nil {
+		return err
+	}
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.Status != domain.UserStatusSuspended {
+		return domain.ValidationError{Field: "status", Message: "only suspended members can be reactivated"}
+	}
+
+	return s.userService.ActivateUser(ctx, userID)
+}
+
+// RevokeMemberSessions signs a member out everywhere by revoking their
+// refresh tokens, e.g. after a lost device.
+func (s *OrgAdminService) RevokeMemberSessions(ctx context.Context, actorID, orgID, userID uuid.UUID) error {
+	if err := s.checkAccountAction(ctx, actorID, orgID, userID); err != nil {
+		return err
+	}
+	return s.auth.LogoutAll(ctx, userID)
+}
+
+// RemoveMember removes a member from the organization, with the roles they
+// hold in it. Their account is left as it is.
+func (s *OrgAdminService) RemoveMember(ctx context.Context, actorID, orgID, userID uuid.UUID) error {
+	if actorID == userID {
+		return domain.ValidationError{Field: "user_id", Message: "cannot remove yourself"}
+	}
+	if err := s.checkMember(ctx, orgID, userID); err != nil {
+		return err
+	}
+	return s.organizations.RemoveMember(ctx, orgID, userID)
+}
+
+// checkMember returns ErrNotFound unless the user belongs to the
+// organization, so admins can't probe for users outside it.
+func (s *OrgAdminService) checkMember(ctx context.Context, orgID, userID uuid.UUID) error {
+	member, err := s.orgs.IsMember(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// checkAccountAction allows account-wide actions on members who belong to
+// this organization only and whose global roles grant nothing the actor
+// lacks.
+func (s *OrgAdminService) checkAccountAction(ctx context.Context, actorID, orgID, userID uuid.UUID) error {
+	if actorID == userID {
+		return domain.ValidationError{Field: "user_id", Message: "cannot act on yourself"}
+	}
+	if err := s.checkMember(ctx, orgID, userID); err != nil {
+		return err
+	}
+
+	orgs, err := s.orgs.ListForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(orgs, func(o domain.Organization) bool { return o.ID != orgID }) {
+		return domain.ErrForbidden
+	}
+
+	actorRoles, err := s.roles.GetEffectiveUserRoles(ctx, actorID)
+	if err != nil {
+		return err
+	}
+	targetRoles, err := s.roles.GetEffectiveUserRoles(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	actor := domain.User{Roles: actorRoles}
+	target := domain.User{Roles: targetRoles}
+	for _, p := range target.AllPermissions() {
+		if !p.Deny && !actor.HasPermission(p.Resource, p.Action) {
+			return domain.ErrForbidden
+		}
+	}
+
+	return nil
+}
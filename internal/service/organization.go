@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// OrganizationService manages organizations and their membership.
+type OrganizationService struct {
+	orgs      storage.OrganizationRepository
+	users     storage.UserRepository
+	publisher event.Publisher
+}
+
+func NewOrganizationService(
+	orgs storage.OrganizationRepository,
+	users storage.UserRepository,
+	publisher event.Publisher,
+) *OrganizationService {
+	return &OrganizationService{
+		orgs:      orgs,
+		users:     users,
+		publisher: publisher,
+	}
+}
+
+func (s *OrganizationService) CreateOrganization(ctx context.Context, name, slug string) (*domain.Organization, error) {
+	org, err := domain.NewOrganization(name, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.orgs.Create(ctx, org); err != nil {
+		return nil, err
+	}
+
+	return org, nil
+}
+
+func (s *OrganizationService) GetOrganization(ctx context.Context, id uuid.UUID) (*domain.Organization, error) {
+	return s.orgs.GetByID(ctx, id)
+}
+
+func (s *OrganizationService) GetOrganizationBySlug(ctx context.Context, slug string) (*domain.Organization, error) {
+	return s.orgs.GetBySlug(ctx, slug)
+}
+
+func (s *OrganizationService) ListOrganizations(ctx context.Context, offset, limit int) ([]domain.Organization, int64, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.orgs.List(ctx, offset, limit)
+}
+
+type UpdateOrganizationInput struct {
+	Name *string
+	Slug *string
+}
+
+func (s *OrganizationService) UpdateOrganization(ctx context.Context, id uuid.UUID, input UpdateOrganizationInput) (*domain.Organization, error) {
+	org, err := s.orgs.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Name != nil {
+		org.Name = *input.Name
+	}
+
+	if input.Slug != nil {
+		org.Slug = *input.Slug
+	}
+
+	if err := org.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.orgs.Update(ctx, org); err != nil {
+		return nil, err
+	}
+
+	return org, nil
+}
+
+func (s *OrganizationService) DeleteOrganization(ctx context.Context, id uuid.UUID) error {
+	return s.orgs.Delete(ctx, id)
+}
+
+func (s *OrganizationService) AddMember(ctx context.Context, orgID, userID uuid.UUID) error {
+	org, err := s.orgs.GetByID(ctx, orgID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.users.GetByID(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.orgs.AddMember(ctx, orgID, userID); err != nil {
+		return err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.OrganizationMemberAddedEvent(userID, org))
+
+	return nil
+}
+
+// RemoveMember removes a user from an organization. Roles scoped to the
+// organization are revoked with the membership.
+func (s *OrganizationService) RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error {
+	org, err := s.orgs.GetByID(ctx, orgID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.orgs.RemoveMember(ctx, orgID, userID); err != nil {
+		return err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.OrganizationMemberRemovedEvent(userID, org))
+
+	return nil
+}
+
+func (s *OrganizationService) ListMembers(ctx context.Context, orgID uuid.UUID, offset, limit int) ([]domain.OrganizationMember, int64, error) {
+	if _, err := s.orgs.GetByID(ctx, orgID); err != nil {
+		return nil, 0, err
+	}
+
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.orgs.ListMembers(ctx, orgID, offset, limit)
+}
+
+func (s *OrganizationService) ListUserOrganizations(ctx context.Context, userID uuid.UUID) ([]domain.Organization, error) {
+	return s.orgs.ListForUser(ctx, userID)
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/auth"
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// PasswordHistory remembers users' recent password hashes so a password
+// change can't go back to one of them. It is shared by every place that
+// sets a password.
+type PasswordHistory struct {
+	repo storage.PasswordHistoryRepository
+	size int
+}
+
+// NewPasswordHistory keeps the last size passwords per user. A size of 0
+// turns reuse checks off.
+func NewPasswordHistory(repo storage.PasswordHistoryRepository, size int) *PasswordHistory {
+	return &PasswordHistory{repo: repo, size: size}
+}
+
+// CheckReuse returns a validation error if password matches the user's
+// current password or one of their recent ones.
+func (h *PasswordHistory) CheckReuse(ctx context.Context, user *domain.User, password string) error {
+	if h.size <= 0 {
+		return nil
+	}
+
+	hashes, err := h.repo.Recent(ctx, user.ID, h.size)
+	if err != nil {
+		return err
+	}
+	if user.PasswordHash != "" {
+		hashes = append(hashes, user.PasswordHash)
+	}
+
+	checked := make(map[string]bool, len(hashes))
+	for _, hash := range hashes {
+		if checked[hash] {
+			continue
+		}
+		checked[hash] = true
+
+		if auth.CheckPassword(password, hash) == nil {
+			return domain.ValidationError{
+				Field:   "new_password",
+				Message: fmt.Sprintf("must not match any of your last %d passwords", h.size),
+			}
+		}
+	}
+
+	return nil
+}
+
+// Record adds a password the user has just been given to their history.
+func (h *PasswordHistory) Record(ctx context.Context, userID uuid.UUID, passwordHash string) error {
+	if h.size <= 0 {
+		return nil
+	}
+	return h.repo.Add(ctx, userID, passwordHash, h.size)
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// PipelineThresholds are the backlogs past which the event pipeline is
+// reported unhealthy, failing readiness. Zero fields aren't checked.
+type PipelineThresholds struct {
+	MaxEventBacklog int           // Events waiting for the broker, buffered and spooled
+	MaxEventAge     time.Duration // Age of the oldest of them
+	MaxWebhookDue   int64         // Webhook deliveries due
+	MaxWebhookDelay time.Duration // How long the oldest due delivery has waited
+}
+
+// PipelineHealth is a snapshot of the event pipeline: the events waiting
+// for the broker and the webhook deliveries waiting for their endpoints.
+type PipelineHealth struct {
+	Healthy  bool                     `json:"healthy"`
+	Problems []string                 `json:"problems,omitempty"`
+	Events   event.PublisherStats     `json:"events"`
+	Webhooks domain.WebhookQueueStats `json:"webhooks"`
+
+	// Derived from the snapshot, as the thresholds are checked
+	EventBacklog       int     `json:"event_backlog"`
+	OldestEventAge     float64 `json:"oldest_event_age_seconds"`
+	OldestWebhookDelay float64 `json:"oldest_webhook_delay_seconds"`
+}
+
+// PipelineService reports the health of the event pipeline.
+type PipelineService struct {
+	queue      *event.Resilient
+	webhooks   storage.WebhookRepository
+	thresholds PipelineThresholds
+}
+
+func NewPipelineService(queue *event.Resilient, webhooks storage.WebhookRepository, thresholds PipelineThresholds) *PipelineService {
+	return &PipelineService{queue: queue, webhooks: webhooks, thresholds: thresholds}
+}
+
+// Health takes a snapshot of the pipeline and checks it against the
+// thresholds. An error means the webhook queue couldn't be read.
+func (s *PipelineService) Health(ctx context.Context) (PipelineHealth, error) {
+	webhooks, err := s.webhooks.QueueStats(ctx)
+	if err != nil {
+		return PipelineHealth{}, err
+	}
+
+	now := domain.Now()
+	h := PipelineHealth{
+		Events:   s.queue.Stats(),
+		Webhooks: webhooks,
+	}
+	h.EventBacklog = h.Events.Buffered + h.Events.Spooled
+	if h.Events.Oldest != nil {
+		h.OldestEventAge = max(now.Sub(*h.Events.Oldest), 0).Seconds()
+	}
+	if webhooks.OldestDue != nil {
+		h.OldestWebhookDelay = max(now.Sub(*webhooks.OldestDue), 0).Seconds()
+	}
+
+	t := s.thresholds
+	if t.MaxEventBacklog > 0 && h.EventBacklog > t.MaxEventBacklog {
+		h.Problems = append(h.Problems, fmt.Sprintf("%d events waiting for the broker, over %d", h.EventBacklog, t.MaxEventBacklog))
+	}
+	if t.MaxEventAge > 0 && h.OldestEventAge > t.MaxEventAge.Seconds() {
+		h.Problems = append(h.Problems, fmt.Sprintf("oldest event waiting %.0fs, over %s", h.OldestEventAge, t.MaxEventAge))
+	}
+	if t.MaxWebhookDue > 0 && webhooks.Due > t.MaxWebhookDue {
+		h.Problems = append(h.Problems, fmt.Sprintf("%d webhook deliveries due, over %d", webhooks.Due, t.MaxWebhookDue))
+	}
+	if t.MaxWebhookDelay > 0 && h.OldestWebhookDelay > t.MaxWebhookDelay.Seconds() {
+		h.Problems = append(h.Problems, fmt.Sprintf("oldest webhook delivery due %.0fs ago, over %s", h.OldestWebhookDelay, t.MaxWebhookDelay))
+	}
+	h.Healthy = len(h.Problems) == 0
+
+	return h, nil
+}
@@ -0,0 +1,360 @@
+package service
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// PolicyDocument is who may do what, in the shape external policy engines
+// load as data: users by ID with their effective roles and permissions, and
+// roles by ID. Users who are deleted are left out, and so are expired role
+// assignments; nothing identifying a user beyond their ID is included.
+type PolicyDocument struct {
+	Revision string                `json:"revision"` // Changes whenever the rest does
+	Roles    map[string]PolicyRole `json:"roles"`
+	Users    map[string]PolicyUser `json:"users"`
+}
+
+// PolicyRole is a role and the permissions it grants and denies, as
+// "resource:action".
+type PolicyRole struct {
+	Name           string     `json:"name"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
+	Allow          []string   `json:"allow"`
+	Deny           []string   `json:"deny"`
+}
+
+// PolicyUser is a user's roles, held directly or through a group, and what
+// they add up to: Allow and Deny from global roles, and per organization ID
+// from roles scoped to it.
+type PolicyUser struct {
+	Type                    string                  `json:"type"`
+	Status                  string                  `json:"status"`
+	Roles                   []string                `json:"roles"` // IDs
+	Groups                  []string                `json:"groups"`
+	Organizations           []string                `json:"organizations"` // IDs of those the user belongs to
+	Allow                   []string                `json:"allow"`
+	Deny                    []string                `json:"deny"`
+	OrganizationPermissions map[string]PolicyGrants `json:"organization_permissions,omitempty"`
+}
+
+// PolicyGrants is what a user's roles in one organization grant and deny.
+type PolicyGrants struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+// PolicyService exposes roles and permissions as a policy source for
+// Open Policy Agent and Casbin, so this service can be their data plane.
+type PolicyService struct {
+	datasets storage.DatasetRepository
+}
+
+func NewPolicyService(datasets storage.DatasetRepository) *PolicyService {
+	return &PolicyService{datasets: datasets}
+}
+
+// Document builds the policy document from one consistent snapshot.
+func (s *PolicyService) Document(ctx context.Context) (*PolicyDocument, error) {
+	d, err := s.datasets.Export(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	perms := make(map[uuid.UUID]domain.Permission, len(d.Permissions))
+	for _, p := range d.Permissions {
+		perms[p.ID] = p
+	}
+
+	doc := &PolicyDocument{
+		Roles: make(map[string]PolicyRole, len(d.Roles)),
+		Users: make(map[string]PolicyUser, len(d.Users)),
+	}
+
+	// Roles with their permissions resolved, for summing up users' roles
+	roles := make(map[uuid.UUID]domain.Role, len(d.Roles))
+	for _, r := range d.Roles {
+		role := PolicyRole{Name: r.Name, OrganizationID: r.OrganizationID, Allow: []string{}, Deny: []string{}}
+		resolved := r
+		resolved.Permissions = nil
+		for _, ref := range r.Permissions {
+			p := perms[ref.ID]
+			p.Deny = ref.Deny
+			resolved.Permissions = append(resolved.Permissions, p)
+			if p.Deny {
+				role.Deny = append(role.Deny, p.String())
+			} else {
+				role.Allow = append(role.Allow, p.String())
+			}
+		}
+		roles[r.ID] = resolved
+		doc.Roles[r.ID.String()] = role
+	}
+
+	groups := make(map[uuid.UUID]domain.Group, len(d.Groups))
+	for _, g := range d.Groups {
+		groups[g.ID] = g
+	}
+	userGroups := make(map[uuid.UUID][]uuid.UUID)
+	for _, m := range d.GroupMembers {
+		userGroups[m.UserID] = append(userGroups[m.UserID], m.GroupID)
+	}
+	userOrgs := make(map[uuid.UUID][]string)
+	for _, m := range d.OrganizationMembers {
+		userOrgs[m.UserID] = append(userOrgs[m.UserID], m.OrganizationID.String())
+	}
+
+	now := domain.Now()
+	for _, u := range d.Users {
+		if u.IsDeleted() {
+			continue
+		}
+
+		user := PolicyUser{
+			Type:          string(u.Type),
+			Status:        string(u.Status),
+			Roles:         []string{},
+			Groups:        []string{},
+			Organizations: append([]string{}, userOrgs[u.ID]...),
+		}
+
+		// Effective roles: direct ones that haven't expired, and the
+		// groups' ones
+		var held []uuid.UUID
+		for _, r := range u.Roles {
+			if r.ExpiresAt == nil || r.ExpiresAt.After(now) {
+				held = append(held, r.ID)
+			}
+		}
+		for _, id := range userGroups[u.ID] {
+			g := groups[id]
+			user.Groups = append(user.Groups, g.Name)
+			for _, r := range g.Roles {
+				held = append(held, r.ID)
+			}
+		}
+		slices.SortFunc(held, func(a, b uuid.UUID) int { return bytes.Compare(a[:], b[:]) })
+		held = slices.Compact(held)
+
+		effective := domain.User{}
+		for _, id := range held {
+			user.Roles = append(user.Roles, id.String())
+			effective.Roles = append(effective.Roles, roles[id])
+		}
+
+		user.Allow, user.Deny = splitGrants(effective.AllPermissions())
+		for i := range effective.Roles {
+			orgID := effective.Roles[i].OrganizationID
+			if orgID == nil {
+				continue
+			}
+			if user.OrganizationPermissions == nil {
+				user.OrganizationPermissions = make(map[string]PolicyGrants)
+			}
+			if _, done := user.OrganizationPermissions[orgID.String()]; done {
+				continue
+			}
+			var grants PolicyGrants
+			grants.Allow, grants.Deny = splitGrants(effective.OrganizationPermissions(*orgID))
+			user.OrganizationPermissions[orgID.String()] = grants
+		}
+		slices.Sort(user.Groups)
+		slices.Sort(user.Organizations)
+
+		doc.Users[u.ID.String()] = user
+	}
+
+	// Maps marshal with sorted keys, so equal documents hash the same
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	doc.Revision = hex.EncodeToString(sum[:16])
+
+	return doc, nil
+}
+
+func splitGrants(perms []domain.Permission) (allow, deny []string) {
+	allow, deny = []string{}, []string{}
+	for i := range perms {
+		if perms[i].Deny {
+			deny = append(deny, perms[i].String())
+		} else {
+			allow = append(allow, perms[i].String())
+		}
+	}
+	slices.Sort(allow)
+	slices.Sort(deny)
+	return allow, deny
+}
+
+// OPARoot is where the policy document and OPAPolicy live in OPA's data
+// tree: data.aegis.users, data.aegis.roles, data.aegis.authz.allow.
+const OPARoot = "aegis"
+
+// OPAPolicy is the Rego policy shipped in the bundle. data.aegis.authz.allow
+// answers input {user_id, resource, action, organization_id?} the way
+// aegis does: the user is active, one of their roles grants the permission
+// (wildcards included) and none denies it; with organization_id only roles
+// scoped to that organization count.
+const OPAPolicy = `package aegis.authz
+
+import rego.v1
+
+default allow := false
+
+grants := data.aegis.users[input.user_id].organization_permissions[input.organization_id] if {
+	input.organization_id
+} else := data.aegis.users[input.user_id]
+
+allow if {
+	data.aegis.users[input.user_id].status == "active"
+	some p in grants.allow
+	covers(p)
+	not denied
+}
+
+denied if {
+	some p in grants.deny
+	covers(p)
+}
+
+covers(p) if {
+	[resource, action] := split(p, ":")
+	resource in {"*", input.resource}
+	action in {"*", input.action}
+}
+`
+
+// OPABundle packs doc as an OPA bundle: data.json under OPARoot, the
+// policy, and a manifest with doc's revision.
+func OPABundle(doc *PolicyDocument) ([]byte, error) {
+	data, err := json.Marshal(map[string]any{OPARoot: doc})
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := json.Marshal(map[string]any{
+		"revision": doc.Revision,
+		"roots":    []string{OPARoot},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, file := range []struct {
+		name string
+		data []byte
+	}{
+		{"/.manifest", manifest},
+		{"/data.json", data},
+		{"/" + OPARoot + "/authz.rego", []byte(OPAPolicy)},
+	} {
+		hdr := &tar.Header{
+			Name:     file.name,
+			Mode:     0o644,
+			Size:     int64(len(file.data)),
+			ModTime:  time.Unix(0, 0),
+			Typeflag: tar.TypeReg,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(file.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// CasbinModel is the Casbin model for CasbinPolicy: RBAC with domains, where
+// the domain is "global" or an organization ID, keyMatch wildcards and
+// deny-overrides-allow. Requests are (sub, dom, obj, act) with sub
+// "user:<id>".
+const CasbinModel = `[request_definition]
+r = sub, dom, obj, act
+
+[policy_definition]
+p = sub, dom, obj, act, eft
+
+[role_definition]
+g = _, _, _
+
+[policy_effect]
+e = some(where (p.eft == allow)) && !some(where (p.eft == deny))
+
+[matchers]
+m = g(r.sub, p.sub, r.dom) && r.dom == p.dom && keyMatch(r.obj, p.obj) && keyMatch(r.act, p.act)
+`
+
+// CasbinGlobalDomain is the domain of global roles in CasbinPolicy.
+const CasbinGlobalDomain = "global"
+
+// CasbinPolicy lists doc as Casbin policy lines for CasbinModel: a p line
+// per role permission and a g line per effective role of each active user.
+func CasbinPolicy(doc *PolicyDocument) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	roleIDs := slices.Sorted(maps.Keys(doc.Roles))
+	domains := make(map[string]string, len(roleIDs))
+	for _, id := range roleIDs {
+		role := doc.Roles[id]
+		dom := CasbinGlobalDomain
+		if role.OrganizationID != nil {
+			dom = role.OrganizationID.String()
+		}
+		domains[id] = dom
+
+		for _, list := range []struct {
+			perms []string
+			eft   string
+		}{{role.Allow, "allow"}, {role.Deny, "deny"}} {
+			for _, p := range list.perms {
+				resource, action, _ := strings.Cut(p, ":")
+				if err := w.Write([]string{"p", "role:" + id, dom, resource, action, list.eft}); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(doc.Users)) {
+		user := doc.Users[id]
+		if user.Status != string(domain.UserStatusActive) {
+			continue
+		}
+		for _, roleID := range user.Roles {
+			if err := w.Write([]string{"g", "user:" + id, "role:" + roleID, domains[roleID]}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
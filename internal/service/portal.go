@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/auth"
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// maxUsageDays bounds how far back API key usage can be requested.
+const maxUsageDays = 90
+
+// PortalService is the partner self-service surface: API keys and
+// webhooks, always scoped to the partner that owns them. Anything owned by
+// someone else is reported as ErrNotFound.
+type PortalService struct {
+	apiKeys  storage.APIKeyRepository
+	users    storage.UserRepository
+	webhooks *WebhookService
+	repo     storage.WebhookRepository
+}
+
+func NewPortalService(
+	apiKeys storage.APIKeyRepository,
+	users storage.UserRepository,
+	webhookRepo storage.WebhookRepository,
+	webhooks *WebhookService,
+) *PortalService {
+	return &PortalService{
+		apiKeys:  apiKeys,
+		users:    users,
+		webhooks: webhooks,
+		repo:     webhookRepo,
+	}
+}
+
+type CreateAPIKeyInput struct {
+	OwnerID     uuid.UUID
+	Name        string
+	Environment domain.APIKeyEnvironment
+	Scopes      []string
+	Granted     []string // The owner's permissions; scopes must be within them
+	Denied      []string // The owner's denied permissions; scopes may not reach them
+}
+
+// CreateAPIKey issues a key and returns it with its raw form, which is
+// only available now.
+func (s *PortalService) CreateAPIKey(ctx context.Context, input CreateAPIKeyInput) (*domain.APIKey, string, error) {
+	key, err := domain.NewAPIKey(input.OwnerID, input.Name, input.Environment, input.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, scope := range key.Scopes {
+		if !permissionGranted(input.Granted, scope) || permissionsOverlap(input.Denied, scope) {
+			return nil, "", domain.ValidationError{Field: "scopes", Message: "scope " + scope + " exceeds your permissions"}
+		}
+	}
+
+	token, err := s.issueSecret(key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.apiKeys.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	return key, token, nil
+}
+
+func (s *PortalService) ListAPIKeys(ctx context.Context, ownerID uuid.UUID) ([]domain.APIKey, error) {
+	return s.apiKeys.ListForOwner(ctx, ownerID)
+}
+
+// GetAPIKeyUsage returns the key's daily request counts for the last days
+// days, today included.
+func (s *PortalService) GetAPIKeyUsage(ctx context.Context, ownerID, keyID uuid.UUID, days int) ([]domain.APIKeyUsage, error) {
+	key, err := s.ownedAPIKey(ctx, ownerID, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if days <= 0 || days > maxUsageDays {
+		days = 30
+	}
+	since := domain.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -(days - 1))
+
+	return s.apiKeys.Usage(ctx, key.ID, since)
+}
+
+// RegenerateAPIKey replaces the key's secret, keeping its ID, scopes and
+// usage. The old secret stops working immediately.
+func (s *PortalService) RegenerateAPIKey(ctx context.Context, ownerID, keyID uuid.UUID) (*domain.APIKey, string, error) {
+	key, err := s.ownedAPIKey(ctx, ownerID, keyID)
+	if err != nil {
+		return nil, "", err
+	}
+	if key.IsRevoked() {
+		return nil, "", domain.ErrConflict
+	}
+
+	token, err := s.issueSecret(key)
+	if err != nil {
+		return nil, "", err
+	}
+	now := domain.Now()
+	key.RotatedAt = &now
+
+	if err := s.apiKeys.Update(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	return key, token, nil
+}
+
+func (s *PortalService) RevokeAPIKey(ctx context.Context, ownerID, keyID uuid.UUID) error {
+	key, err := s.ownedAPIKey(ctx, ownerID, keyID)
+	if err != nil {
+		return err
+	}
+	if key.IsRevoked() {
+		return nil
+	}
+
+	key.Revoke()
+	return s.apiKeys.Update(ctx, key)
+}
+
+// AuthenticateAPIKey resolves a raw key to the key it belongs to and counts
+// the request against it. Unknown, revoked and mismatched keys, and keys of
+// inactive owners, all return ErrInvalidCredential.
+func (s *PortalService) AuthenticateAPIKey(ctx context.Context, raw string) (*domain.APIKey, error) {
+	prefix, secret, ok := domain.ParseAPIKey(raw)
+	if !ok {
+		return nil, domain.ErrInvalidCredential
+	}
+
+	key, err := s.apiKeys.GetByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, domain.ErrInvalidCredential
+	}
+	if key.IsRevoked() || subtle.ConstantTimeCompare([]byte(auth.HashToken(secret)), []byte(key.SecretHash)) != 1 {
+		return nil, domain.ErrInvalidCredential
+	}
+
+	owner, err := s.users.GetByID(ctx, key.OwnerID)
+	if err != nil || !owner.IsActive() {
+		return nil, domain.ErrInvalidCredential
+	}
+
+	// Usage is informational; failing to count it doesn't fail the request
+	_ = s.apiKeys.RecordUsage(ctx, key.ID, domain.Now())
+
+	return key, nil
+}
+
+func (s *PortalService) issueSecret(key *domain.APIKey) (string, error) {
+	secret, err := domain.GenerateTokenString()
+	if err != nil {
+		return "", err
+	}
+	key.SecretHash = auth.HashToken(secret)
+	return key.Token(secret), nil
+}
+
+func (s *PortalService) ownedAPIKey(ctx context.Context, ownerID, keyID uuid.UUID) (*domain.APIKey, error) {
+	key, err := s.apiKeys.GetByID(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if key.OwnerID != ownerID {
+		return nil, domain.ErrNotFound
+	}
+	return key, nil
+}
+
+// CreateWebhook registers a webhook owned by the partner. It only receives
+// events about the partner.
+func (s *PortalService) CreateWebhook(ctx context.Context, ownerID uuid.UUID, url, description string, eventTypes []string) (*domain.Webhook, error) {
+	return s.webhooks.CreateWebhook(ctx, CreateWebhookInput{
+		URL:         url,
+		Description: description,
+		EventTypes:  eventTypes,
+		CreatedBy:   ownerID,
+		OwnerID:     &ownerID,
+	})
+}
+
+func (s *PortalService) ListWebhooks(ctx context.Context, ownerID uuid.UUID) ([]domain.Webhook, error) {
+	return s.repo.ListForOwner(ctx, ownerID)
+}
+
+func (s *PortalService) UpdateWebhook(ctx context.Context, ownerID, id uuid.UUID, input UpdateWebhookInput) (*domain.Webhook, error) {
+	if err := s.ownedWebhook(ctx, ownerID, id); err != nil {
+		return nil, err
+	}
+	return s.webhooks.UpdateWebhook(ctx, id, input)
+}
+
+func (s *PortalService) DeleteWebhook(ctx context.Context, ownerID, id uuid.UUID) error {
+	if err := s.ownedWebhook(ctx, ownerID, id); err != nil {
+		return err
+	}
+	return s.webhooks.DeleteWebhook(ctx, id)
+}
+
+func (s *PortalService) RotateWebhookSecret(ctx context.Context, ownerID, id uuid.UUID) (*domain.Webhook, error) {
+	if err := s.ownedWebhook(ctx, ownerID, id); err != nil {
+		return nil, err
+	}
+	return s.webhooks.RotateSecret(ctx, id)
+}
+
+// SendTestWebhook sends one of the partner's webhooks a test event now;
+// see WebhookService.SendTest. Like every delivery shown to partners, it
+// carries the receiver's status but not its response body.
+func (s *PortalService) SendTestWebhook(ctx context.Context, ownerID, id uuid.UUID) (*domain.WebhookDelivery, error) {
+	if err := s.ownedWebhook(ctx, ownerID, id); err != nil {
+		return nil, err
+	}
+	return withoutResponseBody(s.webhooks.SendTest(ctx, id))
+}
+
+// ListWebhookDeliveries returns the delivery history of one of the
+// partner's webhooks, newest first.
+func (s *PortalService) ListWebhookDeliveries(ctx context.Context, ownerID uuid.UUID, filter storage.DeliveryFilter) ([]domain.WebhookDelivery, int64, error) {
+	if err := s.ownedWebhook(ctx, ownerID, filter.WebhookID); err != nil {
+		return nil, 0, err
+	}
+	deliveries, total, err := s.webhooks.ListDeliveries(ctx, filter)
+	for i := range deliveries {
+		deliveries[i].ResponseBody = ""
+	}
+	return deliveries, total, err
+}
+
+func (s *PortalService) GetWebhookDelivery(ctx context.Context, ownerID, webhookID, deliveryID uuid.UUID) (*domain.WebhookDelivery, error) {
+	if err := s.ownedWebhook(ctx, ownerID, webhookID); err != nil {
+		return nil, err
+	}
+	return withoutResponseBody(s.webhooks.GetDelivery(ctx, webhookID, deliveryID))
+}
+
+// RedeliverWebhookDelivery sends a delivery of one of the partner's
+// webhooks again.
+func (s *PortalService) RedeliverWebhookDelivery(ctx context.Context, ownerID, webhookID, deliveryID uuid.UUID) (*domain.WebhookDelivery, error) {
+	if err := s.ownedWebhook(ctx, ownerID, webhookID); err != nil {
+		return nil, err
+	}
+	return withoutResponseBody(s.webhooks.RedeliverDelivery(ctx, webhookID, deliveryID))
+}
+
+// withoutResponseBody clears what the receiver answered from a delivery
+// shown to a partner. Otherwise a partner could read whatever their URL
+// reaches from the server, not only their own receiver.
+func withoutResponseBody(d *domain.WebhookDelivery, err error) (*domain.WebhookDelivery, error) {
+	if d != nil {
+		d.ResponseBody = ""
+	}
+	return d, err
+}
+
+func (s *PortalService) ownedWebhook(ctx context.Context, ownerID, id uuid.UUID) error {
+	webhook, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if webhook.OwnerID == nil || *webhook.OwnerID != ownerID {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// permissionsOverlap reports whether any of denied covers scope or is
+// covered by it: a users:* scope would reach a denied users:delete.
+func permissionsOverlap(denied []string, scope string) bool {
+	for _, d := range denied {
+		if permissionGranted([]string{d}, scope) || permissionGranted([]string{scope}, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// permissionGranted reports whether scope (resource:action) is covered by
+// granted, honoring the same wildcards as role permissions.
+func permissionGranted(granted []string, scope string) bool {
+	resource, action, _ := strings.Cut(scope, ":")
+	for _, g := range granted {
+		gr, ga, _ := strings.Cut(g, ":")
+		if (gr == resource || gr == "*") && (ga == action || ga == "*") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// ProfileService manages the customer and partner profiles linked to a
+// user's login. Switching between them is done by AuthService.SwitchProfile.
+type ProfileService struct {
+	profiles  storage.ProfileRepository
+	users     storage.UserRepository
+	roles     storage.RoleRepository
+	publisher event.Publisher
+}
+
+func NewProfileService(
+	profiles storage.ProfileRepository,
+	users storage.UserRepository,
+	roles storage.RoleRepository,
+	publisher event.Publisher,
+) *ProfileService {
+	return &ProfileService{
+		profiles:  profiles,
+		users:     users,
+		roles:     roles,
+		publisher: publisher,
+	}
+}
+
+// CreateProfile links a profile of profileType to the user. A user has at
+// most one profile of each type, and none of their own user type, which
+// their plain login already acts as.
+func (s *ProfileService) CreateProfile(ctx context.Context, userID uuid.UUID, profileType domain.UserType, displayName string) (*domain.Profile, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := domain.NewProfile(user.ID, profileType, displayName)
+	if err != nil {
+		return nil, err
+	}
+	if profile.Type == user.Type {
+		return nil, domain.ValidationError{Field: "type", Message: "user already is a " + string(user.Type)}
+	}
+
+	if err := s.profiles.Create(ctx, profile); err != nil {
+		return nil, err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.ProfileCreatedEvent(profile))
+
+	return profile, nil
+}
+
+func (s *ProfileService) ListProfiles(ctx context.Context, userID uuid.UUID) ([]domain.Profile, error) {
+	return s.profiles.ListForUser(ctx, userID)
+}
+
+// GetProfile returns the user's profile, or ErrNotFound if it belongs to
+// someone else.
+func (s *ProfileService) GetProfile(ctx context.Context, userID, profileID uuid.UUID) (*domain.Profile, error) {
+	profile, err := s.profiles.GetByID(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	if profile.UserID != userID {
+		return nil, domain.ErrNotFound
+	}
+	return profile, nil
+}
+
+// DetachProfile removes one of the user's profiles. Refresh tokens issued
+// for it stop working; access tokens run out at their expiry.
+func (s *ProfileService) DetachProfile(ctx context.Context, userID, profileID uuid.UUID) error {
+	profile, err := s.GetProfile(ctx, userID, profileID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.profiles.Delete(ctx, profile.ID); err != nil {
+		return err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.ProfileDetachedEvent(profile))
+
+	return nil
+}
+
+// AssignRole grants a role to one of the user's profiles. Only global roles
+// can be assigned, as profile tokens carry no organization.
+func (s *ProfileService) AssignRole(ctx context.Context, userID, profileID, roleID uuid.UUID) error {
+	if _, err := s.GetProfile(ctx, userID, profileID); err != nil {
+		return err
+	}
+
+	role, err := s.roles.GetByID(ctx, roleID)
+	if err != nil {
+		return err
+	}
+	if !role.IsGlobal() {
+		return domain.ValidationError{Field: "role_id", Message: "profiles can only hold global roles"}
+	}
+
+	return s.profiles.AssignRole(ctx, profileID, roleID)
+}
+
+func (s *ProfileService) RemoveRole(ctx context.Context, userID, profileID, roleID uuid.UUID) error {
+	if _, err := s.GetProfile(ctx, userID, profileID); err != nil {
+		return err
+	}
+
+	return s.profiles.RemoveRole(ctx, profileID, roleID)
+}
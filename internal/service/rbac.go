@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -15,6 +17,9 @@ type RBACService struct {
 	users       storage.UserRepository
 	roles       storage.RoleRepository
 	permissions storage.PermissionRepository
+	orgs        storage.OrganizationRepository
+	groups      storage.GroupRepository
+	grants      storage.ResourceGrantRepository
 	publisher   event.Publisher
 }
 
@@ -22,49 +27,144 @@ func NewRBACService(
 	users storage.UserRepository,
 	roles storage.RoleRepository,
 	permissions storage.PermissionRepository,
+	orgs storage.OrganizationRepository,
+	groups storage.GroupRepository,
+	grants storage.ResourceGrantRepository,
 	publisher event.Publisher,
 ) *RBACService {
 	return &RBACService{
 		users:       users,
 		roles:       roles,
 		permissions: permissions,
+		orgs:        orgs,
+		groups:      groups,
+		grants:      grants,
 		publisher:   publisher,
 	}
 }
 
-func (s *RBACService) CreateRole(ctx context.Context, name, description string) (*domain.Role, error) {
+// CreateRole creates a role. A non-nil organizationID scopes the role to
+// that organization.
+func (s *RBACService) CreateRole(ctx context.Context, name, description string, organizationID *uuid.UUID) (*domain.Role, error) {
 	role, err := domain.NewRole(name, description)
 	if err != nil {
 		return nil, err
 	}
 
+	if organizationID != nil {
+		if _, err := s.orgs.GetByID(ctx, *organizationID); err != nil {
+			return nil, err
+		}
+		role.OrganizationID = organizationID
+	}
+
 	if err := s.roles.Create(ctx, role); err != nil {
 		return nil, err
 	}
 
+	_ = s.publisher.Publish(ctx, domain.RoleChangedEvent(domain.EventRoleCreated, role))
+
 	return role, nil
 }
 
+// GetRole returns a role with its permissions and assignment counts.
 func (s *RBACService) GetRole(ctx context.Context, id uuid.UUID) (*domain.Role, error) {
-	return s.roles.GetByID(ctx, id)
+	return s.GetRoleFields(ctx, id, nil)
+}
+
+// GetRoleFields gets a role, counting its assignments only if fields has
+// "assignments".
+func (s *RBACService) GetRoleFields(ctx context.Context, id uuid.UUID, fields FieldSet) (*domain.Role, error) {
+	role, err := s.roles.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fields.Has("assignments") {
+		return role, nil
+	}
+	roles := []domain.Role{*role}
+	if err := s.loadAssignments(ctx, roles); err != nil {
+		return nil, err
+	}
+	return &roles[0], nil
 }
 
 func (s *RBACService) GetRoleByName(ctx context.Context, name string) (*domain.Role, error) {
 	return s.roles.GetByName(ctx, name)
 }
 
-func (s *RBACService) ListRoles(ctx context.Context) ([]domain.Role, error) {
-	return s.roles.List(ctx)
+// ListRoles lists roles. With an organization scope, only the global roles
+// and the roles of that organization are returned.
+func (s *RBACService) ListRoles(ctx context.Context, organizationID *uuid.UUID) ([]domain.Role, error) {
+	return s.ListRolesFields(ctx, organizationID, nil)
+}
+
+// ListRolesFields lists roles as ListRoles does, counting their assignments
+// only if fields has "assignments".
+func (s *RBACService) ListRolesFields(ctx context.Context, organizationID *uuid.UUID, fields FieldSet) ([]domain.Role, error) {
+	roles, err := s.roles.List(ctx, storage.RoleFilter{OrganizationID: organizationID})
+	if err != nil {
+		return nil, err
+	}
+
+	if fields.Has("assignments") {
+		if err := s.loadAssignments(ctx, roles); err != nil {
+			return nil, err
+		}
+	}
+	return roles, nil
+}
+
+// ListRoleUsers lists the users a role is assigned to directly. Members of
+// groups holding the role are not included.
+func (s *RBACService) ListRoleUsers(ctx context.Context, roleID uuid.UUID, offset, limit int) ([]domain.User, int64, error) {
+	if _, err := s.roles.GetByID(ctx, roleID); err != nil {
+		return nil, 0, err
+	}
+
+	return s.roles.ListUsersWithRole(ctx, roleID, offset, limit)
+}
+
+// loadAssignments sets the assignment counts of roles.
+func (s *RBACService) loadAssignments(ctx context.Context, roles []domain.Role) error {
+	if len(roles) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(roles))
+	for i := range roles {
+		ids[i] = roles[i].ID
+	}
+
+	counts, err := s.roles.CountAssignments(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range roles {
+		c := counts[roles[i].ID]
+		roles[i].Assignments = &c
+	}
+	return nil
 }
 
-func (s *RBACService) UpdateRole(ctx context.Context, id uuid.UUID, name, description string) (*domain.Role, error) {
+type UpdateRoleInput struct {
+	Name        *string
+	Description *string
+}
+
+func (s *RBACService) UpdateRole(ctx context.Context, id uuid.UUID, input UpdateRoleInput) (*domain.Role, error) {
 	role, err := s.roles.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	role.Name = name
-	role.Description = description
+	if input.Name != nil {
+		role.Name = *input.Name
+	}
+	if input.Description != nil {
+		role.Description = *input.Description
+	}
 
 	if err := role.Validate(); err != nil {
 		return nil, err
@@ -74,14 +174,34 @@ func (s *RBACService) UpdateRole(ctx context.Context, id uuid.UUID, name, descri
 		return nil, err
 	}
 
+	_ = s.publisher.Publish(ctx, domain.RoleChangedEvent(domain.EventRoleUpdated, role))
+
 	return role, nil
 }
 
 func (s *RBACService) DeleteRole(ctx context.Context, id uuid.UUID) error {
-	return s.roles.Delete(ctx, id)
+	role, err := s.roles.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.roles.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.RoleChangedEvent(domain.EventRoleDeleted, role))
+
+	return nil
 }
 
-func (s *RBACService) AssignRole(ctx context.Context, userID, roleID uuid.UUID) error {
+// AssignRole assigns a role to a user until expiresAt, or until it's
+// removed if expiresAt is nil. Assigning a role the user already holds
+// replaces its expiry.
+func (s *RBACService) AssignRole(ctx context.Context, userID, roleID uuid.UUID, expiresAt *time.Time) error {
+	if expiresAt != nil && !expiresAt.After(domain.Now()) {
+		return domain.ValidationError{Field: "expires_at", Message: "must be in the future"}
+	}
+
 	// TODO: find better way to check user exists or not
 	if _, err := s.users.GetByID(ctx, userID); err != nil {
 		return err
@@ -93,11 +213,22 @@ func (s *RBACService) AssignRole(ctx context.Context, userID, roleID uuid.UUID)
 		return err
 	}
 
-	if err := s.roles.AssignRole(ctx, userID, roleID); err != nil {
+	// Organization roles can only be held by members of that organization
+	if role.OrganizationID != nil {
+		member, err := s.orgs.IsMember(ctx, *role.OrganizationID, userID)
+		if err != nil {
+			return err
+		}
+		if !member {
+			return domain.ValidationError{Field: "role_id", Message: "user is not a member of the role's organization"}
+		}
+	}
+
+	if err := s.roles.AssignRole(ctx, userID, roleID, expiresAt); err != nil {
 		return err
 	}
 
-	_ = s.publisher.Publish(ctx, domain.RoleAssignedEvent(userID, role.Name))
+	_ = s.publisher.Publish(ctx, domain.RoleAssignedEvent(userID, role.Name, expiresAt))
 
 	return nil
 }
@@ -118,10 +249,31 @@ func (s *RBACService) RemoveRole(ctx context.Context, userID, roleID uuid.UUID)
 	return nil
 }
 
+// ExpireRoles removes the role assignments that have lapsed and publishes
+// an event for each. Expired assignments already grant nothing, so this
+// only tidies up and tells subscribers.
+func (s *RBACService) ExpireRoles(ctx context.Context) (int64, error) {
+	expired, err := s.roles.DeleteExpiredAssignments(ctx, domain.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	for _, e := range expired {
+		_ = s.publisher.Publish(ctx, domain.RoleExpiredEvent(e))
+	}
+
+	return int64(len(expired)), nil
+}
+
 func (s *RBACService) GetUserRoles(ctx context.Context, userID uuid.UUID) ([]domain.Role, error) {
 	return s.roles.GetUserRoles(ctx, userID)
 }
 
+// GetEffectiveUserRoles returns the user's roles including those inherited from groups.
+func (s *RBACService) GetEffectiveUserRoles(ctx context.Context, userID uuid.UUID) ([]domain.Role, error) {
+	return s.roles.GetEffectiveUserRoles(ctx, userID)
+}
+
 func (s *RBACService) CreatePermission(ctx context.Context, resource, action, description string) (*domain.Permission, error) {
 	perm, err := domain.NewPermission(resource, action, description)
 	if err != nil {
@@ -132,15 +284,25 @@ func (s *RBACService) CreatePermission(ctx context.Context, resource, action, de
 		return nil, err
 	}
 
+	_ = s.publisher.Publish(ctx, domain.PermissionChangedEvent(domain.EventPermissionCreated, perm))
+
 	return perm, nil
 }
 
-func (s *RBACService) ListPermissions(ctx context.Context) ([]domain.Permission, error) {
-	return s.permissions.List(ctx)
+// ListPermissions lists a page of permissions, ordered by resource and
+// action, and the total number matching filter.
+func (s *RBACService) ListPermissions(ctx context.Context, filter storage.PermissionFilter) ([]domain.Permission, int64, error) {
+	filter.Resource = strings.ToLower(strings.TrimSpace(filter.Resource))
+	filter.Search = strings.TrimSpace(filter.Search)
+	return s.permissions.Search(ctx, filter)
 }
 
-func (s *RBACService) AddPermissionToRole(ctx context.Context, roleID, permissionID uuid.UUID) error {
-	if _, err := s.roles.GetByID(ctx, roleID); err != nil {
+// AddPermissionToRole grants the permission to the role, or with deny
+// makes the role deny it. Adding a permission the role already has switches
+// it between the two.
+func (s *RBACService) AddPermissionToRole(ctx context.Context, roleID, permissionID uuid.UUID, deny bool) error {
+	role, err := s.roles.GetByID(ctx, roleID)
+	if err != nil {
 		return err
 	}
 
@@ -148,26 +310,54 @@ func (s *RBACService) AddPermissionToRole(ctx context.Context, roleID, permissio
 		return err
 	}
 
-	return s.permissions.AssignToRole(ctx, roleID, permissionID)
+	if err := s.permissions.AssignToRole(ctx, roleID, permissionID, deny); err != nil {
+		return err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.RoleChangedEvent(domain.EventRoleUpdated, role))
+
+	return nil
 }
 
 func (s *RBACService) RemovePermissionFromRole(ctx context.Context, roleID, permissionID uuid.UUID) error {
-	return s.permissions.RemoveFromRole(ctx, roleID, permissionID)
+	role, err := s.roles.GetByID(ctx, roleID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.permissions.RemoveFromRole(ctx, roleID, permissionID); err != nil {
+		return err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.RoleChangedEvent(domain.EventRoleUpdated, role))
+
+	return nil
 }
 
-func (s *RBACService) CheckPermission(ctx context.Context, userID uuid.UUID, resource, action string) (bool, error) {
-	roles, err := s.roles.GetUserRoles(ctx, userID)
+// CheckPermission reports whether the user may perform action on resource,
+// or with a resourceID on that one instance of it. The user's global roles,
+// held directly or through a group, decide first: a denial refuses and a
+// grant allows. Otherwise a grant on the instance to the user or one of
+// their groups allows.
+func (s *RBACService) CheckPermission(ctx context.Context, userID uuid.UUID, resource, resourceID, action string) (bool, error) {
+	roles, err := s.roles.GetEffectiveUserRoles(ctx, userID)
 	if err != nil {
 		return false, err
 	}
 
-	for _, role := range roles {
-		if role.HasPermission(resource, action) {
-			return true, nil
-		}
+	user := domain.User{Roles: roles}
+	perms := user.AllPermissions()
+	if domain.Denies(perms, resource, action) {
+		return false, nil
+	}
+	if domain.Allows(perms, resource, action) {
+		return true, nil
+	}
+	if resourceID == "" {
+		return false, nil
 	}
 
-	return false, nil
+	return s.grants.Allows(ctx, userID, resource, resourceID, action)
 }
 
 func (s *RBACService) GetPermission(ctx context.Context, id uuid.UUID) (*domain.Permission, error) {
@@ -175,5 +365,16 @@ func (s *RBACService) GetPermission(ctx context.Context, id uuid.UUID) (*domain.
 }
 
 func (s *RBACService) DeletePermission(ctx context.Context, id uuid.UUID) error {
-	return s.permissions.Delete(ctx, id)
+	perm, err := s.permissions.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.permissions.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.PermissionChangedEvent(domain.EventPermissionDeleted, perm))
+
+	return nil
 }
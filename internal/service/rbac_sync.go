@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// RBACSeed lists permissions and global roles that should exist. It is read
+// from JSON or YAML files kept in version control.
+type RBACSeed struct {
+	Permissions []PermissionSeed `json:"permissions" yaml:"permissions"`
+	Roles       []RoleSeed       `json:"roles" yaml:"roles"`
+}
+
+// PermissionSeed is a permission that should exist.
+type PermissionSeed struct {
+	Resource    string `json:"resource" yaml:"resource"`
+	Action      string `json:"action" yaml:"action"`
+	Description string `json:"description" yaml:"description"`
+}
+
+// RoleSeed is a global role that should exist, and the permissions it
+// should be granted and denied, as "resource:action".
+type RoleSeed struct {
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description" yaml:"description"`
+	Permissions []string `json:"permissions" yaml:"permissions"`
+	Deny        []string `json:"deny,omitempty" yaml:"deny,omitempty"`
+}
+
+// DefaultRBACSeed is the baseline every deployment needs: CreateUser gives
+// new users the user role, and the admin role can do everything.
+var DefaultRBACSeed = RBACSeed{
+	Permissions: []PermissionSeed{
+		{Resource: "users", Action: "*", Description: "All operations on users"},
+		{Resource: "roles", Action: "*", Description: "All operations on roles"},
+		{Resource: "permissions", Action: "*", Description: "All operations on permissions"},
+		{Resource: "users", Action: "read", Description: "View user information"},
+		{Resource: "users", Action: "read_basic", Description: "View users without their contact details"},
+	},
+	Roles: []RoleSeed{
+		{
+			Name:        "admin",
+			Description: "Full system administrator",
+			Permissions: []string{"users:*", "roles:*", "permissions:*"},
+		},
+		{
+			Name:        "user",
+			Description: "Standard user role",
+			Permissions: []string{"users:read"},
+		},
+	},
+}
+
+// SyncOptions controls how far Sync goes beyond creating what's missing.
+type SyncOptions struct {
+	// UpdateDescriptions overwrites the descriptions of existing
+	// permissions and roles with the seed's.
+	UpdateDescriptions bool
+
+	// Prune revokes grants the seed doesn't list from its roles and
+	// deletes global roles and permissions it doesn't list. Roles still
+	// held by users, groups or profiles, and permissions still granted by
+	// organization roles, are kept and reported in SyncResult.Skipped.
+	Prune bool
+}
+
+// SyncResult counts what Sync changed.
+type SyncResult struct {
+	PermissionsCreated int      `json:"permissions_created"`
+	PermissionsUpdated int      `json:"permissions_updated"`
+	PermissionsDeleted int      `json:"permissions_deleted"`
+	RolesCreated       int      `json:"roles_created"`
+	RolesUpdated       int      `json:"roles_updated"`
+	RolesDeleted       int      `json:"roles_deleted"`
+	PermissionsGranted int      `json:"permissions_granted"`
+	PermissionsRevoked int      `json:"permissions_revoked"`
+	Skipped            []string `json:"skipped,omitempty"`
+}
+
+// Changed reports whether Sync changed anything.
+func (r SyncResult) Changed() bool {
+	return r.PermissionsCreated+r.PermissionsUpdated+r.PermissionsDeleted+
+		r.RolesCreated+r.RolesUpdated+r.RolesDeleted+
+		r.PermissionsGranted+r.PermissionsRevoked > 0
+}
+
+// Seed creates the seed's permissions and roles that don't exist yet and
+// grants the roles any listed permissions they lack. Existing permissions,
+// roles and grants are left as they are, so seeding twice changes nothing.
+func (s *RBACService) Seed(ctx context.Context, seed RBACSeed) (SyncResult, error) {
+	return s.Sync(ctx, seed, SyncOptions{})
+}
+
+// Sync reconciles the stored permissions and global roles with seed: it
+// creates what's missing and grants the listed permissions, and with opts
+// also updates descriptions and removes what the seed doesn't list.
+// Organization roles are never touched. Run it in a transaction to apply
+// all or nothing.
+func (s *RBACService) Sync(ctx context.Context, seed RBACSeed, opts SyncOptions) (SyncResult, error) {
+	var result SyncResult
+
+	existing, err := s.permissions.List(ctx)
+	if err != nil {
+		return result, err
+	}
+	perms := make(map[string]*domain.Permission, len(existing))
+	for i := range existing {
+		perms[existing[i].String()] = &existing[i]
+	}
+
+	listed := make(map[string]bool, len(seed.Permissions))
+	for _, p := range seed.Permissions {
+		want, err := domain.NewPermission(p.Resource, p.Action, p.Description)
+		if err != nil {
+			return result, err
+		}
+		listed[want.String()] = true
+
+		if perm, ok := perms[want.String()]; ok {
+			if opts.UpdateDescriptions && perm.Description != want.Description {
+				perm.Description = want.Description
+				if err := s.permissions.Update(ctx, perm); err != nil {
+					return result, err
+				}
+				result.PermissionsUpdated++
+			}
+			continue
+		}
+
+		err = s.permissions.Create(ctx, want)
+		if errors.Is(err, domain.ErrAlreadyExists) {
+			// Created concurrently, e.g. by another replica
+			if want, err = s.permissions.GetByResourceAction(ctx, want.Resource, want.Action); err != nil {
+				return result, err
+			}
+		} else if err != nil {
+			return result, err
+		} else {
+			result.PermissionsCreated++
+		}
+		perms[want.String()] = want
+	}
+
+	roleNames := make(map[string]bool, len(seed.Roles))
+	for _, r := range seed.Roles {
+		want, err := domain.NewRole(r.Name, r.Description)
+		if err != nil {
+			return result, err
+		}
+		roleNames[want.Name] = true
+
+		role, err := s.syncRole(ctx, want, opts, &result)
+		if err != nil {
+			return result, err
+		}
+
+		grants := normalizePermissionNames(r.Permissions)
+		denials := normalizePermissionNames(r.Deny)
+		for _, name := range denials {
+			if slices.Contains(grants, name) {
+				return result, domain.ValidationError{
+					Field:   "deny",
+					Message: fmt.Sprintf("role %s: %s is both granted and denied", role.Name, name),
+				}
+			}
+		}
+
+		for _, name := range grants {
+			if err := s.syncGrant(ctx, role, perms[name], name, false, &result); err != nil {
+				return result, err
+			}
+		}
+		for _, name := range denials {
+			if err := s.syncGrant(ctx, role, perms[name], name, true, &result); err != nil {
+				return result, err
+			}
+		}
+
+		if opts.Prune {
+			for _, perm := range role.Permissions {
+				if slices.Contains(grants, perm.String()) || slices.Contains(denials, perm.String()) {
+					continue
+				}
+				if err := s.permissions.RemoveFromRole(ctx, role.ID, perm.ID); err != nil {
+					return result, err
+				}
+				result.PermissionsRevoked++
+			}
+		}
+	}
+
+	if !opts.Prune {
+		return result, nil
+	}
+
+	roles, err := s.roles.List(ctx, storage.RoleFilter{})
+	if err != nil {
+		return result, err
+	}
+	for _, role := range roles {
+		if !role.IsGlobal() || roleNames[role.Name] {
+			continue
+		}
+		err := s.roles.Delete(ctx, role.ID)
+		if errors.Is(err, domain.ErrConflict) {
+			result.Skipped = append(result.Skipped, "role "+role.Name+": still assigned")
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+		result.RolesDeleted++
+	}
+
+	for name, perm := range perms {
+		if listed[name] {
+			continue
+		}
+		err := s.permissions.Delete(ctx, perm.ID)
+		if errors.Is(err, domain.ErrConflict) {
+			result.Skipped = append(result.Skipped, "permission "+name+": still granted")
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+		result.PermissionsDeleted++
+	}
+	slices.Sort(result.Skipped)
+
+	return result, nil
+}
+
+func normalizePermissionNames(names []string) []string {
+	out := make([]string, len(names))
+	for i, name := range names {
+		out[i] = strings.ToLower(strings.TrimSpace(name))
+	}
+	return out
+}
+
+// syncGrant makes role grant perm, or deny it with deny, unless it already
+// does.
+func (s *RBACService) syncGrant(ctx context.Context, role *domain.Role, perm *domain.Permission, name string, deny bool, result *SyncResult) error {
+	for _, p := range role.Permissions {
+		if p.String() == name && p.Deny == deny {
+			return nil
+		}
+	}
+	if perm == nil {
+		return domain.ValidationError{
+			Field:   "permissions",
+			Message: fmt.Sprintf("role %s: unknown permission %q (want resource:action)", role.Name, name),
+		}
+	}
+	if err := s.permissions.AssignToRole(ctx, role.ID, perm.ID, deny); err != nil {
+		return err
+	}
+	granted := *perm
+	granted.Deny = deny
+	role.AddPermission(granted)
+	result.PermissionsGranted++
+	return nil
+}
+
+// syncRole returns the global role named like want, creating it if missing
+// and updating its description if asked to.
+func (s *RBACService) syncRole(ctx context.Context, want *domain.Role, opts SyncOptions, result *SyncResult) (*domain.Role, error) {
+	role, err := s.roles.GetByName(ctx, want.Name)
+	if errors.Is(err, domain.ErrNotFound) {
+		err = s.roles.Create(ctx, want)
+		if err == nil {
+			result.RolesCreated++
+			return want, nil
+		}
+		if errors.Is(err, domain.ErrAlreadyExists) {
+			role, err = s.roles.GetByName(ctx, want.Name)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.UpdateDescriptions && role.Description != want.Description {
+		role.Description = want.Description
+		if err := s.roles.Update(ctx, role); err != nil {
+			return nil, err
+		}
+		result.RolesUpdated++
+	}
+	return role, nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/encryption"
+)
+
+// ReencryptProgress reports a re-encryption pass.
+type ReencryptProgress struct {
+	CurrentKeyID string           `json:"current_key_id"` // Empty when secrets are stored in the clear
+	Reencrypted  int              `json:"reencrypted"`
+	Failed       int              `json:"failed"`           // Not decryptable with the key they claim
+	ByKey        map[string]int64 `json:"by_key,omitempty"` // Secrets per key ID, once the pass is done
+}
+
+// Done reports whether every secret is under the current key.
+func (p ReencryptProgress) Done() bool {
+	for keyID, count := range p.ByKey {
+		if keyID != p.CurrentKeyID && count > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ReencryptSecrets moves webhook secrets under old keys to the current key
+// in batches of batchSize, calling progress after each batch if it's not
+// nil. Rows are marked with their key as they're rewritten, so a pass that
+// is interrupted picks up where it stopped when run again. Secrets under
+// keys that are no longer loaded are left as they are and show up in
+// ByKey.
+func (s *WebhookService) ReencryptSecrets(ctx context.Context, batchSize int, progress func(ReencryptProgress)) (ReencryptProgress, error) {
+	p := ReencryptProgress{CurrentKeyID: encryption.CurrentKeyID()}
+
+	after := uuid.Nil
+	for {
+		batch, err := s.webhooks.ReencryptSecrets(ctx, after, batchSize)
+		p.Reencrypted += batch.Reencrypted
+		p.Failed += batch.Failed
+		if err != nil {
+			return p, err
+		}
+		if batch.Scanned == 0 {
+			break
+		}
+		after = batch.Last
+		if progress != nil {
+			progress(p)
+		}
+	}
+
+	byKey, err := s.webhooks.CountSecretsByKey(ctx)
+	if err != nil {
+		return p, err
+	}
+	p.ByKey = byKey
+
+	return p, nil
+}
+
+// SecretKeyStatus counts webhook secrets per key ID without changing any.
+func (s *WebhookService) SecretKeyStatus(ctx context.Context) (ReencryptProgress, error) {
+	byKey, err := s.webhooks.CountSecretsByKey(ctx)
+	if err != nil {
+		return ReencryptProgress{}, err
+	}
+	return ReencryptProgress{CurrentKeyID: encryption.CurrentKeyID(), ByKey: byKey}, nil
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/risk"
+)
+
+// enforceRisk evaluates signal and converts the recommended action into a
+// domain error. Flagged operations are published as events so they can be
+// audited and alerted on.
+func enforceRisk(ctx context.Context, engine risk.Engine, publisher event.Publisher, signal risk.Signal) error {
+	if signal.Timestamp.IsZero() {
+		signal.Timestamp = domain.Now()
+	}
+
+	assessment, err := engine.Evaluate(ctx, signal)
+	if err != nil {
+		return err
+	}
+
+	if assessment.Action == risk.ActionAllow {
+		return nil
+	}
+
+	_ = publisher.Publish(ctx, domain.RiskFlaggedEvent(
+		signal.UserID,
+		string(signal.Operation),
+		string(assessment.Action),
+		assessment.Score,
+		assessment.Reasons,
+	))
+
+	if assessment.Action == risk.ActionBlock {
+		return domain.ErrForbidden
+	}
+	return domain.ErrChallengeRequired
+}
@@ -0,0 +1,437 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/saml"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// SAMLService signs users in through SAML identity providers. Their account
+// is created on their first sign-in, and the roles the groups their
+// assertion lists map to are brought in line with it on each one.
+//
+// Each provider has its own service provider endpoints under baseURL:
+// metadata, which is also the entity ID, login and the assertion consumer
+// service.
+type SAMLService struct {
+	providers storage.SAMLProviderRepository
+	roles     storage.RoleRepository
+	auth      *AuthService
+	accounts  *federation
+	baseURL   string
+	clockSkew time.Duration
+
+	// Assertions used to sign in, until they expire, so none is replayed.
+	// Per process: with several replicas, one may still be replayed
+	// against another before it expires.
+	mu   sync.Mutex
+	used map[string]time.Time
+}
+
+func NewSAMLService(
+	providers storage.SAMLProviderRepository,
+	users storage.UserRepository,
+	roles storage.RoleRepository,
+	identities storage.FederatedIdentityRepository,
+	rbac *RBACService,
+	auth *AuthService,
+	suggester *UsernameSuggester,
+	publisher event.Publisher,
+	userType domain.UserType,
+	baseURL string,
+	clockSkew time.Duration,
+) *SAMLService {
+	return &SAMLService{
+		providers: providers,
+		roles:     roles,
+		auth:      auth,
+		accounts: &federation{
+			users:      users,
+			roles:      roles,
+			identities: identities,
+			rbac:       rbac,
+			suggester:  suggester,
+			publisher:  publisher,
+			userType:   userType,
+		},
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		clockSkew: clockSkew,
+		used:      make(map[string]time.Time),
+	}
+}
+
+// serviceProvider returns aegis as the service provider of p.
+func (s *SAMLService) serviceProvider(p *domain.SAMLProvider) (*saml.ServiceProvider, error) {
+	certs, err := saml.ParseCertificates(p.Certificate)
+	if err != nil {
+		return nil, err
+	}
+	base := s.baseURL + "/" + p.Slug
+	return &saml.ServiceProvider{
+		EntityID:        base + "/metadata",
+		ACSURL:          base + "/acs",
+		IdPEntityID:     p.EntityID,
+		IdPSSOURL:       p.SSOURL,
+		IdPCertificates: certs,
+		ClockSkew:       s.clockSkew,
+	}, nil
+}
+
+// enabledProvider returns the enabled provider with the slug. Returns
+// ErrNotFound if there's none.
+func (s *SAMLService) enabledProvider(ctx context.Context, slug string) (*domain.SAMLProvider, *saml.ServiceProvider, error) {
+	provider, err := s.providers.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !provider.Enabled {
+		return nil, nil, domain.ErrNotFound
+	}
+	sp, err := s.serviceProvider(provider)
+	if err != nil {
+		return nil, nil, err
+	}
+	return provider, sp, nil
+}
+
+// Metadata returns the service provider metadata to import into the
+// identity provider with the slug. It's served for disabled providers too,
+// so they can be set up before users sign in.
+func (s *SAMLService) Metadata(ctx context.Context, slug string) ([]byte, error) {
+	provider, err := s.providers.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	sp, err := s.serviceProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+	return sp.Metadata(), nil
+}
+
+// LoginURL returns where to send the user to sign in with the provider.
+// relayState comes back with the response.
+func (s *SAMLService) LoginURL(ctx context.Context, slug, relayState string) (string, error) {
+	_, sp, err := s.enabledProvider(ctx, slug)
+	if err != nil {
+		return "", err
+	}
+	return sp.AuthnRequestURL(relayState, domain.Now())
+}
+
+// Login signs in the user of a SAML response the provider posted, as
+// Login does once the password is verified, to the account linked to the
+// assertion's NameID (see federation). Returns ErrInvalidCredential if the
+// response isn't a valid, unused assertion from the provider, lacks an
+// email, or may not sign in to the account with its email.
+func (s *SAMLService) Login(ctx context.Context, slug, samlResponse string, input LoginInput) (*LoginResult, error) {
+	provider, sp, err := s.enabledProvider(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	now := domain.Now()
+	assertion, err := sp.ParseResponse(samlResponse, now)
+	if err != nil {
+		if errors.Is(err, saml.ErrInvalidResponse) || errors.Is(err, saml.ErrInvalidSignature) {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidCredential, err)
+		}
+		return nil, err
+	}
+	if !s.use(provider.ID.String()+" "+assertion.ID, assertion.NotOnOrAfter, now) {
+		return nil, fmt.Errorf("%w: assertion %s was already used", domain.ErrInvalidCredential, assertion.ID)
+	}
+
+	email := assertion.NameID
+	if provider.EmailAttribute != "" {
+		email = assertion.Attribute(provider.EmailAttribute)
+	}
+	if email == "" {
+		return nil, fmt.Errorf("%w: the assertion has no email", domain.ErrInvalidCredential)
+	}
+	var fullName string
+	if provider.NameAttribute != "" {
+		fullName = assertion.Attribute(provider.NameAttribute)
+	}
+
+	if assertion.NameID == "" {
+		return nil, fmt.Errorf("%w: the assertion has no NameID", domain.ErrInvalidCredential)
+	}
+
+	user, err := s.accounts.account(ctx, identity{
+		provider: domain.SAMLIdentityProvider(provider.ID),
+		subject:  assertion.NameID,
+		domains:  provider.EmailDomains,
+		email:    email,
+		fullName: fullName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []string
+	if provider.GroupsAttribute != "" {
+		groups = assertion.Attributes[provider.GroupsAttribute]
+	}
+	if err := s.syncRoles(ctx, provider.ID, user.ID, groups); err != nil {
+		return nil, err
+	}
+
+	return s.auth.LoginAs(ctx, user, input)
+}
+
+// use records that an assertion was used, and reports whether it wasn't
+// before. Expired ones are forgotten.
+func (s *SAMLService) use(id string, expires, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for used, until := range s.used {
+		if now.After(until) {
+			delete(s.used, used)
+		}
+	}
+	if _, ok := s.used[id]; ok {
+		return false
+	}
+	s.used[id] = expires
+	return true
+}
+
+// syncRoles assigns the user the roles the provider maps their groups to
+// and removes the mapped roles they no longer do.
+func (s *SAMLService) syncRoles(ctx context.Context, providerID, userID uuid.UUID, groups []string) error {
+	mappings, err := s.providers.ListGroupRoles(ctx, providerID)
+	if err != nil || len(mappings) == 0 {
+		return err
+	}
+
+	member := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		member[group] = true
+	}
+
+	managed := make(map[uuid.UUID]bool)
+	wanted := make(map[uuid.UUID]bool)
+	for _, m := range mappings {
+		managed[m.RoleID] = true
+		if member[m.Group] {
+			wanted[m.RoleID] = true
+		}
+	}
+
+	return s.accounts.syncRoles(ctx, userID, managed, wanted)
+}
+
+// ListProviders returns every provider, ordered by slug.
+func (s *SAMLService) ListProviders(ctx context.Context) ([]domain.SAMLProvider, error) {
+	return s.providers.List(ctx)
+}
+
+func (s *SAMLService) GetProvider(ctx context.Context, id uuid.UUID) (*domain.SAMLProvider, error) {
+	return s.providers.GetByID(ctx, id)
+}
+
+type CreateSAMLProviderInput struct {
+	Slug            string
+	Name            string
+	EntityID        string
+	SSOURL          string
+	Certificate     string
+	EmailAttribute  string
+	NameAttribute   string
+	GroupsAttribute string
+	EmailDomains    []string
+}
+
+func (s *SAMLService) CreateProvider(ctx context.Context, input CreateSAMLProviderInput) (*domain.SAMLProvider, error) {
+	provider, err := domain.NewSAMLProvider(input.Slug, input.Name, input.EntityID, input.SSOURL, input.Certificate)
+	if err != nil {
+		return nil, err
+	}
+	provider.EmailAttribute = strings.TrimSpace(input.EmailAttribute)
+	provider.NameAttribute = strings.TrimSpace(input.NameAttribute)
+	provider.GroupsAttribute = strings.TrimSpace(input.GroupsAttribute)
+	if provider.EmailDomains, err = domain.NormalizeEmailDomains("email_domains", input.EmailDomains); err != nil {
+		return nil, err
+	}
+
+	if err := validateSAMLProvider(provider); err != nil {
+		return nil, err
+	}
+
+	if err := s.providers.Create(ctx, provider); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+type UpdateSAMLProviderInput struct {
+	Slug            *string
+	Name            *string
+	EntityID        *string
+	SSOURL          *string
+	Certificate     *string
+	EmailAttribute  *string
+	NameAttribute   *string
+	GroupsAttribute *string
+	EmailDomains    *[]string
+	Enabled         *bool
+}
+
+// UpdateProvider changes a provider. Changing its slug changes its service
+// provider endpoints, which the identity provider must be told of.
+func (s *SAMLService) UpdateProvider(ctx context.Context, id uuid.UUID, input UpdateSAMLProviderInput) (*domain.SAMLProvider, error) {
+	provider, err := s.providers.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Slug != nil {
+		provider.Slug = strings.ToLower(strings.TrimSpace(*input.Slug))
+	}
+
+	if input.Name != nil {
+		provider.Name = strings.TrimSpace(*input.Name)
+	}
+
+	if input.EntityID != nil {
+		provider.EntityID = strings.TrimSpace(*input.EntityID)
+	}
+
+	if input.SSOURL != nil {
+		provider.SSOURL = strings.TrimSpace(*input.SSOURL)
+	}
+
+	if input.Certificate != nil {
+		provider.Certificate = strings.TrimSpace(*input.Certificate)
+	}
+
+	if input.EmailAttribute != nil {
+		provider.EmailAttribute = strings.TrimSpace(*input.EmailAttribute)
+	}
+
+	if input.NameAttribute != nil {
+		provider.NameAttribute = strings.TrimSpace(*input.NameAttribute)
+	}
+
+	if input.GroupsAttribute != nil {
+		provider.GroupsAttribute = strings.TrimSpace(*input.GroupsAttribute)
+	}
+
+	if input.EmailDomains != nil {
+		if provider.EmailDomains, err = domain.NormalizeEmailDomains("email_domains", *input.EmailDomains); err != nil {
+			return nil, err
+		}
+	}
+
+	if input.Enabled != nil {
+		provider.Enabled = *input.Enabled
+	}
+
+	if err := validateSAMLProvider(provider); err != nil {
+		return nil, err
+	}
+
+	if err := s.providers.Update(ctx, provider); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// validateSAMLProvider validates a provider, including that its
+// certificates can verify signatures.
+func validateSAMLProvider(provider *domain.SAMLProvider) error {
+	if err := provider.Validate(); err != nil {
+		return err
+	}
+	if _, err := saml.ParseCertificates(provider.Certificate); err != nil {
+		return domain.ValidationError{Field: "certificate", Message: "must be PEM certificates with RSA keys"}
+	}
+	return nil
+}
+
+// DeleteProvider removes a provider, its group mappings and its links.
+// Users keep their accounts and roles.
+func (s *SAMLService) DeleteProvider(ctx context.Context, id uuid.UUID) error {
+	if err := s.providers.Delete(ctx, id); err != nil {
+		return err
+	}
+	return s.accounts.identities.DeleteAll(ctx, domain.SAMLIdentityProvider(id))
+}
+
+// ListGroupRoles returns a provider's group mappings, ordered by group.
+func (s *SAMLService) ListGroupRoles(ctx context.Context, providerID uuid.UUID) ([]domain.SAMLGroupRole, error) {
+	if _, err := s.providers.GetByID(ctx, providerID); err != nil {
+		return nil, err
+	}
+	return s.providers.ListGroupRoles(ctx, providerID)
+}
+
+// CreateGroupRole gives the users whose assertion from the provider lists
+// group the role, from their next sign-in.
+func (s *SAMLService) CreateGroupRole(ctx context.Context, providerID uuid.UUID, group string, roleID uuid.UUID) (*domain.SAMLGroupRole, error) {
+	mapping, err := domain.NewSAMLGroupRole(providerID, group, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.providers.GetByID(ctx, providerID); err != nil {
+		return nil, err
+	}
+
+	role, err := s.roles.GetByID(ctx, roleID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, domain.ValidationError{Field: "role_id", Message: "role not found"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	mapping.RoleName = role.Name
+
+	if err := s.providers.CreateGroupRole(ctx, mapping); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+// DeleteGroupRole removes a group mapping. Users keep the role, which is
+// no longer the provider's to remove, unless another mapping names it.
+func (s *SAMLService) DeleteGroupRole(ctx context.Context, providerID, id uuid.UUID) error {
+	return s.providers.DeleteGroupRole(ctx, providerID, id)
+}
+
+// ListIdentities returns the accounts the provider's subjects are linked
+// to, ordered by NameID.
+func (s *SAMLService) ListIdentities(ctx context.Context, providerID uuid.UUID) ([]domain.FederatedIdentity, error) {
+	if _, err := s.providers.GetByID(ctx, providerID); err != nil {
+		return nil, err
+	}
+	return s.accounts.identities.List(ctx, domain.SAMLIdentityProvider(providerID))
+}
+
+// LinkIdentity has the provider's subject with the NameID sign in to the
+// user's account, for accounts sign-in won't link itself. Returns
+// ErrAlreadyExists if the subject is linked already.
+func (s *SAMLService) LinkIdentity(ctx context.Context, providerID uuid.UUID, nameID string, userID uuid.UUID) (*domain.FederatedIdentity, error) {
+	if _, err := s.providers.GetByID(ctx, providerID); err != nil {
+		return nil, err
+	}
+	return s.accounts.linkAccount(ctx, domain.SAMLIdentityProvider(providerID), nameID, userID)
+}
+
+// UnlinkIdentity removes one of the provider's links. The subject's next
+// sign-in is treated as a first one.
+func (s *SAMLService) UnlinkIdentity(ctx context.Context, providerID, id uuid.UUID) error {
+	return s.accounts.identities.Delete(ctx, domain.SAMLIdentityProvider(providerID), id)
+}
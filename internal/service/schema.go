@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+
+	"github.com/mvaleed/aegis/internal/schema"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// Readiness reports whether the database schema suits this binary.
+type Readiness struct {
+	Ready      bool   `json:"ready"`
+	Version    uint   `json:"schema_version"`
+	Required   uint   `json:"required_version"`
+	Latest     uint   `json:"latest_version"` // Newest migration this binary knows
+	Contracted uint   `json:"contracted_version,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// SchemaService compares the database schema with the migrations the
+// binary was built with.
+type SchemaService struct {
+	repo       storage.SchemaRepository
+	migrations []schema.Migration
+}
+
+// NewSchemaService creates a schema service for a binary built with
+// migrations.
+func NewSchemaService(repo storage.SchemaRepository, migrations []schema.Migration) *SchemaService {
+	return &SchemaService{repo: repo, migrations: migrations}
+}
+
+// Readiness reads the schema state. An error means the database couldn't
+// be asked; a schema that doesn't suit the binary is reported in the
+// result.
+func (s *SchemaService) Readiness(ctx context.Context) (Readiness, error) {
+	state, err := s.repo.State(ctx)
+	if err != nil {
+		return Readiness{}, err
+	}
+
+	r := Readiness{
+		Ready:      true,
+		Version:    state.Version,
+		Required:   schema.Required(s.migrations),
+		Latest:     schema.Latest(s.migrations),
+		Contracted: state.Contracted,
+	}
+	if err := schema.Ready(s.migrations, state); err != nil {
+		r.Ready = false
+		r.Reason = err.Error()
+	}
+	return r, nil
+}
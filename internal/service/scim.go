@@ -0,0 +1,656 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// scimPageSize is the most users or groups returned per page, and the
+// page size members are read in.
+const scimPageSize = 100
+
+// SCIMUser is a provisioned user with the ID their identity provider
+// knows them by, if it gave one.
+type SCIMUser struct {
+	*domain.User
+	ExternalID string
+}
+
+// SCIMGroup is a provisioned group with its external ID and, when asked
+// for, its members.
+type SCIMGroup struct {
+	*domain.Group
+	ExternalID string
+	Members    []uuid.UUID
+}
+
+// SCIMUserInput holds the attributes an identity provider sets on a user.
+// Nil fields are left as they are; Email is required on creation and
+// can't be changed afterwards.
+type SCIMUserInput struct {
+	Email      *string
+	FullName   *string
+	Phone      *string
+	Type       *domain.UserType
+	Password   *string
+	Active     *bool
+	ExternalID *string
+}
+
+// SCIMGroupInput holds the attributes an identity provider sets on a
+// group. Nil fields are left as they are; Members replaces the members,
+// while AddMembers and RemoveMembers change them.
+type SCIMGroupInput struct {
+	Name          *string
+	ExternalID    *string
+	Members       *[]uuid.UUID
+	AddMembers    []uuid.UUID
+	RemoveMembers []uuid.UUID
+}
+
+// SCIMUserFilter selects users by email or external ID, or neither.
+type SCIMUserFilter struct {
+	Email      string
+	ExternalID string
+	Offset     int
+	Limit      int
+}
+
+// SCIMGroupFilter selects groups by name or external ID, or neither.
+type SCIMGroupFilter struct {
+	Name       string
+	ExternalID string
+	Offset     int
+	Limit      int
+}
+
+// SCIMService provisions users and groups on behalf of an identity
+// provider such as Okta or Azure AD. Provisioned users have no password
+// unless the provider sets one, and deprovisioning them signs them out.
+type SCIMService struct {
+	scim         storage.SCIMRepository
+	users        storage.UserRepository
+	roles        storage.RoleRepository
+	groups       storage.GroupRepository
+	userService  *UserService
+	groupService *GroupService
+	auth         *AuthService
+	suggester    *UsernameSuggester
+	publisher    event.Publisher
+	tx           storage.Transactor
+}
+
+func NewSCIMService(
+	scim storage.SCIMRepository,
+	users storage.UserRepository,
+	roles storage.RoleRepository,
+	groups storage.GroupRepository,
+	userService *UserService,
+	groupService *GroupService,
+	auth *AuthService,
+	suggester *UsernameSuggester,
+	publisher event.Publisher,
+	tx storage.Transactor,
+) *SCIMService {
+	return &SCIMService{
+		scim:         scim,
+		users:        users,
+		roles:        roles,
+		groups:       groups,
+		userService:  userService,
+		groupService: groupService,
+		auth:         auth,
+		suggester:    suggester,
+		publisher:    publisher,
+		tx:           tx,
+	}
+}
+
+// CreateUser provisions a user, active unless input says otherwise. The
+// username is derived from the email.
+func (s *SCIMService) CreateUser(ctx context.Context, input SCIMUserInput) (*SCIMUser, error) {
+	if input.Email == nil || strings.TrimSpace(*input.Email) == "" {
+		return nil, domain.ValidationError{Field: "userName", Message: "required"}
+	}
+	email := *input.Email
+
+	if _, err := s.users.GetByEmail(ctx, email); err == nil {
+		return nil, fmt.Errorf("%w: userName %q", domain.ErrAlreadyExists, email)
+	} else if !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+
+	local, _, _ := strings.Cut(strings.TrimSpace(email), "@")
+	fullName := local
+	if input.FullName != nil && strings.TrimSpace(*input.FullName) != "" {
+		fullName = *input.FullName
+	}
+	userType := domain.UserTypeCustomer
+	if input.Type != nil {
+		userType = *input.Type
+	}
+
+	username, err := s.suggester.FromEmail(ctx, email, fullName)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := domain.NewUser(email, username, fullName, userType)
+	if err != nil {
+		return nil, err
+	}
+	if input.Phone != nil && *input.Phone != "" {
+		if err := user.SetPhone(*input.Phone); err != nil {
+			return nil, err
+		}
+	}
+	if input.Active == nil || *input.Active {
+		if err := user.Activate(); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &SCIMUser{User: user}
+	err = s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.users.Create(ctx, user); err != nil {
+			if errors.Is(err, domain.ErrAlreadyExists) {
+				return fmt.Errorf("%w: userName %q", domain.ErrAlreadyExists, email)
+			}
+			return err
+		}
+
+		if role, err := s.roles.GetByName(ctx, "user"); err == nil {
+			_ = s.roles.AssignRole(ctx, user.ID, role.ID, nil)
+		}
+
+		if input.Password != nil {
+			if err := s.userService.ResetPassword(ctx, user.ID, *input.Password); err != nil {
+				return err
+			}
+		}
+
+		if input.ExternalID != nil {
+			if err := s.setExternalID(ctx, domain.SCIMUser, user.ID, *input.ExternalID); err != nil {
+				return err
+			}
+			result.ExternalID = *input.ExternalID
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created := domain.UserCreatedEvent(user)
+	created.Data["provisioned"] = true
+	_ = s.publisher.Publish(ctx, created)
+
+	return s.GetUser(ctx, user.ID)
+}
+
+// GetUser retrieves a provisioned user.
+func (s *SCIMService) GetUser(ctx context.Context, id uuid.UUID) (*SCIMUser, error) {
+	user, err := s.users.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	externalIDs, err := s.scim.ExternalIDs(ctx, domain.SCIMUser, []uuid.UUID{id})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SCIMUser{User: user, ExternalID: externalIDs[id]}, nil
+}
+
+// ListUsers retrieves a page of users matching filter and the total
+// number matching.
+func (s *SCIMService) ListUsers(ctx context.Context, filter SCIMUserFilter) ([]SCIMUser, int64, error) {
+	var (
+		users []domain.User
+		total int64
+	)
+
+	switch {
+	case filter.Email != "" || filter.ExternalID != "":
+		user, err := s.findUser(ctx, filter)
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, 0, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		total = 1
+		if filter.Offset == 0 && filter.Limit > 0 {
+			users = []domain.User{*user}
+		}
+
+	case filter.Limit <= 0:
+		// A count of zero asks only for the total
+		_, n, err := s.users.List(ctx, storage.UserFilter{Limit: 1})
+		if err != nil {
+			return nil, 0, err
+		}
+		total = n
+
+	default:
+		var err error
+		users, total, err = s.users.List(ctx, storage.UserFilter{
+			Offset: filter.Offset,
+			Limit:  min(filter.Limit, scimPageSize),
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	ids := make([]uuid.UUID, len(users))
+	for i := range users {
+		ids[i] = users[i].ID
+	}
+	externalIDs, err := s.scim.ExternalIDs(ctx, domain.SCIMUser, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]SCIMUser, len(users))
+	for i := range users {
+		result[i] = SCIMUser{User: &users[i], ExternalID: externalIDs[users[i].ID]}
+	}
+	return result, total, nil
+}
+
+// findUser looks a user up by the filter's email, then its external ID.
+func (s *SCIMService) findUser(ctx context.Context, filter SCIMUserFilter) (*domain.User, error) {
+	var user *domain.User
+	if filter.Email != "" {
+		u, err := s.users.GetByEmail(ctx, filter.Email)
+		if err != nil {
+			return nil, err
+		}
+		user = u
+	}
+
+	if filter.ExternalID != "" {
+		id, err := s.scim.FindByExternalID(ctx, domain.SCIMUser, filter.ExternalID)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil && user.ID != id {
+			return nil, domain.ErrNotFound
+		}
+		if user == nil {
+			if user, err = s.users.GetByID(ctx, id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return user, nil
+}
+
+// UpdateUser changes the attributes set in input. Deactivating an active
+// user suspends them and revokes their sessions; activating one restores
+// them.
+func (s *SCIMService) UpdateUser(ctx context.Context, id uuid.UUID, input SCIMUserInput) (*SCIMUser, error) {
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		user, err := s.users.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if input.Email != nil && !strings.EqualFold(strings.TrimSpace(*input.Email), user.Email) {
+			return domain.ValidationError{Field: "userName", Message: "cannot be changed"}
+		}
+
+		if input.Type != nil && *input.Type != user.Type {
+			return domain.ValidationError{Field: "userType", Message: "cannot be changed"}
+		}
+
+		var update UpdateUserInput
+		if input.FullName != nil {
+			update.FullName = Set(*input.FullName)
+		}
+		if input.Phone != nil {
+			if *input.Phone == "" {
+				update.Phone = Clear[string]()
+			} else {
+				update.Phone = Set(*input.Phone)
+			}
+		}
+		if !update.FullName.IsIgnored() || !update.Phone.IsIgnored() {
+			if _, err := s.userService.UpdateUser(ctx, id, update); err != nil {
+				return err
+			}
+		}
+
+		if input.Password != nil {
+			if err := s.userService.ResetPassword(ctx, id, *input.Password); err != nil {
+				return err
+			}
+		}
+
+		if input.Active != nil {
+			switch {
+			case *input.Active && user.Status != domain.UserStatusActive:
+				if err := s.userService.ActivateUser(ctx, id); err != nil {
+					return err
+				}
+			case !*input.Active && user.Status == domain.UserStatusActive:
+				if err := s.userService.SuspendUser(ctx, id, "deprovisioned"); err != nil {
+					return err
+				}
+				if err := s.auth.LogoutAll(ctx, id); err != nil {
+					return err
+				}
+			}
+		}
+
+		if input.ExternalID != nil {
+			return s.setExternalID(ctx, domain.SCIMUser, id, *input.ExternalID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetUser(ctx, id)
+}
+
+// DeleteUser soft-deletes a user and revokes their sessions.
+func (s *SCIMService) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	return s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userService.DeleteUser(ctx, id); err != nil {
+			return err
+		}
+		if err := s.auth.LogoutAll(ctx, id); err != nil {
+			return err
+		}
+		return s.scim.DeleteExternalID(ctx, domain.SCIMUser, id)
+	})
+}
+
+// CreateGroup provisions a group with its members.
+func (s *SCIMService) CreateGroup(ctx context.Context, input SCIMGroupInput) (*SCIMGroup, error) {
+	if input.Name == nil {
+		return nil, domain.ValidationError{Field: "displayName", Message: "required"}
+	}
+
+	var group *domain.Group
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		group, err = s.groupService.CreateGroup(ctx, *input.Name, "")
+		if errors.Is(err, domain.ErrAlreadyExists) {
+			return fmt.Errorf("%w: displayName %q", domain.ErrAlreadyExists, *input.Name)
+		}
+		if err != nil {
+			return err
+		}
+
+		if input.ExternalID != nil {
+			if err := s.setExternalID(ctx, domain.SCIMGroup, group.ID, *input.ExternalID); err != nil {
+				return err
+			}
+		}
+		if input.Members != nil {
+			input.AddMembers = append(input.AddMembers, *input.Members...)
+		}
+		return s.addMembers(ctx, group.ID, input.AddMembers)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetGroup(ctx, group.ID, true)
+}
+
+// GetGroup retrieves a provisioned group, with its members if asked for.
+func (s *SCIMService) GetGroup(ctx context.Context, id uuid.UUID, members bool) (*SCIMGroup, error) {
+	group, err := s.groups.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := s.withSCIM(ctx, []domain.Group{*group}, members)
+	if err != nil {
+		return nil, err
+	}
+	return &groups[0], nil
+}
+
+// ListGroups retrieves a page of groups matching filter, with their
+// members if asked for, and the total number matching.
+func (s *SCIMService) ListGroups(ctx context.Context, filter SCIMGroupFilter, members bool) ([]SCIMGroup, int64, error) {
+	var (
+		groups []domain.Group
+		total  int64
+	)
+
+	switch {
+	case filter.Name != "" || filter.ExternalID != "":
+		group, err := s.findGroup(ctx, filter)
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, 0, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		total = 1
+		if filter.Offset == 0 && filter.Limit > 0 {
+			groups = []domain.Group{*group}
+		}
+
+	case filter.Limit <= 0:
+		_, n, err := s.groups.List(ctx, 0, 1)
+		if err != nil {
+			return nil, 0, err
+		}
+		total = n
+
+	default:
+		var err error
+		groups, total, err = s.groups.List(ctx, filter.Offset, min(filter.Limit, scimPageSize))
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	result, err := s.withSCIM(ctx, groups, members)
+	if err != nil {
+		return nil, 0, err
+	}
+	return result, total, nil
+}
+
+// findGroup looks a group up by the filter's name, then its external ID.
+func (s *SCIMService) findGroup(ctx context.Context, filter SCIMGroupFilter) (*domain.Group, error) {
+	var group *domain.Group
+	if filter.Name != "" {
+		g, err := s.groups.GetByName(ctx, filter.Name)
+		if err != nil {
+			return nil, err
+		}
+		group = g
+	}
+
+	if filter.ExternalID != "" {
+		id, err := s.scim.FindByExternalID(ctx, domain.SCIMGroup, filter.ExternalID)
+		if err != nil {
+			return nil, err
+		}
+		if group != nil && group.ID != id {
+			return nil, domain.ErrNotFound
+		}
+		if group == nil {
+			if group, err = s.groups.GetByID(ctx, id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return group, nil
+}
+
+// withSCIM adds the external IDs and, if asked for, the members of groups.
+func (s *SCIMService) withSCIM(ctx context.Context, groups []domain.Group, members bool) ([]SCIMGroup, error) {
+	ids := make([]uuid.UUID, len(groups))
+	for i := range groups {
+		ids[i] = groups[i].ID
+	}
+	externalIDs, err := s.scim.ExternalIDs(ctx, domain.SCIMGroup, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]SCIMGroup, len(groups))
+	for i := range groups {
+		result[i] = SCIMGroup{Group: &groups[i], ExternalID: externalIDs[groups[i].ID]}
+		if members {
+			if result[i].Members, err = s.members(ctx, groups[i].ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// members reads the IDs of all of a group's members.
+func (s *SCIMService) members(ctx context.Context, groupID uuid.UUID) ([]uuid.UUID, error) {
+	ids := []uuid.UUID{}
+	for {
+		page, total, err := s.groups.ListMembers(ctx, groupID, len(ids), scimPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range page {
+			ids = append(ids, m.UserID)
+		}
+		if len(page) == 0 || int64(len(ids)) >= total {
+			return ids, nil
+		}
+	}
+}
+
+// UpdateGroup changes the attributes set in input. Members are replaced
+// first, then added, then removed.
+func (s *SCIMService) UpdateGroup(ctx context.Context, id uuid.UUID, input SCIMGroupInput) (*SCIMGroup, error) {
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if input.Name != nil {
+			name := strings.ToLower(strings.TrimSpace(*input.Name))
+			_, err := s.groupService.UpdateGroup(ctx, id, UpdateGroupInput{Name: &name})
+			if errors.Is(err, domain.ErrAlreadyExists) {
+				return fmt.Errorf("%w: displayName %q", domain.ErrAlreadyExists, *input.Name)
+			}
+			if err != nil {
+				return err
+			}
+		} else if _, err := s.groups.GetByID(ctx, id); err != nil {
+			return err
+		}
+
+		if input.ExternalID != nil {
+			if err := s.setExternalID(ctx, domain.SCIMGroup, id, *input.ExternalID); err != nil {
+				return err
+			}
+		}
+
+		if input.Members != nil {
+			current, err := s.members(ctx, id)
+			if err != nil {
+				return err
+			}
+			for _, userID := range current {
+				if !slices.Contains(*input.Members, userID) {
+					input.RemoveMembers = append(input.RemoveMembers, userID)
+				}
+			}
+			for _, userID := range *input.Members {
+				if !slices.Contains(current, userID) {
+					input.AddMembers = append(input.AddMembers, userID)
+				}
+			}
+		}
+
+		if err := s.addMembers(ctx, id, input.AddMembers); err != nil {
+			return err
+		}
+		for _, userID := range input.RemoveMembers {
+			if err := s.groupService.RemoveMember(ctx, id, userID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetGroup(ctx, id, true)
+}
+
+// addMembers adds users to a group. A user that doesn't exist fails it
+// with a validation error naming them.
+func (s *SCIMService) addMembers(ctx context.Context, groupID uuid.UUID, userIDs []uuid.UUID) error {
+	for _, userID := range userIDs {
+		err := s.groupService.AddMember(ctx, groupID, userID)
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ValidationError{Field: "members", Message: "no user " + userID.String()}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteGroup deletes a group. Its members lose the roles it granted.
+func (s *SCIMService) DeleteGroup(ctx context.Context, id uuid.UUID) error {
+	return s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.groupService.DeleteGroup(ctx, id); err != nil {
+			return err
+		}
+		return s.scim.DeleteExternalID(ctx, domain.SCIMGroup, id)
+	})
+}
+
+// setExternalID records or, when empty, forgets a resource's external ID.
+// An ID left over from a resource deleted outside SCIM is taken over.
+func (s *SCIMService) setExternalID(ctx context.Context, resource domain.SCIMResource, id uuid.UUID, externalID string) error {
+	if externalID == "" {
+		return s.scim.DeleteExternalID(ctx, resource, id)
+	}
+
+	owner, err := s.scim.FindByExternalID(ctx, resource, externalID)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+	case err != nil:
+		return err
+	case owner != id:
+		if resource == domain.SCIMUser {
+			_, err = s.users.GetByID(ctx, owner)
+		} else {
+			_, err = s.groups.GetByID(ctx, owner)
+		}
+		if err == nil {
+			return fmt.Errorf("%w: externalId %q", domain.ErrAlreadyExists, externalID)
+		}
+		if !errors.Is(err, domain.ErrNotFound) {
+			return err
+		}
+		if err := s.scim.DeleteExternalID(ctx, resource, owner); err != nil {
+			return err
+		}
+	}
+
+	return s.scim.SetExternalID(ctx, resource, id, externalID)
+}
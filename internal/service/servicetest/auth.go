@@ -0,0 +1,211 @@
+// Package servicetest builds services on in-memory stores, so the
+// transports and the SDK can be tested end to end without a database.
+//
+// The stores implement only what the services' tested paths use; calling
+// anything else panics on the nil storage interface they embed.
+package servicetest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/auth"
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/risk"
+	"github.com/mvaleed/aegis/internal/service"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// Auth is an AuthService that signs in one user, with the JWT manager it
+// signs tokens with and the store its refresh tokens are kept in.
+type Auth struct {
+	Service *service.AuthService
+	JWT     *auth.JWTManager
+	Tokens  *Tokens
+}
+
+// NewAuth returns an Auth signing in an active customer with email and
+// password. Risk is never assessed as high, and new devices are let in.
+func NewAuth(email, password string) (*Auth, error) {
+	user, err := domain.NewUser(email, "test", "Test User", domain.UserTypeCustomer)
+	if err != nil {
+		return nil, err
+	}
+	user.Status = domain.UserStatusActive
+	if user.PasswordHash, err = auth.HashPassword(password); err != nil {
+		return nil, err
+	}
+
+	jwt := auth.NewJWTManager(auth.JWTConfig{
+		SecretKey:       "servicetest-secret-key-of-32-bytes",
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 24 * time.Hour,
+		Issuer:          "mvaleed",
+	})
+	tokens := &Tokens{byHash: map[string]*domain.RefreshToken{}}
+	publisher := nopPublisher{}
+	deviceService := service.NewDeviceService(&devices{}, nil, publisher, nil, false, time.Hour)
+
+	return &Auth{
+		Service: service.NewAuthService(
+			users{user: user}, roles{}, tokens, organizations{}, groups{}, nil, nil, logins{},
+			jwt, publisher, risk.AllowAll{}, deviceService, time.Hour,
+		),
+		JWT:    jwt,
+		Tokens: tokens,
+	}, nil
+}
+
+// Tokens is an in-memory storage.TokenRepository.
+type Tokens struct {
+	storage.TokenRepository
+
+	mu     sync.Mutex
+	byHash map[string]*domain.RefreshToken
+}
+
+// Get returns a copy of the refresh token whose raw value is token, or nil.
+func (s *Tokens) Get(token string) *domain.RefreshToken {
+	t, err := s.GetByHash(context.Background(), auth.HashToken(token))
+	if err != nil {
+		return nil
+	}
+	return t
+}
+
+func (s *Tokens) Create(_ context.Context, token *domain.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := *token
+	s.byHash[token.TokenHash] = &t
+	return nil
+}
+
+func (s *Tokens) GetByHash(_ context.Context, hash string) (*domain.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.byHash[hash]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	c := *t
+	return &c, nil
+}
+
+func (s *Tokens) MarkReplaced(_ context.Context, id, replacedByID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.byHash {
+		if t.ID == id && t.RevokedAt == nil {
+			t.Revoke()
+			t.ReplacedByID = &replacedByID
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func (s *Tokens) Revoke(_ context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.byHash {
+		if t.ID == id {
+			t.Revoke()
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+type users struct {
+	storage.UserRepository
+	user *domain.User
+}
+
+func (s users) GetByEmail(_ context.Context, email string) (*domain.User, error) {
+	if domain.NormalizeEmail(email) != s.user.Email {
+		return nil, domain.ErrNotFound
+	}
+	u := *s.user
+	return &u, nil
+}
+
+func (s users) GetByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	if id != s.user.ID {
+		return nil, domain.ErrNotFound
+	}
+	u := *s.user
+	return &u, nil
+}
+
+func (users) Update(context.Context, *domain.User) error { return nil }
+
+type roles struct{ storage.RoleRepository }
+
+func (roles) GetEffectiveUserRoles(context.Context, uuid.UUID) ([]domain.Role, error) {
+	return nil, nil
+}
+
+type organizations struct{ storage.OrganizationRepository }
+
+func (organizations) ListForUser(context.Context, uuid.UUID) ([]domain.Organization, error) {
+	return nil, nil
+}
+
+type groups struct{ storage.GroupRepository }
+
+func (groups) ListForUser(context.Context, uuid.UUID) ([]domain.Group, error) {
+	return nil, nil
+}
+
+type logins struct{ storage.LoginAttemptRepository }
+
+func (logins) Create(context.Context, *domain.LoginAttempt) error { return nil }
+
+type devices struct {
+	storage.DeviceRepository
+
+	mu   sync.Mutex
+	seen []domain.KnownDevice
+}
+
+func (s *devices) GetByFingerprint(_ context.Context, userID uuid.UUID, fingerprint string) (*domain.KnownDevice, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range s.seen {
+		if d.UserID == userID && d.Fingerprint == fingerprint {
+			return &d, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (s *devices) ListForUser(_ context.Context, userID uuid.UUID) ([]domain.KnownDevice, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var known []domain.KnownDevice
+	for _, d := range s.seen {
+		if d.UserID == userID {
+			known = append(known, d)
+		}
+	}
+	return known, nil
+}
+
+func (s *devices) Create(_ context.Context, device *domain.KnownDevice) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seen = append(s.seen, *device)
+	return nil
+}
+
+func (*devices) Update(context.Context, *domain.KnownDevice) error { return nil }
+
+type nopPublisher struct{}
+
+func (nopPublisher) Publish(context.Context, domain.Event) error        { return nil }
+func (nopPublisher) PublishBatch(context.Context, []domain.Event) error { return nil }
+func (nopPublisher) Close() error                                       { return nil }
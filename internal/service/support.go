@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// Support lookups support staff can run. Each takes one parameter.
+const (
+	LookupUsersByEmail = "users_by_email" // email: part of the address, 3+ characters
+	LookupTokensByIP   = "tokens_by_ip"   // ip: refresh tokens issued to it
+	LookupLoginsByIP   = "logins_by_ip"   // ip: login attempts made from it
+)
+
+// supportLookupLimit caps the rows a lookup returns.
+const supportLookupLimit = 50
+
+// SupportLookupInput names a lookup, its parameters, who runs it and why.
+type SupportLookupInput struct {
+	Query     string
+	Params    map[string]string
+	Reason    string
+	ActorID   uuid.UUID
+	IPAddress string
+	UserAgent string
+}
+
+// SupportLookupResult holds the rows of a lookup; only the slice for its
+// query is set.
+type SupportLookupResult struct {
+	Lookup *domain.SupportLookup
+	Users  []domain.User
+	Tokens []domain.RefreshToken
+	Logins []domain.LoginAttempt
+}
+
+// SupportService answers the predefined lookups support staff need without
+// direct database access. Every lookup is audited.
+type SupportService struct {
+	lookups storage.SupportLookupRepository
+}
+
+func NewSupportService(lookups storage.SupportLookupRepository) *SupportService {
+	return &SupportService{lookups: lookups}
+}
+
+// Lookup runs a lookup and records it, with the number of rows found, in
+// the audit trail. No rows are returned unless the record is stored.
+func (s *SupportService) Lookup(ctx context.Context, input SupportLookupInput) (*SupportLookupResult, error) {
+	params, err := supportLookupParams(input.Query, input.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup, err := domain.NewSupportLookup(input.ActorID, input.Query, params, input.Reason, input.IPAddress, input.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SupportLookupResult{Lookup: lookup}
+	switch input.Query {
+	case LookupUsersByEmail:
+		result.Users, err = s.lookups.UsersByEmail(ctx, params["email"], supportLookupLimit)
+		lookup.ResultCount = len(result.Users)
+	case LookupTokensByIP:
+		result.Tokens, err = s.lookups.TokensByIP(ctx, params["ip"], supportLookupLimit)
+		lookup.ResultCount = len(result.Tokens)
+	case LookupLoginsByIP:
+		result.Logins, err = s.lookups.LoginsByIP(ctx, params["ip"], supportLookupLimit)
+		lookup.ResultCount = len(result.Logins)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.lookups.Record(ctx, lookup); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// supportLookupParams checks and normalizes the parameters of query,
+// dropping any it doesn't take.
+func supportLookupParams(query string, params map[string]string) (map[string]string, error) {
+	switch query {
+	case LookupUsersByEmail:
+		email := strings.ToLower(strings.TrimSpace(params["email"]))
+		if len(email) < 3 {
+			return nil, domain.ValidationError{Field: "email", Message: "must be at least 3 characters"}
+		}
+		if len(email) > 255 {
+			return nil, domain.ValidationError{Field: "email", Message: "must be at most 255 characters"}
+		}
+		return map[string]string{"email": email}, nil
+
+	case LookupTokensByIP, LookupLoginsByIP:
+		ip := net.ParseIP(strings.TrimSpace(params["ip"]))
+		if ip == nil {
+			return nil, domain.ValidationError{Field: "ip", Message: "must be an IP address"}
+		}
+		return map[string]string{"ip": ip.String()}, nil
+
+	default:
+		return nil, domain.ValidationError{Field: "query", Message: "unknown lookup"}
+	}
+}
+
+// ListLookups lists a page of the audit trail.
+func (s *SupportService) ListLookups(ctx context.Context, filter storage.SupportLookupFilter) ([]domain.SupportLookup, int64, error) {
+	return s.lookups.List(ctx, filter)
+}
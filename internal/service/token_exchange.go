@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/auth"
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// TokenExchangeService lets OAuth clients exchange a user's access token
+// for a delegation token (RFC 8693), to call another service on the user's
+// behalf with only the permissions that call needs. Every exchange is
+// recorded before the token is returned, and published.
+type TokenExchangeService struct {
+	exchanges storage.TokenExchangeRepository
+	clients   *OAuthClientService
+	auth      *AuthService
+	jwt       *auth.JWTManager
+	publisher event.Publisher
+	ttl       time.Duration
+	audiences []string // Besides our own
+}
+
+func NewTokenExchangeService(
+	exchanges storage.TokenExchangeRepository,
+	clients *OAuthClientService,
+	authService *AuthService,
+	jwt *auth.JWTManager,
+	publisher event.Publisher,
+	ttl time.Duration,
+	audiences []string,
+) *TokenExchangeService {
+	return &TokenExchangeService{
+		exchanges: exchanges,
+		clients:   clients,
+		auth:      authService,
+		jwt:       jwt,
+		publisher: publisher,
+		ttl:       ttl,
+		audiences: audiences,
+	}
+}
+
+// ExchangeInput is a client's request for a delegation token.
+type ExchangeInput struct {
+	ClientID     string
+	ClientSecret string
+	SubjectToken string   // The user's access token
+	Audience     []string // Services the token is for
+	Scope        string   // Space-separated permissions, required
+	IPAddress    string
+	UserAgent    string
+}
+
+// DelegationToken is an access token a client got by token exchange.
+type DelegationToken struct {
+	AccessToken      string
+	ExpiresInSeconds int64
+	Scope            string // The permissions granted, space-separated
+	Exchange         *domain.TokenExchange
+}
+
+// Exchange authenticates the client and issues it a delegation token for
+// the subject token's user, with the permissions in scope and the given
+// audience. The token lives for the exchange TTL, and never past the
+// subject token.
+//
+// Unknown clients and wrong secrets return ErrInvalidCredential, clients
+// whose roles don't allow tokens:exchange ErrUnauthorizedClient. The
+// subject token must be a user's own: client, impersonation, profile and
+// delegation tokens return ErrInvalidSubjectToken. Audiences other than
+// ours and those configured return ErrInvalidTarget, permissions the
+// subject token doesn't grant ErrInvalidScope.
+func (s *TokenExchangeService) Exchange(ctx context.Context, input ExchangeInput) (*DelegationToken, error) {
+	client, err := s.clients.Authenticate(ctx, input.ClientID, input.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if !domain.Allows(client.AllPermissions(), "tokens", "exchange") {
+		return nil, domain.ErrUnauthorizedClient
+	}
+
+	subject, err := s.auth.ValidateToken(ctx, input.SubjectToken)
+	if err != nil {
+		return nil, domain.ErrInvalidSubjectToken
+	}
+	if subject.IsClient() || subject.IsImpersonation() || subject.IsDelegation() || subject.Profile != nil || subject.ExpiresAt == nil {
+		return nil, domain.ErrInvalidSubjectToken
+	}
+
+	audience, err := s.checkAudience(input.Audience)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, denied, err := domain.DelegatedPermissions(subject.Permissions, subject.Denied, input.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := s.ttl
+	if remaining := subject.ExpiresAt.Sub(domain.Now()); remaining < ttl {
+		ttl = remaining
+	}
+	if ttl < time.Second {
+		return nil, domain.ErrInvalidSubjectToken
+	}
+
+	exchange := domain.NewTokenExchange(client.ID, subject.UserID, audience, permissions, subject.ID, input.IPAddress, input.UserAgent)
+
+	accessToken, expiresAt, err := s.jwt.GenerateAccessToken(ctx, auth.TokenPayload{
+		UserID:      subject.UserID,
+		Email:       subject.Email,
+		Username:    subject.Username,
+		UserType:    subject.UserType,
+		Permissions: permissions,
+		Denied:      denied,
+		Environment: subject.Environment,
+		Delegation:  &auth.DelegationClaim{ClientID: client.ID.String(), ExchangeID: exchange.ID},
+		Audience:    audience,
+		Extra:       subject.Extra,
+		TTL:         ttl,
+	})
+	if err != nil {
+		return nil, err
+	}
+	exchange.ExpiresAt = expiresAt
+
+	// No token without its audit record
+	if err := s.exchanges.Create(ctx, exchange); err != nil {
+		return nil, err
+	}
+	_ = s.publisher.Publish(ctx, domain.TokenExchangedEvent(exchange))
+	s.clients.RecordUse(ctx, client.ID)
+
+	return &DelegationToken{
+		AccessToken:      accessToken,
+		ExpiresInSeconds: int64(ttl.Seconds()),
+		Scope:            strings.Join(permissions, " "),
+		Exchange:         exchange,
+	}, nil
+}
+
+// checkAudience returns the requested audience without duplicates, if it's
+// all ours or configured.
+func (s *TokenExchangeService) checkAudience(requested []string) ([]string, error) {
+	var audience []string
+	for _, aud := range requested {
+		aud = strings.TrimSpace(aud)
+		if aud == "" || slices.Contains(audience, aud) {
+			continue
+		}
+		if !slices.Contains(s.audiences, aud) && !slices.Contains(s.jwt.Audience(), aud) {
+			return nil, domain.ErrInvalidTarget
+		}
+		audience = append(audience, aud)
+	}
+	if len(audience) == 0 {
+		return nil, domain.ValidationError{Field: "audience", Message: "required"}
+	}
+	return audience, nil
+}
+
+// ListForUser returns the exchanges of a user's tokens, newest first.
+func (s *TokenExchangeService) ListForUser(ctx context.Context, userID uuid.UUID, offset, limit int) ([]domain.TokenExchange, int64, error) {
+	return s.exchanges.List(ctx, storage.TokenExchangeFilter{UserID: &userID, Offset: offset, Limit: limit})
+}
+
+// ListForClient returns a client's exchanges, newest first.
+func (s *TokenExchangeService) ListForClient(ctx context.Context, clientID uuid.UUID, offset, limit int) ([]domain.TokenExchange, int64, error) {
+	return s.exchanges.List(ctx, storage.TokenExchangeFilter{ClientID: &clientID, Offset: offset, Limit: limit})
+}
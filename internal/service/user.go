@@ -13,6 +13,7 @@ import (
 	"github.com/mvaleed/aegis/internal/auth"
 	"github.com/mvaleed/aegis/internal/domain"
 	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/risk"
 	"github.com/mvaleed/aegis/internal/storage"
 )
 
@@ -20,18 +21,54 @@ import (
 type UserService struct {
 	users     storage.UserRepository
 	roles     storage.RoleRepository
+	history   storage.HistoryRepository
+	directory storage.UserDirectoryRepository
 	publisher event.Publisher
+	risk      risk.Engine
+	suggester *UsernameSuggester
+	screener  *EmailScreener
+	passwords *PasswordHistory
+
+	usernames       storage.UsernameHistoryRepository
+	usernameChanges UsernameChangePolicy
+}
+
+// UsernameChangePolicy limits username changes. Cooldown is the least time
+// between a user's own changes; administrators aren't held to it.
+// Reservation is how long a former username stays reserved for the user
+// who left it, so nobody else can take it over while links and mentions
+// still point at them.
+type UsernameChangePolicy struct {
+	Cooldown    time.Duration
+	Reservation time.Duration
 }
 
 func NewUserService(
 	users storage.UserRepository,
 	roles storage.RoleRepository,
+	history storage.HistoryRepository,
+	directory storage.UserDirectoryRepository,
 	publisher event.Publisher,
+	riskEngine risk.Engine,
+	suggester *UsernameSuggester,
+	screener *EmailScreener,
+	passwords *PasswordHistory,
+	usernames storage.UsernameHistoryRepository,
+	usernameChanges UsernameChangePolicy,
 ) *UserService {
 	return &UserService{
 		users:     users,
 		roles:     roles,
+		history:   history,
+		directory: directory,
 		publisher: publisher,
+		risk:      riskEngine,
+		suggester: suggester,
+		screener:  screener,
+		passwords: passwords,
+
+		usernames:       usernames,
+		usernameChanges: usernameChanges,
 	}
 }
 
@@ -43,6 +80,12 @@ type CreateUserInput struct {
 	Type     domain.UserType
 	Phone    string
 	UserType domain.UserType
+	Locale   string // BCP 47 language tag; optional
+	Timezone string // IANA time zone name; optional
+
+	// Where a self-registration came from, for risk assessment
+	IPAddress string
+	UserAgent string
 }
 
 // CreateUser creates a new user account.
@@ -68,6 +111,37 @@ func (s *UserService) CreateUser(ctx context.Context, input CreateUserInput) (*d
 			return nil, err
 		}
 	}
+	if err := user.SetLocale(input.Locale); err != nil {
+		return nil, err
+	}
+	if err := user.SetTimezone(input.Timezone); err != nil {
+		return nil, err
+	}
+
+	disposableEmail, verr := s.screener.Screen(user.Email)
+	if verr != nil {
+		return nil, *verr
+	}
+
+	signal := risk.Signal{
+		Operation: risk.OperationRegister,
+		Email:     user.Email,
+		IPAddress: input.IPAddress,
+		UserAgent: input.UserAgent,
+	}
+	if disposableEmail {
+		signal.Attributes = map[string]string{risk.AttributeEmailDisposable: "true"}
+	}
+	if err := enforceRisk(ctx, s.risk, s.publisher, signal); err != nil {
+		return nil, err
+	}
+
+	// Former usernames still reserved aren't held by anyone, so the
+	// unique constraint won't catch them
+	if change, err := s.usernames.LatestForUsername(ctx, user.Username); err == nil && change.IsReserved() {
+		suggestions, _ := s.suggester.Suggest(ctx, user.Username, user.FullName)
+		return nil, domain.UsernameTakenError{Username: user.Username, Suggestions: suggestions}
+	}
 
 	if err := s.users.Create(ctx, user); err != nil {
 		if errors.Is(err, domain.ErrAlreadyExists) {
@@ -75,29 +149,59 @@ func (s *UserService) CreateUser(ctx context.Context, input CreateUserInput) (*d
 			if _, emailErr := s.users.GetByEmail(ctx, input.Email); emailErr == nil {
 				return nil, domain.ValidationError{Field: "email", Message: "already taken"}
 			}
-			if _, userErr := s.users.GetByUsername(ctx, input.Username); userErr == nil {
-				return nil, domain.ValidationError{Field: "username", Message: "already taken"}
+			if _, userErr := s.users.GetByUsername(ctx, user.Username); userErr == nil {
+				suggestions, _ := s.suggester.Suggest(ctx, user.Username, user.FullName)
+				return nil, domain.UsernameTakenError{Username: user.Username, Suggestions: suggestions}
 			}
 		}
 		return nil, err
 	}
 
 	defaultRole, err := s.roles.GetByName(ctx, "user")
-	if err == nil {
-		_ = s.roles.AssignRole(ctx, user.ID, defaultRole.ID)
+	if err == nil && s.roles.AssignRole(ctx, user.ID, defaultRole.ID, nil) == nil {
+		user.Version++ // Assigning bumped it
 	}
 
-	_ = s.publisher.Publish(ctx, domain.UserCreatedEvent(user))
+	_ = s.passwords.Record(ctx, user.ID, user.PasswordHash)
+
+	created := domain.UserCreatedEvent(user)
+	if disposableEmail {
+		created.Data["email_disposable"] = true
+	}
+	_ = s.publisher.Publish(ctx, created)
 
 	return user, nil
 }
 
 func (s *UserService) GetUser(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	return s.GetUserFields(ctx, id, nil)
+}
+
+// GetUserFields gets a user, loading their roles only if fields has
+// "roles".
+func (s *UserService) GetUserFields(ctx context.Context, id uuid.UUID, fields FieldSet) (*domain.User, error) {
 	user, err := s.users.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if fields.Has("roles") {
+		roles, err := s.roles.GetUserRoles(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		user.Roles = roles
+	}
+
+	return user, nil
+}
+
+func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	user, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
 	roles, err := s.roles.GetUserRoles(ctx, user.ID)
 	if err != nil {
 		return nil, err
@@ -107,13 +211,19 @@ func (s *UserService) GetUser(ctx context.Context, id uuid.UUID) (*domain.User,
 	return user, nil
 }
 
-func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
-	user, err := s.users.GetByEmail(ctx, email)
+// GetUserAsOf reconstructs a user and their role memberships as they were at
+// the given time. Soft-deleted users are returned with DeletedAt set.
+func (s *UserService) GetUserAsOf(ctx context.Context, id uuid.UUID, at time.Time) (*domain.User, error) {
+	if at.After(domain.Now()) {
+		return nil, domain.ValidationError{Field: "as_of", Message: "must not be in the future"}
+	}
+
+	user, err := s.history.GetUserAsOf(ctx, id, at)
 	if err != nil {
 		return nil, err
 	}
 
-	roles, err := s.roles.GetUserRoles(ctx, user.ID)
+	roles, err := s.history.GetUserRolesAsOf(ctx, id, at)
 	if err != nil {
 		return nil, err
 	}
@@ -122,10 +232,32 @@ func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*domain
 	return user, nil
 }
 
+// GetTimeline lists what happened to a user's account, newest first:
+// profile and status changes, logins, role changes and security events.
+func (s *UserService) GetTimeline(ctx context.Context, filter storage.TimelineFilter) ([]domain.TimelineEntry, int64, error) {
+	if filter.Since != nil && filter.Until != nil && !filter.Until.After(*filter.Since) {
+		return nil, 0, domain.ValidationError{Field: "until", Message: "must be after since"}
+	}
+
+	return s.history.ListTimeline(ctx, filter)
+}
+
+// UpdateUserInput lists the profile fields to change. Fields left zero are
+// not touched; clearing a required field fails validation.
 type UpdateUserInput struct {
-	FullName *string
-	Phone    *string
-	Username *string
+	FullName Field[string]
+	Phone    Field[string]
+	Username Field[string]
+	Locale   Field[string]
+	Timezone Field[string]
+
+	// SelfService holds a username change to the change cooldown, for users
+	// changing their own.
+	SelfService bool
+
+	// Version, unless 0, is the version the user must be at, failing the
+	// update with ErrVersionMismatch otherwise.
+	Version int
 }
 
 func (s *UserService) UpdateUser(ctx context.Context, id uuid.UUID, input UpdateUserInput) (*domain.User, error) {
@@ -133,17 +265,34 @@ func (s *UserService) UpdateUser(ctx context.Context, id uuid.UUID, input Update
 	if err != nil {
 		return nil, err
 	}
+	// Update checks the version again, for writes since it was read
+	if input.Version != 0 && user.Version != input.Version {
+		return nil, domain.ErrVersionMismatch
+	}
+
+	if !input.FullName.IsIgnored() {
+		user.FullName = input.FullName.Value()
+	}
 
-	if input.FullName != nil {
-		user.FullName = *input.FullName
+	formerUsername := user.Username
+	if !input.Username.IsIgnored() {
+		user.Username = domain.NormalizeUsername(input.Username.Value())
+	}
+
+	if !input.Phone.IsIgnored() {
+		if err := user.SetPhone(input.Phone.Value()); err != nil {
+			return nil, err
+		}
 	}
 
-	if input.Username != nil {
-		user.Username = *input.Username
+	if !input.Locale.IsIgnored() {
+		if err := user.SetLocale(input.Locale.Value()); err != nil {
+			return nil, err
+		}
 	}
 
-	if input.Phone != nil {
-		if err := user.SetPhone(*input.Phone); err != nil {
+	if !input.Timezone.IsIgnored() {
+		if err := user.SetTimezone(input.Timezone.Value()); err != nil {
 			return nil, err
 		}
 	}
@@ -152,44 +301,198 @@ func (s *UserService) UpdateUser(ctx context.Context, id uuid.UUID, input Update
 		return nil, err
 	}
 
-	user.UpdatedAt = time.Now().UTC()
+	renamed := user.Username != formerUsername
+	if renamed {
+		if err := s.checkUsernameChange(ctx, user, input.SelfService); err != nil {
+			return nil, err
+		}
+	}
+
+	user.UpdatedAt = domain.Now()
 
 	if err := s.users.Update(ctx, user); err != nil {
+		if renamed && errors.Is(err, domain.ErrAlreadyExists) {
+			suggestions, _ := s.suggester.Suggest(ctx, user.Username, user.FullName)
+			return nil, domain.UsernameTakenError{Username: user.Username, Suggestions: suggestions}
+		}
 		return nil, err
 	}
 
+	if renamed {
+		change := domain.NewUsernameChange(user.ID, formerUsername, s.usernameChanges.Reservation)
+		if err := s.usernames.Create(ctx, change); err != nil {
+			return nil, err
+		}
+		_ = s.publisher.Publish(ctx, domain.UserUsernameChangedEvent(user, formerUsername))
+	}
+
 	_ = s.publisher.Publish(ctx, domain.NewEvent(domain.EventUserUpdated, user.ID, nil))
 
 	return user, nil
 }
 
-func (s *UserService) ChangePassword(ctx context.Context, userID uuid.UUID, currentPassword, newPassword string) error {
-	user, err := s.users.GetByID(ctx, userID)
+// ChangeUsername changes a user's own username, at most once per cooldown.
+// The new username must be free: neither held by another user nor reserved
+// for its former holder. The old one stays reserved for the user, who can
+// take it back.
+func (s *UserService) ChangeUsername(ctx context.Context, id uuid.UUID, username string) (*domain.User, error) {
+	if username == "" {
+		return nil, domain.ValidationError{Field: "username", Message: "required"}
+	}
+
+	return s.UpdateUser(ctx, id, UpdateUserInput{Username: Set(username), SelfService: true})
+}
+
+// checkUsernameChange checks that user may move to their new username.
+func (s *UserService) checkUsernameChange(ctx context.Context, user *domain.User, cooldown bool) error {
+	if cooldown && s.usernameChanges.Cooldown > 0 {
+		last, err := s.usernames.LatestForUser(ctx, user.ID)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return err
+		}
+		if err == nil {
+			if next := last.ChangedAt.Add(s.usernameChanges.Cooldown); domain.Now().Before(next) {
+				return domain.ValidationError{
+					Field:   "username",
+					Message: "was changed recently; it can be changed again after " + next.UTC().Format(time.RFC3339),
+				}
+			}
+		}
+	}
+
+	taken := false
+	if holder, err := s.users.GetByUsername(ctx, user.Username); err == nil {
+		taken = holder.ID != user.ID
+	} else if !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+	if change, err := s.usernames.LatestForUsername(ctx, user.Username); err == nil {
+		taken = taken || (change.IsReserved() && change.UserID != user.ID)
+	} else if !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+	if taken {
+		suggestions, _ := s.suggester.Suggest(ctx, user.Username, user.FullName)
+		return domain.UsernameTakenError{Username: user.Username, Suggestions: suggestions}
+	}
+
+	return nil
+}
+
+// GetUserByUsername returns the user holding username. With resolveFormer,
+// a username nobody holds resolves to the user who most recently left it,
+// and former is true.
+func (s *UserService) GetUserByUsername(ctx context.Context, username string, resolveFormer bool) (user *domain.User, former bool, err error) {
+	username = domain.NormalizeUsername(username)
+
+	user, err = s.users.GetByUsername(ctx, username)
+	if errors.Is(err, domain.ErrNotFound) && resolveFormer {
+		change, err := s.usernames.LatestForUsername(ctx, username)
+		if err != nil {
+			return nil, false, err
+		}
+		if user, err = s.users.GetByID(ctx, change.UserID); err != nil {
+			return nil, false, err
+		}
+		former = true
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	roles, err := s.roles.GetUserRoles(ctx, user.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	user.Roles = roles
+
+	return user, former, nil
+}
+
+// ListUsernameChanges returns the user's former usernames, newest first.
+func (s *UserService) ListUsernameChanges(ctx context.Context, id uuid.UUID) ([]domain.UsernameChange, error) {
+	return s.usernames.ListForUser(ctx, id)
+}
+
+// ChangePasswordInput is a user changing their own password. IPAddress and
+// UserAgent are the caller's, for risk assessment as on login.
+type ChangePasswordInput struct {
+	UserID          uuid.UUID
+	CurrentPassword string
+	NewPassword     string
+	IPAddress       string
+	UserAgent       string
+}
+
+func (s *UserService) ChangePassword(ctx context.Context, input ChangePasswordInput) error {
+	user, err := s.users.GetByID(ctx, input.UserID)
 	if err != nil {
 		return err
 	}
 
-	if err := auth.CheckPassword(currentPassword, user.PasswordHash); err != nil {
+	if err := auth.CheckPassword(input.CurrentPassword, user.PasswordHash); err != nil {
 		return domain.ErrInvalidCredential
 	}
 
+	if err := enforceRisk(ctx, s.risk, s.publisher, risk.Signal{
+		Operation: risk.OperationPasswordChange,
+		UserID:    user.ID,
+		Email:     user.Email,
+		IPAddress: input.IPAddress,
+		UserAgent: input.UserAgent,
+	}); err != nil {
+		return err
+	}
+
+	if err := s.setPassword(ctx, user, input.NewPassword); err != nil {
+		return err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.NewEvent(domain.EventPasswordChanged, user.ID, nil))
+
+	return nil
+}
+
+// ResetPassword sets a user's password without their current one, for
+// administrators helping a locked-out user.
+func (s *UserService) ResetPassword(ctx context.Context, userID uuid.UUID, newPassword string) error {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.setPassword(ctx, user, newPassword); err != nil {
+		return err
+	}
+
+	_ = s.publisher.Publish(ctx, domain.NewEvent(domain.EventPasswordReset, user.ID, nil))
+
+	return nil
+}
+
+// setPassword validates a new password, rejects recently used ones, and
+// saves it.
+func (s *UserService) setPassword(ctx context.Context, user *domain.User, newPassword string) error {
 	if err := auth.ValidatePasswordStrength(newPassword); err != nil {
 		return domain.ValidationError{Field: "new_password", Message: err.Error()}
 	}
 
+	if err := s.passwords.CheckReuse(ctx, user, newPassword); err != nil {
+		return err
+	}
+
 	newHash, err := auth.HashPassword(newPassword)
 	if err != nil {
 		return err
 	}
 
 	user.PasswordHash = newHash
-	user.UpdatedAt = time.Now().UTC()
+	user.UpdatedAt = domain.Now()
 
 	if err := s.users.Update(ctx, user); err != nil {
 		return err
 	}
 
-	_ = s.publisher.Publish(ctx, domain.NewEvent(domain.EventPasswordChanged, user.ID, nil))
+	_ = s.passwords.Record(ctx, user.ID, newHash)
 
 	return nil
 }
@@ -245,9 +548,58 @@ func (s *UserService) DeleteUser(ctx context.Context, id uuid.UUID) error {
 }
 
 func (s *UserService) ListUsers(ctx context.Context, filter storage.UserFilter) ([]domain.User, int64, error) {
+	filter.Locale = domain.NormalizeLocale(filter.Locale)
 	return s.users.List(ctx, filter)
 }
 
+// ListUserSummaries reads from the denormalized user directory. Results are
+// eventually consistent with the users table but carry role names, last
+// login and session counts without per-row lookups.
+func (s *UserService) ListUserSummaries(ctx context.Context, filter storage.DirectoryFilter) ([]domain.UserSummary, int64, error) {
+	filter.Locale = domain.NormalizeLocale(filter.Locale)
+	return s.directory.List(ctx, filter)
+}
+
+// StreamUsers calls fn for every user matching filter, reading batchSize rows
+// from storage at a time. Batches follow on from the last user of the one
+// before rather than by offset, so users created or deleted meanwhile
+// don't shift the rest into being skipped or sent twice. Offset, Limit and
+// After in filter are ignored. Iteration stops at the first error returned
+// by fn or when ctx is cancelled.
+func (s *UserService) StreamUsers(ctx context.Context, filter storage.UserFilter, batchSize int, fn func(*domain.User) error) error {
+	if batchSize <= 0 || batchSize > 100 {
+		batchSize = 100
+	}
+
+	filter.Offset = 0
+	filter.Limit = batchSize
+	filter.After = nil
+	filter.Locale = domain.NormalizeLocale(filter.Locale)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		users, _, err := s.users.List(ctx, filter)
+		if err != nil {
+			return err
+		}
+
+		for i := range users {
+			if err := fn(&users[i]); err != nil {
+				return err
+			}
+		}
+
+		if len(users) < batchSize {
+			return nil
+		}
+		last := users[len(users)-1]
+		filter.After = &storage.UserCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+}
+
 func (s *UserService) VerifyEmail(ctx context.Context, userID uuid.UUID) error {
 	user, err := s.users.GetByID(ctx, userID)
 	if err != nil {
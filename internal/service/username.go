@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// MaxUsernameSuggestions caps the alternatives offered for a taken username.
+const MaxUsernameSuggestions = 3
+
+// UsernameSuggester proposes available alternatives to a taken username. It
+// backs both availability checks and registration errors so they offer the
+// same kind of suggestions.
+type UsernameSuggester struct {
+	users storage.UserRepository
+}
+
+func NewUsernameSuggester(users storage.UserRepository) *UsernameSuggester {
+	return &UsernameSuggester{users: users}
+}
+
+// Suggest returns up to MaxUsernameSuggestions available alternatives to
+// username, using fullName for name-based variants when given.
+func (s *UsernameSuggester) Suggest(ctx context.Context, username, fullName string) ([]string, error) {
+	candidates := s.Candidates(username, fullName)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	taken, err := s.users.TakenUsernames(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Pick(candidates, taken), nil
+}
+
+// FromEmail picks an available username for a user who doesn't choose
+// one, such as one provisioned by an identity provider: the local part of
+// their email, with characters usernames can't hold replaced, else the
+// first suggested variant of it, else a random one.
+func (s *UsernameSuggester) FromEmail(ctx context.Context, email, fullName string) (string, error) {
+	local, _, _ := strings.Cut(strings.TrimSpace(email), "@")
+	base := strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(local))
+
+	if domain.ValidateUsername(base) == nil {
+		taken, err := s.users.TakenUsernames(ctx, []string{base})
+		if err != nil {
+			return "", err
+		}
+		if len(taken) == 0 {
+			return base, nil
+		}
+	}
+
+	suggestions, err := s.Suggest(ctx, base, fullName)
+	if err != nil {
+		return "", err
+	}
+	if len(suggestions) > 0 {
+		return suggestions[0], nil
+	}
+	return "user-" + strings.ReplaceAll(uuid.NewString(), "-", "")[:12], nil
+}
+
+// Candidates returns valid alternatives to a username in order of
+// preference: appended digits, separator variants, then variants of the
+// full name. It returns candidates even for an empty or invalid username so
+// callers that must do constant work can always look them up.
+func (s *UsernameSuggester) Candidates(username, fullName string) []string {
+	base := username
+	if r := []rune(base); len(r) > 45 {
+		base = string(r[:45])
+	}
+
+	candidates := []string{
+		base + "1",
+		base + fmt.Sprint(rand.IntN(900)+100),
+		base + "_" + fmt.Sprint(rand.IntN(90)+10),
+		base + "-" + fmt.Sprint(domain.Now().Year()),
+	}
+
+	if parts := nameParts(fullName); len(parts) > 0 {
+		first, last := parts[0], parts[len(parts)-1]
+		if len(parts) > 1 {
+			candidates = append(candidates,
+				first+"_"+last,
+				first+"-"+last,
+				string([]rune(first)[:1])+last,
+				first+last+fmt.Sprint(rand.IntN(90)+10),
+			)
+		} else {
+			candidates = append(candidates, first+fmt.Sprint(rand.IntN(900)+100))
+		}
+	}
+
+	candidates = append(candidates, base+fmt.Sprint(rand.IntN(9000)+1000))
+
+	valid := candidates[:0]
+	for _, c := range candidates {
+		if c != username && !slices.Contains(valid, c) && domain.ValidateUsername(c) == nil {
+			valid = append(valid, c)
+		}
+	}
+	return valid
+}
+
+// Pick returns up to MaxUsernameSuggestions candidates that are not taken.
+func (s *UsernameSuggester) Pick(candidates, taken []string) []string {
+	var picked []string
+	for _, c := range candidates {
+		if len(picked) == MaxUsernameSuggestions {
+			break
+		}
+		if !slices.Contains(taken, c) {
+			picked = append(picked, c)
+		}
+	}
+	return picked
+}
+
+// nameParts splits a full name into lowercase words of letters and digits.
+// Candidates built from them are still checked against the username policy.
+func nameParts(fullName string) []string {
+	var parts []string
+	for _, word := range strings.Fields(strings.ToLower(domain.NormalizeUsername(fullName))) {
+		word = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, word)
+		if word != "" {
+			parts = append(parts, word)
+		}
+	}
+	return parts
+}
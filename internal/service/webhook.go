@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/storage"
+	"github.com/mvaleed/aegis/internal/webhook"
+)
+
+// WebhookService manages webhook registrations and their delivery history.
+// Delivery itself is handled by webhook.Worker.
+type WebhookService struct {
+	webhooks storage.WebhookRepository
+	sender   *webhook.Sender // Nil until UseSender; test sends need it
+}
+
+func NewWebhookService(webhooks storage.WebhookRepository) *WebhookService {
+	return &WebhookService{webhooks: webhooks}
+}
+
+// UseSender lets SendTest send test events with sender.
+func (s *WebhookService) UseSender(sender *webhook.Sender) {
+	s.sender = sender
+}
+
+type CreateWebhookInput struct {
+	URL         string
+	Description string
+	EventTypes  []string
+	CreatedBy   uuid.UUID
+	OwnerID     *uuid.UUID // Set for a partner's own webhook
+}
+
+// CreateWebhook registers a webhook. The returned webhook carries the signing
+// secret, which callers should show to the client exactly once.
+func (s *WebhookService) CreateWebhook(ctx context.Context, input CreateWebhookInput) (*domain.Webhook, error) {
+	webhook, err := domain.NewWebhook(input.URL, input.Description, input.EventTypes, input.CreatedBy, input.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkPartnerHost(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	if err := s.webhooks.Create(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (s *WebhookService) GetWebhook(ctx context.Context, id uuid.UUID) (*domain.Webhook, error) {
+	return s.webhooks.GetByID(ctx, id)
+}
+
+func (s *WebhookService) ListWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	return s.webhooks.List(ctx)
+}
+
+type UpdateWebhookInput struct {
+	URL         *string
+	Description *string
+	EventTypes  *[]string
+	Active      *bool
+}
+
+func (s *WebhookService) UpdateWebhook(ctx context.Context, id uuid.UUID, input UpdateWebhookInput) (*domain.Webhook, error) {
+	webhook, err := s.webhooks.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	urlChanged := input.URL != nil && *input.URL != webhook.URL
+	if input.URL != nil {
+		webhook.URL = *input.URL
+	}
+
+	if input.Description != nil {
+		webhook.Description = *input.Description
+	}
+
+	if input.EventTypes != nil {
+		webhook.SetEventTypes(*input.EventTypes)
+	}
+
+	if input.Active != nil {
+		webhook.Active = *input.Active
+	}
+
+	if err := webhook.Validate(); err != nil {
+		return nil, err
+	}
+
+	if urlChanged {
+		if err := checkPartnerHost(ctx, webhook); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.webhooks.Update(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// checkPartnerHost refuses a partner's webhook whose host doesn't resolve
+// or resolves to an internal address. The sender checks again on
+// connecting, since the name can be pointed elsewhere afterwards.
+func checkPartnerHost(ctx context.Context, webhook *domain.Webhook) error {
+	if webhook.OwnerID == nil {
+		return nil
+	}
+
+	u, err := url.Parse(webhook.URL)
+	if err != nil {
+		return domain.ValidationError{Field: "url", Message: "must be an absolute http(s) URL"}
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return domain.ValidationError{Field: "url", Message: "host doesn't resolve"}
+	}
+	for _, addr := range addrs {
+		if !domain.PublicAddress(addr) {
+			return domain.ValidationError{Field: "url", Message: "must name a public host"}
+		}
+	}
+	return nil
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	return s.webhooks.Delete(ctx, id)
+}
+
+// RotateSecret issues a new signing secret. The old secret stops working immediately.
+func (s *WebhookService) RotateSecret(ctx context.Context, id uuid.UUID) (*domain.Webhook, error) {
+	webhook, err := s.webhooks.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := webhook.RotateSecret(); err != nil {
+		return nil, err
+	}
+
+	if err := s.webhooks.Update(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, filter storage.DeliveryFilter) ([]domain.WebhookDelivery, int64, error) {
+	if _, err := s.webhooks.GetByID(ctx, filter.WebhookID); err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+
+	return s.webhooks.ListDeliveries(ctx, filter)
+}
+
+// GetDelivery returns a delivery, scoped to the webhook it belongs to.
+func (s *WebhookService) GetDelivery(ctx context.Context, webhookID, deliveryID uuid.UUID) (*domain.WebhookDelivery, error) {
+	delivery, err := s.webhooks.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if delivery.WebhookID != webhookID {
+		return nil, domain.ErrNotFound
+	}
+
+	return delivery, nil
+}
+
+// RedeliverDelivery moves a delivery back onto the queue, whatever its
+// status, so its event is sent again.
+func (s *WebhookService) RedeliverDelivery(ctx context.Context, webhookID, deliveryID uuid.UUID) (*domain.WebhookDelivery, error) {
+	delivery, err := s.GetDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.Requeue()
+
+	if err := s.webhooks.UpdateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// SendTest sends the webhook a signed webhook.test event now, whether it's
+// active or subscribes to the type, and records the attempt in its delivery
+// history. A failed test isn't retried; it's dead-lettered at once and can
+// be redelivered.
+func (s *WebhookService) SendTest(ctx context.Context, id uuid.UUID) (*domain.WebhookDelivery, error) {
+	if s.sender == nil {
+		return nil, errors.New("webhook test sends aren't configured")
+	}
+
+	wh, err := s.webhooks.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	e := event.Stamp(ctx, domain.WebhookTestEvent(wh))
+	body, err := event.DefaultCodec.Encode(event.NewEnvelope(e))
+	if err != nil {
+		return nil, err
+	}
+	delivery := domain.NewWebhookDelivery(wh.ID, e, body)
+
+	status, respBody, err := s.sender.Send(ctx, wh, delivery)
+	switch {
+	case err != nil:
+		delivery.RecordFailure(status, respBody, err.Error(), 1, nil)
+	case status >= 200 && status < 300:
+		delivery.RecordSuccess(status, respBody)
+	default:
+		delivery.RecordFailure(status, respBody, fmt.Sprintf("unexpected status %d", status), 1, nil)
+	}
+
+	// Stored once attempted, so the worker doesn't send it again
+	if err := s.webhooks.CreateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// AccessTokenRepository implements storage.AccessTokenRepository using
+// PostgreSQL.
+type AccessTokenRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewAccessTokenRepository creates a new opaque access token repository.
+func NewAccessTokenRepository(pool *pgxpool.Pool) *AccessTokenRepository {
+	return &AccessTokenRepository{pool: pool}
+}
+
+// Put stores a token's claims.
+func (r *AccessTokenRepository) Put(ctx context.Context, tokenHash string, userID uuid.UUID, claims []byte, expiresAt time.Time) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO opaque_access_tokens (token_hash, user_id, claims, expires_at)
+		VALUES ($1, $2, $3, $4)`,
+		tokenHash, userID, claims, expiresAt,
+	)
+
+	return mapError(err)
+}
+
+// Get retrieves a token's claims, unless they've expired.
+func (r *AccessTokenRepository) Get(ctx context.Context, tokenHash string) ([]byte, bool, error) {
+	db := getDB(ctx, r.pool)
+
+	var claims []byte
+	err := db.QueryRow(ctx, `
+		SELECT claims FROM opaque_access_tokens
+		WHERE token_hash = $1 AND expires_at > $2`,
+		tokenHash, domain.Now(),
+	).Scan(&claims)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, mapError(err)
+	}
+
+	return claims, true, nil
+}
+
+// DeleteForUser deletes a user's tokens.
+func (r *AccessTokenRepository) DeleteForUser(ctx context.Context, userID uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `DELETE FROM opaque_access_tokens WHERE user_id = $1`, userID)
+
+	return mapError(err)
+}
+
+// DeleteExpired removes expired tokens.
+func (r *AccessTokenRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `DELETE FROM opaque_access_tokens WHERE expires_at <= $1`, domain.Now())
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	return result.RowsAffected(), nil
+}
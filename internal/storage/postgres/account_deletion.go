@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// AccountDeletionRepository implements storage.AccountDeletionRepository
+// using PostgreSQL.
+type AccountDeletionRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewAccountDeletionRepository creates a new account deletion repository.
+func NewAccountDeletionRepository(pool *pgxpool.Pool) *AccountDeletionRepository {
+	return &AccountDeletionRepository{pool: pool}
+}
+
+// Create schedules a deletion.
+func (r *AccountDeletionRepository) Create(ctx context.Context, deletion *domain.AccountDeletion) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO account_deletions (user_id, requested_at, delete_after)
+		VALUES ($1, $2, $3)`,
+		deletion.UserID, deletion.RequestedAt, deletion.DeleteAfter,
+	)
+
+	return mapError(err)
+}
+
+// Get returns the user's scheduled deletion.
+func (r *AccountDeletionRepository) Get(ctx context.Context, userID uuid.UUID) (*domain.AccountDeletion, error) {
+	db := getDB(ctx, r.pool)
+
+	var d domain.AccountDeletion
+	err := db.QueryRow(ctx, `
+		SELECT user_id, requested_at, delete_after FROM account_deletions
+		WHERE user_id = $1`, userID,
+	).Scan(&d.UserID, &d.RequestedAt, &d.DeleteAfter)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &d, nil
+}
+
+// Delete removes the user's scheduled deletion.
+func (r *AccountDeletionRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `DELETE FROM account_deletions WHERE user_id = $1`, userID)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// ListDue returns deletions whose grace period ended by now.
+func (r *AccountDeletionRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]domain.AccountDeletion, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT user_id, requested_at, delete_after FROM account_deletions
+		WHERE delete_after <= $1
+		ORDER BY delete_after
+		LIMIT $2`, now, limit)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var deletions []domain.AccountDeletion
+	for rows.Next() {
+		var d domain.AccountDeletion
+		if err := rows.Scan(&d.UserID, &d.RequestedAt, &d.DeleteAfter); err != nil {
+			return nil, mapError(err)
+		}
+		deletions = append(deletions, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return deletions, nil
+}
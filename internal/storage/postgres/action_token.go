@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// ActionTokenRepository implements storage.ActionTokenRepository using PostgreSQL.
+type ActionTokenRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewActionTokenRepository creates a new action token repository.
+func NewActionTokenRepository(pool *pgxpool.Pool) *ActionTokenRepository {
+	return &ActionTokenRepository{pool: pool}
+}
+
+const actionTokenColumns = `id, purpose, subject_id, payload, token_hash, expires_at, used_at, revoked_at, created_at`
+
+// Create stores a new token.
+func (r *ActionTokenRepository) Create(ctx context.Context, token *domain.ActionToken) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO action_tokens (`+actionTokenColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		token.ID,
+		string(token.Purpose),
+		token.SubjectID,
+		attributesOrEmpty(token.Payload),
+		token.TokenHash,
+		token.ExpiresAt,
+		token.UsedAt,
+		token.RevokedAt,
+		token.CreatedAt,
+	)
+
+	return mapError(err)
+}
+
+// Consume marks a valid token used. The row lock taken by the update makes
+// a concurrent call wait, then find the token already used.
+func (r *ActionTokenRepository) Consume(ctx context.Context, purpose domain.ActionPurpose, tokenHash string) (*domain.ActionToken, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `
+		UPDATE action_tokens SET used_at = $3
+		WHERE token_hash = $1 AND purpose = $2
+			AND used_at IS NULL AND revoked_at IS NULL AND expires_at > $3
+		RETURNING `+actionTokenColumns,
+		tokenHash, string(purpose), domain.Now())
+
+	return r.scanActionToken(row)
+}
+
+// RevokeForSubject revokes the subject's outstanding tokens for purpose.
+func (r *ActionTokenRepository) RevokeForSubject(ctx context.Context, purpose domain.ActionPurpose, subjectID uuid.UUID) (int64, error) {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `
+		UPDATE action_tokens SET revoked_at = $3
+		WHERE purpose = $1 AND subject_id = $2 AND used_at IS NULL AND revoked_at IS NULL`,
+		string(purpose), subjectID, domain.Now())
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// DeleteExpired removes expired tokens, used or not.
+func (r *ActionTokenRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `DELETE FROM action_tokens WHERE expires_at <= $1`, domain.Now())
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *ActionTokenRepository) scanActionToken(row scannable) (*domain.ActionToken, error) {
+	var t domain.ActionToken
+	var purpose string
+
+	err := row.Scan(
+		&t.ID,
+		&purpose,
+		&t.SubjectID,
+		&t.Payload,
+		&t.TokenHash,
+		&t.ExpiresAt,
+		&t.UsedAt,
+		&t.RevokedAt,
+		&t.CreatedAt,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	t.Purpose = domain.ActionPurpose(purpose)
+
+	return &t, nil
+}
@@ -0,0 +1,189 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// APIKeyRepository implements storage.APIKeyRepository using PostgreSQL.
+type APIKeyRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewAPIKeyRepository creates a new API key repository.
+func NewAPIKeyRepository(pool *pgxpool.Pool) *APIKeyRepository {
+	return &APIKeyRepository{pool: pool}
+}
+
+const apiKeyColumns = `id, owner_id, name, environment, prefix, secret_hash, scopes,
+	last_used_at, created_at, rotated_at, revoked_at`
+
+// Create stores a new key.
+func (r *APIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO api_keys (`+apiKeyColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		key.ID,
+		key.OwnerID,
+		key.Name,
+		string(key.Environment),
+		key.Prefix,
+		key.SecretHash,
+		key.Scopes,
+		key.LastUsedAt,
+		key.CreatedAt,
+		key.RotatedAt,
+		key.RevokedAt,
+	)
+
+	return mapError(err)
+}
+
+// GetByID retrieves a key by ID.
+func (r *APIKeyRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1`, id)
+
+	return r.scanAPIKey(row)
+}
+
+// GetByPrefix retrieves a key by its prefix.
+func (r *APIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE prefix = $1`, prefix)
+
+	return r.scanAPIKey(row)
+}
+
+// ListForOwner retrieves a partner's keys, oldest first.
+func (r *APIKeyRepository) ListForOwner(ctx context.Context, ownerID uuid.UUID) ([]domain.APIKey, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT `+apiKeyColumns+` FROM api_keys
+		WHERE owner_id = $1
+		ORDER BY created_at, id`, ownerID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var keys []domain.APIKey
+	for rows.Next() {
+		key, err := r.scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return keys, nil
+}
+
+// Update saves the name, secret and revocation of a key.
+func (r *APIKeyRepository) Update(ctx context.Context, key *domain.APIKey) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `
+		UPDATE api_keys SET
+			name = $2, secret_hash = $3, rotated_at = $4, revoked_at = $5
+		WHERE id = $1`,
+		key.ID,
+		key.Name,
+		key.SecretHash,
+		key.RotatedAt,
+		key.RevokedAt,
+	)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// RecordUsage counts one request against the key's UTC day.
+func (r *APIKeyRepository) RecordUsage(ctx context.Context, id uuid.UUID, at time.Time) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		WITH used AS (
+			UPDATE api_keys SET last_used_at = $2 WHERE id = $1
+		)
+		INSERT INTO api_key_usage (api_key_id, day, requests)
+		VALUES ($1, ($2::timestamptz AT TIME ZONE 'UTC')::date, 1)
+		ON CONFLICT (api_key_id, day) DO UPDATE SET requests = api_key_usage.requests + 1`,
+		id, at)
+
+	return mapError(err)
+}
+
+// Usage retrieves the key's daily request counts since the given day.
+func (r *APIKeyRepository) Usage(ctx context.Context, id uuid.UUID, since time.Time) ([]domain.APIKeyUsage, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT day, requests FROM api_key_usage
+		WHERE api_key_id = $1 AND day >= ($2::timestamptz AT TIME ZONE 'UTC')::date
+		ORDER BY day`, id, since)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var usage []domain.APIKeyUsage
+	for rows.Next() {
+		var u domain.APIKeyUsage
+		if err := rows.Scan(&u.Day, &u.Requests); err != nil {
+			return nil, mapError(err)
+		}
+		usage = append(usage, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return usage, nil
+}
+
+func (r *APIKeyRepository) scanAPIKey(row scannable) (*domain.APIKey, error) {
+	var key domain.APIKey
+	var environment string
+
+	err := row.Scan(
+		&key.ID,
+		&key.OwnerID,
+		&key.Name,
+		&environment,
+		&key.Prefix,
+		&key.SecretHash,
+		&key.Scopes,
+		&key.LastUsedAt,
+		&key.CreatedAt,
+		&key.RotatedAt,
+		&key.RevokedAt,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	key.Environment = domain.APIKeyEnvironment(environment)
+	return &key, nil
+}
@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// AttributeDefinitionRepository implements storage.AttributeDefinitionRepository using PostgreSQL.
+type AttributeDefinitionRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewAttributeDefinitionRepository creates a new attribute definition repository.
+func NewAttributeDefinitionRepository(pool *pgxpool.Pool) *AttributeDefinitionRepository {
+	return &AttributeDefinitionRepository{pool: pool}
+}
+
+const attributeDefinitionColumns = `key, type, COALESCE(description, ''), created_at, updated_at`
+
+// Create stores a new definition.
+func (r *AttributeDefinitionRepository) Create(ctx context.Context, def *domain.AttributeDefinition) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO attribute_definitions (key, type, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		def.Key,
+		string(def.Type),
+		def.Description,
+		def.CreatedAt,
+		def.UpdatedAt,
+	)
+
+	return mapError(err)
+}
+
+// Get retrieves a definition by key.
+func (r *AttributeDefinitionRepository) Get(ctx context.Context, key string) (*domain.AttributeDefinition, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `SELECT `+attributeDefinitionColumns+` FROM attribute_definitions WHERE key = $1`, key)
+
+	return r.scanDefinition(row)
+}
+
+// Update saves the definition's description. The type can't change, since
+// stored values would no longer match it.
+func (r *AttributeDefinitionRepository) Update(ctx context.Context, def *domain.AttributeDefinition) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `
+		UPDATE attribute_definitions SET description = $2
+		WHERE key = $1`,
+		def.Key,
+		def.Description,
+	)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// List retrieves every definition, ordered by key.
+func (r *AttributeDefinitionRepository) List(ctx context.Context) ([]domain.AttributeDefinition, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `SELECT `+attributeDefinitionColumns+` FROM attribute_definitions ORDER BY key`)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var defs []domain.AttributeDefinition
+	for rows.Next() {
+		def, err := r.scanDefinition(rows)
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, *def)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return defs, nil
+}
+
+// Delete removes a definition and strips its key from every user in the
+// same statement, so no user is left holding an undefined attribute.
+func (r *AttributeDefinitionRepository) Delete(ctx context.Context, key string) error {
+	db := getDB(ctx, r.pool)
+
+	var deleted int
+	err := db.QueryRow(ctx, `
+		WITH deleted AS (
+			DELETE FROM attribute_definitions WHERE key = $1 RETURNING key
+		), stripped AS (
+			UPDATE users SET attributes = attributes - $1::text, version = version + 1
+			WHERE attributes ? $1::text AND EXISTS (SELECT 1 FROM deleted)
+		)
+		SELECT COUNT(*) FROM deleted`, key).Scan(&deleted)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if deleted == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *AttributeDefinitionRepository) scanDefinition(row scannable) (*domain.AttributeDefinition, error) {
+	var def domain.AttributeDefinition
+	var attrType string
+
+	err := row.Scan(
+		&def.Key,
+		&attrType,
+		&def.Description,
+		&def.CreatedAt,
+		&def.UpdatedAt,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	def.Type = domain.AttributeType(attrType)
+
+	return &def, nil
+}
@@ -0,0 +1,145 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// ConsentRepository implements storage.ConsentRepository using PostgreSQL.
+type ConsentRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewConsentRepository creates a new partner consent repository.
+func NewConsentRepository(pool *pgxpool.Pool) *ConsentRepository {
+	return &ConsentRepository{pool: pool}
+}
+
+const consentColumns = `id, customer_id, partner_id, scopes, granted_at, updated_at, revoked_at`
+
+// Create stores a new consent.
+func (r *ConsentRepository) Create(ctx context.Context, consent *domain.Consent) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO partner_consents (`+consentColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		consent.ID,
+		consent.CustomerID,
+		consent.PartnerID,
+		consent.Scopes,
+		consent.GrantedAt,
+		consent.UpdatedAt,
+		consent.RevokedAt,
+	)
+
+	return mapError(err)
+}
+
+// GetByID retrieves a consent by ID.
+func (r *ConsentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Consent, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `SELECT `+consentColumns+` FROM partner_consents WHERE id = $1`, id)
+
+	return r.scanConsent(row)
+}
+
+// GetActive retrieves the customer's active consent for the partner.
+func (r *ConsentRepository) GetActive(ctx context.Context, customerID, partnerID uuid.UUID) (*domain.Consent, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `
+		SELECT `+consentColumns+` FROM partner_consents
+		WHERE customer_id = $1 AND partner_id = $2 AND revoked_at IS NULL`,
+		customerID, partnerID)
+
+	return r.scanConsent(row)
+}
+
+// ListForCustomer retrieves the consents a customer gave, newest first.
+func (r *ConsentRepository) ListForCustomer(ctx context.Context, customerID uuid.UUID, withRevoked bool) ([]domain.Consent, error) {
+	return r.listConsents(ctx, `
+		SELECT `+consentColumns+` FROM partner_consents
+		WHERE customer_id = $1 AND ($2 OR revoked_at IS NULL)
+		ORDER BY granted_at DESC, id`, customerID, withRevoked)
+}
+
+// ListForPartner retrieves the active consents given to a partner.
+func (r *ConsentRepository) ListForPartner(ctx context.Context, partnerID uuid.UUID) ([]domain.Consent, error) {
+	return r.listConsents(ctx, `
+		SELECT `+consentColumns+` FROM partner_consents
+		WHERE partner_id = $1 AND revoked_at IS NULL
+		ORDER BY granted_at DESC, id`, partnerID)
+}
+
+// Update saves the scopes and revocation of a consent.
+func (r *ConsentRepository) Update(ctx context.Context, consent *domain.Consent) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `
+		UPDATE partner_consents SET scopes = $2, updated_at = $3, revoked_at = $4
+		WHERE id = $1`,
+		consent.ID,
+		consent.Scopes,
+		consent.UpdatedAt,
+		consent.RevokedAt,
+	)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *ConsentRepository) listConsents(ctx context.Context, query string, args ...any) ([]domain.Consent, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var consents []domain.Consent
+	for rows.Next() {
+		consent, err := r.scanConsent(rows)
+		if err != nil {
+			return nil, err
+		}
+		consents = append(consents, *consent)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return consents, nil
+}
+
+func (r *ConsentRepository) scanConsent(row scannable) (*domain.Consent, error) {
+	var consent domain.Consent
+
+	err := row.Scan(
+		&consent.ID,
+		&consent.CustomerID,
+		&consent.PartnerID,
+		&consent.Scopes,
+		&consent.GrantedAt,
+		&consent.UpdatedAt,
+		&consent.RevokedAt,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &consent, nil
+}
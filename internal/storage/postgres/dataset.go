@@ -0,0 +1,414 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// DatasetRepository implements storage.DatasetRepository using PostgreSQL.
+type DatasetRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewDatasetRepository creates a new dataset repository.
+func NewDatasetRepository(pool *pgxpool.Pool) *DatasetRepository {
+	return &DatasetRepository{pool: pool}
+}
+
+// Export reads every table of the dataset in a read-only repeatable-read
+// transaction of its own.
+func (r *DatasetRepository) Export(ctx context.Context) (*storage.Dataset, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var d storage.Dataset
+
+	err = queryEach(ctx, tx, `
+		SELECT id, resource, action, COALESCE(description, ''), created_at
+		FROM permissions ORDER BY resource, action`,
+		func(rows pgx.Rows) error {
+			var p domain.Permission
+			if err := rows.Scan(&p.ID, &p.Resource, &p.Action, &p.Description, &p.CreatedAt); err != nil {
+				return err
+			}
+			d.Permissions = append(d.Permissions, p)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryEach(ctx, tx, `
+		SELECT id, name, slug, created_at, updated_at
+		FROM organizations ORDER BY slug`,
+		func(rows pgx.Rows) error {
+			var o domain.Organization
+			if err := rows.Scan(&o.ID, &o.Name, &o.Slug, &o.CreatedAt, &o.UpdatedAt); err != nil {
+				return err
+			}
+			d.Organizations = append(d.Organizations, o)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make(map[uuid.UUID]int)
+	err = queryEach(ctx, tx, `
+		SELECT id, name, COALESCE(description, ''), organization_id, created_at, updated_at
+		FROM roles ORDER BY organization_id NULLS FIRST, name`,
+		func(rows pgx.Rows) error {
+			var role domain.Role
+			if err := rows.Scan(&role.ID, &role.Name, &role.Description, &role.OrganizationID, &role.CreatedAt, &role.UpdatedAt); err != nil {
+				return err
+			}
+			roles[role.ID] = len(d.Roles)
+			d.Roles = append(d.Roles, role)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryEach(ctx, tx, `
+		SELECT role_id, permission_id, deny FROM role_permissions ORDER BY role_id, permission_id`,
+		func(rows pgx.Rows) error {
+			var roleID, permID uuid.UUID
+			var deny bool
+			if err := rows.Scan(&roleID, &permID, &deny); err != nil {
+				return err
+			}
+			role := &d.Roles[roles[roleID]]
+			role.Permissions = append(role.Permissions, domain.Permission{ID: permID, Deny: deny})
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[uuid.UUID]int)
+	err = queryEach(ctx, tx, `
+		SELECT id, name, COALESCE(description, ''), created_at, updated_at
+		FROM groups ORDER BY name`,
+		func(rows pgx.Rows) error {
+			var g domain.Group
+			if err := rows.Scan(&g.ID, &g.Name, &g.Description, &g.CreatedAt, &g.UpdatedAt); err != nil {
+				return err
+			}
+			groups[g.ID] = len(d.Groups)
+			d.Groups = append(d.Groups, g)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryEach(ctx, tx, `
+		SELECT group_id, role_id FROM group_roles ORDER BY group_id, role_id`,
+		func(rows pgx.Rows) error {
+			var groupID, roleID uuid.UUID
+			if err := rows.Scan(&groupID, &roleID); err != nil {
+				return err
+			}
+			g := &d.Groups[groups[groupID]]
+			g.Roles = append(g.Roles, domain.Role{ID: roleID})
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	users := make(map[uuid.UUID]int)
+	err = queryEach(ctx, tx, `
+		SELECT id, email, password_hash, phone, username, full_name,
+			   user_type, status, email_verified, phone_verified, attributes,
+			   COALESCE(locale, ''), COALESCE(timezone, ''),
+			   created_at, updated_at, deleted_at, version
+		FROM users ORDER BY created_at, id`,
+		func(rows pgx.Rows) error {
+			user, err := scanUserRow(rows)
+			if err != nil {
+				return err
+			}
+			users[user.ID] = len(d.Users)
+			d.Users = append(d.Users, *user)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryEach(ctx, tx, `
+		SELECT user_id, role_id, expires_at FROM user_roles
+		WHERE expires_at IS NULL OR expires_at > $1
+		ORDER BY user_id, created_at`,
+		func(rows pgx.Rows) error {
+			var userID uuid.UUID
+			var role domain.Role
+			if err := rows.Scan(&userID, &role.ID, &role.ExpiresAt); err != nil {
+				return err
+			}
+			user := &d.Users[users[userID]]
+			user.Roles = append(user.Roles, role)
+			return nil
+		}, domain.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryEach(ctx, tx, `
+		SELECT organization_id, user_id, joined_at
+		FROM organization_members ORDER BY organization_id, joined_at`,
+		func(rows pgx.Rows) error {
+			var m domain.OrganizationMember
+			if err := rows.Scan(&m.OrganizationID, &m.UserID, &m.JoinedAt); err != nil {
+				return err
+			}
+			d.OrganizationMembers = append(d.OrganizationMembers, m)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryEach(ctx, tx, `
+		SELECT group_id, user_id, joined_at
+		FROM group_members ORDER BY group_id, joined_at`,
+		func(rows pgx.Rows) error {
+			var m domain.GroupMember
+			if err := rows.Scan(&m.GroupID, &m.UserID, &m.JoinedAt); err != nil {
+				return err
+			}
+			d.GroupMembers = append(d.GroupMembers, m)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+// Import inserts the dataset table by table. Each record's ID is looked up
+// again by natural key when the insert conflicts, and links are written
+// with the IDs the records ended up with.
+func (r *DatasetRepository) Import(ctx context.Context, d *storage.Dataset) (storage.DatasetImportResult, error) {
+	db := getDB(ctx, r.pool)
+	var result storage.DatasetImportResult
+
+	perms := make(map[uuid.UUID]uuid.UUID, len(d.Permissions))
+	for _, p := range d.Permissions {
+		id, created, err := insertOrFind(ctx, db, `
+			INSERT INTO permissions (id, resource, action, description, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT DO NOTHING
+			RETURNING id`, []any{p.ID, p.Resource, p.Action, p.Description, p.CreatedAt},
+			`SELECT id FROM permissions WHERE resource = $1 AND action = $2`, p.Resource, p.Action)
+		if err != nil {
+			return result, fmt.Errorf("permission %s: %w", p.String(), err)
+		}
+		perms[p.ID] = id
+		countInsert(created, &result.PermissionsCreated, &result.PermissionsExisting)
+	}
+
+	orgs := make(map[uuid.UUID]uuid.UUID, len(d.Organizations))
+	for _, o := range d.Organizations {
+		id, created, err := insertOrFind(ctx, db, `
+			INSERT INTO organizations (id, name, slug, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT DO NOTHING
+			RETURNING id`, []any{o.ID, o.Name, o.Slug, o.CreatedAt, o.UpdatedAt},
+			`SELECT id FROM organizations WHERE slug = $1`, o.Slug)
+		if err != nil {
+			return result, fmt.Errorf("organization %s: %w", o.Slug, err)
+		}
+		orgs[o.ID] = id
+		countInsert(created, &result.OrganizationsCreated, &result.OrganizationsExisting)
+	}
+
+	roles := make(map[uuid.UUID]uuid.UUID, len(d.Roles))
+	for _, role := range d.Roles {
+		var orgID *uuid.UUID
+		if role.OrganizationID != nil {
+			id := orgs[*role.OrganizationID]
+			orgID = &id
+		}
+		id, created, err := insertOrFind(ctx, db, `
+			INSERT INTO roles (id, name, description, organization_id, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT DO NOTHING
+			RETURNING id`, []any{role.ID, role.Name, role.Description, orgID, role.CreatedAt, role.UpdatedAt},
+			`SELECT id FROM roles WHERE name = $1 AND organization_id IS NOT DISTINCT FROM $2`, role.Name, orgID)
+		if err != nil {
+			return result, fmt.Errorf("role %s: %w", role.Name, err)
+		}
+		roles[role.ID] = id
+		countInsert(created, &result.RolesCreated, &result.RolesExisting)
+
+		for _, p := range role.Permissions {
+			n, err := insertLink(ctx, db, `
+				INSERT INTO role_permissions (role_id, permission_id, deny)
+				VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`, id, perms[p.ID], p.Deny)
+			if err != nil {
+				return result, fmt.Errorf("role %s: %w", role.Name, err)
+			}
+			result.LinksCreated += n
+		}
+	}
+
+	groups := make(map[uuid.UUID]uuid.UUID, len(d.Groups))
+	for _, g := range d.Groups {
+		id, created, err := insertOrFind(ctx, db, `
+			INSERT INTO groups (id, name, description, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT DO NOTHING
+			RETURNING id`, []any{g.ID, g.Name, g.Description, g.CreatedAt, g.UpdatedAt},
+			`SELECT id FROM groups WHERE name = $1`, g.Name)
+		if err != nil {
+			return result, fmt.Errorf("group %s: %w", g.Name, err)
+		}
+		groups[g.ID] = id
+		countInsert(created, &result.GroupsCreated, &result.GroupsExisting)
+
+		for _, role := range g.Roles {
+			n, err := insertLink(ctx, db, `
+				INSERT INTO group_roles (group_id, role_id)
+				VALUES ($1, $2) ON CONFLICT DO NOTHING`, id, roles[role.ID])
+			if err != nil {
+				return result, fmt.Errorf("group %s: %w", g.Name, err)
+			}
+			result.LinksCreated += n
+		}
+	}
+
+	users := make(map[uuid.UUID]bool, len(d.Users))
+	for _, u := range d.Users {
+		tag, err := db.Exec(ctx, `
+			INSERT INTO users (
+				id, email, password_hash, phone, username, full_name,
+				user_type, status, email_verified, phone_verified, attributes,
+				locale, timezone, created_at, updated_at, deleted_at, version
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, ''), NULLIF($13, ''), $14, $15, $16, $17)
+			ON CONFLICT DO NOTHING`,
+			u.ID, u.Email, u.PasswordHash, u.Phone, u.Username, u.FullName,
+			string(u.Type), string(u.Status), u.EmailVerified, u.PhoneVerified, attributesOrEmpty(u.Attributes),
+			u.Locale, u.Timezone, u.CreatedAt, u.UpdatedAt, u.DeletedAt, u.Version)
+		if err != nil {
+			return result, fmt.Errorf("user %s: %w", u.Email, mapError(err))
+		}
+		if tag.RowsAffected() == 0 {
+			result.UsersSkipped = append(result.UsersSkipped, u.Email)
+			continue
+		}
+		users[u.ID] = true
+		result.UsersCreated++
+
+		for _, role := range u.Roles {
+			n, err := insertLink(ctx, db, `
+				INSERT INTO user_roles (user_id, role_id, expires_at)
+				VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`, u.ID, roles[role.ID], role.ExpiresAt)
+			if err != nil {
+				return result, fmt.Errorf("user %s: %w", u.Email, err)
+			}
+			result.LinksCreated += n
+		}
+	}
+
+	for _, m := range d.OrganizationMembers {
+		if !users[m.UserID] {
+			continue
+		}
+		n, err := insertLink(ctx, db, `
+			INSERT INTO organization_members (organization_id, user_id, joined_at)
+			VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`, orgs[m.OrganizationID], m.UserID, m.JoinedAt)
+		if err != nil {
+			return result, fmt.Errorf("organization member %s: %w", m.UserID, err)
+		}
+		result.LinksCreated += n
+	}
+
+	for _, m := range d.GroupMembers {
+		if !users[m.UserID] {
+			continue
+		}
+		n, err := insertLink(ctx, db, `
+			INSERT INTO group_members (group_id, user_id, joined_at)
+			VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`, groups[m.GroupID], m.UserID, m.JoinedAt)
+		if err != nil {
+			return result, fmt.Errorf("group member %s: %w", m.UserID, err)
+		}
+		result.LinksCreated += n
+	}
+
+	return result, nil
+}
+
+// queryEach runs query and calls fn on each row.
+func queryEach(ctx context.Context, db DBTX, query string, fn func(pgx.Rows) error, args ...any) error {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return mapError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return mapError(err)
+		}
+	}
+
+	return mapError(rows.Err())
+}
+
+// insertOrFind runs insert, which must return the ID of the row it adds
+// and do nothing on conflict, and falls back to finding the existing row's
+// ID with lookup. It reports whether the row was inserted.
+func insertOrFind(ctx context.Context, db DBTX, insert string, insertArgs []any, lookup string, lookupArgs ...any) (uuid.UUID, bool, error) {
+	var id uuid.UUID
+	err := db.QueryRow(ctx, insert, insertArgs...).Scan(&id)
+	if err == nil {
+		return id, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return id, false, mapError(err)
+	}
+
+	// The conflict may be on the ID of a different record, which leaves
+	// nothing to reuse
+	if err := db.QueryRow(ctx, lookup, lookupArgs...).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return id, false, fmt.Errorf("%w: ID is taken by a different record", domain.ErrConflict)
+		}
+		return id, false, mapError(err)
+	}
+	return id, false, nil
+}
+
+// insertLink runs an insert of a link row and returns how many rows it
+// added.
+func insertLink(ctx context.Context, db DBTX, insert string, args ...any) (int, error) {
+	tag, err := db.Exec(ctx, insert, args...)
+	if err != nil {
+		return 0, mapError(err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+func countInsert(created bool, createdCount, existingCount *int) {
+	if created {
+		*createdCount++
+	} else {
+		*existingCount++
+	}
+}
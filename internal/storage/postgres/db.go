@@ -40,6 +40,11 @@ func New(ctx context.Context, connString string) (*DB, error) {
 	return &DB{pool: pool}, nil
 }
 
+// NewTransactor returns a storage.Transactor for an existing pool.
+func NewTransactor(pool *pgxpool.Pool) *DB {
+	return &DB{pool: pool}
+}
+
 // Close closes all connections in the pool.
 func (db *DB) Close() {
 	db.pool.Close()
@@ -54,10 +59,41 @@ func (db *DB) Pool() *pgxpool.Pool {
 // Repositories returns all repositories backed by this database.
 func (db *DB) Repositories() *storage.Repositories {
 	return &storage.Repositories{
-		Users:       NewUserRepository(db.pool),
-		Roles:       NewRoleRepository(db.pool),
-		Permissions: NewPermissionRepository(db.pool),
-		Tokens:      NewTokenRepository(db.pool),
+		Users:          NewUserRepository(db.pool),
+		Roles:          NewRoleRepository(db.pool),
+		Permissions:    NewPermissionRepository(db.pool),
+		Tokens:         NewTokenRepository(db.pool),
+		History:        NewHistoryRepository(db.pool),
+		Webhooks:       NewWebhookRepository(db.pool),
+		Directory:      NewUserDirectoryRepository(db.pool),
+		Orgs:           NewOrganizationRepository(db.pool),
+		Groups:         NewGroupRepository(db.pool),
+		Invitations:    NewInvitationRepository(db.pool),
+		Idempotency:    NewIdempotencyRepository(db.pool),
+		Impersonations: NewImpersonationRepository(db.pool),
+		Attributes:     NewAttributeDefinitionRepository(db.pool),
+		Devices:        NewDeviceRepository(db.pool),
+		LoginAttempts:  NewLoginAttemptRepository(db.pool),
+		Passwords:      NewPasswordHistoryRepository(db.pool),
+		Usernames:      NewUsernameHistoryRepository(db.pool),
+		Deletions:      NewAccountDeletionRepository(db.pool),
+		NotifyPrefs:    NewNotificationPreferenceRepository(db.pool),
+		Profiles:       NewProfileRepository(db.pool),
+		APIKeys:        NewAPIKeyRepository(db.pool),
+		Consents:       NewConsentRepository(db.pool),
+		ActionTokens:   NewActionTokenRepository(db.pool),
+		Datasets:       NewDatasetRepository(db.pool),
+		Schema:         NewSchemaRepository(db.pool),
+		ResourceGrants: NewResourceGrantRepository(db.pool),
+		SupportLookups: NewSupportLookupRepository(db.pool),
+		SCIM:           NewSCIMRepository(db.pool),
+		LDAPMappings:   NewLDAPMappingRepository(db.pool),
+		SAMLProviders:  NewSAMLProviderRepository(db.pool),
+		Identities:     NewFederatedIdentityRepository(db.pool),
+		DeviceAuths:    NewDeviceAuthorizationRepository(db.pool),
+		OAuthClients:   NewOAuthClientRepository(db.pool),
+		TokenExchanges: NewTokenExchangeRepository(db.pool),
+		AccessTokens:   NewAccessTokenRepository(db.pool),
 	}
 }
 
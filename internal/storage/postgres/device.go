@@ -0,0 +1,177 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// DeviceRepository implements storage.DeviceRepository using PostgreSQL.
+type DeviceRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewDeviceRepository creates a new known device repository.
+func NewDeviceRepository(pool *pgxpool.Pool) *DeviceRepository {
+	return &DeviceRepository{pool: pool}
+}
+
+const deviceColumns = `id, user_id, fingerprint, COALESCE(user_agent, ''), COALESCE(last_ip_address, ''),
+	networks, trusted, confirmed_at, first_seen_at, last_seen_at`
+
+// Create stores a new device.
+func (r *DeviceRepository) Create(ctx context.Context, device *domain.KnownDevice) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO known_devices (
+			id, user_id, fingerprint, user_agent, last_ip_address, networks, trusted,
+			confirmed_at, first_seen_at, last_seen_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		device.ID,
+		device.UserID,
+		device.Fingerprint,
+		device.UserAgent,
+		device.LastIPAddress,
+		networksOrEmpty(device.Networks),
+		device.Trusted,
+		device.ConfirmedAt,
+		device.FirstSeenAt,
+		device.LastSeenAt,
+	)
+
+	return mapError(err)
+}
+
+// GetByID retrieves a device by ID.
+func (r *DeviceRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.KnownDevice, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `SELECT `+deviceColumns+` FROM known_devices WHERE id = $1`, id)
+
+	return r.scanDevice(row)
+}
+
+// GetByFingerprint retrieves a user's device by fingerprint.
+func (r *DeviceRepository) GetByFingerprint(ctx context.Context, userID uuid.UUID, fingerprint string) (*domain.KnownDevice, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `
+		SELECT `+deviceColumns+` FROM known_devices
+		WHERE user_id = $1 AND fingerprint = $2`,
+		userID, fingerprint)
+
+	return r.scanDevice(row)
+}
+
+// Update saves the device's mutable fields.
+func (r *DeviceRepository) Update(ctx context.Context, device *domain.KnownDevice) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `
+		UPDATE known_devices SET
+			user_agent = $2,
+			last_ip_address = $3,
+			networks = $4,
+			trusted = $5,
+			confirmed_at = $6,
+			last_seen_at = $7
+		WHERE id = $1`,
+		device.ID,
+		device.UserAgent,
+		device.LastIPAddress,
+		networksOrEmpty(device.Networks),
+		device.Trusted,
+		device.ConfirmedAt,
+		device.LastSeenAt,
+	)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// ListForUser retrieves a user's devices, most recently seen first.
+func (r *DeviceRepository) ListForUser(ctx context.Context, userID uuid.UUID) ([]domain.KnownDevice, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT `+deviceColumns+` FROM known_devices
+		WHERE user_id = $1
+		ORDER BY last_seen_at DESC`,
+		userID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var devices []domain.KnownDevice
+	for rows.Next() {
+		device, err := r.scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, *device)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return devices, nil
+}
+
+// Delete removes a device.
+func (r *DeviceRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `DELETE FROM known_devices WHERE id = $1`, id)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *DeviceRepository) scanDevice(row scannable) (*domain.KnownDevice, error) {
+	var d domain.KnownDevice
+
+	err := row.Scan(
+		&d.ID,
+		&d.UserID,
+		&d.Fingerprint,
+		&d.UserAgent,
+		&d.LastIPAddress,
+		&d.Networks,
+		&d.Trusted,
+		&d.ConfirmedAt,
+		&d.FirstSeenAt,
+		&d.LastSeenAt,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &d, nil
+}
+
+// networksOrEmpty stores a nil slice as an empty array, matching the
+// column's NOT NULL constraint.
+func networksOrEmpty(networks []string) []string {
+	if networks == nil {
+		return []string{}
+	}
+	return networks
+}
@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// DeviceAuthorizationRepository implements
+// storage.DeviceAuthorizationRepository using PostgreSQL.
+type DeviceAuthorizationRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewDeviceAuthorizationRepository creates a new device authorization
+// repository.
+func NewDeviceAuthorizationRepository(pool *pgxpool.Pool) *DeviceAuthorizationRepository {
+	return &DeviceAuthorizationRepository{pool: pool}
+}
+
+const deviceAuthorizationColumns = `id, device_code_hash, user_code, client_id, scope, status,
+	user_id, interval_seconds, last_polled_at, expires_at, created_at`
+
+// Create stores a new authorization.
+func (r *DeviceAuthorizationRepository) Create(ctx context.Context, a *domain.DeviceAuthorization) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO device_authorizations (`+deviceAuthorizationColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		a.ID,
+		a.DeviceCodeHash,
+		a.UserCode,
+		a.ClientID,
+		a.Scope,
+		string(a.Status),
+		a.UserID,
+		int(a.Interval/time.Second),
+		a.LastPolledAt,
+		a.ExpiresAt,
+		a.CreatedAt,
+	)
+
+	return mapError(err)
+}
+
+// GetByDeviceCodeHash retrieves an authorization by its device code hash.
+func (r *DeviceAuthorizationRepository) GetByDeviceCodeHash(ctx context.Context, hash string) (*domain.DeviceAuthorization, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `SELECT `+deviceAuthorizationColumns+` FROM device_authorizations WHERE device_code_hash = $1`, hash)
+
+	return r.scanDeviceAuthorization(row)
+}
+
+// GetByUserCode retrieves an authorization by its user code.
+func (r *DeviceAuthorizationRepository) GetByUserCode(ctx context.Context, userCode string) (*domain.DeviceAuthorization, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `SELECT `+deviceAuthorizationColumns+` FROM device_authorizations WHERE user_code = $1`, userCode)
+
+	return r.scanDeviceAuthorization(row)
+}
+
+// Update saves changes to an authorization.
+func (r *DeviceAuthorizationRepository) Update(ctx context.Context, a *domain.DeviceAuthorization) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `
+		UPDATE device_authorizations SET
+			status = $2, user_id = $3, interval_seconds = $4, last_polled_at = $5
+		WHERE id = $1`,
+		a.ID,
+		string(a.Status),
+		a.UserID,
+		int(a.Interval/time.Second),
+		a.LastPolledAt,
+	)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete removes an authorization.
+func (r *DeviceAuthorizationRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `DELETE FROM device_authorizations WHERE id = $1`, id)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteExpired removes expired authorizations, decided or not.
+func (r *DeviceAuthorizationRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `DELETE FROM device_authorizations WHERE expires_at <= $1`, domain.Now())
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *DeviceAuthorizationRepository) scanDeviceAuthorization(row scannable) (*domain.DeviceAuthorization, error) {
+	var a domain.DeviceAuthorization
+	var status string
+	var interval int
+
+	err := row.Scan(
+		&a.ID,
+		&a.DeviceCodeHash,
+		&a.UserCode,
+		&a.ClientID,
+		&a.Scope,
+		&status,
+		&a.UserID,
+		&interval,
+		&a.LastPolledAt,
+		&a.ExpiresAt,
+		&a.CreatedAt,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	a.Status = domain.DeviceAuthorizationStatus(status)
+	a.Interval = time.Duration(interval) * time.Second
+
+	return &a, nil
+}
@@ -0,0 +1,182 @@
+package postgres
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// UserDirectoryRepository implements storage.UserDirectoryRepository using PostgreSQL.
+// The projection query lives in the refresh_user_directory function
+// (see migrations/004_user_directory) so backfill and runtime share it.
+type UserDirectoryRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewUserDirectoryRepository creates a new user directory repository.
+func NewUserDirectoryRepository(pool *pgxpool.Pool) *UserDirectoryRepository {
+	return &UserDirectoryRepository{pool: pool}
+}
+
+// Refresh rebuilds the directory row for a user.
+func (r *UserDirectoryRepository) Refresh(ctx context.Context, userID uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `SELECT refresh_user_directory($1)`, userID)
+
+	return mapError(err)
+}
+
+// RefreshAll rebuilds every directory row.
+func (r *UserDirectoryRepository) RefreshAll(ctx context.Context) (int64, error) {
+	db := getDB(ctx, r.pool)
+
+	var n int64
+	err := db.QueryRow(ctx, `SELECT refresh_user_directory(NULL)`).Scan(&n)
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	return n, nil
+}
+
+// RecordLogin advances last_login_at; out-of-order events never move it backwards.
+func (r *UserDirectoryRepository) RecordLogin(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		UPDATE user_directory
+		SET last_login_at = GREATEST(COALESCE(last_login_at, $2), $2)
+		WHERE user_id = $1`, userID, at)
+
+	return mapError(err)
+}
+
+// List retrieves user summaries with pagination and optional filtering.
+func (r *UserDirectoryRepository) List(ctx context.Context, filter storage.DirectoryFilter) ([]domain.UserSummary, int64, error) {
+	db := getDB(ctx, r.pool)
+
+	args := []any{}
+	argIndex := 1
+
+	whereClause := "1=1"
+	if !filter.Deleted {
+		whereClause += " AND deleted_at IS NULL"
+	}
+
+	if filter.Status != nil {
+		whereClause += " AND status = $" + strconv.Itoa(argIndex)
+		args = append(args, string(*filter.Status))
+		argIndex++
+	}
+
+	if filter.Type != nil {
+		whereClause += " AND user_type = $" + strconv.Itoa(argIndex)
+		args = append(args, string(*filter.Type))
+		argIndex++
+	}
+
+	if filter.OrganizationID != nil {
+		whereClause += " AND organization_ids @> ARRAY[$" + strconv.Itoa(argIndex) + "::uuid]"
+		args = append(args, *filter.OrganizationID)
+		argIndex++
+	}
+
+	if filter.Role != "" {
+		whereClause += " AND role_names @> ARRAY[$" + strconv.Itoa(argIndex) + "::text]"
+		args = append(args, filter.Role)
+		argIndex++
+	}
+
+	if len(filter.Attributes) > 0 {
+		whereClause += " AND user_id IN (SELECT id FROM users WHERE attributes @> $" + strconv.Itoa(argIndex) + "::jsonb)"
+		args = append(args, filter.Attributes)
+		argIndex++
+	}
+
+	if filter.Locale != "" {
+		whereClause += " AND user_id IN (SELECT id FROM users WHERE locale = $" + strconv.Itoa(argIndex) + " OR locale LIKE $" + strconv.Itoa(argIndex) + " || '-%')"
+		args = append(args, filter.Locale)
+		argIndex++
+	}
+
+	if filter.Timezone != "" {
+		whereClause += " AND user_id IN (SELECT id FROM users WHERE timezone = $" + strconv.Itoa(argIndex) + ")"
+		args = append(args, filter.Timezone)
+		argIndex++
+	}
+
+	if filter.Search != "" {
+		whereClause += " AND (LOWER(email) LIKE LOWER($" + strconv.Itoa(argIndex) + ") OR " +
+			"LOWER(username) LIKE LOWER($" + strconv.Itoa(argIndex) + ") OR " +
+			"LOWER(full_name) LIKE LOWER($" + strconv.Itoa(argIndex) + "))"
+		args = append(args, "%"+filter.Search+"%")
+		argIndex++
+	}
+
+	var total int64
+	err := db.QueryRow(ctx, "SELECT COUNT(*) FROM user_directory WHERE "+whereClause, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	listArgs := append(args, filter.Limit, filter.Offset)
+	listQuery := `
+		SELECT user_id, email, phone, username, full_name, user_type, status,
+			   email_verified, phone_verified, role_names, organization_ids, last_login_at, active_sessions,
+			   created_at, updated_at, deleted_at, refreshed_at
+		FROM user_directory WHERE ` + whereClause + `
+		ORDER BY created_at DESC
+		LIMIT $` + strconv.Itoa(argIndex) + ` OFFSET $` + strconv.Itoa(argIndex+1)
+
+	rows, err := db.Query(ctx, listQuery, listArgs...)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+	defer rows.Close()
+
+	var summaries []domain.UserSummary
+	for rows.Next() {
+		var s domain.UserSummary
+		var userType, status string
+
+		err := rows.Scan(
+			&s.ID,
+			&s.Email,
+			&s.Phone,
+			&s.Username,
+			&s.FullName,
+			&userType,
+			&status,
+			&s.EmailVerified,
+			&s.PhoneVerified,
+			&s.RoleNames,
+			&s.OrganizationIDs,
+			&s.LastLoginAt,
+			&s.ActiveSessions,
+			&s.CreatedAt,
+			&s.UpdatedAt,
+			&s.DeletedAt,
+			&s.RefreshedAt,
+		)
+		if err != nil {
+			return nil, 0, mapError(err)
+		}
+
+		s.Type = domain.UserType(userType)
+		s.Status = domain.UserStatus(status)
+		summaries = append(summaries, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	return summaries, total, nil
+}
@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// SandboxSchema holds the sandbox copy of every table, created by running
+// the migrations with it first on the search path (make migrate-sandbox-up).
+const SandboxSchema = "sandbox"
+
+// searchPathKey is the connection's custom data key for the environment
+// its search_path was last set for.
+const searchPathKey = "aegis.environment"
+
+// IsolateEnvironments makes connections acquired from the pool use the
+// schema of the context's environment (see domain.WithEnvironment):
+// sandbox requests only see SandboxSchema, production requests the
+// connection's default search_path. Since every repository acquires its
+// connection, or begins its transaction, with the request context, this is
+// the one place isolation is enforced.
+func IsolateEnvironments(config *pgxpool.Config) {
+	next := config.BeforeAcquire
+	config.BeforeAcquire = func(ctx context.Context, conn *pgx.Conn) bool {
+		if next != nil && !next(ctx, conn) {
+			return false
+		}
+
+		env := domain.EnvironmentFromContext(ctx)
+		data := conn.PgConn().CustomData()
+		current, set := data[searchPathKey].(domain.Environment)
+		if set && current == env {
+			return true
+		}
+		if !set && !env.IsSandbox() {
+			// New connections start on the default search_path
+			data[searchPathKey] = env
+			return true
+		}
+
+		sql := "RESET search_path"
+		if env.IsSandbox() {
+			sql = "SET search_path TO " + SandboxSchema
+		}
+		if _, err := conn.Exec(ctx, sql); err != nil {
+			// Destroy the connection rather than risk using the wrong schema
+			return false
+		}
+		data[searchPathKey] = env
+		return true
+	}
+}
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// FederatedIdentityRepository implements storage.FederatedIdentityRepository
+// using PostgreSQL.
+type FederatedIdentityRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewFederatedIdentityRepository creates a new federated identity
+// repository.
+func NewFederatedIdentityRepository(pool *pgxpool.Pool) *FederatedIdentityRepository {
+	return &FederatedIdentityRepository{pool: pool}
+}
+
+// Create stores a new link.
+func (r *FederatedIdentityRepository) Create(ctx context.Context, identity *domain.FederatedIdentity) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO federated_identities (id, provider, subject, user_id, created_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		identity.ID,
+		identity.Provider,
+		identity.Subject,
+		identity.UserID,
+		identity.CreatedAt,
+	)
+
+	return mapError(err)
+}
+
+// GetBySubject retrieves the link of a provider's subject.
+func (r *FederatedIdentityRepository) GetBySubject(ctx context.Context, provider, subject string) (*domain.FederatedIdentity, error) {
+	db := getDB(ctx, r.pool)
+
+	var fi domain.FederatedIdentity
+	err := db.QueryRow(ctx, `
+		SELECT id, provider, subject, user_id, created_at
+		FROM federated_identities
+		WHERE provider = $1 AND subject = $2`, provider, subject,
+	).Scan(&fi.ID, &fi.Provider, &fi.Subject, &fi.UserID, &fi.CreatedAt)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &fi, nil
+}
+
+// List retrieves a provider's links.
+func (r *FederatedIdentityRepository) List(ctx context.Context, provider string) ([]domain.FederatedIdentity, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT id, provider, subject, user_id, created_at
+		FROM federated_identities
+		WHERE provider = $1
+		ORDER BY subject`, provider)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var identities []domain.FederatedIdentity
+	for rows.Next() {
+		var fi domain.FederatedIdentity
+		if err := rows.Scan(&fi.ID, &fi.Provider, &fi.Subject, &fi.UserID, &fi.CreatedAt); err != nil {
+			return nil, mapError(err)
+		}
+		identities = append(identities, fi)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return identities, nil
+}
+
+// Delete removes one of a provider's links.
+func (r *FederatedIdentityRepository) Delete(ctx context.Context, provider string, id uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `DELETE FROM federated_identities WHERE provider = $1 AND id = $2`, provider, id)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteAll removes every link of a provider.
+func (r *FederatedIdentityRepository) DeleteAll(ctx context.Context, provider string) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `DELETE FROM federated_identities WHERE provider = $1`, provider)
+	return mapError(err)
+}
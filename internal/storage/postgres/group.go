@@ -0,0 +1,328 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// GroupRepository implements storage.GroupRepository using PostgreSQL.
+type GroupRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewGroupRepository creates a new group repository.
+func NewGroupRepository(pool *pgxpool.Pool) *GroupRepository {
+	return &GroupRepository{pool: pool}
+}
+
+// Create stores a new group.
+func (r *GroupRepository) Create(ctx context.Context, group *domain.Group) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO groups (id, name, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		group.ID,
+		group.Name,
+		group.Description,
+		group.CreatedAt,
+		group.UpdatedAt,
+	)
+
+	return mapError(err)
+}
+
+// GetByID retrieves a group by ID with its roles.
+func (r *GroupRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Group, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `
+		SELECT id, name, COALESCE(description, ''), created_at, updated_at
+		FROM groups WHERE id = $1`, id)
+
+	group, err := r.scanGroup(row)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := r.getGroupRoles(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	group.Roles = roles
+
+	return group, nil
+}
+
+// GetByName retrieves a group by name with its roles.
+func (r *GroupRepository) GetByName(ctx context.Context, name string) (*domain.Group, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `
+		SELECT id, name, COALESCE(description, ''), created_at, updated_at
+		FROM groups WHERE LOWER(name) = LOWER($1)`, name)
+
+	group, err := r.scanGroup(row)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := r.getGroupRoles(ctx, group.ID)
+	if err != nil {
+		return nil, err
+	}
+	group.Roles = roles
+
+	return group, nil
+}
+
+// Update saves changes to an existing group.
+func (r *GroupRepository) Update(ctx context.Context, group *domain.Group) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `
+		UPDATE groups SET name = $2, description = $3
+		WHERE id = $1`,
+		group.ID,
+		group.Name,
+		group.Description,
+	)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a group. Memberships and role assignments cascade.
+func (r *GroupRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `DELETE FROM groups WHERE id = $1`, id)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// List retrieves groups ordered by name. Roles are not loaded.
+func (r *GroupRepository) List(ctx context.Context, offset, limit int) ([]domain.Group, int64, error) {
+	db := getDB(ctx, r.pool)
+
+	var total int64
+	if err := db.QueryRow(ctx, `SELECT COUNT(*) FROM groups`).Scan(&total); err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT id, name, COALESCE(description, ''), created_at, updated_at
+		FROM groups
+		ORDER BY name
+		LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+	defer rows.Close()
+
+	var groups []domain.Group
+	for rows.Next() {
+		group, err := r.scanGroup(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		groups = append(groups, *group)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	return groups, total, nil
+}
+
+// AddMember adds a user to a group.
+func (r *GroupRepository) AddMember(ctx context.Context, groupID, userID uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO group_members (group_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT (group_id, user_id) DO NOTHING`,
+		groupID, userID)
+
+	return mapError(err)
+}
+
+// RemoveMember removes a user from a group.
+func (r *GroupRepository) RemoveMember(ctx context.Context, groupID, userID uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		DELETE FROM group_members
+		WHERE group_id = $1 AND user_id = $2`,
+		groupID, userID)
+
+	return mapError(err)
+}
+
+// ListMembers retrieves a group's members, oldest first.
+func (r *GroupRepository) ListMembers(ctx context.Context, groupID uuid.UUID, offset, limit int) ([]domain.GroupMember, int64, error) {
+	db := getDB(ctx, r.pool)
+
+	var total int64
+	err := db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM group_members WHERE group_id = $1`, groupID).Scan(&total)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT group_id, user_id, joined_at
+		FROM group_members
+		WHERE group_id = $1
+		ORDER BY joined_at, user_id
+		LIMIT $2 OFFSET $3`, groupID, limit, offset)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+	defer rows.Close()
+
+	var members []domain.GroupMember
+	for rows.Next() {
+		var m domain.GroupMember
+		if err := rows.Scan(&m.GroupID, &m.UserID, &m.JoinedAt); err != nil {
+			return nil, 0, mapError(err)
+		}
+		members = append(members, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	return members, total, nil
+}
+
+// ListForUser retrieves the groups a user belongs to.
+func (r *GroupRepository) ListForUser(ctx context.Context, userID uuid.UUID) ([]domain.Group, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT g.id, g.name, COALESCE(g.description, ''), g.created_at, g.updated_at
+		FROM groups g
+		JOIN group_members gm ON g.id = gm.group_id
+		WHERE gm.user_id = $1
+		ORDER BY g.name`, userID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var groups []domain.Group
+	for rows.Next() {
+		group, err := r.scanGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, *group)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return groups, nil
+}
+
+// AssignRole assigns a role to a group.
+func (r *GroupRepository) AssignRole(ctx context.Context, groupID, roleID uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO group_roles (group_id, role_id)
+		VALUES ($1, $2)
+		ON CONFLICT (group_id, role_id) DO NOTHING`,
+		groupID, roleID)
+
+	return mapError(err)
+}
+
+// RemoveRole removes a role from a group.
+func (r *GroupRepository) RemoveRole(ctx context.Context, groupID, roleID uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		DELETE FROM group_roles
+		WHERE group_id = $1 AND role_id = $2`,
+		groupID, roleID)
+
+	return mapError(err)
+}
+
+func (r *GroupRepository) getGroupRoles(ctx context.Context, groupID uuid.UUID) ([]domain.Role, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT r.id, r.name, r.description, r.organization_id, r.created_at, r.updated_at
+		FROM roles r
+		JOIN group_roles gr ON r.id = gr.role_id
+		WHERE gr.group_id = $1
+		ORDER BY r.name`, groupID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var roles []domain.Role
+	for rows.Next() {
+		var role domain.Role
+		err := rows.Scan(
+			&role.ID,
+			&role.Name,
+			&role.Description,
+			&role.OrganizationID,
+			&role.CreatedAt,
+			&role.UpdatedAt,
+		)
+		if err != nil {
+			return nil, mapError(err)
+		}
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return roles, nil
+}
+
+func (r *GroupRepository) scanGroup(row scannable) (*domain.Group, error) {
+	var group domain.Group
+
+	err := row.Scan(
+		&group.ID,
+		&group.Name,
+		&group.Description,
+		&group.CreatedAt,
+		&group.UpdatedAt,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &group, nil
+}
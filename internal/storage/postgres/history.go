@@ -0,0 +1,233 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// HistoryRepository implements storage.HistoryRepository using PostgreSQL.
+// History rows are written by triggers (see migrations/002_user_history).
+type HistoryRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewHistoryRepository creates a new history repository.
+func NewHistoryRepository(pool *pgxpool.Pool) *HistoryRepository {
+	return &HistoryRepository{pool: pool}
+}
+
+// GetUserAsOf returns the latest user snapshot recorded at or before at.
+func (r *HistoryRepository) GetUserAsOf(ctx context.Context, id uuid.UUID, at time.Time) (*domain.User, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `
+		SELECT user_id, email, phone, username, full_name,
+			   user_type, status, email_verified, phone_verified, attributes,
+			   COALESCE(locale, ''), COALESCE(timezone, ''),
+			   created_at, updated_at, deleted_at, version
+		FROM users_history
+		WHERE user_id = $1 AND valid_from <= $2
+		ORDER BY valid_from DESC, history_id DESC
+		LIMIT 1`, id, at)
+
+	var user domain.User
+	var userType, status string
+
+	err := row.Scan(
+		&user.ID,
+		&user.Email,
+		&user.Phone,
+		&user.Username,
+		&user.FullName,
+		&userType,
+		&status,
+		&user.EmailVerified,
+		&user.PhoneVerified,
+		&user.Attributes,
+		&user.Locale,
+		&user.Timezone,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.DeletedAt,
+		&user.Version,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	user.Type = domain.UserType(userType)
+	user.Status = domain.UserStatus(status)
+
+	return &user, nil
+}
+
+// GetUserRolesAsOf returns the memberships whose validity interval contains at.
+func (r *HistoryRepository) GetUserRolesAsOf(ctx context.Context, userID uuid.UUID, at time.Time) ([]domain.Role, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT DISTINCT ON (role_id) role_id, role_name
+		FROM user_roles_history
+		WHERE user_id = $1 AND valid_from <= $2 AND (valid_to IS NULL OR valid_to > $2)
+		ORDER BY role_id, valid_from DESC`, userID, at)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var roles []domain.Role
+	for rows.Next() {
+		var role domain.Role
+		if err := rows.Scan(&role.ID, &role.Name); err != nil {
+			return nil, mapError(err)
+		}
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return roles, nil
+}
+
+// timelineEntries derives a user's timeline entries ($1) from the tables
+// that record them. Consecutive users_history snapshots are compared to
+// find what each update changed.
+const timelineEntries = `
+	WITH versions AS (
+		SELECT valid_from, created_at, status,
+			ROW_NUMBER() OVER w AS n,
+			LAG(status) OVER w AS prev_status,
+			ARRAY_REMOVE(ARRAY[
+				CASE WHEN email IS DISTINCT FROM LAG(email) OVER w THEN 'email' END,
+				CASE WHEN phone IS DISTINCT FROM LAG(phone) OVER w THEN 'phone' END,
+				CASE WHEN username IS DISTINCT FROM LAG(username) OVER w THEN 'username' END,
+				CASE WHEN full_name IS DISTINCT FROM LAG(full_name) OVER w THEN 'full_name' END,
+				CASE WHEN user_type IS DISTINCT FROM LAG(user_type) OVER w THEN 'user_type' END,
+				CASE WHEN email_verified IS DISTINCT FROM LAG(email_verified) OVER w THEN 'email_verified' END,
+				CASE WHEN phone_verified IS DISTINCT FROM LAG(phone_verified) OVER w THEN 'phone_verified' END,
+				CASE WHEN attributes IS DISTINCT FROM LAG(attributes) OVER w THEN 'attributes' END,
+				CASE WHEN deleted_at IS DISTINCT FROM LAG(deleted_at) OVER w THEN 'deleted_at' END
+			], NULL) AS fields
+		FROM users_history
+		WHERE user_id = $1
+		WINDOW w AS (ORDER BY valid_from, history_id)
+	),
+	entries (kind, occurred_at, data) AS (
+		SELECT 'user.created', created_at, '{}'::jsonb
+		FROM versions WHERE n = 1
+		UNION ALL
+		SELECT 'user.updated', valid_from, jsonb_build_object('fields', fields)
+		FROM versions WHERE n > 1 AND cardinality(fields) > 0
+		UNION ALL
+		SELECT 'user.status_changed', valid_from, jsonb_build_object('from', prev_status, 'to', status)
+		FROM versions WHERE n > 1 AND status IS DISTINCT FROM prev_status
+		UNION ALL
+		SELECT CASE WHEN success THEN 'login.succeeded' ELSE 'login.failed' END, created_at,
+			jsonb_strip_nulls(jsonb_build_object(
+				'ip_address', ip_address, 'user_agent', user_agent, 'failure_reason', failure_reason))
+		FROM login_attempts WHERE user_id = $1
+		UNION ALL
+		SELECT 'role.assigned', valid_from, jsonb_build_object('role_id', role_id, 'role', role_name)
+		FROM user_roles_history WHERE user_id = $1
+		UNION ALL
+		SELECT 'role.removed', valid_to, jsonb_build_object('role_id', role_id, 'role', role_name)
+		FROM user_roles_history WHERE user_id = $1 AND valid_to IS NOT NULL
+		UNION ALL
+		-- The password set at sign-up is recorded too; it isn't a change
+		SELECT 'password.changed', ph.created_at, '{}'::jsonb
+		FROM password_history ph JOIN users u ON u.id = ph.user_id
+		WHERE ph.user_id = $1 AND ph.created_at > u.created_at + INTERVAL '5 seconds'
+		UNION ALL
+		SELECT 'device.added', first_seen_at,
+			jsonb_strip_nulls(jsonb_build_object('device_id', id, 'user_agent', user_agent))
+		FROM known_devices WHERE user_id = $1
+		UNION ALL
+		SELECT 'impersonation.started', started_at,
+			jsonb_build_object('session_id', id, 'actor_id', actor_id, 'reason', reason)
+		FROM impersonation_sessions WHERE target_id = $1
+		UNION ALL
+		SELECT 'impersonation.ended', ended_at, jsonb_build_object('session_id', id, 'actor_id', actor_id)
+		FROM impersonation_sessions WHERE target_id = $1 AND ended_at IS NOT NULL
+	)`
+
+// ListTimeline merges a user's history into a single feed, newest first.
+// Password changes only appear while password history is kept.
+func (r *HistoryRepository) ListTimeline(ctx context.Context, filter storage.TimelineFilter) ([]domain.TimelineEntry, int64, error) {
+	db := getDB(ctx, r.pool)
+
+	if filter.Limit <= 0 {
+		filter.Limit = 20
+	}
+	if filter.Limit > 100 {
+		filter.Limit = 100
+	}
+
+	args := []any{filter.UserID}
+	argIndex := 2
+	whereClause := "TRUE"
+
+	if len(filter.Kinds) > 0 {
+		kinds := make([]string, len(filter.Kinds))
+		for i, kind := range filter.Kinds {
+			kinds[i] = string(kind)
+		}
+		whereClause += " AND kind = ANY($" + string(rune('0'+argIndex)) + ")"
+		args = append(args, kinds)
+		argIndex++
+	}
+
+	if filter.Since != nil {
+		whereClause += " AND occurred_at >= $" + string(rune('0'+argIndex))
+		args = append(args, *filter.Since)
+		argIndex++
+	}
+
+	if filter.Until != nil {
+		whereClause += " AND occurred_at < $" + string(rune('0'+argIndex))
+		args = append(args, *filter.Until)
+		argIndex++
+	}
+
+	var total int64
+	err := db.QueryRow(ctx, timelineEntries+" SELECT COUNT(*) FROM entries WHERE "+whereClause, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	listArgs := append(args, filter.Limit, filter.Offset)
+	rows, err := db.Query(ctx, timelineEntries+`
+		SELECT kind, occurred_at, data
+		FROM entries WHERE `+whereClause+`
+		ORDER BY occurred_at DESC, kind
+		LIMIT $`+string(rune('0'+argIndex))+` OFFSET $`+string(rune('0'+argIndex+1)),
+		listArgs...)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+	defer rows.Close()
+
+	var entries []domain.TimelineEntry
+	for rows.Next() {
+		var e domain.TimelineEntry
+		var kind string
+		if err := rows.Scan(&kind, &e.OccurredAt, &e.Data); err != nil {
+			return nil, 0, mapError(err)
+		}
+		e.Kind = domain.TimelineKind(kind)
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	return entries, total, nil
+}
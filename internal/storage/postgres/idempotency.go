@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// IdempotencyRepository implements storage.IdempotencyRepository using PostgreSQL.
+type IdempotencyRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewIdempotencyRepository creates a new idempotency key repository.
+func NewIdempotencyRepository(pool *pgxpool.Pool) *IdempotencyRepository {
+	return &IdempotencyRepository{pool: pool}
+}
+
+// Reserve claims the scope and key for the record. An expired record holding
+// the key is replaced; a live one is returned instead.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, rec *domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	db := getDB(ctx, r.pool)
+
+	var scope string
+	err := db.QueryRow(ctx, `
+		INSERT INTO idempotency_keys (scope, key, operation, request_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (scope, key) DO UPDATE SET
+			operation = EXCLUDED.operation,
+			request_hash = EXCLUDED.request_hash,
+			status_code = 0,
+			response = NULL,
+			completed_at = NULL,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= $7
+		RETURNING scope`,
+		rec.Scope,
+		rec.Key,
+		rec.Operation,
+		rec.RequestHash,
+		rec.CreatedAt,
+		rec.ExpiresAt,
+		domain.Now(),
+	).Scan(&scope)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, mapError(err)
+	}
+
+	// The key is held by a live record
+	row := db.QueryRow(ctx, `
+		SELECT scope, key, operation, request_hash, status_code, response,
+			completed_at, created_at, expires_at
+		FROM idempotency_keys
+		WHERE scope = $1 AND key = $2`,
+		rec.Scope, rec.Key)
+
+	var existing domain.IdempotencyRecord
+	err = row.Scan(
+		&existing.Scope,
+		&existing.Key,
+		&existing.Operation,
+		&existing.RequestHash,
+		&existing.StatusCode,
+		&existing.Response,
+		&existing.CompletedAt,
+		&existing.CreatedAt,
+		&existing.ExpiresAt,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &existing, nil
+}
+
+// Complete stores the response of a reserved record.
+func (r *IdempotencyRepository) Complete(ctx context.Context, rec *domain.IdempotencyRecord) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `
+		UPDATE idempotency_keys SET
+			status_code = $3, response = $4, completed_at = $5
+		WHERE scope = $1 AND key = $2`,
+		rec.Scope,
+		rec.Key,
+		rec.StatusCode,
+		rec.Response,
+		rec.CompletedAt,
+	)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Release deletes an uncompleted record.
+func (r *IdempotencyRepository) Release(ctx context.Context, scope, key string) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE scope = $1 AND key = $2 AND completed_at IS NULL`,
+		scope, key)
+
+	return mapError(err)
+}
+
+// DeleteExpired removes expired records.
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, domain.Now())
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// ImpersonationRepository implements storage.ImpersonationRepository using PostgreSQL.
+type ImpersonationRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewImpersonationRepository creates a new impersonation session repository.
+func NewImpersonationRepository(pool *pgxpool.Pool) *ImpersonationRepository {
+	return &ImpersonationRepository{pool: pool}
+}
+
+const impersonationColumns = `id, actor_id, target_id, reason, COALESCE(ip_address, ''), COALESCE(user_agent, ''),
+	started_at, expires_at, ended_at`
+
+// Create stores a new session.
+func (r *ImpersonationRepository) Create(ctx context.Context, session *domain.ImpersonationSession) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO impersonation_sessions (
+			id, actor_id, target_id, reason, ip_address, user_agent,
+			started_at, expires_at, ended_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		session.ID,
+		session.ActorID,
+		session.TargetID,
+		session.Reason,
+		session.IPAddress,
+		session.UserAgent,
+		session.StartedAt,
+		session.ExpiresAt,
+		session.EndedAt,
+	)
+
+	return mapError(err)
+}
+
+// GetByID retrieves a session by ID.
+func (r *ImpersonationRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.ImpersonationSession, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `SELECT `+impersonationColumns+` FROM impersonation_sessions WHERE id = $1`, id)
+
+	return r.scanSession(row)
+}
+
+// End saves the session's end time.
+func (r *ImpersonationRepository) End(ctx context.Context, session *domain.ImpersonationSession) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `
+		UPDATE impersonation_sessions SET ended_at = $2
+		WHERE id = $1`,
+		session.ID, session.EndedAt)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// ListForUser retrieves sessions where the user was the actor or the target.
+func (r *ImpersonationRepository) ListForUser(ctx context.Context, userID uuid.UUID, offset, limit int) ([]domain.ImpersonationSession, int64, error) {
+	db := getDB(ctx, r.pool)
+
+	var total int64
+	err := db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM impersonation_sessions
+		WHERE actor_id = $1 OR target_id = $1`, userID).Scan(&total)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT `+impersonationColumns+`
+		FROM impersonation_sessions
+		WHERE actor_id = $1 OR target_id = $1
+		ORDER BY started_at DESC
+		LIMIT $2 OFFSET $3`,
+		userID, limit, offset)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+	defer rows.Close()
+
+	var sessions []domain.ImpersonationSession
+	for rows.Next() {
+		session, err := r.scanSession(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		sessions = append(sessions, *session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	return sessions, total, nil
+}
+
+func (r *ImpersonationRepository) scanSession(row scannable) (*domain.ImpersonationSession, error) {
+	var s domain.ImpersonationSession
+
+	err := row.Scan(
+		&s.ID,
+		&s.ActorID,
+		&s.TargetID,
+		&s.Reason,
+		&s.IPAddress,
+		&s.UserAgent,
+		&s.StartedAt,
+		&s.ExpiresAt,
+		&s.EndedAt,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &s, nil
+}
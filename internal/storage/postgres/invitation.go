@@ -0,0 +1,189 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// InvitationRepository implements storage.InvitationRepository using PostgreSQL.
+type InvitationRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewInvitationRepository creates a new invitation repository.
+func NewInvitationRepository(pool *pgxpool.Pool) *InvitationRepository {
+	return &InvitationRepository{pool: pool}
+}
+
+const invitationColumns = `id, email, user_id, role_id, invited_by, status, send_count,
+	expires_at, accepted_at, revoked_at, created_at, updated_at`
+
+// Create stores a new invitation.
+func (r *InvitationRepository) Create(ctx context.Context, inv *domain.Invitation) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO invitations (`+invitationColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		inv.ID,
+		inv.Email,
+		inv.UserID,
+		inv.RoleID,
+		inv.InvitedBy,
+		string(inv.Status),
+		inv.SendCount,
+		inv.ExpiresAt,
+		inv.AcceptedAt,
+		inv.RevokedAt,
+		inv.CreatedAt,
+		inv.UpdatedAt,
+	)
+
+	return mapError(err)
+}
+
+// GetByID retrieves an invitation by ID.
+func (r *InvitationRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Invitation, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `SELECT `+invitationColumns+` FROM invitations WHERE id = $1`, id)
+
+	return r.scanInvitation(row)
+}
+
+// GetPendingForUser retrieves the user's pending invitation.
+func (r *InvitationRepository) GetPendingForUser(ctx context.Context, userID uuid.UUID) (*domain.Invitation, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `
+		SELECT `+invitationColumns+`
+		FROM invitations WHERE user_id = $1 AND status = 'pending'`, userID)
+
+	return r.scanInvitation(row)
+}
+
+// Update saves changes to an existing invitation.
+func (r *InvitationRepository) Update(ctx context.Context, inv *domain.Invitation) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `
+		UPDATE invitations SET
+			role_id = $2, status = $3, send_count = $4,
+			expires_at = $5, accepted_at = $6, revoked_at = $7
+		WHERE id = $1`,
+		inv.ID,
+		inv.RoleID,
+		string(inv.Status),
+		inv.SendCount,
+		inv.ExpiresAt,
+		inv.AcceptedAt,
+		inv.RevokedAt,
+	)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// List retrieves invitations with pagination and optional filtering.
+func (r *InvitationRepository) List(ctx context.Context, filter storage.InvitationFilter) ([]domain.Invitation, int64, error) {
+	db := getDB(ctx, r.pool)
+
+	args := []any{}
+	argIndex := 1
+
+	whereClause := "1=1"
+	if filter.Status != nil {
+		switch *filter.Status {
+		case domain.InvitationStatusExpired:
+			whereClause += " AND status = 'pending' AND expires_at <= $" + string(rune('0'+argIndex))
+			args = append(args, domain.Now())
+			argIndex++
+		case domain.InvitationStatusPending:
+			whereClause += " AND status = 'pending' AND expires_at > $" + string(rune('0'+argIndex))
+			args = append(args, domain.Now())
+			argIndex++
+		default:
+			whereClause += " AND status = $" + string(rune('0'+argIndex))
+			args = append(args, string(*filter.Status))
+			argIndex++
+		}
+	}
+
+	if filter.Email != "" {
+		whereClause += " AND email = LOWER($" + string(rune('0'+argIndex)) + ")"
+		args = append(args, filter.Email)
+		argIndex++
+	}
+
+	var total int64
+	err := db.QueryRow(ctx, "SELECT COUNT(*) FROM invitations WHERE "+whereClause, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	listArgs := append(args, filter.Limit, filter.Offset)
+	listQuery := `
+		SELECT ` + invitationColumns + `
+		FROM invitations WHERE ` + whereClause + `
+		ORDER BY created_at DESC
+		LIMIT $` + string(rune('0'+argIndex)) + ` OFFSET $` + string(rune('0'+argIndex+1))
+
+	rows, err := db.Query(ctx, listQuery, listArgs...)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+	defer rows.Close()
+
+	var invitations []domain.Invitation
+	for rows.Next() {
+		inv, err := r.scanInvitation(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		invitations = append(invitations, *inv)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	return invitations, total, nil
+}
+
+func (r *InvitationRepository) scanInvitation(row scannable) (*domain.Invitation, error) {
+	var inv domain.Invitation
+	var status string
+
+	err := row.Scan(
+		&inv.ID,
+		&inv.Email,
+		&inv.UserID,
+		&inv.RoleID,
+		&inv.InvitedBy,
+		&status,
+		&inv.SendCount,
+		&inv.ExpiresAt,
+		&inv.AcceptedAt,
+		&inv.RevokedAt,
+		&inv.CreatedAt,
+		&inv.UpdatedAt,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	inv.Status = domain.InvitationStatus(status)
+
+	return &inv, nil
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// LDAPMappingRepository implements storage.LDAPMappingRepository using
+// PostgreSQL.
+type LDAPMappingRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewLDAPMappingRepository creates a new LDAP group mapping repository.
+func NewLDAPMappingRepository(pool *pgxpool.Pool) *LDAPMappingRepository {
+	return &LDAPMappingRepository{pool: pool}
+}
+
+// Create stores a new mapping.
+func (r *LDAPMappingRepository) Create(ctx context.Context, mapping *domain.LDAPGroupMapping) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO ldap_group_roles (id, group_dn, role_id, created_at)
+		VALUES ($1, $2, $3, $4)`,
+		mapping.ID,
+		mapping.GroupDN,
+		mapping.RoleID,
+		mapping.CreatedAt,
+	)
+
+	return mapError(err)
+}
+
+// Delete removes a mapping.
+func (r *LDAPMappingRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `DELETE FROM ldap_group_roles WHERE id = $1`, id)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// List retrieves every mapping.
+func (r *LDAPMappingRepository) List(ctx context.Context) ([]domain.LDAPGroupMapping, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT m.id, m.group_dn, m.role_id, r.name, m.created_at
+		FROM ldap_group_roles m
+		JOIN roles r ON r.id = m.role_id
+		ORDER BY m.group_dn, r.name`)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var mappings []domain.LDAPGroupMapping
+	for rows.Next() {
+		var m domain.LDAPGroupMapping
+		if err := rows.Scan(&m.ID, &m.GroupDN, &m.RoleID, &m.RoleName, &m.CreatedAt); err != nil {
+			return nil, mapError(err)
+		}
+		mappings = append(mappings, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return mappings, nil
+}
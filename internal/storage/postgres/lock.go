@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/jobs"
+)
+
+// AdvisoryElector implements jobs.Elector with session-level advisory locks.
+// A lease holds its connection out of the pool: the lock lasts as long as
+// the session, so a leader that crashes or is cut off from the database
+// loses it without anyone having to time it out.
+type AdvisoryElector struct {
+	pool          *pgxpool.Pool
+	checkInterval time.Duration
+}
+
+// NewAdvisoryElector creates an elector whose leases check their connection
+// every checkInterval.
+func NewAdvisoryElector(pool *pgxpool.Pool, checkInterval time.Duration) *AdvisoryElector {
+	if checkInterval <= 0 {
+		checkInterval = 15 * time.Second
+	}
+	return &AdvisoryElector{pool: pool, checkInterval: checkInterval}
+}
+
+// TryAcquire takes the advisory lock keyed by the hash of name.
+func (e *AdvisoryElector) TryAcquire(ctx context.Context, name string) (jobs.Lease, bool, error) {
+	conn, err := e.pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, name).Scan(&locked); err != nil {
+		conn.Release()
+		return nil, false, mapError(err)
+	}
+	if !locked {
+		conn.Release()
+		return nil, false, nil
+	}
+
+	lease := &advisoryLease{
+		conn: conn,
+		name: name,
+		lost: make(chan struct{}),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go lease.watch(e.checkInterval)
+
+	return lease, true, nil
+}
+
+type advisoryLease struct {
+	conn *pgxpool.Conn
+	name string
+
+	lost chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+func (l *advisoryLease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// watch pings the lease's connection until Release, reporting the lease
+// lost if the connection fails.
+func (l *advisoryLease) watch(interval time.Duration) {
+	defer close(l.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			err := l.conn.Ping(ctx)
+			cancel()
+			if err != nil {
+				close(l.lost)
+				return
+			}
+		}
+	}
+}
+
+// Release unlocks and returns the connection to the pool, or closes it if
+// unlocking fails, which ends the session and with it the lock.
+func (l *advisoryLease) Release() {
+	close(l.stop)
+	<-l.done
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := l.conn.Exec(ctx, `SELECT pg_advisory_unlock(hashtext($1))`, l.name); err != nil {
+		_ = l.conn.Conn().Close(ctx)
+	}
+	l.conn.Release()
+}
@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// LoginAttemptRepository implements storage.LoginAttemptRepository using PostgreSQL.
+type LoginAttemptRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewLoginAttemptRepository creates a new login attempt repository.
+func NewLoginAttemptRepository(pool *pgxpool.Pool) *LoginAttemptRepository {
+	return &LoginAttemptRepository{pool: pool}
+}
+
+// Create stores an attempt.
+func (r *LoginAttemptRepository) Create(ctx context.Context, attempt *domain.LoginAttempt) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO login_attempts (id, user_id, success, failure_reason, ip_address, user_agent, created_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7)`,
+		attempt.ID,
+		attempt.UserID,
+		attempt.Success,
+		attempt.FailureReason,
+		attempt.IPAddress,
+		attempt.UserAgent,
+		attempt.CreatedAt,
+	)
+
+	return mapError(err)
+}
+
+// List retrieves attempts matching the filter, newest first.
+func (r *LoginAttemptRepository) List(ctx context.Context, filter storage.LoginAttemptFilter) ([]domain.LoginAttempt, int64, error) {
+	db := getDB(ctx, r.pool)
+
+	if filter.Limit <= 0 {
+		filter.Limit = 20
+	}
+	if filter.Limit > 100 {
+		filter.Limit = 100
+	}
+
+	args := []any{filter.UserID}
+	argIndex := 2
+	whereClause := "user_id = $1"
+
+	if filter.Success != nil {
+		whereClause += " AND success = $" + string(rune('0'+argIndex))
+		args = append(args, *filter.Success)
+		argIndex++
+	}
+
+	if filter.Since != nil {
+		whereClause += " AND created_at >= $" + string(rune('0'+argIndex))
+		args = append(args, *filter.Since)
+		argIndex++
+	}
+
+	if filter.Until != nil {
+		whereClause += " AND created_at < $" + string(rune('0'+argIndex))
+		args = append(args, *filter.Until)
+		argIndex++
+	}
+
+	if filter.IPAddress != "" {
+		whereClause += " AND ip_address = $" + string(rune('0'+argIndex))
+		args = append(args, filter.IPAddress)
+		argIndex++
+	}
+
+	var total int64
+	err := db.QueryRow(ctx, "SELECT COUNT(*) FROM login_attempts WHERE "+whereClause, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	listArgs := append(args, filter.Limit, filter.Offset)
+	rows, err := db.Query(ctx, `
+		SELECT id, user_id, success, COALESCE(failure_reason, ''), COALESCE(ip_address, ''),
+			COALESCE(user_agent, ''), created_at
+		FROM login_attempts WHERE `+whereClause+`
+		ORDER BY created_at DESC
+		LIMIT $`+string(rune('0'+argIndex))+` OFFSET $`+string(rune('0'+argIndex+1)),
+		listArgs...)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+	defer rows.Close()
+
+	var attempts []domain.LoginAttempt
+	for rows.Next() {
+		var a domain.LoginAttempt
+		if err := rows.Scan(
+			&a.ID,
+			&a.UserID,
+			&a.Success,
+			&a.FailureReason,
+			&a.IPAddress,
+			&a.UserAgent,
+			&a.CreatedAt,
+		); err != nil {
+			return nil, 0, mapError(err)
+		}
+		attempts = append(attempts, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	return attempts, total, nil
+}
+
+// DeleteOlderThan removes attempts made before cutoff.
+func (r *LoginAttemptRepository) DeleteOlderThan(ctx context.Context, cutoff time.Time) (int64, error) {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `DELETE FROM login_attempts WHERE created_at < $1`, cutoff)
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// CountSince counts recent attempts by outcome.
+func (r *LoginAttemptRepository) CountSince(ctx context.Context, since time.Time) (succeeded, failed int64, err error) {
+	db := getDB(ctx, r.pool)
+
+	err = db.QueryRow(ctx, `
+		SELECT COUNT(*) FILTER (WHERE success), COUNT(*) FILTER (WHERE NOT success)
+		FROM login_attempts
+		WHERE created_at >= $1`, since).Scan(&succeeded, &failed)
+	if err != nil {
+		return 0, 0, mapError(err)
+	}
+
+	return succeeded, failed, nil
+}
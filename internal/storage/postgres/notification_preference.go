@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// NotificationPreferenceRepository implements
+// storage.NotificationPreferenceRepository using PostgreSQL.
+type NotificationPreferenceRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewNotificationPreferenceRepository creates a new notification preference
+// repository.
+func NewNotificationPreferenceRepository(pool *pgxpool.Pool) *NotificationPreferenceRepository {
+	return &NotificationPreferenceRepository{pool: pool}
+}
+
+// ListForUser returns the preferences the user has set.
+func (r *NotificationPreferenceRepository) ListForUser(ctx context.Context, userID uuid.UUID) ([]domain.NotificationPreference, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT category, channel, enabled FROM notification_preferences
+		WHERE user_id = $1
+		ORDER BY category, channel`, userID,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var prefs []domain.NotificationPreference
+	for rows.Next() {
+		var p domain.NotificationPreference
+		if err := rows.Scan(&p.Category, &p.Channel, &p.Enabled); err != nil {
+			return nil, mapError(err)
+		}
+		prefs = append(prefs, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return prefs, nil
+}
+
+// Get returns the user's preference for a category and channel.
+func (r *NotificationPreferenceRepository) Get(ctx context.Context, userID uuid.UUID, category, channel string) (*domain.NotificationPreference, error) {
+	db := getDB(ctx, r.pool)
+
+	p := domain.NotificationPreference{Category: category, Channel: channel}
+	err := db.QueryRow(ctx, `
+		SELECT enabled FROM notification_preferences
+		WHERE user_id = $1 AND category = $2 AND channel = $3`,
+		userID, category, channel,
+	).Scan(&p.Enabled)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &p, nil
+}
+
+// Set saves the given preferences of the user.
+func (r *NotificationPreferenceRepository) Set(ctx context.Context, userID uuid.UUID, prefs []domain.NotificationPreference) error {
+	db := getDB(ctx, r.pool)
+
+	now := domain.Now()
+	for _, p := range prefs {
+		_, err := db.Exec(ctx, `
+			INSERT INTO notification_preferences (user_id, category, channel, enabled, updated_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (user_id, category, channel)
+			DO UPDATE SET enabled = EXCLUDED.enabled, updated_at = EXCLUDED.updated_at`,
+			userID, p.Category, p.Channel, p.Enabled, now,
+		)
+		if err != nil {
+			return mapError(err)
+		}
+	}
+
+	return nil
+}
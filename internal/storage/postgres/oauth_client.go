@@ -0,0 +1,206 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// OAuthClientRepository implements storage.OAuthClientRepository using
+// PostgreSQL.
+type OAuthClientRepository struct {
+	pool  *pgxpool.Pool
+	roles *RoleRepository
+}
+
+// NewOAuthClientRepository creates a new OAuth client repository.
+func NewOAuthClientRepository(pool *pgxpool.Pool) *OAuthClientRepository {
+	return &OAuthClientRepository{pool: pool, roles: NewRoleRepository(pool)}
+}
+
+const oauthClientColumns = `id, name, secret_hash, enabled, created_by, last_used_at, created_at, updated_at`
+
+// Create stores a new client.
+func (r *OAuthClientRepository) Create(ctx context.Context, c *domain.OAuthClient) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO oauth_clients (`+oauthClientColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		c.ID,
+		c.Name,
+		c.SecretHash,
+		c.Enabled,
+		c.CreatedBy,
+		c.LastUsedAt,
+		c.CreatedAt,
+		c.UpdatedAt,
+	)
+
+	return mapError(err)
+}
+
+// GetByID retrieves a client by ID with its roles.
+func (r *OAuthClientRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.OAuthClient, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `SELECT `+oauthClientColumns+` FROM oauth_clients WHERE id = $1`, id)
+
+	client, err := r.scanOAuthClient(row)
+	if err != nil {
+		return nil, err
+	}
+
+	if client.Roles, err = r.getClientRoles(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// List retrieves every client with its roles.
+func (r *OAuthClientRepository) List(ctx context.Context) ([]domain.OAuthClient, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `SELECT `+oauthClientColumns+` FROM oauth_clients ORDER BY name, id`)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var clients []domain.OAuthClient
+	for rows.Next() {
+		client, err := r.scanOAuthClient(rows)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, *client)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	for i := range clients {
+		if clients[i].Roles, err = r.getClientRoles(ctx, clients[i].ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return clients, nil
+}
+
+// Update saves changes to a client.
+func (r *OAuthClientRepository) Update(ctx context.Context, c *domain.OAuthClient) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `
+		UPDATE oauth_clients SET name = $2, secret_hash = $3, enabled = $4
+		WHERE id = $1`,
+		c.ID,
+		c.Name,
+		c.SecretHash,
+		c.Enabled,
+	)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a client. Its role assignments go with it through ON
+// DELETE CASCADE.
+func (r *OAuthClientRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `DELETE FROM oauth_clients WHERE id = $1`, id)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// RecordUse sets when the client last got a token.
+func (r *OAuthClientRepository) RecordUse(ctx context.Context, id uuid.UUID, at time.Time) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `UPDATE oauth_clients SET last_used_at = $2 WHERE id = $1`, id, at)
+
+	return mapError(err)
+}
+
+// AssignRole assigns a role to a client.
+func (r *OAuthClientRepository) AssignRole(ctx context.Context, clientID, roleID uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO oauth_client_roles (client_id, role_id)
+		VALUES ($1, $2)
+		ON CONFLICT (client_id, role_id) DO NOTHING`,
+		clientID, roleID)
+
+	return mapError(err)
+}
+
+// RemoveRole removes a role from a client.
+func (r *OAuthClientRepository) RemoveRole(ctx context.Context, clientID, roleID uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		DELETE FROM oauth_client_roles
+		WHERE client_id = $1 AND role_id = $2`,
+		clientID, roleID)
+
+	return mapError(err)
+}
+
+func (r *OAuthClientRepository) getClientRoles(ctx context.Context, clientID uuid.UUID) ([]domain.Role, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT r.id, r.name, r.description, r.organization_id, r.created_at, r.updated_at
+		FROM roles r
+		JOIN oauth_client_roles cr ON r.id = cr.role_id
+		WHERE cr.client_id = $1
+		ORDER BY r.name`, clientID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	return r.roles.scanRolesWithPermissions(ctx, rows)
+}
+
+func (r *OAuthClientRepository) scanOAuthClient(row scannable) (*domain.OAuthClient, error) {
+	var c domain.OAuthClient
+
+	err := row.Scan(
+		&c.ID,
+		&c.Name,
+		&c.SecretHash,
+		&c.Enabled,
+		&c.CreatedBy,
+		&c.LastUsedAt,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &c, nil
+}
@@ -0,0 +1,286 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// OrganizationRepository implements storage.OrganizationRepository using PostgreSQL.
+type OrganizationRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewOrganizationRepository creates a new organization repository.
+func NewOrganizationRepository(pool *pgxpool.Pool) *OrganizationRepository {
+	return &OrganizationRepository{pool: pool}
+}
+
+// Create stores a new organization.
+func (r *OrganizationRepository) Create(ctx context.Context, org *domain.Organization) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO organizations (id, name, slug, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		org.ID,
+		org.Name,
+		org.Slug,
+		org.CreatedAt,
+		org.UpdatedAt,
+	)
+
+	return mapError(err)
+}
+
+// GetByID retrieves an organization by ID.
+func (r *OrganizationRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Organization, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `
+		SELECT id, name, slug, created_at, updated_at
+		FROM organizations WHERE id = $1`, id)
+
+	return r.scanOrganization(row)
+}
+
+// GetBySlug retrieves an organization by slug.
+func (r *OrganizationRepository) GetBySlug(ctx context.Context, slug string) (*domain.Organization, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `
+		SELECT id, name, slug, created_at, updated_at
+		FROM organizations WHERE slug = $1`, slug)
+
+	return r.scanOrganization(row)
+}
+
+// Update saves changes to an existing organization.
+func (r *OrganizationRepository) Update(ctx context.Context, org *domain.Organization) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `
+		UPDATE organizations SET name = $2, slug = $3
+		WHERE id = $1`,
+		org.ID,
+		org.Name,
+		org.Slug,
+	)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete removes an organization. Memberships and scoped roles cascade.
+func (r *OrganizationRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	// Its scoped roles go with it, so their holders' versions are bumped
+	result, err := db.Exec(ctx, `
+		WITH bumped AS (
+			UPDATE users SET version = version + 1
+			WHERE id IN (
+				SELECT ur.user_id FROM user_roles ur
+				JOIN roles r ON r.id = ur.role_id
+				WHERE r.organization_id = $1
+			)
+		)
+		DELETE FROM organizations WHERE id = $1`, id)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// List retrieves organizations ordered by name.
+func (r *OrganizationRepository) List(ctx context.Context, offset, limit int) ([]domain.Organization, int64, error) {
+	db := getDB(ctx, r.pool)
+
+	var total int64
+	if err := db.QueryRow(ctx, `SELECT COUNT(*) FROM organizations`).Scan(&total); err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT id, name, slug, created_at, updated_at
+		FROM organizations
+		ORDER BY name
+		LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+	defer rows.Close()
+
+	var orgs []domain.Organization
+	for rows.Next() {
+		org, err := r.scanOrganization(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		orgs = append(orgs, *org)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	return orgs, total, nil
+}
+
+// AddMember adds a user to an organization.
+func (r *OrganizationRepository) AddMember(ctx context.Context, orgID, userID uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO organization_members (organization_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT (organization_id, user_id) DO NOTHING`,
+		orgID, userID)
+
+	return mapError(err)
+}
+
+// RemoveMember removes a user from an organization and drops the roles
+// scoped to it, so a former member keeps no organization permissions.
+// Dropping any bumps the user's version.
+func (r *OrganizationRepository) RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		WITH removed AS (
+			DELETE FROM user_roles
+			WHERE user_id = $2
+			  AND role_id IN (SELECT id FROM roles WHERE organization_id = $1)
+			RETURNING user_id
+		)
+		UPDATE users SET version = version + 1
+		WHERE id IN (SELECT user_id FROM removed)`,
+		orgID, userID)
+	if err != nil {
+		return mapError(err)
+	}
+
+	_, err = db.Exec(ctx, `
+		DELETE FROM organization_members
+		WHERE organization_id = $1 AND user_id = $2`,
+		orgID, userID)
+
+	return mapError(err)
+}
+
+// IsMember reports whether the user belongs to the organization.
+func (r *OrganizationRepository) IsMember(ctx context.Context, orgID, userID uuid.UUID) (bool, error) {
+	db := getDB(ctx, r.pool)
+
+	var exists bool
+	err := db.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM organization_members
+			WHERE organization_id = $1 AND user_id = $2
+		)`, orgID, userID).Scan(&exists)
+	if err != nil {
+		return false, mapError(err)
+	}
+
+	return exists, nil
+}
+
+// ListMembers retrieves an organization's members, oldest first.
+func (r *OrganizationRepository) ListMembers(ctx context.Context, orgID uuid.UUID, offset, limit int) ([]domain.OrganizationMember, int64, error) {
+	db := getDB(ctx, r.pool)
+
+	var total int64
+	err := db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM organization_members WHERE organization_id = $1`, orgID).Scan(&total)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT organization_id, user_id, joined_at
+		FROM organization_members
+		WHERE organization_id = $1
+		ORDER BY joined_at, user_id
+		LIMIT $2 OFFSET $3`, orgID, limit, offset)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+	defer rows.Close()
+
+	var members []domain.OrganizationMember
+	for rows.Next() {
+		var m domain.OrganizationMember
+		if err := rows.Scan(&m.OrganizationID, &m.UserID, &m.JoinedAt); err != nil {
+			return nil, 0, mapError(err)
+		}
+		members = append(members, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	return members, total, nil
+}
+
+// ListForUser retrieves the organizations a user belongs to.
+func (r *OrganizationRepository) ListForUser(ctx context.Context, userID uuid.UUID) ([]domain.Organization, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT o.id, o.name, o.slug, o.created_at, o.updated_at
+		FROM organizations o
+		JOIN organization_members om ON o.id = om.organization_id
+		WHERE om.user_id = $1
+		ORDER BY o.name`, userID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var orgs []domain.Organization
+	for rows.Next() {
+		org, err := r.scanOrganization(rows)
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, *org)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return orgs, nil
+}
+
+func (r *OrganizationRepository) scanOrganization(row scannable) (*domain.Organization, error) {
+	var org domain.Organization
+
+	err := row.Scan(
+		&org.ID,
+		&org.Name,
+		&org.Slug,
+		&org.CreatedAt,
+		&org.UpdatedAt,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &org, nil
+}
@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// PasswordHistoryRepository implements storage.PasswordHistoryRepository using PostgreSQL.
+type PasswordHistoryRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPasswordHistoryRepository creates a new password history repository.
+func NewPasswordHistoryRepository(pool *pgxpool.Pool) *PasswordHistoryRepository {
+	return &PasswordHistoryRepository{pool: pool}
+}
+
+// Add stores a password hash and trims the user's history to keep entries.
+func (r *PasswordHistoryRepository) Add(ctx context.Context, userID uuid.UUID, passwordHash string, keep int) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO password_history (id, user_id, password_hash, created_at)
+		VALUES ($1, $2, $3, $4)`,
+		domain.NewID(), userID, passwordHash, domain.Now(),
+	)
+	if err != nil {
+		return mapError(err)
+	}
+
+	_, err = db.Exec(ctx, `
+		DELETE FROM password_history
+		WHERE user_id = $1 AND id NOT IN (
+			SELECT id FROM password_history
+			WHERE user_id = $1
+			ORDER BY created_at DESC
+			LIMIT $2
+		)`,
+		userID, keep,
+	)
+
+	return mapError(err)
+}
+
+// Recent returns the user's newest password hashes, newest first.
+func (r *PasswordHistoryRepository) Recent(ctx context.Context, userID uuid.UUID, limit int) ([]string, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT password_hash FROM password_history
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2`,
+		userID, limit,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, rows.Err()
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
 )
 
 // PermissionRepository implements storage.PermissionRepository using PostgreSQL.
@@ -82,6 +83,85 @@ func (r *PermissionRepository) List(ctx context.Context) ([]domain.Permission, e
 	return perms, nil
 }
 
+// Search retrieves a page of permissions matching filter.
+func (r *PermissionRepository) Search(ctx context.Context, filter storage.PermissionFilter) ([]domain.Permission, int64, error) {
+	db := getDB(ctx, r.pool)
+
+	if filter.Limit <= 0 {
+		filter.Limit = 20
+	}
+	if filter.Limit > 100 {
+		filter.Limit = 100
+	}
+
+	var args []any
+	argIndex := 1
+	whereClause := "1=1"
+
+	if filter.Resource != "" {
+		whereClause += " AND resource = $" + string(rune('0'+argIndex))
+		args = append(args, filter.Resource)
+		argIndex++
+	}
+
+	if filter.Search != "" {
+		whereClause += " AND (LOWER(resource) LIKE LOWER($" + string(rune('0'+argIndex)) + ") OR " +
+			"LOWER(action) LIKE LOWER($" + string(rune('0'+argIndex)) + ") OR " +
+			"LOWER(description) LIKE LOWER($" + string(rune('0'+argIndex)) + "))"
+		args = append(args, "%"+filter.Search+"%")
+		argIndex++
+	}
+
+	var total int64
+	err := db.QueryRow(ctx, "SELECT COUNT(*) FROM permissions WHERE "+whereClause, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	listArgs := append(args, filter.Limit, filter.Offset)
+	rows, err := db.Query(ctx, `
+		SELECT id, resource, action, description, created_at
+		FROM permissions WHERE `+whereClause+`
+		ORDER BY resource, action
+		LIMIT $`+string(rune('0'+argIndex))+` OFFSET $`+string(rune('0'+argIndex+1)),
+		listArgs...)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+	defer rows.Close()
+
+	var perms []domain.Permission
+	for rows.Next() {
+		perm, err := r.scanPermission(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		perms = append(perms, *perm)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	return perms, total, nil
+}
+
+// Update saves a permission's description.
+func (r *PermissionRepository) Update(ctx context.Context, perm *domain.Permission) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `UPDATE permissions SET description = $2 WHERE id = $1`, perm.ID, perm.Description)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
 // Delete removes a permission.
 func (r *PermissionRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	db := getDB(ctx, r.pool)
@@ -108,15 +188,15 @@ func (r *PermissionRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-// AssignToRole assigns a permission to a role.
-func (r *PermissionRepository) AssignToRole(ctx context.Context, roleID, permissionID uuid.UUID) error {
+// AssignToRole grants or denies a permission to a role.
+func (r *PermissionRepository) AssignToRole(ctx context.Context, roleID, permissionID uuid.UUID, deny bool) error {
 	db := getDB(ctx, r.pool)
 
 	_, err := db.Exec(ctx, `
-		INSERT INTO role_permissions (role_id, permission_id)
-		VALUES ($1, $2)
-		ON CONFLICT (role_id, permission_id) DO NOTHING`,
-		roleID, permissionID)
+		INSERT INTO role_permissions (role_id, permission_id, deny)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (role_id, permission_id) DO UPDATE SET deny = EXCLUDED.deny`,
+		roleID, permissionID, deny)
 
 	return mapError(err)
 }
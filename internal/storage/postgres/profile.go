@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// ProfileRepository implements storage.ProfileRepository using PostgreSQL.
+type ProfileRepository struct {
+	pool  *pgxpool.Pool
+	roles *RoleRepository
+}
+
+// NewProfileRepository creates a new profile repository.
+func NewProfileRepository(pool *pgxpool.Pool) *ProfileRepository {
+	return &ProfileRepository{pool: pool, roles: NewRoleRepository(pool)}
+}
+
+// Create stores a new profile.
+func (r *ProfileRepository) Create(ctx context.Context, profile *domain.Profile) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO user_profiles (id, user_id, profile_type, display_name, created_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		profile.ID,
+		profile.UserID,
+		profile.Type,
+		profile.DisplayName,
+		profile.CreatedAt,
+	)
+
+	return mapError(err)
+}
+
+// GetByID retrieves a profile by ID with its roles.
+func (r *ProfileRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Profile, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `
+		SELECT id, user_id, profile_type, display_name, created_at
+		FROM user_profiles WHERE id = $1`, id)
+
+	profile, err := r.scanProfile(row)
+	if err != nil {
+		return nil, err
+	}
+
+	if profile.Roles, err = r.getProfileRoles(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+// ListForUser retrieves a user's profiles with their roles.
+func (r *ProfileRepository) ListForUser(ctx context.Context, userID uuid.UUID) ([]domain.Profile, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT id, user_id, profile_type, display_name, created_at
+		FROM user_profiles
+		WHERE user_id = $1
+		ORDER BY created_at, id`, userID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var profiles []domain.Profile
+	for rows.Next() {
+		profile, err := r.scanProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, *profile)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	for i := range profiles {
+		if profiles[i].Roles, err = r.getProfileRoles(ctx, profiles[i].ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return profiles, nil
+}
+
+// Delete removes a profile. Its role assignments and refresh tokens go with
+// it through ON DELETE CASCADE.
+func (r *ProfileRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `DELETE FROM user_profiles WHERE id = $1`, id)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// AssignRole assigns a role to a profile.
+func (r *ProfileRepository) AssignRole(ctx context.Context, profileID, roleID uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO profile_roles (profile_id, role_id)
+		VALUES ($1, $2)
+		ON CONFLICT (profile_id, role_id) DO NOTHING`,
+		profileID, roleID)
+
+	return mapError(err)
+}
+
+// RemoveRole removes a role from a profile.
+func (r *ProfileRepository) RemoveRole(ctx context.Context, profileID, roleID uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		DELETE FROM profile_roles
+		WHERE profile_id = $1 AND role_id = $2`,
+		profileID, roleID)
+
+	return mapError(err)
+}
+
+func (r *ProfileRepository) getProfileRoles(ctx context.Context, profileID uuid.UUID) ([]domain.Role, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT r.id, r.name, r.description, r.organization_id, r.created_at, r.updated_at
+		FROM roles r
+		JOIN profile_roles pr ON r.id = pr.role_id
+		WHERE pr.profile_id = $1
+		ORDER BY r.name`, profileID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	return r.roles.scanRolesWithPermissions(ctx, rows)
+}
+
+func (r *ProfileRepository) scanProfile(row scannable) (*domain.Profile, error) {
+	var profile domain.Profile
+
+	err := row.Scan(
+		&profile.ID,
+		&profile.UserID,
+		&profile.Type,
+		&profile.DisplayName,
+		&profile.CreatedAt,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &profile, nil
+}
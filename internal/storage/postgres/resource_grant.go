@@ -0,0 +1,173 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// ResourceGrantRepository implements storage.ResourceGrantRepository using
+// PostgreSQL.
+type ResourceGrantRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewResourceGrantRepository creates a new resource grant repository.
+func NewResourceGrantRepository(pool *pgxpool.Pool) *ResourceGrantRepository {
+	return &ResourceGrantRepository{pool: pool}
+}
+
+const resourceGrantColumns = `id, resource, resource_id, action, user_id, group_id, granted_by, created_at`
+
+// Create stores a new grant.
+func (r *ResourceGrantRepository) Create(ctx context.Context, grant *domain.ResourceGrant) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO resource_grants (`+resourceGrantColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		grant.ID,
+		grant.Resource,
+		grant.ResourceID,
+		grant.Action,
+		grant.UserID,
+		grant.GroupID,
+		grant.GrantedBy,
+		grant.CreatedAt,
+	)
+
+	return mapError(err)
+}
+
+// GetByID retrieves a grant by ID.
+func (r *ResourceGrantRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.ResourceGrant, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `SELECT `+resourceGrantColumns+` FROM resource_grants WHERE id = $1`, id)
+
+	return r.scanGrant(row)
+}
+
+// Delete removes a grant.
+func (r *ResourceGrantRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `DELETE FROM resource_grants WHERE id = $1`, id)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteForResource removes every grant on an instance.
+func (r *ResourceGrantRepository) DeleteForResource(ctx context.Context, resource, resourceID string) (int64, error) {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `
+		DELETE FROM resource_grants WHERE resource = $1 AND resource_id = $2`,
+		resource, resourceID)
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// List retrieves a page of grants matching filter, newest first.
+func (r *ResourceGrantRepository) List(ctx context.Context, filter storage.ResourceGrantFilter) ([]domain.ResourceGrant, int64, error) {
+	db := getDB(ctx, r.pool)
+
+	if filter.Limit <= 0 {
+		filter.Limit = 20
+	}
+	if filter.Limit > 100 {
+		filter.Limit = 100
+	}
+
+	const where = `
+		WHERE ($1 = '' OR resource = $1)
+		  AND ($2 = '' OR resource_id = $2)
+		  AND ($3::uuid IS NULL OR user_id = $3)
+		  AND ($4::uuid IS NULL OR group_id = $4)`
+	args := []any{filter.Resource, filter.ResourceID, filter.UserID, filter.GroupID}
+
+	var total int64
+	if err := db.QueryRow(ctx, `SELECT COUNT(*) FROM resource_grants`+where, args...).Scan(&total); err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT `+resourceGrantColumns+` FROM resource_grants`+where+`
+		ORDER BY created_at DESC, id
+		LIMIT $5 OFFSET $6`,
+		append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+	defer rows.Close()
+
+	var grants []domain.ResourceGrant
+	for rows.Next() {
+		grant, err := r.scanGrant(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		grants = append(grants, *grant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	return grants, total, nil
+}
+
+// Allows looks for a grant to the user or to a group they belong to.
+func (r *ResourceGrantRepository) Allows(ctx context.Context, userID uuid.UUID, resource, resourceID, action string) (bool, error) {
+	db := getDB(ctx, r.pool)
+
+	var allowed bool
+	err := db.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM resource_grants
+			WHERE resource = $2 AND resource_id = $3 AND action IN ($4, '*')
+			  AND (user_id = $1
+			       OR group_id IN (SELECT group_id FROM group_members WHERE user_id = $1))
+		)`,
+		userID, resource, resourceID, action,
+	).Scan(&allowed)
+	if err != nil {
+		return false, mapError(err)
+	}
+
+	return allowed, nil
+}
+
+func (r *ResourceGrantRepository) scanGrant(row scannable) (*domain.ResourceGrant, error) {
+	var grant domain.ResourceGrant
+
+	err := row.Scan(
+		&grant.ID,
+		&grant.Resource,
+		&grant.ResourceID,
+		&grant.Action,
+		&grant.UserID,
+		&grant.GroupID,
+		&grant.GrantedBy,
+		&grant.CreatedAt,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &grant, nil
+}
@@ -5,9 +5,11 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
 )
 
 // RoleRepository implements storage.RoleRepository using PostgreSQL.
@@ -25,11 +27,12 @@ func (r *RoleRepository) Create(ctx context.Context, role *domain.Role) error {
 	db := getDB(ctx, r.pool)
 
 	_, err := db.Exec(ctx, `
-		INSERT INTO roles (id, name, description, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)`,
+		INSERT INTO roles (id, name, description, organization_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
 		role.ID,
 		role.Name,
 		role.Description,
+		role.OrganizationID,
 		role.CreatedAt,
 		role.UpdatedAt,
 	)
@@ -42,7 +45,7 @@ func (r *RoleRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Rol
 	db := getDB(ctx, r.pool)
 
 	row := db.QueryRow(ctx, `
-		SELECT id, name, description, created_at, updated_at
+		SELECT id, name, description, organization_id, created_at, updated_at
 		FROM roles WHERE id = $1`, id)
 
 	role, err := r.scanRole(row)
@@ -60,13 +63,13 @@ func (r *RoleRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Rol
 	return role, nil
 }
 
-// GetByName retrieves a role by name with its permissions.
+// GetByName retrieves a global role by name with its permissions.
 func (r *RoleRepository) GetByName(ctx context.Context, name string) (*domain.Role, error) {
 	db := getDB(ctx, r.pool)
 
 	row := db.QueryRow(ctx, `
-		SELECT id, name, description, created_at, updated_at
-		FROM roles WHERE name = $1`, name)
+		SELECT id, name, description, organization_id, created_at, updated_at
+		FROM roles WHERE name = $1 AND organization_id IS NULL`, name)
 
 	role, err := r.scanRole(row)
 	if err != nil {
@@ -87,26 +90,38 @@ func (r *RoleRepository) GetByName(ctx context.Context, name string) (*domain.Ro
 func (r *RoleRepository) Update(ctx context.Context, role *domain.Role) error {
 	db := getDB(ctx, r.pool)
 
-	result, err := db.Exec(ctx, `
-		UPDATE roles SET name = $2, description = $3, updated_at = $4
-		WHERE id = $1`,
+	// A rename changes the roles users are returned with, so it bumps the
+	// versions of those holding the role
+	var updated int64
+	err := db.QueryRow(ctx, `
+		WITH previous AS (
+			SELECT name FROM roles WHERE id = $1
+		), updated AS (
+			UPDATE roles SET name = $2, description = $3, updated_at = $4
+			WHERE id = $1
+			RETURNING id
+		), bumped AS (
+			UPDATE users SET version = version + 1
+			WHERE id IN (SELECT user_id FROM user_roles WHERE role_id IN (SELECT id FROM updated))
+			  AND (SELECT name FROM previous) <> $2
+		)
+		SELECT COUNT(*) FROM updated`,
 		role.ID,
 		role.Name,
 		role.Description,
-		time.Now().UTC(),
-	)
+		domain.Now(),
+	).Scan(&updated)
 	if err != nil {
 		return mapError(err)
 	}
 
-	if result.RowsAffected() == 0 {
+	if updated == 0 {
 		return domain.ErrNotFound
 	}
 
 	return nil
 }
 
-// Delete removes a role.
 func (r *RoleRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	db := getDB(ctx, r.pool)
 
@@ -120,6 +135,18 @@ func (r *RoleRepository) Delete(ctx context.Context, id uuid.UUID) error {
 		return domain.ErrConflict
 	}
 
+	// Same for groups, profiles and OAuth clients holding it
+	err = db.QueryRow(ctx, `
+		SELECT (SELECT COUNT(*) FROM group_roles WHERE role_id = $1)
+		     + (SELECT COUNT(*) FROM profile_roles WHERE role_id = $1)
+		     + (SELECT COUNT(*) FROM oauth_client_roles WHERE role_id = $1)`, id).Scan(&count)
+	if err != nil {
+		return mapError(err)
+	}
+	if count > 0 {
+		return domain.ErrConflict
+	}
+
 	result, err := db.Exec(ctx, `DELETE FROM roles WHERE id = $1`, id)
 	if err != nil {
 		return mapError(err)
@@ -132,13 +159,15 @@ func (r *RoleRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-// List retrieves all roles.
-func (r *RoleRepository) List(ctx context.Context) ([]domain.Role, error) {
+// List retrieves roles, optionally limited to those usable in one organization.
+func (r *RoleRepository) List(ctx context.Context, filter storage.RoleFilter) ([]domain.Role, error) {
 	db := getDB(ctx, r.pool)
 
 	rows, err := db.Query(ctx, `
-		SELECT id, name, description, created_at, updated_at
-		FROM roles ORDER BY name`)
+		SELECT id, name, description, organization_id, created_at, updated_at
+		FROM roles
+		WHERE $1::uuid IS NULL OR organization_id IS NULL OR organization_id = $1
+		ORDER BY organization_id NULLS FIRST, name`, filter.OrganizationID)
 	if err != nil {
 		return nil, mapError(err)
 	}
@@ -165,72 +194,260 @@ func (r *RoleRepository) List(ctx context.Context) ([]domain.Role, error) {
 	return roles, nil
 }
 
-// GetUserRoles retrieves all roles assigned to a user.
+// GetUserRoles retrieves the unexpired roles assigned to a user.
 func (r *RoleRepository) GetUserRoles(ctx context.Context, userID uuid.UUID) ([]domain.Role, error) {
 	db := getDB(ctx, r.pool)
 
 	rows, err := db.Query(ctx, `
-		SELECT r.id, r.name, r.description, r.created_at, r.updated_at
+		SELECT r.id, r.name, r.description, r.organization_id, r.created_at, r.updated_at,
+			   ur.expires_at
 		FROM roles r
 		JOIN user_roles ur ON r.id = ur.role_id
-		WHERE ur.user_id = $1
-		ORDER BY r.name`, userID)
+		WHERE ur.user_id = $1 AND (ur.expires_at IS NULL OR ur.expires_at > $2)
+		ORDER BY r.name`, userID, domain.Now())
 	if err != nil {
 		return nil, mapError(err)
 	}
 	defer rows.Close()
 
-	var roles []domain.Role
-	for rows.Next() {
-		role, err := r.scanRole(rows)
-		if err != nil {
-			return nil, err
-		}
-		roles = append(roles, *role)
-	}
+	return r.scanRolesWithPermissions(ctx, rows)
+}
 
-	// Load permissions for each role
-	for i := range roles {
-		perms, err := r.getRolePermissions(ctx, roles[i].ID)
-		if err != nil {
-			return nil, err
-		}
-		roles[i].Permissions = perms
+// GetEffectiveUserRoles retrieves the user's own unexpired roles plus those
+// inherited from their groups. A role held both ways keeps no expiry.
+func (r *RoleRepository) GetEffectiveUserRoles(ctx context.Context, userID uuid.UUID) ([]domain.Role, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		WITH grants AS (
+			SELECT role_id, expires_at
+			FROM user_roles
+			WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > $2)
+			UNION ALL
+			SELECT gr.role_id, NULL
+			FROM group_roles gr
+			JOIN group_members gm ON gm.group_id = gr.group_id
+			JOIN roles gr_role ON gr_role.id = gr.role_id
+			WHERE gm.user_id = $1
+			  AND (gr_role.organization_id IS NULL OR EXISTS (
+					SELECT 1 FROM organization_members om
+					WHERE om.organization_id = gr_role.organization_id AND om.user_id = $1
+			  ))
+		)
+		SELECT r.id, r.name, r.description, r.organization_id, r.created_at, r.updated_at,
+			   g.expires_at
+		FROM roles r
+		JOIN (
+			SELECT role_id,
+				   CASE WHEN bool_and(expires_at IS NOT NULL) THEN MAX(expires_at) END AS expires_at
+			FROM grants
+			GROUP BY role_id
+		) g ON g.role_id = r.id
+		ORDER BY r.name`, userID, domain.Now())
+	if err != nil {
+		return nil, mapError(err)
 	}
+	defer rows.Close()
 
-	return roles, nil
+	return r.scanRolesWithPermissions(ctx, rows)
 }
 
-// AssignRole assigns a role to a user.
-func (r *RoleRepository) AssignRole(ctx context.Context, userID, roleID uuid.UUID) error {
+// AssignRole assigns a role to a user, replacing the expiry of an existing
+// assignment, and bumps the user's version.
+func (r *RoleRepository) AssignRole(ctx context.Context, userID, roleID uuid.UUID, expiresAt *time.Time) error {
 	db := getDB(ctx, r.pool)
 
 	_, err := db.Exec(ctx, `
-		INSERT INTO user_roles (user_id, role_id)
-		VALUES ($1, $2)
-		ON CONFLICT (user_id, role_id) DO NOTHING`,
-		userID, roleID)
+		WITH assigned AS (
+			INSERT INTO user_roles (user_id, role_id, expires_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (user_id, role_id) DO UPDATE SET expires_at = EXCLUDED.expires_at
+			RETURNING user_id
+		)
+		UPDATE users SET version = version + 1
+		WHERE id IN (SELECT user_id FROM assigned)`,
+		userID, roleID, expiresAt)
 
 	return mapError(err)
 }
 
-// RemoveRole removes a role from a user.
+// RemoveRole removes a role from a user, bumping their version if they
+// held it.
 func (r *RoleRepository) RemoveRole(ctx context.Context, userID, roleID uuid.UUID) error {
 	db := getDB(ctx, r.pool)
 
 	_, err := db.Exec(ctx, `
-		DELETE FROM user_roles
-		WHERE user_id = $1 AND role_id = $2`,
+		WITH removed AS (
+			DELETE FROM user_roles
+			WHERE user_id = $1 AND role_id = $2
+			RETURNING user_id
+		)
+		UPDATE users SET version = version + 1
+		WHERE id IN (SELECT user_id FROM removed)`,
 		userID, roleID)
 
 	return mapError(err)
 }
 
+// DeleteExpiredAssignments removes lapsed user role assignments, bumping
+// the versions of the users who held them.
+func (r *RoleRepository) DeleteExpiredAssignments(ctx context.Context, now time.Time) ([]domain.RoleExpiry, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		WITH expired AS (
+			DELETE FROM user_roles ur
+			USING roles r
+			WHERE r.id = ur.role_id AND ur.expires_at <= $1
+			RETURNING ur.user_id, ur.role_id, r.name, ur.expires_at
+		), bumped AS (
+			UPDATE users SET version = version + 1
+			WHERE id IN (SELECT user_id FROM expired)
+		)
+		SELECT user_id, role_id, name, expires_at FROM expired`, now)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var expired []domain.RoleExpiry
+	for rows.Next() {
+		var e domain.RoleExpiry
+		if err := rows.Scan(&e.UserID, &e.RoleID, &e.RoleName, &e.ExpiresAt); err != nil {
+			return nil, mapError(err)
+		}
+		expired = append(expired, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return expired, nil
+}
+
+// ListUsersWithRole retrieves the users a role is assigned to directly.
+func (r *RoleRepository) ListUsersWithRole(ctx context.Context, roleID uuid.UUID, offset, limit int) ([]domain.User, int64, error) {
+	db := getDB(ctx, r.pool)
+
+	var total int64
+	err := db.QueryRow(ctx, `
+		SELECT COUNT(*)
+		FROM user_roles ur
+		JOIN users u ON u.id = ur.user_id
+		WHERE ur.role_id = $1 AND u.deleted_at IS NULL
+		  AND (ur.expires_at IS NULL OR ur.expires_at > $2)`, roleID, domain.Now()).Scan(&total)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT u.id, u.email, u.password_hash, u.phone, u.username, u.full_name,
+			   u.user_type, u.status, u.email_verified, u.phone_verified, u.attributes,
+			   COALESCE(u.locale, ''), COALESCE(u.timezone, ''),
+			   u.created_at, u.updated_at, u.deleted_at, u.version
+		FROM user_roles ur
+		JOIN users u ON u.id = ur.user_id
+		WHERE ur.role_id = $1 AND u.deleted_at IS NULL
+		  AND (ur.expires_at IS NULL OR ur.expires_at > $4)
+		ORDER BY ur.created_at, u.id
+		LIMIT $2 OFFSET $3`, roleID, limit, offset, domain.Now())
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+	defer rows.Close()
+
+	var users []domain.User
+	for rows.Next() {
+		user, err := scanUserRow(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, *user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	return users, total, nil
+}
+
+// CountAssignments counts the holders of each role in one query.
+func (r *RoleRepository) CountAssignments(ctx context.Context, roleIDs []uuid.UUID) (map[uuid.UUID]domain.RoleAssignments, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT r.id,
+			(SELECT COUNT(*) FROM user_roles ur JOIN users u ON u.id = ur.user_id
+			 WHERE ur.role_id = r.id AND u.deleted_at IS NULL
+			   AND (ur.expires_at IS NULL OR ur.expires_at > $2)),
+			(SELECT COUNT(*) FROM group_roles WHERE role_id = r.id),
+			(SELECT COUNT(*) FROM profile_roles WHERE role_id = r.id)
+		FROM unnest($1::uuid[]) AS r(id)`, roleIDs, domain.Now())
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	counts := make(map[uuid.UUID]domain.RoleAssignments, len(roleIDs))
+	for rows.Next() {
+		var id uuid.UUID
+		var c domain.RoleAssignments
+		if err := rows.Scan(&id, &c.Users, &c.Groups, &c.Profiles); err != nil {
+			return nil, mapError(err)
+		}
+		counts[id] = c
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return counts, nil
+}
+
+// scanRolesWithPermissions drains rows of a user's roles, each followed by
+// the assignment's expiry, and loads their permissions.
+func (r *RoleRepository) scanRolesWithPermissions(ctx context.Context, rows pgx.Rows) ([]domain.Role, error) {
+	var roles []domain.Role
+	for rows.Next() {
+		var role domain.Role
+		err := rows.Scan(
+			&role.ID,
+			&role.Name,
+			&role.Description,
+			&role.OrganizationID,
+			&role.CreatedAt,
+			&role.UpdatedAt,
+			&role.ExpiresAt,
+		)
+		if err != nil {
+			return nil, mapError(err)
+		}
+		roles = append(roles, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	// Load permissions for each role
+	for i := range roles {
+		perms, err := r.getRolePermissions(ctx, roles[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		roles[i].Permissions = perms
+	}
+
+	return roles, nil
+}
+
 func (r *RoleRepository) getRolePermissions(ctx context.Context, roleID uuid.UUID) ([]domain.Permission, error) {
 	db := getDB(ctx, r.pool)
 
 	rows, err := db.Query(ctx, `
-		SELECT p.id, p.resource, p.action, p.description, p.created_at
+		SELECT p.id, p.resource, p.action, p.description, p.created_at, rp.deny
 		FROM permissions p
 		JOIN role_permissions rp ON p.id = rp.permission_id
 		WHERE rp.role_id = $1
@@ -243,7 +460,7 @@ func (r *RoleRepository) getRolePermissions(ctx context.Context, roleID uuid.UUI
 	var perms []domain.Permission
 	for rows.Next() {
 		var p domain.Permission
-		err := rows.Scan(&p.ID, &p.Resource, &p.Action, &p.Description, &p.CreatedAt)
+		err := rows.Scan(&p.ID, &p.Resource, &p.Action, &p.Description, &p.CreatedAt, &p.Deny)
 		if err != nil {
 			return nil, mapError(err)
 		}
@@ -260,6 +477,7 @@ func (r *RoleRepository) scanRole(row scannable) (*domain.Role, error) {
 		&role.ID,
 		&role.Name,
 		&role.Description,
+		&role.OrganizationID,
 		&role.CreatedAt,
 		&role.UpdatedAt,
 	)
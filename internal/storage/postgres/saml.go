@@ -0,0 +1,231 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// SAMLProviderRepository implements storage.SAMLProviderRepository using
+// PostgreSQL.
+type SAMLProviderRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewSAMLProviderRepository creates a new SAML provider repository.
+func NewSAMLProviderRepository(pool *pgxpool.Pool) *SAMLProviderRepository {
+	return &SAMLProviderRepository{pool: pool}
+}
+
+const samlProviderColumns = `id, slug, name, entity_id, sso_url, certificate,
+	email_attribute, name_attribute, groups_attribute, email_domains, enabled, created_at, updated_at`
+
+// Create stores a new provider.
+func (r *SAMLProviderRepository) Create(ctx context.Context, provider *domain.SAMLProvider) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO saml_providers (`+samlProviderColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		provider.ID,
+		provider.Slug,
+		provider.Name,
+		provider.EntityID,
+		provider.SSOURL,
+		provider.Certificate,
+		provider.EmailAttribute,
+		provider.NameAttribute,
+		provider.GroupsAttribute,
+		provider.EmailDomains,
+		provider.Enabled,
+		provider.CreatedAt,
+		provider.UpdatedAt,
+	)
+
+	return mapError(err)
+}
+
+// GetByID retrieves a provider by ID.
+func (r *SAMLProviderRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.SAMLProvider, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `SELECT `+samlProviderColumns+` FROM saml_providers WHERE id = $1`, id)
+
+	return r.scanProvider(row)
+}
+
+// GetBySlug retrieves a provider by its slug.
+func (r *SAMLProviderRepository) GetBySlug(ctx context.Context, slug string) (*domain.SAMLProvider, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `SELECT `+samlProviderColumns+` FROM saml_providers WHERE slug = $1`, slug)
+
+	return r.scanProvider(row)
+}
+
+// List retrieves every provider.
+func (r *SAMLProviderRepository) List(ctx context.Context) ([]domain.SAMLProvider, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `SELECT `+samlProviderColumns+` FROM saml_providers ORDER BY slug`)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var providers []domain.SAMLProvider
+	for rows.Next() {
+		provider, err := r.scanProvider(rows)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, *provider)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return providers, nil
+}
+
+// Update saves changes to an existing provider.
+func (r *SAMLProviderRepository) Update(ctx context.Context, provider *domain.SAMLProvider) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `
+		UPDATE saml_providers SET
+			slug = $2, name = $3, entity_id = $4, sso_url = $5, certificate = $6,
+			email_attribute = $7, name_attribute = $8, groups_attribute = $9, email_domains = $10,
+			enabled = $11
+		WHERE id = $1`,
+		provider.ID,
+		provider.Slug,
+		provider.Name,
+		provider.EntityID,
+		provider.SSOURL,
+		provider.Certificate,
+		provider.EmailAttribute,
+		provider.NameAttribute,
+		provider.GroupsAttribute,
+		provider.EmailDomains,
+		provider.Enabled,
+	)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a provider. Group roles cascade.
+func (r *SAMLProviderRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `DELETE FROM saml_providers WHERE id = $1`, id)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// CreateGroupRole stores a new group mapping.
+func (r *SAMLProviderRepository) CreateGroupRole(ctx context.Context, mapping *domain.SAMLGroupRole) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO saml_group_roles (id, provider_id, group_name, role_id, created_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		mapping.ID,
+		mapping.ProviderID,
+		mapping.Group,
+		mapping.RoleID,
+		mapping.CreatedAt,
+	)
+
+	return mapError(err)
+}
+
+// DeleteGroupRole removes a group mapping.
+func (r *SAMLProviderRepository) DeleteGroupRole(ctx context.Context, providerID, id uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `DELETE FROM saml_group_roles WHERE provider_id = $1 AND id = $2`, providerID, id)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// ListGroupRoles retrieves a provider's group mappings.
+func (r *SAMLProviderRepository) ListGroupRoles(ctx context.Context, providerID uuid.UUID) ([]domain.SAMLGroupRole, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT m.id, m.provider_id, m.group_name, m.role_id, r.name, m.created_at
+		FROM saml_group_roles m
+		JOIN roles r ON r.id = m.role_id
+		WHERE m.provider_id = $1
+		ORDER BY m.group_name, r.name`, providerID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var mappings []domain.SAMLGroupRole
+	for rows.Next() {
+		var m domain.SAMLGroupRole
+		if err := rows.Scan(&m.ID, &m.ProviderID, &m.Group, &m.RoleID, &m.RoleName, &m.CreatedAt); err != nil {
+			return nil, mapError(err)
+		}
+		mappings = append(mappings, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return mappings, nil
+}
+
+func (r *SAMLProviderRepository) scanProvider(row scannable) (*domain.SAMLProvider, error) {
+	var p domain.SAMLProvider
+
+	err := row.Scan(
+		&p.ID,
+		&p.Slug,
+		&p.Name,
+		&p.EntityID,
+		&p.SSOURL,
+		&p.Certificate,
+		&p.EmailAttribute,
+		&p.NameAttribute,
+		&p.GroupsAttribute,
+		&p.EmailDomains,
+		&p.Enabled,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &p, nil
+}
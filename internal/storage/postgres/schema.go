@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/schema"
+)
+
+// SchemaRepository implements storage.SchemaRepository using PostgreSQL.
+type SchemaRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewSchemaRepository creates a new schema repository.
+func NewSchemaRepository(pool *pgxpool.Pool) *SchemaRepository {
+	return &SchemaRepository{pool: pool}
+}
+
+// State reads golang-migrate's schema_migrations and the phases recorded
+// by aegisctl migrate. Either table may be missing: schema_migrations
+// before the first migration, schema_migration_phases before migration 022.
+func (r *SchemaRepository) State(ctx context.Context) (schema.State, error) {
+	db := getDB(ctx, r.pool)
+
+	var migrations, phases bool
+	err := db.QueryRow(ctx, `
+		SELECT to_regclass('schema_migrations') IS NOT NULL,
+		       to_regclass('schema_migration_phases') IS NOT NULL`,
+	).Scan(&migrations, &phases)
+	if err != nil {
+		return schema.State{}, mapError(err)
+	}
+
+	var state schema.State
+	if !migrations {
+		return state, nil
+	}
+
+	var version int64
+	err = db.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &state.Dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return state, nil
+	}
+	if err != nil {
+		return schema.State{}, mapError(err)
+	}
+	state.Version = uint(version)
+
+	if phases {
+		var contracted int64
+		err = db.QueryRow(ctx, `
+			SELECT COALESCE(MAX(version), 0) FROM schema_migration_phases
+			WHERE phase = 'contract' AND version <= $1`,
+			version,
+		).Scan(&contracted)
+		if err != nil {
+			return schema.State{}, mapError(err)
+		}
+		state.Contracted = uint(contracted)
+	}
+	return state, nil
+}
+
+// Record inserts the migrations it doesn't have yet, so versions applied
+// with the migrate tool or before migration 022 are filled in too.
+func (r *SchemaRepository) Record(ctx context.Context, migrations []schema.Migration, version uint) error {
+	db := getDB(ctx, r.pool)
+
+	var exists bool
+	err := db.QueryRow(ctx, `SELECT to_regclass('schema_migration_phases') IS NOT NULL`).Scan(&exists)
+	if err != nil || !exists {
+		return mapError(err)
+	}
+
+	if _, err := db.Exec(ctx, `DELETE FROM schema_migration_phases WHERE version > $1`, int64(version)); err != nil {
+		return mapError(err)
+	}
+	for _, m := range migrations {
+		if m.Version > version {
+			break
+		}
+		_, err := db.Exec(ctx, `
+			INSERT INTO schema_migration_phases (version, name, phase, applied_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (version) DO NOTHING`,
+			int64(m.Version), m.Name, string(m.Phase), domain.Now(),
+		)
+		if err != nil {
+			return mapError(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// SCIMRepository implements storage.SCIMRepository using PostgreSQL.
+type SCIMRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewSCIMRepository creates a new SCIM repository.
+func NewSCIMRepository(pool *pgxpool.Pool) *SCIMRepository {
+	return &SCIMRepository{pool: pool}
+}
+
+// SetExternalID upserts the resource's external ID.
+func (r *SCIMRepository) SetExternalID(ctx context.Context, resource domain.SCIMResource, id uuid.UUID, externalID string) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO scim_external_ids (resource_type, resource_id, external_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (resource_type, resource_id) DO UPDATE SET external_id = EXCLUDED.external_id`,
+		string(resource), id, externalID)
+
+	return mapError(err)
+}
+
+// DeleteExternalID removes the resource's external ID.
+func (r *SCIMRepository) DeleteExternalID(ctx context.Context, resource domain.SCIMResource, id uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		DELETE FROM scim_external_ids
+		WHERE resource_type = $1 AND resource_id = $2`,
+		string(resource), id)
+
+	return mapError(err)
+}
+
+// ExternalIDs looks up the external IDs of a batch of resources.
+func (r *SCIMRepository) ExternalIDs(ctx context.Context, resource domain.SCIMResource, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	db := getDB(ctx, r.pool)
+
+	externalIDs := make(map[uuid.UUID]string)
+	if len(ids) == 0 {
+		return externalIDs, nil
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT resource_id, external_id
+		FROM scim_external_ids
+		WHERE resource_type = $1 AND resource_id = ANY($2)`,
+		string(resource), ids)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id         uuid.UUID
+			externalID string
+		)
+		if err := rows.Scan(&id, &externalID); err != nil {
+			return nil, mapError(err)
+		}
+		externalIDs[id] = externalID
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return externalIDs, nil
+}
+
+// FindByExternalID looks up a resource by its external ID.
+func (r *SCIMRepository) FindByExternalID(ctx context.Context, resource domain.SCIMResource, externalID string) (uuid.UUID, error) {
+	db := getDB(ctx, r.pool)
+
+	var id uuid.UUID
+	err := db.QueryRow(ctx, `
+		SELECT resource_id FROM scim_external_ids
+		WHERE resource_type = $1 AND external_id = $2`,
+		string(resource), externalID).Scan(&id)
+	if err != nil {
+		return uuid.Nil, mapError(err)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,239 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// SupportLookupRepository implements storage.SupportLookupRepository using PostgreSQL.
+type SupportLookupRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewSupportLookupRepository creates a new support lookup repository.
+func NewSupportLookupRepository(pool *pgxpool.Pool) *SupportLookupRepository {
+	return &SupportLookupRepository{pool: pool}
+}
+
+// likeEscaper escapes LIKE wildcards so a fragment only matches itself.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// UsersByEmail matches the fragment anywhere in the email.
+func (r *SupportLookupRepository) UsersByEmail(ctx context.Context, fragment string, limit int) ([]domain.User, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT id, email, password_hash, phone, username, full_name,
+			   user_type, status, email_verified, phone_verified, attributes,
+			   COALESCE(locale, ''), COALESCE(timezone, ''),
+			   created_at, updated_at, deleted_at, version
+		FROM users
+		WHERE email ILIKE '%' || $1 || '%'
+		ORDER BY created_at DESC
+		LIMIT $2`,
+		likeEscaper.Replace(fragment), limit)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var users []domain.User
+	for rows.Next() {
+		user, err := scanUserRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		user.PasswordHash = ""
+		users = append(users, *user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return users, nil
+}
+
+// TokensByIP lists the tokens issued to the address. Token hashes are left
+// out.
+func (r *SupportLookupRepository) TokensByIP(ctx context.Context, ipAddress string, limit int) ([]domain.RefreshToken, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT id, user_id, expires_at, created_at, revoked_at,
+			   COALESCE(ip_address, ''), COALESCE(user_agent, ''), profile_id
+		FROM refresh_tokens
+		WHERE ip_address = $1
+		ORDER BY created_at DESC
+		LIMIT $2`,
+		ipAddress, limit)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var tokens []domain.RefreshToken
+	for rows.Next() {
+		var t domain.RefreshToken
+		if err := rows.Scan(
+			&t.ID,
+			&t.UserID,
+			&t.ExpiresAt,
+			&t.CreatedAt,
+			&t.RevokedAt,
+			&t.IPAddress,
+			&t.UserAgent,
+			&t.ProfileID,
+		); err != nil {
+			return nil, mapError(err)
+		}
+		tokens = append(tokens, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return tokens, nil
+}
+
+// LoginsByIP lists the attempts made from the address.
+func (r *SupportLookupRepository) LoginsByIP(ctx context.Context, ipAddress string, limit int) ([]domain.LoginAttempt, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT id, user_id, success, COALESCE(failure_reason, ''), COALESCE(ip_address, ''),
+			COALESCE(user_agent, ''), created_at
+		FROM login_attempts
+		WHERE ip_address = $1
+		ORDER BY created_at DESC
+		LIMIT $2`,
+		ipAddress, limit)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var attempts []domain.LoginAttempt
+	for rows.Next() {
+		var a domain.LoginAttempt
+		if err := rows.Scan(
+			&a.ID,
+			&a.UserID,
+			&a.Success,
+			&a.FailureReason,
+			&a.IPAddress,
+			&a.UserAgent,
+			&a.CreatedAt,
+		); err != nil {
+			return nil, mapError(err)
+		}
+		attempts = append(attempts, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return attempts, nil
+}
+
+const supportLookupColumns = `id, actor_id, query, params, reason, result_count,
+	COALESCE(ip_address, ''), COALESCE(user_agent, ''), created_at`
+
+// Record stores a lookup.
+func (r *SupportLookupRepository) Record(ctx context.Context, lookup *domain.SupportLookup) error {
+	db := getDB(ctx, r.pool)
+
+	params := lookup.Params
+	if params == nil {
+		params = map[string]string{}
+	}
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO support_lookups (
+			id, actor_id, query, params, reason, result_count,
+			ip_address, user_agent, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		lookup.ID,
+		lookup.ActorID,
+		lookup.Query,
+		params,
+		lookup.Reason,
+		lookup.ResultCount,
+		lookup.IPAddress,
+		lookup.UserAgent,
+		lookup.CreatedAt,
+	)
+
+	return mapError(err)
+}
+
+// List retrieves lookups matching the filter, newest first.
+func (r *SupportLookupRepository) List(ctx context.Context, filter storage.SupportLookupFilter) ([]domain.SupportLookup, int64, error) {
+	db := getDB(ctx, r.pool)
+
+	if filter.Limit <= 0 {
+		filter.Limit = 20
+	}
+	if filter.Limit > 100 {
+		filter.Limit = 100
+	}
+
+	const where = `
+		WHERE ($1::uuid IS NULL OR actor_id = $1)
+		  AND ($2 = '' OR query = $2)`
+	args := []any{filter.ActorID, filter.Query}
+
+	var total int64
+	if err := db.QueryRow(ctx, `SELECT COUNT(*) FROM support_lookups`+where, args...).Scan(&total); err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT `+supportLookupColumns+` FROM support_lookups`+where+`
+		ORDER BY created_at DESC, id
+		LIMIT $3 OFFSET $4`,
+		append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+	defer rows.Close()
+
+	var lookups []domain.SupportLookup
+	for rows.Next() {
+		var (
+			l       domain.SupportLookup
+			actorID *uuid.UUID
+		)
+		if err := rows.Scan(
+			&l.ID,
+			&actorID,
+			&l.Query,
+			&l.Params,
+			&l.Reason,
+			&l.ResultCount,
+			&l.IPAddress,
+			&l.UserAgent,
+			&l.CreatedAt,
+		); err != nil {
+			return nil, 0, mapError(err)
+		}
+		if actorID != nil {
+			l.ActorID = *actorID
+		}
+		lookups = append(lookups, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	return lookups, total, nil
+}
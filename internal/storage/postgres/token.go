@@ -2,8 +2,10 @@ package postgres
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/mvaleed/aegis/internal/domain"
@@ -19,14 +21,19 @@ func NewTokenRepository(pool *pgxpool.Pool) *TokenRepository {
 	return &TokenRepository{pool: pool}
 }
 
+const refreshTokenColumns = `id, user_id, token_hash, expires_at, created_at,
+	revoked_at, ip_address, user_agent, profile_id, scope, COALESCE(family_id, id), replaced_by_id,
+	COALESCE(session_started_at, created_at), COALESCE(remember_me, TRUE)`
+
 // Create stores a new refresh token.
 func (r *TokenRepository) Create(ctx context.Context, token *domain.RefreshToken) error {
 	db := getDB(ctx, r.pool)
 
 	_, err := db.Exec(ctx, `
 		INSERT INTO refresh_tokens (
-			id, user_id, token_hash, expires_at, created_at, ip_address, user_agent
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			id, user_id, token_hash, expires_at, created_at, ip_address, user_agent, profile_id, scope,
+			family_id, session_started_at, remember_me
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
 		token.ID,
 		token.UserID,
 		token.TokenHash,
@@ -34,6 +41,11 @@ func (r *TokenRepository) Create(ctx context.Context, token *domain.RefreshToken
 		token.CreatedAt,
 		token.IPAddress,
 		token.UserAgent,
+		token.ProfileID,
+		token.Scope,
+		token.FamilyID,
+		token.SessionStartedAt,
+		token.RememberMe,
 	)
 
 	return mapError(err)
@@ -43,10 +55,7 @@ func (r *TokenRepository) Create(ctx context.Context, token *domain.RefreshToken
 func (r *TokenRepository) GetByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
 	db := getDB(ctx, r.pool)
 
-	row := db.QueryRow(ctx, `
-		SELECT id, user_id, token_hash, expires_at, created_at,
-			   revoked_at, ip_address, user_agent, replaced_by_id
-		FROM refresh_tokens WHERE token_hash = $1`, hash)
+	row := db.QueryRow(ctx, `SELECT `+refreshTokenColumns+` FROM refresh_tokens WHERE token_hash = $1`, hash)
 
 	return r.scanToken(row)
 }
@@ -56,8 +65,8 @@ func (r *TokenRepository) Revoke(ctx context.Context, id uuid.UUID) error {
 	db := getDB(ctx, r.pool)
 
 	result, err := db.Exec(ctx, `
-		UPDATE refresh_tokens SET revoked_at = NOW()
-		WHERE id = $1 AND revoked_at IS NULL`, id)
+		UPDATE refresh_tokens SET revoked_at = $2
+		WHERE id = $1 AND revoked_at IS NULL`, id, domain.Now())
 	if err != nil {
 		return mapError(err)
 	}
@@ -74,8 +83,8 @@ func (r *TokenRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID
 	db := getDB(ctx, r.pool)
 
 	_, err := db.Exec(ctx, `
-		UPDATE refresh_tokens SET revoked_at = NOW()
-		WHERE user_id = $1 AND revoked_at IS NULL`, userID)
+		UPDATE refresh_tokens SET revoked_at = $2
+		WHERE user_id = $1 AND revoked_at IS NULL`, userID, domain.Now())
 
 	return mapError(err)
 }
@@ -86,7 +95,87 @@ func (r *TokenRepository) DeleteExpired(ctx context.Context) (int64, error) {
 
 	result, err := db.Exec(ctx, `
 		DELETE FROM refresh_tokens
-		WHERE expires_at < NOW() - INTERVAL '7 days'`)
+		WHERE expires_at < $1::timestamptz - INTERVAL '7 days'`, domain.Now())
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// CountActive counts unrevoked, unexpired tokens and their users.
+func (r *TokenRepository) CountActive(ctx context.Context, now time.Time) (sessions, users int64, err error) {
+	db := getDB(ctx, r.pool)
+
+	err = db.QueryRow(ctx, `
+		SELECT COUNT(*), COUNT(DISTINCT user_id)
+		FROM refresh_tokens
+		WHERE revoked_at IS NULL AND expires_at > $1`, now).Scan(&sessions, &users)
+	if err != nil {
+		return 0, 0, mapError(err)
+	}
+
+	return sessions, users, nil
+}
+
+// CountActiveForUser counts the user's unrevoked, unexpired tokens.
+func (r *TokenRepository) CountActiveForUser(ctx context.Context, userID uuid.UUID, now time.Time) (int64, error) {
+	db := getDB(ctx, r.pool)
+
+	var count int64
+	err := db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM refresh_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2`, userID, now).Scan(&count)
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	return count, nil
+}
+
+// ListActiveForUser returns the user's unrevoked, unexpired tokens.
+func (r *TokenRepository) ListActiveForUser(ctx context.Context, userID uuid.UUID, now time.Time) ([]domain.RefreshToken, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT `+refreshTokenColumns+`
+		FROM refresh_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY created_at DESC`, userID, now)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return r.collectTokens(rows)
+}
+
+// MarkReplaced revokes a rotated token, linking it to its replacement.
+func (r *TokenRepository) MarkReplaced(ctx context.Context, id, replacedByID uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `
+		UPDATE refresh_tokens SET revoked_at = $3, replaced_by_id = $2
+		WHERE id = $1 AND revoked_at IS NULL`, id, replacedByID, domain.Now())
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// RevokeFamily revokes a token family: the tokens with its family_id and
+// the one whose ID it is, which covers tokens issued before families and so
+// without a family_id.
+func (r *TokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) (int64, error) {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `
+		UPDATE refresh_tokens SET revoked_at = $2
+		WHERE (family_id = $1 OR id = $1) AND revoked_at IS NULL`, familyID, domain.Now())
 	if err != nil {
 		return 0, mapError(err)
 	}
@@ -94,6 +183,41 @@ func (r *TokenRepository) DeleteExpired(ctx context.Context) (int64, error) {
 	return result.RowsAffected(), nil
 }
 
+// ListFamily returns a token family, oldest first.
+func (r *TokenRepository) ListFamily(ctx context.Context, familyID uuid.UUID) ([]domain.RefreshToken, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT `+refreshTokenColumns+`
+		FROM refresh_tokens
+		WHERE family_id = $1 OR id = $1
+		ORDER BY created_at`, familyID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return r.collectTokens(rows)
+}
+
+func (r *TokenRepository) collectTokens(rows pgx.Rows) ([]domain.RefreshToken, error) {
+	defer rows.Close()
+
+	var tokens []domain.RefreshToken
+	for rows.Next() {
+		token, err := r.scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return tokens, nil
+}
+
 func (r *TokenRepository) scanToken(row scannable) (*domain.RefreshToken, error) {
 	var token domain.RefreshToken
 
@@ -106,6 +230,12 @@ func (r *TokenRepository) scanToken(row scannable) (*domain.RefreshToken, error)
 		&token.RevokedAt,
 		&token.IPAddress,
 		&token.UserAgent,
+		&token.ProfileID,
+		&token.Scope,
+		&token.FamilyID,
+		&token.ReplacedByID,
+		&token.SessionStartedAt,
+		&token.RememberMe,
 	)
 	if err != nil {
 		return nil, mapError(err)
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// TokenExchangeRepository implements storage.TokenExchangeRepository using
+// PostgreSQL.
+type TokenExchangeRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewTokenExchangeRepository creates a new token exchange repository.
+func NewTokenExchangeRepository(pool *pgxpool.Pool) *TokenExchangeRepository {
+	return &TokenExchangeRepository{pool: pool}
+}
+
+const tokenExchangeColumns = `id, client_id, user_id, audience, permissions, subject_token_id,
+	COALESCE(ip_address, ''), COALESCE(user_agent, ''), created_at, expires_at`
+
+// Create records an exchange.
+func (r *TokenExchangeRepository) Create(ctx context.Context, e *domain.TokenExchange) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO token_exchanges (
+			id, client_id, user_id, audience, permissions, subject_token_id,
+			ip_address, user_agent, created_at, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		e.ID,
+		e.ClientID,
+		e.UserID,
+		e.Audience,
+		e.Permissions,
+		e.SubjectTokenID,
+		e.IPAddress,
+		e.UserAgent,
+		e.CreatedAt,
+		e.ExpiresAt,
+	)
+
+	return mapError(err)
+}
+
+// List retrieves exchanges matching the filter, newest first.
+func (r *TokenExchangeRepository) List(ctx context.Context, filter storage.TokenExchangeFilter) ([]domain.TokenExchange, int64, error) {
+	db := getDB(ctx, r.pool)
+
+	const where = `
+		WHERE ($1::uuid IS NULL OR user_id = $1)
+		AND ($2::uuid IS NULL OR client_id = $2)`
+
+	var total int64
+	err := db.QueryRow(ctx, `SELECT COUNT(*) FROM token_exchanges`+where,
+		filter.UserID, filter.ClientID).Scan(&total)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT `+tokenExchangeColumns+`
+		FROM token_exchanges`+where+`
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4`,
+		filter.UserID, filter.ClientID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+	defer rows.Close()
+
+	var exchanges []domain.TokenExchange
+	for rows.Next() {
+		exchange, err := r.scanExchange(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		exchanges = append(exchanges, *exchange)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	return exchanges, total, nil
+}
+
+func (r *TokenExchangeRepository) scanExchange(row scannable) (*domain.TokenExchange, error) {
+	var e domain.TokenExchange
+
+	err := row.Scan(
+		&e.ID,
+		&e.ClientID,
+		&e.UserID,
+		&e.Audience,
+		&e.Permissions,
+		&e.SubjectTokenID,
+		&e.IPAddress,
+		&e.UserAgent,
+		&e.CreatedAt,
+		&e.ExpiresAt,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &e, nil
+}
@@ -2,7 +2,7 @@ package postgres
 
 import (
 	"context"
-	"time"
+	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -28,9 +28,9 @@ func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 	_, err := db.Exec(ctx, `
 		INSERT INTO users (
 			id, email, password_hash, phone, username, full_name,
-			user_type, status, email_verified, phone_verified,
-			created_at, updated_at, version
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+			user_type, status, email_verified, phone_verified, attributes,
+			locale, timezone, created_at, updated_at, version
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, ''), NULLIF($13, ''), $14, $15, $16)`,
 		user.ID,
 		user.Email,
 		user.PasswordHash,
@@ -41,6 +41,9 @@ func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 		string(user.Status),
 		user.EmailVerified,
 		user.PhoneVerified,
+		attributesOrEmpty(user.Attributes),
+		user.Locale,
+		user.Timezone,
 		user.CreatedAt,
 		user.UpdatedAt,
 		user.Version,
@@ -55,7 +58,8 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Use
 
 	row := db.QueryRow(ctx, `
 		SELECT id, email, password_hash, phone, username, full_name,
-			   user_type, status, email_verified, phone_verified,
+			   user_type, status, email_verified, phone_verified, attributes,
+			   COALESCE(locale, ''), COALESCE(timezone, ''),
 			   created_at, updated_at, deleted_at, version
 		FROM users WHERE id = $1 AND deleted_at IS NULL`, id)
 
@@ -68,7 +72,8 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.
 
 	row := db.QueryRow(ctx, `
 		SELECT id, email, password_hash, phone, username, full_name,
-			   user_type, status, email_verified, phone_verified,
+			   user_type, status, email_verified, phone_verified, attributes,
+			   COALESCE(locale, ''), COALESCE(timezone, ''),
 			   created_at, updated_at, deleted_at, version
 		FROM users WHERE LOWER(email) = LOWER($1) AND deleted_at IS NULL`, email)
 
@@ -81,7 +86,8 @@ func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*d
 
 	row := db.QueryRow(ctx, `
 		SELECT id, email, password_hash, phone, username, full_name,
-			   user_type, status, email_verified, phone_verified,
+			   user_type, status, email_verified, phone_verified, attributes,
+			   COALESCE(locale, ''), COALESCE(timezone, ''),
 			   created_at, updated_at, deleted_at, version
 		FROM users WHERE username = $1 AND deleted_at IS NULL`, username)
 
@@ -103,9 +109,11 @@ func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
 			status = $8,
 			email_verified = $9,
 			phone_verified = $10,
-			updated_at = $11,
+			locale = NULLIF($11, ''),
+			timezone = NULLIF($12, ''),
+			updated_at = $13,
 			version = version + 1
-		WHERE id = $1 AND version = $12 AND deleted_at IS NULL`,
+		WHERE id = $1 AND version = $14 AND deleted_at IS NULL`,
 		user.ID,
 		user.Email,
 		user.PasswordHash,
@@ -116,7 +124,9 @@ func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
 		string(user.Status),
 		user.EmailVerified,
 		user.PhoneVerified,
-		time.Now().UTC(),
+		user.Locale,
+		user.Timezone,
+		domain.Now(),
 		user.Version,
 	)
 	if err != nil {
@@ -139,13 +149,47 @@ func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
 	return nil
 }
 
+// UpdateAttributes saves the user's custom attributes with optimistic locking.
+func (r *UserRepository) UpdateAttributes(ctx context.Context, user *domain.User) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `
+		UPDATE users SET
+			attributes = $2,
+			updated_at = $3,
+			version = version + 1
+		WHERE id = $1 AND version = $4 AND deleted_at IS NULL`,
+		user.ID,
+		attributesOrEmpty(user.Attributes),
+		domain.Now(),
+		user.Version,
+	)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		existing, err := r.GetByID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		if existing.Version != user.Version {
+			return domain.ErrVersionMismatch
+		}
+		return domain.ErrNotFound
+	}
+
+	user.Version++
+	return nil
+}
+
 // Delete performs a soft delete.
 func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	db := getDB(ctx, r.pool)
 
 	result, err := db.Exec(ctx, `
-		UPDATE users SET deleted_at = NOW(), updated_at = NOW()
-		WHERE id = $1 AND deleted_at IS NULL`, id)
+		UPDATE users SET deleted_at = $2, updated_at = $2
+		WHERE id = $1 AND deleted_at IS NULL`, id, domain.Now())
 	if err != nil {
 		return mapError(err)
 	}
@@ -183,7 +227,7 @@ func (r *UserRepository) List(ctx context.Context, filter storage.UserFilter) ([
 		if whereClause != "" {
 			whereClause += " AND "
 		}
-		whereClause += "status = $" + string(rune('0'+argIndex))
+		whereClause += "status = $" + strconv.Itoa(argIndex)
 		args = append(args, string(*filter.Status))
 		argIndex++
 	}
@@ -192,18 +236,54 @@ func (r *UserRepository) List(ctx context.Context, filter storage.UserFilter) ([
 		if whereClause != "" {
 			whereClause += " AND "
 		}
-		whereClause += "user_type = $" + string(rune('0'+argIndex))
+		whereClause += "user_type = $" + strconv.Itoa(argIndex)
 		args = append(args, string(*filter.Type))
 		argIndex++
 	}
 
+	if filter.OrganizationID != nil {
+		if whereClause != "" {
+			whereClause += " AND "
+		}
+		whereClause += "id IN (SELECT user_id FROM organization_members WHERE organization_id = $" + strconv.Itoa(argIndex) + ")"
+		args = append(args, *filter.OrganizationID)
+		argIndex++
+	}
+
+	if len(filter.Attributes) > 0 {
+		if whereClause != "" {
+			whereClause += " AND "
+		}
+		whereClause += "attributes @> $" + strconv.Itoa(argIndex) + "::jsonb"
+		args = append(args, filter.Attributes)
+		argIndex++
+	}
+
+	if filter.Locale != "" {
+		if whereClause != "" {
+			whereClause += " AND "
+		}
+		whereClause += "(locale = $" + strconv.Itoa(argIndex) + " OR locale LIKE $" + strconv.Itoa(argIndex) + " || '-%')"
+		args = append(args, filter.Locale)
+		argIndex++
+	}
+
+	if filter.Timezone != "" {
+		if whereClause != "" {
+			whereClause += " AND "
+		}
+		whereClause += "timezone = $" + strconv.Itoa(argIndex)
+		args = append(args, filter.Timezone)
+		argIndex++
+	}
+
 	if filter.Search != "" {
 		if whereClause != "" {
 			whereClause += " AND "
 		}
-		whereClause += "(LOWER(email) LIKE LOWER($" + string(rune('0'+argIndex)) + ") OR " +
-			"LOWER(username) LIKE LOWER($" + string(rune('0'+argIndex)) + ") OR " +
-			"LOWER(full_name) LIKE LOWER($" + string(rune('0'+argIndex)) + "))"
+		whereClause += "(LOWER(email) LIKE LOWER($" + strconv.Itoa(argIndex) + ") OR " +
+			"LOWER(username) LIKE LOWER($" + strconv.Itoa(argIndex) + ") OR " +
+			"LOWER(full_name) LIKE LOWER($" + strconv.Itoa(argIndex) + "))"
 		args = append(args, "%"+filter.Search+"%")
 		argIndex++
 	}
@@ -212,23 +292,30 @@ func (r *UserRepository) List(ctx context.Context, filter storage.UserFilter) ([
 		whereClause = "1=1"
 	}
 
-	// Count total
-	countQuery := "SELECT COUNT(*) FROM users WHERE " + whereClause
+	// Count total, unless paging by keyset
 	var total int64
-	err := db.QueryRow(ctx, countQuery, args...).Scan(&total)
-	if err != nil {
-		return nil, 0, mapError(err)
+	if filter.After == nil {
+		countQuery := "SELECT COUNT(*) FROM users WHERE " + whereClause
+		if err := db.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+			return nil, 0, mapError(err)
+		}
+	} else {
+		whereClause += " AND (created_at, id) < ($" + strconv.Itoa(argIndex) + ", $" + strconv.Itoa(argIndex+1) + ")"
+		args = append(args, filter.After.CreatedAt, filter.After.ID)
+		argIndex += 2
+		filter.Offset = 0
 	}
 
 	// Get page
 	listArgs := append(args, filter.Limit, filter.Offset)
 	listQuery := `
 		SELECT id, email, password_hash, phone, username, full_name,
-			   user_type, status, email_verified, phone_verified,
+			   user_type, status, email_verified, phone_verified, attributes,
+			   COALESCE(locale, ''), COALESCE(timezone, ''),
 			   created_at, updated_at, deleted_at, version
 		FROM users WHERE ` + whereClause + `
-		ORDER BY created_at DESC
-		LIMIT $` + string(rune('0'+argIndex)) + ` OFFSET $` + string(rune('0'+argIndex+1))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $` + strconv.Itoa(argIndex) + ` OFFSET $` + strconv.Itoa(argIndex+1)
 
 	rows, err := db.Query(ctx, listQuery, listArgs...)
 	if err != nil {
@@ -257,7 +344,57 @@ type scannable interface {
 	Scan(dest ...any) error
 }
 
+// EmailTaken reports whether any user, including soft-deleted ones, holds the email.
+func (r *UserRepository) EmailTaken(ctx context.Context, email string) (bool, error) {
+	db := getDB(ctx, r.pool)
+
+	var taken bool
+	err := db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM users WHERE LOWER(email) = LOWER($1))`, email).Scan(&taken)
+	if err != nil {
+		return false, mapError(err)
+	}
+
+	return taken, nil
+}
+
+// TakenUsernames returns the given usernames that are held by any user,
+// including soft-deleted ones, or reserved for their former holder.
+func (r *UserRepository) TakenUsernames(ctx context.Context, usernames []string) ([]string, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT username FROM users WHERE username = ANY($1)
+		UNION
+		SELECT username FROM username_history WHERE username = ANY($1) AND reserved_until > $2`,
+		usernames, domain.Now())
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var taken []string
+	for rows.Next() {
+		var username string
+		if err := rows.Scan(&username); err != nil {
+			return nil, mapError(err)
+		}
+		taken = append(taken, username)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return taken, nil
+}
+
 func (r *UserRepository) scanUser(row scannable) (*domain.User, error) {
+	return scanUserRow(row)
+}
+
+// scanUserRow scans the columns the user queries select, in order.
+func scanUserRow(row scannable) (*domain.User, error) {
 	var user domain.User
 	var userType, status string
 
@@ -272,6 +409,9 @@ func (r *UserRepository) scanUser(row scannable) (*domain.User, error) {
 		&status,
 		&user.EmailVerified,
 		&user.PhoneVerified,
+		&user.Attributes,
+		&user.Locale,
+		&user.Timezone,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 		&user.DeletedAt,
@@ -287,6 +427,14 @@ func (r *UserRepository) scanUser(row scannable) (*domain.User, error) {
 	return &user, nil
 }
 
+// attributesOrEmpty keeps a nil map from being stored as SQL NULL.
+func attributesOrEmpty(attrs map[string]any) map[string]any {
+	if attrs == nil {
+		return map[string]any{}
+	}
+	return attrs
+}
+
 func (r *UserRepository) scanUserFromRows(rows scannable) (*domain.User, error) {
 	return r.scanUser(rows)
 }
@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// UsernameHistoryRepository implements storage.UsernameHistoryRepository
+// using PostgreSQL.
+type UsernameHistoryRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewUsernameHistoryRepository creates a new username history repository.
+func NewUsernameHistoryRepository(pool *pgxpool.Pool) *UsernameHistoryRepository {
+	return &UsernameHistoryRepository{pool: pool}
+}
+
+const usernameChangeColumns = `id, user_id, username, changed_at, reserved_until`
+
+// Create records a username change.
+func (r *UsernameHistoryRepository) Create(ctx context.Context, change *domain.UsernameChange) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO username_history (id, user_id, username, changed_at, reserved_until)
+		VALUES ($1, $2, $3, $4, $5)`,
+		change.ID, change.UserID, change.Username, change.ChangedAt, change.ReservedUntil,
+	)
+
+	return mapError(err)
+}
+
+// LatestForUser returns the user's most recent change.
+func (r *UsernameHistoryRepository) LatestForUser(ctx context.Context, userID uuid.UUID) (*domain.UsernameChange, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `
+		SELECT `+usernameChangeColumns+` FROM username_history
+		WHERE user_id = $1
+		ORDER BY changed_at DESC
+		LIMIT 1`, userID)
+
+	return r.scanChange(row)
+}
+
+// LatestForUsername returns the most recent change away from username.
+func (r *UsernameHistoryRepository) LatestForUsername(ctx context.Context, username string) (*domain.UsernameChange, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `
+		SELECT `+usernameChangeColumns+` FROM username_history
+		WHERE username = $1
+		ORDER BY changed_at DESC
+		LIMIT 1`, username)
+
+	return r.scanChange(row)
+}
+
+// ListForUser returns the user's changes, newest first.
+func (r *UsernameHistoryRepository) ListForUser(ctx context.Context, userID uuid.UUID) ([]domain.UsernameChange, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		SELECT `+usernameChangeColumns+` FROM username_history
+		WHERE user_id = $1
+		ORDER BY changed_at DESC`, userID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var changes []domain.UsernameChange
+	for rows.Next() {
+		change, err := r.scanChange(rows)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, *change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return changes, nil
+}
+
+func (r *UsernameHistoryRepository) scanChange(row scannable) (*domain.UsernameChange, error) {
+	var change domain.UsernameChange
+
+	err := row.Scan(
+		&change.ID,
+		&change.UserID,
+		&change.Username,
+		&change.ChangedAt,
+		&change.ReservedUntil,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &change, nil
+}
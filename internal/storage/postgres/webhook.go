@@ -0,0 +1,484 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/encryption"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// WebhookRepository implements storage.WebhookRepository using PostgreSQL.
+type WebhookRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewWebhookRepository creates a new webhook repository.
+func NewWebhookRepository(pool *pgxpool.Pool) *WebhookRepository {
+	return &WebhookRepository{pool: pool}
+}
+
+const webhookColumns = `id, url, description, event_types, secret, secret_key_id, active, created_by, owner_id, created_at, updated_at`
+
+// webhookSecretColumn binds encrypted secrets to their column.
+const webhookSecretColumn = "webhooks.secret"
+
+const deliveryColumns = `id, webhook_id, event_id, event_type, payload, status, attempts,
+	next_attempt_at, last_attempt_at, response_status, response_body, last_error,
+	created_at, delivered_at`
+
+// Create stores a new webhook.
+func (r *WebhookRepository) Create(ctx context.Context, webhook *domain.Webhook) error {
+	db := getDB(ctx, r.pool)
+
+	var createdBy *uuid.UUID
+	if webhook.CreatedBy != uuid.Nil {
+		createdBy = &webhook.CreatedBy
+	}
+
+	secret, keyID, err := encryption.Encrypt(webhookSecretColumn, webhook.Secret)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(ctx, `
+		INSERT INTO webhooks (`+webhookColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		webhook.ID,
+		webhook.URL,
+		webhook.Description,
+		webhook.EventTypes,
+		secret,
+		keyID,
+		webhook.Active,
+		createdBy,
+		webhook.OwnerID,
+		webhook.CreatedAt,
+		webhook.UpdatedAt,
+	)
+
+	return mapError(err)
+}
+
+// GetByID retrieves a webhook by ID.
+func (r *WebhookRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Webhook, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE id = $1`, id)
+
+	return r.scanWebhook(row)
+}
+
+// Update saves changes to an existing webhook.
+func (r *WebhookRepository) Update(ctx context.Context, webhook *domain.Webhook) error {
+	db := getDB(ctx, r.pool)
+
+	secret, keyID, err := encryption.Encrypt(webhookSecretColumn, webhook.Secret)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.Exec(ctx, `
+		UPDATE webhooks SET
+			url = $2, description = $3, event_types = $4, secret = $5, secret_key_id = $6, active = $7
+		WHERE id = $1`,
+		webhook.ID,
+		webhook.URL,
+		webhook.Description,
+		webhook.EventTypes,
+		secret,
+		keyID,
+		webhook.Active,
+	)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a webhook. Deliveries cascade.
+func (r *WebhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// List retrieves all webhooks.
+func (r *WebhookRepository) List(ctx context.Context) ([]domain.Webhook, error) {
+	return r.listWebhooks(ctx, `SELECT `+webhookColumns+` FROM webhooks ORDER BY created_at`)
+}
+
+// ListForOwner retrieves the webhooks a partner registered.
+func (r *WebhookRepository) ListForOwner(ctx context.Context, ownerID uuid.UUID) ([]domain.Webhook, error) {
+	return r.listWebhooks(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE owner_id = $1 ORDER BY created_at`, ownerID)
+}
+
+// ListActive retrieves the webhooks that should receive events.
+func (r *WebhookRepository) ListActive(ctx context.Context) ([]domain.Webhook, error) {
+	return r.listWebhooks(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE active ORDER BY created_at`)
+}
+
+func (r *WebhookRepository) listWebhooks(ctx context.Context, query string, args ...any) ([]domain.Webhook, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var webhooks []domain.Webhook
+	for rows.Next() {
+		webhook, err := r.scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return webhooks, nil
+}
+
+// CreateDelivery queues a delivery. A repeated event for the same webhook is ignored.
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	db := getDB(ctx, r.pool)
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO webhook_deliveries (
+			id, webhook_id, event_id, event_type, payload, status, attempts,
+			next_attempt_at, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (webhook_id, event_id) DO NOTHING`,
+		delivery.ID,
+		delivery.WebhookID,
+		delivery.EventID,
+		delivery.EventType,
+		delivery.Payload,
+		string(delivery.Status),
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.CreatedAt,
+	)
+
+	return mapError(err)
+}
+
+// GetDelivery retrieves a delivery by ID.
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id uuid.UUID) (*domain.WebhookDelivery, error) {
+	db := getDB(ctx, r.pool)
+
+	row := db.QueryRow(ctx, `SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id)
+
+	return r.scanDelivery(row)
+}
+
+// ClaimDueDeliveries leases due deliveries to the caller.
+// SKIP LOCKED lets several workers drain the queue without blocking each other.
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `
+		UPDATE webhook_deliveries SET next_attempt_at = $3::timestamptz + $2::interval
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $3
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+deliveryColumns, limit, lease, domain.Now())
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var deliveries []domain.WebhookDelivery
+	for rows.Next() {
+		delivery, err := r.scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return deliveries, nil
+}
+
+// UpdateDelivery saves the outcome of a delivery attempt.
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	db := getDB(ctx, r.pool)
+
+	result, err := db.Exec(ctx, `
+		UPDATE webhook_deliveries SET
+			status = $2, attempts = $3, next_attempt_at = $4, last_attempt_at = $5,
+			response_status = $6, response_body = $7, last_error = $8, delivered_at = $9
+		WHERE id = $1`,
+		delivery.ID,
+		string(delivery.Status),
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.LastAttemptAt,
+		delivery.ResponseStatus,
+		delivery.ResponseBody,
+		delivery.LastError,
+		delivery.DeliveredAt,
+	)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// ListDeliveries retrieves delivery history for a webhook, newest first.
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, filter storage.DeliveryFilter) ([]domain.WebhookDelivery, int64, error) {
+	db := getDB(ctx, r.pool)
+
+	whereClause := "webhook_id = $1"
+	args := []any{filter.WebhookID}
+
+	if filter.Status != nil {
+		whereClause += " AND status = $2"
+		args = append(args, string(*filter.Status))
+	}
+
+	var total int64
+	err := db.QueryRow(ctx, "SELECT COUNT(*) FROM webhook_deliveries WHERE "+whereClause, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	argIndex := len(args) + 1
+	listArgs := append(args, filter.Limit, filter.Offset)
+	listQuery := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries WHERE ` + whereClause + `
+		ORDER BY created_at DESC
+		LIMIT $` + string(rune('0'+argIndex)) + ` OFFSET $` + string(rune('0'+argIndex+1))
+
+	rows, err := db.Query(ctx, listQuery, listArgs...)
+	if err != nil {
+		return nil, 0, mapError(err)
+	}
+	defer rows.Close()
+
+	var deliveries []domain.WebhookDelivery
+	for rows.Next() {
+		delivery, err := r.scanDelivery(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, mapError(err)
+	}
+
+	return deliveries, total, nil
+}
+
+// ReencryptSecrets re-encrypts a batch of secrets under old keys. A row is
+// only rewritten if it hasn't changed since it was read, so a secret
+// rotated meanwhile isn't overwritten.
+func (r *WebhookRepository) ReencryptSecrets(ctx context.Context, after uuid.UUID, limit int) (storage.ReencryptResult, error) {
+	db := getDB(ctx, r.pool)
+	result := storage.ReencryptResult{Last: after}
+	current := encryption.CurrentKeyID()
+
+	rows, err := db.Query(ctx, `
+		SELECT id, secret, secret_key_id
+		FROM webhooks
+		WHERE id > $1 AND secret_key_id <> $2 AND secret_key_id = ANY($3)
+		ORDER BY id
+		LIMIT $4`, after, current, encryption.KnownKeyIDs(), limit)
+	if err != nil {
+		return result, mapError(err)
+	}
+
+	type stored struct {
+		id            uuid.UUID
+		secret, keyID string
+	}
+	var batch []stored
+	for rows.Next() {
+		var s stored
+		if err := rows.Scan(&s.id, &s.secret, &s.keyID); err != nil {
+			rows.Close()
+			return result, mapError(err)
+		}
+		batch = append(batch, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return result, mapError(err)
+	}
+
+	for _, s := range batch {
+		result.Last = s.id
+		result.Scanned++
+
+		plaintext, err := encryption.Decrypt(webhookSecretColumn, s.secret, s.keyID)
+		if err != nil {
+			result.Failed++
+			continue
+		}
+		secret, keyID, err := encryption.Encrypt(webhookSecretColumn, plaintext)
+		if err != nil {
+			return result, err
+		}
+
+		tag, err := db.Exec(ctx, `
+			UPDATE webhooks SET secret = $2, secret_key_id = $3
+			WHERE id = $1 AND secret = $4 AND secret_key_id = $5`,
+			s.id, secret, keyID, s.secret, s.keyID)
+		if err != nil {
+			return result, mapError(err)
+		}
+		if tag.RowsAffected() > 0 {
+			result.Reencrypted++
+		}
+	}
+
+	return result, nil
+}
+
+// QueueStats counts pending deliveries, using the partial index on due
+// deliveries.
+func (r *WebhookRepository) QueueStats(ctx context.Context) (domain.WebhookQueueStats, error) {
+	db := getDB(ctx, r.pool)
+
+	var stats domain.WebhookQueueStats
+	err := db.QueryRow(ctx, `
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE next_attempt_at <= $1),
+		       MIN(next_attempt_at) FILTER (WHERE next_attempt_at <= $1)
+		FROM webhook_deliveries
+		WHERE status = 'pending'`, domain.Now()).Scan(&stats.Pending, &stats.Due, &stats.OldestDue)
+	if err != nil {
+		return domain.WebhookQueueStats{}, mapError(err)
+	}
+
+	return stats, nil
+}
+
+// CountSecretsByKey counts secrets per key ID.
+func (r *WebhookRepository) CountSecretsByKey(ctx context.Context) (map[string]int64, error) {
+	db := getDB(ctx, r.pool)
+
+	rows, err := db.Query(ctx, `SELECT secret_key_id, COUNT(*) FROM webhooks GROUP BY secret_key_id`)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var keyID string
+		var count int64
+		if err := rows.Scan(&keyID, &count); err != nil {
+			return nil, mapError(err)
+		}
+		counts[keyID] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	return counts, nil
+}
+
+func (r *WebhookRepository) scanWebhook(row scannable) (*domain.Webhook, error) {
+	var webhook domain.Webhook
+	var createdBy *uuid.UUID
+	var secret, keyID string
+
+	err := row.Scan(
+		&webhook.ID,
+		&webhook.URL,
+		&webhook.Description,
+		&webhook.EventTypes,
+		&secret,
+		&keyID,
+		&webhook.Active,
+		&createdBy,
+		&webhook.OwnerID,
+		&webhook.CreatedAt,
+		&webhook.UpdatedAt,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	webhook.Secret, err = encryption.Decrypt(webhookSecretColumn, secret, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("webhook %s: %w", webhook.ID, err)
+	}
+
+	if createdBy != nil {
+		webhook.CreatedBy = *createdBy
+	}
+
+	return &webhook, nil
+}
+
+func (r *WebhookRepository) scanDelivery(row scannable) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	var status string
+
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&delivery.Payload,
+		&status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastAttemptAt,
+		&delivery.ResponseStatus,
+		&delivery.ResponseBody,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+		&delivery.DeliveredAt,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	delivery.Status = domain.WebhookDeliveryStatus(status)
+
+	return &delivery, nil
+}
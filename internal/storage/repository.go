@@ -11,9 +11,11 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/schema"
 )
 
 // UserRepository defines the operations for user persistence.
@@ -40,16 +42,45 @@ type UserRepository interface {
 
 	// List retrieves users with pagination and optional filtering.
 	List(ctx context.Context, filter UserFilter) ([]domain.User, int64, error)
+
+	// EmailTaken reports whether any user, including soft-deleted ones,
+	// holds the email.
+	EmailTaken(ctx context.Context, email string) (bool, error)
+
+	// TakenUsernames returns the given usernames that any user, including
+	// soft-deleted ones, holds, or that are reserved for their former
+	// holder.
+	TakenUsernames(ctx context.Context, usernames []string) ([]string, error)
+
+	// UpdateAttributes saves the user's custom attributes. Uses optimistic
+	// locking like Update.
+	UpdateAttributes(ctx context.Context, user *domain.User) error
 }
 
 // UserFilter contains options for filtering and paginating user lists.
 type UserFilter struct {
-	Status  *domain.UserStatus
-	Type    *domain.UserType
-	Search  string // Searches email, username, full_name
-	Offset  int
-	Limit   int
-	Deleted bool // If true, include soft-deleted users
+	Status         *domain.UserStatus
+	Type           *domain.UserType
+	OrganizationID *uuid.UUID     // Only members of this organization
+	Search         string         // Searches email, username, full_name
+	Attributes     map[string]any // Only users whose attributes contain all of these
+	Locale         string         // Only users with this locale, or a more specific one ("de" matches "de-AT")
+	Timezone       string         // Only users in this time zone
+	Offset         int
+	Limit          int
+	Deleted        bool // If true, include soft-deleted users
+
+	// After, if set, lists the users following it in the order, newest
+	// first, instead of skipping Offset of them, and leaves the total
+	// uncounted (0).
+	After *UserCursor
+}
+
+// UserCursor is the position of a user in the order users are listed in:
+// by creation time, then ID.
+type UserCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
 }
 
 // RoleRepository defines operations for role persistence.
@@ -60,26 +91,66 @@ type RoleRepository interface {
 	// GetByID retrieves a role by ID with its permissions.
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Role, error)
 
-	// GetByName retrieves a role by name with its permissions.
+	// GetByName retrieves a global role by name with its permissions.
 	GetByName(ctx context.Context, name string) (*domain.Role, error)
 
-	// Update saves changes to an existing role.
+	// Update saves changes to an existing role. Renaming it bumps the
+	// versions of the users it's assigned to.
 	Update(ctx context.Context, role *domain.Role) error
 
-	// Delete removes a role. Returns ErrConflict if users are assigned to it.
+	// Delete removes a role. Returns ErrConflict if users, groups, profiles
+	// or OAuth clients are assigned to it.
 	Delete(ctx context.Context, id uuid.UUID) error
 
-	// List retrieves all roles.
-	List(ctx context.Context) ([]domain.Role, error)
+	// List retrieves roles. With an organization scope it returns the global
+	// roles plus the roles scoped to that organization.
+	List(ctx context.Context, filter RoleFilter) ([]domain.Role, error)
 
-	// GetUserRoles retrieves all roles assigned to a user.
+	// GetUserRoles retrieves the unexpired roles assigned to a user, with
+	// the ExpiresAt of time-bound assignments.
 	GetUserRoles(ctx context.Context, userID uuid.UUID) ([]domain.Role, error)
 
-	// AssignRole assigns a role to a user. Idempotent - no error if already assigned.
-	AssignRole(ctx context.Context, userID, roleID uuid.UUID) error
+	// GetEffectiveUserRoles retrieves the roles assigned to a user directly or
+	// through their groups. Organization-scoped group roles only count while
+	// the user is a member of that organization, and expired assignments
+	// don't count. ExpiresAt is set on roles held only through time-bound
+	// assignments.
+	GetEffectiveUserRoles(ctx context.Context, userID uuid.UUID) ([]domain.Role, error)
+
+	// AssignRole assigns a role to a user until expiresAt, or until removed
+	// if nil. Assigning a role the user already holds replaces its expiry.
+	// Either bumps the user's version.
+	AssignRole(ctx context.Context, userID, roleID uuid.UUID, expiresAt *time.Time) error
 
-	// RemoveRole removes a role from a user. Idempotent - no error if not assigned.
+	// RemoveRole removes a role from a user, bumping their version.
+	// Idempotent - no error if not assigned.
 	RemoveRole(ctx context.Context, userID, roleID uuid.UUID) error
+
+	// DeleteExpiredAssignments removes the user role assignments that had
+	// expired by now and returns them, bumping the users' versions.
+	DeleteExpiredAssignments(ctx context.Context, now time.Time) ([]domain.RoleExpiry, error)
+
+	// ListUsersWithRole retrieves the users the role is assigned to directly,
+	// in assignment order, skipping soft-deleted users and expired
+	// assignments.
+	ListUsersWithRole(ctx context.Context, roleID uuid.UUID, offset, limit int) ([]domain.User, int64, error)
+
+	// CountAssignments counts the users, groups and profiles holding each of
+	// the roles. Roles nobody holds get zero counts.
+	CountAssignments(ctx context.Context, roleIDs []uuid.UUID) (map[uuid.UUID]domain.RoleAssignments, error)
+}
+
+// RoleFilter contains options for filtering role lists.
+type RoleFilter struct {
+	OrganizationID *uuid.UUID
+}
+
+// PermissionFilter narrows a permission listing.
+type PermissionFilter struct {
+	Resource string // Exact resource
+	Search   string // Searches resource, action, description
+	Offset   int
+	Limit    int
 }
 
 // PermissionRepository defines operations for permission persistence.
@@ -96,11 +167,20 @@ type PermissionRepository interface {
 	// List retrieves all permissions.
 	List(ctx context.Context) ([]domain.Permission, error)
 
+	// Search retrieves a page of permissions matching filter, ordered by
+	// resource and action, and the total number matching.
+	Search(ctx context.Context, filter PermissionFilter) ([]domain.Permission, int64, error)
+
+	// Update saves a permission's description. Returns ErrNotFound if it
+	// doesn't exist.
+	Update(ctx context.Context, perm *domain.Permission) error
+
 	// Delete removes a permission. Returns ErrConflict if roles use it.
 	Delete(ctx context.Context, id uuid.UUID) error
 
-	// AssignToRole assigns a permission to a role. Idempotent.
-	AssignToRole(ctx context.Context, roleID, permissionID uuid.UUID) error
+	// AssignToRole grants a permission to a role, or denies it with deny.
+	// Assigning it again sets whether it's denied.
+	AssignToRole(ctx context.Context, roleID, permissionID uuid.UUID, deny bool) error
 
 	// RemoveFromRole removes a permission from a role. Idempotent.
 	RemoveFromRole(ctx context.Context, roleID, permissionID uuid.UUID) error
@@ -120,17 +200,876 @@ type TokenRepository interface {
 	// RevokeAllForUser revokes all tokens for a user.
 	RevokeAllForUser(ctx context.Context, userID uuid.UUID) error
 
+	// MarkReplaced revokes a token rotated into replacedByID. Returns
+	// ErrNotFound if the token was already revoked.
+	MarkReplaced(ctx context.Context, id, replacedByID uuid.UUID) error
+
+	// RevokeFamily revokes a token family's unrevoked tokens and returns
+	// how many were revoked.
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) (int64, error)
+
+	// ListFamily returns a token family, oldest first.
+	ListFamily(ctx context.Context, familyID uuid.UUID) ([]domain.RefreshToken, error)
+
 	// DeleteExpired removes expired tokens older than the given duration.
 	DeleteExpired(ctx context.Context) (int64, error)
+
+	// CountActive counts the tokens neither revoked nor expired at now, and
+	// the distinct users holding them.
+	CountActive(ctx context.Context, now time.Time) (sessions, users int64, err error)
+
+	// CountActiveForUser counts the user's tokens neither revoked nor
+	// expired at now.
+	CountActiveForUser(ctx context.Context, userID uuid.UUID, now time.Time) (int64, error)
+
+	// ListActiveForUser returns the user's tokens neither revoked nor
+	// expired at now, newest first.
+	ListActiveForUser(ctx context.Context, userID uuid.UUID, now time.Time) ([]domain.RefreshToken, error)
+}
+
+// HistoryRepository provides point-in-time reads over user and membership history.
+type HistoryRepository interface {
+	// GetUserAsOf returns the user as it was at the given time.
+	// Returns ErrNotFound if the user did not exist yet.
+	GetUserAsOf(ctx context.Context, id uuid.UUID, at time.Time) (*domain.User, error)
+
+	// GetUserRolesAsOf returns the roles the user held at the given time.
+	// Roles carry their name at the time of assignment; permissions are not loaded.
+	GetUserRolesAsOf(ctx context.Context, userID uuid.UUID, at time.Time) ([]domain.Role, error)
+
+	// ListTimeline returns what happened to a user, newest first, merged from
+	// the user and role history, login attempts, password history, known
+	// devices and impersonation sessions.
+	ListTimeline(ctx context.Context, filter TimelineFilter) ([]domain.TimelineEntry, int64, error)
+}
+
+// TimelineFilter narrows a user's activity timeline.
+type TimelineFilter struct {
+	UserID uuid.UUID
+	Kinds  []domain.TimelineKind // Any kind if empty
+	Since  *time.Time            // Inclusive
+	Until  *time.Time            // Exclusive
+	Offset int
+	Limit  int
+}
+
+// OrganizationRepository defines operations for organizations and their members.
+type OrganizationRepository interface {
+	// Create stores a new organization. Returns ErrAlreadyExists if the slug is taken.
+	Create(ctx context.Context, org *domain.Organization) error
+
+	// GetByID retrieves an organization by ID. Returns ErrNotFound if not found.
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Organization, error)
+
+	// GetBySlug retrieves an organization by slug. Returns ErrNotFound if not found.
+	GetBySlug(ctx context.Context, slug string) (*domain.Organization, error)
+
+	// Update saves changes to an existing organization.
+	Update(ctx context.Context, org *domain.Organization) error
+
+	// Delete removes an organization, its memberships and its scoped roles,
+	// bumping the versions of the users who held them.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// List retrieves organizations with pagination.
+	List(ctx context.Context, offset, limit int) ([]domain.Organization, int64, error)
+
+	// AddMember adds a user to an organization. Idempotent.
+	AddMember(ctx context.Context, orgID, userID uuid.UUID) error
+
+	// RemoveMember removes a user from an organization along with the
+	// organization-scoped roles they held, bumping their version if they
+	// held any. Idempotent.
+	RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error
+
+	// IsMember reports whether the user belongs to the organization.
+	IsMember(ctx context.Context, orgID, userID uuid.UUID) (bool, error)
+
+	// ListMembers retrieves an organization's members with pagination.
+	ListMembers(ctx context.Context, orgID uuid.UUID, offset, limit int) ([]domain.OrganizationMember, int64, error)
+
+	// ListForUser retrieves the organizations a user belongs to.
+	ListForUser(ctx context.Context, userID uuid.UUID) ([]domain.Organization, error)
+}
+
+// GroupRepository defines operations for groups, their members and their roles.
+type GroupRepository interface {
+	// Create stores a new group. Returns ErrAlreadyExists if the name is taken.
+	Create(ctx context.Context, group *domain.Group) error
+
+	// GetByID retrieves a group by ID with its roles; role permissions are not
+	// loaded. Returns ErrNotFound if not found.
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Group, error)
+
+	// GetByName retrieves a group by name, ignoring case, with its roles.
+	// Returns ErrNotFound if not found.
+	GetByName(ctx context.Context, name string) (*domain.Group, error)
+
+	// Update saves changes to an existing group.
+	Update(ctx context.Context, group *domain.Group) error
+
+	// Delete removes a group along with its memberships and role assignments.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// List retrieves groups with pagination.
+	List(ctx context.Context, offset, limit int) ([]domain.Group, int64, error)
+
+	// AddMember adds a user to a group. Idempotent.
+	AddMember(ctx context.Context, groupID, userID uuid.UUID) error
+
+	// RemoveMember removes a user from a group. Idempotent.
+	RemoveMember(ctx context.Context, groupID, userID uuid.UUID) error
+
+	// ListMembers retrieves a group's members with pagination.
+	ListMembers(ctx context.Context, groupID uuid.UUID, offset, limit int) ([]domain.GroupMember, int64, error)
+
+	// ListForUser retrieves the groups a user belongs to. Roles are not loaded.
+	ListForUser(ctx context.Context, userID uuid.UUID) ([]domain.Group, error)
+
+	// AssignRole assigns a role to a group. Idempotent.
+	AssignRole(ctx context.Context, groupID, roleID uuid.UUID) error
+
+	// RemoveRole removes a role from a group. Idempotent.
+	RemoveRole(ctx context.Context, groupID, roleID uuid.UUID) error
+}
+
+// InvitationRepository defines operations for invitation persistence.
+type InvitationRepository interface {
+	// Create stores a new invitation. Returns ErrAlreadyExists if the user
+	// already has a pending invitation.
+	Create(ctx context.Context, inv *domain.Invitation) error
+
+	// GetByID retrieves an invitation by ID. Returns ErrNotFound if not found.
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Invitation, error)
+
+	// GetPendingForUser retrieves the user's pending invitation, expired or not.
+	// Returns ErrNotFound if there is none.
+	GetPendingForUser(ctx context.Context, userID uuid.UUID) (*domain.Invitation, error)
+
+	// Update saves changes to an existing invitation.
+	Update(ctx context.Context, inv *domain.Invitation) error
+
+	// List retrieves invitations, newest first.
+	List(ctx context.Context, filter InvitationFilter) ([]domain.Invitation, int64, error)
+}
+
+// InvitationFilter contains options for filtering and paginating invitations.
+type InvitationFilter struct {
+	Status *domain.InvitationStatus // Expired matches pending invitations past expiry
+	Email  string
+	Offset int
+	Limit  int
+}
+
+// UserDirectoryRepository maintains the denormalized user read model.
+// Writes go to the source tables; the directory is refreshed afterwards.
+type UserDirectoryRepository interface {
+	// Refresh rebuilds the directory row for a user from the source tables.
+	Refresh(ctx context.Context, userID uuid.UUID) error
+
+	// RefreshAll rebuilds every row and returns the number refreshed.
+	RefreshAll(ctx context.Context) (int64, error)
+
+	// RecordLogin advances the user's last login time.
+	RecordLogin(ctx context.Context, userID uuid.UUID, at time.Time) error
+
+	// List retrieves user summaries with pagination and optional filtering.
+	List(ctx context.Context, filter DirectoryFilter) ([]domain.UserSummary, int64, error)
+}
+
+// DirectoryFilter contains options for filtering and paginating the user directory.
+type DirectoryFilter struct {
+	Status         *domain.UserStatus
+	Type           *domain.UserType
+	OrganizationID *uuid.UUID     // Only members of this organization
+	Role           string         // Only users holding this role
+	Search         string         // Searches email, username, full_name
+	Attributes     map[string]any // Only users whose attributes contain all of these
+	Locale         string         // Only users with this locale, or a more specific one ("de" matches "de-AT")
+	Timezone       string         // Only users in this time zone
+	Offset         int
+	Limit          int
+	Deleted        bool // If true, include soft-deleted users
+}
+
+// WebhookRepository defines operations for webhook endpoints and their delivery queue.
+type WebhookRepository interface {
+	// Create stores a new webhook.
+	Create(ctx context.Context, webhook *domain.Webhook) error
+
+	// GetByID retrieves a webhook by ID. Returns ErrNotFound if not found.
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Webhook, error)
+
+	// Update saves changes to an existing webhook. Returns ErrNotFound if not found.
+	Update(ctx context.Context, webhook *domain.Webhook) error
+
+	// Delete removes a webhook and its delivery history. Returns ErrNotFound if not found.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// List retrieves all webhooks.
+	List(ctx context.Context) ([]domain.Webhook, error)
+
+	// ListForOwner retrieves the webhooks a partner registered.
+	ListForOwner(ctx context.Context, ownerID uuid.UUID) ([]domain.Webhook, error)
+
+	// ListActive retrieves the webhooks that should receive events.
+	ListActive(ctx context.Context) ([]domain.Webhook, error)
+
+	// CreateDelivery queues a delivery. Idempotent per webhook and event.
+	CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+
+	// GetDelivery retrieves a delivery by ID. Returns ErrNotFound if not found.
+	GetDelivery(ctx context.Context, id uuid.UUID) (*domain.WebhookDelivery, error)
+
+	// ClaimDueDeliveries returns up to limit pending deliveries whose next attempt
+	// is due, pushing their next attempt out by lease so that concurrent workers
+	// don't pick up the same rows.
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error)
+
+	// UpdateDelivery saves the outcome of a delivery attempt.
+	UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+
+	// ListDeliveries retrieves delivery history for a webhook, newest first.
+	ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]domain.WebhookDelivery, int64, error)
+
+	// QueueStats counts the pending deliveries of all webhooks.
+	QueueStats(ctx context.Context) (domain.WebhookQueueStats, error)
+
+	// ReencryptSecrets re-encrypts with the current key up to limit secrets,
+	// in ID order after the given one, that are under another loaded key.
+	ReencryptSecrets(ctx context.Context, after uuid.UUID, limit int) (ReencryptResult, error)
+
+	// CountSecretsByKey counts the secrets under each key ID, "" being the
+	// secrets stored in the clear.
+	CountSecretsByKey(ctx context.Context) (map[string]int64, error)
+}
+
+// ReencryptResult reports one batch of re-encryption.
+type ReencryptResult struct {
+	Last        uuid.UUID // Where the next batch starts
+	Scanned     int       // Zero once no rows are left
+	Reencrypted int
+	Failed      int // Not decryptable with the key they claim
+}
+
+// DeliveryFilter contains options for filtering and paginating webhook deliveries.
+type DeliveryFilter struct {
+	WebhookID uuid.UUID
+	Status    *domain.WebhookDeliveryStatus
+	Offset    int
+	Limit     int
+}
+
+// ImpersonationRepository defines operations for impersonation session persistence.
+type ImpersonationRepository interface {
+	// Create stores a new session.
+	Create(ctx context.Context, session *domain.ImpersonationSession) error
+
+	// GetByID retrieves a session by ID. Returns ErrNotFound if not found.
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.ImpersonationSession, error)
+
+	// End saves the session's end time.
+	End(ctx context.Context, session *domain.ImpersonationSession) error
+
+	// ListForUser retrieves sessions where the user was the actor or the
+	// target, newest first.
+	ListForUser(ctx context.Context, userID uuid.UUID, offset, limit int) ([]domain.ImpersonationSession, int64, error)
+}
+
+// TokenExchangeRepository defines operations for the token exchange audit.
+type TokenExchangeRepository interface {
+	// Create records an exchange.
+	Create(ctx context.Context, exchange *domain.TokenExchange) error
+
+	// List retrieves exchanges matching the filter, newest first.
+	List(ctx context.Context, filter TokenExchangeFilter) ([]domain.TokenExchange, int64, error)
+}
+
+// TokenExchangeFilter narrows a token exchange listing.
+type TokenExchangeFilter struct {
+	UserID   *uuid.UUID // Exchanges of this user's tokens
+	ClientID *uuid.UUID // Exchanges by this client
+	Offset   int
+	Limit    int
+}
+
+// AccessTokenRepository stores the claims of opaque access tokens. It
+// implements auth.OpaqueStore.
+type AccessTokenRepository interface {
+	// Put stores the claims of the token hashed tokenHash, issued to
+	// userID, until expiresAt.
+	Put(ctx context.Context, tokenHash string, userID uuid.UUID, claims []byte, expiresAt time.Time) error
+
+	// Get returns the claims stored for tokenHash, or false if there are
+	// none or they've expired.
+	Get(ctx context.Context, tokenHash string) ([]byte, bool, error)
+
+	// DeleteForUser drops every token issued to userID.
+	DeleteForUser(ctx context.Context, userID uuid.UUID) error
+
+	// DeleteExpired removes expired tokens and returns how many were removed.
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// IdempotencyRepository defines operations for idempotency key persistence.
+type IdempotencyRepository interface {
+	// Reserve stores the record if its scope and key are free (or held by an
+	// expired record) and returns nil. Otherwise it returns the existing
+	// record, completed or not.
+	Reserve(ctx context.Context, rec *domain.IdempotencyRecord) (*domain.IdempotencyRecord, error)
+
+	// Complete stores the response of a reserved record.
+	Complete(ctx context.Context, rec *domain.IdempotencyRecord) error
+
+	// Release deletes an uncompleted record so the request can be retried.
+	Release(ctx context.Context, scope, key string) error
+
+	// DeleteExpired removes expired records and returns how many were removed.
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// ActionTokenRepository defines operations for action token persistence.
+type ActionTokenRepository interface {
+	// Create stores a new token.
+	Create(ctx context.Context, token *domain.ActionToken) error
+
+	// Consume marks the token with the hash used, if it was issued for
+	// purpose and is still valid, and returns it. Of concurrent calls for
+	// the same token at most one succeeds; the others, like calls for
+	// unknown, used, revoked or expired tokens, return ErrNotFound.
+	Consume(ctx context.Context, purpose domain.ActionPurpose, tokenHash string) (*domain.ActionToken, error)
+
+	// RevokeForSubject revokes the subject's outstanding tokens for purpose
+	// and returns how many were revoked.
+	RevokeForSubject(ctx context.Context, purpose domain.ActionPurpose, subjectID uuid.UUID) (int64, error)
+
+	// DeleteExpired removes expired tokens and returns how many were removed.
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// AttributeDefinitionRepository defines operations for the custom attribute registry.
+type AttributeDefinitionRepository interface {
+	// Create stores a new definition. Returns ErrAlreadyExists if the key is taken.
+	Create(ctx context.Context, def *domain.AttributeDefinition) error
+
+	// Get retrieves a definition by key. Returns ErrNotFound if not found.
+	Get(ctx context.Context, key string) (*domain.AttributeDefinition, error)
+
+	// Update saves the definition's description.
+	Update(ctx context.Context, def *domain.AttributeDefinition) error
+
+	// List retrieves every definition, ordered by key.
+	List(ctx context.Context) ([]domain.AttributeDefinition, error)
+
+	// Delete removes a definition and strips its key from every user.
+	// Returns ErrNotFound if not found.
+	Delete(ctx context.Context, key string) error
+}
+
+// DeviceRepository defines operations for known device persistence.
+type DeviceRepository interface {
+	// Create stores a new device. Returns ErrAlreadyExists if the user
+	// already has a device with the same fingerprint.
+	Create(ctx context.Context, device *domain.KnownDevice) error
+
+	// GetByID retrieves a device by ID. Returns ErrNotFound if not found.
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.KnownDevice, error)
+
+	// GetByFingerprint retrieves a user's device by fingerprint.
+	// Returns ErrNotFound if not found.
+	GetByFingerprint(ctx context.Context, userID uuid.UUID, fingerprint string) (*domain.KnownDevice, error)
+
+	// Update saves the device's mutable fields.
+	Update(ctx context.Context, device *domain.KnownDevice) error
+
+	// ListForUser retrieves a user's devices, most recently seen first.
+	ListForUser(ctx context.Context, userID uuid.UUID) ([]domain.KnownDevice, error)
+
+	// Delete removes a device. Returns ErrNotFound if not found.
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// LoginAttemptFilter narrows a user's login history.
+type LoginAttemptFilter struct {
+	UserID    uuid.UUID
+	Success   *bool      // Only successful, or only failed, attempts
+	Since     *time.Time // Inclusive
+	Until     *time.Time // Exclusive
+	IPAddress string
+	Offset    int
+	Limit     int
+}
+
+// LoginAttemptRepository defines operations for login history persistence.
+type LoginAttemptRepository interface {
+	// Create stores an attempt.
+	Create(ctx context.Context, attempt *domain.LoginAttempt) error
+
+	// List retrieves attempts matching the filter, newest first, with the
+	// total number of matches.
+	List(ctx context.Context, filter LoginAttemptFilter) ([]domain.LoginAttempt, int64, error)
+
+	// DeleteOlderThan removes attempts made before cutoff.
+	DeleteOlderThan(ctx context.Context, cutoff time.Time) (int64, error)
+
+	// CountSince counts the successful and failed attempts made at or after
+	// since, across all users.
+	CountSince(ctx context.Context, since time.Time) (succeeded, failed int64, err error)
+}
+
+// ProfileRepository defines operations for user profile persistence.
+type ProfileRepository interface {
+	// Create stores a new profile. Returns ErrAlreadyExists if the user
+	// already has a profile of that type.
+	Create(ctx context.Context, profile *domain.Profile) error
+
+	// GetByID retrieves a profile by ID with its roles and their
+	// permissions. Returns ErrNotFound if not found.
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Profile, error)
+
+	// ListForUser retrieves a user's profiles with their roles, oldest first.
+	ListForUser(ctx context.Context, userID uuid.UUID) ([]domain.Profile, error)
+
+	// Delete removes a profile, its role assignments and the refresh tokens
+	// issued for it. Returns ErrNotFound if not found.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// AssignRole assigns a role to a profile. Idempotent.
+	AssignRole(ctx context.Context, profileID, roleID uuid.UUID) error
+
+	// RemoveRole removes a role from a profile. Idempotent.
+	RemoveRole(ctx context.Context, profileID, roleID uuid.UUID) error
+}
+
+// APIKeyRepository defines operations for partner API key persistence.
+type APIKeyRepository interface {
+	// Create stores a new key.
+	Create(ctx context.Context, key *domain.APIKey) error
+
+	// GetByID retrieves a key by ID. Returns ErrNotFound if not found.
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.APIKey, error)
+
+	// GetByPrefix retrieves a key by the prefix of its raw form. Returns
+	// ErrNotFound if not found.
+	GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error)
+
+	// ListForOwner retrieves a partner's keys, including revoked ones,
+	// oldest first.
+	ListForOwner(ctx context.Context, ownerID uuid.UUID) ([]domain.APIKey, error)
+
+	// Update saves the name, secret and revocation of a key. Returns
+	// ErrNotFound if not found.
+	Update(ctx context.Context, key *domain.APIKey) error
+
+	// RecordUsage counts one request made with the key at the given time.
+	RecordUsage(ctx context.Context, id uuid.UUID, at time.Time) error
+
+	// Usage retrieves the key's daily request counts since the given day,
+	// oldest first. Days without requests are omitted.
+	Usage(ctx context.Context, id uuid.UUID, since time.Time) ([]domain.APIKeyUsage, error)
+}
+
+// ConsentRepository defines operations for partner consent persistence.
+type ConsentRepository interface {
+	// Create stores a new consent. Returns ErrAlreadyExists if the customer
+	// already has an active consent for the partner.
+	Create(ctx context.Context, consent *domain.Consent) error
+
+	// GetByID retrieves a consent by ID. Returns ErrNotFound if not found.
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Consent, error)
+
+	// GetActive retrieves the customer's active consent for the partner.
+	// Returns ErrNotFound if there is none.
+	GetActive(ctx context.Context, customerID, partnerID uuid.UUID) (*domain.Consent, error)
+
+	// ListForCustomer retrieves the consents a customer gave, newest first.
+	// Revoked consents are only included if withRevoked is true.
+	ListForCustomer(ctx context.Context, customerID uuid.UUID, withRevoked bool) ([]domain.Consent, error)
+
+	// ListForPartner retrieves the active consents given to a partner,
+	// newest first.
+	ListForPartner(ctx context.Context, partnerID uuid.UUID) ([]domain.Consent, error)
+
+	// Update saves the scopes and revocation of a consent. Returns
+	// ErrNotFound if not found.
+	Update(ctx context.Context, consent *domain.Consent) error
+}
+
+// ResourceGrantFilter narrows a resource grant listing. Zero fields match
+// every grant.
+type ResourceGrantFilter struct {
+	Resource   string
+	ResourceID string
+	UserID     *uuid.UUID // Grants to the user itself, not to their groups
+	GroupID    *uuid.UUID
+	Offset     int
+	Limit      int
+}
+
+// ResourceGrantRepository defines operations for permissions on single
+// resource instances.
+type ResourceGrantRepository interface {
+	// Create stores a new grant. Returns ErrAlreadyExists if the subject
+	// already has the action on the instance.
+	Create(ctx context.Context, grant *domain.ResourceGrant) error
+
+	// GetByID retrieves a grant by ID. Returns ErrNotFound if not found.
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.ResourceGrant, error)
+
+	// Delete removes a grant. Returns ErrNotFound if not found.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// DeleteForResource removes every grant on an instance, for when the
+	// instance itself is deleted, and returns how many there were.
+	DeleteForResource(ctx context.Context, resource, resourceID string) (int64, error)
+
+	// List retrieves a page of grants matching filter, newest first, and
+	// the total number matching.
+	List(ctx context.Context, filter ResourceGrantFilter) ([]domain.ResourceGrant, int64, error)
+
+	// Allows reports whether the user, directly or through one of their
+	// groups, was granted action, or "*", on the instance.
+	Allows(ctx context.Context, userID uuid.UUID, resource, resourceID, action string) (bool, error)
+}
+
+// SupportLookupFilter narrows the support lookup audit trail.
+type SupportLookupFilter struct {
+	ActorID *uuid.UUID
+	Query   string
+	Offset  int
+	Limit   int
+}
+
+// SupportLookupRepository runs the predefined support lookups and keeps
+// their audit trail. Each lookup returns at most limit rows, newest first.
+type SupportLookupRepository interface {
+	// UsersByEmail finds users, soft-deleted ones included, whose email
+	// contains fragment, ignoring case.
+	UsersByEmail(ctx context.Context, fragment string, limit int) ([]domain.User, error)
+
+	// TokensByIP finds refresh tokens issued to ipAddress.
+	TokensByIP(ctx context.Context, ipAddress string, limit int) ([]domain.RefreshToken, error)
+
+	// LoginsByIP finds login attempts made from ipAddress.
+	LoginsByIP(ctx context.Context, ipAddress string, limit int) ([]domain.LoginAttempt, error)
+
+	// Record stores a lookup in the audit trail.
+	Record(ctx context.Context, lookup *domain.SupportLookup) error
+
+	// List retrieves a page of the audit trail matching filter, newest
+	// first, and the total number matching.
+	List(ctx context.Context, filter SupportLookupFilter) ([]domain.SupportLookup, int64, error)
+}
+
+// SCIMRepository stores the IDs identity providers give the users and
+// groups they provision. Each resource has at most one, unique within its
+// type.
+type SCIMRepository interface {
+	// SetExternalID records the resource's external ID, replacing the one
+	// it had. Returns ErrAlreadyExists if another resource of the type has
+	// it.
+	SetExternalID(ctx context.Context, resource domain.SCIMResource, id uuid.UUID, externalID string) error
+
+	// DeleteExternalID forgets the resource's external ID. Idempotent.
+	DeleteExternalID(ctx context.Context, resource domain.SCIMResource, id uuid.UUID) error
+
+	// ExternalIDs returns the external IDs of the resources that have one.
+	ExternalIDs(ctx context.Context, resource domain.SCIMResource, ids []uuid.UUID) (map[uuid.UUID]string, error)
+
+	// FindByExternalID returns the ID of the resource with externalID.
+	// Returns ErrNotFound if none has it.
+	FindByExternalID(ctx context.Context, resource domain.SCIMResource, externalID string) (uuid.UUID, error)
+}
+
+// LDAPMappingRepository stores which roles LDAP groups grant.
+type LDAPMappingRepository interface {
+	// Create stores a new mapping. Returns ErrAlreadyExists if the group
+	// already maps to the role.
+	Create(ctx context.Context, mapping *domain.LDAPGroupMapping) error
+
+	// Delete removes a mapping. Returns ErrNotFound if not found.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// List retrieves every mapping with its role name, ordered by group.
+	List(ctx context.Context) ([]domain.LDAPGroupMapping, error)
+}
+
+// SAMLProviderRepository stores SAML identity providers and which roles
+// their groups grant.
+type SAMLProviderRepository interface {
+	// Create stores a new provider. Returns ErrAlreadyExists if the slug
+	// is taken.
+	Create(ctx context.Context, provider *domain.SAMLProvider) error
+
+	// GetByID retrieves a provider. Returns ErrNotFound if not found.
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.SAMLProvider, error)
+
+	// GetBySlug retrieves a provider by its slug. Returns ErrNotFound if
+	// not found.
+	GetBySlug(ctx context.Context, slug string) (*domain.SAMLProvider, error)
+
+	// List retrieves every provider, ordered by slug.
+	List(ctx context.Context) ([]domain.SAMLProvider, error)
+
+	// Update saves changes to a provider. Returns ErrNotFound if not found
+	// and ErrAlreadyExists if the new slug is taken.
+	Update(ctx context.Context, provider *domain.SAMLProvider) error
+
+	// Delete removes a provider and its group roles. Returns ErrNotFound
+	// if not found.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// CreateGroupRole stores a new group mapping. Returns ErrAlreadyExists
+	// if the group already maps to the role.
+	CreateGroupRole(ctx context.Context, mapping *domain.SAMLGroupRole) error
+
+	// DeleteGroupRole removes one of a provider's group mappings. Returns
+	// ErrNotFound if the provider has no such mapping.
+	DeleteGroupRole(ctx context.Context, providerID, id uuid.UUID) error
+
+	// ListGroupRoles retrieves a provider's group mappings with their role
+	// names, ordered by group.
+	ListGroupRoles(ctx context.Context, providerID uuid.UUID) ([]domain.SAMLGroupRole, error)
+}
+
+// FederatedIdentityRepository stores which accounts directory entries and
+// identity provider subjects sign in to.
+type FederatedIdentityRepository interface {
+	// Create stores a new link. Returns ErrAlreadyExists if the subject is
+	// already linked.
+	Create(ctx context.Context, identity *domain.FederatedIdentity) error
+
+	// GetBySubject retrieves the link of a provider's subject. Returns
+	// ErrNotFound if it isn't linked.
+	GetBySubject(ctx context.Context, provider, subject string) (*domain.FederatedIdentity, error)
+
+	// List retrieves a provider's links, ordered by subject.
+	List(ctx context.Context, provider string) ([]domain.FederatedIdentity, error)
+
+	// Delete removes one of a provider's links. Returns ErrNotFound if the
+	// provider has no such link.
+	Delete(ctx context.Context, provider string, id uuid.UUID) error
+
+	// DeleteAll removes every link of a provider.
+	DeleteAll(ctx context.Context, provider string) error
+}
+
+// DeviceAuthorizationRepository stores pending device authorization
+// grants.
+type DeviceAuthorizationRepository interface {
+	// Create stores a new authorization. Returns ErrAlreadyExists if its
+	// user code or device code is taken.
+	Create(ctx context.Context, authorization *domain.DeviceAuthorization) error
+
+	// GetByDeviceCodeHash retrieves an authorization by the hash of its
+	// device code. Returns ErrNotFound if not found.
+	GetByDeviceCodeHash(ctx context.Context, hash string) (*domain.DeviceAuthorization, error)
+
+	// GetByUserCode retrieves an authorization by its normalized user
+	// code. Returns ErrNotFound if not found.
+	GetByUserCode(ctx context.Context, userCode string) (*domain.DeviceAuthorization, error)
+
+	// Update saves an authorization's status, decider, interval and last
+	// poll. Returns ErrNotFound if not found.
+	Update(ctx context.Context, authorization *domain.DeviceAuthorization) error
+
+	// Delete removes an authorization. Returns ErrNotFound if not found,
+	// so of two concurrent deletes only one succeeds.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// DeleteExpired removes expired authorizations and returns how many.
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// OAuthClientRepository defines operations for OAuth client persistence.
+type OAuthClientRepository interface {
+	// Create stores a new client.
+	Create(ctx context.Context, client *domain.OAuthClient) error
+
+	// GetByID retrieves a client by ID with its roles and their
+	// permissions. Returns ErrNotFound if not found.
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.OAuthClient, error)
+
+	// List retrieves every client with its roles, ordered by name.
+	List(ctx context.Context) ([]domain.OAuthClient, error)
+
+	// Update saves a client's name, secret hash and enabled flag. Returns
+	// ErrNotFound if not found.
+	Update(ctx context.Context, client *domain.OAuthClient) error
+
+	// Delete removes a client and its role assignments. Returns ErrNotFound
+	// if not found.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// RecordUse sets when the client last got a token.
+	RecordUse(ctx context.Context, id uuid.UUID, at time.Time) error
+
+	// AssignRole assigns a role to a client. Idempotent.
+	AssignRole(ctx context.Context, clientID, roleID uuid.UUID) error
+
+	// RemoveRole removes a role from a client. Idempotent.
+	RemoveRole(ctx context.Context, clientID, roleID uuid.UUID) error
+}
+
+// PasswordHistoryRepository defines operations for password history
+// persistence.
+type PasswordHistoryRepository interface {
+	// Add stores a password hash for the user and removes all but the
+	// newest keep entries.
+	Add(ctx context.Context, userID uuid.UUID, passwordHash string, keep int) error
+
+	// Recent returns the user's newest password hashes, newest first.
+	Recent(ctx context.Context, userID uuid.UUID, limit int) ([]string, error)
+}
+
+// UsernameHistoryRepository defines operations for the usernames users
+// have moved away from.
+type UsernameHistoryRepository interface {
+	// Create records a username change.
+	Create(ctx context.Context, change *domain.UsernameChange) error
+
+	// LatestForUser returns the user's most recent change. Returns
+	// ErrNotFound if they've never changed their username.
+	LatestForUser(ctx context.Context, userID uuid.UUID) (*domain.UsernameChange, error)
+
+	// LatestForUsername returns the most recent change away from username.
+	// Returns ErrNotFound if nobody has left it.
+	LatestForUsername(ctx context.Context, username string) (*domain.UsernameChange, error)
+
+	// ListForUser returns the user's changes, newest first.
+	ListForUser(ctx context.Context, userID uuid.UUID) ([]domain.UsernameChange, error)
+}
+
+// AccountDeletionRepository defines operations for scheduled account
+// deletions.
+type AccountDeletionRepository interface {
+	// Create schedules a deletion. Returns ErrAlreadyExists if the user's
+	// account already has one.
+	Create(ctx context.Context, deletion *domain.AccountDeletion) error
+
+	// Get returns the user's scheduled deletion. Returns ErrNotFound if
+	// there is none.
+	Get(ctx context.Context, userID uuid.UUID) (*domain.AccountDeletion, error)
+
+	// Delete removes the user's scheduled deletion, to cancel it or once
+	// it's carried out. Returns ErrNotFound if there is none.
+	Delete(ctx context.Context, userID uuid.UUID) error
+
+	// ListDue returns up to limit deletions whose grace period ended by
+	// now, earliest first.
+	ListDue(ctx context.Context, now time.Time, limit int) ([]domain.AccountDeletion, error)
+}
+
+// NotificationPreferenceRepository defines operations for users'
+// notification preferences.
+type NotificationPreferenceRepository interface {
+	// ListForUser returns the preferences the user has set.
+	ListForUser(ctx context.Context, userID uuid.UUID) ([]domain.NotificationPreference, error)
+
+	// Get returns the user's preference for a category and channel.
+	// Returns ErrNotFound if they haven't set one.
+	Get(ctx context.Context, userID uuid.UUID, category, channel string) (*domain.NotificationPreference, error)
+
+	// Set saves the given preferences of the user, leaving the others.
+	Set(ctx context.Context, userID uuid.UUID, prefs []domain.NotificationPreference) error
+}
+
+// DatasetRepository reads and writes the auth dataset in bulk, for backups
+// and environment cloning.
+type DatasetRepository interface {
+	// Export reads the whole dataset from one repeatable-read snapshot, so
+	// it is consistent while the service keeps writing. Soft-deleted users
+	// are included; expired role assignments are not.
+	Export(ctx context.Context) (*Dataset, error)
+
+	// Import inserts a dataset. Permissions, roles, organizations and
+	// groups that already exist under the same ID or natural key are
+	// reused; users whose ID, email or username is taken are skipped along
+	// with their assignments and memberships. Run it in a transaction to
+	// apply all or nothing.
+	Import(ctx context.Context, dataset *Dataset) (DatasetImportResult, error)
+}
+
+// Dataset holds who exists and what they may do: users, roles,
+// permissions and the organization structure. Credentials other than
+// password hashes aren't part of it.
+type Dataset struct {
+	Permissions         []domain.Permission
+	Organizations       []domain.Organization
+	Roles               []domain.Role  // Permissions hold only IDs and Deny
+	Groups              []domain.Group // Roles hold only IDs
+	Users               []domain.User  // Roles hold only IDs and ExpiresAt
+	OrganizationMembers []domain.OrganizationMember
+	GroupMembers        []domain.GroupMember
+}
+
+// DatasetImportResult counts what Import inserted and reused.
+type DatasetImportResult struct {
+	PermissionsCreated    int
+	PermissionsExisting   int
+	OrganizationsCreated  int
+	OrganizationsExisting int
+	RolesCreated          int
+	RolesExisting         int
+	GroupsCreated         int
+	GroupsExisting        int
+	UsersCreated          int
+	UsersSkipped          []string // Emails
+	LinksCreated          int      // Grants, role assignments and memberships
+}
+
+// SchemaRepository reads which migrations the database has applied.
+type SchemaRepository interface {
+	// State returns the migration version and the newest contract
+	// migration applied. A database that was never migrated is at
+	// version 0.
+	State(ctx context.Context) (schema.State, error)
+
+	// Record notes the phase of each of migrations up to version and
+	// forgets those above it, after migrating up or down to version. It
+	// does nothing before the migration that creates its table. Run it in
+	// a transaction to apply all or nothing.
+	Record(ctx context.Context, migrations []schema.Migration, version uint) error
 }
 
 // Repositories bundles all repositories together.
 // This makes it easy to pass around and inject dependencies.
 type Repositories struct {
-	Users       UserRepository
-	Roles       RoleRepository
-	Permissions PermissionRepository
-	Tokens      TokenRepository
+	Users          UserRepository
+	Roles          RoleRepository
+	Permissions    PermissionRepository
+	Tokens         TokenRepository
+	History        HistoryRepository
+	Webhooks       WebhookRepository
+	Directory      UserDirectoryRepository
+	Orgs           OrganizationRepository
+	Groups         GroupRepository
+	Invitations    InvitationRepository
+	Idempotency    IdempotencyRepository
+	Impersonations ImpersonationRepository
+	Attributes     AttributeDefinitionRepository
+	Devices        DeviceRepository
+	LoginAttempts  LoginAttemptRepository
+	Passwords      PasswordHistoryRepository
+	Usernames      UsernameHistoryRepository
+	Deletions      AccountDeletionRepository
+	NotifyPrefs    NotificationPreferenceRepository
+	Profiles       ProfileRepository
+	APIKeys        APIKeyRepository
+	Consents       ConsentRepository
+	ActionTokens   ActionTokenRepository
+	Datasets       DatasetRepository
+	Schema         SchemaRepository
+	ResourceGrants ResourceGrantRepository
+	SupportLookups SupportLookupRepository
+	SCIM           SCIMRepository
+	LDAPMappings   LDAPMappingRepository
+	SAMLProviders  SAMLProviderRepository
+	Identities     FederatedIdentityRepository
+	DeviceAuths    DeviceAuthorizationRepository
+	OAuthClients   OAuthClientRepository
+	TokenExchanges TokenExchangeRepository
+	AccessTokens   AccessTokenRepository
 }
 
 // Transactor provides transaction support for operations that need atomicity.
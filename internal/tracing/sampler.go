@@ -0,0 +1,130 @@
+package tracing
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Rule sets the sampling rate for requests whose route matches Pattern.
+//
+// Patterns are HTTP paths ("/health") or gRPC full method names
+// ("/user.v1.AuthService/Login"). A trailing "*" matches any suffix
+// ("/api/v1/auth/*"). When several rules match, the longest pattern wins.
+type Rule struct {
+	Pattern string
+	Rate    float64 // 0 to 1
+}
+
+func (r Rule) matches(route string) bool {
+	if prefix, ok := strings.CutSuffix(r.Pattern, "*"); ok {
+		return strings.HasPrefix(route, prefix)
+	}
+	return route == r.Pattern
+}
+
+// ParseRules parses "pattern=rate" items, e.g. "/health=0.01".
+func ParseRules(items []string) ([]Rule, error) {
+	rules := make([]Rule, 0, len(items))
+	for _, item := range items {
+		pattern, value, ok := strings.Cut(item, "=")
+		pattern = strings.TrimSpace(pattern)
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("sampling rule %q: want pattern=rate", item)
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("sampling rule %q: rate must be between 0 and 1", item)
+		}
+
+		rules = append(rules, Rule{Pattern: pattern, Rate: rate})
+	}
+	return rules, nil
+}
+
+// RouteSampler is a head sampler with a rate per route. The route is taken
+// from the span name, so it applies to root server spans named by
+// HTTPSpanName or by the gRPC instrumentation; child spans follow their
+// parent's decision.
+//
+// Spans that aren't sampled are dropped, or with recordUnsampled kept
+// recording (but not exported) so a TailSampler can still keep them.
+type RouteSampler struct {
+	fallback        sdktrace.Sampler
+	rules           []Rule
+	samplers        []sdktrace.Sampler
+	recordUnsampled bool
+}
+
+// NewRouteSampler samples routes matching a rule at its rate and every
+// other route at defaultRate.
+func NewRouteSampler(defaultRate float64, rules []Rule, recordUnsampled bool) *RouteSampler {
+	s := &RouteSampler{
+		fallback:        sdktrace.TraceIDRatioBased(defaultRate),
+		rules:           rules,
+		samplers:        make([]sdktrace.Sampler, len(rules)),
+		recordUnsampled: recordUnsampled,
+	}
+	for i, r := range rules {
+		s.samplers[i] = sdktrace.TraceIDRatioBased(r.Rate)
+	}
+	return s
+}
+
+func (s *RouteSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	parent := trace.SpanContextFromContext(p.ParentContext)
+
+	switch {
+	case parent.IsSampled():
+		return s.result(p, sdktrace.RecordAndSample)
+	case parent.IsValid() && !parent.IsRemote():
+		return s.result(p, sdktrace.Drop)
+	}
+
+	decision := s.samplerFor(spanRoute(p.Name)).ShouldSample(p).Decision
+	return s.result(p, decision)
+}
+
+func (s *RouteSampler) Description() string {
+	return fmt.Sprintf("RouteSampler{rules=%d}", len(s.rules))
+}
+
+func (s *RouteSampler) result(p sdktrace.SamplingParameters, decision sdktrace.SamplingDecision) sdktrace.SamplingResult {
+	if decision == sdktrace.Drop && s.recordUnsampled {
+		decision = sdktrace.RecordOnly
+	}
+	return sdktrace.SamplingResult{
+		Decision:   decision,
+		Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+	}
+}
+
+func (s *RouteSampler) samplerFor(route string) sdktrace.Sampler {
+	best := -1
+	for i, r := range s.rules {
+		if r.matches(route) && (best < 0 || len(r.Pattern) > len(s.rules[best].Pattern)) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return s.fallback
+	}
+	return s.samplers[best]
+}
+
+// spanRoute extracts the route from a span name: the path of
+// "GET /api/v1/users", or the method of "user.v1.AuthService/Login" with a
+// leading slash.
+func spanRoute(name string) string {
+	if _, path, ok := strings.Cut(name, " "); ok {
+		return path
+	}
+	if !strings.HasPrefix(name, "/") {
+		return "/" + name
+	}
+	return name
+}
@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// KeepFunc decides, once a span has ended, whether to export it even
+// though the head sampler didn't sample it.
+type KeepFunc func(span sdktrace.ReadOnlySpan) bool
+
+// TailSampler is a SpanProcessor that passes sampled spans on to next, along
+// with unsampled spans any KeepFunc asks to keep. It needs the head sampler
+// to record unsampled spans (see NewRouteSampler).
+//
+// Decisions are made per span as it ends, so a kept span's unsampled
+// children that ended before it are not exported.
+type TailSampler struct {
+	next sdktrace.SpanProcessor
+	keep []KeepFunc
+}
+
+func NewTailSampler(next sdktrace.SpanProcessor, keep ...KeepFunc) *TailSampler {
+	return &TailSampler{next: next, keep: keep}
+}
+
+func (t *TailSampler) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	t.next.OnStart(parent, s)
+}
+
+func (t *TailSampler) OnEnd(s sdktrace.ReadOnlySpan) {
+	if s.SpanContext().IsSampled() {
+		t.next.OnEnd(s)
+		return
+	}
+
+	for _, keep := range t.keep {
+		if keep(s) {
+			t.next.OnEnd(keptSpan{s})
+			return
+		}
+	}
+}
+
+func (t *TailSampler) Shutdown(ctx context.Context) error {
+	return t.next.Shutdown(ctx)
+}
+
+func (t *TailSampler) ForceFlush(ctx context.Context) error {
+	return t.next.ForceFlush(ctx)
+}
+
+// keptSpan marks a span kept by tail sampling as sampled, so exporting
+// processors don't skip it.
+type keptSpan struct {
+	sdktrace.ReadOnlySpan
+}
+
+func (s keptSpan) SpanContext() trace.SpanContext {
+	sc := s.ReadOnlySpan.SpanContext()
+	return sc.WithTraceFlags(sc.TraceFlags().WithSampled(true))
+}
+
+// KeepErrors keeps spans that ended with an error status.
+func KeepErrors(span sdktrace.ReadOnlySpan) bool {
+	return span.Status().Code == codes.Error
+}
+
+// gRPC status codes for failed authentication and authorization.
+const (
+	grpcPermissionDenied = 7
+	grpcUnauthenticated  = 16
+)
+
+// KeepAuthFailures keeps requests rejected with HTTP 401/403 or gRPC
+// UNAUTHENTICATED/PERMISSION_DENIED.
+func KeepAuthFailures(span sdktrace.ReadOnlySpan) bool {
+	for _, kv := range span.Attributes() {
+		switch kv.Key {
+		case "http.response.status_code", "http.status_code":
+			if kv.Value.Type() == attribute.INT64 {
+				code := kv.Value.AsInt64()
+				return code == 401 || code == 403
+			}
+		case "rpc.grpc.status_code":
+			if kv.Value.Type() == attribute.INT64 {
+				code := kv.Value.AsInt64()
+				return code == grpcUnauthenticated || code == grpcPermissionDenied
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+// Package tracing sets up OpenTelemetry tracing.
+//
+// HTTP and gRPC requests are instrumented by the transports using the global
+// tracer provider, which does nothing until Setup installs one. Sampling is
+// decided per route by RouteSampler, so cheap, noisy routes like health
+// checks can be sampled rarely while others are traced more often. With tail
+// sampling on, every request is recorded and a TailSampler exports the
+// unsampled ones worth keeping (errors, auth failures) after they end.
+//
+// Spans are exported over OTLP/HTTP, configured with the standard
+// OTEL_EXPORTER_OTLP_* environment variables.
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Config configures tracing.
+type Config struct {
+	ServiceName string
+	DefaultRate float64
+	Rules       []Rule
+
+	// TailSampling records every request and keeps unsampled ones that
+	// match Keep after they end.
+	TailSampling bool
+	Keep         []KeepFunc
+}
+
+// Setup installs a global tracer provider exporting over OTLP/HTTP. The
+// returned function flushes and shuts it down.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	var processor sdktrace.SpanProcessor = sdktrace.NewBatchSpanProcessor(exporter)
+	if cfg.TailSampling {
+		processor = NewTailSampler(processor, cfg.Keep...)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(NewRouteSampler(cfg.DefaultRate, cfg.Rules, cfg.TailSampling)),
+		sdktrace.WithSpanProcessor(processor),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return provider.Shutdown, nil
+}
+
+// HTTPSpanName names HTTP server spans "METHOD /path" when they start, so
+// RouteSampler can match the path. Transports rename them to the route
+// pattern once routing is done, to keep span names low-cardinality.
+func HTTPSpanName(operation string, r *http.Request) string {
+	return r.Method + " " + r.URL.Path
+}
@@ -0,0 +1,109 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/google/uuid"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// complexityLimit caps the fields a query may resolve, counting each list
+// as its limit argument, so nested lists can't fan out unbounded.
+const complexityLimit = 1000
+
+// NewHandler returns the GraphQL endpoint. Requests must carry a Viewer.
+func NewHandler(resolver *Resolver, logger *slog.Logger) http.Handler {
+	srv := handler.New(NewExecutableSchema(Config{
+		Resolvers:  resolver,
+		Directives: DirectiveRoot{Authorize: authorize},
+		Complexity: complexity(),
+	}))
+
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+
+	srv.Use(extension.Introspection{})
+	srv.Use(extension.FixedComplexityLimit(complexityLimit))
+
+	srv.SetErrorPresenter(func(ctx context.Context, err error) *gqlerror.Error {
+		return presentError(ctx, err, logger)
+	})
+	srv.SetRecoverFunc(func(ctx context.Context, v any) error {
+		logger.Error("graphql resolver panicked", slog.Any("panic", v))
+		return errors.New("internal server error")
+	})
+
+	return srv
+}
+
+// complexity counts each paginated list as its limit times the cost of
+// one node, and each unpaginated one as listEstimate nodes.
+func complexity() ComplexityRoot {
+	const listEstimate = 10
+
+	var c ComplexityRoot
+	c.Query.Users = func(child int, _ *UserFilter, _ *int, limit *int) int {
+		return child * *limit
+	}
+	c.Query.Permissions = func(child int, _, _ *string, _ *int, limit *int) int {
+		return child * *limit
+	}
+	c.Query.Roles = func(child int, _ *uuid.UUID) int {
+		return child * listEstimate
+	}
+	c.User.Roles = func(child int) int {
+		return child * listEstimate
+	}
+	c.User.Sessions = func(child int) int {
+		return child * listEstimate
+	}
+	c.Role.Permissions = func(child int) int {
+		return child * listEstimate
+	}
+	return c
+}
+
+// presentError reports errors with the codes the REST API uses, hiding
+// the details of unexpected ones.
+func presentError(ctx context.Context, err error, logger *slog.Logger) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+
+	var code string
+	switch {
+	case errors.Is(err, errPermissionDenied):
+		code = "FORBIDDEN"
+	case errors.Is(err, domain.ErrNotFound):
+		gqlErr.Message, code = "resource not found", "NOT_FOUND"
+	case errors.Is(err, domain.ErrInvalidInput):
+		code = "INVALID_INPUT"
+	case errors.Is(err, domain.ErrUnauthorized):
+		gqlErr.Message, code = "unauthorized", "UNAUTHORIZED"
+	case errors.Is(err, domain.ErrForbidden):
+		gqlErr.Message, code = "forbidden", "FORBIDDEN"
+	default:
+		// Query parsing and validation errors are meant for the caller
+		if errors.As(err, new(*gqlerror.Error)) && errors.Unwrap(err) == nil {
+			return gqlErr
+		}
+		logger.Error("unhandled error", slog.String("error", err.Error()))
+		gqlErr.Message, code = "internal server error", "INTERNAL_ERROR"
+	}
+
+	if gqlErr.Extensions == nil {
+		gqlErr.Extensions = map[string]any{}
+	}
+	gqlErr.Extensions["code"] = code
+	return gqlErr
+}
@@ -0,0 +1,38 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package graphql
+
+import (
+	"github.com/google/uuid"
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+type PermissionConnection struct {
+	Nodes  []domain.Permission `json:"nodes"`
+	Total  int                 `json:"total"`
+	Offset int                 `json:"offset"`
+	Limit  int                 `json:"limit"`
+}
+
+type Query struct {
+}
+
+type UserConnection struct {
+	Nodes  []domain.User `json:"nodes"`
+	Total  int           `json:"total"`
+	Offset int           `json:"offset"`
+	Limit  int           `json:"limit"`
+}
+
+type UserFilter struct {
+	Type   *string `json:"type,omitempty"`
+	Status *string `json:"status,omitempty"`
+	// Only members of this organization
+	OrganizationID *uuid.UUID `json:"organizationId,omitempty"`
+	// Matched against email, username and full name
+	Search *string `json:"search,omitempty"`
+	// A BCP 47 language tag; "de" also matches "de-AT"
+	Locale *string `json:"locale,omitempty"`
+	// An IANA time zone name
+	Timezone *string `json:"timezone,omitempty"`
+}
@@ -0,0 +1,37 @@
+// Package graphql serves the GraphQL API: users, roles, permissions and
+// sessions, resolved through the service layer with field-level
+// authorization from the caller's RBAC permissions.
+//
+// generated.go and models_gen.go are generated by gqlgen from
+// schema.graphqls (make graphql); the resolvers are in *.resolvers.go.
+package graphql
+
+import (
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+)
+
+// Resolver is the root resolver.
+type Resolver struct {
+	users *service.UserService
+	rbac  *service.RBACService
+	auth  *service.AuthService
+}
+
+// NewResolver returns a root resolver over the services.
+func NewResolver(users *service.UserService, rbac *service.RBACService, auth *service.AuthService) *Resolver {
+	return &Resolver{users: users, rbac: rbac, auth: auth}
+}
+
+// maxLimit is the largest page a list query returns, as in the REST API.
+const maxLimit = 100
+
+func checkPage(offset, limit int) error {
+	if offset < 0 {
+		return domain.ValidationError{Field: "offset", Message: "must not be negative"}
+	}
+	if limit <= 0 || limit > maxLimit {
+		return domain.ValidationError{Field: "limit", Message: "must be between 1 and 100"}
+	}
+	return nil
+}
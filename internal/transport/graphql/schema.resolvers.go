@@ -0,0 +1,168 @@
+package graphql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.66
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// Me is the resolver for the me field.
+func (r *queryResolver) Me(ctx context.Context) (*domain.User, error) {
+	v, err := viewerFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.users.GetUser(ctx, v.UserID)
+}
+
+// User is the resolver for the user field.
+func (r *queryResolver) User(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	v, err := viewerFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if id != v.UserID && !v.Can("users", "read") {
+		return nil, fmt.Errorf("%w: requires users:read", errPermissionDenied)
+	}
+	return r.users.GetUser(ctx, id)
+}
+
+// Users is the resolver for the users field.
+func (r *queryResolver) Users(ctx context.Context, filter *UserFilter, offset *int, limit *int) (*UserConnection, error) {
+	f := storage.UserFilter{
+		Offset: *offset,
+		Limit:  *limit,
+	}
+	if err := checkPage(f.Offset, f.Limit); err != nil {
+		return nil, err
+	}
+
+	if filter != nil {
+		if filter.Type != nil {
+			ut := domain.UserType(*filter.Type)
+			if !ut.Valid() {
+				return nil, domain.ValidationError{Field: "type", Message: "unknown user type"}
+			}
+			f.Type = &ut
+		}
+		if filter.Status != nil {
+			st := domain.UserStatus(*filter.Status)
+			if !st.Valid() {
+				return nil, domain.ValidationError{Field: "status", Message: "unknown user status"}
+			}
+			f.Status = &st
+		}
+		f.OrganizationID = filter.OrganizationID
+		if filter.Search != nil {
+			f.Search = *filter.Search
+		}
+		if filter.Locale != nil {
+			f.Locale = *filter.Locale
+		}
+		if filter.Timezone != nil {
+			f.Timezone = *filter.Timezone
+		}
+	}
+
+	users, total, err := r.users.ListUsers(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserConnection{Nodes: users, Total: int(total), Offset: f.Offset, Limit: f.Limit}, nil
+}
+
+// Role is the resolver for the role field.
+func (r *queryResolver) Role(ctx context.Context, id uuid.UUID) (*domain.Role, error) {
+	return r.rbac.GetRole(ctx, id)
+}
+
+// Roles is the resolver for the roles field.
+func (r *queryResolver) Roles(ctx context.Context, organizationID *uuid.UUID) ([]domain.Role, error) {
+	return r.rbac.ListRoles(ctx, organizationID)
+}
+
+// Permissions is the resolver for the permissions field.
+func (r *queryResolver) Permissions(ctx context.Context, resource *string, search *string, offset *int, limit *int) (*PermissionConnection, error) {
+	f := storage.PermissionFilter{
+		Offset: *offset,
+		Limit:  *limit,
+	}
+	if err := checkPage(f.Offset, f.Limit); err != nil {
+		return nil, err
+	}
+	if resource != nil {
+		f.Resource = *resource
+	}
+	if search != nil {
+		f.Search = *search
+	}
+
+	perms, total, err := r.rbac.ListPermissions(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PermissionConnection{Nodes: perms, Total: int(total), Offset: f.Offset, Limit: f.Limit}, nil
+}
+
+// Chain is the resolver for the chain field.
+func (r *sessionResolver) Chain(ctx context.Context, obj *domain.RefreshToken) ([]domain.RefreshToken, error) {
+	return r.auth.SessionChain(ctx, obj)
+}
+
+// Type is the resolver for the type field.
+func (r *userResolver) Type(ctx context.Context, obj *domain.User) (string, error) {
+	return string(obj.Type), nil
+}
+
+// Status is the resolver for the status field.
+func (r *userResolver) Status(ctx context.Context, obj *domain.User) (string, error) {
+	return string(obj.Status), nil
+}
+
+// Locale is the resolver for the locale field.
+func (r *userResolver) Locale(ctx context.Context, obj *domain.User) (*string, error) {
+	if obj.Locale == "" {
+		return nil, nil
+	}
+	return &obj.Locale, nil
+}
+
+// Timezone is the resolver for the timezone field.
+func (r *userResolver) Timezone(ctx context.Context, obj *domain.User) (*string, error) {
+	if obj.Timezone == "" {
+		return nil, nil
+	}
+	return &obj.Timezone, nil
+}
+
+// Roles is the resolver for the roles field.
+func (r *userResolver) Roles(ctx context.Context, obj *domain.User) ([]domain.Role, error) {
+	return r.rbac.GetUserRoles(ctx, obj.ID)
+}
+
+// Sessions is the resolver for the sessions field.
+func (r *userResolver) Sessions(ctx context.Context, obj *domain.User) ([]domain.RefreshToken, error) {
+	return r.auth.ListSessions(ctx, obj.ID)
+}
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Session returns SessionResolver implementation.
+func (r *Resolver) Session() SessionResolver { return &sessionResolver{r} }
+
+// User returns UserResolver implementation.
+func (r *Resolver) User() UserResolver { return &userResolver{r} }
+
+type queryResolver struct{ *Resolver }
+type sessionResolver struct{ *Resolver }
+type userResolver struct{ *Resolver }
@@ -0,0 +1,71 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/pkg/authmiddleware"
+)
+
+// Viewer is the authenticated caller, set by the HTTP server once the
+// request is authenticated.
+type Viewer struct {
+	UserID      uuid.UUID
+	UserType    string
+	Permissions []string
+	Denied      []string
+}
+
+type viewerKey struct{}
+
+// WithViewer returns a context carrying the caller.
+func WithViewer(ctx context.Context, v *Viewer) context.Context {
+	return context.WithValue(ctx, viewerKey{}, v)
+}
+
+func viewerFrom(ctx context.Context) (*Viewer, error) {
+	v, ok := ctx.Value(viewerKey{}).(*Viewer)
+	if !ok {
+		return nil, domain.ErrUnauthorized
+	}
+	return v, nil
+}
+
+// Can reports whether the caller holds resource:action. Partners act on
+// other users only within their consent, which GraphQL doesn't check, so
+// they get nothing beyond their own user.
+func (v *Viewer) Can(resource, action string) bool {
+	if v.UserType == string(domain.UserTypePartner) {
+		return false
+	}
+	return authmiddleware.Permits(v.Permissions, v.Denied, resource, action)
+}
+
+// errPermissionDenied is reported for fields the caller can't see.
+var errPermissionDenied = errors.New("permission denied")
+
+// authorize implements @authorize. With owner, a field of the caller's own
+// user is allowed whatever their permissions.
+func authorize(ctx context.Context, obj any, next graphql.Resolver, resource, action string, owner *bool) (any, error) {
+	v, err := viewerFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if owner != nil && *owner {
+		if u, ok := obj.(*domain.User); ok && u.ID == v.UserID {
+			return next(ctx)
+		}
+	}
+
+	if !v.Can(resource, action) {
+		return nil, fmt.Errorf("%w: requires %s:%s", errPermissionDenied, resource, action)
+	}
+
+	return next(ctx)
+}
@@ -23,34 +23,31 @@ func NewAuthHandler(authService *service.AuthService, userService *service.UserS
 }
 
 func (h *authHandler) Login(ctx context.Context, req *userv1.LoginRequest) (*userv1.LoginResponse, error) {
+	ipAddress, userAgent := clientInfo(ctx)
 	result, err := h.authService.Login(ctx, service.LoginInput{
-		Email:     "",
-		Password:  "",
-		IPAddress: "",
-		UserAgent: "",
+		Email:     req.Email,
+		Password:  req.Password,
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
 	})
 	if err != nil {
 		return nil, mapDomainError(err)
 	}
 
-	user, err := h.userService.GetUserByEmail(ctx, req.Email)
-	if err != nil {
-		return nil, mapDomainError(err)
-	}
-
 	return &userv1.LoginResponse{
 		AccessToken:  result.AccessToken,
 		RefreshToken: result.RefreshToken,
 		ExpiresIn:    result.ExpiresInSeconds,
-		User:         domainUserToProto(user),
+		User:         domainUserToProto(result.User),
 	}, nil
 }
 
 func (h *authHandler) RefreshToken(ctx context.Context, req *userv1.RefreshTokenRequest) (*userv1.RefreshTokenResponse, error) {
+	ipAddress, userAgent := clientInfo(ctx)
 	result, err := h.authService.RefreshToken(ctx, service.RefreshTokenInput{
-		RefreshToken: "",
-		IPAddress:    "",
-		UserAgent:    "",
+		RefreshToken: req.RefreshToken,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
 	})
 	if err != nil {
 		return nil, mapDomainError(err)
@@ -71,7 +68,19 @@ func (h *authHandler) Logout(ctx context.Context, req *userv1.LogoutRequest) (*e
 }
 
 func (h *authHandler) LogoutAll(ctx context.Context, req *userv1.LogoutAllRequest) (*emptypb.Empty, error) {
-	if err := h.authService.LogoutAll(ctx, domain.UUIDFromString(req.UserId)); err != nil {
+	userID, err := parseID("user_id", req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Revoking someone else's sessions is an admin operation
+	if claims, _ := ClaimsFromContext(ctx); claims == nil || claims.UserID != userID {
+		if err := requirePermission(ctx, "users", "admin"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := h.authService.LogoutAll(ctx, userID); err != nil {
 		return nil, mapDomainError(err)
 	}
 	return &emptypb.Empty{}, nil
@@ -83,11 +92,38 @@ func (h *authHandler) ValidateToken(ctx context.Context, req *userv1.ValidateTok
 		return &userv1.ValidateTokenResponse{Valid: false}, nil
 	}
 
-	return &userv1.ValidateTokenResponse{
-		Valid:       true,
-		UserId:      claims.UserID.String(),
-		Email:       claims.Email,
-		UserType:    userTypeToProto(domain.UserType(claims.UserType)),
-		Permissions: claims.Permissions,
-	}, nil
+	orgs := make([]*userv1.OrganizationMembership, len(claims.Organizations))
+	for i, o := range claims.Organizations {
+		orgs[i] = &userv1.OrganizationMembership{
+			OrganizationId:    o.ID.String(),
+			Permissions:       o.Permissions,
+			DeniedPermissions: o.Denied,
+		}
+	}
+
+	resp := &userv1.ValidateTokenResponse{
+		Valid:             true,
+		UserId:            claims.UserID.String(),
+		Email:             claims.Email,
+		UserType:          userTypeToProto(domain.UserType(claims.UserType)),
+		Permissions:       claims.Permissions,
+		DeniedPermissions: claims.Denied,
+		Organizations:     orgs,
+		Groups:            claims.Groups,
+	}
+
+	// Minimal tokens carry no email; introspection still reports it
+	if resp.Email == "" {
+		env, _ := domain.ParseEnvironment(claims.Environment)
+		if user, err := h.userService.GetUser(domain.WithEnvironment(ctx, env), claims.UserID); err == nil {
+			resp.Email = user.Email
+		}
+	}
+
+	if claims.IsImpersonation() {
+		resp.ActorId = claims.Actor.UserID.String()
+		resp.ImpersonationSessionId = claims.Actor.SessionID.String()
+	}
+
+	return resp, nil
 }
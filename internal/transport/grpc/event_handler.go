@@ -0,0 +1,80 @@
+package grpc
+
+import (
+	userv1 "github.com/mvaleed/aegis/api/proto/user/v1"
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type eventHandler struct {
+	userv1.UnimplementedEventServiceServer
+	bus *event.Bus
+}
+
+func NewEventHandler(bus *event.Bus) userv1.EventServiceServer {
+	return &eventHandler{bus: bus}
+}
+
+func (h *eventHandler) Subscribe(req *userv1.SubscribeRequest, stream grpc.ServerStreamingServer[userv1.Event]) error {
+	ctx := stream.Context()
+	if err := requirePermission(ctx, "events", "read"); err != nil {
+		return err
+	}
+
+	sub := h.bus.Subscribe(ctx, 0, req.EventTypes...)
+	defer sub.Cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-sub.Events():
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(domainEventToProto(e)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func domainEventToProto(e domain.Event) *userv1.Event {
+	// Event data may hold values structpb can't represent directly
+	// (e.g. []string); fall back to omitting the payload rather than failing.
+	data, err := structpb.NewStruct(normalizeEventData(e.Data))
+	if err != nil {
+		data = nil
+	}
+
+	return &userv1.Event{
+		Id:            e.ID.String(),
+		Type:          e.Type,
+		Timestamp:     timestamppb.New(e.Timestamp),
+		UserId:        e.UserID.String(),
+		Data:          data,
+		SchemaVersion: int32(e.SchemaVersion),
+		CorrelationId: e.CorrelationID,
+	}
+}
+
+// normalizeEventData converts typed slices into []any so structpb accepts them.
+func normalizeEventData(data map[string]any) map[string]any {
+	out := make(map[string]any, len(data))
+	for k, v := range data {
+		switch vv := v.(type) {
+		case []string:
+			list := make([]any, len(vv))
+			for i, s := range vv {
+				list[i] = s
+			}
+			out[k] = list
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
@@ -0,0 +1,143 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"github.com/mvaleed/aegis/internal/auth"
+	"github.com/mvaleed/aegis/internal/authz"
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+)
+
+// fieldFilters lists the messages whose fields are guarded by permissions,
+// matching the HTTP API's.
+var fieldFilters = map[protoreflect.FullName]authz.FieldPermissions{
+	"user.v1.User": authz.UserFields,
+}
+
+// fieldFilterInterceptor clears the guarded fields of responses the caller
+// lacks the permission for, in every message but those describing the
+// caller. It runs before idempotencyInterceptor, so replayed responses are
+// filtered for whoever is replayed them.
+func (s *Server) fieldFilterInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+
+	if claims, ok := ClaimsFromContext(ctx); ok {
+		if m, ok := resp.(proto.Message); ok {
+			filterFields(m.ProtoReflect(), claims)
+		}
+	}
+	return resp, nil
+}
+
+// streamFieldFilterInterceptor filters the messages a stream sends, as
+// fieldFilterInterceptor does responses.
+func (s *Server) streamFieldFilterInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	claims, ok := ClaimsFromContext(ss.Context())
+	if !ok {
+		return handler(srv, ss)
+	}
+	return handler(srv, &filteringStream{ServerStream: ss, claims: claims})
+}
+
+// filteringStream filters the messages sent on a grpc.ServerStream.
+type filteringStream struct {
+	grpc.ServerStream
+	claims *auth.Claims
+}
+
+func (s *filteringStream) SendMsg(m interface{}) error {
+	if msg, ok := m.(proto.Message); ok {
+		filterFields(msg.ProtoReflect(), s.claims)
+	}
+	return s.ServerStream.SendMsg(m)
+}
+
+// filterFields clears the fields of m and the messages in it that
+// fieldFilters guards and claims lack the permission for, unless the
+// message's id is the caller's.
+func filterFields(m protoreflect.Message, claims *auth.Claims) {
+	fields := m.Descriptor().Fields()
+
+	if guarded, ok := fieldFilters[m.Descriptor().FullName()]; ok {
+		id := fields.ByName("id")
+		if id == nil || m.Get(id).String() != claims.UserID.String() {
+			hidden := guarded.Hidden(func(resource, action string) bool {
+				return hasPermission(claims, resource, action)
+			})
+			for name := range hidden {
+				if fd := fields.ByName(protoreflect.Name(name)); fd != nil {
+					m.Clear(fd)
+				}
+			}
+		}
+	}
+
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				v.Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+					filterFields(v.Message(), claims)
+					return true
+				})
+			}
+		case fd.IsList():
+			if fd.Message() != nil {
+				list := v.List()
+				for i := range list.Len() {
+					filterFields(list.Get(i).Message(), claims)
+				}
+			}
+		case fd.Message() != nil:
+			filterFields(v.Message(), claims)
+		}
+		return true
+	})
+}
+
+// readMaskFields returns the fields of message md a read mask asks for, as
+// a sparse fieldset for the services, or nil for an empty mask.
+func readMaskFields(mask *fieldmaskpb.FieldMask, md protoreflect.MessageDescriptor) (service.FieldSet, error) {
+	if len(mask.GetPaths()) == 0 {
+		return nil, nil
+	}
+
+	fields := service.FieldSet{"id": true}
+	for _, path := range mask.GetPaths() {
+		if md.Fields().ByName(protoreflect.Name(path)) == nil {
+			return nil, invalidArgument(domain.ValidationError{Field: "read_mask", Message: fmt.Sprintf("unknown field %q", path)})
+		}
+		fields[path] = true
+	}
+	return fields, nil
+}
+
+// applyReadMask clears the fields of m not in fields.
+func applyReadMask(m protoreflect.Message, fields service.FieldSet) {
+	m.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		if !fields.Has(string(fd.Name())) {
+			m.Clear(fd)
+		}
+		return true
+	})
+}
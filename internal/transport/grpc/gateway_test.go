@@ -0,0 +1,99 @@
+package grpc_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/mvaleed/aegis/internal/service/servicetest"
+	grpcTransport "github.com/mvaleed/aegis/internal/transport/grpc"
+)
+
+func TestGatewayLoginThenRefresh(t *testing.T) {
+	const email, password = "ada@example.com", "correct horse battery"
+
+	authn, err := servicetest.NewAuth(email, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := grpcTransport.NewServer(nil, authn.Service, nil, nil, nil, nil, nil, nil, nil, nil, authn.JWT, false, nil, logger)
+	go func() { _ = server.Serve(bufconn.Listen(1 << 20)) }()
+	defer server.GracefulStop()
+
+	gateway, err := grpcTransport.NewGateway(ctx, server, insecure.NewCredentials())
+	if err != nil {
+		t.Fatalf("NewGateway: %v", err)
+	}
+
+	var login struct {
+		AccessToken  string `json:"accessToken"`
+		RefreshToken string `json:"refreshToken"`
+		User         struct {
+			Email string `json:"email"`
+		} `json:"user"`
+	}
+	post(t, gateway, "/v1/auth/login", map[string]string{"email": email, "password": password}, &login)
+	if login.AccessToken == "" || login.RefreshToken == "" {
+		t.Fatalf("login returned no tokens: %+v", login)
+	}
+	if login.User.Email != email {
+		t.Fatalf("login returned user %q", login.User.Email)
+	}
+
+	signedIn := authn.Tokens.Get(login.RefreshToken)
+	if signedIn == nil {
+		t.Fatal("login's refresh token not stored")
+	}
+	if signedIn.IPAddress != "203.0.113.7" || signedIn.UserAgent != "gateway-test" {
+		t.Fatalf("login recorded client %q %q", signedIn.IPAddress, signedIn.UserAgent)
+	}
+
+	var refresh struct {
+		AccessToken  string `json:"accessToken"`
+		RefreshToken string `json:"refreshToken"`
+	}
+	post(t, gateway, "/v1/auth/refresh", map[string]string{"refreshToken": login.RefreshToken}, &refresh)
+	if refresh.AccessToken == "" || refresh.RefreshToken == "" || refresh.RefreshToken == login.RefreshToken {
+		t.Fatalf("refresh didn't rotate the tokens: %+v", refresh)
+	}
+	if replaced := authn.Tokens.Get(login.RefreshToken); replaced.ReplacedByID == nil {
+		t.Fatal("login's refresh token not replaced")
+	}
+}
+
+// post sends body as JSON from a client behind a proxy, and decodes the
+// response into resp.
+func post(t *testing.T, handler http.Handler, path string, body, resp any) {
+	t.Helper()
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gateway-test")
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST %s: %d %s", path, rec.Code, rec.Body)
+	}
+	if err := json.NewDecoder(rec.Body).Decode(resp); err != nil {
+		t.Fatalf("POST %s: %v", path, err)
+	}
+}
@@ -0,0 +1,235 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	userv1 "github.com/mvaleed/aegis/api/proto/user/v1"
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type groupHandler struct {
+	userv1.UnimplementedGroupServiceServer
+	groupService *service.GroupService
+}
+
+func NewGroupHandler(groupService *service.GroupService) userv1.GroupServiceServer {
+	return &groupHandler{groupService: groupService}
+}
+
+func (h *groupHandler) CreateGroup(ctx context.Context, req *userv1.CreateGroupRequest) (*userv1.CreateGroupResponse, error) {
+	if err := requirePermission(ctx, "groups", "write"); err != nil {
+		return nil, err
+	}
+
+	group, err := h.groupService.CreateGroup(ctx, req.Name, req.Description)
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return &userv1.CreateGroupResponse{
+		Group: domainGroupToProto(group),
+	}, nil
+}
+
+func (h *groupHandler) GetGroup(ctx context.Context, req *userv1.GetGroupRequest) (*userv1.GetGroupResponse, error) {
+	if err := requirePermission(ctx, "groups", "read"); err != nil {
+		return nil, err
+	}
+
+	id, err := parseID("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := h.groupService.GetGroup(ctx, id)
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return &userv1.GetGroupResponse{
+		Group: domainGroupToProto(group),
+	}, nil
+}
+
+func (h *groupHandler) ListGroups(ctx context.Context, req *userv1.ListGroupsRequest) (*userv1.ListGroupsResponse, error) {
+	if err := requirePermission(ctx, "groups", "read"); err != nil {
+		return nil, err
+	}
+
+	page, pageSize := normalizePage(req.Page, req.PageSize)
+
+	groups, total, err := h.groupService.ListGroups(ctx, int((page-1)*pageSize), int(pageSize))
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	resp := &userv1.ListGroupsResponse{
+		Groups:   make([]*userv1.Group, len(groups)),
+		Total:    int32(total),
+		Page:     page,
+		PageSize: pageSize,
+	}
+	for i := range groups {
+		resp.Groups[i] = domainGroupToProto(&groups[i])
+	}
+
+	return resp, nil
+}
+
+func (h *groupHandler) DeleteGroup(ctx context.Context, req *userv1.DeleteGroupRequest) (*emptypb.Empty, error) {
+	if err := requirePermission(ctx, "groups", "delete"); err != nil {
+		return nil, err
+	}
+
+	id, err := parseID("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.groupService.DeleteGroup(ctx, id); err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (h *groupHandler) AddGroupMember(ctx context.Context, req *userv1.AddGroupMemberRequest) (*emptypb.Empty, error) {
+	if err := requirePermission(ctx, "groups", "write"); err != nil {
+		return nil, err
+	}
+
+	groupID, userID, err := parseGroupPair(req.GroupId, req.UserId, "user_id")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.groupService.AddMember(ctx, groupID, userID); err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (h *groupHandler) RemoveGroupMember(ctx context.Context, req *userv1.RemoveGroupMemberRequest) (*emptypb.Empty, error) {
+	if err := requirePermission(ctx, "groups", "write"); err != nil {
+		return nil, err
+	}
+
+	groupID, userID, err := parseGroupPair(req.GroupId, req.UserId, "user_id")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.groupService.RemoveMember(ctx, groupID, userID); err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (h *groupHandler) ListGroupMembers(ctx context.Context, req *userv1.ListGroupMembersRequest) (*userv1.ListGroupMembersResponse, error) {
+	if err := requirePermission(ctx, "groups", "read"); err != nil {
+		return nil, err
+	}
+
+	groupID, err := parseID("group_id", req.GroupId)
+	if err != nil {
+		return nil, err
+	}
+
+	page, pageSize := normalizePage(req.Page, req.PageSize)
+
+	members, total, err := h.groupService.ListMembers(ctx, groupID, int((page-1)*pageSize), int(pageSize))
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	resp := &userv1.ListGroupMembersResponse{
+		Members:  make([]*userv1.GroupMember, len(members)),
+		Total:    int32(total),
+		Page:     page,
+		PageSize: pageSize,
+	}
+	for i, m := range members {
+		resp.Members[i] = &userv1.GroupMember{
+			GroupId:  m.GroupID.String(),
+			UserId:   m.UserID.String(),
+			JoinedAt: timestamppb.New(m.JoinedAt),
+		}
+	}
+
+	return resp, nil
+}
+
+func (h *groupHandler) AssignGroupRole(ctx context.Context, req *userv1.AssignGroupRoleRequest) (*emptypb.Empty, error) {
+	if err := requirePermission(ctx, "roles", "assign"); err != nil {
+		return nil, err
+	}
+
+	groupID, roleID, err := parseGroupPair(req.GroupId, req.RoleId, "role_id")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.groupService.AssignRole(ctx, groupID, roleID); err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (h *groupHandler) RemoveGroupRole(ctx context.Context, req *userv1.RemoveGroupRoleRequest) (*emptypb.Empty, error) {
+	if err := requirePermission(ctx, "roles", "assign"); err != nil {
+		return nil, err
+	}
+
+	groupID, roleID, err := parseGroupPair(req.GroupId, req.RoleId, "role_id")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.groupService.RemoveRole(ctx, groupID, roleID); err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// parseGroupPair parses a group ID together with a member or role ID.
+func parseGroupPair(groupIDStr, otherIDStr, otherField string) (uuid.UUID, uuid.UUID, error) {
+	groupID, err := parseID("group_id", groupIDStr)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	otherID, err := parseID(otherField, otherIDStr)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	return groupID, otherID, nil
+}
+
+func domainGroupToProto(g *domain.Group) *userv1.Group {
+	if g == nil {
+		return nil
+	}
+
+	pb := &userv1.Group{
+		Id:          g.ID.String(),
+		Name:        g.Name,
+		Description: g.Description,
+		CreatedAt:   timestamppb.New(g.CreatedAt),
+		UpdatedAt:   timestamppb.New(g.UpdatedAt),
+	}
+
+	for i := range g.Roles {
+		pb.Roles = append(pb.Roles, domainRoleToProto(&g.Roles[i]))
+	}
+
+	return pb
+}
@@ -2,9 +2,15 @@ package grpc
 
 import (
 	"errors"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
@@ -19,40 +25,121 @@ import (
 //
 // Then uncomment and adjust the handlers below.
 
-// mapDomainError converts domain errors to gRPC status errors
+// errorDomain is the ErrorInfo domain of aegis's errors.
+const errorDomain = "aegis"
+
+// mapDomainError converts domain errors to gRPC status errors. Their details
+// carry an ErrorInfo whose reason is a code like the HTTP API's, a
+// BadRequest listing the fields at fault for validation errors, and a
+// RetryInfo where trying again shortly may succeed.
 func mapDomainError(err error) error {
 	if err == nil {
 		return nil
 	}
 
+	var usernameTaken domain.UsernameTakenError
+	if errors.As(err, &usernameTaken) {
+		msg := "username already taken"
+		var metadata map[string]string
+		if len(usernameTaken.Suggestions) > 0 {
+			msg += "; available: " + strings.Join(usernameTaken.Suggestions, ", ")
+			metadata = map[string]string{"suggestions": strings.Join(usernameTaken.Suggestions, ",")}
+		}
+		return statusError(codes.AlreadyExists, msg, &errdetails.ErrorInfo{
+			Reason:   "USERNAME_TAKEN",
+			Domain:   errorDomain,
+			Metadata: metadata,
+		}, &errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "username", Description: "already taken"},
+		}})
+	}
+
 	switch {
 	case errors.Is(err, domain.ErrNotFound):
-		return status.Error(codes.NotFound, err.Error())
+		return domainError(codes.NotFound, err, "NOT_FOUND")
 	case errors.Is(err, domain.ErrAlreadyExists):
-		return status.Error(codes.AlreadyExists, err.Error())
+		return domainError(codes.AlreadyExists, err, "ALREADY_EXISTS")
 	case errors.Is(err, domain.ErrInvalidCredential):
-		return status.Error(codes.Unauthenticated, err.Error())
+		return domainError(codes.Unauthenticated, err, "INVALID_CREDENTIALS")
+	case errors.Is(err, domain.ErrChallengeRequired):
+		return domainError(codes.Unauthenticated, err, "CHALLENGE_REQUIRED")
+	case errors.Is(err, domain.ErrDeviceConfirmationRequired):
+		return domainError(codes.Unauthenticated, err, "DEVICE_CONFIRMATION_REQUIRED")
+	case errors.Is(err, domain.ErrForbidden):
+		return domainError(codes.PermissionDenied, err, "FORBIDDEN")
+	case errors.Is(err, domain.ErrTooManySessions):
+		return domainError(codes.ResourceExhausted, err, "TOO_MANY_SESSIONS")
 	case errors.Is(err, domain.ErrInvalidStatus):
-		return status.Error(codes.FailedPrecondition, err.Error())
+		return domainError(codes.FailedPrecondition, err, "INVALID_STATUS")
 	case errors.Is(err, domain.ErrConcurrentModification):
-		return status.Error(codes.Aborted, err.Error())
+		return domainError(codes.Aborted, err, "CONCURRENT_MODIFICATION",
+			&errdetails.RetryInfo{RetryDelay: durationpb.New(100 * time.Millisecond)})
 	case errors.Is(err, domain.ErrTokenExpired):
-		return status.Error(codes.Unauthenticated, err.Error())
+		return domainError(codes.Unauthenticated, err, "TOKEN_EXPIRED")
 	case errors.Is(err, domain.ErrTokenRevoked):
-		return status.Error(codes.Unauthenticated, err.Error())
+		return domainError(codes.Unauthenticated, err, "TOKEN_REVOKED")
+	case errors.Is(err, domain.ErrIdempotencyKeyReused):
+		return domainError(codes.FailedPrecondition, err, "IDEMPOTENCY_KEY_REUSED")
+	case errors.Is(err, domain.ErrRequestInProgress):
+		// As the HTTP API's Retry-After
+		return domainError(codes.Aborted, err, "REQUEST_IN_PROGRESS",
+			&errdetails.RetryInfo{RetryDelay: durationpb.New(time.Second)})
 	}
 
-	var validationErr *domain.ValidationError
-	if errors.As(err, &validationErr) {
-		return status.Error(codes.InvalidArgument, err.Error())
+	var validationErr domain.ValidationError
+	var validationErrs domain.ValidationErrors
+	if errors.As(err, &validationErr) || errors.As(err, &validationErrs) {
+		return invalidArgument(err)
 	}
 
+	return statusError(codes.Internal, "internal server error", &errdetails.ErrorInfo{Reason: "INTERNAL_ERROR", Domain: errorDomain})
+}
+
+// domainError is a status error for err, with an ErrorInfo of reason
+// followed by details.
+func domainError(code codes.Code, err error, reason string, details ...protoadapt.MessageV1) error {
+	info := &errdetails.ErrorInfo{Reason: reason, Domain: errorDomain}
+	return statusError(code, err.Error(), append([]protoadapt.MessageV1{info}, details...)...)
+}
+
+// statusError is a status error with details, or without them should they
+// fail to marshal.
+func statusError(code codes.Code, msg string, details ...protoadapt.MessageV1) error {
+	st := status.New(code, msg)
+	withDetails, err := st.WithDetails(details...)
+	if err != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+// invalidArgument converts a validation error to InvalidArgument, with the
+// fields at fault as BadRequest field violations in the status details, as
+// the HTTP API lists them in its problem details.
+func invalidArgument(err error) error {
+	var violations []*errdetails.BadRequest_FieldViolation
+	var validationErr domain.ValidationError
 	var validationErrs domain.ValidationErrors
-	if errors.As(err, &validationErrs) {
-		return status.Error(codes.InvalidArgument, err.Error())
+	switch {
+	case errors.As(err, &validationErrs):
+		for _, e := range validationErrs {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: e.Field, Description: e.Message})
+		}
+	case errors.As(err, &validationErr):
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: validationErr.Field, Description: validationErr.Message})
 	}
 
-	return status.Error(codes.Internal, "internal server error")
+	return domainError(codes.InvalidArgument, err, "INVALID_INPUT", &errdetails.BadRequest{FieldViolations: violations})
+}
+
+// parseID parses a UUID request field, failing with InvalidArgument so a
+// malformed ID never reaches the services as the nil UUID.
+func parseID(field, value string) (uuid.UUID, error) {
+	id, err := domain.ParseID(field, value)
+	if err != nil {
+		return uuid.Nil, invalidArgument(err)
+	}
+	return id, nil
 }
 
 /*
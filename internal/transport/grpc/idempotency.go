@@ -0,0 +1,113 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	userv1 "github.com/mvaleed/aegis/api/proto/user/v1"
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+)
+
+// Metadata keys for idempotent calls. The REST gateway maps them to the
+// Idempotency-Key and Idempotent-Replayed HTTP headers.
+const (
+	idempotencyKeyMetadata     = "idempotency-key"
+	idempotentReplayedMetadata = "idempotent-replayed"
+)
+
+// idempotentMethods lists the mutations that honor idempotency keys, with a
+// constructor for their response type so stored responses can be replayed.
+var idempotentMethods = map[string]func() proto.Message{
+	"/user.v1.UserService/CreateUser": func() proto.Message { return &userv1.CreateUserResponse{} },
+	"/user.v1.RBACService/CreateRole": func() proto.Message { return &userv1.CreateRoleResponse{} },
+	"/user.v1.RBACService/AssignRole": func() proto.Message { return &emptypb.Empty{} },
+}
+
+// idempotencyInterceptor stores the response of an idempotent method called
+// with an idempotency key and replays it to retries with the same key and
+// request, mirroring the HTTP Idempotency-Key header. It runs after
+// authentication so keys are scoped to the caller.
+func (s *Server) idempotencyInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	newResponse, ok := idempotentMethods[info.FullMethod]
+	if !ok || s.idempotencyService == nil {
+		return handler(ctx, req)
+	}
+
+	key := idempotencyKeyFromContext(ctx)
+	if key == "" {
+		return handler(ctx, req)
+	}
+
+	request, err := proto.MarshalOptions{Deterministic: true}.Marshal(req.(proto.Message))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	scope := service.AnonymousIdempotencyScope
+	if claims, ok := ClaimsFromContext(ctx); ok {
+		scope = claims.UserID.String()
+	}
+
+	var result interface{}
+	stored, err := s.idempotencyService.Do(ctx, scope, key, info.FullMethod, request,
+		func(ctx context.Context) (*service.IdempotentResponse, error) {
+			resp, err := handler(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			result = resp
+
+			body, err := proto.Marshal(resp.(proto.Message))
+			if err != nil {
+				return nil, err
+			}
+			return &service.IdempotentResponse{StatusCode: int(codes.OK), Body: body}, nil
+		})
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		var validationErr domain.ValidationError
+		if errors.As(err, &validationErr) {
+			return nil, invalidArgument(err)
+		}
+		return nil, mapDomainError(err)
+	}
+
+	if !stored.Replayed {
+		return result, nil
+	}
+
+	resp := newResponse()
+	if err := proto.Unmarshal(stored.Body, resp); err != nil {
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(idempotentReplayedMetadata, "true"))
+
+	return resp, nil
+}
+
+func idempotencyKeyFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if v := md.Get(idempotencyKeyMetadata); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
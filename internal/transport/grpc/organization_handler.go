@@ -0,0 +1,205 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	userv1 "github.com/mvaleed/aegis/api/proto/user/v1"
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type organizationHandler struct {
+	userv1.UnimplementedOrganizationServiceServer
+	orgService *service.OrganizationService
+}
+
+func NewOrganizationHandler(orgService *service.OrganizationService) userv1.OrganizationServiceServer {
+	return &organizationHandler{orgService: orgService}
+}
+
+func (h *organizationHandler) CreateOrganization(ctx context.Context, req *userv1.CreateOrganizationRequest) (*userv1.CreateOrganizationResponse, error) {
+	if err := requirePermission(ctx, "organizations", "write"); err != nil {
+		return nil, err
+	}
+
+	org, err := h.orgService.CreateOrganization(ctx, req.Name, req.Slug)
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return &userv1.CreateOrganizationResponse{
+		Organization: domainOrganizationToProto(org),
+	}, nil
+}
+
+func (h *organizationHandler) GetOrganization(ctx context.Context, req *userv1.GetOrganizationRequest) (*userv1.GetOrganizationResponse, error) {
+	if err := requirePermission(ctx, "organizations", "read"); err != nil {
+		return nil, err
+	}
+
+	id, err := parseID("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	org, err := h.orgService.GetOrganization(ctx, id)
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return &userv1.GetOrganizationResponse{
+		Organization: domainOrganizationToProto(org),
+	}, nil
+}
+
+func (h *organizationHandler) ListOrganizations(ctx context.Context, req *userv1.ListOrganizationsRequest) (*userv1.ListOrganizationsResponse, error) {
+	if err := requirePermission(ctx, "organizations", "read"); err != nil {
+		return nil, err
+	}
+
+	page, pageSize := normalizePage(req.Page, req.PageSize)
+
+	orgs, total, err := h.orgService.ListOrganizations(ctx, int((page-1)*pageSize), int(pageSize))
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	resp := &userv1.ListOrganizationsResponse{
+		Organizations: make([]*userv1.Organization, len(orgs)),
+		Total:         int32(total),
+		Page:          page,
+		PageSize:      pageSize,
+	}
+	for i := range orgs {
+		resp.Organizations[i] = domainOrganizationToProto(&orgs[i])
+	}
+
+	return resp, nil
+}
+
+func (h *organizationHandler) DeleteOrganization(ctx context.Context, req *userv1.DeleteOrganizationRequest) (*emptypb.Empty, error) {
+	if err := requirePermission(ctx, "organizations", "delete"); err != nil {
+		return nil, err
+	}
+
+	id, err := parseID("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.orgService.DeleteOrganization(ctx, id); err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (h *organizationHandler) AddMember(ctx context.Context, req *userv1.AddMemberRequest) (*emptypb.Empty, error) {
+	if err := requirePermission(ctx, "organizations", "write"); err != nil {
+		return nil, err
+	}
+
+	orgID, userID, err := parseMembership(req.OrganizationId, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.orgService.AddMember(ctx, orgID, userID); err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (h *organizationHandler) RemoveMember(ctx context.Context, req *userv1.RemoveMemberRequest) (*emptypb.Empty, error) {
+	if err := requirePermission(ctx, "organizations", "write"); err != nil {
+		return nil, err
+	}
+
+	orgID, userID, err := parseMembership(req.OrganizationId, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.orgService.RemoveMember(ctx, orgID, userID); err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (h *organizationHandler) ListMembers(ctx context.Context, req *userv1.ListMembersRequest) (*userv1.ListMembersResponse, error) {
+	if err := requirePermission(ctx, "organizations", "read"); err != nil {
+		return nil, err
+	}
+
+	orgID, err := parseID("organization_id", req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	page, pageSize := normalizePage(req.Page, req.PageSize)
+
+	members, total, err := h.orgService.ListMembers(ctx, orgID, int((page-1)*pageSize), int(pageSize))
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	resp := &userv1.ListMembersResponse{
+		Members:  make([]*userv1.OrganizationMember, len(members)),
+		Total:    int32(total),
+		Page:     page,
+		PageSize: pageSize,
+	}
+	for i, m := range members {
+		resp.Members[i] = &userv1.OrganizationMember{
+			OrganizationId: m.OrganizationID.String(),
+			UserId:         m.UserID.String(),
+			JoinedAt:       timestamppb.New(m.JoinedAt),
+		}
+	}
+
+	return resp, nil
+}
+
+func parseMembership(orgIDStr, userIDStr string) (uuid.UUID, uuid.UUID, error) {
+	orgID, err := parseID("organization_id", orgIDStr)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	userID, err := parseID("user_id", userIDStr)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	return orgID, userID, nil
+}
+
+// normalizePage applies the default and maximum page size; pages are 1-based.
+func normalizePage(page, pageSize int32) (int32, int32) {
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	if page <= 0 {
+		page = 1
+	}
+	return page, pageSize
+}
+
+func domainOrganizationToProto(o *domain.Organization) *userv1.Organization {
+	if o == nil {
+		return nil
+	}
+
+	return &userv1.Organization{
+		Id:        o.ID.String(),
+		Name:      o.Name,
+		Slug:      o.Slug,
+		CreatedAt: timestamppb.New(o.CreatedAt),
+		UpdatedAt: timestamppb.New(o.UpdatedAt),
+	}
+}
@@ -2,10 +2,13 @@ package grpc
 
 import (
 	"context"
+	"time"
 
+	"github.com/google/uuid"
 	userv1 "github.com/mvaleed/aegis/api/proto/user/v1"
 	"github.com/mvaleed/aegis/internal/domain"
 	"github.com/mvaleed/aegis/internal/service"
+	"github.com/mvaleed/aegis/internal/storage"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -23,7 +26,16 @@ func (h *rbacHandler) CreateRole(ctx context.Context, req *userv1.CreateRoleRequ
 		return nil, err
 	}
 
-	role, err := h.rbacService.CreateRole(ctx, req.Name, req.Description)
+	var orgID *uuid.UUID
+	if req.OrganizationId != "" {
+		id, err := parseID("organization_id", req.OrganizationId)
+		if err != nil {
+			return nil, err
+		}
+		orgID = &id
+	}
+
+	role, err := h.rbacService.CreateRole(ctx, req.Name, req.Description, orgID)
 	if err != nil {
 		return nil, mapDomainError(err)
 	}
@@ -38,9 +50,106 @@ func (h *rbacHandler) AssignRole(ctx context.Context, req *userv1.AssignRoleRequ
 		return nil, err
 	}
 
-	if err := h.rbacService.AssignRole(ctx, domain.UUIDFromString(req.UserId), domain.UUIDFromString(req.RoleId)); err != nil {
+	userID, err := parseID("user_id", req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	roleID, err := parseID("role_id", req.RoleId)
+	if err != nil {
+		return nil, err
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresAt != nil {
+		t := req.ExpiresAt.AsTime()
+		expiresAt = &t
+	}
+
+	if err := h.rbacService.AssignRole(ctx, userID, roleID, expiresAt); err != nil {
 		return nil, mapDomainError(err)
 	}
 
 	return &emptypb.Empty{}, nil
 }
+
+func (h *rbacHandler) AddPermissionToRole(ctx context.Context, req *userv1.AddPermissionToRoleRequest) (*emptypb.Empty, error) {
+	if err := requirePermission(ctx, "roles", "write"); err != nil {
+		return nil, err
+	}
+
+	roleID, err := parseID("role_id", req.RoleId)
+	if err != nil {
+		return nil, err
+	}
+	permissionID, err := parseID("permission_id", req.PermissionId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.rbacService.AddPermissionToRole(ctx, roleID, permissionID, req.Deny); err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// CheckPermission answers for the caller, or with users:read for anyone.
+// Denials override grants, as they do for the caller's own token. With a
+// resource_id, grants on that instance are consulted too.
+func (h *rbacHandler) CheckPermission(ctx context.Context, req *userv1.CheckPermissionRequest) (*userv1.CheckPermissionResponse, error) {
+	userID, err := parseID("user_id", req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if claims, ok := ClaimsFromContext(ctx); !ok || claims.UserID != userID {
+		if err := requirePermission(ctx, "users", "read"); err != nil {
+			return nil, err
+		}
+	}
+
+	allowed, err := h.rbacService.CheckPermission(ctx, userID, req.Resource, req.ResourceId, req.Action)
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return &userv1.CheckPermissionResponse{HasPermission: allowed}, nil
+}
+
+func (h *rbacHandler) ListPermissions(ctx context.Context, req *userv1.ListPermissionsRequest) (*userv1.ListPermissionsResponse, error) {
+	if err := requirePermission(ctx, "permissions", "read"); err != nil {
+		return nil, err
+	}
+
+	page, pageSize := normalizePage(req.Page, req.PageSize)
+
+	perms, total, err := h.rbacService.ListPermissions(ctx, storage.PermissionFilter{
+		Resource: req.Resource,
+		Search:   req.Search,
+		Offset:   int((page - 1) * pageSize),
+		Limit:    int(pageSize),
+	})
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	resp := &userv1.ListPermissionsResponse{
+		Total:    int32(total),
+		Page:     page,
+		PageSize: pageSize,
+	}
+	if req.GroupByResource {
+		for _, g := range domain.GroupPermissions(perms) {
+			group := &userv1.PermissionGroup{Resource: g.Resource}
+			for i := range g.Permissions {
+				group.Permissions = append(group.Permissions, domainPermissionToProto(&g.Permissions[i]))
+			}
+			resp.Groups = append(resp.Groups, group)
+		}
+	} else {
+		for i := range perms {
+			resp.Permissions = append(resp.Permissions, domainPermissionToProto(&perms[i]))
+		}
+	}
+
+	return resp, nil
+}
@@ -9,68 +9,123 @@ package grpc
 
 import (
 	"context"
+	"crypto/tls"
 	"log/slog"
 	"net"
+	"strings"
 
+	"github.com/google/uuid"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
 
+	userv1 "github.com/mvaleed/aegis/api/proto/user/v1"
 	"github.com/mvaleed/aegis/internal/auth"
+	"github.com/mvaleed/aegis/internal/authz"
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
 	"github.com/mvaleed/aegis/internal/service"
 )
 
 // Server wraps the gRPC server with dependencies
 type Server struct {
-	grpcServer  *grpc.Server
-	userService *service.UserService
-	authService *service.AuthService
-	rbacService *service.RBACService
-	jwtManager  *auth.JWTManager
-	logger      *slog.Logger
+	grpcServer         *grpc.Server
+	userService        *service.UserService
+	authService        *service.AuthService
+	rbacService        *service.RBACService
+	idempotencyService *service.IdempotencyService
+	shadow             *authz.Shadow // Nil unless a shadow policy is set
+	eventBus           *event.Bus
+	jwtManager         *auth.JWTManager
+	logger             *slog.Logger
+	sandboxEnabled     bool
+
+	gateway *bufconn.Listener // Served alongside the listener; see NewGateway
 }
 
-// NewServer creates a new gRPC server with all handlers registered
+// NewServer creates a new gRPC server with all handlers registered. It
+// serves TLS if tlsConfig isn't nil.
 func NewServer(
 	userService *service.UserService,
 	authService *service.AuthService,
 	rbacService *service.RBACService,
+	orgService *service.OrganizationService,
+	groupService *service.GroupService,
+	idempotencyService *service.IdempotencyService,
+	attributeService *service.AttributeService,
+	consentService *service.ConsentService,
+	shadow *authz.Shadow,
+	eventBus *event.Bus,
 	jwtManager *auth.JWTManager,
+	sandboxEnabled bool,
+	tlsConfig *tls.Config,
 	logger *slog.Logger,
 ) *Server {
 	s := &Server{
-		userService: userService,
-		authService: authService,
-		rbacService: rbacService,
-		jwtManager:  jwtManager,
-		logger:      logger,
+		userService:        userService,
+		authService:        authService,
+		rbacService:        rbacService,
+		idempotencyService: idempotencyService,
+		shadow:             shadow,
+		eventBus:           eventBus,
+		jwtManager:         jwtManager,
+		logger:             logger,
+		sandboxEnabled:     sandboxEnabled,
+		gateway:            bufconn.Listen(gatewayBufferSize),
 	}
 
 	// Create gRPC server with interceptors
-	grpcServer := grpc.NewServer(
+	opts := []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
 		grpc.ChainUnaryInterceptor(
+			s.deadlineInterceptor,
+			s.correlationInterceptor,
 			s.loggingInterceptor,
 			s.recoveryInterceptor,
 			s.authInterceptor,
+			s.validationInterceptor,
+			s.fieldFilterInterceptor,
+			s.idempotencyInterceptor,
 		),
-	)
+		grpc.ChainStreamInterceptor(
+			s.streamDeadlineInterceptor,
+			s.streamLoggingInterceptor,
+			s.streamRecoveryInterceptor,
+			s.streamAuthInterceptor,
+			s.streamValidationInterceptor,
+			s.streamFieldFilterInterceptor,
+		),
+	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	grpcServer := grpc.NewServer(opts...)
 
 	// Register service handlers
-	// NOTE: After running `buf generate`, uncomment these lines and import
-	// the generated package:
-	//   userv1 "user-service/api/proto/user/v1"
-	//
-	// userv1.RegisterUserServiceServer(grpcServer, NewUserHandler(s))
-	// userv1.RegisterAuthServiceServer(grpcServer, NewAuthHandler(s))
-	// userv1.RegisterRBACServiceServer(grpcServer, NewRBACHandler(s))
+	userv1.RegisterUserServiceServer(grpcServer, NewUserHandler(userService, attributeService, consentService))
+	userv1.RegisterAuthServiceServer(grpcServer, NewAuthHandler(authService, userService))
+	userv1.RegisterRBACServiceServer(grpcServer, NewRBACHandler(rbacService))
+	userv1.RegisterOrganizationServiceServer(grpcServer, NewOrganizationHandler(orgService))
+	userv1.RegisterGroupServiceServer(grpcServer, NewGroupHandler(groupService))
+	userv1.RegisterEventServiceServer(grpcServer, NewEventHandler(eventBus))
 
 	s.grpcServer = grpcServer
 	return s
 }
 
-// Serve starts the gRPC server on the given listener
+// gatewayBufferSize is how many bytes the gateway's in-process
+// connections buffer in each direction.
+const gatewayBufferSize = 1 << 20
+
+// Serve starts the gRPC server on the given listener, and for the gateway
 func (s *Server) Serve(listener net.Listener) error {
+	go func() { _ = s.grpcServer.Serve(gatewayListener{s.gateway}) }()
 	return s.grpcServer.Serve(listener)
 }
 
@@ -79,6 +134,27 @@ func (s *Server) GracefulStop() {
 	s.grpcServer.GracefulStop()
 }
 
+// correlationInterceptor sets the correlation ID of events published while
+// handling the call: the caller's x-request-id, or a new one.
+func (s *Server) correlationInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("x-request-id"); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	if id == "" {
+		id = uuid.NewString()
+	}
+
+	return handler(event.WithCorrelationID(ctx, id), req)
+}
+
 // loggingInterceptor logs all incoming requests
 func (s *Server) loggingInterceptor(
 	ctx context.Context,
@@ -128,13 +204,35 @@ func (s *Server) authInterceptor(
 	info *grpc.UnaryServerInfo,
 	handler grpc.UnaryHandler,
 ) (interface{}, error) {
+	ctx, err := s.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// authenticate validates the bearer token in the incoming metadata and
+// returns a context carrying the claims and the token's environment.
+// Public methods pass through, in the environment named by the
+// x-environment metadata.
+func (s *Server) authenticate(ctx context.Context, method string) (context.Context, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+
 	// Skip auth for public endpoints
-	if isPublicMethod(info.FullMethod) {
-		return handler(ctx, req)
+	if isPublicMethod(method) {
+		var name string
+		if values := md.Get("x-environment"); len(values) > 0 {
+			name = values[0]
+		}
+		env, valid := domain.ParseEnvironment(name)
+		if !valid {
+			return nil, status.Error(codes.InvalidArgument, "x-environment must be production or sandbox")
+		}
+		return s.withEnvironment(ctx, env)
 	}
 
 	// Extract token from metadata
-	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
 		return nil, status.Error(codes.Unauthenticated, "missing metadata")
 	}
@@ -150,27 +248,160 @@ func (s *Server) authInterceptor(
 		token = token[7:]
 	}
 
-	// Validate token
-	claims, err := s.jwtManager.ValidateAccessToken(token)
+	// Validate token; impersonation tokens also need an active session
+	claims, err := s.authService.ValidateToken(ctx, token)
 	if err != nil {
 		return nil, status.Error(codes.Unauthenticated, "invalid token")
 	}
 
+	if claims.IsImpersonation() {
+		s.logger.Info("impersonated request",
+			"method", method,
+			"user_id", claims.UserID.String(),
+			"actor_id", claims.Actor.UserID.String(),
+			"session_id", claims.Actor.SessionID.String(),
+		)
+
+		if ownerOnlyMethods[method] {
+			return nil, status.Error(codes.PermissionDenied, "not allowed while impersonating")
+		}
+	}
+
+	env, _ := domain.ParseEnvironment(claims.Environment)
+	ctx, err = s.withEnvironment(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+
+	// Profiles don't act through the user's roles, so their decisions
+	// aren't shadowed
+	if s.shadow != nil && claims.Profile == nil {
+		ctx = context.WithValue(ctx, shadowKey{}, s.shadow)
+	}
+
 	// Add claims to context
-	ctx = context.WithValue(ctx, claimsKey{}, claims)
+	return context.WithValue(ctx, claimsKey{}, claims), nil
+}
 
-	return handler(ctx, req)
+// withEnvironment returns ctx working in env, unless env is the sandbox and
+// it's disabled.
+func (s *Server) withEnvironment(ctx context.Context, env domain.Environment) (context.Context, error) {
+	if env.IsSandbox() && !s.sandboxEnabled {
+		return nil, status.Error(codes.PermissionDenied, "the sandbox is disabled")
+	}
+	return domain.WithEnvironment(ctx, env), nil
+}
+
+// streamLoggingInterceptor logs streaming calls when they finish
+func (s *Server) streamLoggingInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	s.logger.Info("gRPC stream",
+		"method", info.FullMethod,
+	)
+
+	err := handler(srv, ss)
+	if err != nil {
+		s.logger.Error("gRPC stream failed",
+			"method", info.FullMethod,
+			"error", err,
+		)
+	}
+
+	return err
+}
+
+// streamRecoveryInterceptor recovers from panics in streaming handlers
+func (s *Server) streamRecoveryInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("gRPC panic recovered",
+				"method", info.FullMethod,
+				"panic", r,
+			)
+			err = status.Error(codes.Internal, "internal server error")
+		}
+	}()
+
+	return handler(srv, ss)
+}
+
+// streamAuthInterceptor validates JWT tokens for streaming endpoints
+func (s *Server) streamAuthInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx, err := s.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// contextStream overrides the context of a grpc.ServerStream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
 }
 
 // claimsKey is the context key for JWT claims
 type claimsKey struct{}
 
+// shadowKey is the context key for the *authz.Shadow that requirePermission
+// reports decisions to.
+type shadowKey struct{}
+
 // ClaimsFromContext extracts JWT claims from the context
 func ClaimsFromContext(ctx context.Context) (*auth.Claims, bool) {
 	claims, ok := ctx.Value(claimsKey{}).(*auth.Claims)
 	return claims, ok
 }
 
+// clientInfo returns the caller's IP address and user agent, as the HTTP
+// handlers record them. Through the gateway, they're the HTTP client's: the
+// first X-Forwarded-For address and the forwarded User-Agent. Other callers
+// get their connection's address, whatever metadata they send.
+func clientInfo(ctx context.Context) (ipAddress, userAgent string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if fromGateway(ctx) {
+		if xff := md.Get("x-forwarded-for"); len(xff) > 0 {
+			first, _, _ := strings.Cut(xff[0], ",")
+			ipAddress = strings.TrimSpace(first)
+		}
+		if ua := md.Get(runtime.MetadataPrefix + "user-agent"); len(ua) > 0 {
+			userAgent = ua[0]
+		}
+		return ipAddress, userAgent
+	}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		ipAddress = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ipAddress); err == nil {
+			ipAddress = host
+		}
+	}
+	if ua := md.Get("user-agent"); len(ua) > 0 {
+		userAgent = ua[0]
+	}
+	return ipAddress, userAgent
+}
+
 // isPublicMethod returns true if the method doesn't require authentication
 func isPublicMethod(method string) bool {
 	publicMethods := map[string]bool{
@@ -181,19 +412,94 @@ func isPublicMethod(method string) bool {
 	return publicMethods[method]
 }
 
+// ownerOnlyMethods can't be called with an impersonation token
+var ownerOnlyMethods = map[string]bool{
+	"/user.v1.UserService/ChangePassword": true,
+	"/user.v1.AuthService/LogoutAll":      true,
+}
+
+// requireConsent lets partners act on targetID only within that user's
+// consent for resource:action. Everyone else, and partners acting on
+// themselves, pass.
+func requireConsent(ctx context.Context, consents *service.ConsentService, targetID uuid.UUID, resource, action string) error {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "not authenticated")
+	}
+	if claims.UserType != string(domain.UserTypePartner) || claims.UserID == targetID {
+		return nil
+	}
+
+	allowed, err := consents.Allows(ctx, targetID, claims.UserID, resource+":"+action)
+	if err != nil {
+		return mapDomainError(err)
+	}
+	if !allowed {
+		return status.Error(codes.PermissionDenied, "the user hasn't consented to this access")
+	}
+	return nil
+}
+
+// denyPartner rejects partners, for calls that would reach users regardless
+// of their consent.
+func denyPartner(ctx context.Context) error {
+	if claims, ok := ClaimsFromContext(ctx); ok && claims.UserType == string(domain.UserTypePartner) {
+		return status.Error(codes.PermissionDenied, "not available to partners")
+	}
+	return nil
+}
+
 // requirePermission checks if the current user has the required permission
+// and none of their roles deny it, reporting the decision to the shadow
+// policy if there is one
 func requirePermission(ctx context.Context, resource, action string) error {
+	return requireAnyPermission(ctx, resource, action)
+}
+
+// requireAnyPermission checks for any of the actions on resource, such as
+// users:read or users:read_basic, whose responses fieldFilterInterceptor
+// trims.
+func requireAnyPermission(ctx context.Context, resource string, actions ...string) error {
 	claims, ok := ClaimsFromContext(ctx)
 	if !ok {
 		return status.Error(codes.Unauthenticated, "not authenticated")
 	}
 
+	action, allowed := actions[0], false
+	for _, a := range actions {
+		if hasPermission(claims, resource, a) {
+			action, allowed = a, true
+			break
+		}
+	}
+	if shadow, ok := ctx.Value(shadowKey{}).(*authz.Shadow); ok {
+		method, _ := grpc.Method(ctx)
+		shadow.Observe(ctx, authz.Decision{
+			UserID:   claims.UserID,
+			Resource: resource,
+			Action:   action,
+			Allowed:  allowed,
+			Source:   "grpc " + method,
+		})
+	}
+
+	if !allowed {
+		return status.Error(codes.PermissionDenied, "permission denied")
+	}
+	return nil
+}
+
+func hasPermission(claims *auth.Claims, resource, action string) bool {
 	requiredPerm := resource + ":" + action
+	for _, perm := range claims.Denied {
+		if perm == requiredPerm || perm == "*:*" || perm == resource+":*" || perm == "*:"+action {
+			return false
+		}
+	}
 	for _, perm := range claims.Permissions {
 		if perm == requiredPerm || perm == "*:*" || perm == resource+":*" {
-			return nil
+			return true
 		}
 	}
-
-	return status.Error(codes.PermissionDenied, "permission denied")
+	return false
 }
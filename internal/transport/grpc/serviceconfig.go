@@ -0,0 +1,258 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// methodPolicy describes the call policy for a set of methods of one service.
+// The same table drives the published client service config and the
+// server-side deadline caps, so the two can't drift apart.
+type methodPolicy struct {
+	service string
+	methods []string // Empty applies to every method of the service not listed elsewhere
+
+	timeout     time.Duration // Default client timeout, published in the service config
+	maxDeadline time.Duration // Server-side cap on the caller's deadline; 0 leaves it alone
+
+	retry bool // Retry UNAVAILABLE with backoff; idempotent methods only
+	hedge bool // Send hedged attempts; cheap, latency-sensitive reads only
+}
+
+const protoPackage = "user.v1"
+
+var methodPolicies = []methodPolicy{
+	// Service defaults: no retries, since a mutation may have been applied
+	// before the failure was observed.
+	{service: "UserService", timeout: 10 * time.Second, maxDeadline: 30 * time.Second},
+	{service: "AuthService", timeout: 10 * time.Second, maxDeadline: 30 * time.Second},
+	{service: "RBACService", timeout: 10 * time.Second, maxDeadline: 30 * time.Second},
+	{service: "OrganizationService", timeout: 10 * time.Second, maxDeadline: 30 * time.Second},
+	{service: "GroupService", timeout: 10 * time.Second, maxDeadline: 30 * time.Second},
+
+	// Reads
+	{service: "UserService", methods: []string{"GetUser", "GetUserByEmail", "ListUsers"},
+		timeout: 5 * time.Second, maxDeadline: 15 * time.Second, retry: true},
+	{service: "RBACService", methods: []string{"GetRole", "ListRoles", "ListPermissions"},
+		timeout: 5 * time.Second, maxDeadline: 15 * time.Second, retry: true},
+	{service: "OrganizationService", methods: []string{"GetOrganization", "ListOrganizations", "ListMembers"},
+		timeout: 5 * time.Second, maxDeadline: 15 * time.Second, retry: true},
+	{service: "GroupService", methods: []string{"GetGroup", "ListGroups", "ListGroupMembers"},
+		timeout: 5 * time.Second, maxDeadline: 15 * time.Second, retry: true},
+
+	// Hot-path authorization checks
+	{service: "AuthService", methods: []string{"ValidateToken"},
+		timeout: 2 * time.Second, maxDeadline: 5 * time.Second, hedge: true},
+	{service: "RBACService", methods: []string{"CheckPermission"},
+		timeout: 2 * time.Second, maxDeadline: 5 * time.Second, hedge: true},
+
+	// Creates honor the idempotency-key metadata; retries resend it, so a
+	// create that was applied before the failure is replayed, not repeated.
+	{service: "UserService", methods: []string{"CreateUser"},
+		timeout: 10 * time.Second, maxDeadline: 30 * time.Second, retry: true},
+	{service: "RBACService", methods: []string{"CreateRole"},
+		timeout: 10 * time.Second, maxDeadline: 30 * time.Second, retry: true},
+
+	// Mutations that are idempotent in storage (assignments, memberships,
+	// state transitions) and can be retried safely.
+	{service: "UserService", methods: []string{"ActivateUser", "VerifyEmail", "VerifyPhone"},
+		timeout: 10 * time.Second, maxDeadline: 30 * time.Second, retry: true},
+	{service: "RBACService", methods: []string{"AssignRole", "RemoveRole", "AddPermissionToRole", "RemovePermissionFromRole"},
+		timeout: 10 * time.Second, maxDeadline: 30 * time.Second, retry: true},
+	{service: "OrganizationService", methods: []string{"AddMember", "RemoveMember"},
+		timeout: 10 * time.Second, maxDeadline: 30 * time.Second, retry: true},
+	{service: "GroupService", methods: []string{"AddGroupMember", "RemoveGroupMember", "AssignGroupRole", "RemoveGroupRole"},
+		timeout: 10 * time.Second, maxDeadline: 30 * time.Second, retry: true},
+
+	// Streams
+	{service: "UserService", methods: []string{"StreamUsers"},
+		timeout: 5 * time.Minute, maxDeadline: 10 * time.Minute},
+	{service: "EventService", methods: []string{"Subscribe"}}, // Long-lived; no timeout
+}
+
+// Service config JSON types (see https://github.com/grpc/grpc/blob/master/doc/service_config.md)
+
+type serviceConfig struct {
+	MethodConfig    []methodConfig   `json:"methodConfig"`
+	RetryThrottling *retryThrottling `json:"retryThrottling,omitempty"`
+}
+
+type methodConfig struct {
+	Name          []methodName   `json:"name"`
+	Timeout       string         `json:"timeout,omitempty"`
+	RetryPolicy   *retryPolicy   `json:"retryPolicy,omitempty"`
+	HedgingPolicy *hedgingPolicy `json:"hedgingPolicy,omitempty"`
+}
+
+type methodName struct {
+	Service string `json:"service"`
+	Method  string `json:"method,omitempty"`
+}
+
+type retryPolicy struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+type hedgingPolicy struct {
+	MaxAttempts         int      `json:"maxAttempts"`
+	HedgingDelay        string   `json:"hedgingDelay"`
+	NonFatalStatusCodes []string `json:"nonFatalStatusCodes"`
+}
+
+type retryThrottling struct {
+	MaxTokens  int     `json:"maxTokens"`
+	TokenRatio float64 `json:"tokenRatio"`
+}
+
+// serviceConfigJSON is built once from methodPolicies.
+var serviceConfigJSON = buildServiceConfig()
+
+// ServiceConfig returns the gRPC service config clients should dial with,
+// e.g. grpc.WithDefaultServiceConfig(ServiceConfig()).
+func ServiceConfig() string {
+	return serviceConfigJSON
+}
+
+// ServiceConfigHandler serves the service config so SDKs can fetch it.
+func ServiceConfigHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		_, _ = w.Write([]byte(serviceConfigJSON))
+	})
+}
+
+func buildServiceConfig() string {
+	cfg := serviceConfig{
+		// Stop retrying when more than half the recent calls failed, so
+		// retries can't amplify an outage.
+		RetryThrottling: &retryThrottling{MaxTokens: 10, TokenRatio: 0.1},
+	}
+
+	for _, p := range methodPolicies {
+		mc := methodConfig{}
+
+		service := protoPackage + "." + p.service
+		if len(p.methods) == 0 {
+			mc.Name = []methodName{{Service: service}}
+		}
+		for _, m := range p.methods {
+			mc.Name = append(mc.Name, methodName{Service: service, Method: m})
+		}
+
+		if p.timeout > 0 {
+			mc.Timeout = durationJSON(p.timeout)
+		}
+
+		if p.retry {
+			mc.RetryPolicy = &retryPolicy{
+				MaxAttempts:          4,
+				InitialBackoff:       "0.1s",
+				MaxBackoff:           "1s",
+				BackoffMultiplier:    2,
+				RetryableStatusCodes: []string{"UNAVAILABLE"},
+			}
+		}
+
+		if p.hedge {
+			mc.HedgingPolicy = &hedgingPolicy{
+				MaxAttempts:         3,
+				HedgingDelay:        "0.1s",
+				NonFatalStatusCodes: []string{"UNAVAILABLE"},
+			}
+		}
+
+		cfg.MethodConfig = append(cfg.MethodConfig, mc)
+	}
+
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		panic("grpc: marshal service config: " + err.Error())
+	}
+	return string(b)
+}
+
+// durationJSON formats d as a protobuf JSON duration ("1.5s").
+func durationJSON(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
+
+// maxDeadlines maps "/user.v1.Service/Method" (or "/user.v1.Service/" for
+// the service default) to its server-side deadline cap.
+var maxDeadlines = buildMaxDeadlines()
+
+func buildMaxDeadlines() map[string]time.Duration {
+	m := make(map[string]time.Duration)
+	for _, p := range methodPolicies {
+		prefix := "/" + protoPackage + "." + p.service + "/"
+		if len(p.methods) == 0 {
+			m[prefix] = p.maxDeadline
+		}
+		for _, method := range p.methods {
+			m[prefix+method] = p.maxDeadline
+		}
+	}
+	return m
+}
+
+// maxDeadlineFor returns the deadline cap for a full method name.
+func maxDeadlineFor(fullMethod string) time.Duration {
+	if d, ok := maxDeadlines[fullMethod]; ok {
+		return d
+	}
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return maxDeadlines[fullMethod[:i+1]]
+	}
+	return 0
+}
+
+// capDeadline shortens the context deadline to the method's cap. Callers
+// without a deadline get the cap as their deadline.
+func capDeadline(ctx context.Context, fullMethod string) (context.Context, context.CancelFunc) {
+	limit := maxDeadlineFor(fullMethod)
+	if limit <= 0 {
+		return ctx, func() {}
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= limit {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, limit)
+}
+
+// deadlineInterceptor enforces the per-method maximum deadline
+func (s *Server) deadlineInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	ctx, cancel := capDeadline(ctx, info.FullMethod)
+	defer cancel()
+
+	return handler(ctx, req)
+}
+
+// streamDeadlineInterceptor enforces the per-method maximum deadline for streams
+func (s *Server) streamDeadlineInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx, cancel := capDeadline(ss.Context(), info.FullMethod)
+	defer cancel()
+
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
@@ -2,20 +2,30 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	userv1 "github.com/mvaleed/aegis/api/proto/user/v1"
 	"github.com/mvaleed/aegis/internal/domain"
 	"github.com/mvaleed/aegis/internal/service"
+	"github.com/mvaleed/aegis/internal/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type userHandler struct {
 	userv1.UnimplementedUserServiceServer
-	userService *service.UserService
+	userService      *service.UserService
+	attributeService *service.AttributeService
+	consentService   *service.ConsentService
 }
 
-func NewUserHandler(userService *service.UserService) userv1.UserServiceServer {
-	return &userHandler{userService: userService}
+func NewUserHandler(userService *service.UserService, attributeService *service.AttributeService, consentService *service.ConsentService) userv1.UserServiceServer {
+	return &userHandler{userService: userService, attributeService: attributeService, consentService: consentService}
 }
 
 func (h *userHandler) CreateUser(ctx context.Context, req *userv1.CreateUserRequest) (*userv1.CreateUserResponse, error) {
@@ -26,6 +36,8 @@ func (h *userHandler) CreateUser(ctx context.Context, req *userv1.CreateUserRequ
 		FullName: req.FullName,
 		Phone:    req.Phone,
 		UserType: mapProtoUserType(req.UserType),
+		Locale:   req.Locale,
+		Timezone: req.Timezone,
 	})
 	if err != nil {
 		return nil, mapDomainError(err)
@@ -37,16 +49,175 @@ func (h *userHandler) CreateUser(ctx context.Context, req *userv1.CreateUserRequ
 }
 
 func (h *userHandler) GetUser(ctx context.Context, req *userv1.GetUserRequest) (*userv1.GetUserResponse, error) {
-	user, err := h.userService.GetUser(ctx, domain.UUIDFromString(req.Id))
+	id, err := parseID("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := requireConsent(ctx, h.consentService, id, "users", "read"); err != nil {
+		return nil, err
+	}
+
+	fields, err := readMaskFields(req.ReadMask, (&userv1.User{}).ProtoReflect().Descriptor())
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := h.userService.GetUserFields(ctx, id, fields)
 	if err != nil {
 		return nil, mapDomainError(err)
 	}
 
-	return &userv1.GetUserResponse{
+	pb := domainUserToProto(user)
+	applyReadMask(pb.ProtoReflect(), fields)
+	return &userv1.GetUserResponse{User: pb}, nil
+}
+
+func (h *userHandler) UpdateUser(ctx context.Context, req *userv1.UpdateUserRequest) (*userv1.UpdateUserResponse, error) {
+	if err := requirePermission(ctx, "users", "write"); err != nil {
+		return nil, err
+	}
+
+	id, err := parseID("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := requireConsent(ctx, h.consentService, id, "users", "write"); err != nil {
+		return nil, err
+	}
+
+	input, err := updateUserInput(req)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := h.userService.UpdateUser(ctx, id, input)
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return &userv1.UpdateUserResponse{
 		User: domainUserToProto(user),
 	}, nil
 }
 
+// ChangePassword changes the caller's own password; nobody can change
+// another user's this way, admins included.
+func (h *userHandler) ChangePassword(ctx context.Context, req *userv1.ChangePasswordRequest) (*emptypb.Empty, error) {
+	userID, err := parseID("user_id", req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if claims, ok := ClaimsFromContext(ctx); !ok || claims.UserID != userID {
+		return nil, status.Error(codes.PermissionDenied, "can only change your own password")
+	}
+
+	ipAddress, userAgent := clientInfo(ctx)
+	if err := h.userService.ChangePassword(ctx, service.ChangePasswordInput{
+		UserID:          userID,
+		CurrentPassword: req.CurrentPassword,
+		NewPassword:     req.NewPassword,
+		IPAddress:       ipAddress,
+		UserAgent:       userAgent,
+	}); err != nil {
+		return nil, mapDomainError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// updateUserInput maps an UpdateUserRequest onto set, clear or ignore for
+// each field: with an update mask, listed fields are set if present and
+// cleared if not, and unlisted ones ignored; without one, present fields
+// are set.
+func updateUserInput(req *userv1.UpdateUserRequest) (service.UpdateUserInput, error) {
+	if len(req.GetUpdateMask().GetPaths()) == 0 {
+		return service.UpdateUserInput{
+			Username: presentField(req.Username),
+			FullName: presentField(req.FullName),
+			Phone:    presentField(req.Phone),
+			Locale:   presentField(req.Locale),
+			Timezone: presentField(req.Timezone),
+		}, nil
+	}
+
+	var input service.UpdateUserInput
+	for _, path := range req.UpdateMask.Paths {
+		switch path {
+		case "username":
+			input.Username = maskedField(req.Username)
+		case "full_name":
+			input.FullName = maskedField(req.FullName)
+		case "phone":
+			input.Phone = maskedField(req.Phone)
+		case "locale":
+			input.Locale = maskedField(req.Locale)
+		case "timezone":
+			input.Timezone = maskedField(req.Timezone)
+		default:
+			return service.UpdateUserInput{}, invalidArgument(domain.ValidationError{Field: "update_mask", Message: fmt.Sprintf("unknown field %q", path)})
+		}
+	}
+	return input, nil
+}
+
+func presentField(v *string) service.Field[string] {
+	if v == nil {
+		return service.Field[string]{}
+	}
+	return service.Set(*v)
+}
+
+func maskedField(v *string) service.Field[string] {
+	if v == nil {
+		return service.Clear[string]()
+	}
+	return service.Set(*v)
+}
+
+func (h *userHandler) StreamUsers(req *userv1.StreamUsersRequest, stream grpc.ServerStreamingServer[userv1.User]) error {
+	ctx := stream.Context()
+	if err := requireAnyPermission(ctx, "users", "read", "read_basic"); err != nil {
+		return err
+	}
+	if err := denyPartner(ctx); err != nil {
+		return err
+	}
+
+	filter := storage.UserFilter{Search: req.Search, Locale: req.Locale, Timezone: req.Timezone}
+	// UNSPECIFIED, like leaving the field out, doesn't filter
+	if req.UserType != nil && *req.UserType != userv1.UserType_USER_TYPE_UNSPECIFIED {
+		ut := mapProtoUserType(*req.UserType)
+		filter.Type = &ut
+	}
+	if req.Status != nil && *req.Status != userv1.UserStatus_USER_STATUS_UNSPECIFIED {
+		st := mapProtoUserStatus(*req.Status)
+		filter.Status = &st
+	}
+	if req.OrganizationId != "" {
+		orgID, err := parseID("organization_id", req.OrganizationId)
+		if err != nil {
+			return err
+		}
+		filter.OrganizationID = &orgID
+	}
+
+	attrs, err := h.attributeService.ParseFilter(ctx, req.Attributes)
+	if err != nil {
+		var validationErr domain.ValidationError
+		if errors.As(err, &validationErr) {
+			return invalidArgument(err)
+		}
+		return mapDomainError(err)
+	}
+	filter.Attributes = attrs
+
+	err = h.userService.StreamUsers(ctx, filter, int(req.BatchSize), func(u *domain.User) error {
+		return stream.Send(domainUserToProto(u))
+	})
+	return mapDomainError(err)
+}
+
 // ... implement remaining methods similarly
 
 // Helper functions for type conversion:
@@ -61,12 +232,20 @@ func domainUserToProto(u *domain.User) *userv1.User {
 		roles[i] = domainRoleToProto(&r)
 	}
 
+	var phone string
+	if u.Phone != nil {
+		phone = *u.Phone
+	}
+
+	// Attributes only hold JSON values, so conversion can't fail
+	attributes, _ := structpb.NewStruct(u.Attributes)
+
 	return &userv1.User{
 		Id:            u.ID.String(),
 		Email:         u.Email,
 		Username:      u.Username,
 		FullName:      u.FullName,
-		Phone:         *u.Phone,
+		Phone:         phone,
 		UserType:      userTypeToProto(u.UserType),
 		Status:        userStatusToProto(u.Status),
 		EmailVerified: u.EmailVerified,
@@ -74,6 +253,9 @@ func domainUserToProto(u *domain.User) *userv1.User {
 		CreatedAt:     timestamppb.New(u.CreatedAt),
 		UpdatedAt:     timestamppb.New(u.UpdatedAt),
 		Roles:         roles,
+		Attributes:    attributes,
+		Locale:        u.Locale,
+		Timezone:      u.Timezone,
 	}
 }
 
@@ -87,12 +269,18 @@ func domainRoleToProto(r *domain.Role) *userv1.Role {
 		permissions[i] = domainPermissionToProto(&p)
 	}
 
+	var orgID string
+	if r.OrganizationID != nil {
+		orgID = r.OrganizationID.String()
+	}
+
 	return &userv1.Role{
-		Id:          r.ID.String(),
-		Name:        r.Name,
-		Description: r.Description,
-		Permissions: permissions,
-		CreatedAt:   timestamppb.New(r.CreatedAt),
+		Id:             r.ID.String(),
+		Name:           r.Name,
+		Description:    r.Description,
+		Permissions:    permissions,
+		CreatedAt:      timestamppb.New(r.CreatedAt),
+		OrganizationId: orgID,
 	}
 }
 
@@ -106,6 +294,7 @@ func domainPermissionToProto(p *domain.Permission) *userv1.Permission {
 		Resource:    p.Resource,
 		Action:      p.Action,
 		Description: p.Description,
+		Deny:        p.Deny,
 	}
 }
 
@@ -135,6 +324,21 @@ func mapProtoUserType(ut userv1.UserType) domain.UserType {
 	}
 }
 
+func mapProtoUserStatus(us userv1.UserStatus) domain.UserStatus {
+	switch us {
+	case userv1.UserStatus_USER_STATUS_PENDING:
+		return domain.UserStatusPending
+	case userv1.UserStatus_USER_STATUS_ACTIVE:
+		return domain.UserStatusActive
+	case userv1.UserStatus_USER_STATUS_INACTIVE:
+		return domain.UserStatusInactive
+	case userv1.UserStatus_USER_STATUS_SUSPENDED:
+		return domain.UserStatusSuspended
+	default:
+		return ""
+	}
+}
+
 func userStatusToProto(us domain.UserStatus) userv1.UserStatus {
 	switch us {
 	case domain.UserStatusPending:
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/mvaleed/aegis/internal/cache"
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// Namespaces of the cached read routes
+const (
+	cacheRoles       = "roles"
+	cachePermissions = "permissions"
+)
+
+// CacheInvalidations lists the events that change each cached namespace.
+// Role responses carry the roles' permissions and assignment counts, so
+// they change with assignments and deleted permissions too. Group role
+// assignments publish no event and show once the TTL runs out.
+var CacheInvalidations = cache.Invalidations{
+	domain.EventRoleCreated:       {cacheRoles},
+	domain.EventRoleUpdated:       {cacheRoles},
+	domain.EventRoleDeleted:       {cacheRoles},
+	domain.EventUserRoleAssigned:  {cacheRoles},
+	domain.EventUserRoleRemoved:   {cacheRoles},
+	domain.EventUserRoleExpired:   {cacheRoles},
+	domain.EventUserDeleted:       {cacheRoles},
+	domain.EventPermissionCreated: {cachePermissions},
+	domain.EventPermissionDeleted: {cachePermissions, cacheRoles},
+}
+
+// cached serves GET responses from the response cache, when one is
+// configured, storing successful ones in namespace by path and query. It
+// must come after the route's permission checks, since a stored response
+// is served to every caller reaching it. Responses carry an ETag, which
+// If-None-Match is answered with 304 for, and let the client reuse them
+// for the cache's TTL. A failing cache is bypassed.
+func (s *Server) cached(namespace string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if s.responseCache == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx := r.Context()
+
+			key, err := s.responseCache.Key(ctx, namespace, r.URL.Path+"?"+r.URL.Query().Encode())
+			if err != nil {
+				s.logger.Warn("response cache unavailable", slog.String("error", err.Error()))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, ok, err := s.responseCache.Get(ctx, key)
+			if err != nil {
+				s.logger.Warn("response cache read failed", slog.String("error", err.Error()))
+			}
+			if ok {
+				s.writeCached(w, r, body, "HIT")
+				return
+			}
+
+			rec := &responseRecorder{header: make(http.Header), request: requestOf(w)}
+			next.ServeHTTP(rec, r)
+			if rec.status != http.StatusOK {
+				rec.flush(w)
+				return
+			}
+
+			body = rec.body.Bytes()
+			if err := s.responseCache.Set(ctx, key, body); err != nil {
+				s.logger.Warn("response cache write failed", slog.String("error", err.Error()))
+			}
+			s.writeCached(w, r, body, "MISS")
+		})
+	}
+}
+
+// writeCached sends a cached JSON body, or 304 if the client has it.
+func (s *Server) writeCached(w http.ResponseWriter, r *http.Request, body []byte, result string) {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	h := w.Header()
+	h.Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(s.responseCache.TTL().Seconds())))
+	h.Set("ETag", etag)
+	h.Set("X-Cache", result)
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	h.Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
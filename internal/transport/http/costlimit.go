@@ -0,0 +1,177 @@
+package http
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Request costs for expensive endpoints. A caller's quota is a token bucket
+// of CostQuotaCapacity units refilled at CostQuotaRefillPerMinute; each
+// request spends its cost up front.
+const (
+	costList       = 2  // Paginated listing without free-text search
+	costSearch     = 10 // Free-text search (LIKE scans)
+	costHistory    = 5  // Point-in-time reads over history tables
+	costStream     = 20 // Long-lived streams and full exports
+	costBulkMember = 3  // Membership changes that cascade
+	costOutbound   = 10 // Requests that wait on a third party, such as webhook test sends
+	costCodeEntry  = 5  // Short codes users type, which could otherwise be guessed
+)
+
+// costFunc computes the cost of a request.
+type costFunc func(r *http.Request) int
+
+func fixedCost(n int) costFunc {
+	return func(*http.Request) int { return n }
+}
+
+// searchCost charges costSearch when the request carries a search term.
+func searchCost(r *http.Request) int {
+	if r.URL.Query().Get("search") != "" {
+		return costSearch
+	}
+	return costList
+}
+
+// costLimiter tracks per-caller cost budgets, keyed by user ID or, for
+// anonymous callers, by client IP.
+type costLimiter struct {
+	logger *slog.Logger
+
+	mu        sync.Mutex
+	capacity  float64
+	refill    float64 // Units per second
+	enforce   bool    // When false, over-quota requests are logged but served
+	buckets   map[string]*costBucket
+	lastSweep time.Time
+}
+
+type costBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newCostLimiter(capacity, refillPerMinute int, enforce bool, logger *slog.Logger) *costLimiter {
+	l := &costLimiter{
+		logger:    logger,
+		buckets:   make(map[string]*costBucket),
+		lastSweep: time.Now(),
+	}
+	l.setLimits(capacity, refillPerMinute, enforce)
+	return l
+}
+
+// setLimits changes the bucket size, refill rate and enforcement. Existing
+// buckets keep their balance, capped at the new capacity on their next
+// request.
+func (l *costLimiter) setLimits(capacity, refillPerMinute int, enforce bool) {
+	if refillPerMinute <= 0 {
+		refillPerMinute = 1
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.capacity = float64(capacity)
+	l.refill = float64(refillPerMinute) / 60
+	l.enforce = enforce
+}
+
+// limits returns the current capacity and whether it is enforced.
+func (l *costLimiter) limits() (capacity int, enforce bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.capacity), l.enforce
+}
+
+// take spends cost from the caller's bucket. It returns the remaining budget
+// and, when the budget is insufficient, how long until it would be.
+func (l *costLimiter) take(key string, cost int, now time.Time) (remaining float64, wait time.Duration, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	b, exists := l.buckets[key]
+	if !exists {
+		b = &costBucket{tokens: l.capacity, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.capacity, b.tokens+now.Sub(b.last).Seconds()*l.refill)
+	b.last = now
+
+	c := float64(cost)
+	if b.tokens < c {
+		missing := c - b.tokens
+		return b.tokens, time.Duration(missing / l.refill * float64(time.Second)), false
+	}
+
+	b.tokens -= c
+	return b.tokens, 0, true
+}
+
+// sweep drops buckets that have refilled completely so idle callers don't
+// accumulate. Must be called with mu held.
+func (l *costLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	full := time.Duration(l.capacity / l.refill * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > full {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// withCost returns middleware charging the authenticated caller cost(r).
+// It must run after authMiddleware. A nil limiter disables quotas.
+func (s *Server) withCost(cost costFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if s.costLimiter == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := getUserClaims(r.Context())
+			if claims == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			n := cost(r)
+			remaining, wait, ok := s.costLimiter.take(claims.UserID.String(), n, time.Now())
+			capacity, enforce := s.costLimiter.limits()
+
+			w.Header().Set("X-Cost-Limit", strconv.Itoa(capacity))
+			w.Header().Set("X-Cost-Remaining", strconv.Itoa(int(remaining)))
+			w.Header().Set("X-Request-Cost", strconv.Itoa(n))
+
+			if !ok {
+				s.costLimiter.logger.Warn("cost quota exceeded",
+					slog.String("user_id", claims.UserID.String()),
+					slog.String("path", r.URL.Path),
+					slog.Int("cost", n),
+					slog.Bool("enforced", enforce),
+				)
+
+				if enforce {
+					w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+					s.writeErrorResponse(w, http.StatusTooManyRequests, errorResponse{
+						Error: "request cost quota exceeded, retry later",
+						Code:  "RATE_LIMITED",
+					})
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/mvaleed/aegis/internal/authz"
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+)
+
+// filterFields strips the fields fields guards from JSON responses when
+// the caller lacks their permission, in every object but those describing
+// the caller, whose "id" is theirs. It must come before idempotent, so
+// stored responses are filtered for whoever is replayed them.
+func (s *Server) filterFields(fields authz.FieldPermissions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := getUserClaims(r.Context())
+			if claims == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			hidden := fields.Hidden(claims.hasPermission)
+			if hidden == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := &responseRecorder{header: make(http.Header), request: requestOf(w)}
+			next.ServeHTTP(rec, r)
+
+			var body any
+			dec := json.NewDecoder(bytes.NewReader(rec.body.Bytes()))
+			dec.UseNumber()
+			if !strings.HasPrefix(rec.header.Get("Content-Type"), "application/json") || dec.Decode(&body) != nil {
+				rec.flush(w)
+				return
+			}
+
+			stripFields(body, hidden, claims.UserID.String())
+			rec.body.Reset()
+			if err := json.NewEncoder(&rec.body).Encode(body); err != nil {
+				s.writeError(w, err)
+				return
+			}
+			rec.header.Del("Content-Length")
+			rec.flush(w)
+		})
+	}
+}
+
+// stripFields deletes the hidden fields from each object in v, except the
+// objects whose "id" is self.
+func stripFields(v any, hidden map[string]bool, self string) {
+	switch v := v.(type) {
+	case map[string]any:
+		if v["id"] != self {
+			for field := range hidden {
+				delete(v, field)
+			}
+		}
+		for _, child := range v {
+			stripFields(child, hidden, self)
+		}
+	case []any:
+		for _, child := range v {
+			stripFields(child, hidden, self)
+		}
+	}
+}
+
+// Sparse fieldsets. GET endpoints taking ?fields=, a comma-separated list
+// of the response's JSON fields, return only those (and "id"), and the
+// services skip loading what none of them needs.
+
+// fieldsParam reads the sparse fieldset a request asks for of resp, a
+// response struct, or nil if it asks for none.
+func fieldsParam(r *http.Request, resp any) (service.FieldSet, error) {
+	param := r.URL.Query().Get("fields")
+	if param == "" {
+		return nil, nil
+	}
+
+	known := make(map[string]bool)
+	t := reflect.TypeOf(resp)
+	for i := range t.NumField() {
+		if name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ","); name != "" && name != "-" {
+			known[name] = true
+		}
+	}
+
+	fields := service.FieldSet{"id": true}
+	for name := range strings.SplitSeq(param, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			return nil, domain.ValidationError{Field: "fields", Message: fmt.Sprintf("unknown field %q", name)}
+		}
+		fields[name] = true
+	}
+	return fields, nil
+}
+
+// sparse returns resp with only the fields wanted.
+func sparse(resp any, fields service.FieldSet) any {
+	if fields == nil {
+		return resp
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return resp
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return resp
+	}
+	for name := range m {
+		if !fields.Has(name) {
+			delete(m, name)
+		}
+	}
+	return m
+}
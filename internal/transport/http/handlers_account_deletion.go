@@ -0,0 +1,65 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+type accountDeletionResponse struct {
+	RequestedAt time.Time `json:"requested_at"`
+	DeleteAfter time.Time `json:"delete_after"`
+}
+
+func toAccountDeletionResponse(d *domain.AccountDeletion) *accountDeletionResponse {
+	if d == nil {
+		return nil
+	}
+	return &accountDeletionResponse{RequestedAt: d.RequestedAt, DeleteAfter: d.DeleteAfter}
+}
+
+type scheduleAccountDeletionRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// handleScheduleAccountDeletion schedules the current user's account for
+// deletion once ACCOUNT_DELETION_GRACE_PERIOD is over.
+func (s *Server) handleScheduleAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	var req scheduleAccountDeletionRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	deletion, err := s.accountDeletions.Schedule(r.Context(), claims.UserID, req.Password)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusAccepted, toAccountDeletionResponse(deletion))
+}
+
+// handleCancelAccountDeletion cancels the current user's scheduled account
+// deletion.
+func (s *Server) handleCancelAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	if err := s.accountDeletions.Cancel(r.Context(), claims.UserID); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusNoContent, nil)
+}
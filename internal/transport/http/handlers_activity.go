@@ -0,0 +1,151 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+const (
+	// activityProtocol is the WebSocket subprotocol of the live activity
+	// feed. Browsers must offer it alongside the bearer token protocol.
+	activityProtocol = "aegis.activity.v1"
+
+	// bearerProtocolPrefix marks the subprotocol carrying the access token
+	// of browser clients, which can't set an Authorization header on a
+	// WebSocket handshake.
+	bearerProtocolPrefix = "bearer."
+
+	// activityCacheTTL is how long a counters snapshot is shared between
+	// connections before the database is asked again.
+	activityCacheTTL = time.Second
+
+	activityWriteTimeout = 10 * time.Second
+)
+
+// isWebSocketUpgrade reports whether r opens a WebSocket.
+func isWebSocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// websocketBearer lets WebSocket handshakes without an Authorization header
+// pass their access token as a "bearer.<token>" subprotocol.
+func (s *Server) websocketBearer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isWebSocketUpgrade(r) && r.Header.Get("Authorization") == "" {
+			for _, header := range r.Header.Values("Sec-WebSocket-Protocol") {
+				for _, proto := range strings.Split(header, ",") {
+					if token, ok := strings.CutPrefix(strings.TrimSpace(proto), bearerProtocolPrefix); ok {
+						r.Header.Set("Authorization", "Bearer "+token)
+					}
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// activityCache shares the latest activity counters between connections, so
+// the number of dashboards open doesn't multiply the queries.
+type activityCache struct {
+	mu       sync.Mutex
+	counters domain.ActivityCounters
+	fetched  time.Time
+}
+
+func (c *activityCache) get(ctx context.Context, fetch func(context.Context) (domain.ActivityCounters, error)) (domain.ActivityCounters, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.fetched) < activityCacheTTL {
+		return c.counters, nil
+	}
+
+	counters, err := fetch(ctx)
+	if err != nil {
+		return counters, err
+	}
+	c.counters, c.fetched = counters, time.Now()
+	return counters, nil
+}
+
+type activityCountersMessage struct {
+	Type              string `json:"type"`
+	ActiveSessions    int64  `json:"active_sessions"`
+	ActiveUsers       int64  `json:"active_users"`
+	LoginsPerMinute   int64  `json:"logins_per_minute"`
+	FailuresPerMinute int64  `json:"failures_per_minute"`
+	At                string `json:"at"`
+}
+
+// handleActivityLive streams activity counters over a WebSocket every
+// interval seconds (5 by default, 1 to 60) until the client disconnects.
+func (s *Server) handleActivityLive(w http.ResponseWriter, r *http.Request) {
+	interval := 5 * time.Second
+	if v := r.URL.Query().Get("interval"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds < 1 || seconds > 60 {
+			s.writeError(w, domain.ValidationError{Field: "interval", Message: "must be between 1 and 60 seconds"})
+			return
+		}
+		interval = time.Duration(seconds) * time.Second
+	}
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		Subprotocols:   []string{activityProtocol},
+		OriginPatterns: s.websocketOrigins,
+	})
+	if err != nil {
+		// Accept has already written the error response
+		return
+	}
+	defer conn.CloseNow()
+
+	// Nothing is read from the client, but reading is what notices it
+	// going away
+	ctx := conn.CloseRead(r.Context())
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		counters, err := s.activity.get(ctx, s.authService.ActivityCounters)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			// Dashboards keep the last numbers until the next tick
+			s.logger.Warn("reading activity counters", slog.Any("error", err))
+		} else {
+			writeCtx, cancel := context.WithTimeout(ctx, activityWriteTimeout)
+			err = wsjson.Write(writeCtx, conn, activityCountersMessage{
+				Type:              "counters",
+				ActiveSessions:    counters.ActiveSessions,
+				ActiveUsers:       counters.ActiveUsers,
+				LoginsPerMinute:   counters.LoginsPerMinute,
+				FailuresPerMinute: counters.FailuresPerMinute,
+				At:                counters.At.Format(time.RFC3339),
+			})
+			cancel()
+			if err != nil {
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			conn.Close(websocket.StatusNormalClosure, "")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package http
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// Attribute response types
+
+type attributeDefinitionResponse struct {
+	Key         string `json:"key"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+func toAttributeDefinitionResponse(d *domain.AttributeDefinition) attributeDefinitionResponse {
+	return attributeDefinitionResponse{
+		Key:         d.Key,
+		Type:        string(d.Type),
+		Description: d.Description,
+		CreatedAt:   d.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   d.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// Attribute definition handlers
+
+type createAttributeDefinitionRequest struct {
+	Key         string `json:"key" validate:"required"`
+	Type        string `json:"type" validate:"required,oneof=string number boolean string_list"`
+	Description string `json:"description" validate:"max=500"`
+}
+
+func (s *Server) handleCreateAttributeDefinition(w http.ResponseWriter, r *http.Request) {
+	var req createAttributeDefinitionRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	def, err := s.attributeService.CreateDefinition(r.Context(), req.Key, domain.AttributeType(req.Type), req.Description)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusCreated, toAttributeDefinitionResponse(def))
+}
+
+func (s *Server) handleListAttributeDefinitions(w http.ResponseWriter, r *http.Request) {
+	defs, err := s.attributeService.ListDefinitions(r.Context())
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	defResponses := make([]attributeDefinitionResponse, len(defs))
+	for i := range defs {
+		defResponses[i] = toAttributeDefinitionResponse(&defs[i])
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"attributes": defResponses,
+	})
+}
+
+func (s *Server) handleGetAttributeDefinition(w http.ResponseWriter, r *http.Request) {
+	def, err := s.attributeService.GetDefinition(r.Context(), chi.URLParam(r, "key"))
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toAttributeDefinitionResponse(def))
+}
+
+type updateAttributeDefinitionRequest struct {
+	Description string `json:"description" validate:"max=500"`
+}
+
+func (s *Server) handleUpdateAttributeDefinition(w http.ResponseWriter, r *http.Request) {
+	var req updateAttributeDefinitionRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	def, err := s.attributeService.UpdateDefinition(r.Context(), chi.URLParam(r, "key"), req.Description)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toAttributeDefinitionResponse(def))
+}
+
+func (s *Server) handleDeleteAttributeDefinition(w http.ResponseWriter, r *http.Request) {
+	if err := s.attributeService.DeleteDefinition(r.Context(), chi.URLParam(r, "key")); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// User attribute handlers
+
+// handleUpdateUserAttributes applies the request body as a merge patch to
+// the user's attributes: keys set to null are removed, others are replaced.
+func (s *Server) handleUpdateUserAttributes(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	var patch map[string]any
+	if err := s.readJSON(r, &patch); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	user, err := s.attributeService.UpdateUserAttributes(r.Context(), id, patch)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toUserResponse(user))
+}
+
+// attributeFilterParams collects attr.<key>=value query parameters.
+func attributeFilterParams(query url.Values) map[string]string {
+	var raw map[string]string
+	for name, values := range query {
+		key, ok := strings.CutPrefix(name, "attr.")
+		if !ok || key == "" || len(values) == 0 {
+			continue
+		}
+		if raw == nil {
+			raw = make(map[string]string)
+		}
+		raw[key] = values[0]
+	}
+	return raw
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/mvaleed/aegis/internal/domain"
 	"github.com/mvaleed/aegis/internal/service"
@@ -13,19 +14,58 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	s.writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
 
+type readinessResponse struct {
+	service.Readiness
+	Pipeline service.PipelineHealth `json:"pipeline"`
+}
+
+// handleReady answers 503 until the schema has the migrations this binary
+// needs, and again once a contract migration it doesn't know has removed
+// something it may still use. It also answers 503 while the event pipeline
+// is backed up past its alert thresholds.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	readiness, err := s.schemaService.Readiness(r.Context())
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	pipeline, err := s.pipelineService.Health(r.Context())
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	if !pipeline.Healthy && readiness.Ready {
+		readiness.Ready = false
+		readiness.Reason = "event pipeline: " + strings.Join(pipeline.Problems, "; ")
+	}
+
+	status := http.StatusOK
+	if !readiness.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	s.writeJSON(w, status, readinessResponse{Readiness: readiness, Pipeline: pipeline})
+}
+
 type registerRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Username string `json:"username"`
-	FullName string `json:"full_name"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+	Username string `json:"username" validate:"required"`
+	FullName string `json:"full_name" validate:"required,max=200"`
 	Phone    string `json:"phone,omitempty"`
+	Locale   string `json:"locale,omitempty" validate:"locale"`
+	Timezone string `json:"timezone,omitempty" validate:"timezone"`
 }
 
 type authResponse struct {
 	AccessToken  string       `json:"access_token"`
 	RefreshToken string       `json:"refresh_token"`
 	ExpiresIn    int64        `json:"expires_in"`
+	Scope        string       `json:"scope,omitempty"`
 	User         userResponse `json:"user"`
+
+	// PendingDeletion is set on logins to accounts scheduled for deletion,
+	// so clients can offer to cancel it.
+	PendingDeletion *accountDeletionResponse `json:"pending_deletion,omitempty"`
 }
 
 func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
@@ -42,6 +82,11 @@ func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
 		FullName: req.FullName,
 		Type:     domain.UserTypeCustomer,
 		Phone:    req.Phone,
+		Locale:   req.Locale,
+		Timezone: req.Timezone,
+
+		IPAddress: getClientIP(r),
+		UserAgent: r.UserAgent(),
 	})
 	if err != nil {
 		s.writeError(w, err)
@@ -54,6 +99,7 @@ func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
 		Password:  req.Password,
 		IPAddress: getClientIP(r),
 		UserAgent: r.UserAgent(),
+		DeviceID:  r.Header.Get(deviceIDHeader),
 	})
 	if err != nil {
 		// Registration succeeded but auto-login failed - just return user
@@ -72,8 +118,10 @@ func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
 }
 
 type loginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email      string `json:"email" validate:"required"` // An LDAP username with LDAP_ENABLED
+	Password   string `json:"password" validate:"required"`
+	Scope      string `json:"scope"`       // Optional, e.g. "openid email"
+	RememberMe bool   `json:"remember_me"` // Optional; see REFRESH_TOKEN_SHORT_TTL
 }
 
 func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
@@ -88,22 +136,34 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 		Password:  req.Password,
 		IPAddress: getClientIP(r),
 		UserAgent: r.UserAgent(),
+		DeviceID:  r.Header.Get(deviceIDHeader),
+		Scope:     req.Scope,
+
+		RememberMe: req.RememberMe,
 	})
 	if err != nil {
 		s.writeError(w, err)
 		return
 	}
 
+	pending, err := s.accountDeletions.Pending(r.Context(), result.User.ID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
 	s.writeJSON(w, http.StatusOK, authResponse{
-		AccessToken:  result.AccessToken,
-		RefreshToken: result.RefreshToken,
-		ExpiresIn:    result.ExpiresInSeconds,
-		User:         toUserResponse(result.User),
+		AccessToken:     result.AccessToken,
+		RefreshToken:    result.RefreshToken,
+		ExpiresIn:       result.ExpiresInSeconds,
+		Scope:           result.Scope,
+		User:            toUserResponse(result.User),
+		PendingDeletion: toAccountDeletionResponse(pending),
 	})
 }
 
 type refreshRequest struct {
-	RefreshToken string `json:"refresh_token"`
+	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
 func (s *Server) handleRefreshToken(w http.ResponseWriter, r *http.Request) {
@@ -132,12 +192,13 @@ func (s *Server) handleRefreshToken(w http.ResponseWriter, r *http.Request) {
 		AccessToken:  result.AccessToken,
 		RefreshToken: result.RefreshToken,
 		ExpiresIn:    result.ExpiresInSeconds,
+		Scope:        result.Scope,
 		User:         toUserResponse(result.User),
 	})
 }
 
 type logoutRequest struct {
-	RefreshToken string `json:"refresh_token"`
+	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
 func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
@@ -169,3 +230,9 @@ func (s *Server) handleLogoutAll(w http.ResponseWriter, r *http.Request) {
 
 	s.writeJSON(w, http.StatusOK, map[string]string{"message": "logged out from all devices"})
 }
+
+// handleTokenStats reports access tokens accepted or rejected because of
+// clock skew.
+func (s *Server) handleTokenStats(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, http.StatusOK, s.jwtManager.Stats())
+}
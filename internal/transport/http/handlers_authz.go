@@ -0,0 +1,29 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// handleShadowReport reports how often the shadow policy disagrees with the
+// enforced decisions, and the latest disagreements. It's 404 unless
+// AUTHZ_SHADOW_POLICY is set.
+func (s *Server) handleShadowReport(w http.ResponseWriter, r *http.Request) {
+	if s.shadow == nil {
+		s.writeError(w, domain.ErrNotFound)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, s.shadow.Report())
+}
+
+func (s *Server) handleResetShadow(w http.ResponseWriter, r *http.Request) {
+	if s.shadow == nil {
+		s.writeError(w, domain.ErrNotFound)
+		return
+	}
+
+	s.shadow.Reset()
+	s.writeJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mvaleed/aegis/internal/service"
+)
+
+// Availability response types
+
+type fieldAvailabilityResponse struct {
+	Value       string   `json:"value"`
+	Available   bool     `json:"available"`
+	Reason      string   `json:"reason,omitempty"`
+	Message     string   `json:"message,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+type availabilityResponse struct {
+	Email    *fieldAvailabilityResponse `json:"email,omitempty"`
+	Username *fieldAvailabilityResponse `json:"username,omitempty"`
+}
+
+func toFieldAvailabilityResponse(f *service.FieldAvailability) *fieldAvailabilityResponse {
+	if f == nil {
+		return nil
+	}
+	return &fieldAvailabilityResponse{
+		Value:       f.Value,
+		Available:   f.Available,
+		Reason:      f.Reason,
+		Message:     f.Message,
+		Suggestions: f.Suggestions,
+	}
+}
+
+// Availability handlers
+
+func (s *Server) handleCheckAvailability(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	result, err := s.availabilityService.Check(r.Context(), service.AvailabilityInput{
+		Email:    query.Get("email"),
+		Username: query.Get("username"),
+		FullName: query.Get("full_name"),
+	})
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	s.writeJSON(w, http.StatusOK, availabilityResponse{
+		Email:    toFieldAvailabilityResponse(result.Email),
+		Username: toFieldAvailabilityResponse(result.Username),
+	})
+}
+
+// availabilityLimit rate limits availability checks per user, or per client
+// IP for anonymous callers, to slow down enumeration.
+func (s *Server) availabilityLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := "ip:" + getClientIP(r)
+		if claims := getUserClaims(r.Context()); claims != nil {
+			key = "user:" + claims.UserID.String()
+		}
+
+		_, wait, ok := s.availabilityLimiter.take(key, 1, time.Now())
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			s.writeErrorResponse(w, http.StatusTooManyRequests, errorResponse{
+				Error: "too many availability checks, retry later",
+				Code:  "RATE_LIMITED",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
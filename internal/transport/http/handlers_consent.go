@@ -0,0 +1,148 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// Consent response types
+
+type consentResponse struct {
+	ID         string   `json:"id"`
+	CustomerID string   `json:"customer_id"`
+	PartnerID  string   `json:"partner_id"`
+	Scopes     []string `json:"scopes"`
+	GrantedAt  string   `json:"granted_at"`
+	UpdatedAt  string   `json:"updated_at"`
+	RevokedAt  *string  `json:"revoked_at,omitempty"`
+}
+
+func toConsentResponse(c *domain.Consent) consentResponse {
+	resp := consentResponse{
+		ID:         c.ID.String(),
+		CustomerID: c.CustomerID.String(),
+		PartnerID:  c.PartnerID.String(),
+		Scopes:     c.Scopes,
+		GrantedAt:  c.GrantedAt.Format(time.RFC3339),
+		UpdatedAt:  c.UpdatedAt.Format(time.RFC3339),
+	}
+
+	if c.RevokedAt != nil {
+		t := c.RevokedAt.Format(time.RFC3339)
+		resp.RevokedAt = &t
+	}
+
+	return resp
+}
+
+func (s *Server) writeConsents(w http.ResponseWriter, consents []domain.Consent) {
+	consentResponses := make([]consentResponse, len(consents))
+	for i := range consents {
+		consentResponses[i] = toConsentResponse(&consents[i])
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"consents": consentResponses,
+		"total":    len(consents),
+	})
+}
+
+// Consent handlers
+
+type grantConsentRequest struct {
+	PartnerID string   `json:"partner_id" validate:"required,uuid"`
+	Scopes    []string `json:"scopes" validate:"required"`
+}
+
+// handleGrantConsent gives or replaces the caller's consent for a partner.
+func (s *Server) handleGrantConsent(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	var req grantConsentRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	partnerID, err := uuid.Parse(req.PartnerID)
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "partner_id", Message: "invalid UUID"})
+		return
+	}
+
+	consent, err := s.consentService.GrantConsent(r.Context(), claims.UserID, partnerID, req.Scopes)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toConsentResponse(consent))
+}
+
+// handleListConsents lists the caller's consents; ?include_revoked=true
+// adds revoked ones.
+func (s *Server) handleListConsents(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	withRevoked := r.URL.Query().Get("include_revoked") == "true"
+
+	consents, err := s.consentService.ListCustomerConsents(r.Context(), claims.UserID, withRevoked)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeConsents(w, consents)
+}
+
+func (s *Server) handleRevokeConsent(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	consentID, err := uuid.Parse(chi.URLParam(r, "consentId"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "consentId", Message: "invalid UUID"})
+		return
+	}
+
+	if err := s.consentService.RevokeConsent(r.Context(), claims.UserID, consentID); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleListPartnerConsents lists the customers who consented to the
+// calling partner, and to what.
+func (s *Server) handleListPartnerConsents(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	consents, err := s.consentService.ListPartnerConsents(r.Context(), claims.UserID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeConsents(w, consents)
+}
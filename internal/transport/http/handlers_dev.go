@@ -0,0 +1,94 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/notify"
+)
+
+// Dev outbox response types
+
+type outboxMessageResponse struct {
+	ID       int64          `json:"id"`
+	Channel  string         `json:"channel"`
+	To       string         `json:"to"`
+	Template string         `json:"template"`
+	Subject  string         `json:"subject,omitempty"`
+	Text     string         `json:"text"`
+	HTML     string         `json:"html,omitempty"`
+	Data     map[string]any `json:"data"`
+	SentAt   string         `json:"sent_at"`
+}
+
+func toOutboxMessageResponse(m notify.CapturedMessage) outboxMessageResponse {
+	data := m.Data
+	if data == nil {
+		data = map[string]any{}
+	}
+	return outboxMessageResponse{
+		ID:       m.ID,
+		Channel:  string(m.Channel),
+		To:       m.To,
+		Template: m.Template,
+		Subject:  m.Subject,
+		Text:     m.Text,
+		HTML:     m.HTML,
+		Data:     data,
+		SentAt:   m.SentAt.Format(time.RFC3339Nano),
+	}
+}
+
+func outboxFilter(r *http.Request) notify.OutboxFilter {
+	query := r.URL.Query()
+	return notify.OutboxFilter{
+		Channel:  notify.Channel(query.Get("channel")),
+		To:       query.Get("to"),
+		Template: query.Get("template"),
+	}
+}
+
+// Dev outbox handlers. These are only routed in development and sandbox
+// environments and need no authentication.
+
+func (s *Server) handleListOutbox(w http.ResponseWriter, r *http.Request) {
+	messages := s.outbox.Messages(outboxFilter(r))
+
+	responses := make([]outboxMessageResponse, len(messages))
+	for i, m := range messages {
+		responses[i] = toOutboxMessageResponse(m)
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"messages": responses,
+		"total":    len(responses),
+	})
+}
+
+func (s *Server) handleLatestOutboxMessage(w http.ResponseWriter, r *http.Request) {
+	messages := s.outbox.Messages(outboxFilter(r))
+	if len(messages) == 0 {
+		s.writeError(w, domain.ErrNotFound)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toOutboxMessageResponse(messages[0]))
+}
+
+func (s *Server) handleGetOutboxMessage(w http.ResponseWriter, r *http.Request) {
+	m, ok := s.outbox.Get(chi.URLParam(r, "id"))
+	if !ok {
+		s.writeError(w, domain.ErrNotFound)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toOutboxMessageResponse(m))
+}
+
+func (s *Server) handleClearOutbox(w http.ResponseWriter, r *http.Request) {
+	s.outbox.Clear()
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,164 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// deviceIDHeader carries an optional client-generated device ID on login.
+// Clients that send one are recognized across user agent upgrades.
+const deviceIDHeader = "X-Device-ID"
+
+// Device response types
+
+type deviceResponse struct {
+	ID            string   `json:"id"`
+	UserAgent     string   `json:"user_agent"`
+	LastIPAddress string   `json:"last_ip_address"`
+	Networks      []string `json:"networks"`
+	Trusted       bool     `json:"trusted"`
+	Confirmed     bool     `json:"confirmed"`
+	FirstSeenAt   string   `json:"first_seen_at"`
+	LastSeenAt    string   `json:"last_seen_at"`
+}
+
+func toDeviceResponse(d *domain.KnownDevice) deviceResponse {
+	networks := d.Networks
+	if networks == nil {
+		networks = []string{}
+	}
+	return deviceResponse{
+		ID:            d.ID.String(),
+		UserAgent:     d.UserAgent,
+		LastIPAddress: d.LastIPAddress,
+		Networks:      networks,
+		Trusted:       d.Trusted,
+		Confirmed:     d.IsConfirmed(),
+		FirstSeenAt:   d.FirstSeenAt.Format(time.RFC3339),
+		LastSeenAt:    d.LastSeenAt.Format(time.RFC3339),
+	}
+}
+
+func (s *Server) writeDevices(w http.ResponseWriter, devices []domain.KnownDevice) {
+	deviceResponses := make([]deviceResponse, len(devices))
+	for i := range devices {
+		deviceResponses[i] = toDeviceResponse(&devices[i])
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"devices": deviceResponses,
+		"total":   len(devices),
+	})
+}
+
+// Device handlers
+
+type confirmDeviceRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// handleConfirmDevice approves a held login from the link emailed to the
+// user. It issues no tokens; the user signs in again.
+func (s *Server) handleConfirmDevice(w http.ResponseWriter, r *http.Request) {
+	var req confirmDeviceRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	device, err := s.deviceService.ConfirmLogin(r.Context(), req.Token)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toDeviceResponse(device))
+}
+
+func (s *Server) handleListCurrentUserDevices(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	devices, err := s.deviceService.ListDevices(r.Context(), claims.UserID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeDevices(w, devices)
+}
+
+func (s *Server) handleTrustDevice(w http.ResponseWriter, r *http.Request) {
+	s.setDeviceTrusted(w, r, true)
+}
+
+func (s *Server) handleUntrustDevice(w http.ResponseWriter, r *http.Request) {
+	s.setDeviceTrusted(w, r, false)
+}
+
+func (s *Server) setDeviceTrusted(w http.ResponseWriter, r *http.Request, trusted bool) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	deviceID, err := uuid.Parse(chi.URLParam(r, "deviceId"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "deviceId", Message: "invalid UUID"})
+		return
+	}
+
+	device, err := s.deviceService.SetTrusted(r.Context(), claims.UserID, deviceID, trusted)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toDeviceResponse(device))
+}
+
+func (s *Server) handleForgetDevice(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	deviceID, err := uuid.Parse(chi.URLParam(r, "deviceId"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "deviceId", Message: "invalid UUID"})
+		return
+	}
+
+	if err := s.deviceService.ForgetDevice(r.Context(), claims.UserID, deviceID); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleListUserDevices(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	devices, err := s.deviceService.ListDevices(r.Context(), userID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeDevices(w, devices)
+}
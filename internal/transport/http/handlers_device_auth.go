@@ -0,0 +1,165 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+)
+
+// Device authorization response types
+
+type deviceCodeResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+}
+
+type deviceTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+type deviceAuthorizationResponse struct {
+	UserCode  string `json:"user_code"`
+	ClientID  string `json:"client_id"`
+	Scope     string `json:"scope,omitempty"`
+	Status    string `json:"status"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+func toDeviceAuthorizationResponse(a *domain.DeviceAuthorization) deviceAuthorizationResponse {
+	return deviceAuthorizationResponse{
+		UserCode:  domain.FormatUserCode(a.UserCode),
+		ClientID:  a.ClientID,
+		Scope:     a.Scope,
+		Status:    string(a.Status),
+		ExpiresAt: a.ExpiresAt.Format(time.RFC3339),
+	}
+}
+
+// Device authorization handlers. The device endpoints take form-encoded
+// bodies, as OAuth 2.0 clients send.
+
+// handleDeviceCode starts a device authorization. The device shows the
+// user code and verification URI to the user, then polls the token
+// endpoint every interval seconds.
+func (s *Server) handleDeviceCode(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		s.writeOAuthError(w, domain.ValidationError{Field: "body", Message: "malformed form"})
+		return
+	}
+
+	code, err := s.deviceAuthService.Start(r.Context(), r.PostForm.Get("client_id"), r.PostForm.Get("scope"))
+	if err != nil {
+		s.writeOAuthError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	s.writeJSON(w, http.StatusOK, deviceCodeResponse{
+		DeviceCode:              code.DeviceCode,
+		UserCode:                code.UserCode,
+		VerificationURI:         code.VerificationURI,
+		VerificationURIComplete: code.VerificationURIComplete,
+		ExpiresIn:               int64(code.ExpiresIn / time.Second),
+		Interval:                int64(code.Interval / time.Second),
+	})
+}
+
+// handleDeviceCodeGrant is the token endpoint for a device polling with
+// its device code.
+func (s *Server) handleDeviceCodeGrant(w http.ResponseWriter, r *http.Request) {
+	deviceCode := r.PostForm.Get("device_code")
+	if deviceCode == "" {
+		s.writeOAuthError(w, domain.ValidationError{Field: "device_code", Message: "required"})
+		return
+	}
+
+	result, err := s.deviceAuthService.Poll(r.Context(), service.PollInput{
+		DeviceCode: deviceCode,
+		ClientID:   r.PostForm.Get("client_id"),
+		IPAddress:  getClientIP(r),
+		UserAgent:  r.UserAgent(),
+	})
+	if err != nil {
+		s.writeOAuthError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	s.writeJSON(w, http.StatusOK, deviceTokenResponse{
+		AccessToken:  result.AccessToken,
+		RefreshToken: result.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    result.ExpiresInSeconds,
+		Scope:        result.Scope,
+	})
+}
+
+// Verification handlers, for the signed-in user the device shows the user
+// code to
+
+type deviceDecisionRequest struct {
+	UserCode string `json:"user_code" validate:"required"`
+}
+
+// handleGetDeviceAuthorization returns the pending authorization with the
+// user_code query parameter, so the user can check which client asks for
+// what before approving it.
+func (s *Server) handleGetDeviceAuthorization(w http.ResponseWriter, r *http.Request) {
+	userCode := r.URL.Query().Get("user_code")
+	if userCode == "" {
+		s.writeError(w, domain.ValidationError{Field: "user_code", Message: "required"})
+		return
+	}
+
+	authorization, err := s.deviceAuthService.Pending(r.Context(), userCode)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toDeviceAuthorizationResponse(authorization))
+}
+
+func (s *Server) handleApproveDeviceAuthorization(w http.ResponseWriter, r *http.Request) {
+	s.decideDeviceAuthorization(w, r, true)
+}
+
+func (s *Server) handleDenyDeviceAuthorization(w http.ResponseWriter, r *http.Request) {
+	s.decideDeviceAuthorization(w, r, false)
+}
+
+func (s *Server) decideDeviceAuthorization(w http.ResponseWriter, r *http.Request, approve bool) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	var req deviceDecisionRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	if req.UserCode == "" {
+		s.writeError(w, domain.ValidationError{Field: "user_code", Message: "required"})
+		return
+	}
+
+	authorization, err := s.deviceAuthService.Decide(r.Context(), req.UserCode, claims.UserID, approve)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toDeviceAuthorizationResponse(authorization))
+}
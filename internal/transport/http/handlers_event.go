@@ -0,0 +1,127 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// sseHeartbeatInterval keeps idle connections alive through proxies.
+const sseHeartbeatInterval = 20 * time.Second
+
+type eventResponse struct {
+	ID            string         `json:"id"`
+	Type          string         `json:"type"`
+	SchemaVersion int            `json:"schema_version"`
+	Timestamp     string         `json:"timestamp"`
+	UserID        string         `json:"user_id"`
+	CorrelationID string         `json:"correlation_id,omitempty"`
+	Data          map[string]any `json:"data,omitempty"`
+}
+
+func toEventResponse(e domain.Event) eventResponse {
+	return eventResponse{
+		ID:            e.ID.String(),
+		Type:          e.Type,
+		SchemaVersion: e.SchemaVersion,
+		Timestamp:     e.Timestamp.Format(time.RFC3339Nano),
+		UserID:        e.UserID.String(),
+		CorrelationID: e.CorrelationID,
+		Data:          e.Data,
+	}
+}
+
+type eventSchemaResponse struct {
+	Type    string   `json:"type"`
+	Version int      `json:"version"`
+	Fields  []string `json:"fields"`
+}
+
+// handleEventStream pushes domain events to the client using Server-Sent Events.
+// Clients can restrict the stream with ?types=user.created,user.deleted.
+func (s *Server) handleEventStream(w http.ResponseWriter, r *http.Request) {
+	var types []string
+	for _, t := range strings.Split(r.URL.Query().Get("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+
+	rc := http.NewResponseController(w)
+	// Streams outlive the server's write timeout.
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, errorResponse{
+			Error: "streaming not supported",
+			Code:  "INTERNAL_ERROR",
+		})
+		return
+	}
+
+	sub := s.eventBus.Subscribe(r.Context(), 0, types...)
+	defer sub.Cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	_ = rc.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+
+		case e, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(toEventResponse(e))
+			if err != nil {
+				s.logger.Error("failed to encode event", "error", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handlePublisherStats reports the broker backlog and circuit breaker state.
+func (s *Server) handlePublisherStats(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, http.StatusOK, s.brokerQueue.Stats())
+}
+
+// handleEventSchemas lists the event types published and the current
+// schema version of each.
+func (s *Server) handleEventSchemas(w http.ResponseWriter, r *http.Request) {
+	schemas := domain.EventSchemas()
+	resp := make([]eventSchemaResponse, len(schemas))
+	for i, schema := range schemas {
+		fields := schema.Fields
+		if fields == nil {
+			fields = []string{}
+		}
+		resp[i] = eventSchemaResponse{Type: schema.Type, Version: schema.Version, Fields: fields}
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{"schemas": resp})
+}
@@ -0,0 +1,332 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+)
+
+// Group response types
+
+type groupResponse struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Roles       []roleResponse `json:"roles,omitempty"`
+	CreatedAt   string         `json:"created_at"`
+	UpdatedAt   string         `json:"updated_at"`
+}
+
+type groupMemberResponse struct {
+	GroupID  string `json:"group_id"`
+	UserID   string `json:"user_id"`
+	JoinedAt string `json:"joined_at"`
+}
+
+func toGroupResponse(g *domain.Group) groupResponse {
+	resp := groupResponse{
+		ID:          g.ID.String(),
+		Name:        g.Name,
+		Description: g.Description,
+		CreatedAt:   g.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   g.UpdatedAt.Format(time.RFC3339),
+	}
+
+	for _, r := range g.Roles {
+		resp.Roles = append(resp.Roles, toRoleResponse(&r))
+	}
+
+	return resp
+}
+
+func toGroupMemberResponse(m *domain.GroupMember) groupMemberResponse {
+	return groupMemberResponse{
+		GroupID:  m.GroupID.String(),
+		UserID:   m.UserID.String(),
+		JoinedAt: m.JoinedAt.Format(time.RFC3339),
+	}
+}
+
+// Group handlers
+
+type createGroupRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description"`
+}
+
+func (s *Server) handleCreateGroup(w http.ResponseWriter, r *http.Request) {
+	var req createGroupRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	group, err := s.groupService.CreateGroup(r.Context(), req.Name, req.Description)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusCreated, toGroupResponse(group))
+}
+
+func (s *Server) handleListGroups(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	offset, limit := 0, 20
+	if v, err := strconv.Atoi(query.Get("offset")); err == nil && v >= 0 {
+		offset = v
+	}
+	if v, err := strconv.Atoi(query.Get("limit")); err == nil && v > 0 && v <= 100 {
+		limit = v
+	}
+
+	groups, total, err := s.groupService.ListGroups(r.Context(), offset, limit)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	groupResponses := make([]groupResponse, len(groups))
+	for i, g := range groups {
+		groupResponses[i] = toGroupResponse(&g)
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"groups": groupResponses,
+		"total":  total,
+		"offset": offset,
+		"limit":  limit,
+	})
+}
+
+func (s *Server) handleGetGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	group, err := s.groupService.GetGroup(r.Context(), id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toGroupResponse(group))
+}
+
+type updateGroupRequest struct {
+	Name        *string `json:"name,omitempty" validate:"max=100"`
+	Description *string `json:"description,omitempty"`
+}
+
+func (s *Server) handleUpdateGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	var req updateGroupRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	group, err := s.groupService.UpdateGroup(r.Context(), id, service.UpdateGroupInput{
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toGroupResponse(group))
+}
+
+func (s *Server) handleDeleteGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	if err := s.groupService.DeleteGroup(r.Context(), id); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleListGroupMembers(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	query := r.URL.Query()
+
+	offset, limit := 0, 20
+	if v, err := strconv.Atoi(query.Get("offset")); err == nil && v >= 0 {
+		offset = v
+	}
+	if v, err := strconv.Atoi(query.Get("limit")); err == nil && v > 0 && v <= 100 {
+		limit = v
+	}
+
+	members, total, err := s.groupService.ListMembers(r.Context(), id, offset, limit)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	memberResponses := make([]groupMemberResponse, len(members))
+	for i, m := range members {
+		memberResponses[i] = toGroupMemberResponse(&m)
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"members": memberResponses,
+		"total":   total,
+		"offset":  offset,
+		"limit":   limit,
+	})
+}
+
+type addGroupMemberRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+}
+
+func (s *Server) handleAddGroupMember(w http.ResponseWriter, r *http.Request) {
+	groupID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	var req addGroupMemberRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "user_id", Message: "invalid UUID"})
+		return
+	}
+
+	if err := s.groupService.AddMember(r.Context(), groupID, userID); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]string{"message": "member added"})
+}
+
+func (s *Server) handleRemoveGroupMember(w http.ResponseWriter, r *http.Request) {
+	groupID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	userID, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "userId", Message: "invalid UUID"})
+		return
+	}
+
+	if err := s.groupService.RemoveMember(r.Context(), groupID, userID); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]string{"message": "member removed"})
+}
+
+type assignGroupRoleRequest struct {
+	RoleID string `json:"role_id" validate:"required,uuid"`
+}
+
+func (s *Server) handleAssignRoleToGroup(w http.ResponseWriter, r *http.Request) {
+	groupID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	var req assignGroupRoleRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	roleID, err := uuid.Parse(req.RoleID)
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "role_id", Message: "invalid UUID"})
+		return
+	}
+
+	if err := s.groupService.AssignRole(r.Context(), groupID, roleID); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]string{"message": "role assigned"})
+}
+
+func (s *Server) handleRemoveRoleFromGroup(w http.ResponseWriter, r *http.Request) {
+	groupID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	roleID, err := uuid.Parse(chi.URLParam(r, "roleId"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "roleId", Message: "invalid UUID"})
+		return
+	}
+
+	if err := s.groupService.RemoveRole(r.Context(), groupID, roleID); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]string{"message": "role removed"})
+}
+
+func (s *Server) handleListCurrentUserGroups(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	groups, err := s.groupService.ListUserGroups(r.Context(), claims.UserID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	groupResponses := make([]groupResponse, len(groups))
+	for i, g := range groups {
+		groupResponses[i] = toGroupResponse(&g)
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"groups": groupResponses,
+		"total":  len(groups),
+	})
+}
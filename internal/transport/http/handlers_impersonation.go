@@ -0,0 +1,191 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+)
+
+// Impersonation response types
+
+type impersonationSessionResponse struct {
+	ID        string  `json:"id"`
+	ActorID   string  `json:"actor_id"`
+	TargetID  string  `json:"target_id"`
+	Reason    string  `json:"reason"`
+	IPAddress string  `json:"ip_address,omitempty"`
+	UserAgent string  `json:"user_agent,omitempty"`
+	Active    bool    `json:"active"`
+	StartedAt string  `json:"started_at"`
+	ExpiresAt string  `json:"expires_at"`
+	EndedAt   *string `json:"ended_at,omitempty"`
+}
+
+func toImpersonationSessionResponse(s *domain.ImpersonationSession) impersonationSessionResponse {
+	resp := impersonationSessionResponse{
+		ID:        s.ID.String(),
+		ActorID:   s.ActorID.String(),
+		TargetID:  s.TargetID.String(),
+		Reason:    s.Reason,
+		IPAddress: s.IPAddress,
+		UserAgent: s.UserAgent,
+		Active:    s.IsActive(),
+		StartedAt: s.StartedAt.Format(time.RFC3339),
+		ExpiresAt: s.ExpiresAt.Format(time.RFC3339),
+	}
+
+	if s.EndedAt != nil {
+		t := s.EndedAt.Format(time.RFC3339)
+		resp.EndedAt = &t
+	}
+
+	return resp
+}
+
+type impersonationResponse struct {
+	AccessToken string                       `json:"access_token"`
+	ExpiresIn   int64                        `json:"expires_in"`
+	Session     impersonationSessionResponse `json:"session"`
+	User        userResponse                 `json:"user"`
+}
+
+// Impersonation handlers
+
+type startImpersonationRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+func (s *Server) handleStartImpersonation(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	targetID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	var req startImpersonationRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	result, err := s.authService.StartImpersonation(r.Context(), service.StartImpersonationInput{
+		ActorID:   claims.UserID,
+		TargetID:  targetID,
+		Reason:    req.Reason,
+		IPAddress: getClientIP(r),
+		UserAgent: r.UserAgent(),
+	})
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.logger.Info("impersonation started",
+		"actor_id", claims.UserID.String(),
+		"user_id", targetID.String(),
+		"session_id", result.Session.ID.String(),
+		"reason", result.Session.Reason,
+	)
+
+	s.writeJSON(w, http.StatusCreated, impersonationResponse{
+		AccessToken: result.AccessToken,
+		ExpiresIn:   result.ExpiresInSeconds,
+		Session:     toImpersonationSessionResponse(result.Session),
+		User:        toUserResponse(result.User),
+	})
+}
+
+type endImpersonationRequest struct {
+	SessionID string `json:"session_id" validate:"uuid"`
+}
+
+// handleEndImpersonation ends the session of the impersonation token used to
+// call it, or, called with the support user's own token, the given session.
+func (s *Server) handleEndImpersonation(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	var sessionID, actorID uuid.UUID
+	if claims.Actor != nil {
+		sessionID = claims.Actor.SessionID
+		actorID = claims.Actor.UserID
+	} else {
+		var req endImpersonationRequest
+		if err := s.readJSON(r, &req); err != nil {
+			s.writeError(w, err)
+			return
+		}
+
+		id, err := uuid.Parse(req.SessionID)
+		if err != nil {
+			s.writeError(w, domain.ValidationError{Field: "session_id", Message: "invalid UUID"})
+			return
+		}
+		sessionID = id
+		actorID = claims.UserID
+	}
+
+	session, err := s.authService.EndImpersonation(r.Context(), sessionID, actorID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.logger.Info("impersonation ended",
+		"actor_id", actorID.String(),
+		"user_id", session.TargetID.String(),
+		"session_id", session.ID.String(),
+	)
+
+	s.writeJSON(w, http.StatusOK, toImpersonationSessionResponse(session))
+}
+
+func (s *Server) handleListImpersonations(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	query := r.URL.Query()
+	offset, limit := 0, 20
+	if v, err := strconv.Atoi(query.Get("offset")); err == nil && v >= 0 {
+		offset = v
+	}
+	if v, err := strconv.Atoi(query.Get("limit")); err == nil && v > 0 && v <= 100 {
+		limit = v
+	}
+
+	sessions, total, err := s.authService.ListImpersonations(r.Context(), userID, offset, limit)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	sessionResponses := make([]impersonationSessionResponse, len(sessions))
+	for i := range sessions {
+		sessionResponses[i] = toImpersonationSessionResponse(&sessions[i])
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"sessions": sessionResponses,
+		"total":    total,
+		"offset":   offset,
+		"limit":    limit,
+	})
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// handleIntrospect is the OAuth 2.0 token introspection endpoint (RFC
+// 7662), for services verifying access tokens they can't read, opaque ones
+// in particular. The caller authenticates as an OAuth client whose roles
+// grant tokens:introspect, as for the client credentials grant, and posts
+// token. Active tokens, JWTs included and whatever their audience, come
+// back with their claims and "active": true; anything else, refresh tokens
+// included, as {"active": false}.
+func (s *Server) handleIntrospect(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		s.writeOAuthError(w, domain.ValidationError{Field: "body", Message: "malformed form"})
+		return
+	}
+
+	clientID, secret, basic := r.BasicAuth()
+	if !basic {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID == "" || secret == "" {
+		s.writeOAuthError(w, domain.ValidationError{Field: "client_id", Message: "client_id and client_secret are required"})
+		return
+	}
+
+	client, err := s.oauthClientService.Authenticate(r.Context(), clientID, secret)
+	if errors.Is(err, domain.ErrInvalidCredential) {
+		if basic {
+			w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		s.writeJSON(w, http.StatusUnauthorized, oauthErrorResponse{Error: "invalid_client", ErrorDescription: err.Error()})
+		return
+	}
+	if err != nil {
+		s.writeOAuthError(w, err)
+		return
+	}
+	if !domain.Allows(client.AllPermissions(), "tokens", "introspect") {
+		s.writeOAuthError(w, domain.ErrUnauthorizedClient)
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		s.writeOAuthError(w, domain.ValidationError{Field: "token", Message: "required"})
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	claims, err := s.authService.IntrospectToken(r.Context(), token)
+	if err != nil {
+		s.writeJSON(w, http.StatusOK, map[string]any{"active": false})
+		return
+	}
+
+	data, err := json.Marshal(claims)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	resp := map[string]any{}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	resp["active"] = true
+	resp["token_type"] = "Bearer"
+
+	s.writeJSON(w, http.StatusOK, resp)
+}
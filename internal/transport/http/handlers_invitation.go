@@ -0,0 +1,292 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// Invitation response types
+
+type invitationResponse struct {
+	ID         string  `json:"id"`
+	Email      string  `json:"email"`
+	UserID     string  `json:"user_id"`
+	RoleID     *string `json:"role_id,omitempty"`
+	InvitedBy  string  `json:"invited_by"`
+	Status     string  `json:"status"`
+	SendCount  int     `json:"send_count"`
+	ExpiresAt  string  `json:"expires_at"`
+	AcceptedAt *string `json:"accepted_at,omitempty"`
+	RevokedAt  *string `json:"revoked_at,omitempty"`
+	CreatedAt  string  `json:"created_at"`
+	UpdatedAt  string  `json:"updated_at"`
+}
+
+// issuedInvitationResponse carries the raw token. It is only returned when
+// a token is issued (create and resend); the token is not stored.
+type issuedInvitationResponse struct {
+	Invitation invitationResponse `json:"invitation"`
+	Token      string             `json:"token"`
+}
+
+func toInvitationResponse(inv *domain.Invitation) invitationResponse {
+	resp := invitationResponse{
+		ID:        inv.ID.String(),
+		Email:     inv.Email,
+		UserID:    inv.UserID.String(),
+		InvitedBy: inv.InvitedBy.String(),
+		Status:    string(inv.CurrentStatus()),
+		SendCount: inv.SendCount,
+		ExpiresAt: inv.ExpiresAt.Format(time.RFC3339),
+		CreatedAt: inv.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: inv.UpdatedAt.Format(time.RFC3339),
+	}
+
+	if inv.RoleID != nil {
+		roleID := inv.RoleID.String()
+		resp.RoleID = &roleID
+	}
+
+	if inv.AcceptedAt != nil {
+		t := inv.AcceptedAt.Format(time.RFC3339)
+		resp.AcceptedAt = &t
+	}
+
+	if inv.RevokedAt != nil {
+		t := inv.RevokedAt.Format(time.RFC3339)
+		resp.RevokedAt = &t
+	}
+
+	return resp
+}
+
+// Invitation handlers
+
+type createInvitationRequest struct {
+	Email          string  `json:"email" validate:"required,email"`
+	FullName       string  `json:"full_name,omitempty" validate:"max=200"`
+	UserType       string  `json:"user_type,omitempty" validate:"oneof=admin customer partner"`
+	RoleID         *string `json:"role_id,omitempty" validate:"uuid"`
+	ExpiresInHours int     `json:"expires_in_hours,omitempty" validate:"min=1,max=720"`
+}
+
+func (s *Server) handleCreateInvitation(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	var req createInvitationRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	if req.Email == "" {
+		s.writeError(w, domain.ValidationError{Field: "email", Message: "required"})
+		return
+	}
+
+	var roleID *uuid.UUID
+	if req.RoleID != nil {
+		id, err := uuid.Parse(*req.RoleID)
+		if err != nil {
+			s.writeError(w, domain.ValidationError{Field: "role_id", Message: "invalid UUID"})
+			return
+		}
+		roleID = &id
+	}
+
+	userType := domain.UserType(req.UserType)
+	if req.UserType != "" && !userType.Valid() {
+		s.writeError(w, domain.ValidationError{Field: "user_type", Message: "invalid user type"})
+		return
+	}
+
+	inv, token, err := s.invitationService.CreateInvitation(r.Context(), service.CreateInvitationInput{
+		Email:     req.Email,
+		FullName:  req.FullName,
+		Type:      userType,
+		RoleID:    roleID,
+		InvitedBy: claims.UserID,
+		ExpiresIn: time.Duration(req.ExpiresInHours) * time.Hour,
+	})
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusCreated, issuedInvitationResponse{
+		Invitation: toInvitationResponse(inv),
+		Token:      token,
+	})
+}
+
+func (s *Server) handleListInvitations(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := storage.InvitationFilter{
+		Email:  query.Get("email"),
+		Offset: 0,
+		Limit:  20,
+	}
+
+	if v, err := strconv.Atoi(query.Get("offset")); err == nil && v >= 0 {
+		filter.Offset = v
+	}
+	if v, err := strconv.Atoi(query.Get("limit")); err == nil && v > 0 && v <= 100 {
+		filter.Limit = v
+	}
+
+	if status := query.Get("status"); status != "" {
+		st := domain.InvitationStatus(status)
+		if !st.Valid() {
+			s.writeError(w, domain.ValidationError{Field: "status", Message: "invalid status"})
+			return
+		}
+		filter.Status = &st
+	}
+
+	invitations, total, err := s.invitationService.ListInvitations(r.Context(), filter)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	invitationResponses := make([]invitationResponse, len(invitations))
+	for i, inv := range invitations {
+		invitationResponses[i] = toInvitationResponse(&inv)
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"invitations": invitationResponses,
+		"total":       total,
+		"offset":      filter.Offset,
+		"limit":       filter.Limit,
+	})
+}
+
+func (s *Server) handleGetInvitation(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	inv, err := s.invitationService.GetInvitation(r.Context(), id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toInvitationResponse(inv))
+}
+
+type resendInvitationRequest struct {
+	ExpiresInHours int `json:"expires_in_hours,omitempty" validate:"min=1,max=720"`
+}
+
+func (s *Server) handleResendInvitation(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	// The body is optional
+	var req resendInvitationRequest
+	if r.ContentLength != 0 {
+		if err := s.readJSON(r, &req); err != nil {
+			s.writeError(w, err)
+			return
+		}
+	}
+
+	inv, token, err := s.invitationService.ResendInvitation(r.Context(), id, time.Duration(req.ExpiresInHours)*time.Hour)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, issuedInvitationResponse{
+		Invitation: toInvitationResponse(inv),
+		Token:      token,
+	})
+}
+
+func (s *Server) handleRevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	inv, err := s.invitationService.RevokeInvitation(r.Context(), id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toInvitationResponse(inv))
+}
+
+type acceptInvitationRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Username string `json:"username" validate:"required"`
+	FullName string `json:"full_name,omitempty" validate:"max=200"`
+	Password string `json:"password" validate:"required"`
+}
+
+func (s *Server) handleAcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var req acceptInvitationRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	if req.Token == "" {
+		s.writeError(w, domain.ValidationError{Field: "token", Message: "required"})
+		return
+	}
+
+	user, err := s.invitationService.AcceptInvitation(r.Context(), service.AcceptInvitationInput{
+		Token:    req.Token,
+		Username: req.Username,
+		FullName: req.FullName,
+		Password: req.Password,
+	})
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	// Log the new user in, as registration does
+	result, err := s.authService.Login(r.Context(), service.LoginInput{
+		Email:     user.Email,
+		Password:  req.Password,
+		IPAddress: getClientIP(r),
+		UserAgent: r.UserAgent(),
+	})
+	if err != nil {
+		s.writeJSON(w, http.StatusOK, map[string]any{
+			"user": toUserResponse(user),
+		})
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, authResponse{
+		AccessToken:  result.AccessToken,
+		RefreshToken: result.RefreshToken,
+		ExpiresIn:    result.ExpiresInSeconds,
+		User:         toUserResponse(result.User),
+	})
+}
@@ -0,0 +1,9 @@
+package http
+
+import "net/http"
+
+// handleJobStats reports whether this replica runs the background jobs and
+// what each has done since startup.
+func (s *Server) handleJobStats(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, http.StatusOK, s.scheduler.Stats())
+}
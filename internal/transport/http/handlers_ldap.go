@@ -0,0 +1,172 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// LDAP group mapping response types
+
+type ldapMappingResponse struct {
+	ID        string `json:"id"`
+	GroupDN   string `json:"group_dn"`
+	RoleID    string `json:"role_id"`
+	RoleName  string `json:"role_name"`
+	CreatedAt string `json:"created_at"`
+}
+
+func toLDAPMappingResponse(m *domain.LDAPGroupMapping) ldapMappingResponse {
+	return ldapMappingResponse{
+		ID:        m.ID.String(),
+		GroupDN:   m.GroupDN,
+		RoleID:    m.RoleID.String(),
+		RoleName:  m.RoleName,
+		CreatedAt: m.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// Federated identity response types
+
+type federatedIdentityResponse struct {
+	ID        string `json:"id"`
+	Subject   string `json:"subject"`
+	UserID    string `json:"user_id"`
+	CreatedAt string `json:"created_at"`
+}
+
+func toFederatedIdentityResponse(fi *domain.FederatedIdentity) federatedIdentityResponse {
+	return federatedIdentityResponse{
+		ID:        fi.ID.String(),
+		Subject:   fi.Subject,
+		UserID:    fi.UserID.String(),
+		CreatedAt: fi.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// LDAP group mapping handlers
+
+type createLDAPMappingRequest struct {
+	GroupDN string `json:"group_dn" validate:"required,max=500"`
+	RoleID  string `json:"role_id" validate:"required,uuid"`
+}
+
+func (s *Server) handleListLDAPMappings(w http.ResponseWriter, r *http.Request) {
+	mappings, err := s.ldapService.ListMappings(r.Context())
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	resp := make([]ldapMappingResponse, len(mappings))
+	for i := range mappings {
+		resp[i] = toLDAPMappingResponse(&mappings[i])
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{"mappings": resp})
+}
+
+// handleCreateLDAPMapping gives the members of an LDAP group a role, from
+// their next sign-in.
+func (s *Server) handleCreateLDAPMapping(w http.ResponseWriter, r *http.Request) {
+	var req createLDAPMappingRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	roleID, err := uuid.Parse(req.RoleID)
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "role_id", Message: "invalid UUID"})
+		return
+	}
+
+	mapping, err := s.ldapService.CreateMapping(r.Context(), req.GroupDN, roleID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusCreated, toLDAPMappingResponse(mapping))
+}
+
+func (s *Server) handleDeleteLDAPMapping(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	if err := s.ldapService.DeleteMapping(r.Context(), id); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusNoContent, nil)
+}
+
+// LDAP identity link handlers
+
+type linkLDAPIdentityRequest struct {
+	DN     string `json:"dn" validate:"required,max=1000"`
+	UserID string `json:"user_id" validate:"required,uuid"`
+}
+
+func (s *Server) handleListLDAPIdentities(w http.ResponseWriter, r *http.Request) {
+	identities, err := s.ldapService.ListIdentities(r.Context())
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	resp := make([]federatedIdentityResponse, len(identities))
+	for i := range identities {
+		resp[i] = toFederatedIdentityResponse(&identities[i])
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{"identities": resp})
+}
+
+// handleLinkLDAPIdentity has a directory entry sign in to an existing
+// account, which sign-in won't link by itself if it's privileged or its
+// email is outside LDAP_EMAIL_DOMAINS.
+func (s *Server) handleLinkLDAPIdentity(w http.ResponseWriter, r *http.Request) {
+	var req linkLDAPIdentityRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "user_id", Message: "invalid UUID"})
+		return
+	}
+
+	identity, err := s.ldapService.LinkIdentity(r.Context(), req.DN, userID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusCreated, toFederatedIdentityResponse(identity))
+}
+
+func (s *Server) handleUnlinkLDAPIdentity(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	if err := s.ldapService.UnlinkIdentity(r.Context(), id); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusNoContent, nil)
+}
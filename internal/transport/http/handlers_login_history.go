@@ -0,0 +1,118 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// Login history response types
+
+type loginAttemptResponse struct {
+	ID            string `json:"id"`
+	Success       bool   `json:"success"`
+	FailureReason string `json:"failure_reason,omitempty"`
+	IPAddress     string `json:"ip_address"`
+	UserAgent     string `json:"user_agent"`
+	CreatedAt     string `json:"created_at"`
+}
+
+func toLoginAttemptResponse(a *domain.LoginAttempt) loginAttemptResponse {
+	return loginAttemptResponse{
+		ID:            a.ID.String(),
+		Success:       a.Success,
+		FailureReason: a.FailureReason,
+		IPAddress:     a.IPAddress,
+		UserAgent:     a.UserAgent,
+		CreatedAt:     a.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// Login history handlers
+
+func (s *Server) handleListCurrentUserLoginHistory(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	s.writeLoginHistory(w, r, claims.UserID)
+}
+
+func (s *Server) handleListUserLoginHistory(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	s.writeLoginHistory(w, r, userID)
+}
+
+// writeLoginHistory lists a user's login attempts, filtered by the success,
+// since, until and ip query parameters.
+func (s *Server) writeLoginHistory(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	query := r.URL.Query()
+
+	filter := storage.LoginAttemptFilter{
+		UserID:    userID,
+		IPAddress: query.Get("ip"),
+		Offset:    0,
+		Limit:     20,
+	}
+
+	if offset, err := strconv.Atoi(query.Get("offset")); err == nil && offset >= 0 {
+		filter.Offset = offset
+	}
+	if limit, err := strconv.Atoi(query.Get("limit")); err == nil && limit > 0 && limit <= 100 {
+		filter.Limit = limit
+	}
+
+	if success, err := strconv.ParseBool(query.Get("success")); err == nil {
+		filter.Success = &success
+	}
+
+	for _, param := range []struct {
+		name string
+		dest **time.Time
+	}{
+		{"since", &filter.Since},
+		{"until", &filter.Until},
+	} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			s.writeError(w, domain.ValidationError{Field: param.name, Message: "must be an RFC 3339 timestamp"})
+			return
+		}
+		*param.dest = &t
+	}
+
+	attempts, total, err := s.authService.ListLoginHistory(r.Context(), filter)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	attemptResponses := make([]loginAttemptResponse, len(attempts))
+	for i := range attempts {
+		attemptResponses[i] = toLoginAttemptResponse(&attempts[i])
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"attempts": attemptResponses,
+		"total":    total,
+		"offset":   filter.Offset,
+		"limit":    filter.Limit,
+	})
+}
@@ -0,0 +1,73 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/mvaleed/aegis/internal/event"
+)
+
+// handleMetrics serves the event pipeline's health in the Prometheus text
+// exposition format, for scraping. Counters are since startup, so alert on
+// their rate().
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	pipeline, err := s.pipelineService.Health(r.Context())
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	var b bytes.Buffer
+	m := metricsWriter{&b}
+
+	events := pipeline.Events
+	m.gauge("aegis_events_buffered", "Events waiting for the broker in memory.", float64(events.Buffered))
+	m.gauge("aegis_events_spooled", "Events waiting for the broker on disk.", float64(events.Spooled))
+	m.gauge("aegis_events_oldest_age_seconds", "Age of the oldest event waiting for the broker; 0 when none is.", pipeline.OldestEventAge)
+	m.counter("aegis_events_published_total", "Events delivered to the broker.", float64(events.Published))
+	m.counter("aegis_events_publish_failures_total", "Failed attempts to deliver events to the broker.", float64(events.Failures))
+	m.counter("aegis_events_dropped_total", "Events lost to a full buffer and spool.", float64(events.Dropped))
+
+	m.help("aegis_events_breaker_state", "gauge", "Circuit breaker state of event delivery; 1 for the current state.")
+	for _, state := range []event.BreakerState{event.BreakerClosed, event.BreakerOpen, event.BreakerHalfOpen} {
+		var v float64
+		if events.Breaker == state {
+			v = 1
+		}
+		fmt.Fprintf(&b, "aegis_events_breaker_state{state=%q} %g\n", state, v)
+	}
+
+	m.gauge("aegis_webhook_deliveries_pending", "Webhook deliveries not yet delivered or dead-lettered.", float64(pipeline.Webhooks.Pending))
+	m.gauge("aegis_webhook_deliveries_due", "Pending webhook deliveries whose next attempt is due.", float64(pipeline.Webhooks.Due))
+	m.gauge("aegis_webhook_deliveries_oldest_delay_seconds", "How long the oldest due webhook delivery has waited; 0 when none is due.", pipeline.OldestWebhookDelay)
+
+	var healthy float64
+	if pipeline.Healthy {
+		healthy = 1
+	}
+	m.gauge("aegis_pipeline_healthy", "1 while the event pipeline is within its alert thresholds.", healthy)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(b.Bytes())
+}
+
+// metricsWriter writes metrics in the Prometheus text exposition format.
+type metricsWriter struct {
+	b *bytes.Buffer
+}
+
+func (m metricsWriter) help(name, kind, help string) {
+	fmt.Fprintf(m.b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (m metricsWriter) gauge(name, help string, v float64) {
+	m.help(name, "gauge", help)
+	fmt.Fprintf(m.b, "%s %g\n", name, v)
+}
+
+func (m metricsWriter) counter(name, help string, v float64) {
+	m.help(name, "counter", help)
+	fmt.Fprintf(m.b, "%s %g\n", name, v)
+}
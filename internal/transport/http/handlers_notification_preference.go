@@ -0,0 +1,61 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+)
+
+// notificationPreferencesRequest and the response share a shape: whether
+// each category of notification is sent over each channel, e.g.
+// {"notifications": {"security": {"email": true, "sms": false}}}.
+type notificationPreferencesRequest struct {
+	Notifications service.NotificationPreferences `json:"notifications"`
+}
+
+type notificationPreferencesResponse struct {
+	Notifications service.NotificationPreferences `json:"notifications"`
+}
+
+// handleGetNotificationPreferences returns the current user's notification
+// preferences, for every category and channel.
+func (s *Server) handleGetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	prefs, err := s.notificationPreferences.Get(r.Context(), claims.UserID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, notificationPreferencesResponse{Notifications: prefs})
+}
+
+// handleUpdateNotificationPreferences sets the given notification
+// preferences of the current user, leaving those not given as they were.
+func (s *Server) handleUpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	var req notificationPreferencesRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	prefs, err := s.notificationPreferences.Update(r.Context(), claims.UserID, req.Notifications)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, notificationPreferencesResponse{Notifications: prefs})
+}
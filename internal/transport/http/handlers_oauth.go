@@ -0,0 +1,353 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+)
+
+// Grant types the token endpoint supports
+const (
+	deviceCodeGrantType        = "urn:ietf:params:oauth:grant-type:device_code"
+	clientCredentialsGrantType = "client_credentials"
+	tokenExchangeGrantType     = "urn:ietf:params:oauth:grant-type:token-exchange"
+)
+
+// oauthErrorResponse is an error in the shape OAuth 2.0 clients expect
+// (RFC 6749 section 5.2), rather than errorResponse.
+type oauthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// writeOAuthError writes err as an OAuth 2.0 error. Errors without an
+// OAuth code go through writeError.
+func (s *Server) writeOAuthError(w http.ResponseWriter, err error) {
+	var code string
+	switch {
+	case errors.Is(err, domain.ErrAuthorizationPending):
+		code = "authorization_pending"
+	case errors.Is(err, domain.ErrSlowDown):
+		code = "slow_down"
+	case errors.Is(err, domain.ErrAccessDenied):
+		code = "access_denied"
+	case errors.Is(err, domain.ErrDeviceCodeExpired):
+		code = "expired_token"
+	case errors.Is(err, domain.ErrInvalidScope):
+		code = "invalid_scope"
+	case errors.Is(err, domain.ErrInvalidTarget):
+		code = "invalid_target"
+	case errors.Is(err, domain.ErrUnauthorizedClient):
+		code = "unauthorized_client"
+	case errors.Is(err, domain.ErrInvalidCredential), errors.Is(err, domain.ErrInvalidSubjectToken):
+		code = "invalid_grant"
+	case errors.Is(err, domain.ErrInvalidInput):
+		code = "invalid_request"
+	default:
+		s.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	s.writeJSON(w, http.StatusBadRequest, oauthErrorResponse{Error: code, ErrorDescription: err.Error()})
+}
+
+// handleToken is the OAuth 2.0 token endpoint, taking form-encoded bodies.
+// Password sign-in stays at /api/v1/auth/login.
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		s.writeOAuthError(w, domain.ValidationError{Field: "body", Message: "malformed form"})
+		return
+	}
+
+	switch r.PostForm.Get("grant_type") {
+	case deviceCodeGrantType:
+		s.handleDeviceCodeGrant(w, r)
+	case clientCredentialsGrantType:
+		s.handleClientCredentialsGrant(w, r)
+	case tokenExchangeGrantType:
+		s.handleTokenExchangeGrant(w, r)
+	default:
+		w.Header().Set("Cache-Control", "no-store")
+		s.writeJSON(w, http.StatusBadRequest, oauthErrorResponse{
+			Error:            "unsupported_grant_type",
+			ErrorDescription: "grant_type must be " + clientCredentialsGrantType + ", " + deviceCodeGrantType + " or " + tokenExchangeGrantType,
+		})
+	}
+}
+
+type clientTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// handleClientCredentialsGrant issues an OAuth client a token of its own.
+// The client authenticates with HTTP Basic or, failing that, client_id and
+// client_secret in the form; scope optionally narrows the permissions to
+// some of those its roles grant.
+func (s *Server) handleClientCredentialsGrant(w http.ResponseWriter, r *http.Request) {
+	clientID, secret, basic := r.BasicAuth()
+	if !basic {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID == "" || secret == "" {
+		s.writeOAuthError(w, domain.ValidationError{Field: "client_id", Message: "client_id and client_secret are required"})
+		return
+	}
+
+	token, err := s.oauthClientService.IssueToken(r.Context(), clientID, secret, r.PostForm.Get("scope"))
+	if errors.Is(err, domain.ErrInvalidCredential) {
+		// RFC 6749 section 5.2: 401, with a challenge if Basic was tried
+		if basic {
+			w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		s.writeJSON(w, http.StatusUnauthorized, oauthErrorResponse{Error: "invalid_client", ErrorDescription: err.Error()})
+		return
+	}
+	if err != nil {
+		s.writeOAuthError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	s.writeJSON(w, http.StatusOK, clientTokenResponse{
+		AccessToken: token.AccessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   token.ExpiresInSeconds,
+		Scope:       token.Scope,
+	})
+}
+
+// OAuth client response types
+
+type oauthClientResponse struct {
+	ID         string         `json:"id"` // Its client_id
+	Name       string         `json:"name"`
+	Enabled    bool           `json:"enabled"`
+	Roles      []roleResponse `json:"roles"`
+	CreatedBy  *string        `json:"created_by,omitempty"`
+	LastUsedAt *string        `json:"last_used_at,omitempty"`
+	CreatedAt  string         `json:"created_at"`
+	UpdatedAt  string         `json:"updated_at"`
+}
+
+// oauthClientSecretResponse is a client with its secret, returned once
+// when it's registered or rotated.
+type oauthClientSecretResponse struct {
+	oauthClientResponse
+	ClientSecret string `json:"client_secret"`
+}
+
+func toOAuthClientResponse(c *domain.OAuthClient) oauthClientResponse {
+	roles := make([]roleResponse, len(c.Roles))
+	for i := range c.Roles {
+		roles[i] = toRoleResponse(&c.Roles[i])
+	}
+
+	resp := oauthClientResponse{
+		ID:        c.ID.String(),
+		Name:      c.Name,
+		Enabled:   c.Enabled,
+		Roles:     roles,
+		CreatedAt: c.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: c.UpdatedAt.Format(time.RFC3339),
+	}
+	if c.CreatedBy != nil {
+		createdBy := c.CreatedBy.String()
+		resp.CreatedBy = &createdBy
+	}
+	if c.LastUsedAt != nil {
+		lastUsed := c.LastUsedAt.Format(time.RFC3339)
+		resp.LastUsedAt = &lastUsed
+	}
+	return resp
+}
+
+// OAuth client handlers
+
+type createOAuthClientRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+type updateOAuthClientRequest struct {
+	Name    *string `json:"name" validate:"max=100"`
+	Enabled *bool   `json:"enabled"`
+}
+
+type assignOAuthClientRoleRequest struct {
+	RoleID string `json:"role_id" validate:"required,uuid"`
+}
+
+func (s *Server) handleListOAuthClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := s.oauthClientService.ListClients(r.Context())
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	resp := make([]oauthClientResponse, len(clients))
+	for i := range clients {
+		resp[i] = toOAuthClientResponse(&clients[i])
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{"clients": resp})
+}
+
+func (s *Server) handleGetOAuthClient(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	client, err := s.oauthClientService.GetClient(r.Context(), id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toOAuthClientResponse(client))
+}
+
+func (s *Server) handleCreateOAuthClient(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	var req createOAuthClientRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	client, secret, err := s.oauthClientService.CreateClient(r.Context(), req.Name, claims.UserID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusCreated, oauthClientSecretResponse{
+		oauthClientResponse: toOAuthClientResponse(client),
+		ClientSecret:        secret,
+	})
+}
+
+func (s *Server) handleUpdateOAuthClient(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	var req updateOAuthClientRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	client, err := s.oauthClientService.UpdateClient(r.Context(), id, service.UpdateOAuthClientInput{
+		Name:    req.Name,
+		Enabled: req.Enabled,
+	})
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toOAuthClientResponse(client))
+}
+
+// handleRotateOAuthClientSecret replaces a client's secret; the old one
+// stops working at once.
+func (s *Server) handleRotateOAuthClientSecret(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	client, secret, err := s.oauthClientService.RotateSecret(r.Context(), id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, oauthClientSecretResponse{
+		oauthClientResponse: toOAuthClientResponse(client),
+		ClientSecret:        secret,
+	})
+}
+
+func (s *Server) handleDeleteOAuthClient(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	if err := s.oauthClientService.DeleteClient(r.Context(), id); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *Server) handleAssignRoleToOAuthClient(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	var req assignOAuthClientRoleRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	roleID, err := uuid.Parse(req.RoleID)
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "role_id", Message: "invalid UUID"})
+		return
+	}
+
+	client, err := s.oauthClientService.AssignRole(r.Context(), id, roleID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toOAuthClientResponse(client))
+}
+
+func (s *Server) handleRemoveRoleFromOAuthClient(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+	roleID, err := uuid.Parse(chi.URLParam(r, "roleId"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "roleId", Message: "invalid UUID"})
+		return
+	}
+
+	if err := s.oauthClientService.RemoveRole(r.Context(), id, roleID); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,179 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+)
+
+// Organization admin handlers. Routes are scoped to the orgId URL parameter
+// by requireOrganizationPermission, which has already checked it.
+
+// orgAdminTarget parses the organization and member IDs from the URL.
+func orgAdminTarget(r *http.Request) (orgID, userID uuid.UUID, err error) {
+	orgID, err = uuid.Parse(chi.URLParam(r, "orgId"))
+	if err != nil {
+		return orgID, userID, domain.ValidationError{Field: "orgId", Message: "invalid UUID"}
+	}
+	userID, err = uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		return orgID, userID, domain.ValidationError{Field: "userId", Message: "invalid UUID"}
+	}
+	return orgID, userID, nil
+}
+
+func (s *Server) handleOrgAdminListMembers(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgId"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "orgId", Message: "invalid UUID"})
+		return
+	}
+
+	query := r.URL.Query()
+
+	offset, limit := 0, 20
+	if v, err := strconv.Atoi(query.Get("offset")); err == nil && v >= 0 {
+		offset = v
+	}
+	if v, err := strconv.Atoi(query.Get("limit")); err == nil && v > 0 && v <= 100 {
+		limit = v
+	}
+
+	members, total, err := s.orgAdminService.ListMembers(r.Context(), orgID, offset, limit)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	memberResponses := make([]organizationMemberResponse, len(members))
+	for i, m := range members {
+		memberResponses[i] = toOrganizationMemberResponse(&m)
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"members": memberResponses,
+		"total":   total,
+		"offset":  offset,
+		"limit":   limit,
+	})
+}
+
+type orgAdminInviteRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	FullName string `json:"full_name,omitempty" validate:"max=200"`
+}
+
+// handleOrgAdminInvite invites a new user into the organization. The
+// invitation is emailed; its token isn't returned.
+func (s *Server) handleOrgAdminInvite(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgId"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "orgId", Message: "invalid UUID"})
+		return
+	}
+
+	var req orgAdminInviteRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	if req.Email == "" {
+		s.writeError(w, domain.ValidationError{Field: "email", Message: "required"})
+		return
+	}
+
+	inv, err := s.orgAdminService.InviteMember(r.Context(), service.InviteMemberInput{
+		OrganizationID: orgID,
+		Email:          req.Email,
+		FullName:       req.FullName,
+		InvitedBy:      claims.UserID,
+	})
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusCreated, toInvitationResponse(inv))
+}
+
+func (s *Server) handleOrgAdminSuspend(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+
+	orgID, userID, err := orgAdminTarget(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	var req suspendRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	if err := s.orgAdminService.SuspendMember(r.Context(), claims.UserID, orgID, userID, req.Reason); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]string{"message": "member suspended"})
+}
+
+func (s *Server) handleOrgAdminReactivate(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+
+	orgID, userID, err := orgAdminTarget(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	if err := s.orgAdminService.ReactivateMember(r.Context(), claims.UserID, orgID, userID); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]string{"message": "member reactivated"})
+}
+
+func (s *Server) handleOrgAdminRevokeSessions(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+
+	orgID, userID, err := orgAdminTarget(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	if err := s.orgAdminService.RevokeMemberSessions(r.Context(), claims.UserID, orgID, userID); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]string{"message": "member signed out everywhere"})
+}
+
+func (s *Server) handleOrgAdminRemove(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+
+	orgID, userID, err := orgAdminTarget(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	if err := s.orgAdminService.RemoveMember(r.Context(), claims.UserID, orgID, userID); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,287 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+)
+
+// Organization response types
+
+type organizationResponse struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Slug      string `json:"slug"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type organizationMemberResponse struct {
+	OrganizationID string `json:"organization_id"`
+	UserID         string `json:"user_id"`
+	JoinedAt       string `json:"joined_at"`
+}
+
+func toOrganizationResponse(o *domain.Organization) organizationResponse {
+	return organizationResponse{
+		ID:        o.ID.String(),
+		Name:      o.Name,
+		Slug:      o.Slug,
+		CreatedAt: o.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: o.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func toOrganizationMemberResponse(m *domain.OrganizationMember) organizationMemberResponse {
+	return organizationMemberResponse{
+		OrganizationID: m.OrganizationID.String(),
+		UserID:         m.UserID.String(),
+		JoinedAt:       m.JoinedAt.Format(time.RFC3339),
+	}
+}
+
+// organizationScope reads the optional ?organization_id= list scope.
+func organizationScope(r *http.Request) (*uuid.UUID, error) {
+	raw := r.URL.Query().Get("organization_id")
+	if raw == "" {
+		return nil, nil
+	}
+
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, domain.ValidationError{Field: "organization_id", Message: "invalid UUID"}
+	}
+	return &id, nil
+}
+
+// Organization handlers
+
+type createOrganizationRequest struct {
+	Name string `json:"name" validate:"required,max=200"`
+	Slug string `json:"slug" validate:"required,max=63"`
+}
+
+func (s *Server) handleCreateOrganization(w http.ResponseWriter, r *http.Request) {
+	var req createOrganizationRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	org, err := s.orgService.CreateOrganization(r.Context(), req.Name, req.Slug)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusCreated, toOrganizationResponse(org))
+}
+
+func (s *Server) handleListOrganizations(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	offset, limit := 0, 20
+	if v, err := strconv.Atoi(query.Get("offset")); err == nil && v >= 0 {
+		offset = v
+	}
+	if v, err := strconv.Atoi(query.Get("limit")); err == nil && v > 0 && v <= 100 {
+		limit = v
+	}
+
+	orgs, total, err := s.orgService.ListOrganizations(r.Context(), offset, limit)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	orgResponses := make([]organizationResponse, len(orgs))
+	for i, o := range orgs {
+		orgResponses[i] = toOrganizationResponse(&o)
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"organizations": orgResponses,
+		"total":         total,
+		"offset":        offset,
+		"limit":         limit,
+	})
+}
+
+func (s *Server) handleGetOrganization(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	org, err := s.orgService.GetOrganization(r.Context(), id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toOrganizationResponse(org))
+}
+
+type updateOrganizationRequest struct {
+	Name *string `json:"name,omitempty" validate:"max=200"`
+	Slug *string `json:"slug,omitempty" validate:"max=63"`
+}
+
+func (s *Server) handleUpdateOrganization(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	var req updateOrganizationRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	org, err := s.orgService.UpdateOrganization(r.Context(), id, service.UpdateOrganizationInput{
+		Name: req.Name,
+		Slug: req.Slug,
+	})
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toOrganizationResponse(org))
+}
+
+func (s *Server) handleDeleteOrganization(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	if err := s.orgService.DeleteOrganization(r.Context(), id); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleListOrganizationMembers(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	query := r.URL.Query()
+
+	offset, limit := 0, 20
+	if v, err := strconv.Atoi(query.Get("offset")); err == nil && v >= 0 {
+		offset = v
+	}
+	if v, err := strconv.Atoi(query.Get("limit")); err == nil && v > 0 && v <= 100 {
+		limit = v
+	}
+
+	members, total, err := s.orgService.ListMembers(r.Context(), id, offset, limit)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	memberResponses := make([]organizationMemberResponse, len(members))
+	for i, m := range members {
+		memberResponses[i] = toOrganizationMemberResponse(&m)
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"members": memberResponses,
+		"total":   total,
+		"offset":  offset,
+		"limit":   limit,
+	})
+}
+
+type addOrganizationMemberRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+}
+
+func (s *Server) handleAddOrganizationMember(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	var req addOrganizationMemberRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "user_id", Message: "invalid UUID"})
+		return
+	}
+
+	if err := s.orgService.AddMember(r.Context(), orgID, userID); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]string{"message": "member added"})
+}
+
+func (s *Server) handleRemoveOrganizationMember(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	userID, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "userId", Message: "invalid UUID"})
+		return
+	}
+
+	if err := s.orgService.RemoveMember(r.Context(), orgID, userID); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]string{"message": "member removed"})
+}
+
+func (s *Server) handleListCurrentUserOrganizations(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	orgs, err := s.orgService.ListUserOrganizations(r.Context(), claims.UserID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	orgResponses := make([]organizationResponse, len(orgs))
+	for i, o := range orgs {
+		orgResponses[i] = toOrganizationResponse(&o)
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"organizations": orgResponses,
+		"total":         len(orgs),
+	})
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/mvaleed/aegis/internal/service"
+)
+
+// Policy source handlers. Each builds the policy document afresh; responses
+// carry its revision as ETag, and a matching If-None-Match gets 304 so
+// polling engines don't download it again.
+
+// policyDocument builds the document, or writes 304 or an error and
+// returns nil.
+func (s *Server) policyDocument(w http.ResponseWriter, r *http.Request) *service.PolicyDocument {
+	doc, err := s.policyService.Document(r.Context())
+	if err != nil {
+		s.writeError(w, err)
+		return nil
+	}
+
+	etag := `"` + doc.Revision + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+	return doc
+}
+
+// handleOPAData serves the policy document, to load into OPA under
+// data.aegis (e.g. PUT /v1/data/aegis) or to read directly.
+func (s *Server) handleOPAData(w http.ResponseWriter, r *http.Request) {
+	if doc := s.policyDocument(w, r); doc != nil {
+		s.writeJSON(w, http.StatusOK, doc)
+	}
+}
+
+// handleOPABundle serves the document and the aegis.authz policy as an OPA
+// bundle, for OPA's bundle service to poll.
+func (s *Server) handleOPABundle(w http.ResponseWriter, r *http.Request) {
+	doc := s.policyDocument(w, r)
+	if doc == nil {
+		return
+	}
+
+	bundle, err := service.OPABundle(doc)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(bundle)
+}
+
+// handleCasbinPolicy serves the document as Casbin policy lines for the
+// model served by handleCasbinModel.
+func (s *Server) handleCasbinPolicy(w http.ResponseWriter, r *http.Request) {
+	doc := s.policyDocument(w, r)
+	if doc == nil {
+		return
+	}
+
+	policy, err := service.CasbinPolicy(doc)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(policy)
+}
+
+func (s *Server) handleCasbinModel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(service.CasbinModel))
+}
@@ -0,0 +1,415 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+)
+
+// Portal response types
+
+type apiKeyResponse struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Environment string   `json:"environment"`
+	Prefix      string   `json:"prefix"`
+	Key         string   `json:"key,omitempty"` // Only set on create and regenerate
+	Scopes      []string `json:"scopes"`
+	Revoked     bool     `json:"revoked"`
+	LastUsedAt  *string  `json:"last_used_at,omitempty"`
+	RotatedAt   *string  `json:"rotated_at,omitempty"`
+	CreatedAt   string   `json:"created_at"`
+}
+
+func toAPIKeyResponse(k *domain.APIKey, token string) apiKeyResponse {
+	resp := apiKeyResponse{
+		ID:          k.ID.String(),
+		Name:        k.Name,
+		Environment: string(k.Environment),
+		Prefix:      k.Prefix,
+		Key:         token,
+		Scopes:      k.Scopes,
+		Revoked:     k.IsRevoked(),
+		CreatedAt:   k.CreatedAt.Format(time.RFC3339),
+	}
+
+	if resp.Scopes == nil {
+		resp.Scopes = []string{}
+	}
+	if k.LastUsedAt != nil {
+		t := k.LastUsedAt.Format(time.RFC3339)
+		resp.LastUsedAt = &t
+	}
+	if k.RotatedAt != nil {
+		t := k.RotatedAt.Format(time.RFC3339)
+		resp.RotatedAt = &t
+	}
+
+	return resp
+}
+
+type apiKeyUsageResponse struct {
+	Day      string `json:"day"`
+	Requests int64  `json:"requests"`
+}
+
+// API key handlers
+
+type createAPIKeyRequest struct {
+	Name        string   `json:"name" validate:"required,max=100"`
+	Environment string   `json:"environment" validate:"oneof=sandbox production"`
+	Scopes      []string `json:"scopes"`
+}
+
+func (s *Server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	var req createAPIKeyRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	key, token, err := s.portalService.CreateAPIKey(r.Context(), service.CreateAPIKeyInput{
+		OwnerID:     claims.UserID,
+		Name:        req.Name,
+		Environment: domain.APIKeyEnvironment(req.Environment),
+		Scopes:      req.Scopes,
+		Granted:     claims.Permissions,
+		Denied:      claims.Denied,
+	})
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusCreated, toAPIKeyResponse(key, token))
+}
+
+func (s *Server) handleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	keys, err := s.portalService.ListAPIKeys(r.Context(), claims.UserID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	keyResponses := make([]apiKeyResponse, len(keys))
+	for i := range keys {
+		keyResponses[i] = toAPIKeyResponse(&keys[i], "")
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"api_keys": keyResponses,
+		"total":    len(keys),
+	})
+}
+
+// handleGetAPIKeyUsage returns daily request counts; ?days= picks the
+// window (default 30, at most 90).
+func (s *Server) handleGetAPIKeyUsage(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	keyID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+
+	usage, err := s.portalService.GetAPIKeyUsage(r.Context(), claims.UserID, keyID, days)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	var total int64
+	usageResponses := make([]apiKeyUsageResponse, len(usage))
+	for i, u := range usage {
+		usageResponses[i] = apiKeyUsageResponse{Day: u.Day.Format(time.DateOnly), Requests: u.Requests}
+		total += u.Requests
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"usage":    usageResponses,
+		"requests": total,
+	})
+}
+
+func (s *Server) handleRegenerateAPIKey(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	keyID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	key, token, err := s.portalService.RegenerateAPIKey(r.Context(), claims.UserID, keyID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toAPIKeyResponse(key, token))
+}
+
+func (s *Server) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	keyID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	if err := s.portalService.RevokeAPIKey(r.Context(), claims.UserID, keyID); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Partner webhook handlers
+
+func (s *Server) handleCreatePortalWebhook(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	var req createWebhookRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	webhook, err := s.portalService.CreateWebhook(r.Context(), claims.UserID, req.URL, req.Description, req.EventTypes)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusCreated, toWebhookResponse(webhook, true))
+}
+
+func (s *Server) handleListPortalWebhooks(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	webhooks, err := s.portalService.ListWebhooks(r.Context(), claims.UserID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	webhookResponses := make([]webhookResponse, len(webhooks))
+	for i := range webhooks {
+		webhookResponses[i] = toWebhookResponse(&webhooks[i], false)
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"webhooks": webhookResponses,
+		"total":    len(webhooks),
+	})
+}
+
+func (s *Server) handleUpdatePortalWebhook(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	var req updateWebhookRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	webhook, err := s.portalService.UpdateWebhook(r.Context(), claims.UserID, id, service.UpdateWebhookInput{
+		URL:         req.URL,
+		Description: req.Description,
+		EventTypes:  req.EventTypes,
+		Active:      req.Active,
+	})
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toWebhookResponse(webhook, false))
+}
+
+func (s *Server) handleRotatePortalWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	webhook, err := s.portalService.RotateWebhookSecret(r.Context(), claims.UserID, id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toWebhookResponse(webhook, true))
+}
+
+func (s *Server) handleDeletePortalWebhook(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	if err := s.portalService.DeleteWebhook(r.Context(), claims.UserID, id); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleSendTestPortalWebhook(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	delivery, err := s.portalService.SendTestWebhook(r.Context(), claims.UserID, id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toWebhookDeliveryResponse(delivery, true))
+}
+
+func (s *Server) handleListPortalWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	filter, ok := s.parseDeliveryFilter(w, r, id)
+	if !ok {
+		return
+	}
+
+	deliveries, total, err := s.portalService.ListWebhookDeliveries(r.Context(), claims.UserID, filter)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeDeliveries(w, deliveries, total, filter)
+}
+
+func (s *Server) handleGetPortalWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	webhookID, deliveryID, ok := s.parseDeliveryPath(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := s.portalService.GetWebhookDelivery(r.Context(), claims.UserID, webhookID, deliveryID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toWebhookDeliveryResponse(delivery, true))
+}
+
+func (s *Server) handleRedeliverPortalWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	webhookID, deliveryID, ok := s.parseDeliveryPath(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := s.portalService.RedeliverWebhookDelivery(r.Context(), claims.UserID, webhookID, deliveryID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusAccepted, toWebhookDeliveryResponse(delivery, false))
+}
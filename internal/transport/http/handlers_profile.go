@@ -0,0 +1,217 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// Profile response types
+
+type profileResponse struct {
+	ID          string         `json:"id"`
+	Type        string         `json:"type"`
+	DisplayName string         `json:"display_name,omitempty"`
+	Roles       []roleResponse `json:"roles"`
+	CreatedAt   string         `json:"created_at"`
+}
+
+func toProfileResponse(p *domain.Profile) profileResponse {
+	roles := make([]roleResponse, len(p.Roles))
+	for i := range p.Roles {
+		roles[i] = toRoleResponse(&p.Roles[i])
+	}
+	return profileResponse{
+		ID:          p.ID.String(),
+		Type:        string(p.Type),
+		DisplayName: p.DisplayName,
+		Roles:       roles,
+		CreatedAt:   p.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// Profile handlers
+
+type createProfileRequest struct {
+	Type        string `json:"type" validate:"required,oneof=customer partner"`
+	DisplayName string `json:"display_name" validate:"max=200"`
+}
+
+func (s *Server) handleCreateProfile(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	var req createProfileRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	profile, err := s.profileService.CreateProfile(r.Context(), claims.UserID, domain.UserType(req.Type), req.DisplayName)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusCreated, toProfileResponse(profile))
+}
+
+func (s *Server) handleListProfiles(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	profiles, err := s.profileService.ListProfiles(r.Context(), claims.UserID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	profileResponses := make([]profileResponse, len(profiles))
+	for i := range profiles {
+		profileResponses[i] = toProfileResponse(&profiles[i])
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"profiles": profileResponses,
+		"total":    len(profiles),
+	})
+}
+
+func (s *Server) handleDetachProfile(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	profileID, err := uuid.Parse(chi.URLParam(r, "profileId"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "profileId", Message: "invalid UUID"})
+		return
+	}
+
+	if err := s.profileService.DetachProfile(r.Context(), claims.UserID, profileID); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type switchProfileRequest struct {
+	ProfileID string `json:"profile_id" validate:"uuid"` // Empty to switch back to the user
+}
+
+// handleSwitchProfile issues tokens for acting as one of the caller's
+// profiles, or as the user again when no profile is given.
+func (s *Server) handleSwitchProfile(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	var req switchProfileRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	var profileID *uuid.UUID
+	if req.ProfileID != "" {
+		id, err := uuid.Parse(req.ProfileID)
+		if err != nil {
+			s.writeError(w, domain.ValidationError{Field: "profile_id", Message: "invalid UUID"})
+			return
+		}
+		profileID = &id
+	}
+
+	result, err := s.authService.SwitchProfile(r.Context(), claims.UserID, profileID, claims.Scope, getClientIP(r), r.UserAgent())
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, authResponse{
+		AccessToken:  result.AccessToken,
+		RefreshToken: result.RefreshToken,
+		ExpiresIn:    result.ExpiresInSeconds,
+		Scope:        result.Scope,
+		User:         toUserResponse(result.User),
+	})
+}
+
+func (s *Server) handleAssignRoleToProfile(w http.ResponseWriter, r *http.Request) {
+	userID, profileID, ok := s.profilePathIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req assignRoleRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	roleID, err := uuid.Parse(req.RoleID)
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "role_id", Message: "invalid UUID"})
+		return
+	}
+
+	if err := s.profileService.AssignRole(r.Context(), userID, profileID, roleID); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]string{"message": "role assigned to profile"})
+}
+
+func (s *Server) handleRemoveRoleFromProfile(w http.ResponseWriter, r *http.Request) {
+	userID, profileID, ok := s.profilePathIDs(w, r)
+	if !ok {
+		return
+	}
+
+	roleID, err := uuid.Parse(chi.URLParam(r, "roleId"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "role_id", Message: "invalid UUID"})
+		return
+	}
+
+	if err := s.profileService.RemoveRole(r.Context(), userID, profileID, roleID); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]string{"message": "role removed from profile"})
+}
+
+// profilePathIDs parses the user and profile IDs of /users/{id}/profiles/
+// {profileId} routes, writing the error response if either is invalid.
+func (s *Server) profilePathIDs(w http.ResponseWriter, r *http.Request) (userID, profileID uuid.UUID, ok bool) {
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	profileID, err = uuid.Parse(chi.URLParam(r, "profileId"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "profileId", Message: "invalid UUID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, profileID, true
+}
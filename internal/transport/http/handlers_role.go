@@ -2,22 +2,29 @@ package http
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
 	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+	"github.com/mvaleed/aegis/internal/storage"
 )
 
 // Role response types
 
 type roleResponse struct {
-	ID          string               `json:"id"`
-	Name        string               `json:"name"`
-	Description string               `json:"description"`
-	Permissions []permissionResponse `json:"permissions,omitempty"`
-	CreatedAt   string               `json:"created_at"`
-	UpdatedAt   string               `json:"updated_at"`
+	ID             string               `json:"id"`
+	Name           string               `json:"name"`
+	Description    string               `json:"description"`
+	OrganizationID *string              `json:"organization_id,omitempty"`
+	Permissions    []permissionResponse `json:"permissions,omitempty"`
+	Assignments    *assignmentsResponse `json:"assignments,omitempty"`
+	ExpiresAt      *string              `json:"expires_at,omitempty"`
+	CreatedAt      string               `json:"created_at"`
+	UpdatedAt      string               `json:"updated_at"`
 }
 
 type permissionResponse struct {
@@ -26,6 +33,18 @@ type permissionResponse struct {
 	Action      string `json:"action"`
 	Description string `json:"description"`
 	CreatedAt   string `json:"created_at"`
+	Deny        bool   `json:"deny,omitempty"` // In a role: denied rather than granted
+}
+
+type assignmentsResponse struct {
+	Users    int64 `json:"users"`
+	Groups   int64 `json:"groups"`
+	Profiles int64 `json:"profiles"`
+}
+
+type permissionGroupResponse struct {
+	Resource    string               `json:"resource"`
+	Permissions []permissionResponse `json:"permissions"`
 }
 
 func toRoleResponse(r *domain.Role) roleResponse {
@@ -37,10 +56,28 @@ func toRoleResponse(r *domain.Role) roleResponse {
 		UpdatedAt:   r.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
 
+	if r.OrganizationID != nil {
+		orgID := r.OrganizationID.String()
+		resp.OrganizationID = &orgID
+	}
+
 	for _, p := range r.Permissions {
 		resp.Permissions = append(resp.Permissions, toPermissionResponse(&p))
 	}
 
+	if r.ExpiresAt != nil {
+		expiresAt := r.ExpiresAt.Format("2006-01-02T15:04:05Z07:00")
+		resp.ExpiresAt = &expiresAt
+	}
+
+	if r.Assignments != nil {
+		resp.Assignments = &assignmentsResponse{
+			Users:    r.Assignments.Users,
+			Groups:   r.Assignments.Groups,
+			Profiles: r.Assignments.Profiles,
+		}
+	}
+
 	return resp
 }
 
@@ -51,14 +88,16 @@ func toPermissionResponse(p *domain.Permission) permissionResponse {
 		Action:      p.Action,
 		Description: p.Description,
 		CreatedAt:   p.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		Deny:        p.Deny,
 	}
 }
 
 // Role handlers
 
 type createRoleRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name           string  `json:"name" validate:"required,max=50"`
+	Description    string  `json:"description"`
+	OrganizationID *string `json:"organization_id,omitempty" validate:"uuid"`
 }
 
 func (s *Server) handleCreateRole(w http.ResponseWriter, r *http.Request) {
@@ -73,7 +112,17 @@ func (s *Server) handleCreateRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	role, err := s.rbacService.CreateRole(r.Context(), req.Name, req.Description)
+	var orgID *uuid.UUID
+	if req.OrganizationID != nil {
+		id, err := uuid.Parse(*req.OrganizationID)
+		if err != nil {
+			s.writeError(w, domain.ValidationError{Field: "organization_id", Message: "invalid UUID"})
+			return
+		}
+		orgID = &id
+	}
+
+	role, err := s.rbacService.CreateRole(r.Context(), req.Name, req.Description, orgID)
 	if err != nil {
 		s.writeError(w, err)
 		return
@@ -83,15 +132,27 @@ func (s *Server) handleCreateRole(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleListRoles(w http.ResponseWriter, r *http.Request) {
-	roles, err := s.rbacService.ListRoles(r.Context())
+	orgID, err := organizationScope(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	fields, err := fieldsParam(r, roleResponse{})
 	if err != nil {
 		s.writeError(w, err)
 		return
 	}
 
-	roleResponses := make([]roleResponse, len(roles))
+	roles, err := s.rbacService.ListRolesFields(r.Context(), orgID, fields)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	roleResponses := make([]any, len(roles))
 	for i, role := range roles {
-		roleResponses[i] = toRoleResponse(&role)
+		roleResponses[i] = sparse(toRoleResponse(&role), fields)
 	}
 
 	s.writeJSON(w, http.StatusOK, map[string]any{
@@ -108,17 +169,60 @@ func (s *Server) handleGetRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	role, err := s.rbacService.GetRole(r.Context(), id)
+	fields, err := fieldsParam(r, roleResponse{})
 	if err != nil {
 		s.writeError(w, err)
 		return
 	}
 
-	s.writeJSON(w, http.StatusOK, toRoleResponse(role))
+	role, err := s.rbacService.GetRoleFields(r.Context(), id, fields)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, sparse(toRoleResponse(role), fields))
+}
+
+// handleListRoleUsers lists the users a role is assigned to directly.
+func (s *Server) handleListRoleUsers(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	query := r.URL.Query()
+
+	offset, limit := 0, 20
+	if v, err := strconv.Atoi(query.Get("offset")); err == nil && v >= 0 {
+		offset = v
+	}
+	if v, err := strconv.Atoi(query.Get("limit")); err == nil && v > 0 && v <= 100 {
+		limit = v
+	}
+
+	users, total, err := s.rbacService.ListRoleUsers(r.Context(), id, offset, limit)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	userResponses := make([]userResponse, len(users))
+	for i := range users {
+		userResponses[i] = toUserResponse(&users[i])
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"users":  userResponses,
+		"total":  total,
+		"offset": offset,
+		"limit":  limit,
+	})
 }
 
 type updateRoleRequest struct {
-	Name        *string `json:"name,omitempty"`
+	Name        *string `json:"name,omitempty" validate:"max=50"`
 	Description *string `json:"description,omitempty"`
 }
 
@@ -136,17 +240,10 @@ func (s *Server) handleUpdateRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get current values if not provided
-	name := ""
-	description := ""
-	if req.Name != nil {
-		name = *req.Name
-	}
-	if req.Description != nil {
-		description = *req.Description
-	}
-
-	role, err := s.rbacService.UpdateRole(r.Context(), id, name, description)
+	role, err := s.rbacService.UpdateRole(r.Context(), id, service.UpdateRoleInput{
+		Name:        req.Name,
+		Description: req.Description,
+	})
 	if err != nil {
 		s.writeError(w, err)
 		return
@@ -174,7 +271,8 @@ func (s *Server) handleDeleteRole(w http.ResponseWriter, r *http.Request) {
 // Role-Permission management
 
 type addPermissionRequest struct {
-	PermissionID string `json:"permission_id"`
+	PermissionID string `json:"permission_id" validate:"required,uuid"`
+	Deny         bool   `json:"deny,omitempty"`
 }
 
 func (s *Server) handleAddPermissionToRole(w http.ResponseWriter, r *http.Request) {
@@ -197,12 +295,16 @@ func (s *Server) handleAddPermissionToRole(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if err := s.rbacService.AddPermissionToRole(r.Context(), roleID, permID); err != nil {
+	if err := s.rbacService.AddPermissionToRole(r.Context(), roleID, permID, req.Deny); err != nil {
 		s.writeError(w, err)
 		return
 	}
 
-	s.writeJSON(w, http.StatusOK, map[string]string{"message": "permission added to role"})
+	message := "permission added to role"
+	if req.Deny {
+		message = "permission denied to role"
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"message": message})
 }
 
 func (s *Server) handleRemovePermissionFromRole(w http.ResponseWriter, r *http.Request) {
@@ -231,7 +333,8 @@ func (s *Server) handleRemovePermissionFromRole(w http.ResponseWriter, r *http.R
 // User-Role management
 
 type assignRoleRequest struct {
-	RoleID string `json:"role_id"`
+	RoleID    string     `json:"role_id" validate:"required,uuid"`
+	ExpiresAt *time.Time `json:"expires_at"` // RFC 3339; omitted keeps the role until removed
 }
 
 func (s *Server) handleAssignRoleToUser(w http.ResponseWriter, r *http.Request) {
@@ -254,7 +357,7 @@ func (s *Server) handleAssignRoleToUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := s.rbacService.AssignRole(r.Context(), userID, roleID); err != nil {
+	if err := s.rbacService.AssignRole(r.Context(), userID, roleID, req.ExpiresAt); err != nil {
 		s.writeError(w, err)
 		return
 	}
@@ -288,8 +391,8 @@ func (s *Server) handleRemoveRoleFromUser(w http.ResponseWriter, r *http.Request
 // Permission handlers
 
 type createPermissionRequest struct {
-	Resource    string `json:"resource"`
-	Action      string `json:"action"`
+	Resource    string `json:"resource" validate:"required,max=50"`
+	Action      string `json:"action" validate:"required,max=50"`
 	Description string `json:"description"`
 }
 
@@ -318,22 +421,66 @@ func (s *Server) handleCreatePermission(w http.ResponseWriter, r *http.Request)
 	s.writeJSON(w, http.StatusCreated, toPermissionResponse(perm))
 }
 
+// handleListPermissions lists permissions filtered by the resource and
+// search query parameters. With group=resource the page comes back as
+// groups of permissions per resource.
 func (s *Server) handleListPermissions(w http.ResponseWriter, r *http.Request) {
-	perms, err := s.rbacService.ListPermissions(r.Context())
+	query := r.URL.Query()
+
+	filter := storage.PermissionFilter{
+		Resource: query.Get("resource"),
+		Search:   query.Get("search"),
+		Offset:   0,
+		Limit:    20,
+	}
+
+	if offset, err := strconv.Atoi(query.Get("offset")); err == nil && offset >= 0 {
+		filter.Offset = offset
+	}
+	if limit, err := strconv.Atoi(query.Get("limit")); err == nil && limit > 0 && limit <= 100 {
+		filter.Limit = limit
+	}
+
+	group := query.Get("group")
+	if group != "" && group != "resource" {
+		s.writeError(w, domain.ValidationError{Field: "group", Message: "must be resource"})
+		return
+	}
+
+	perms, total, err := s.rbacService.ListPermissions(r.Context(), filter)
 	if err != nil {
 		s.writeError(w, err)
 		return
 	}
 
-	permResponses := make([]permissionResponse, len(perms))
-	for i, p := range perms {
-		permResponses[i] = toPermissionResponse(&p)
+	resp := map[string]any{
+		"total":  total,
+		"offset": filter.Offset,
+		"limit":  filter.Limit,
 	}
 
-	s.writeJSON(w, http.StatusOK, map[string]any{
-		"permissions": permResponses,
-		"total":       len(perms),
-	})
+	if group == "resource" {
+		groups := domain.GroupPermissions(perms)
+		groupResponses := make([]permissionGroupResponse, len(groups))
+		for i, g := range groups {
+			groupResponses[i] = permissionGroupResponse{
+				Resource:    g.Resource,
+				Permissions: make([]permissionResponse, len(g.Permissions)),
+			}
+			for j, p := range g.Permissions {
+				groupResponses[i].Permissions[j] = toPermissionResponse(&p)
+			}
+		}
+		resp["groups"] = groupResponses
+	} else {
+		permResponses := make([]permissionResponse, len(perms))
+		for i, p := range perms {
+			permResponses[i] = toPermissionResponse(&p)
+		}
+		resp["permissions"] = permResponses
+	}
+
+	s.writeJSON(w, http.StatusOK, resp)
 }
 
 func (s *Server) handleGetPermission(w http.ResponseWriter, r *http.Request) {
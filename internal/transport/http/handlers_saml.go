@@ -0,0 +1,431 @@
+package http
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+)
+
+// SAML provider response types
+
+type samlProviderResponse struct {
+	ID              string   `json:"id"`
+	Slug            string   `json:"slug"`
+	Name            string   `json:"name"`
+	EntityID        string   `json:"entity_id"`
+	SSOURL          string   `json:"sso_url"`
+	Certificate     string   `json:"certificate"`
+	EmailAttribute  string   `json:"email_attribute"`
+	NameAttribute   string   `json:"name_attribute"`
+	GroupsAttribute string   `json:"groups_attribute"`
+	EmailDomains    []string `json:"email_domains"`
+	Enabled         bool     `json:"enabled"`
+	CreatedAt       string   `json:"created_at"`
+	UpdatedAt       string   `json:"updated_at"`
+}
+
+func toSAMLProviderResponse(p *domain.SAMLProvider) samlProviderResponse {
+	return samlProviderResponse{
+		ID:              p.ID.String(),
+		Slug:            p.Slug,
+		Name:            p.Name,
+		EntityID:        p.EntityID,
+		SSOURL:          p.SSOURL,
+		Certificate:     p.Certificate,
+		EmailAttribute:  p.EmailAttribute,
+		NameAttribute:   p.NameAttribute,
+		GroupsAttribute: p.GroupsAttribute,
+		EmailDomains:    p.EmailDomains,
+		Enabled:         p.Enabled,
+		CreatedAt:       p.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       p.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+type samlGroupRoleResponse struct {
+	ID        string `json:"id"`
+	Group     string `json:"group"`
+	RoleID    string `json:"role_id"`
+	RoleName  string `json:"role_name"`
+	CreatedAt string `json:"created_at"`
+}
+
+func toSAMLGroupRoleResponse(m *domain.SAMLGroupRole) samlGroupRoleResponse {
+	return samlGroupRoleResponse{
+		ID:        m.ID.String(),
+		Group:     m.Group,
+		RoleID:    m.RoleID.String(),
+		RoleName:  m.RoleName,
+		CreatedAt: m.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// SAML sign-in handlers
+
+func (s *Server) handleSAMLMetadata(w http.ResponseWriter, r *http.Request) {
+	metadata, err := s.samlService.Metadata(r.Context(), chi.URLParam(r, "provider"))
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/samlmetadata+xml")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(metadata)
+}
+
+// handleSAMLLogin sends the browser to the identity provider to sign in.
+// The relay_state query parameter comes back with the response.
+func (s *Server) handleSAMLLogin(w http.ResponseWriter, r *http.Request) {
+	target, err := s.samlService.LoginURL(r.Context(), chi.URLParam(r, "provider"), r.URL.Query().Get("relay_state"))
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// handleSAMLACS signs in the user of the response the identity provider
+// posted. With SAML_REDIRECT_URL set the browser is sent there with the
+// tokens in the fragment, which never reaches a server; otherwise they're
+// returned as from a password login.
+func (s *Server) handleSAMLACS(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		s.writeError(w, domain.ValidationError{Field: "SAMLResponse", Message: "malformed form"})
+		return
+	}
+	response := r.PostForm.Get("SAMLResponse")
+	if response == "" {
+		s.writeError(w, domain.ValidationError{Field: "SAMLResponse", Message: "required"})
+		return
+	}
+
+	result, err := s.samlService.Login(r.Context(), chi.URLParam(r, "provider"), response, service.LoginInput{
+		IPAddress: getClientIP(r),
+		UserAgent: r.UserAgent(),
+		DeviceID:  r.Header.Get(deviceIDHeader),
+	})
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	if s.samlRedirectURL == "" {
+		s.writeJSON(w, http.StatusOK, authResponse{
+			AccessToken:  result.AccessToken,
+			RefreshToken: result.RefreshToken,
+			ExpiresIn:    result.ExpiresInSeconds,
+			Scope:        result.Scope,
+			User:         toUserResponse(result.User),
+		})
+		return
+	}
+
+	fragment := url.Values{
+		"access_token":  {result.AccessToken},
+		"refresh_token": {result.RefreshToken},
+		"expires_in":    {strconv.FormatInt(result.ExpiresInSeconds, 10)},
+	}
+	if state := r.PostForm.Get("RelayState"); state != "" {
+		fragment.Set("relay_state", state)
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, s.samlRedirectURL+"#"+fragment.Encode(), http.StatusSeeOther)
+}
+
+// SAML provider handlers
+
+type createSAMLProviderRequest struct {
+	Slug            string   `json:"slug" validate:"required,max=63"`
+	Name            string   `json:"name" validate:"required,max=255"`
+	EntityID        string   `json:"entity_id" validate:"required,max=500"`
+	SSOURL          string   `json:"sso_url" validate:"required,url"`
+	Certificate     string   `json:"certificate" validate:"required"`
+	EmailAttribute  string   `json:"email_attribute" validate:"max=255"`
+	NameAttribute   string   `json:"name_attribute" validate:"max=255"`
+	GroupsAttribute string   `json:"groups_attribute" validate:"max=255"`
+	EmailDomains    []string `json:"email_domains"`
+}
+
+type updateSAMLProviderRequest struct {
+	Slug            *string   `json:"slug" validate:"max=63"`
+	Name            *string   `json:"name" validate:"max=255"`
+	EntityID        *string   `json:"entity_id" validate:"max=500"`
+	SSOURL          *string   `json:"sso_url" validate:"url"`
+	Certificate     *string   `json:"certificate"`
+	EmailAttribute  *string   `json:"email_attribute" validate:"max=255"`
+	NameAttribute   *string   `json:"name_attribute" validate:"max=255"`
+	GroupsAttribute *string   `json:"groups_attribute" validate:"max=255"`
+	EmailDomains    *[]string `json:"email_domains"`
+	Enabled         *bool     `json:"enabled"`
+}
+
+func (s *Server) handleListSAMLProviders(w http.ResponseWriter, r *http.Request) {
+	providers, err := s.samlService.ListProviders(r.Context())
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	resp := make([]samlProviderResponse, len(providers))
+	for i := range providers {
+		resp[i] = toSAMLProviderResponse(&providers[i])
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{"providers": resp})
+}
+
+func (s *Server) handleGetSAMLProvider(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	provider, err := s.samlService.GetProvider(r.Context(), id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toSAMLProviderResponse(provider))
+}
+
+func (s *Server) handleCreateSAMLProvider(w http.ResponseWriter, r *http.Request) {
+	var req createSAMLProviderRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	provider, err := s.samlService.CreateProvider(r.Context(), service.CreateSAMLProviderInput{
+		Slug:            req.Slug,
+		Name:            req.Name,
+		EntityID:        req.EntityID,
+		SSOURL:          req.SSOURL,
+		Certificate:     req.Certificate,
+		EmailAttribute:  req.EmailAttribute,
+		NameAttribute:   req.NameAttribute,
+		GroupsAttribute: req.GroupsAttribute,
+		EmailDomains:    req.EmailDomains,
+	})
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusCreated, toSAMLProviderResponse(provider))
+}
+
+func (s *Server) handleUpdateSAMLProvider(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	var req updateSAMLProviderRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	provider, err := s.samlService.UpdateProvider(r.Context(), id, service.UpdateSAMLProviderInput{
+		Slug:            req.Slug,
+		Name:            req.Name,
+		EntityID:        req.EntityID,
+		SSOURL:          req.SSOURL,
+		Certificate:     req.Certificate,
+		EmailAttribute:  req.EmailAttribute,
+		NameAttribute:   req.NameAttribute,
+		GroupsAttribute: req.GroupsAttribute,
+		EmailDomains:    req.EmailDomains,
+		Enabled:         req.Enabled,
+	})
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toSAMLProviderResponse(provider))
+}
+
+func (s *Server) handleDeleteSAMLProvider(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	if err := s.samlService.DeleteProvider(r.Context(), id); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusNoContent, nil)
+}
+
+// SAML group role handlers
+
+type createSAMLGroupRoleRequest struct {
+	Group  string `json:"group" validate:"required,max=500"`
+	RoleID string `json:"role_id" validate:"required,uuid"`
+}
+
+func (s *Server) handleListSAMLGroupRoles(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	mappings, err := s.samlService.ListGroupRoles(r.Context(), id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	resp := make([]samlGroupRoleResponse, len(mappings))
+	for i := range mappings {
+		resp[i] = toSAMLGroupRoleResponse(&mappings[i])
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{"group_roles": resp})
+}
+
+// handleCreateSAMLGroupRole gives the users whose assertion from the
+// provider lists a group a role, from their next sign-in.
+func (s *Server) handleCreateSAMLGroupRole(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	var req createSAMLGroupRoleRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	roleID, err := uuid.Parse(req.RoleID)
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "role_id", Message: "invalid UUID"})
+		return
+	}
+
+	mapping, err := s.samlService.CreateGroupRole(r.Context(), id, req.Group, roleID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusCreated, toSAMLGroupRoleResponse(mapping))
+}
+
+func (s *Server) handleDeleteSAMLGroupRole(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+	mappingID, err := uuid.Parse(chi.URLParam(r, "mappingId"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "mappingId", Message: "invalid UUID"})
+		return
+	}
+
+	if err := s.samlService.DeleteGroupRole(r.Context(), id, mappingID); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusNoContent, nil)
+}
+
+// SAML identity link handlers
+
+type linkSAMLIdentityRequest struct {
+	NameID string `json:"name_id" validate:"required,max=1000"`
+	UserID string `json:"user_id" validate:"required,uuid"`
+}
+
+func (s *Server) handleListSAMLIdentities(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	identities, err := s.samlService.ListIdentities(r.Context(), id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	resp := make([]federatedIdentityResponse, len(identities))
+	for i := range identities {
+		resp[i] = toFederatedIdentityResponse(&identities[i])
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{"identities": resp})
+}
+
+// handleLinkSAMLIdentity has a subject of the provider sign in to an
+// existing account, which sign-in won't link by itself if it's privileged
+// or its email is outside the provider's domains.
+func (s *Server) handleLinkSAMLIdentity(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	var req linkSAMLIdentityRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "user_id", Message: "invalid UUID"})
+		return
+	}
+
+	identity, err := s.samlService.LinkIdentity(r.Context(), id, req.NameID, userID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusCreated, toFederatedIdentityResponse(identity))
+}
+
+func (s *Server) handleUnlinkSAMLIdentity(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+	identityID, err := uuid.Parse(chi.URLParam(r, "identityId"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "identityId", Message: "invalid UUID"})
+		return
+	}
+
+	if err := s.samlService.UnlinkIdentity(r.Context(), id, identityID); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,247 @@
+package http
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// Support lookup response types
+
+type supportLookupResponse struct {
+	ID          string            `json:"id"`
+	ActorID     *string           `json:"actor_id"` // Null once the actor's account is gone
+	Query       string            `json:"query"`
+	Params      map[string]string `json:"params"`
+	Reason      string            `json:"reason"`
+	ResultCount int               `json:"result_count"`
+	IPAddress   string            `json:"ip_address,omitempty"`
+	UserAgent   string            `json:"user_agent,omitempty"`
+	CreatedAt   string            `json:"created_at"`
+}
+
+func toSupportLookupResponse(l *domain.SupportLookup) supportLookupResponse {
+	resp := supportLookupResponse{
+		ID:          l.ID.String(),
+		Query:       l.Query,
+		Params:      l.Params,
+		Reason:      l.Reason,
+		ResultCount: l.ResultCount,
+		IPAddress:   l.IPAddress,
+		UserAgent:   l.UserAgent,
+		CreatedAt:   l.CreatedAt.Format(time.RFC3339),
+	}
+	if l.ActorID != uuid.Nil {
+		id := l.ActorID.String()
+		resp.ActorID = &id
+	}
+	return resp
+}
+
+type supportUserResponse struct {
+	ID        string  `json:"id"`
+	Email     string  `json:"email"`
+	Username  string  `json:"username"`
+	FullName  string  `json:"full_name"`
+	UserType  string  `json:"user_type"`
+	Status    string  `json:"status"`
+	CreatedAt string  `json:"created_at"`
+	DeletedAt *string `json:"deleted_at,omitempty"`
+}
+
+type supportTokenResponse struct {
+	ID        string  `json:"id"`
+	UserID    string  `json:"user_id"`
+	ProfileID *string `json:"profile_id,omitempty"`
+	IPAddress string  `json:"ip_address"`
+	UserAgent string  `json:"user_agent,omitempty"`
+	CreatedAt string  `json:"created_at"`
+	ExpiresAt string  `json:"expires_at"`
+	RevokedAt *string `json:"revoked_at,omitempty"`
+}
+
+type supportLoginResponse struct {
+	ID            string `json:"id"`
+	UserID        string `json:"user_id"`
+	Success       bool   `json:"success"`
+	FailureReason string `json:"failure_reason,omitempty"`
+	IPAddress     string `json:"ip_address"`
+	UserAgent     string `json:"user_agent,omitempty"`
+	CreatedAt     string `json:"created_at"`
+}
+
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.Format(time.RFC3339)
+	return &s
+}
+
+func toSupportLookupResults(result *service.SupportLookupResult) any {
+	switch result.Lookup.Query {
+	case service.LookupUsersByEmail:
+		users := make([]supportUserResponse, len(result.Users))
+		for i, u := range result.Users {
+			users[i] = supportUserResponse{
+				ID:        u.ID.String(),
+				Email:     u.Email,
+				Username:  u.Username,
+				FullName:  u.FullName,
+				UserType:  string(u.Type),
+				Status:    string(u.Status),
+				CreatedAt: u.CreatedAt.Format(time.RFC3339),
+				DeletedAt: formatOptionalTime(u.DeletedAt),
+			}
+		}
+		return users
+
+	case service.LookupTokensByIP:
+		tokens := make([]supportTokenResponse, len(result.Tokens))
+		for i, t := range result.Tokens {
+			tokens[i] = supportTokenResponse{
+				ID:        t.ID.String(),
+				UserID:    t.UserID.String(),
+				IPAddress: t.IPAddress,
+				UserAgent: t.UserAgent,
+				CreatedAt: t.CreatedAt.Format(time.RFC3339),
+				ExpiresAt: t.ExpiresAt.Format(time.RFC3339),
+				RevokedAt: formatOptionalTime(t.RevokedAt),
+			}
+			if t.ProfileID != nil {
+				id := t.ProfileID.String()
+				tokens[i].ProfileID = &id
+			}
+		}
+		return tokens
+
+	default:
+		logins := make([]supportLoginResponse, len(result.Logins))
+		for i, a := range result.Logins {
+			logins[i] = supportLoginResponse{
+				ID:            a.ID.String(),
+				UserID:        a.UserID.String(),
+				Success:       a.Success,
+				FailureReason: a.FailureReason,
+				IPAddress:     a.IPAddress,
+				UserAgent:     a.UserAgent,
+				CreatedAt:     a.CreatedAt.Format(time.RFC3339),
+			}
+		}
+		return logins
+	}
+}
+
+// Support lookup handlers
+
+type supportLookupRequest struct {
+	Query  string            `json:"query" validate:"required"`
+	Params map[string]string `json:"params"`
+	Reason string            `json:"reason" validate:"required,max=500"`
+}
+
+// handleSupportLookup runs one of the predefined lookups and returns its
+// rows along with the audit record made for it.
+func (s *Server) handleSupportLookup(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+
+	var req supportLookupRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	result, err := s.supportService.Lookup(r.Context(), service.SupportLookupInput{
+		Query:     req.Query,
+		Params:    req.Params,
+		Reason:    req.Reason,
+		ActorID:   claims.UserID,
+		IPAddress: getClientIP(r),
+		UserAgent: r.UserAgent(),
+	})
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"lookup":  toSupportLookupResponse(result.Lookup),
+		"results": toSupportLookupResults(result),
+	})
+}
+
+// handleListSupportLookups lists the audit trail, filtered by actor_id and
+// query.
+func (s *Server) handleListSupportLookups(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := storage.SupportLookupFilter{
+		Query:  query.Get("query"),
+		Offset: 0,
+		Limit:  20,
+	}
+
+	if actorID := query.Get("actor_id"); actorID != "" {
+		id, err := uuid.Parse(actorID)
+		if err != nil {
+			s.writeError(w, domain.ValidationError{Field: "actor_id", Message: "invalid UUID"})
+			return
+		}
+		filter.ActorID = &id
+	}
+	if offset, err := strconv.Atoi(query.Get("offset")); err == nil && offset >= 0 {
+		filter.Offset = offset
+	}
+	if limit, err := strconv.Atoi(query.Get("limit")); err == nil && limit > 0 && limit <= 100 {
+		filter.Limit = limit
+	}
+
+	lookups, total, err := s.supportService.ListLookups(r.Context(), filter)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	lookupResponses := make([]supportLookupResponse, len(lookups))
+	for i := range lookups {
+		lookupResponses[i] = toSupportLookupResponse(&lookups[i])
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"lookups": lookupResponses,
+		"total":   total,
+		"offset":  filter.Offset,
+		"limit":   filter.Limit,
+	})
+}
+
+// supportLookupLimit rate limits support lookups per user.
+func (s *Server) supportLookupLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims := getUserClaims(r.Context())
+		if claims == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		_, wait, ok := s.supportLookupLimiter.take(claims.UserID.String(), 1, time.Now())
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			s.writeErrorResponse(w, http.StatusTooManyRequests, errorResponse{
+				Error: "too many support lookups, retry later",
+				Code:  "RATE_LIMITED",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// Timeline response types
+
+type timelineEntryResponse struct {
+	Kind       string         `json:"kind"`
+	OccurredAt string         `json:"occurred_at"`
+	Data       map[string]any `json:"data"`
+}
+
+func toTimelineEntryResponse(e *domain.TimelineEntry) timelineEntryResponse {
+	data := e.Data
+	if data == nil {
+		data = map[string]any{}
+	}
+	return timelineEntryResponse{
+		Kind:       string(e.Kind),
+		OccurredAt: e.OccurredAt.Format(time.RFC3339),
+		Data:       data,
+	}
+}
+
+// Timeline handlers
+
+// handleGetUserTimeline lists a user's account activity, newest first,
+// filtered by the kind (comma-separated), since and until query parameters.
+func (s *Server) handleGetUserTimeline(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	query := r.URL.Query()
+
+	filter := storage.TimelineFilter{
+		UserID: userID,
+		Offset: 0,
+		Limit:  20,
+	}
+
+	if offset, err := strconv.Atoi(query.Get("offset")); err == nil && offset >= 0 {
+		filter.Offset = offset
+	}
+	if limit, err := strconv.Atoi(query.Get("limit")); err == nil && limit > 0 && limit <= 100 {
+		filter.Limit = limit
+	}
+
+	if kinds := query.Get("kind"); kinds != "" {
+		for _, name := range strings.Split(kinds, ",") {
+			kind, ok := domain.ParseTimelineKind(strings.TrimSpace(name))
+			if !ok {
+				s.writeError(w, domain.ValidationError{Field: "kind", Message: "unknown kind " + name})
+				return
+			}
+			filter.Kinds = append(filter.Kinds, kind)
+		}
+	}
+
+	for _, param := range []struct {
+		name string
+		dest **time.Time
+	}{
+		{"since", &filter.Since},
+		{"until", &filter.Until},
+	} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			s.writeError(w, domain.ValidationError{Field: param.name, Message: "must be an RFC 3339 timestamp"})
+			return
+		}
+		*param.dest = &t
+	}
+
+	entries, total, err := s.userService.GetTimeline(r.Context(), filter)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	entryResponses := make([]timelineEntryResponse, len(entries))
+	for i := range entries {
+		entryResponses[i] = toTimelineEntryResponse(&entries[i])
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"entries": entryResponses,
+		"total":   total,
+		"offset":  filter.Offset,
+		"limit":   filter.Limit,
+	})
+}
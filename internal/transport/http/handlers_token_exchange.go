@@ -0,0 +1,179 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+)
+
+// accessTokenType is the only subject token type exchanged, and the type of
+// the tokens issued (RFC 8693 section 3).
+const accessTokenType = "urn:ietf:params:oauth:token-type:access_token"
+
+// Token exchange response types
+
+type tokenExchangeTokenResponse struct {
+	AccessToken     string `json:"access_token"`
+	IssuedTokenType string `json:"issued_token_type"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int64  `json:"expires_in"`
+	Scope           string `json:"scope"`
+}
+
+type tokenExchangeResponse struct {
+	ID             string   `json:"id"`
+	ClientID       string   `json:"client_id"`
+	UserID         string   `json:"user_id"`
+	Audience       []string `json:"audience"`
+	Permissions    []string `json:"permissions"`
+	SubjectTokenID string   `json:"subject_token_id"`
+	IPAddress      string   `json:"ip_address,omitempty"`
+	UserAgent      string   `json:"user_agent,omitempty"`
+	CreatedAt      string   `json:"created_at"`
+	ExpiresAt      string   `json:"expires_at"`
+}
+
+func toTokenExchangeResponse(e *domain.TokenExchange) tokenExchangeResponse {
+	return tokenExchangeResponse{
+		ID:             e.ID.String(),
+		ClientID:       e.ClientID.String(),
+		UserID:         e.UserID.String(),
+		Audience:       e.Audience,
+		Permissions:    e.Permissions,
+		SubjectTokenID: e.SubjectTokenID,
+		IPAddress:      e.IPAddress,
+		UserAgent:      e.UserAgent,
+		CreatedAt:      e.CreatedAt.Format(time.RFC3339),
+		ExpiresAt:      e.ExpiresAt.Format(time.RFC3339),
+	}
+}
+
+// handleTokenExchangeGrant issues an OAuth client a delegation token for
+// the user of subject_token, with the permissions in scope, for the
+// services in audience (which may repeat). The client authenticates as for
+// the client credentials grant.
+func (s *Server) handleTokenExchangeGrant(w http.ResponseWriter, r *http.Request) {
+	clientID, secret, basic := r.BasicAuth()
+	if !basic {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID == "" || secret == "" {
+		s.writeOAuthError(w, domain.ValidationError{Field: "client_id", Message: "client_id and client_secret are required"})
+		return
+	}
+
+	if r.PostForm.Get("subject_token") == "" {
+		s.writeOAuthError(w, domain.ValidationError{Field: "subject_token", Message: "required"})
+		return
+	}
+	if r.PostForm.Get("subject_token_type") != accessTokenType {
+		s.writeOAuthError(w, domain.ValidationError{Field: "subject_token_type", Message: "must be " + accessTokenType})
+		return
+	}
+	if t := r.PostForm.Get("requested_token_type"); t != "" && t != accessTokenType {
+		s.writeOAuthError(w, domain.ValidationError{Field: "requested_token_type", Message: "must be " + accessTokenType})
+		return
+	}
+
+	token, err := s.tokenExchangeService.Exchange(r.Context(), service.ExchangeInput{
+		ClientID:     clientID,
+		ClientSecret: secret,
+		SubjectToken: r.PostForm.Get("subject_token"),
+		Audience:     r.PostForm["audience"],
+		Scope:        r.PostForm.Get("scope"),
+		IPAddress:    getClientIP(r),
+		UserAgent:    r.UserAgent(),
+	})
+	if errors.Is(err, domain.ErrInvalidCredential) {
+		if basic {
+			w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		s.writeJSON(w, http.StatusUnauthorized, oauthErrorResponse{Error: "invalid_client", ErrorDescription: err.Error()})
+		return
+	}
+	if err != nil {
+		s.writeOAuthError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	s.writeJSON(w, http.StatusOK, tokenExchangeTokenResponse{
+		AccessToken:     token.AccessToken,
+		IssuedTokenType: accessTokenType,
+		TokenType:       "Bearer",
+		ExpiresIn:       token.ExpiresInSeconds,
+		Scope:           token.Scope,
+	})
+}
+
+// Audit handlers
+
+// handleListUserTokenExchanges lists the exchanges of a user's tokens.
+func (s *Server) handleListUserTokenExchanges(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	offset, limit := exchangePagination(r)
+	exchanges, total, err := s.tokenExchangeService.ListForUser(r.Context(), userID, offset, limit)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeTokenExchanges(w, exchanges, total, offset, limit)
+}
+
+// handleListClientTokenExchanges lists a client's exchanges.
+func (s *Server) handleListClientTokenExchanges(w http.ResponseWriter, r *http.Request) {
+	clientID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	offset, limit := exchangePagination(r)
+	exchanges, total, err := s.tokenExchangeService.ListForClient(r.Context(), clientID, offset, limit)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeTokenExchanges(w, exchanges, total, offset, limit)
+}
+
+func exchangePagination(r *http.Request) (offset, limit int) {
+	query := r.URL.Query()
+	offset, limit = 0, 20
+	if v, err := strconv.Atoi(query.Get("offset")); err == nil && v >= 0 {
+		offset = v
+	}
+	if v, err := strconv.Atoi(query.Get("limit")); err == nil && v > 0 && v <= 100 {
+		limit = v
+	}
+	return offset, limit
+}
+
+func (s *Server) writeTokenExchanges(w http.ResponseWriter, exchanges []domain.TokenExchange, total int64, offset, limit int) {
+	resp := make([]tokenExchangeResponse, len(exchanges))
+	for i := range exchanges {
+		resp[i] = toTokenExchangeResponse(&exchanges[i])
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"exchanges": resp,
+		"total":     total,
+		"offset":    offset,
+		"limit":     limit,
+	})
+}
@@ -1,13 +1,18 @@
 package http
 
 import (
+	"errors"
+	"maps"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
+	"github.com/mvaleed/aegis/internal/authz"
 	"github.com/mvaleed/aegis/internal/domain"
 	"github.com/mvaleed/aegis/internal/service"
 	"github.com/mvaleed/aegis/internal/storage"
@@ -16,18 +21,21 @@ import (
 // User response types
 
 type userResponse struct {
-	ID            string   `json:"id"`
-	Email         string   `json:"email"`
-	Username      string   `json:"username"`
-	FullName      string   `json:"full_name"`
-	Phone         *string  `json:"phone,omitempty"`
-	Type          string   `json:"type"`
-	Status        string   `json:"status"`
-	EmailVerified bool     `json:"email_verified"`
-	PhoneVerified bool     `json:"phone_verified"`
-	Roles         []string `json:"roles,omitempty"`
-	CreatedAt     string   `json:"created_at"`
-	UpdatedAt     string   `json:"updated_at"`
+	ID            string         `json:"id"`
+	Email         string         `json:"email"`
+	Username      string         `json:"username"`
+	FullName      string         `json:"full_name"`
+	Phone         *string        `json:"phone,omitempty"`
+	Type          string         `json:"type"`
+	Status        string         `json:"status"`
+	EmailVerified bool           `json:"email_verified"`
+	PhoneVerified bool           `json:"phone_verified"`
+	Locale        string         `json:"locale,omitempty"`
+	Timezone      string         `json:"timezone,omitempty"`
+	Roles         []string       `json:"roles,omitempty"`
+	Attributes    map[string]any `json:"attributes,omitempty"`
+	CreatedAt     string         `json:"created_at"`
+	UpdatedAt     string         `json:"updated_at"`
 }
 
 func toUserResponse(u *domain.User) userResponse {
@@ -41,6 +49,9 @@ func toUserResponse(u *domain.User) userResponse {
 		Status:        string(u.Status),
 		EmailVerified: u.EmailVerified,
 		PhoneVerified: u.PhoneVerified,
+		Locale:        u.Locale,
+		Timezone:      u.Timezone,
+		Attributes:    u.Attributes,
 		CreatedAt:     u.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:     u.UpdatedAt.Format(time.RFC3339),
 	}
@@ -52,6 +63,46 @@ func toUserResponse(u *domain.User) userResponse {
 	return resp
 }
 
+// userSummaryResponse is a userResponse read from the user directory,
+// extended with activity fields.
+type userSummaryResponse struct {
+	userResponse
+	OrganizationIDs []string `json:"organization_ids,omitempty"`
+	LastLoginAt     *string  `json:"last_login_at,omitempty"`
+	ActiveSessions  int      `json:"active_sessions"`
+}
+
+func toUserSummaryResponse(u *domain.UserSummary) userSummaryResponse {
+	resp := userSummaryResponse{
+		userResponse: userResponse{
+			ID:            u.ID.String(),
+			Email:         u.Email,
+			Username:      u.Username,
+			FullName:      u.FullName,
+			Phone:         u.Phone,
+			Type:          string(u.Type),
+			Status:        string(u.Status),
+			EmailVerified: u.EmailVerified,
+			PhoneVerified: u.PhoneVerified,
+			Roles:         u.RoleNames,
+			CreatedAt:     u.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:     u.UpdatedAt.Format(time.RFC3339),
+		},
+		ActiveSessions: u.ActiveSessions,
+	}
+
+	for _, id := range u.OrganizationIDs {
+		resp.OrganizationIDs = append(resp.OrganizationIDs, id.String())
+	}
+
+	if u.LastLoginAt != nil {
+		t := u.LastLoginAt.Format(time.RFC3339)
+		resp.LastLoginAt = &t
+	}
+
+	return resp
+}
+
 // User handlers
 
 func (s *Server) handleGetCurrentUser(w http.ResponseWriter, r *http.Request) {
@@ -61,19 +112,30 @@ func (s *Server) handleGetCurrentUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := s.userService.GetUser(r.Context(), claims.UserID)
+	fields, err := fieldsParam(r, userResponse{})
 	if err != nil {
 		s.writeError(w, err)
 		return
 	}
 
-	s.writeJSON(w, http.StatusOK, toUserResponse(user))
+	user, err := s.userService.GetUserFields(r.Context(), claims.UserID, fields)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeUser(w, r, user, fields)
 }
 
+// updateUserRequest is a JSON merge patch (RFC 7396), for PATCH and PUT
+// alike: omitted fields are left alone, null clears a field and "" sets it
+// to empty, which only optional fields may be.
 type updateUserRequest struct {
-	FullName *string `json:"full_name,omitempty"`
-	Username *string `json:"username,omitempty"`
-	Phone    *string `json:"phone,omitempty"`
+	FullName service.Field[string] `json:"full_name" validate:"max=200"`
+	Username service.Field[string] `json:"username"`
+	Phone    service.Field[string] `json:"phone"`
+	Locale   service.Field[string] `json:"locale" validate:"locale"`
+	Timezone service.Field[string] `json:"timezone" validate:"timezone"`
 }
 
 func (s *Server) handleUpdateCurrentUser(w http.ResponseWriter, r *http.Request) {
@@ -83,6 +145,12 @@ func (s *Server) handleUpdateCurrentUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	version, err := ifMatchVersion(r, claims.UserID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
 	var req updateUserRequest
 	if err := s.readJSON(r, &req); err != nil {
 		s.writeError(w, err)
@@ -90,10 +158,42 @@ func (s *Server) handleUpdateCurrentUser(w http.ResponseWriter, r *http.Request)
 	}
 
 	user, err := s.userService.UpdateUser(r.Context(), claims.UserID, service.UpdateUserInput{
-		FullName: req.FullName,
-		Username: req.Username,
-		Phone:    req.Phone,
+		FullName:    req.FullName,
+		Username:    req.Username,
+		Phone:       req.Phone,
+		Locale:      req.Locale,
+		Timezone:    req.Timezone,
+		SelfService: true,
+		Version:     version,
 	})
+	if err != nil {
+		s.writeUpdateError(w, err, version)
+		return
+	}
+
+	s.writeUser(w, r, user, nil)
+}
+
+type changeUsernameRequest struct {
+	Username string `json:"username" validate:"required"`
+}
+
+// handleChangeUsername changes the current user's username, at most once
+// per USERNAME_CHANGE_COOLDOWN.
+func (s *Server) handleChangeUsername(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	var req changeUsernameRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	user, err := s.userService.ChangeUsername(r.Context(), claims.UserID, req.Username)
 	if err != nil {
 		s.writeError(w, err)
 		return
@@ -102,9 +202,26 @@ func (s *Server) handleUpdateCurrentUser(w http.ResponseWriter, r *http.Request)
 	s.writeJSON(w, http.StatusOK, toUserResponse(user))
 }
 
+// handleDeactivateCurrentUser deactivates the current user's account and
+// signs them out everywhere.
+func (s *Server) handleDeactivateCurrentUser(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	if err := s.authService.Deactivate(r.Context(), claims.UserID); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]string{"message": "account deactivated"})
+}
+
 type changePasswordRequest struct {
-	CurrentPassword string `json:"current_password"`
-	NewPassword     string `json:"new_password"`
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
 }
 
 func (s *Server) handleChangePassword(w http.ResponseWriter, r *http.Request) {
@@ -129,7 +246,13 @@ func (s *Server) handleChangePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.userService.ChangePassword(r.Context(), claims.UserID, req.CurrentPassword, req.NewPassword); err != nil {
+	if err := s.userService.ChangePassword(r.Context(), service.ChangePasswordInput{
+		UserID:          claims.UserID,
+		CurrentPassword: req.CurrentPassword,
+		NewPassword:     req.NewPassword,
+		IPAddress:       getClientIP(r),
+		UserAgent:       r.UserAgent(),
+	}); err != nil {
 		s.writeError(w, err)
 		return
 	}
@@ -142,10 +265,19 @@ func (s *Server) handleChangePassword(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleListUsers(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 
-	filter := storage.UserFilter{
-		Search: query.Get("search"),
-		Offset: 0,
-		Limit:  20,
+	fields, err := fieldsParam(r, userSummaryResponse{})
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	filter := storage.DirectoryFilter{
+		Search:   query.Get("search"),
+		Role:     query.Get("role"),
+		Locale:   query.Get("locale"),
+		Timezone: query.Get("timezone"),
+		Offset:   0,
+		Limit:    20,
 	}
 
 	if offset, err := strconv.Atoi(query.Get("offset")); err == nil && offset >= 0 {
@@ -169,15 +301,28 @@ func (s *Server) handleListUsers(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	users, total, err := s.userService.ListUsers(r.Context(), filter)
+	orgID, err := organizationScope(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	filter.OrganizationID = orgID
+
+	filter.Attributes, err = s.attributeService.ParseFilter(r.Context(), attributeFilterParams(query))
 	if err != nil {
 		s.writeError(w, err)
 		return
 	}
 
-	userResponses := make([]userResponse, len(users))
+	users, total, err := s.userService.ListUserSummaries(r.Context(), filter)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	userResponses := make([]any, len(users))
 	for i, u := range users {
-		userResponses[i] = toUserResponse(&u)
+		userResponses[i] = sparse(toUserSummaryResponse(&u), fields)
 	}
 
 	s.writeJSON(w, http.StatusOK, map[string]any{
@@ -196,15 +341,99 @@ func (s *Server) handleGetUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := s.userService.GetUser(r.Context(), id)
+	fields, err := fieldsParam(r, userResponse{})
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	user, err := s.userService.GetUserFields(r.Context(), id, fields)
 	if err != nil {
 		s.writeError(w, err)
 		return
 	}
 
+	s.writeUser(w, r, user, fields)
+}
+
+// handleGetUserByUsername looks a user up by username. With
+// ?resolve_former=true, a username someone has moved away from redirects
+// to the user who last held it.
+func (s *Server) handleGetUserByUsername(w http.ResponseWriter, r *http.Request) {
+	resolveFormer, _ := strconv.ParseBool(r.URL.Query().Get("resolve_former"))
+
+	user, former, err := s.userService.GetUserByUsername(r.Context(), chi.URLParam(r, "username"), resolveFormer)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	if former {
+		w.Header().Set("Location", "/api/v1/users/"+user.ID.String())
+		s.writeJSON(w, http.StatusFound, map[string]string{"id": user.ID.String(), "username": user.Username})
+		return
+	}
+
 	s.writeJSON(w, http.StatusOK, toUserResponse(user))
 }
 
+type usernameChangeResponse struct {
+	Username      string    `json:"username"`
+	ChangedAt     time.Time `json:"changed_at"`
+	ReservedUntil time.Time `json:"reserved_until"`
+}
+
+// handleListUsernameChanges lists the usernames a user has moved away
+// from, newest first.
+func (s *Server) handleListUsernameChanges(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	changes, err := s.userService.ListUsernameChanges(r.Context(), id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	resp := make([]usernameChangeResponse, len(changes))
+	for i, c := range changes {
+		resp[i] = usernameChangeResponse{Username: c.Username, ChangedAt: c.ChangedAt, ReservedUntil: c.ReservedUntil}
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{"usernames": resp})
+}
+
+// handleGetUserSnapshot returns the user and their roles as of a past time.
+func (s *Server) handleGetUserSnapshot(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	asOf, err := time.Parse(time.RFC3339, r.URL.Query().Get("as_of"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "as_of", Message: "must be an RFC 3339 timestamp"})
+		return
+	}
+
+	user, err := s.userService.GetUserAsOf(r.Context(), id, asOf)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"as_of":   asOf.UTC().Format(time.RFC3339),
+		"deleted": user.IsDeleted(),
+		"user":    toUserResponse(user),
+	})
+}
+
 func (s *Server) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := uuid.Parse(idStr)
@@ -213,6 +442,12 @@ func (s *Server) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, err := ifMatchVersion(r, id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
 	var req updateUserRequest
 	if err := s.readJSON(r, &req); err != nil {
 		s.writeError(w, err)
@@ -223,13 +458,102 @@ func (s *Server) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
 		FullName: req.FullName,
 		Username: req.Username,
 		Phone:    req.Phone,
+		Locale:   req.Locale,
+		Timezone: req.Timezone,
+		Version:  version,
 	})
 	if err != nil {
-		s.writeError(w, err)
+		s.writeUpdateError(w, err, version)
 		return
 	}
 
-	s.writeJSON(w, http.StatusOK, toUserResponse(user))
+	s.writeUser(w, r, user, nil)
+}
+
+// writeUser writes the fields of user wanted with its ETag, or 304 if
+// If-None-Match lists it; see userETag.
+func (s *Server) writeUser(w http.ResponseWriter, r *http.Request, user *domain.User, fields service.FieldSet) {
+	etag := userETag(r, user.ID, user.Version, fields)
+	h := w.Header()
+	h.Set("ETag", etag)
+	h.Add("Vary", "Authorization")
+	h.Add("Vary", apiKeyHeader)
+	if r.Method == http.MethodGet && etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, sparse(toUserResponse(user), fields))
+}
+
+// userETag returns the ETag of the representation of user id at version
+// that the caller gets. The whole user has a strong tag naming the version
+// and the fields filterFields hides from the caller (e.g. "7-email-phone"),
+// as their body differs from that of callers who see them; Vary tells the
+// callers apart. A sparse fieldset has the weak tag of the same, as
+// If-None-Match may compare it but If-Match never matches it.
+func userETag(r *http.Request, id uuid.UUID, version int, fields service.FieldSet) string {
+	etag := `"` + strconv.Itoa(version) + hiddenUserFields(r, id) + `"`
+	if fields != nil {
+		return "W/" + etag
+	}
+	return etag
+}
+
+// hiddenUserFields returns the fields of user id that filterFields hides
+// from the caller, each prefixed with "-", or "" if they see them all.
+func hiddenUserFields(r *http.Request, id uuid.UUID) string {
+	claims := getUserClaims(r.Context())
+	if claims == nil || claims.UserID == id {
+		return ""
+	}
+	var hidden string
+	for _, field := range slices.Sorted(maps.Keys(authz.UserFields.Hidden(claims.hasPermission))) {
+		hidden += "-" + field
+	}
+	return hidden
+}
+
+// ifMatchVersion returns the version an If-Match header requires of user
+// id, 0 for none (no header, or *). PUT and PATCH on a user take its ETag
+// back in If-Match and fail with 412 if the user has changed since, rather
+// than overwriting the change. As RFC 9110 has it, If-Match compares tags
+// strongly: only the strong tag writeUser sends the caller for the whole
+// user matches, and weak tags, or any other, come back as -1, which no
+// version matches.
+func ifMatchVersion(r *http.Request, id uuid.UUID) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	switch {
+	case header == "" || header == "*":
+		return 0, nil
+	case strings.Contains(header, ","):
+		return 0, domain.ValidationError{Field: "If-Match", Message: "must be a single ETag"}
+	}
+
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return -1, nil
+	}
+	tag, ok := strings.CutSuffix(header[1:len(header)-1], hiddenUserFields(r, id))
+	if !ok {
+		return -1, nil
+	}
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		return -1, nil
+	}
+	return version, nil
+}
+
+// writeUpdateError writes the error of an update made with If-Match
+// version, a version mismatch then being 412 rather than 409.
+func (s *Server) writeUpdateError(w http.ResponseWriter, err error, version int) {
+	if version != 0 && errors.Is(err, domain.ErrVersionMismatch) {
+		s.writeErrorResponse(w, http.StatusPreconditionFailed, errorResponse{
+			Error: "user was modified since the version in If-Match",
+			Code:  "PRECONDITION_FAILED",
+		})
+		return
+	}
+	s.writeError(w, err)
 }
 
 func (s *Server) handleActivateUser(w http.ResponseWriter, r *http.Request) {
@@ -274,6 +598,37 @@ func (s *Server) handleSuspendUser(w http.ResponseWriter, r *http.Request) {
 	s.writeJSON(w, http.StatusOK, map[string]string{"message": "user suspended"})
 }
 
+type resetPasswordRequest struct {
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+func (s *Server) handleResetUserPassword(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	var req resetPasswordRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	if req.NewPassword == "" {
+		s.writeError(w, domain.ValidationError{Field: "new_password", Message: "required"})
+		return
+	}
+
+	if err := s.userService.ResetPassword(r.Context(), id, req.NewPassword); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]string{"message": "password reset"})
+}
+
 func (s *Server) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := uuid.Parse(idStr)
@@ -289,3 +644,90 @@ func (s *Server) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
 
 	s.writeJSON(w, http.StatusNoContent, nil)
 }
+
+// handleSendEmailVerification emails the current user a link to verify
+// their email address.
+func (s *Server) handleSendEmailVerification(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	if err := s.emailVerification.SendVerification(r.Context(), claims.UserID); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusAccepted, map[string]string{"message": "verification email sent"})
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// handleVerifyEmail verifies an email address from the emailed link.
+func (s *Server) handleVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req verifyEmailRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	user, err := s.emailVerification.VerifyEmail(r.Context(), req.Token)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toUserResponse(user))
+}
+
+type changeEmailRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+// handleChangeEmail starts moving the current user to a new email address,
+// which only happens once they confirm it from the link sent there.
+func (s *Server) handleChangeEmail(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	var req changeEmailRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	if err := s.emailVerification.RequestEmailChange(r.Context(), claims.UserID, req.Password, req.Email); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusAccepted, map[string]string{"message": "confirmation email sent"})
+}
+
+type confirmEmailChangeRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// handleConfirmEmailChange applies an email change from the emailed link.
+func (s *Server) handleConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	var req confirmEmailChangeRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	user, err := s.emailVerification.ConfirmEmailChange(r.Context(), req.Token)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toUserResponse(user))
+}
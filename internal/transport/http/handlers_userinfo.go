@@ -0,0 +1,77 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// userInfoResponse holds OpenID Connect standard claims about the bearer of
+// a token. Only sub is always present; the rest depend on the token's scope.
+type userInfoResponse struct {
+	Subject string `json:"sub"`
+
+	// profile scope
+	Name              string `json:"name,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	UpdatedAt         int64  `json:"updated_at,omitempty"`
+	UserType          string `json:"user_type,omitempty"` // The profile's when acting as one
+	Locale            string `json:"locale,omitempty"`
+	Zoneinfo          string `json:"zoneinfo,omitempty"`
+
+	// email scope
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+
+	// phone scope
+	PhoneNumber         *string `json:"phone_number,omitempty"`
+	PhoneNumberVerified *bool   `json:"phone_number_verified,omitempty"`
+}
+
+// handleUserInfo is the OpenID Connect userinfo endpoint: it returns the
+// current details of the token's subject, read from the database, limited
+// to the claims its scope releases. Tokens issued without a scope get all
+// of them.
+func (s *Server) handleUserInfo(w http.ResponseWriter, r *http.Request) {
+	claims := getUserClaims(r.Context())
+	if claims == nil {
+		s.writeError(w, domain.ErrUnauthorized)
+		return
+	}
+
+	if !domain.ScopeGrants(claims.Scope, domain.ScopeOpenID) {
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="openid"`)
+		s.writeErrorResponse(w, http.StatusForbidden, errorResponse{
+			Error: "token was not issued with the openid scope",
+			Code:  "INSUFFICIENT_SCOPE",
+		})
+		return
+	}
+
+	user, err := s.userService.GetUser(r.Context(), claims.UserID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	resp := userInfoResponse{Subject: user.ID.String()}
+	if domain.ScopeGrants(claims.Scope, domain.ScopeProfile) {
+		resp.Name = user.FullName
+		resp.PreferredUsername = user.Username
+		resp.UpdatedAt = user.UpdatedAt.Unix()
+		resp.UserType = claims.UserType
+		resp.Locale = user.Locale
+		resp.Zoneinfo = user.Timezone
+	}
+	if domain.ScopeGrants(claims.Scope, domain.ScopeEmail) {
+		resp.Email = user.Email
+		resp.EmailVerified = &user.EmailVerified
+	}
+	if domain.ScopeGrants(claims.Scope, domain.ScopePhone) && user.Phone != nil {
+		resp.PhoneNumber = user.Phone
+		resp.PhoneNumberVerified = &user.PhoneVerified
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	s.writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,373 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// Webhook response types
+
+type webhookResponse struct {
+	ID          string   `json:"id"`
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	EventTypes  []string `json:"event_types"`
+	Active      bool     `json:"active"`
+	Secret      string   `json:"secret,omitempty"` // Only set on create and rotate
+	CreatedBy   string   `json:"created_by,omitempty"`
+	OwnerID     string   `json:"owner_id,omitempty"` // Set for a partner's own webhook
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+}
+
+type webhookDeliveryResponse struct {
+	ID             string          `json:"id"`
+	WebhookID      string          `json:"webhook_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *string         `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *string         `json:"last_attempt_at,omitempty"`
+	ResponseStatus *int            `json:"response_status,omitempty"`
+	ResponseBody   string          `json:"response_body,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	Payload        json.RawMessage `json:"payload,omitempty"`
+	CreatedAt      string          `json:"created_at"`
+	DeliveredAt    *string         `json:"delivered_at,omitempty"`
+}
+
+func toWebhookResponse(wh *domain.Webhook, withSecret bool) webhookResponse {
+	resp := webhookResponse{
+		ID:          wh.ID.String(),
+		URL:         wh.URL,
+		Description: wh.Description,
+		EventTypes:  wh.EventTypes,
+		Active:      wh.Active,
+		CreatedAt:   wh.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   wh.UpdatedAt.Format(time.RFC3339),
+	}
+
+	if resp.EventTypes == nil {
+		resp.EventTypes = []string{}
+	}
+
+	if withSecret {
+		resp.Secret = wh.Secret
+	}
+
+	if wh.CreatedBy != uuid.Nil {
+		resp.CreatedBy = wh.CreatedBy.String()
+	}
+	if wh.OwnerID != nil {
+		resp.OwnerID = wh.OwnerID.String()
+	}
+
+	return resp
+}
+
+func toWebhookDeliveryResponse(d *domain.WebhookDelivery, withPayload bool) webhookDeliveryResponse {
+	resp := webhookDeliveryResponse{
+		ID:             d.ID.String(),
+		WebhookID:      d.WebhookID.String(),
+		EventID:        d.EventID.String(),
+		EventType:      d.EventType,
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		ResponseStatus: d.ResponseStatus,
+		ResponseBody:   d.ResponseBody,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt.Format(time.RFC3339),
+	}
+
+	if d.Status == domain.DeliveryPending {
+		t := d.NextAttemptAt.Format(time.RFC3339)
+		resp.NextAttemptAt = &t
+	}
+
+	if d.LastAttemptAt != nil {
+		t := d.LastAttemptAt.Format(time.RFC3339)
+		resp.LastAttemptAt = &t
+	}
+
+	if d.DeliveredAt != nil {
+		t := d.DeliveredAt.Format(time.RFC3339)
+		resp.DeliveredAt = &t
+	}
+
+	if withPayload {
+		resp.Payload = d.Payload
+	}
+
+	return resp
+}
+
+// Webhook handlers
+
+type createWebhookRequest struct {
+	URL         string   `json:"url" validate:"required,url"`
+	Description string   `json:"description" validate:"max=500"`
+	EventTypes  []string `json:"event_types"`
+}
+
+func (s *Server) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req createWebhookRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	input := service.CreateWebhookInput{
+		URL:         req.URL,
+		Description: req.Description,
+		EventTypes:  req.EventTypes,
+	}
+	if claims := getUserClaims(r.Context()); claims != nil {
+		input.CreatedBy = claims.UserID
+	}
+
+	webhook, err := s.webhookSvc.CreateWebhook(r.Context(), input)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusCreated, toWebhookResponse(webhook, true))
+}
+
+func (s *Server) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := s.webhookSvc.ListWebhooks(r.Context())
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	webhookResponses := make([]webhookResponse, len(webhooks))
+	for i, wh := range webhooks {
+		webhookResponses[i] = toWebhookResponse(&wh, false)
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"webhooks": webhookResponses,
+		"total":    len(webhooks),
+	})
+}
+
+func (s *Server) handleGetWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	webhook, err := s.webhookSvc.GetWebhook(r.Context(), id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toWebhookResponse(webhook, false))
+}
+
+type updateWebhookRequest struct {
+	URL         *string   `json:"url,omitempty" validate:"url"`
+	Description *string   `json:"description,omitempty" validate:"max=500"`
+	EventTypes  *[]string `json:"event_types,omitempty"`
+	Active      *bool     `json:"active,omitempty"`
+}
+
+func (s *Server) handleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	var req updateWebhookRequest
+	if err := s.readJSON(r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	webhook, err := s.webhookSvc.UpdateWebhook(r.Context(), id, service.UpdateWebhookInput{
+		URL:         req.URL,
+		Description: req.Description,
+		EventTypes:  req.EventTypes,
+		Active:      req.Active,
+	})
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toWebhookResponse(webhook, false))
+}
+
+func (s *Server) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	if err := s.webhookSvc.DeleteWebhook(r.Context(), id); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleRotateWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	webhook, err := s.webhookSvc.RotateSecret(r.Context(), id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toWebhookResponse(webhook, true))
+}
+
+func (s *Server) handleListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	filter, ok := s.parseDeliveryFilter(w, r, id)
+	if !ok {
+		return
+	}
+
+	deliveries, total, err := s.webhookSvc.ListDeliveries(r.Context(), filter)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeDeliveries(w, deliveries, total, filter)
+}
+
+// parseDeliveryFilter reads the offset, limit and status query parameters
+// of a delivery history request.
+func (s *Server) parseDeliveryFilter(w http.ResponseWriter, r *http.Request, webhookID uuid.UUID) (storage.DeliveryFilter, bool) {
+	query := r.URL.Query()
+
+	filter := storage.DeliveryFilter{
+		WebhookID: webhookID,
+		Offset:    0,
+		Limit:     20,
+	}
+
+	if offset, err := strconv.Atoi(query.Get("offset")); err == nil && offset >= 0 {
+		filter.Offset = offset
+	}
+	if limit, err := strconv.Atoi(query.Get("limit")); err == nil && limit > 0 && limit <= 100 {
+		filter.Limit = limit
+	}
+
+	if status := query.Get("status"); status != "" {
+		st := domain.WebhookDeliveryStatus(status)
+		if !st.Valid() {
+			s.writeError(w, domain.ValidationError{Field: "status", Message: "must be one of pending, succeeded, dead"})
+			return filter, false
+		}
+		filter.Status = &st
+	}
+
+	return filter, true
+}
+
+func (s *Server) writeDeliveries(w http.ResponseWriter, deliveries []domain.WebhookDelivery, total int64, filter storage.DeliveryFilter) {
+	deliveryResponses := make([]webhookDeliveryResponse, len(deliveries))
+	for i, d := range deliveries {
+		deliveryResponses[i] = toWebhookDeliveryResponse(&d, false)
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"deliveries": deliveryResponses,
+		"total":      total,
+		"offset":     filter.Offset,
+		"limit":      filter.Limit,
+	})
+}
+
+func (s *Server) handleGetWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	webhookID, deliveryID, ok := s.parseDeliveryPath(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := s.webhookSvc.GetDelivery(r.Context(), webhookID, deliveryID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toWebhookDeliveryResponse(delivery, true))
+}
+
+func (s *Server) handleRedeliverWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	webhookID, deliveryID, ok := s.parseDeliveryPath(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := s.webhookSvc.RedeliverDelivery(r.Context(), webhookID, deliveryID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusAccepted, toWebhookDeliveryResponse(delivery, false))
+}
+
+// handleSendTestWebhook sends a signed webhook.test event now and returns
+// the attempt, with the receiver's response.
+func (s *Server) handleSendTestWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return
+	}
+
+	delivery, err := s.webhookSvc.SendTest(r.Context(), id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toWebhookDeliveryResponse(delivery, true))
+}
+
+func (s *Server) parseDeliveryPath(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	webhookID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "id", Message: "invalid UUID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	deliveryID, err := uuid.Parse(chi.URLParam(r, "deliveryId"))
+	if err != nil {
+		s.writeError(w, domain.ValidationError{Field: "deliveryId", Message: "invalid UUID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return webhookID, deliveryID, true
+}
@@ -0,0 +1,45 @@
+package http
+
+import (
+	"net/http"
+
+	"golang.org/x/text/language"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// errorLanguage picks the language of the error messages written to w: the
+// best match for Accept-Language, else for the user's locale, else English.
+func (s *Server) errorLanguage(w http.ResponseWriter) language.Tag {
+	rw := requestOf(w)
+	if rw == nil {
+		return language.English
+	}
+
+	prefs := rw.accept
+	if tag, err := language.Parse(rw.user); err == nil {
+		prefs = append(prefs[:len(prefs):len(prefs)], tag)
+	}
+	return s.messages.Match(prefs...)
+}
+
+// translateError translates the messages of resp, written for err.
+func (s *Server) translateError(resp *errorResponse, err error, tag language.Tag) {
+	// Validation errors are reworded from their parts, as their Error does
+	switch ve := err.(type) {
+	case domain.ValidationError:
+		resp.Error = s.messages.Sprintf(tag, "validation error on %s: %s", ve.Field, s.messages.Translate(tag, ve.Message))
+	case domain.ValidationErrors:
+		if len(ve) == 1 {
+			resp.Error = s.messages.Sprintf(tag, "validation error on %s: %s", ve[0].Field, s.messages.Translate(tag, ve[0].Message))
+		} else {
+			resp.Error = s.messages.Sprintf(tag, "%d validation errors", len(ve))
+		}
+	default:
+		resp.Error = s.messages.Translate(tag, resp.Error)
+	}
+
+	for field, msg := range resp.Details {
+		resp.Details[field] = s.messages.Translate(tag, msg)
+	}
+}
@@ -0,0 +1,135 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+)
+
+// maxIdempotentBodyBytes caps the request body buffered for hashing.
+const maxIdempotentBodyBytes = 1 << 20
+
+// errNotStored marks a response that should not be replayed (non-2xx), so
+// the key is released and the request can be retried.
+var errNotStored = errors.New("response not stored")
+
+// idempotent makes a mutation safe to retry when the client sends an
+// Idempotency-Key header: the first successful response is stored and
+// replayed to retries with the same key and body, marked with
+// Idempotent-Replayed: true. Requests without the header are unaffected.
+func (s *Server) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" || s.idempotencyService == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodyBytes))
+		if err != nil {
+			s.writeError(w, domain.ValidationError{Field: "body", Message: "too large"})
+			return
+		}
+
+		scope := service.AnonymousIdempotencyScope
+		if claims := getUserClaims(r.Context()); claims != nil {
+			scope = claims.UserID.String()
+		}
+
+		rec := &responseRecorder{header: make(http.Header), request: requestOf(w)}
+
+		resp, err := s.idempotencyService.Do(r.Context(), scope, key, r.Method+" "+r.URL.Path, body,
+			func(ctx context.Context) (*service.IdempotentResponse, error) {
+				r.Body = io.NopCloser(bytes.NewReader(body))
+				next.ServeHTTP(rec, r.WithContext(ctx))
+
+				if rec.status < 200 || rec.status > 299 {
+					return nil, errNotStored
+				}
+				return &service.IdempotentResponse{StatusCode: rec.status, Body: rec.body.Bytes()}, nil
+			})
+
+		switch {
+		case errors.Is(err, errNotStored):
+			rec.flush(w)
+			return
+		case errors.Is(err, domain.ErrIdempotencyKeyReused):
+			s.writeErrorResponse(w, http.StatusUnprocessableEntity, errorResponse{
+				Error: "idempotency key was used for a different request",
+				Code:  "IDEMPOTENCY_KEY_REUSED",
+			})
+			return
+		case errors.Is(err, domain.ErrRequestInProgress):
+			w.Header().Set("Retry-After", "1")
+			s.writeErrorResponse(w, http.StatusConflict, errorResponse{
+				Error: "a request with this idempotency key is in progress",
+				Code:  "REQUEST_IN_PROGRESS",
+			})
+			return
+		case err != nil:
+			var ve domain.ValidationError
+			if errors.As(err, &ve) {
+				s.writeErrorResponse(w, http.StatusBadRequest, errorResponse{
+					Error:   ve.Error(),
+					Code:    "INVALID_INPUT",
+					Details: map[string]string{ve.Field: ve.Message},
+				})
+				return
+			}
+			s.writeError(w, err)
+			return
+		}
+
+		if !resp.Replayed {
+			rec.flush(w)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(resp.StatusCode)
+		_, _ = w.Write(resp.Body)
+	})
+}
+
+// responseRecorder buffers a response so it can be stored before it is sent.
+type responseRecorder struct {
+	header  http.Header
+	status  int
+	body    bytes.Buffer
+	request *requestWriter // Of the writer the response is for, for writeError
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+// flush sends the buffered response.
+func (r *responseRecorder) flush(w http.ResponseWriter) {
+	for k, v := range r.header {
+		w.Header()[k] = v
+	}
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	w.WriteHeader(r.status)
+	_, _ = w.Write(r.body.Bytes())
+}
@@ -1,12 +1,29 @@
 package http
 
 import (
+	"log/slog"
+	"mime"
 	"net/http"
 	"strings"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/auth"
+	"github.com/mvaleed/aegis/internal/authz"
+	"github.com/mvaleed/aegis/internal/domain"
 )
 
+// apiKeyHeader carries a partner API key, as an alternative to sending it
+// as the bearer token.
+const apiKeyHeader = "X-API-Key"
+
+// environmentHeader selects the sandbox for requests without a token, such
+// as registering, logging in and refreshing. Authenticated requests work in
+// the environment of their token or API key.
+const environmentHeader = "X-Environment"
+
 // userClaims holds the authenticated user's information from the JWT.
 type userClaims struct {
 	UserID      uuid.UUID
@@ -14,16 +31,76 @@ type userClaims struct {
 	Username    string
 	UserType    string
 	Permissions []string
+	Denied      []string // Override Permissions
+
+	// Organizations carries the permissions the user's roles grant and deny
+	// in each organization they belong to.
+	Organizations []auth.OrganizationClaim
+
+	// Actor is set when a support user is impersonating UserID.
+	Actor *auth.ActorClaim
+
+	// Profile is set when the user is acting as one of their profiles.
+	Profile *auth.ProfileClaim
+
+	// Scope is the token's OpenID Connect scope; empty is unrestricted.
+	Scope string
+
+	// APIKey is set when the request authenticated with a partner API key.
+	// Permissions are then the key's scopes.
+	APIKey *domain.APIKey
+
+	// ClientID is set when the token was issued to an OAuth client, whose
+	// ID UserID then is.
+	ClientID string
+
+	// Delegation is set when an OAuth client is acting for the user with a
+	// token it got by token exchange.
+	Delegation *auth.DelegationClaim
 }
 
-// hasPermission checks if the user has a specific permission.
+// hasPermission checks if the user has a specific permission that none of
+// their roles deny.
 func (c *userClaims) hasPermission(resource, action string) bool {
+	return permits(c.Permissions, c.Denied, resource, action)
+}
+
+// hasScope reports whether the token was granted any of scopes. Tokens
+// without a scope, API keys included, are granted everything.
+func (c *userClaims) hasScope(scopes ...string) bool {
+	for _, scope := range scopes {
+		if domain.ScopeGrants(c.Scope, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasOrganizationPermission checks the permissions the user's roles scoped
+// to orgID grant and deny.
+func (c *userClaims) hasOrganizationPermission(orgID uuid.UUID, resource, action string) bool {
+	for _, org := range c.Organizations {
+		if org.ID == orgID {
+			return permits(org.Permissions, org.Denied, resource, action)
+		}
+	}
+	return false
+}
+
+// permits reports whether granted covers resource:action, wildcards
+// included, and denied doesn't.
+func permits(granted, denied []string, resource, action string) bool {
 	target := resource + ":" + action
 	wildcard := resource + ":*"
 	superAdmin := "*:*"
 	actionWildcard := "*:" + action
 
-	for _, p := range c.Permissions {
+	for _, p := range denied {
+		if p == target || p == wildcard || p == superAdmin || p == actionWildcard {
+			return false
+		}
+	}
+	for _, p := range granted {
 		if p == target || p == wildcard || p == superAdmin || p == actionWildcard {
 			return true
 		}
@@ -34,9 +111,14 @@ func (c *userClaims) hasPermission(resource, action string) bool {
 // authMiddleware validates JWT tokens and sets user claims in context.
 func (s *Server) authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := r.Header.Get(apiKeyHeader); key != "" {
+			s.serveWithAPIKey(w, r, next, key)
+			return
+		}
+
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			s.writeJSON(w, http.StatusUnauthorized, errorResponse{
+			s.writeErrorResponse(w, http.StatusUnauthorized, errorResponse{
 				Error: "missing authorization header",
 				Code:  "UNAUTHORIZED",
 			})
@@ -46,7 +128,7 @@ func (s *Server) authMiddleware(next http.Handler) http.Handler {
 		// Expect "Bearer <token>"
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			s.writeJSON(w, http.StatusUnauthorized, errorResponse{
+			s.writeErrorResponse(w, http.StatusUnauthorized, errorResponse{
 				Error: "invalid authorization header format",
 				Code:  "UNAUTHORIZED",
 			})
@@ -54,10 +136,14 @@ func (s *Server) authMiddleware(next http.Handler) http.Handler {
 		}
 
 		tokenString := parts[1]
+		if domain.IsAPIKey(tokenString) {
+			s.serveWithAPIKey(w, r, next, tokenString)
+			return
+		}
 
 		claims, err := s.authService.ValidateToken(r.Context(), tokenString)
 		if err != nil {
-			s.writeJSON(w, http.StatusUnauthorized, errorResponse{
+			s.writeErrorResponse(w, http.StatusUnauthorized, errorResponse{
 				Error: "invalid or expired token",
 				Code:  "UNAUTHORIZED",
 			})
@@ -65,33 +151,144 @@ func (s *Server) authMiddleware(next http.Handler) http.Handler {
 		}
 
 		userClaims := &userClaims{
-			UserID:      claims.UserID,
-			Email:       claims.Email,
-			Username:    claims.Username,
-			UserType:    claims.UserType,
-			Permissions: claims.Permissions,
+			UserID:        claims.UserID,
+			Email:         claims.Email,
+			Username:      claims.Username,
+			UserType:      claims.UserType,
+			Permissions:   claims.Permissions,
+			Denied:        claims.Denied,
+			Organizations: claims.Organizations,
+			Actor:         claims.Actor,
+			Profile:       claims.Profile,
+			Scope:         claims.Scope,
+			ClientID:      claims.ClientID,
+			Delegation:    claims.Delegation,
+		}
+
+		if claims.IsImpersonation() {
+			s.logger.Info("impersonated request",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("user_id", claims.UserID.String()),
+				slog.String("actor_id", claims.Actor.UserID.String()),
+				slog.String("session_id", claims.Actor.SessionID.String()),
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+			)
+		}
+
+		if claims.IsDelegation() {
+			s.logger.Info("delegated request",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("user_id", claims.UserID.String()),
+				slog.String("client_id", claims.Delegation.ClientID),
+				slog.String("exchange_id", claims.Delegation.ExchangeID.String()),
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+			)
+		}
+
+		env, _ := domain.ParseEnvironment(claims.Environment)
+		if !s.allowEnvironment(w, env) {
+			return
+		}
+
+		if rw := requestOf(w); rw != nil {
+			rw.user = claims.Locale
 		}
 
-		ctx := setUserClaims(r.Context(), userClaims)
+		ctx := domain.WithEnvironment(r.Context(), env)
+		ctx = setUserClaims(ctx, userClaims)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// environmentMiddleware puts the environment named by environmentHeader in
+// the context.
+func (s *Server) environmentMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		env, ok := domain.ParseEnvironment(r.Header.Get(environmentHeader))
+		if !ok {
+			s.writeErrorResponse(w, http.StatusBadRequest, errorResponse{
+				Error: environmentHeader + " must be production or sandbox",
+				Code:  "INVALID_ENVIRONMENT",
+			})
+			return
+		}
+		if !s.allowEnvironment(w, env) {
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(domain.WithEnvironment(r.Context(), env)))
+	})
+}
+
+// allowEnvironment rejects sandbox requests when the sandbox is disabled.
+func (s *Server) allowEnvironment(w http.ResponseWriter, env domain.Environment) bool {
+	if env.IsSandbox() && !s.sandboxEnabled {
+		s.writeErrorResponse(w, http.StatusForbidden, errorResponse{
+			Error: "the sandbox is disabled",
+			Code:  "SANDBOX_DISABLED",
+		})
+		return false
+	}
+	return true
+}
+
+// optionalAuthMiddleware authenticates requests that carry a token and lets
+// anonymous requests through without claims.
+func (s *Server) optionalAuthMiddleware(next http.Handler) http.Handler {
+	authenticated := s.authMiddleware(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" && r.Header.Get(apiKeyHeader) == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		authenticated.ServeHTTP(w, r)
+	})
+}
+
 // requirePermission returns middleware that checks for a specific permission.
 func (s *Server) requirePermission(resource, action string) func(http.Handler) http.Handler {
+	return s.requireAnyPermission(resource, action)
+}
+
+// requireAnyPermission returns middleware that checks for any of the
+// actions on resource, such as users:read or users:read_basic, whose
+// responses filterFields trims.
+func (s *Server) requireAnyPermission(resource string, actions ...string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			claims := getUserClaims(r.Context())
 			if claims == nil {
-				s.writeJSON(w, http.StatusUnauthorized, errorResponse{
+				s.writeErrorResponse(w, http.StatusUnauthorized, errorResponse{
 					Error: "unauthorized",
 					Code:  "UNAUTHORIZED",
 				})
 				return
 			}
 
-			if !claims.hasPermission(resource, action) {
-				s.writeJSON(w, http.StatusForbidden, errorResponse{
+			action, allowed := actions[0], false
+			for _, a := range actions {
+				if claims.hasPermission(resource, a) {
+					action, allowed = a, true
+					break
+				}
+			}
+
+			// API keys, profiles, clients and delegation tokens don't act
+			// through the user's roles, or not all of them
+			if claims.APIKey == nil && claims.Profile == nil && claims.ClientID == "" && claims.Delegation == nil {
+				s.shadow.Observe(r.Context(), authz.Decision{
+					UserID:   claims.UserID,
+					Resource: resource,
+					Action:   action,
+					Allowed:  allowed,
+					Source:   "http " + r.Method + " " + r.URL.Path,
+				})
+			}
+
+			if !allowed {
+				s.writeErrorResponse(w, http.StatusForbidden, errorResponse{
 					Error: "you don't have permission to perform this action",
 					Code:  "FORBIDDEN",
 				})
@@ -103,6 +300,229 @@ func (s *Server) requirePermission(resource, action string) func(http.Handler) h
 	}
 }
 
+// requireOrganizationPermission returns middleware that scopes a route to
+// the organization in its orgId URL parameter: the user needs the
+// permission from a role in that organization. Global roles don't count, so
+// organization admins can only reach their own organizations.
+func (s *Server) requireOrganizationPermission(resource, action string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := getUserClaims(r.Context())
+			if claims == nil {
+				s.writeErrorResponse(w, http.StatusUnauthorized, errorResponse{
+					Error: "unauthorized",
+					Code:  "UNAUTHORIZED",
+				})
+				return
+			}
+
+			orgID, err := uuid.Parse(chi.URLParam(r, "orgId"))
+			if err != nil {
+				s.writeError(w, domain.ValidationError{Field: "orgId", Message: "invalid UUID"})
+				return
+			}
+
+			if !claims.hasOrganizationPermission(orgID, resource, action) {
+				s.writeErrorResponse(w, http.StatusForbidden, errorResponse{
+					Error: "you don't have permission to perform this action in this organization",
+					Code:  "FORBIDDEN",
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// serveWithAPIKey authenticates the request as the owner of a partner API
+// key, with the key's scopes as permissions. Keys are production data, but
+// requests made with a sandbox key work in the sandbox.
+func (s *Server) serveWithAPIKey(w http.ResponseWriter, r *http.Request, next http.Handler, raw string) {
+	production := domain.WithEnvironment(r.Context(), domain.EnvironmentProduction)
+	key, err := s.portalService.AuthenticateAPIKey(production, raw)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusUnauthorized, errorResponse{
+			Error: "invalid API key",
+			Code:  "UNAUTHORIZED",
+		})
+		return
+	}
+
+	env := key.Environment.DataEnvironment()
+	if !s.allowEnvironment(w, env) {
+		return
+	}
+
+	ctx := setUserClaims(domain.WithEnvironment(r.Context(), env), &userClaims{
+		UserID:      key.OwnerID,
+		UserType:    string(domain.UserTypePartner),
+		Permissions: key.Scopes,
+		APIKey:      key,
+	})
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// requirePartner only lets partners through: partner users and anyone
+// acting as their partner profile.
+func (s *Server) requirePartner(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if claims := getUserClaims(r.Context()); claims == nil || claims.UserType != string(domain.UserTypePartner) {
+			s.writeErrorResponse(w, http.StatusForbidden, errorResponse{
+				Error: "only available to partners",
+				Code:  "FORBIDDEN",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireConsent lets partners act on the user in the {id} path parameter
+// only within that user's consent for resource:action. Everyone else, and
+// partners acting on themselves, pass through. It must run after routing,
+// so use it with With or in a Group rather than on a Route.
+func (s *Server) requireConsent(resource, action string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := getUserClaims(r.Context())
+			if claims == nil || claims.UserType != string(domain.UserTypePartner) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Handlers reject a malformed ID themselves
+			targetID, err := uuid.Parse(chi.URLParam(r, "id"))
+			if err != nil || targetID == claims.UserID {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			allowed, err := s.consentService.Allows(r.Context(), targetID, claims.UserID, resource+":"+action)
+			if err != nil {
+				s.writeError(w, err)
+				return
+			}
+			if !allowed {
+				s.writeErrorResponse(w, http.StatusForbidden, errorResponse{
+					Error: "the user hasn't consented to this access",
+					Code:  "CONSENT_REQUIRED",
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// denyPartner rejects partners, for requests that would reach users
+// regardless of their consent.
+func (s *Server) denyPartner(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if claims := getUserClaims(r.Context()); claims != nil && claims.UserType == string(domain.UserTypePartner) {
+			s.writeErrorResponse(w, http.StatusForbidden, errorResponse{
+				Error: "not available to partners",
+				Code:  "FORBIDDEN",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireScope returns middleware that checks the token was granted any of
+// scopes, on top of the permissions the route requires.
+func (s *Server) requireScope(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := getUserClaims(r.Context())
+			if claims == nil {
+				s.writeErrorResponse(w, http.StatusUnauthorized, errorResponse{
+					Error: "unauthorized",
+					Code:  "UNAUTHORIZED",
+				})
+				return
+			}
+
+			if !claims.hasScope(scopes...) {
+				s.writeErrorResponse(w, http.StatusForbidden, errorResponse{
+					Error: "token scope doesn't allow this action; requires " + strings.Join(scopes, " or "),
+					Code:  "INSUFFICIENT_SCOPE",
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireAPIScope requires the API scope for the request's method:
+// api.read or api.write to read, api.write to change anything.
+func (s *Server) requireAPIScope(next http.Handler) http.Handler {
+	read := s.requireScope(domain.ScopeAPIRead, domain.ScopeAPIWrite)(next)
+	write := s.requireScope(domain.ScopeAPIWrite)(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			read.ServeHTTP(w, r)
+		default:
+			write.ServeHTTP(w, r)
+		}
+	})
+}
+
+// denyAPIKey rejects requests authenticated with an API key, for actions
+// that need the partner signed in, and those made with an OAuth client's
+// token, which has no user behind it or, delegated, isn't the user.
+func (s *Server) denyAPIKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims := getUserClaims(r.Context())
+		if claims != nil && claims.APIKey != nil {
+			s.writeErrorResponse(w, http.StatusForbidden, errorResponse{
+				Error: "not allowed with an API key",
+				Code:  "API_KEY_FORBIDDEN",
+			})
+			return
+		}
+		if claims != nil && claims.ClientID != "" {
+			s.writeErrorResponse(w, http.StatusForbidden, errorResponse{
+				Error: "not allowed with a client token",
+				Code:  "CLIENT_FORBIDDEN",
+			})
+			return
+		}
+		if claims != nil && claims.Delegation != nil {
+			s.writeErrorResponse(w, http.StatusForbidden, errorResponse{
+				Error: "not allowed with a delegation token",
+				Code:  "DELEGATION_FORBIDDEN",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// denyImpersonation rejects requests made with an impersonation token, for
+// actions only the account owner may take.
+func (s *Server) denyImpersonation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if claims := getUserClaims(r.Context()); claims != nil && claims.Actor != nil {
+			s.writeErrorResponse(w, http.StatusForbidden, errorResponse{
+				Error: "not allowed while impersonating",
+				Code:  "IMPERSONATION_FORBIDDEN",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 // getClientIP extracts the client IP from the request.
 func getClientIP(r *http.Request) string {
 	// Try X-Forwarded-For first (set by proxies/load balancers)
@@ -125,3 +545,23 @@ func getClientIP(r *http.Request) string {
 	}
 	return r.RemoteAddr
 }
+
+// mergePatch admits PATCH requests whose body is a JSON merge patch (RFC
+// 7396), sent as application/merge-patch+json or plain application/json,
+// rejecting other patch formats, such as JSON Patch, which would decode as
+// something else or not at all.
+func (s *Server) mergePatch(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType != "application/merge-patch+json" && mediaType != "application/json" {
+			w.Header().Set("Accept-Patch", "application/merge-patch+json")
+			s.writeErrorResponse(w, http.StatusUnsupportedMediaType, errorResponse{
+				Error: "body must be a JSON merge patch (application/merge-patch+json)",
+				Code:  "UNSUPPORTED_MEDIA_TYPE",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
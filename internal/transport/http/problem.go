@@ -0,0 +1,119 @@
+package http
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// problemTypePrefix makes a problem type URI of an error code, e.g.
+// urn:aegis:problem:version-mismatch for VERSION_MISMATCH.
+const problemTypePrefix = "urn:aegis:problem:"
+
+// problem is an error response as Problem Details (RFC 9457). Code, Errors
+// and Suggestions are extension members, the first two carrying what the
+// legacy body's code and details did.
+type problem struct {
+	Type        string         `json:"type"`
+	Title       string         `json:"title"`
+	Status      int            `json:"status"`
+	Detail      string         `json:"detail,omitempty"`
+	Instance    string         `json:"instance,omitempty"`
+	Code        string         `json:"code,omitempty"`
+	Errors      []fieldProblem `json:"errors,omitempty"`
+	Suggestions []string       `json:"suggestions,omitempty"`
+}
+
+// fieldProblem is what's wrong with one field of a request.
+type fieldProblem struct {
+	Field  string `json:"field"`
+	Detail string `json:"detail"`
+}
+
+// requestWriter carries what writeError needs to know of the request, which
+// it isn't handed, down to it with the writer.
+type requestWriter struct {
+	http.ResponseWriter
+	path   string         // For the problem's instance
+	accept []language.Tag // From Accept-Language, most preferred first
+	user   string         // The authenticated user's locale, set by authMiddleware
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *requestWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// requestMiddleware wraps the writer in a requestWriter.
+func (s *Server) requestMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept, _, _ := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+		next.ServeHTTP(&requestWriter{ResponseWriter: w, path: r.URL.Path, accept: accept}, r)
+	})
+}
+
+// requestOf finds the requestWriter w writes through, or nil.
+func requestOf(w http.ResponseWriter) *requestWriter {
+	for {
+		switch v := w.(type) {
+		case *requestWriter:
+			return v
+		case *responseRecorder:
+			return v.request
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = v.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
+
+// writeErrorResponse writes resp, an error not coming from the service
+// layer, in the configured error format.
+func (s *Server) writeErrorResponse(w http.ResponseWriter, status int, resp errorResponse) {
+	s.sendError(w, status, resp, nil)
+}
+
+// sendError translates resp, written for err, and writes it as problem
+// details, or as it is with ERROR_FORMAT=legacy.
+func (s *Server) sendError(w http.ResponseWriter, status int, resp errorResponse, err error) {
+	w.Header().Add("Vary", "Accept-Language")
+	if tag := s.errorLanguage(w); tag != language.English {
+		s.translateError(&resp, err, tag)
+		w.Header().Set("Content-Language", tag.String())
+	}
+
+	if s.legacyErrors {
+		s.writeJSON(w, status, resp)
+		return
+	}
+
+	p := problem{
+		Type:        "about:blank",
+		Title:       http.StatusText(status),
+		Status:      status,
+		Detail:      resp.Error,
+		Code:        resp.Code,
+		Suggestions: resp.Suggestions,
+	}
+	if resp.Code != "" {
+		p.Type = problemTypePrefix + strings.ReplaceAll(strings.ToLower(resp.Code), "_", "-")
+	}
+	if rw := requestOf(w); rw != nil {
+		p.Instance = rw.path
+	}
+	for field, detail := range resp.Details {
+		p.Errors = append(p.Errors, fieldProblem{Field: field, Detail: detail})
+	}
+	slices.SortFunc(p.Errors, func(a, b fieldProblem) int { return strings.Compare(a.Field, b.Field) })
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		s.logger.Error("failed to encode response", slog.String("error", err.Error()))
+	}
+}
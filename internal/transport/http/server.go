@@ -3,6 +3,7 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"log/slog"
@@ -11,57 +12,221 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/mvaleed/aegis/internal/auth"
+	"github.com/mvaleed/aegis/internal/authz"
+	"github.com/mvaleed/aegis/internal/cache"
 	"github.com/mvaleed/aegis/internal/config"
 	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/i18n"
+	"github.com/mvaleed/aegis/internal/jobs"
+	"github.com/mvaleed/aegis/internal/notify"
 	"github.com/mvaleed/aegis/internal/service"
+	"github.com/mvaleed/aegis/internal/tracing"
+	"github.com/mvaleed/aegis/internal/transport/graphql"
+	"github.com/mvaleed/aegis/internal/validate"
 )
 
 // Server is the HTTP server for the user service.
 type Server struct {
-	httpServer  *http.Server
-	router      *chi.Mux
-	userService *service.UserService
-	authService *service.AuthService
-	rbacService *service.RBACService
-	jwtManager  *auth.JWTManager
-	logger      *slog.Logger
+	httpServer              *http.Server
+	router                  *chi.Mux
+	userService             *service.UserService
+	authService             *service.AuthService
+	rbacService             *service.RBACService
+	webhookSvc              *service.WebhookService
+	orgService              *service.OrganizationService
+	groupService            *service.GroupService
+	invitationService       *service.InvitationService
+	idempotencyService      *service.IdempotencyService
+	availabilityService     *service.AvailabilityService
+	attributeService        *service.AttributeService
+	deviceService           *service.DeviceService
+	profileService          *service.ProfileService
+	portalService           *service.PortalService
+	consentService          *service.ConsentService
+	emailVerification       *service.EmailVerificationService
+	schemaService           *service.SchemaService
+	policyService           *service.PolicyService
+	supportService          *service.SupportService
+	orgAdminService         *service.OrgAdminService
+	ldapService             *service.LDAPService // Nil unless LDAP_ENABLED is set
+	samlService             *service.SAMLService // Nil unless SAML_ENABLED is set
+	deviceAuthService       *service.DeviceAuthService
+	oauthClientService      *service.OAuthClientService
+	pipelineService         *service.PipelineService
+	tokenExchangeService    *service.TokenExchangeService
+	accountDeletions        *service.AccountDeletionService
+	notificationPreferences *service.NotificationPreferenceService
+	shadow                  *authz.Shadow  // Nil unless a shadow policy is set
+	responseCache           *cache.Cache   // Nil unless CACHE_BACKEND is set
+	outbox                  *notify.Outbox // Only set in development
+	costLimiter             *costLimiter
+	availabilityLimiter     *costLimiter
+	supportLookupLimiter    *costLimiter
+	activity                activityCache
+	eventBus                *event.Bus
+	brokerQueue             *event.Resilient
+	scheduler               *jobs.Scheduler
+	messages                *i18n.Catalog // Translates writeError's messages
+	jwtManager              *auth.JWTManager
+	logger                  *slog.Logger
+
+	sandboxEnabled   bool
+	websocketOrigins []string
+	samlRedirectURL  string
+	legacyErrors     bool // ERROR_FORMAT=legacy
+	compressionLevel int  // 0 not to compress
 }
 
-// NewServer creates a new HTTP server.
+// NewServer creates a new HTTP server. Rate limits and cost quotas follow
+// reloads of live.
 func NewServer(
-	cfg *config.Config,
+	live *config.Live,
 	userService *service.UserService,
 	authService *service.AuthService,
 	rbacService *service.RBACService,
+	webhookService *service.WebhookService,
+	orgService *service.OrganizationService,
+	groupService *service.GroupService,
+	invitationService *service.InvitationService,
+	idempotencyService *service.IdempotencyService,
+	availabilityService *service.AvailabilityService,
+	attributeService *service.AttributeService,
+	deviceService *service.DeviceService,
+	profileService *service.ProfileService,
+	portalService *service.PortalService,
+	consentService *service.ConsentService,
+	emailVerification *service.EmailVerificationService,
+	schemaService *service.SchemaService,
+	policyService *service.PolicyService,
+	supportService *service.SupportService,
+	orgAdminService *service.OrgAdminService,
+	ldapService *service.LDAPService,
+	samlService *service.SAMLService,
+	deviceAuthService *service.DeviceAuthService,
+	oauthClientService *service.OAuthClientService,
+	pipelineService *service.PipelineService,
+	tokenExchangeService *service.TokenExchangeService,
+	accountDeletions *service.AccountDeletionService,
+	notificationPreferences *service.NotificationPreferenceService,
+	shadow *authz.Shadow,
+	responseCache *cache.Cache,
+	outbox *notify.Outbox,
+	eventBus *event.Bus,
+	brokerQueue *event.Resilient,
+	scheduler *jobs.Scheduler,
+	messages *i18n.Catalog,
 	jwtManager *auth.JWTManager,
 	logger *slog.Logger,
 ) *Server {
+	cfg := live.Load()
 	s := &Server{
-		router:      chi.NewRouter(),
-		userService: userService,
-		authService: authService,
-		rbacService: rbacService,
+		router:                  chi.NewRouter(),
+		userService:             userService,
+		authService:             authService,
+		rbacService:             rbacService,
+		webhookSvc:              webhookService,
+		orgService:              orgService,
+		groupService:            groupService,
+		invitationService:       invitationService,
+		idempotencyService:      idempotencyService,
+		availabilityService:     availabilityService,
+		attributeService:        attributeService,
+		deviceService:           deviceService,
+		profileService:          profileService,
+		portalService:           portalService,
+		consentService:          consentService,
+		emailVerification:       emailVerification,
+		schemaService:           schemaService,
+		policyService:           policyService,
+		supportService:          supportService,
+		orgAdminService:         orgAdminService,
+		ldapService:             ldapService,
+		samlService:             samlService,
+		deviceAuthService:       deviceAuthService,
+		oauthClientService:      oauthClientService,
+		pipelineService:         pipelineService,
+		tokenExchangeService:    tokenExchangeService,
+		accountDeletions:        accountDeletions,
+		notificationPreferences: notificationPreferences,
+		shadow:                  shadow,
+		responseCache:           responseCache,
+		outbox:                  outbox,
+		availabilityLimiter: newCostLimiter(
+			cfg.AvailabilityRatePerMinute,
+			cfg.AvailabilityRatePerMinute,
+			true,
+			logger,
+		),
+		supportLookupLimiter: newCostLimiter(
+			cfg.SupportLookupRatePerMinute,
+			cfg.SupportLookupRatePerMinute,
+			true,
+			logger,
+		),
+		eventBus:    eventBus,
+		brokerQueue: brokerQueue,
+		scheduler:   scheduler,
+		messages:    messages,
 		jwtManager:  jwtManager,
 		logger:      logger,
+
+		sandboxEnabled:   cfg.SandboxEnabled,
+		websocketOrigins: cfg.WebSocketOrigins,
+		samlRedirectURL:  cfg.SAMLRedirectURL,
+		legacyErrors:     cfg.ErrorFormat == "legacy",
+		compressionLevel: cfg.HTTPCompressionLevel,
 	}
 
+	if cfg.CostQuotaEnabled {
+		s.costLimiter = newCostLimiter(
+			cfg.CostQuotaCapacity,
+			cfg.CostQuotaRefillPerMinute,
+			cfg.CostQuotaEnforce,
+			logger,
+		)
+	}
+
+	live.OnReload(func(cfg *config.Config) {
+		s.availabilityLimiter.setLimits(cfg.AvailabilityRatePerMinute, cfg.AvailabilityRatePerMinute, true)
+		s.supportLookupLimiter.setLimits(cfg.SupportLookupRatePerMinute, cfg.SupportLookupRatePerMinute, true)
+		if s.costLimiter != nil {
+			s.costLimiter.setLimits(cfg.CostQuotaCapacity, cfg.CostQuotaRefillPerMinute, cfg.CostQuotaEnforce)
+		}
+	})
+
 	s.setupMiddleware()
 	s.setupRoutes()
 
 	return s
 }
 
-// ListenAndServe starts the HTTP server on the given address.
-func (s *Server) ListenAndServe(addr string) error {
+// ListenAndServe starts the HTTP server on the given address, over TLS if
+// tlsConfig isn't nil. It speaks HTTP/1.1 and HTTP/2, the latter as h2c
+// without TLS.
+func (s *Server) ListenAndServe(addr string, tlsConfig *tls.Config) error {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(true)
+
 	s.httpServer = &http.Server{
 		Addr:         addr,
 		Handler:      s.router,
+		Protocols:    protocols,
+		TLSConfig:    tlsConfig,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	if tlsConfig != nil {
+		// The certificate comes from tlsConfig
+		return s.httpServer.ListenAndServeTLS("", "")
+	}
 	return s.httpServer.ListenAndServe()
 }
 
@@ -73,64 +238,238 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	return s.httpServer.Shutdown(ctx)
 }
 
+// compressedTypes are the content types worth compressing: JSON, from lists
+// and exports especially, and CSV exports. Event streams aren't, as they
+// flush event by event.
+var compressedTypes = []string{
+	"application/json",
+	"application/problem+json",
+	"application/scim+json",
+	"text/csv",
+}
+
 func (s *Server) setupMiddleware() {
+	s.router.Use(s.tracingMiddleware)
 	s.router.Use(middleware.RequestID)
+	s.router.Use(s.correlationMiddleware)
+	s.router.Use(s.environmentMiddleware)
 	s.router.Use(middleware.RealIP)
 	s.router.Use(s.loggingMiddleware)
+	if s.compressionLevel > 0 {
+		// Outside the response cache, so it caches uncompressed responses
+		s.router.Use(middleware.Compress(s.compressionLevel, compressedTypes...))
+	}
+	s.router.Use(s.requestMiddleware)
 	s.router.Use(middleware.Recoverer)
-	s.router.Use(middleware.Timeout(30 * time.Second))
+	s.router.Use(s.timeoutMiddleware(30 * time.Second))
+}
+
+// tracingMiddleware starts a server span per request. Spans start out named
+// after the path, which the sampler matches on, and are renamed to the route
+// pattern once the request has been routed.
+func (s *Server) tracingMiddleware(next http.Handler) http.Handler {
+	named := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		if pattern := chi.RouteContext(r.Context()).RoutePattern(); pattern != "" {
+			trace.SpanFromContext(r.Context()).SetName(r.Method + " " + pattern)
+		}
+	})
+	return otelhttp.NewHandler(named, "http", otelhttp.WithSpanNameFormatter(tracing.HTTPSpanName))
+}
+
+// correlationMiddleware makes the request ID the correlation ID of events
+// published while handling the request.
+func (s *Server) correlationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := event.WithCorrelationID(r.Context(), middleware.GetReqID(r.Context()))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// streamingPaths are the routes that respond for as long as the client
+// stays connected: Server-Sent Events and WebSockets.
+var streamingPaths = map[string]bool{
+	"/api/v1/events/stream": true,
+	"/api/v1/activity/live": true,
+}
+
+// timeoutMiddleware applies a request timeout to everything except the
+// streaming routes. They're told apart by path, which the client can't
+// change without missing the route, rather than by headers it chooses.
+func (s *Server) timeoutMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
+	withTimeout := middleware.Timeout(timeout)
+	return func(next http.Handler) http.Handler {
+		timed := withTimeout(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if streamingPaths[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			timed.ServeHTTP(w, r)
+		})
+	}
 }
 
 func (s *Server) setupRoutes() {
 	s.router.Get("/health", s.handleHealth)
+	s.router.Get("/readyz", s.handleReady)
+	s.router.Get("/metrics", s.handleMetrics)
+
+	// OpenID Connect clients expect userinfo at a fixed path, by GET or POST
+	s.router.With(s.authMiddleware).Get("/userinfo", s.handleUserInfo)
+	s.router.With(s.authMiddleware).Post("/userinfo", s.handleUserInfo)
+
+	// OAuth 2.0 token endpoint, for machine clients and for CLIs and other
+	// devices without a browser, and introspection, for services verifying
+	// tokens they can't read
+	s.router.Post("/oauth/device/code", s.handleDeviceCode)
+	s.router.Post("/oauth/token", s.handleToken)
+	s.router.Post("/oauth/introspect", s.handleIntrospect)
+
+	if s.outbox != nil {
+		s.router.Route("/dev/outbox", func(r chi.Router) {
+			r.Get("/", s.handleListOutbox)
+			r.Get("/latest", s.handleLatestOutboxMessage)
+			r.Get("/{id}", s.handleGetOutboxMessage)
+			r.Delete("/", s.handleClearOutbox)
+		})
+	}
 
 	s.router.Route("/api/v1", func(r chi.Router) {
-		r.Post("/auth/register", s.handleRegister)
+		r.With(s.idempotent).Post("/auth/register", s.handleRegister)
 		r.Post("/auth/login", s.handleLogin)
 		r.Post("/auth/refresh", s.handleRefreshToken)
+		r.Post("/invitations/accept", s.handleAcceptInvitation)
+		r.Post("/auth/devices/confirm", s.handleConfirmDevice)
+		r.Post("/auth/verify-email", s.handleVerifyEmail)
+		r.Post("/auth/confirm-email-change", s.handleConfirmEmailChange)
+		r.With(s.optionalAuthMiddleware, s.availabilityLimit).Get("/availability", s.handleCheckAvailability)
+
+		// Service provider endpoints of each SAML identity provider
+		if s.samlService != nil {
+			r.Route("/auth/saml/{provider}", func(r chi.Router) {
+				r.Get("/metadata", s.handleSAMLMetadata)
+				r.Get("/login", s.handleSAMLLogin)
+				r.Post("/acs", s.handleSAMLACS)
+			})
+		}
 
+		// Any token may sign out and ask for userinfo, whatever its scope
 		r.Group(func(r chi.Router) {
 			r.Use(s.authMiddleware)
 
 			r.Post("/auth/logout", s.handleLogout)
-			r.Post("/auth/logout-all", s.handleLogoutAll)
+			r.With(s.denyAPIKey, s.denyImpersonation).Post("/auth/logout-all", s.handleLogoutAll)
+			r.Post("/impersonation/end", s.handleEndImpersonation)
+			r.Get("/userinfo", s.handleUserInfo)
+			r.Post("/userinfo", s.handleUserInfo)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(s.websocketBearer, s.authMiddleware, s.requireAPIScope)
+
+			// Approving a device signs it in as the user, so only they can
+			r.Route("/oauth/device", func(r chi.Router) {
+				r.Use(s.denyAPIKey, s.denyImpersonation, s.withCost(fixedCost(costCodeEntry)))
+				r.Get("/", s.handleGetDeviceAuthorization)
+				r.Post("/approve", s.handleApproveDeviceAuthorization)
+				r.Post("/deny", s.handleDenyDeviceAuthorization)
+			})
 
 			r.Get("/users/me", s.handleGetCurrentUser)
 			r.Put("/users/me", s.handleUpdateCurrentUser)
-			r.Put("/users/me/password", s.handleChangePassword)
+			r.With(s.mergePatch).Patch("/users/me", s.handleUpdateCurrentUser)
+			r.With(s.denyAPIKey, s.denyImpersonation).Put("/users/me/password", s.handleChangePassword)
+			r.With(s.denyAPIKey, s.denyImpersonation).Put("/users/me/username", s.handleChangeUsername)
+			r.Get("/users/me/organizations", s.handleListCurrentUserOrganizations)
+			r.Get("/users/me/groups", s.handleListCurrentUserGroups)
+			r.Get("/users/me/devices", s.handleListCurrentUserDevices)
+			r.Get("/users/me/login-history", s.handleListCurrentUserLoginHistory)
+			r.Get("/users/me/preferences", s.handleGetNotificationPreferences)
+			r.Group(func(r chi.Router) {
+				r.Use(s.denyAPIKey, s.denyImpersonation)
+				r.Put("/users/me/devices/{deviceId}/trust", s.handleTrustDevice)
+				r.Delete("/users/me/devices/{deviceId}/trust", s.handleUntrustDevice)
+				r.Delete("/users/me/devices/{deviceId}", s.handleForgetDevice)
+				r.Post("/users/me/email/verification", s.handleSendEmailVerification)
+				r.Post("/users/me/email", s.handleChangeEmail)
+				r.Post("/users/me/deactivate", s.handleDeactivateCurrentUser)
+				r.Post("/users/me/delete", s.handleScheduleAccountDeletion)
+				r.Delete("/users/me/delete", s.handleCancelAccountDeletion)
+				r.Put("/users/me/preferences", s.handleUpdateNotificationPreferences)
+				r.Post("/users/me/profiles", s.handleCreateProfile)
+				r.Delete("/users/me/profiles/{profileId}", s.handleDetachProfile)
+				r.Post("/auth/profiles/switch", s.handleSwitchProfile)
+			})
+			r.Get("/users/me/profiles", s.handleListProfiles)
+			r.Get("/users/me/consents", s.handleListConsents)
+			r.Group(func(r chi.Router) {
+				r.Use(s.denyAPIKey, s.denyImpersonation)
+				r.Put("/users/me/consents", s.handleGrantConsent)
+				r.Delete("/users/me/consents/{consentId}", s.handleRevokeConsent)
+			})
 
 			r.Route("/users", func(r chi.Router) {
-				r.Use(s.requirePermission("users", "read"))
-				r.Get("/", s.handleListUsers)
-				r.Get("/{id}", s.handleGetUser)
+				r.Use(s.requireAnyPermission("users", "read", "read_basic"), s.filterFields(authz.UserFields))
+				r.With(s.withCost(searchCost), s.denyPartner).Get("/", s.handleListUsers)
+				r.With(s.requireConsent("users", "read")).Get("/{id}", s.handleGetUser)
+				r.With(s.denyPartner).Get("/by-username/{username}", s.handleGetUserByUsername)
 
 				r.Group(func(r chi.Router) {
 					r.Use(s.requirePermission("users", "write"))
+					r.Use(s.requireConsent("users", "write"))
 					r.Put("/{id}", s.handleUpdateUser)
+					r.With(s.mergePatch).Patch("/{id}", s.handleUpdateUser)
+					r.Patch("/{id}/attributes", s.handleUpdateUserAttributes)
 					r.Post("/{id}/activate", s.handleActivateUser)
 					r.Post("/{id}/suspend", s.handleSuspendUser)
+					r.With(s.denyAPIKey, s.denyImpersonation).Put("/{id}/password", s.handleResetUserPassword)
 				})
 
 				r.Group(func(r chi.Router) {
 					r.Use(s.requirePermission("users", "delete"))
+					r.Use(s.requireConsent("users", "delete"))
 					r.Delete("/{id}", s.handleDeleteUser)
 				})
+
+				r.Group(func(r chi.Router) {
+					r.Use(s.requirePermission("users", "audit"))
+					r.Use(s.requireConsent("users", "audit"))
+					r.With(s.withCost(fixedCost(costHistory))).Get("/{id}/snapshot", s.handleGetUserSnapshot)
+					r.With(s.withCost(fixedCost(costList))).Get("/{id}/impersonations", s.handleListImpersonations)
+					r.With(s.withCost(fixedCost(costList))).Get("/{id}/token-exchanges", s.handleListUserTokenExchanges)
+					r.Get("/{id}/devices", s.handleListUserDevices)
+					r.With(s.withCost(fixedCost(costList))).Get("/{id}/login-history", s.handleListUserLoginHistory)
+					r.With(s.withCost(fixedCost(costHistory))).Get("/{id}/timeline", s.handleGetUserTimeline)
+					r.Get("/{id}/usernames", s.handleListUsernameChanges)
+				})
+
+				r.Group(func(r chi.Router) {
+					r.Use(s.requirePermission("users", "impersonate"))
+					r.Use(s.denyAPIKey, s.denyImpersonation, s.denyPartner)
+					r.Post("/{id}/impersonate", s.handleStartImpersonation)
+				})
 			})
 
 			r.Group(func(r chi.Router) {
 				r.Use(s.requirePermission("roles", "assign"))
-				r.Post("/users/{id}/roles", s.handleAssignRoleToUser)
+				r.Use(s.requireConsent("roles", "assign"))
+				r.With(s.idempotent).Post("/users/{id}/roles", s.handleAssignRoleToUser)
 				r.Delete("/users/{id}/roles/{roleId}", s.handleRemoveRoleFromUser)
+				r.Post("/users/{id}/profiles/{profileId}/roles", s.handleAssignRoleToProfile)
+				r.Delete("/users/{id}/profiles/{profileId}/roles/{roleId}", s.handleRemoveRoleFromProfile)
 			})
 
 			r.Route("/roles", func(r chi.Router) {
 				r.Use(s.requirePermission("roles", "read"))
-				r.Get("/", s.handleListRoles)
-				r.Get("/{id}", s.handleGetRole)
+				r.With(s.cached(cacheRoles)).Get("/", s.handleListRoles)
+				r.With(s.cached(cacheRoles)).Get("/{id}", s.handleGetRole)
+				r.With(s.requireAnyPermission("users", "read", "read_basic"), s.filterFields(authz.UserFields), s.withCost(fixedCost(costList)), s.denyPartner).
+					Get("/{id}/users", s.handleListRoleUsers)
 
 				r.Group(func(r chi.Router) {
 					r.Use(s.requirePermission("roles", "write"))
-					r.Post("/", s.handleCreateRole)
+					r.With(s.idempotent).Post("/", s.handleCreateRole)
 					r.Put("/{id}", s.handleUpdateRole)
 					r.Post("/{id}/permissions", s.handleAddPermissionToRole)
 					r.Delete("/{id}/permissions/{permissionId}", s.handleRemovePermissionFromRole)
@@ -142,10 +481,253 @@ func (s *Server) setupRoutes() {
 				})
 			})
 
+			// Which roles LDAP groups grant, synced as members sign in
+			if s.ldapService != nil {
+				r.Route("/ldap/group-mappings", func(r chi.Router) {
+					r.With(s.requirePermission("roles", "read")).Get("/", s.handleListLDAPMappings)
+
+					r.Group(func(r chi.Router) {
+						r.Use(s.requirePermission("roles", "assign"))
+						r.Post("/", s.handleCreateLDAPMapping)
+						r.Delete("/{id}", s.handleDeleteLDAPMapping)
+					})
+				})
+
+				// Which accounts directory entries sign in to
+				r.Route("/ldap/identities", func(r chi.Router) {
+					r.Use(s.requirePermission("users", "admin"))
+					r.Get("/", s.handleListLDAPIdentities)
+					r.Post("/", s.handleLinkLDAPIdentity)
+					r.Delete("/{id}", s.handleUnlinkLDAPIdentity)
+				})
+			}
+
+			// Machine clients of the client credentials grant
+			r.Route("/oauth/clients", func(r chi.Router) {
+				r.Use(s.requirePermission("oauth_clients", "read"))
+				r.Get("/", s.handleListOAuthClients)
+				r.Get("/{id}", s.handleGetOAuthClient)
+				r.With(s.withCost(fixedCost(costList))).Get("/{id}/exchanges", s.handleListClientTokenExchanges)
+
+				r.Group(func(r chi.Router) {
+					r.Use(s.requirePermission("oauth_clients", "write"))
+					r.Post("/", s.handleCreateOAuthClient)
+					r.Put("/{id}", s.handleUpdateOAuthClient)
+					r.Post("/{id}/secret", s.handleRotateOAuthClientSecret)
+				})
+
+				r.Group(func(r chi.Router) {
+					r.Use(s.requirePermission("oauth_clients", "delete"))
+					r.Delete("/{id}", s.handleDeleteOAuthClient)
+				})
+
+				r.Group(func(r chi.Router) {
+					r.Use(s.requirePermission("roles", "assign"))
+					r.Post("/{id}/roles", s.handleAssignRoleToOAuthClient)
+					r.Delete("/{id}/roles/{roleId}", s.handleRemoveRoleFromOAuthClient)
+				})
+			})
+
+			if s.samlService != nil {
+				r.Route("/saml/providers", func(r chi.Router) {
+					r.Use(s.requirePermission("saml_providers", "read"))
+					r.Get("/", s.handleListSAMLProviders)
+					r.Get("/{id}", s.handleGetSAMLProvider)
+					r.Get("/{id}/group-roles", s.handleListSAMLGroupRoles)
+
+					r.Group(func(r chi.Router) {
+						r.Use(s.requirePermission("saml_providers", "write"))
+						r.Post("/", s.handleCreateSAMLProvider)
+						r.Put("/{id}", s.handleUpdateSAMLProvider)
+					})
+
+					r.Group(func(r chi.Router) {
+						r.Use(s.requirePermission("saml_providers", "delete"))
+						r.Delete("/{id}", s.handleDeleteSAMLProvider)
+					})
+
+					// Which roles the provider's groups grant, synced as users
+					// sign in
+					r.Group(func(r chi.Router) {
+						r.Use(s.requirePermission("roles", "assign"))
+						r.Post("/{id}/group-roles", s.handleCreateSAMLGroupRole)
+						r.Delete("/{id}/group-roles/{mappingId}", s.handleDeleteSAMLGroupRole)
+					})
+
+					// Which accounts the provider's subjects sign in to
+					r.Group(func(r chi.Router) {
+						r.Use(s.requirePermission("users", "admin"))
+						r.Get("/{id}/identities", s.handleListSAMLIdentities)
+						r.Post("/{id}/identities", s.handleLinkSAMLIdentity)
+						r.Delete("/{id}/identities/{identityId}", s.handleUnlinkSAMLIdentity)
+					})
+				})
+			}
+
+			r.Group(func(r chi.Router) {
+				r.Use(s.requirePermission("events", "read"))
+				r.With(s.withCost(fixedCost(costStream))).Get("/events/stream", s.handleEventStream)
+				r.Get("/events/publisher", s.handlePublisherStats)
+				r.Get("/events/schemas", s.handleEventSchemas)
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(s.requirePermission("users", "admin"))
+				r.Get("/auth/token-stats", s.handleTokenStats)
+				r.Get("/jobs", s.handleJobStats)
+				r.With(s.withCost(fixedCost(costStream))).Get("/activity/live", s.handleActivityLive)
+			})
+
+			r.Route("/organizations", func(r chi.Router) {
+				r.Use(s.requirePermission("organizations", "read"))
+				r.With(s.withCost(fixedCost(costList))).Get("/", s.handleListOrganizations)
+				r.Get("/{id}", s.handleGetOrganization)
+				r.With(s.withCost(fixedCost(costList))).Get("/{id}/members", s.handleListOrganizationMembers)
+
+				r.Group(func(r chi.Router) {
+					r.Use(s.requirePermission("organizations", "write"))
+					r.Post("/", s.handleCreateOrganization)
+					r.Put("/{id}", s.handleUpdateOrganization)
+					r.Post("/{id}/members", s.handleAddOrganizationMember)
+					r.With(s.withCost(fixedCost(costBulkMember))).Delete("/{id}/members/{userId}", s.handleRemoveOrganizationMember)
+				})
+
+				r.Group(func(r chi.Router) {
+					r.Use(s.requirePermission("organizations", "delete"))
+					r.Delete("/{id}", s.handleDeleteOrganization)
+				})
+			})
+
+			// Organization admins managing their own organization's members
+			r.Route("/org-admin/{orgId}", func(r chi.Router) {
+				r.With(s.requireOrganizationPermission("members", "read"), s.withCost(fixedCost(costList))).
+					Get("/members", s.handleOrgAdminListMembers)
+
+				r.Group(func(r chi.Router) {
+					r.Use(s.denyAPIKey, s.denyImpersonation)
+					r.With(s.requireOrganizationPermission("members", "invite")).Post("/invitations", s.handleOrgAdminInvite)
+					r.With(s.requireOrganizationPermission("members", "remove")).Delete("/members/{userId}", s.handleOrgAdminRemove)
+
+					r.Group(func(r chi.Router) {
+						r.Use(s.requireOrganizationPermission("members", "suspend"))
+						r.Post("/members/{userId}/suspend", s.handleOrgAdminSuspend)
+						r.Post("/members/{userId}/reactivate", s.handleOrgAdminReactivate)
+						r.Post("/members/{userId}/revoke-sessions", s.handleOrgAdminRevokeSessions)
+					})
+				})
+			})
+
+			r.Route("/groups", func(r chi.Router) {
+				r.Use(s.requirePermission("groups", "read"))
+				r.With(s.withCost(fixedCost(costList))).Get("/", s.handleListGroups)
+				r.Get("/{id}", s.handleGetGroup)
+				r.With(s.withCost(fixedCost(costList))).Get("/{id}/members", s.handleListGroupMembers)
+
+				r.Group(func(r chi.Router) {
+					r.Use(s.requirePermission("groups", "write"))
+					r.Post("/", s.handleCreateGroup)
+					r.Put("/{id}", s.handleUpdateGroup)
+					r.Post("/{id}/members", s.handleAddGroupMember)
+					r.Delete("/{id}/members/{userId}", s.handleRemoveGroupMember)
+				})
+
+				r.Group(func(r chi.Router) {
+					r.Use(s.requirePermission("roles", "assign"))
+					r.Post("/{id}/roles", s.handleAssignRoleToGroup)
+					r.Delete("/{id}/roles/{roleId}", s.handleRemoveRoleFromGroup)
+				})
+
+				r.Group(func(r chi.Router) {
+					r.Use(s.requirePermission("groups", "delete"))
+					r.Delete("/{id}", s.handleDeleteGroup)
+				})
+			})
+
+			r.Route("/invitations", func(r chi.Router) {
+				r.Use(s.requirePermission("invitations", "read"))
+				r.Get("/", s.handleListInvitations)
+				r.Get("/{id}", s.handleGetInvitation)
+
+				r.Group(func(r chi.Router) {
+					r.Use(s.requirePermission("invitations", "write"))
+					r.Post("/", s.handleCreateInvitation)
+					r.Post("/{id}/resend", s.handleResendInvitation)
+				})
+
+				r.Group(func(r chi.Router) {
+					r.Use(s.requirePermission("invitations", "delete"))
+					r.Post("/{id}/revoke", s.handleRevokeInvitation)
+				})
+			})
+
+			r.Route("/portal", func(r chi.Router) {
+				r.Use(s.denyAPIKey, s.denyImpersonation, s.requirePartner)
+				r.Use(s.requirePermission("portal", "read"))
+				r.Get("/api-keys", s.handleListAPIKeys)
+				r.Get("/api-keys/{id}/usage", s.handleGetAPIKeyUsage)
+				r.Get("/webhooks", s.handleListPortalWebhooks)
+				r.With(s.withCost(fixedCost(costList))).Get("/webhooks/{id}/deliveries", s.handleListPortalWebhookDeliveries)
+				r.Get("/webhooks/{id}/deliveries/{deliveryId}", s.handleGetPortalWebhookDelivery)
+				r.Get("/consents", s.handleListPartnerConsents)
+
+				r.Group(func(r chi.Router) {
+					r.Use(s.requirePermission("portal", "write"))
+					r.Post("/api-keys", s.handleCreateAPIKey)
+					r.Post("/api-keys/{id}/regenerate", s.handleRegenerateAPIKey)
+					r.Delete("/api-keys/{id}", s.handleRevokeAPIKey)
+					r.Post("/webhooks", s.handleCreatePortalWebhook)
+					r.Put("/webhooks/{id}", s.handleUpdatePortalWebhook)
+					r.Post("/webhooks/{id}/rotate-secret", s.handleRotatePortalWebhookSecret)
+					r.With(s.withCost(fixedCost(costOutbound))).Post("/webhooks/{id}/test", s.handleSendTestPortalWebhook)
+					r.Post("/webhooks/{id}/deliveries/{deliveryId}/redeliver", s.handleRedeliverPortalWebhookDelivery)
+					r.Delete("/webhooks/{id}", s.handleDeletePortalWebhook)
+				})
+			})
+
+			r.Route("/webhooks", func(r chi.Router) {
+				r.Use(s.requirePermission("webhooks", "read"))
+				r.Get("/", s.handleListWebhooks)
+				r.Get("/{id}", s.handleGetWebhook)
+				r.With(s.withCost(fixedCost(costList))).Get("/{id}/deliveries", s.handleListWebhookDeliveries)
+				r.Get("/{id}/deliveries/{deliveryId}", s.handleGetWebhookDelivery)
+
+				r.Group(func(r chi.Router) {
+					r.Use(s.requirePermission("webhooks", "write"))
+					r.Post("/", s.handleCreateWebhook)
+					r.Put("/{id}", s.handleUpdateWebhook)
+					r.Post("/{id}/rotate-secret", s.handleRotateWebhookSecret)
+					r.With(s.withCost(fixedCost(costOutbound))).Post("/{id}/test", s.handleSendTestWebhook)
+					r.Post("/{id}/deliveries/{deliveryId}/redeliver", s.handleRedeliverWebhookDelivery)
+				})
+
+				r.Group(func(r chi.Router) {
+					r.Use(s.requirePermission("webhooks", "delete"))
+					r.Delete("/{id}", s.handleDeleteWebhook)
+				})
+			})
+
+			r.Route("/attributes", func(r chi.Router) {
+				r.Use(s.requirePermission("attributes", "read"))
+				r.Get("/", s.handleListAttributeDefinitions)
+				r.Get("/{key}", s.handleGetAttributeDefinition)
+
+				r.Group(func(r chi.Router) {
+					r.Use(s.requirePermission("attributes", "write"))
+					r.Post("/", s.handleCreateAttributeDefinition)
+					r.Put("/{key}", s.handleUpdateAttributeDefinition)
+				})
+
+				r.Group(func(r chi.Router) {
+					r.Use(s.requirePermission("attributes", "delete"))
+					r.Delete("/{key}", s.handleDeleteAttributeDefinition)
+				})
+			})
+
 			r.Route("/permissions", func(r chi.Router) {
 				r.Use(s.requirePermission("permissions", "read"))
-				r.Get("/", s.handleListPermissions)
-				r.Get("/{id}", s.handleGetPermission)
+				// Cache hits spare the database, so they cost nothing
+				r.With(s.cached(cachePermissions), s.withCost(searchCost)).Get("/", s.handleListPermissions)
+				r.With(s.cached(cachePermissions)).Get("/{id}", s.handleGetPermission)
 
 				r.Group(func(r chi.Router) {
 					r.Use(s.requirePermission("permissions", "write"))
@@ -157,10 +739,70 @@ func (s *Server) setupRoutes() {
 					r.Delete("/{id}", s.handleDeletePermission)
 				})
 			})
+
+			r.Route("/resource-grants", func(r chi.Router) {
+				r.Use(s.requirePermission("permissions", "read"))
+				r.Get("/", s.handleListResourceGrants)
+				r.Get("/{id}", s.handleGetResourceGrant)
+
+				r.Group(func(r chi.Router) {
+					r.Use(s.requirePermission("permissions", "write"))
+					r.Post("/", s.handleGrantResource)
+					r.Delete("/{id}", s.handleRevokeResourceGrant)
+					r.Delete("/", s.handleRevokeResourceGrants)
+				})
+			})
+
+			r.Route("/authz/shadow", func(r chi.Router) {
+				r.With(s.requirePermission("permissions", "read")).Get("/", s.handleShadowReport)
+				r.With(s.requirePermission("permissions", "write")).Delete("/", s.handleResetShadow)
+			})
+
+			// Predefined lookups for support staff, each one audited
+			r.Route("/support/lookups", func(r chi.Router) {
+				r.With(s.requirePermission("support", "lookup"), s.denyAPIKey, s.denyImpersonation, s.supportLookupLimit).
+					Post("/", s.handleSupportLookup)
+				r.With(s.requirePermission("support", "audit")).Get("/", s.handleListSupportLookups)
+			})
+
+			// Roles and permissions as a policy source for OPA and Casbin.
+			// Users' roles are part of it, hence users:read.
+			r.Route("/policy", func(r chi.Router) {
+				r.Use(s.requirePermission("permissions", "read"))
+				r.Use(s.requirePermission("users", "read"))
+				r.Get("/opa/data", s.handleOPAData)
+				r.Get("/opa/bundle", s.handleOPABundle)
+				r.Get("/casbin", s.handleCasbinPolicy)
+				r.Get("/casbin/model", s.handleCasbinModel)
+			})
 		})
 	})
 }
 
+// Mount attaches an additional handler (e.g. the gRPC gateway) under pattern.
+// It must be called before the server starts serving.
+func (s *Server) Mount(pattern string, handler http.Handler) {
+	s.router.Mount(pattern, handler)
+}
+
+// MountGraphQL serves the GraphQL endpoint at /graphql to authenticated
+// callers, charged as a search since one query may list several pages.
+// The schema only reads, so api.read is scope enough even for a POST. It
+// must be called before the server starts serving.
+func (s *Server) MountGraphQL(handler http.Handler) {
+	readScope := s.requireScope(domain.ScopeAPIRead, domain.ScopeAPIWrite)
+	s.router.With(s.authMiddleware, readScope, s.withCost(fixedCost(costSearch))).Handle("/graphql", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims := getUserClaims(r.Context())
+		ctx := graphql.WithViewer(r.Context(), &graphql.Viewer{
+			UserID:      claims.UserID,
+			UserType:    claims.UserType,
+			Permissions: claims.Permissions,
+			Denied:      claims.Denied,
+		})
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	}))
+}
+
 // Handler returns the HTTP handler.
 func (s *Server) Handler() http.Handler {
 	return s.router
@@ -169,9 +811,10 @@ func (s *Server) Handler() http.Handler {
 // Response helpers
 
 type errorResponse struct {
-	Error   string            `json:"error"`
-	Code    string            `json:"code,omitempty"`
-	Details map[string]string `json:"details,omitempty"`
+	Error       string            `json:"error"`
+	Code        string            `json:"code,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+	Suggestions []string          `json:"suggestions,omitempty"`
 }
 
 func (s *Server) writeJSON(w http.ResponseWriter, status int, data any) {
@@ -185,8 +828,18 @@ func (s *Server) writeJSON(w http.ResponseWriter, status int, data any) {
 func (s *Server) writeError(w http.ResponseWriter, err error) {
 	var status int
 	var resp errorResponse
+	var usernameTaken domain.UsernameTakenError
 
 	switch {
+	case errors.As(err, &usernameTaken):
+		status = http.StatusConflict
+		resp = errorResponse{
+			Error:       "username already taken",
+			Code:        "USERNAME_TAKEN",
+			Details:     map[string]string{"username": "already taken"},
+			Suggestions: usernameTaken.Suggestions,
+		}
+
 	case errors.Is(err, domain.ErrNotFound):
 		status = http.StatusNotFound
 		resp = errorResponse{Error: "resource not found", Code: "NOT_FOUND"}
@@ -215,6 +868,21 @@ func (s *Server) writeError(w http.ResponseWriter, err error) {
 		status = http.StatusUnauthorized
 		resp = errorResponse{Error: "unauthorized", Code: "UNAUTHORIZED"}
 
+	case errors.Is(err, domain.ErrDeviceConfirmationRequired):
+		status = http.StatusUnauthorized
+		resp = errorResponse{
+			Error: "login from a new device; confirm it from the link sent by email, then sign in again",
+			Code:  "DEVICE_CONFIRMATION_REQUIRED",
+		}
+
+	case errors.Is(err, domain.ErrChallengeRequired):
+		status = http.StatusUnauthorized
+		resp = errorResponse{Error: "additional verification required", Code: "CHALLENGE_REQUIRED"}
+
+	case errors.Is(err, domain.ErrTooManySessions):
+		status = http.StatusForbidden
+		resp = errorResponse{Error: "too many active sessions; sign out of another device first", Code: "TOO_MANY_SESSIONS"}
+
 	case errors.Is(err, domain.ErrForbidden):
 		status = http.StatusForbidden
 		resp = errorResponse{Error: "forbidden", Code: "FORBIDDEN"}
@@ -233,14 +901,16 @@ func (s *Server) writeError(w http.ResponseWriter, err error) {
 		resp = errorResponse{Error: "internal server error", Code: "INTERNAL_ERROR"}
 	}
 
-	s.writeJSON(w, status, resp)
+	s.sendError(w, status, resp, err)
 }
 
+// readJSON decodes the request body into v and checks it against its
+// validate tags, failing with every field at fault.
 func (s *Server) readJSON(r *http.Request, v any) error {
 	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
 		return domain.ValidationError{Field: "body", Message: "invalid JSON"}
 	}
-	return nil
+	return validate.Struct(v)
 }
 
 func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
@@ -272,6 +942,12 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer
+// (for flushing and deadlines).
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // Context helpers
 
 type contextKey string
@@ -0,0 +1,598 @@
+// Package scim serves SCIM 2.0 (RFC 7643, RFC 7644) provisioning endpoints,
+// so identity providers such as Okta and Azure AD can create, update and
+// deprovision users and groups. Only what those providers use is
+// implemented: equality filters on the attributes they match by, PATCH,
+// and no bulk operations, sorting or ETags.
+package scim
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+)
+
+// maxBodySize caps request bodies; a group replacing its members is the
+// largest.
+const maxBodySize = 1 << 20
+
+// maxCount is the most resources a page holds.
+const maxCount = 100
+
+const contentType = "application/scim+json"
+
+type handler struct {
+	scim    *service.SCIMService
+	token   func() string
+	baseURL string
+	logger  *slog.Logger
+}
+
+// NewHandler returns the SCIM endpoints, to be mounted at /scim/v2.
+// Requests must carry token() as a bearer token; while it's empty every
+// request is refused. baseURL is the public URL of the mount point,
+// which resource locations are built on.
+func NewHandler(scim *service.SCIMService, token func() string, baseURL string, logger *slog.Logger) http.Handler {
+	h := &handler{
+		scim:    scim,
+		token:   token,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		logger:  logger,
+	}
+
+	r := chi.NewRouter()
+	r.Use(h.authenticate)
+
+	r.Get("/ServiceProviderConfig", h.serviceProviderConfig)
+	r.Get("/ResourceTypes", h.resourceTypes)
+
+	r.Route("/Users", func(r chi.Router) {
+		r.Get("/", h.listUsers)
+		r.Post("/", h.createUser)
+		r.Get("/{id}", h.getUser)
+		r.Put("/{id}", h.replaceUser)
+		r.Patch("/{id}", h.patchUser)
+		r.Delete("/{id}", h.deleteUser)
+	})
+
+	r.Route("/Groups", func(r chi.Router) {
+		r.Get("/", h.listGroups)
+		r.Post("/", h.createGroup)
+		r.Get("/{id}", h.getGroup)
+		r.Put("/{id}", h.replaceGroup)
+		r.Patch("/{id}", h.patchGroup)
+		r.Delete("/{id}", h.deleteGroup)
+	})
+
+	return r
+}
+
+// authenticate compares the bearer token in constant time.
+func (h *handler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := h.token()
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="scim"`)
+			h.writeError(w, http.StatusUnauthorized, "", "missing or invalid bearer token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *handler) serviceProviderConfig(w http.ResponseWriter, r *http.Request) {
+	supported := func(ok bool) map[string]bool { return map[string]bool{"supported": ok} }
+	h.writeJSON(w, http.StatusOK, map[string]any{
+		"schemas":        []string{schemaServiceProviderConfig},
+		"patch":          supported(true),
+		"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]any{"supported": true, "maxResults": maxCount},
+		"changePassword": supported(true),
+		"sort":           supported(false),
+		"etag":           supported(false),
+		"authenticationSchemes": []map[string]any{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer token",
+			"description": "The SCIM_TOKEN secret, sent as a bearer token",
+			"primary":     true,
+		}},
+	})
+}
+
+func (h *handler) resourceTypes(w http.ResponseWriter, r *http.Request) {
+	types := []map[string]any{
+		{
+			"schemas":  []string{schemaResourceType},
+			"id":       "User",
+			"name":     "User",
+			"endpoint": "/Users",
+			"schema":   schemaUser,
+		},
+		{
+			"schemas":  []string{schemaResourceType},
+			"id":       "Group",
+			"name":     "Group",
+			"endpoint": "/Groups",
+			"schema":   schemaGroup,
+		},
+	}
+	h.writeJSON(w, http.StatusOK, listResponse(types, len(types), 1))
+}
+
+func (h *handler) listUsers(w http.ResponseWriter, r *http.Request) {
+	offset, count, err := page(r)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalidValue", err.Error())
+		return
+	}
+
+	filter := service.SCIMUserFilter{Offset: offset, Limit: count}
+	if raw := r.URL.Query().Get("filter"); raw != "" {
+		attr, value, err := parseFilter(raw)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, "invalidFilter", err.Error())
+			return
+		}
+		switch attr {
+		case "username", "emails", "emails.value":
+			filter.Email = value
+		case "externalid":
+			filter.ExternalID = value
+		case "id":
+			h.listUser(w, r, value, offset, count)
+			return
+		default:
+			h.writeError(w, http.StatusBadRequest, "invalidFilter", "users can only be filtered by userName, emails, externalId or id")
+			return
+		}
+	}
+
+	users, total, err := h.scim.ListUsers(r.Context(), filter)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	resources := make([]userResource, len(users))
+	for i := range users {
+		resources[i] = h.userResource(&users[i])
+	}
+	h.writeJSON(w, http.StatusOK, listResponse(resources, int(total), offset+1))
+}
+
+// listUser answers a filter on id with a page of the user, or none.
+func (h *handler) listUser(w http.ResponseWriter, r *http.Request, value string, offset, count int) {
+	resources := []userResource{}
+	total := 0
+
+	if id, err := uuid.Parse(value); err == nil {
+		user, err := h.scim.GetUser(r.Context(), id)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			h.writeServiceError(w, err)
+			return
+		}
+		if err == nil {
+			total = 1
+			if offset == 0 && count > 0 {
+				resources = append(resources, h.userResource(user))
+			}
+		}
+	}
+
+	h.writeJSON(w, http.StatusOK, listResponse(resources, total, offset+1))
+}
+
+func (h *handler) createUser(w http.ResponseWriter, r *http.Request) {
+	var req userRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	input, err := req.input()
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	if input.Active == nil {
+		active := true
+		input.Active = &active
+	}
+
+	user, err := h.scim.CreateUser(r.Context(), input)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	resource := h.userResource(user)
+	w.Header().Set("Location", resource.Meta.Location)
+	h.writeJSON(w, http.StatusCreated, resource)
+}
+
+func (h *handler) getUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.id(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.scim.GetUser(r.Context(), id)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, h.userResource(user))
+}
+
+// replaceUser applies a full representation of the user. Attributes it
+// leaves out are cleared where aegis allows it: the phone number is
+// removed, but the name and active state are kept.
+func (h *handler) replaceUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.id(w, r)
+	if !ok {
+		return
+	}
+
+	var req userRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	input, err := req.input()
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	if input.Phone == nil {
+		none := ""
+		input.Phone = &none
+	}
+
+	user, err := h.scim.UpdateUser(r.Context(), id, input)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, h.userResource(user))
+}
+
+func (h *handler) patchUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.id(w, r)
+	if !ok {
+		return
+	}
+
+	var req patchRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	current, err := h.scim.GetUser(r.Context(), id)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	input, err := req.userInput(current.FullName)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, scimType(err), err.Error())
+		return
+	}
+
+	user, err := h.scim.UpdateUser(r.Context(), id, input)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, h.userResource(user))
+}
+
+func (h *handler) deleteUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.id(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.scim.DeleteUser(r.Context(), id); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) listGroups(w http.ResponseWriter, r *http.Request) {
+	offset, count, err := page(r)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalidValue", err.Error())
+		return
+	}
+
+	filter := service.SCIMGroupFilter{Offset: offset, Limit: count}
+	if raw := r.URL.Query().Get("filter"); raw != "" {
+		attr, value, err := parseFilter(raw)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, "invalidFilter", err.Error())
+			return
+		}
+		switch attr {
+		case "displayname":
+			filter.Name = value
+		case "externalid":
+			filter.ExternalID = value
+		case "id":
+			h.listGroup(w, r, value, offset, count)
+			return
+		default:
+			h.writeError(w, http.StatusBadRequest, "invalidFilter", "groups can only be filtered by displayName, externalId or id")
+			return
+		}
+	}
+
+	groups, total, err := h.scim.ListGroups(r.Context(), filter, wantsMembers(r))
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	resources := make([]groupResource, len(groups))
+	for i := range groups {
+		resources[i] = h.groupResource(&groups[i])
+	}
+	h.writeJSON(w, http.StatusOK, listResponse(resources, int(total), offset+1))
+}
+
+// listGroup answers a filter on id with a page of the group, or none.
+func (h *handler) listGroup(w http.ResponseWriter, r *http.Request, value string, offset, count int) {
+	resources := []groupResource{}
+	total := 0
+
+	if id, err := uuid.Parse(value); err == nil {
+		group, err := h.scim.GetGroup(r.Context(), id, wantsMembers(r))
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			h.writeServiceError(w, err)
+			return
+		}
+		if err == nil {
+			total = 1
+			if offset == 0 && count > 0 {
+				resources = append(resources, h.groupResource(group))
+			}
+		}
+	}
+
+	h.writeJSON(w, http.StatusOK, listResponse(resources, total, offset+1))
+}
+
+func (h *handler) createGroup(w http.ResponseWriter, r *http.Request) {
+	var req groupRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	input, err := req.input()
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	group, err := h.scim.CreateGroup(r.Context(), input)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	resource := h.groupResource(group)
+	w.Header().Set("Location", resource.Meta.Location)
+	h.writeJSON(w, http.StatusCreated, resource)
+}
+
+func (h *handler) getGroup(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.id(w, r)
+	if !ok {
+		return
+	}
+
+	group, err := h.scim.GetGroup(r.Context(), id, wantsMembers(r))
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, h.groupResource(group))
+}
+
+// replaceGroup applies a full representation of the group; leaving out
+// members removes them all.
+func (h *handler) replaceGroup(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.id(w, r)
+	if !ok {
+		return
+	}
+
+	var req groupRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	input, err := req.input()
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	if input.Members == nil {
+		input.Members = &[]uuid.UUID{}
+	}
+
+	group, err := h.scim.UpdateGroup(r.Context(), id, input)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, h.groupResource(group))
+}
+
+func (h *handler) patchGroup(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.id(w, r)
+	if !ok {
+		return
+	}
+
+	var req patchRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	input, err := req.groupInput()
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, scimType(err), err.Error())
+		return
+	}
+
+	group, err := h.scim.UpdateGroup(r.Context(), id, input)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	// Providers send many small patches; answer them without listing
+	// the members back unless asked to
+	if !r.URL.Query().Has("attributes") {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, h.groupResource(group))
+}
+
+func (h *handler) deleteGroup(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.id(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.scim.DeleteGroup(r.Context(), id); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// page reads startIndex, which counts from 1, and count as an offset and
+// a limit of at most maxCount.
+func page(r *http.Request) (offset, count int, err error) {
+	q := r.URL.Query()
+
+	start := 1
+	if v := q.Get("startIndex"); v != "" {
+		if start, err = strconv.Atoi(v); err != nil {
+			return 0, 0, errors.New("startIndex must be an integer")
+		}
+		start = max(start, 1)
+	}
+
+	count = maxCount
+	if v := q.Get("count"); v != "" {
+		if count, err = strconv.Atoi(v); err != nil {
+			return 0, 0, errors.New("count must be an integer")
+		}
+		count = min(max(count, 0), maxCount)
+	}
+
+	return start - 1, count, nil
+}
+
+// wantsMembers reports whether the attributes and excludedAttributes
+// parameters leave group members in.
+func wantsMembers(r *http.Request) bool {
+	q := r.URL.Query()
+	if attrs := q.Get("attributes"); attrs != "" {
+		return listsAttribute(attrs, "members")
+	}
+	return !listsAttribute(q.Get("excludedAttributes"), "members")
+}
+
+func listsAttribute(list, name string) bool {
+	for attr := range strings.SplitSeq(list, ",") {
+		if strings.EqualFold(strings.TrimSpace(attr), name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *handler) id(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeError(w, http.StatusNotFound, "", "resource not found")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *handler) decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(v); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalidSyntax", "invalid JSON body")
+		return false
+	}
+	return true
+}
+
+func (h *handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError sends a SCIM error; scimType may be empty.
+func (h *handler) writeError(w http.ResponseWriter, status int, scimType, detail string) {
+	h.writeJSON(w, status, errorResource{
+		Schemas:  []string{schemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+// writeServiceError maps domain errors to SCIM errors, hiding the details
+// of unexpected ones.
+func (h *handler) writeServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		h.writeError(w, http.StatusNotFound, "", "resource not found")
+	case errors.Is(err, domain.ErrAlreadyExists):
+		h.writeError(w, http.StatusConflict, "uniqueness", err.Error())
+	case errors.Is(err, domain.ErrInvalidInput):
+		h.writeError(w, http.StatusBadRequest, scimType(err), err.Error())
+	case errors.Is(err, domain.ErrVersionMismatch):
+		h.writeError(w, http.StatusConflict, "", "resource was modified by another request")
+	default:
+		h.logger.Error("unhandled SCIM error", slog.String("error", err.Error()))
+		h.writeError(w, http.StatusInternalServerError, "", "internal server error")
+	}
+}
+
+// scimType classifies an invalid request.
+func scimType(err error) string {
+	var ve domain.ValidationError
+	switch {
+	case errors.As(err, &ve) && ve.Message == errImmutable:
+		return "mutability"
+	case errors.Is(err, errInvalidPath):
+		return "invalidPath"
+	}
+	return "invalidValue"
+}
@@ -0,0 +1,383 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+)
+
+var errInvalidPath = errors.New("invalid path")
+
+// filterRegex matches the one kind of filter supported: an attribute
+// equal to a string, such as userName eq "ana@example.com".
+var filterRegex = regexp.MustCompile(`(?i)^\s*([a-z][\w:.]*)\s+eq\s+("(?:[^"\\]|\\.)*")\s*$`)
+
+// parseFilter returns the attribute, as attribute normalizes it, and the
+// value of an equality filter.
+func parseFilter(filter string) (attr, value string, err error) {
+	m := filterRegex.FindStringSubmatch(filter)
+	if m == nil {
+		return "", "", errors.New(`only filters of the form attribute eq "value" are supported`)
+	}
+	if err := json.Unmarshal([]byte(m[2]), &value); err != nil {
+		return "", "", fmt.Errorf("invalid string %s", m[2])
+	}
+	return attribute(m[1]), value, nil
+}
+
+// attribute lowercases an attribute name and strips the core schema URN
+// from it; extension attributes keep theirs.
+func attribute(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, schema := range []string{schemaUser, schemaGroup} {
+		if rest, ok := strings.CutPrefix(name, strings.ToLower(schema)+":"); ok {
+			return rest
+		}
+	}
+	return name
+}
+
+// parsePath splits a PATCH path such as emails[type eq "work"].value into
+// its attribute and sub-attribute, joined by a dot, and the filter on
+// values, if any.
+func parsePath(path string) (attr, filter string, err error) {
+	i := strings.IndexByte(path, '[')
+	if i < 0 {
+		return attribute(path), "", nil
+	}
+
+	j := strings.LastIndexByte(path, ']')
+	if j < i {
+		return "", "", fmt.Errorf("%w %q", errInvalidPath, path)
+	}
+	attr, filter = attribute(path[:i]), path[i+1:j]
+
+	if rest := path[j+1:]; rest != "" {
+		sub, ok := strings.CutPrefix(rest, ".")
+		if !ok {
+			return "", "", fmt.Errorf("%w %q", errInvalidPath, path)
+		}
+		attr += "." + strings.ToLower(sub)
+	}
+	return attr, filter, nil
+}
+
+type patchRequest struct {
+	Operations []patchOperation `json:"Operations"`
+}
+
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// each calls fn with the kind of each operation (add, replace or remove)
+// and the attribute, value filter and value it changes. An operation
+// without a path changes each attribute of its value.
+func (req *patchRequest) each(fn func(attr, filter, op string, value json.RawMessage) error) error {
+	for _, op := range req.Operations {
+		kind := strings.ToLower(op.Op)
+		switch kind {
+		case "add", "replace", "remove":
+		default:
+			return domain.ValidationError{Field: "op", Message: "must be add, replace or remove"}
+		}
+
+		if op.Path != "" {
+			attr, filter, err := parsePath(op.Path)
+			if err != nil {
+				return err
+			}
+			if err := fn(attr, filter, kind, op.Value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if kind == "remove" {
+			return fmt.Errorf("%w: remove needs a path", errInvalidPath)
+		}
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &values); err != nil {
+			return domain.ValidationError{Field: "value", Message: "must be an object when there's no path"}
+		}
+		for name, value := range values {
+			if err := fn(attribute(name), "", kind, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// userInput collects what the operations change on a user whose full name
+// is fullName. Attributes aegis doesn't keep are ignored, so providers
+// mapping extra ones don't fail.
+func (req *patchRequest) userInput(fullName string) (service.SCIMUserInput, error) {
+	var (
+		input         service.SCIMUserInput
+		given, family *string
+	)
+
+	err := req.each(func(attr, _, op string, value json.RawMessage) error {
+		remove := op == "remove"
+		switch attr {
+		case "active":
+			if remove {
+				return nil
+			}
+			active, err := boolValue(attr, value)
+			if err != nil {
+				return err
+			}
+			input.Active = &active
+
+		case "username", "emails", "emails.value":
+			if remove {
+				return nil
+			}
+			email, err := multiValue(attr, value)
+			if err != nil {
+				return err
+			}
+			input.Email = &email
+
+		case "displayname", "name.formatted":
+			if remove {
+				return nil
+			}
+			s, err := stringValue(attr, value)
+			if err != nil {
+				return err
+			}
+			input.FullName = &s
+
+		case "name":
+			if remove {
+				return nil
+			}
+			var n name
+			if err := json.Unmarshal(value, &n); err != nil {
+				return domain.ValidationError{Field: attr, Message: "must be an object"}
+			}
+			if n.Formatted != "" {
+				input.FullName = &n.Formatted
+			}
+			if n.GivenName != "" {
+				given = &n.GivenName
+			}
+			if n.FamilyName != "" {
+				family = &n.FamilyName
+			}
+
+		case "name.givenname", "name.familyname":
+			s := ""
+			if !remove {
+				var err error
+				if s, err = stringValue(attr, value); err != nil {
+					return err
+				}
+			}
+			if attr == "name.givenname" {
+				given = &s
+			} else {
+				family = &s
+			}
+
+		case "phonenumbers", "phonenumbers.value":
+			phone := ""
+			if !remove {
+				var err error
+				if phone, err = multiValue(attr, value); err != nil {
+					return err
+				}
+			}
+			input.Phone = &phone
+
+		case "externalid":
+			id := ""
+			if !remove {
+				var err error
+				if id, err = stringValue(attr, value); err != nil {
+					return err
+				}
+			}
+			input.ExternalID = &id
+
+		case "usertype":
+			if remove {
+				return nil
+			}
+			s, err := stringValue(attr, value)
+			if err != nil {
+				return err
+			}
+			t := domain.UserType(strings.ToLower(s))
+			if !t.Valid() {
+				return domain.ValidationError{Field: "userType", Message: "must be admin, customer or partner"}
+			}
+			input.Type = &t
+
+		case "password":
+			if remove {
+				return nil
+			}
+			s, err := stringValue(attr, value)
+			if err != nil {
+				return err
+			}
+			input.Password = &s
+		}
+		return nil
+	})
+	if err != nil {
+		return input, err
+	}
+
+	// Given and family names replace the halves of the full name, split
+	// at its first space, unless a full name was set too
+	if input.FullName == nil && (given != nil || family != nil) {
+		first, last, _ := strings.Cut(fullName, " ")
+		if given != nil {
+			first = *given
+		}
+		if family != nil {
+			last = *family
+		}
+		full := strings.TrimSpace(first + " " + last)
+		input.FullName = &full
+	}
+
+	return input, nil
+}
+
+// groupInput collects what the operations change on a group. Removing
+// members without a filter or value removes them all.
+func (req *patchRequest) groupInput() (service.SCIMGroupInput, error) {
+	var input service.SCIMGroupInput
+
+	err := req.each(func(attr, filter, op string, value json.RawMessage) error {
+		switch attr {
+		case "displayname":
+			if op == "remove" {
+				return domain.ValidationError{Field: "displayName", Message: "required"}
+			}
+			s, err := stringValue(attr, value)
+			if err != nil {
+				return err
+			}
+			input.Name = &s
+
+		case "externalid":
+			id := ""
+			if op != "remove" {
+				var err error
+				if id, err = stringValue(attr, value); err != nil {
+					return err
+				}
+			}
+			input.ExternalID = &id
+
+		case "members":
+			if filter != "" {
+				if op != "remove" {
+					return fmt.Errorf("%w: members can only be filtered when removed", errInvalidPath)
+				}
+				attr, v, err := parseFilter(filter)
+				if err != nil || attr != "value" {
+					return fmt.Errorf("%w: members can only be filtered by value", errInvalidPath)
+				}
+				id, err := uuid.Parse(v)
+				if err != nil {
+					return domain.ValidationError{Field: "members", Message: "invalid user ID " + v}
+				}
+				input.RemoveMembers = append(input.RemoveMembers, id)
+				return nil
+			}
+
+			if op == "remove" && len(value) == 0 {
+				input.Members = &[]uuid.UUID{}
+				return nil
+			}
+
+			ids, err := memberValues(value)
+			if err != nil {
+				return err
+			}
+			switch op {
+			case "add":
+				input.AddMembers = append(input.AddMembers, ids...)
+			case "remove":
+				input.RemoveMembers = append(input.RemoveMembers, ids...)
+			case "replace":
+				input.Members = &ids
+				input.AddMembers, input.RemoveMembers = nil, nil
+			}
+		}
+		return nil
+	})
+
+	return input, err
+}
+
+// memberValues parses a members value: a list of members or one member.
+func memberValues(value json.RawMessage) ([]uuid.UUID, error) {
+	var members []member
+	if err := json.Unmarshal(value, &members); err != nil {
+		var m member
+		if err := json.Unmarshal(value, &m); err != nil {
+			return nil, domain.ValidationError{Field: "members", Message: "must be a list of members"}
+		}
+		members = []member{m}
+	}
+	return memberIDs(members)
+}
+
+func stringValue(attr string, value json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return "", domain.ValidationError{Field: attr, Message: "must be a string"}
+	}
+	return s, nil
+}
+
+// boolValue accepts a boolean or, as Azure AD sends them, a string such as
+// "False".
+func boolValue(attr string, value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		switch strings.ToLower(s) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return false, domain.ValidationError{Field: attr, Message: "must be a boolean"}
+}
+
+// multiValue returns the primary value of an emails or phoneNumbers
+// value, which may be a list, one entry or the value itself.
+func multiValue(attr string, value json.RawMessage) (string, error) {
+	var values []multiValued
+	if err := json.Unmarshal(value, &values); err == nil {
+		return primaryValue(values), nil
+	}
+	var v multiValued
+	if err := json.Unmarshal(value, &v); err == nil {
+		return v.Value, nil
+	}
+	return stringValue(attr, value)
+}
@@ -0,0 +1,269 @@
+package scim
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/service"
+)
+
+const (
+	schemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	schemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	schemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	schemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	schemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	schemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// errImmutable is the message the service rejects changes to attributes
+// that can't change with.
+const errImmutable = "cannot be changed"
+
+type meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location"`
+	Version      string    `json:"version,omitempty"`
+}
+
+type name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// multiValued is an entry of emails or phoneNumbers.
+type multiValued struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type userResource struct {
+	Schemas      []string      `json:"schemas"`
+	ID           uuid.UUID     `json:"id"`
+	ExternalID   string        `json:"externalId,omitempty"`
+	UserName     string        `json:"userName"`
+	Name         name          `json:"name"`
+	DisplayName  string        `json:"displayName,omitempty"`
+	UserType     string        `json:"userType"`
+	Active       bool          `json:"active"`
+	Emails       []multiValued `json:"emails"`
+	PhoneNumbers []multiValued `json:"phoneNumbers,omitempty"`
+	Meta         meta          `json:"meta"`
+}
+
+// userResource presents a user with their email as userName. The full
+// name is split at its first space into given and family names.
+func (h *handler) userResource(u *service.SCIMUser) userResource {
+	given, family, _ := strings.Cut(u.FullName, " ")
+
+	resource := userResource{
+		Schemas:     []string{schemaUser},
+		ID:          u.ID,
+		ExternalID:  u.ExternalID,
+		UserName:    u.Email,
+		Name:        name{Formatted: u.FullName, GivenName: given, FamilyName: family},
+		DisplayName: u.FullName,
+		UserType:    string(u.Type),
+		Active:      u.Status == domain.UserStatusActive,
+		Emails:      []multiValued{{Value: u.Email, Type: "work", Primary: true}},
+		Meta: meta{
+			ResourceType: "User",
+			Created:      u.CreatedAt,
+			LastModified: u.UpdatedAt,
+			Location:     h.baseURL + "/Users/" + u.ID.String(),
+			Version:      `W/"` + strconv.Itoa(u.Version) + `"`,
+		},
+	}
+	if u.Phone != nil {
+		resource.PhoneNumbers = []multiValued{{Value: *u.Phone, Type: "work", Primary: true}}
+	}
+	return resource
+}
+
+// member is an entry of a group's members.
+type member struct {
+	Value   string `json:"value"`
+	Ref     string `json:"$ref,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+type groupResource struct {
+	Schemas     []string  `json:"schemas"`
+	ID          uuid.UUID `json:"id"`
+	ExternalID  string    `json:"externalId,omitempty"`
+	DisplayName string    `json:"displayName"`
+	Members     []member  `json:"members,omitempty"`
+	Meta        meta      `json:"meta"`
+}
+
+func (h *handler) groupResource(g *service.SCIMGroup) groupResource {
+	resource := groupResource{
+		Schemas:     []string{schemaGroup},
+		ID:          g.ID,
+		ExternalID:  g.ExternalID,
+		DisplayName: g.Name,
+		Meta: meta{
+			ResourceType: "Group",
+			Created:      g.CreatedAt,
+			LastModified: g.UpdatedAt,
+			Location:     h.baseURL + "/Groups/" + g.ID.String(),
+		},
+	}
+	for _, id := range g.Members {
+		resource.Members = append(resource.Members, member{
+			Value: id.String(),
+			Ref:   h.baseURL + "/Users/" + id.String(),
+		})
+	}
+	return resource
+}
+
+type listResource[T any] struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []T      `json:"Resources"`
+}
+
+func listResponse[T any](resources []T, total, startIndex int) listResource[T] {
+	if resources == nil {
+		resources = []T{}
+	}
+	return listResource[T]{
+		Schemas:      []string{schemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
+
+type errorResource struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
+
+// userRequest is a user as POST and PUT send it. Attribute names match
+// case-insensitively, as SCIM requires.
+type userRequest struct {
+	ExternalID   *string       `json:"externalId"`
+	UserName     string        `json:"userName"`
+	Name         *name         `json:"name"`
+	DisplayName  string        `json:"displayName"`
+	UserType     string        `json:"userType"`
+	Active       *bool         `json:"active"`
+	Password     *string       `json:"password"`
+	Emails       []multiValued `json:"emails"`
+	PhoneNumbers []multiValued `json:"phoneNumbers"`
+}
+
+// input maps the user onto the service's attributes. The userName is the
+// email, falling back to the primary email when it isn't one.
+func (req *userRequest) input() (service.SCIMUserInput, error) {
+	input := service.SCIMUserInput{
+		ExternalID: req.ExternalID,
+		Active:     req.Active,
+		Password:   req.Password,
+	}
+
+	email := req.UserName
+	if !strings.Contains(email, "@") {
+		if primary := primaryValue(req.Emails); primary != "" {
+			email = primary
+		}
+	}
+	if email != "" {
+		input.Email = &email
+	}
+
+	if fullName := req.fullName(); fullName != "" {
+		input.FullName = &fullName
+	}
+
+	if phone := primaryValue(req.PhoneNumbers); phone != "" {
+		input.Phone = &phone
+	}
+
+	if req.UserType != "" {
+		t := domain.UserType(strings.ToLower(req.UserType))
+		if !t.Valid() {
+			return input, domain.ValidationError{Field: "userType", Message: "must be admin, customer or partner"}
+		}
+		input.Type = &t
+	}
+
+	return input, nil
+}
+
+// fullName prefers the formatted name, then the given and family names,
+// then the display name.
+func (req *userRequest) fullName() string {
+	if req.Name != nil {
+		if f := strings.TrimSpace(req.Name.Formatted); f != "" {
+			return f
+		}
+		if f := strings.TrimSpace(req.Name.GivenName + " " + req.Name.FamilyName); f != "" {
+			return f
+		}
+	}
+	return strings.TrimSpace(req.DisplayName)
+}
+
+// primaryValue returns the value of the primary entry, or of the first
+// one if none is primary.
+func primaryValue(values []multiValued) string {
+	for _, v := range values {
+		if v.Primary {
+			return v.Value
+		}
+	}
+	if len(values) > 0 {
+		return values[0].Value
+	}
+	return ""
+}
+
+type groupRequest struct {
+	ExternalID  *string  `json:"externalId"`
+	DisplayName string   `json:"displayName"`
+	Members     []member `json:"members"`
+}
+
+func (req *groupRequest) input() (service.SCIMGroupInput, error) {
+	input := service.SCIMGroupInput{ExternalID: req.ExternalID}
+	if req.DisplayName != "" {
+		input.Name = &req.DisplayName
+	}
+	if req.Members != nil {
+		members, err := memberIDs(req.Members)
+		if err != nil {
+			return input, err
+		}
+		input.Members = &members
+	}
+	return input, nil
+}
+
+// memberIDs parses the user IDs of group members.
+func memberIDs(members []member) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(members))
+	for _, m := range members {
+		id, err := uuid.Parse(m.Value)
+		if err != nil {
+			return nil, domain.ValidationError{Field: "members", Message: "invalid user ID " + strconv.Quote(m.Value)}
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
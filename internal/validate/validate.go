@@ -0,0 +1,249 @@
+// Package validate checks requests against rules declared in struct tags,
+// before they reach the services:
+//
+//	type createGroupRequest struct {
+//		Name        string `json:"name" validate:"required,max=100"`
+//		Description string `json:"description" validate:"max=500"`
+//	}
+//
+// Rules are comma-separated and checked in order, stopping at a field's
+// first failure; every field failing comes back in one
+// domain.ValidationErrors, named as in JSON. Rules but required pass zero
+// values, so optional fields need no marker. The rules are:
+//
+//	required    not zero, nil or, for strings, blank
+//	email       an email address
+//	min=N       at least N characters, items, or for integers N
+//	max=N       at most N characters, items, or for integers N
+//	oneof=A B   one of the space-separated values
+//	uuid        a UUID
+//	url         an absolute http(s) URL
+//	locale      a BCP 47 language tag
+//	timezone    an IANA time zone
+//
+// The services validate again whatever they're handed; these rules are for
+// failing fast, with all of a request's errors at once.
+package validate
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// Optional is implemented by types holding a value only some of the time,
+// such as service.Field. They're checked as the value they hold, and as
+// absent when they hold none.
+type Optional interface {
+	OptionalValue() (any, bool)
+}
+
+// Struct checks the fields of v, a struct or a pointer to one, against their
+// validate tags, including those of nested structs. It returns
+// domain.ValidationErrors, or nil if every field is valid or v isn't a
+// struct.
+func Struct(v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs domain.ValidationErrors
+	checkStruct(rv, "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Value checks a single value against rules, for requests whose rules
+// aren't in tags. A nil value is absent.
+func Value(field string, value any, rules string) *domain.ValidationError {
+	if value == nil {
+		return check(field, reflect.Value{}, rules)
+	}
+	return check(field, reflect.ValueOf(value), rules)
+}
+
+func checkStruct(rv reflect.Value, prefix string, errs *domain.ValidationErrors) {
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name := fieldName(sf)
+		if name == "" {
+			continue
+		}
+		name = prefix + name
+
+		if rules, ok := sf.Tag.Lookup("validate"); ok {
+			if err := check(name, rv.Field(i), rules); err != nil {
+				*errs = append(*errs, *err)
+				continue
+			}
+		}
+
+		// Nested structs are checked whether or not they're tagged
+		fv := indirect(rv.Field(i))
+		if fv.IsValid() && fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeFor[time.Time]() {
+			checkStruct(fv, name+".", errs)
+		}
+	}
+}
+
+// fieldName is the JSON name of a field, or "" for fields JSON skips.
+func fieldName(sf reflect.StructField) string {
+	tag := sf.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name
+	}
+	return sf.Name
+}
+
+// indirect follows pointers and interfaces down to the value, and Optionals
+// to the value they hold. It returns the zero Value for nil and for
+// Optionals holding none.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() {
+		if v.CanInterface() {
+			if opt, ok := v.Interface().(Optional); ok {
+				value, ok := opt.OptionalValue()
+				if !ok {
+					return reflect.Value{}
+				}
+				v = reflect.ValueOf(value)
+				continue
+			}
+		}
+		switch v.Kind() {
+		case reflect.Pointer, reflect.Interface:
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+		default:
+			return v
+		}
+	}
+	return v
+}
+
+func check(field string, v reflect.Value, rules string) *domain.ValidationError {
+	v = indirect(v)
+	zero := !v.IsValid() || v.IsZero() ||
+		(v.Kind() == reflect.String && strings.TrimSpace(v.String()) == "") ||
+		((v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0)
+
+	for rule := range strings.SplitSeq(rules, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		if name == "required" {
+			if zero {
+				return &domain.ValidationError{Field: field, Message: "required"}
+			}
+			continue
+		}
+		if zero {
+			continue
+		}
+		if msg := apply(name, arg, v); msg != "" {
+			return &domain.ValidationError{Field: field, Message: msg}
+		}
+	}
+	return nil
+}
+
+// apply checks v against a rule, returning what's wrong with it or "".
+func apply(rule, arg string, v reflect.Value) string {
+	switch rule {
+	case "email":
+		if err := domain.ValidateEmail(v.String()); err != nil {
+			return err.Message
+		}
+
+	case "min", "max":
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			panic(fmt.Sprintf("validate: %s=%s: %v", rule, arg, err))
+		}
+		size, unit := measure(v)
+		if rule == "min" && size < int64(n) {
+			return strings.TrimSpace(fmt.Sprintf("must be at least %d %s", n, unit))
+		}
+		if rule == "max" && size > int64(n) {
+			return strings.TrimSpace(fmt.Sprintf("must be at most %d %s", n, unit))
+		}
+
+	case "oneof":
+		options := strings.Fields(arg)
+		for _, option := range options {
+			if fmt.Sprint(v.Interface()) == option {
+				return ""
+			}
+		}
+		if len(options) == 1 {
+			return "must be " + options[0]
+		}
+		return "must be " + strings.Join(options[:len(options)-1], ", ") + " or " + options[len(options)-1]
+
+	case "uuid":
+		if _, err := uuid.Parse(v.String()); err != nil {
+			return "invalid UUID"
+		}
+
+	case "url":
+		u, err := url.Parse(v.String())
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "must be an absolute http(s) URL"
+		}
+
+	case "locale":
+		if err := domain.ValidateLocale(v.String()); err != nil {
+			return err.Message
+		}
+
+	case "timezone":
+		if err := domain.ValidateTimezone(v.String()); err != nil {
+			return err.Message
+		}
+
+	default:
+		panic("validate: unknown rule " + rule)
+	}
+	return ""
+}
+
+// measure returns what min and max compare: the length of strings, in
+// characters, and of lists, or integers themselves.
+func measure(v reflect.Value) (int64, string) {
+	switch v.Kind() {
+	case reflect.String:
+		return int64(utf8.RuneCountInString(v.String())), "characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return int64(v.Len()), "items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), ""
+	}
+	panic("validate: min and max don't apply to " + v.Kind().String())
+}
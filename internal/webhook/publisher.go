@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/event"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// Publisher is an event.Publisher that queues a delivery for every active
+// webhook subscribed to the event, then forwards the event to next.
+//
+// Queueing happens synchronously so that an event is never lost between
+// publish and delivery; the network calls happen later in Worker. Queueing
+// failures are logged rather than returned so they never fail the
+// operation that published the event.
+type Publisher struct {
+	next     event.Publisher
+	webhooks storage.WebhookRepository
+	logger   *slog.Logger
+}
+
+// NewPublisher wraps next with webhook queueing.
+func NewPublisher(next event.Publisher, webhooks storage.WebhookRepository, logger *slog.Logger) *Publisher {
+	return &Publisher{next: next, webhooks: webhooks, logger: logger}
+}
+
+func (p *Publisher) Publish(ctx context.Context, e domain.Event) error {
+	p.enqueue(ctx, []domain.Event{e})
+	return p.next.Publish(ctx, e)
+}
+
+func (p *Publisher) PublishBatch(ctx context.Context, events []domain.Event) error {
+	p.enqueue(ctx, events)
+	return p.next.PublishBatch(ctx, events)
+}
+
+func (p *Publisher) Close() error {
+	return p.next.Close()
+}
+
+func (p *Publisher) enqueue(ctx context.Context, events []domain.Event) {
+	if err := p.queue(ctx, events); err != nil {
+		p.logger.Error("queueing webhook deliveries failed", slog.String("error", err.Error()))
+	}
+}
+
+func (p *Publisher) queue(ctx context.Context, events []domain.Event) error {
+	webhooks, err := p.webhooks.ListActive(ctx)
+	if err != nil {
+		return fmt.Errorf("listing webhooks: %w", err)
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	var errs []error
+	for _, e := range events {
+		var body []byte
+
+		for i := range webhooks {
+			if !webhooks[i].Receives(e) {
+				continue
+			}
+
+			if body == nil {
+				body, err = event.DefaultCodec.Encode(event.NewEnvelope(e))
+				if err != nil {
+					errs = append(errs, fmt.Errorf("encoding event %s: %w", e.ID, err))
+					break
+				}
+			}
+
+			delivery := domain.NewWebhookDelivery(webhooks[i].ID, e, body)
+			if err := p.webhooks.CreateDelivery(ctx, delivery); err != nil {
+				errs = append(errs, fmt.Errorf("queueing delivery for webhook %s: %w", webhooks[i].ID, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,109 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"github.com/mvaleed/aegis/internal/domain"
+)
+
+// maxResponseBody bounds how much of a receiver's response we keep for history.
+const maxResponseBody = 4 << 10
+
+// ErrForbiddenAddress is returned for a send to an address webhooks may
+// not reach; see domain.PublicAddress.
+var ErrForbiddenAddress = errors.New("webhook: address not allowed")
+
+// Sender makes single signed delivery attempts. Worker sends queued
+// deliveries with one; test sends use one directly.
+type Sender struct {
+	client  *http.Client // Only connects to public addresses
+	private *http.Client // Connects anywhere; nil unless allowed
+}
+
+// NewSender creates a sender whose requests time out after timeout. It
+// only connects to public addresses unless allowPrivate is set, and even
+// then partners' webhooks do: their URLs are whatever the partner chose.
+func NewSender(timeout time.Duration, allowPrivate bool) *Sender {
+	s := &Sender{client: newClient(timeout, dialPublic)}
+	if allowPrivate {
+		s.private = newClient(timeout, nil)
+	}
+	return s
+}
+
+func newClient(timeout time.Duration, control func(network, address string, c syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+		Control:   control,
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// No proxy, which would make the connections in our place
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		// Receivers must answer directly; following redirects would
+		// send signed payloads to hosts nobody registered.
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// dialPublic refuses to connect to addresses that aren't public. It runs
+// after the host is resolved, for each address tried, so a name that
+// resolves to an internal address is refused too, whenever it was
+// registered.
+func dialPublic(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !domain.PublicAddress(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// Send POSTs the payload and returns the response status and a truncated body.
+func (s *Sender) Send(ctx context.Context, webhook *domain.Webhook, d *domain.WebhookDelivery) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "aegis-webhooks/1")
+	req.Header.Set(HeaderEvent, d.EventType)
+	req.Header.Set(HeaderDelivery, d.ID.String())
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, time.Now(), d.Payload))
+
+	client := s.client
+	if s.private != nil && webhook.OwnerID == nil {
+		client = s.private
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	return resp.StatusCode, string(body), nil
+}
@@ -0,0 +1,196 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/mvaleed/aegis/internal/domain"
+	"github.com/mvaleed/aegis/internal/storage"
+)
+
+// WorkerConfig controls delivery behaviour.
+type WorkerConfig struct {
+	PollInterval time.Duration // How often to look for due deliveries
+	BatchSize    int           // Deliveries claimed per poll
+	Concurrency  int           // Deliveries sent in parallel
+	Timeout      time.Duration // Per-request timeout
+	MaxAttempts  int           // Attempts before a delivery is dead-lettered
+	BaseBackoff  time.Duration // Delay after the first failure; doubles each attempt
+	MaxBackoff   time.Duration // Upper bound on the delay between attempts
+
+	// AllowPrivateNetworks lets webhooks without an owner reach internal
+	// addresses; see NewSender.
+	AllowPrivateNetworks bool
+}
+
+// DefaultWorkerConfig retries for roughly a day before dead-lettering.
+var DefaultWorkerConfig = WorkerConfig{
+	PollInterval: 5 * time.Second,
+	BatchSize:    50,
+	Concurrency:  8,
+	Timeout:      10 * time.Second,
+	MaxAttempts:  10,
+	BaseBackoff:  30 * time.Second,
+	MaxBackoff:   6 * time.Hour,
+}
+
+// Worker drains the webhook delivery queue.
+type Worker struct {
+	webhooks storage.WebhookRepository
+	sender   *Sender
+	cfg      WorkerConfig
+	logger   *slog.Logger
+}
+
+// NewWorker creates a delivery worker. Zero fields in cfg fall back to
+// DefaultWorkerConfig.
+func NewWorker(webhooks storage.WebhookRepository, cfg WorkerConfig, logger *slog.Logger) *Worker {
+	def := DefaultWorkerConfig
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = def.PollInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = def.BatchSize
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = def.Concurrency
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = def.Timeout
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = def.MaxAttempts
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = def.BaseBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = def.MaxBackoff
+	}
+
+	return &Worker{
+		webhooks: webhooks,
+		sender:   NewSender(cfg.Timeout, cfg.AllowPrivateNetworks),
+		cfg:      cfg,
+		logger:   logger,
+	}
+}
+
+// Run polls for due deliveries until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep draining while there is a backlog; otherwise wait for the next tick.
+		n, err := w.ProcessBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			w.logger.Error("webhook delivery batch failed", slog.String("error", err.Error()))
+		}
+		if n == w.cfg.BatchSize && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch claims and delivers one batch of due deliveries.
+// It returns the number of deliveries claimed.
+func (w *Worker) ProcessBatch(ctx context.Context) (int, error) {
+	// The lease must outlive a full attempt so another worker doesn't
+	// pick the delivery up while we're still waiting on the receiver.
+	lease := 2*w.cfg.Timeout + time.Minute
+
+	deliveries, err := w.webhooks.ClaimDueDeliveries(ctx, w.cfg.BatchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	sem := make(chan struct{}, w.cfg.Concurrency)
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(d *domain.WebhookDelivery) {
+			defer func() { <-sem; wg.Done() }()
+			w.process(ctx, d)
+		}(&deliveries[i])
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+func (w *Worker) process(ctx context.Context, d *domain.WebhookDelivery) {
+	logger := w.logger.With(
+		slog.String("delivery_id", d.ID.String()),
+		slog.String("webhook_id", d.WebhookID.String()),
+		slog.String("event_type", d.EventType),
+	)
+
+	webhook, err := w.webhooks.GetByID(ctx, d.WebhookID)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return // Deleted; the delivery row went with it
+	case err != nil:
+		logger.Error("loading webhook", slog.String("error", err.Error()))
+		return // Lease expires and the delivery is retried
+	case !webhook.Active:
+		// Disabled after the event was queued: dead-letter so it can be
+		// redelivered once the endpoint is turned back on.
+		d.RecordFailure(0, "", "webhook disabled", 1, w.backoff)
+	default:
+		status, body, err := w.sender.Send(ctx, webhook, d)
+		switch {
+		case err != nil:
+			d.RecordFailure(status, body, err.Error(), w.cfg.MaxAttempts, w.backoff)
+		case status >= 200 && status < 300:
+			d.RecordSuccess(status, body)
+		default:
+			d.RecordFailure(status, body, fmt.Sprintf("unexpected status %d", status), w.cfg.MaxAttempts, w.backoff)
+		}
+	}
+
+	if err := w.webhooks.UpdateDelivery(ctx, d); err != nil && !errors.Is(err, domain.ErrNotFound) {
+		logger.Error("saving delivery result", slog.String("error", err.Error()))
+		return
+	}
+
+	switch d.Status {
+	case domain.DeliverySucceeded:
+		logger.Debug("webhook delivered", slog.Int("attempts", d.Attempts))
+	case domain.DeliveryDead:
+		logger.Warn("webhook delivery dead-lettered",
+			slog.Int("attempts", d.Attempts),
+			slog.String("error", d.LastError),
+		)
+	default:
+		logger.Info("webhook delivery failed, will retry",
+			slog.Int("attempts", d.Attempts),
+			slog.Time("next_attempt_at", d.NextAttemptAt),
+			slog.String("error", d.LastError),
+		)
+	}
+}
+
+// backoff returns the delay before the next attempt after the given number
+// of failed attempts: BaseBackoff, 2×, 4×, … capped at MaxBackoff.
+func (w *Worker) backoff(attempt int) time.Duration {
+	delay := w.cfg.BaseBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= w.cfg.MaxBackoff {
+			return w.cfg.MaxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,10 @@
+-- 002_user_history.down.sql
+-- Rollback user history
+
+DROP TRIGGER IF EXISTS record_user_roles_history ON user_roles;
+DROP TRIGGER IF EXISTS record_users_history ON users;
+DROP FUNCTION IF EXISTS record_user_role_history();
+DROP FUNCTION IF EXISTS record_user_history();
+
+DROP TABLE IF EXISTS user_roles_history;
+DROP TABLE IF EXISTS users_history;
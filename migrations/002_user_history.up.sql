@@ -0,0 +1,95 @@
+-- 002_user_history.up.sql
+-- Temporal history of users and role memberships for "as of" reads
+
+-- Every version of a user row. A row is valid from valid_from until the
+-- valid_from of the next row for the same user.
+CREATE TABLE users_history (
+    history_id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    phone VARCHAR(50),
+    username VARCHAR(50) NOT NULL,
+    full_name VARCHAR(200) NOT NULL,
+    user_type user_type NOT NULL,
+    status user_status NOT NULL,
+    email_verified BOOLEAN NOT NULL,
+    phone_verified BOOLEAN NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    deleted_at TIMESTAMPTZ,
+    version INTEGER NOT NULL,
+    valid_from TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for as-of lookups
+CREATE INDEX idx_users_history_user_valid ON users_history (user_id, valid_from DESC);
+
+-- Role memberships with their validity interval. The role name is copied so
+-- history survives role renames and deletions.
+CREATE TABLE user_roles_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    role_id UUID NOT NULL,
+    role_name VARCHAR(50) NOT NULL,
+    valid_from TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    valid_to TIMESTAMPTZ
+);
+
+-- Index for as-of lookups
+CREATE INDEX idx_user_roles_history_user ON user_roles_history (user_id, valid_from);
+
+-- Record a snapshot of the user on every insert and update.
+-- password_hash is deliberately not historized.
+CREATE OR REPLACE FUNCTION record_user_history()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO users_history (
+        user_id, email, phone, username, full_name, user_type, status,
+        email_verified, phone_verified, created_at, updated_at, deleted_at, version
+    ) VALUES (
+        NEW.id, NEW.email, NEW.phone, NEW.username, NEW.full_name, NEW.user_type, NEW.status,
+        NEW.email_verified, NEW.phone_verified, NEW.created_at, NEW.updated_at, NEW.deleted_at, NEW.version
+    );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER record_users_history
+    AFTER INSERT OR UPDATE ON users
+    FOR EACH ROW
+    EXECUTE FUNCTION record_user_history();
+
+-- Open a membership interval on assignment and close it on removal.
+CREATE OR REPLACE FUNCTION record_user_role_history()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO user_roles_history (user_id, role_id, role_name)
+        SELECT NEW.user_id, NEW.role_id, r.name FROM roles r WHERE r.id = NEW.role_id;
+        RETURN NEW;
+    END IF;
+
+    UPDATE user_roles_history SET valid_to = NOW()
+    WHERE user_id = OLD.user_id AND role_id = OLD.role_id AND valid_to IS NULL;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER record_user_roles_history
+    AFTER INSERT OR DELETE ON user_roles
+    FOR EACH ROW
+    EXECUTE FUNCTION record_user_role_history();
+
+-- Backfill the current state so existing data has a starting point
+INSERT INTO users_history (
+    user_id, email, phone, username, full_name, user_type, status,
+    email_verified, phone_verified, created_at, updated_at, deleted_at, version, valid_from
+)
+SELECT id, email, phone, username, full_name, user_type, status,
+       email_verified, phone_verified, created_at, updated_at, deleted_at, version, updated_at
+FROM users;
+
+INSERT INTO user_roles_history (user_id, role_id, role_name, valid_from)
+SELECT ur.user_id, ur.role_id, r.name, ur.created_at
+FROM user_roles ur
+JOIN roles r ON r.id = ur.role_id;
@@ -0,0 +1,9 @@
+-- 003_webhooks.down.sql
+-- Rollback webhooks
+
+DROP TRIGGER IF EXISTS update_webhooks_updated_at ON webhooks;
+
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
+
+DROP TYPE IF EXISTS webhook_delivery_status;
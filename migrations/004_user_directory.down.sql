@@ -0,0 +1,6 @@
+-- 004_user_directory.down.sql
+-- Rollback user directory read model
+
+DROP FUNCTION IF EXISTS refresh_user_directory(UUID);
+
+DROP TABLE IF EXISTS user_directory;
@@ -0,0 +1,92 @@
+-- 004_user_directory.up.sql
+-- Denormalized read model for admin listing and search.
+-- Rows are refreshed by the application as events are published (see
+-- internal/readmodel) and periodically reconciled in full.
+
+CREATE TABLE user_directory (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    phone VARCHAR(20),
+    username VARCHAR(100) NOT NULL,
+    full_name VARCHAR(255) NOT NULL,
+    user_type user_type NOT NULL,
+    status user_status NOT NULL,
+    email_verified BOOLEAN NOT NULL,
+    phone_verified BOOLEAN NOT NULL,
+    role_names TEXT[] NOT NULL DEFAULT '{}',
+    last_login_at TIMESTAMPTZ,
+    active_sessions INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    deleted_at TIMESTAMPTZ,
+    refreshed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Indexes matching the admin list filters and ordering
+CREATE INDEX idx_user_directory_created ON user_directory (created_at DESC) WHERE deleted_at IS NULL;
+CREATE INDEX idx_user_directory_status ON user_directory (status) WHERE deleted_at IS NULL;
+CREATE INDEX idx_user_directory_type ON user_directory (user_type) WHERE deleted_at IS NULL;
+CREATE INDEX idx_user_directory_roles ON user_directory USING GIN (role_names);
+
+-- Rebuilds the directory row for one user, or for every user when p_user_id
+-- is NULL. last_login_at is owned by the login projection and is preserved.
+CREATE OR REPLACE FUNCTION refresh_user_directory(p_user_id UUID)
+RETURNS INTEGER AS $$
+DECLARE
+    affected INTEGER;
+BEGIN
+    INSERT INTO user_directory (
+        user_id, email, phone, username, full_name, user_type, status,
+        email_verified, phone_verified, role_names, active_sessions,
+        created_at, updated_at, deleted_at, refreshed_at
+    )
+    SELECT
+        u.id, u.email, u.phone, u.username, u.full_name, u.user_type, u.status,
+        u.email_verified, u.phone_verified,
+        COALESCE((
+            SELECT array_agg(r.name ORDER BY r.name)
+            FROM user_roles ur JOIN roles r ON r.id = ur.role_id
+            WHERE ur.user_id = u.id
+        ), '{}'),
+        (
+            SELECT COUNT(*)
+            FROM refresh_tokens t
+            WHERE t.user_id = u.id AND t.revoked_at IS NULL AND t.expires_at > NOW()
+        ),
+        u.created_at, u.updated_at, u.deleted_at, NOW()
+    FROM users u
+    WHERE p_user_id IS NULL OR u.id = p_user_id
+    ON CONFLICT (user_id) DO UPDATE SET
+        email = EXCLUDED.email,
+        phone = EXCLUDED.phone,
+        username = EXCLUDED.username,
+        full_name = EXCLUDED.full_name,
+        user_type = EXCLUDED.user_type,
+        status = EXCLUDED.status,
+        email_verified = EXCLUDED.email_verified,
+        phone_verified = EXCLUDED.phone_verified,
+        role_names = EXCLUDED.role_names,
+        active_sessions = EXCLUDED.active_sessions,
+        created_at = EXCLUDED.created_at,
+        updated_at = EXCLUDED.updated_at,
+        deleted_at = EXCLUDED.deleted_at,
+        refreshed_at = EXCLUDED.refreshed_at;
+
+    GET DIAGNOSTICS affected = ROW_COUNT;
+    RETURN affected;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Backfill
+SELECT refresh_user_directory(NULL);
+
+-- Logins aren't stored anywhere else; the newest refresh token is the
+-- closest approximation for existing users.
+UPDATE user_directory d
+SET last_login_at = t.last_issued
+FROM (
+    SELECT user_id, MAX(created_at) AS last_issued
+    FROM refresh_tokens
+    GROUP BY user_id
+) t
+WHERE t.user_id = d.user_id;
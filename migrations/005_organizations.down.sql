@@ -0,0 +1,65 @@
+-- 005_organizations.down.sql
+-- Rollback organizations
+
+-- Restore the pre-organization directory projection
+CREATE OR REPLACE FUNCTION refresh_user_directory(p_user_id UUID)
+RETURNS INTEGER AS $$
+DECLARE
+    affected INTEGER;
+BEGIN
+    INSERT INTO user_directory (
+        user_id, email, phone, username, full_name, user_type, status,
+        email_verified, phone_verified, role_names, active_sessions,
+        created_at, updated_at, deleted_at, refreshed_at
+    )
+    SELECT
+        u.id, u.email, u.phone, u.username, u.full_name, u.user_type, u.status,
+        u.email_verified, u.phone_verified,
+        COALESCE((
+            SELECT array_agg(r.name ORDER BY r.name)
+            FROM user_roles ur JOIN roles r ON r.id = ur.role_id
+            WHERE ur.user_id = u.id
+        ), '{}'),
+        (
+            SELECT COUNT(*)
+            FROM refresh_tokens t
+            WHERE t.user_id = u.id AND t.revoked_at IS NULL AND t.expires_at > NOW()
+        ),
+        u.created_at, u.updated_at, u.deleted_at, NOW()
+    FROM users u
+    WHERE p_user_id IS NULL OR u.id = p_user_id
+    ON CONFLICT (user_id) DO UPDATE SET
+        email = EXCLUDED.email,
+        phone = EXCLUDED.phone,
+        username = EXCLUDED.username,
+        full_name = EXCLUDED.full_name,
+        user_type = EXCLUDED.user_type,
+        status = EXCLUDED.status,
+        email_verified = EXCLUDED.email_verified,
+        phone_verified = EXCLUDED.phone_verified,
+        role_names = EXCLUDED.role_names,
+        active_sessions = EXCLUDED.active_sessions,
+        created_at = EXCLUDED.created_at,
+        updated_at = EXCLUDED.updated_at,
+        deleted_at = EXCLUDED.deleted_at,
+        refreshed_at = EXCLUDED.refreshed_at;
+
+    GET DIAGNOSTICS affected = ROW_COUNT;
+    RETURN affected;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS idx_user_directory_organizations;
+ALTER TABLE user_directory DROP COLUMN IF EXISTS organization_ids;
+
+-- Organization roles would collide once names are global again
+DELETE FROM roles WHERE organization_id IS NOT NULL;
+DROP INDEX IF EXISTS idx_roles_organization_name;
+DROP INDEX IF EXISTS idx_roles_global_name;
+ALTER TABLE roles ADD CONSTRAINT roles_name_unique UNIQUE (name);
+ALTER TABLE roles DROP COLUMN IF EXISTS organization_id;
+
+DROP TRIGGER IF EXISTS update_organizations_updated_at ON organizations;
+
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,93 @@
+-- 005_organizations.up.sql
+-- Organizations (tenants), membership, and organization-scoped roles
+
+CREATE TABLE organizations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(200) NOT NULL,
+    slug VARCHAR(63) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT organizations_slug_unique UNIQUE (slug)
+);
+
+CREATE TABLE organization_members (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (organization_id, user_id)
+);
+
+-- Index for "which organizations does this user belong to"
+CREATE INDEX idx_organization_members_user ON organization_members (user_id);
+
+CREATE TRIGGER update_organizations_updated_at
+    BEFORE UPDATE ON organizations
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Roles are global (organization_id IS NULL) or scoped to one organization.
+-- Names are unique within their scope.
+ALTER TABLE roles ADD COLUMN organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE roles DROP CONSTRAINT roles_name_unique;
+CREATE UNIQUE INDEX idx_roles_global_name ON roles (name) WHERE organization_id IS NULL;
+CREATE UNIQUE INDEX idx_roles_organization_name ON roles (organization_id, name) WHERE organization_id IS NOT NULL;
+
+-- Carry memberships in the user directory so org-scoped listing stays join-free
+ALTER TABLE user_directory ADD COLUMN organization_ids UUID[] NOT NULL DEFAULT '{}';
+CREATE INDEX idx_user_directory_organizations ON user_directory USING GIN (organization_ids);
+
+CREATE OR REPLACE FUNCTION refresh_user_directory(p_user_id UUID)
+RETURNS INTEGER AS $$
+DECLARE
+    affected INTEGER;
+BEGIN
+    INSERT INTO user_directory (
+        user_id, email, phone, username, full_name, user_type, status,
+        email_verified, phone_verified, role_names, organization_ids, active_sessions,
+        created_at, updated_at, deleted_at, refreshed_at
+    )
+    SELECT
+        u.id, u.email, u.phone, u.username, u.full_name, u.user_type, u.status,
+        u.email_verified, u.phone_verified,
+        COALESCE((
+            SELECT array_agg(r.name ORDER BY r.name)
+            FROM user_roles ur JOIN roles r ON r.id = ur.role_id
+            WHERE ur.user_id = u.id
+        ), '{}'),
+        COALESCE((
+            SELECT array_agg(om.organization_id)
+            FROM organization_members om
+            WHERE om.user_id = u.id
+        ), '{}'),
+        (
+            SELECT COUNT(*)
+            FROM refresh_tokens t
+            WHERE t.user_id = u.id AND t.revoked_at IS NULL AND t.expires_at > NOW()
+        ),
+        u.created_at, u.updated_at, u.deleted_at, NOW()
+    FROM users u
+    WHERE p_user_id IS NULL OR u.id = p_user_id
+    ON CONFLICT (user_id) DO UPDATE SET
+        email = EXCLUDED.email,
+        phone = EXCLUDED.phone,
+        username = EXCLUDED.username,
+        full_name = EXCLUDED.full_name,
+        user_type = EXCLUDED.user_type,
+        status = EXCLUDED.status,
+        email_verified = EXCLUDED.email_verified,
+        phone_verified = EXCLUDED.phone_verified,
+        role_names = EXCLUDED.role_names,
+        organization_ids = EXCLUDED.organization_ids,
+        active_sessions = EXCLUDED.active_sessions,
+        created_at = EXCLUDED.created_at,
+        updated_at = EXCLUDED.updated_at,
+        deleted_at = EXCLUDED.deleted_at,
+        refreshed_at = EXCLUDED.refreshed_at;
+
+    GET DIAGNOSTICS affected = ROW_COUNT;
+    RETURN affected;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,6 @@
+-- 006_groups.down.sql
+-- Rollback groups
+
+DROP TABLE IF EXISTS group_roles;
+DROP TABLE IF EXISTS group_members;
+DROP TABLE IF EXISTS groups;
@@ -0,0 +1,5 @@
+-- 007_invitations.down.sql
+-- Rollback invitations
+
+DROP TABLE IF EXISTS invitations;
+DROP TYPE IF EXISTS invitation_status;
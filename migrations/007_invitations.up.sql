@@ -0,0 +1,34 @@
+-- 007_invitations.up.sql
+-- Invitation-based onboarding. The invited account exists as a pending user
+-- until the invitation is accepted.
+
+CREATE TYPE invitation_status AS ENUM ('pending', 'accepted', 'revoked');
+
+CREATE TABLE invitations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    email VARCHAR(255) NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id UUID REFERENCES roles(id) ON DELETE SET NULL,
+    invited_by UUID NOT NULL REFERENCES users(id),
+    token_hash VARCHAR(64) NOT NULL,
+    status invitation_status NOT NULL DEFAULT 'pending',
+    send_count INTEGER NOT NULL DEFAULT 1,
+    expires_at TIMESTAMPTZ NOT NULL,
+    accepted_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT invitations_token_hash_unique UNIQUE (token_hash)
+);
+
+-- At most one outstanding invitation per invitee
+CREATE UNIQUE INDEX idx_invitations_pending_user ON invitations (user_id) WHERE status = 'pending';
+
+-- Index for the admin listing
+CREATE INDEX idx_invitations_created ON invitations (created_at DESC);
+
+CREATE TRIGGER update_invitations_updated_at
+    BEFORE UPDATE ON invitations
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,4 @@
+-- 008_idempotency_keys.down.sql
+-- Rollback idempotency keys
+
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,20 @@
+-- 008_idempotency_keys.up.sql
+-- Responses to mutations sent with an idempotency key, shared by the HTTP
+-- (Idempotency-Key header) and gRPC (idempotency-key metadata) transports.
+
+CREATE TABLE idempotency_keys (
+    scope VARCHAR(64) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    operation VARCHAR(128) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    response BYTEA,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+
+    PRIMARY KEY (scope, key)
+);
+
+-- Index for purging expired keys
+CREATE INDEX idx_idempotency_keys_expires ON idempotency_keys (expires_at);
@@ -0,0 +1,5 @@
+-- 009_impersonation_sessions.down.sql
+-- Rollback impersonation sessions
+
+DELETE FROM permissions WHERE resource = 'users' AND action = 'impersonate';
+DROP TABLE IF EXISTS impersonation_sessions;
@@ -0,0 +1,25 @@
+-- 009_impersonation_sessions.up.sql
+-- Support staff impersonating users. Each session backs one short-lived
+-- access token carrying an act (actor) claim and can be ended early.
+
+CREATE TABLE impersonation_sessions (
+    id UUID PRIMARY KEY,
+    actor_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    target_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    ended_at TIMESTAMPTZ,
+
+    CONSTRAINT impersonation_sessions_not_self CHECK (actor_id <> target_id)
+);
+
+-- Indexes for audit queries
+CREATE INDEX idx_impersonation_sessions_actor ON impersonation_sessions (actor_id, started_at DESC);
+CREATE INDEX idx_impersonation_sessions_target ON impersonation_sessions (target_id, started_at DESC);
+
+INSERT INTO permissions (id, resource, action, description) VALUES
+    (uuid_generate_v4(), 'users', 'impersonate', 'Act as another user for support')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,23 @@
+-- 010_user_attributes.down.sql
+-- Rollback user attributes
+
+DELETE FROM permissions WHERE resource = 'attributes';
+
+CREATE OR REPLACE FUNCTION record_user_history()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO users_history (
+        user_id, email, phone, username, full_name, user_type, status,
+        email_verified, phone_verified, created_at, updated_at, deleted_at, version
+    ) VALUES (
+        NEW.id, NEW.email, NEW.phone, NEW.username, NEW.full_name, NEW.user_type, NEW.status,
+        NEW.email_verified, NEW.phone_verified, NEW.created_at, NEW.updated_at, NEW.deleted_at, NEW.version
+    );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE users_history DROP COLUMN IF EXISTS attributes;
+DROP INDEX IF EXISTS idx_users_attributes;
+ALTER TABLE users DROP COLUMN IF EXISTS attributes;
+DROP TABLE IF EXISTS attribute_definitions;
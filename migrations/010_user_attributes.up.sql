@@ -0,0 +1,46 @@
+-- 010_user_attributes.up.sql
+-- Custom per-user attributes. Keys must be defined in attribute_definitions,
+-- which gives each key a type the application validates values against.
+
+CREATE TABLE attribute_definitions (
+    key VARCHAR(64) PRIMARY KEY,
+    type VARCHAR(20) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT attribute_definitions_type_valid CHECK (type IN ('string', 'number', 'boolean', 'string_list'))
+);
+
+CREATE TRIGGER update_attribute_definitions_updated_at
+    BEFORE UPDATE ON attribute_definitions
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE users ADD COLUMN attributes JSONB NOT NULL DEFAULT '{}';
+
+-- Index for attribute containment filters (attributes @> '{"key": value}')
+CREATE INDEX idx_users_attributes ON users USING GIN (attributes jsonb_path_ops);
+
+-- Historize attributes along with the rest of the user
+ALTER TABLE users_history ADD COLUMN attributes JSONB NOT NULL DEFAULT '{}';
+
+CREATE OR REPLACE FUNCTION record_user_history()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO users_history (
+        user_id, email, phone, username, full_name, user_type, status,
+        email_verified, phone_verified, attributes, created_at, updated_at, deleted_at, version
+    ) VALUES (
+        NEW.id, NEW.email, NEW.phone, NEW.username, NEW.full_name, NEW.user_type, NEW.status,
+        NEW.email_verified, NEW.phone_verified, NEW.attributes, NEW.created_at, NEW.updated_at, NEW.deleted_at, NEW.version
+    );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+INSERT INTO permissions (id, resource, action, description) VALUES
+    (uuid_generate_v4(), 'attributes', 'read', 'View user attribute definitions'),
+    (uuid_generate_v4(), 'attributes', 'write', 'Define user attributes'),
+    (uuid_generate_v4(), 'attributes', 'delete', 'Remove user attribute definitions')
+ON CONFLICT DO NOTHING;
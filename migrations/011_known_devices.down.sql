@@ -0,0 +1,4 @@
+-- 011_known_devices.down.sql
+-- Rollback known devices
+
+DROP TABLE IF EXISTS known_devices;
@@ -0,0 +1,25 @@
+-- 011_known_devices.up.sql
+-- Devices users have signed in from, and the networks each was used on.
+-- Logins from anything else are flagged and may need email confirmation.
+
+CREATE TABLE known_devices (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    fingerprint VARCHAR(64) NOT NULL,
+    user_agent TEXT,
+    last_ip_address VARCHAR(45),
+    networks TEXT[] NOT NULL DEFAULT '{}',
+    trusted BOOLEAN NOT NULL DEFAULT FALSE,
+    confirmed_at TIMESTAMPTZ,
+    confirmation_token_hash VARCHAR(255),
+    confirmation_expires_at TIMESTAMPTZ,
+    pending_network VARCHAR(64),
+    first_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT known_devices_user_fingerprint UNIQUE (user_id, fingerprint)
+);
+
+CREATE INDEX idx_known_devices_user ON known_devices (user_id, last_seen_at DESC);
+CREATE UNIQUE INDEX idx_known_devices_confirmation_token ON known_devices (confirmation_token_hash)
+    WHERE confirmation_token_hash IS NOT NULL;
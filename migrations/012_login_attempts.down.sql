@@ -0,0 +1,4 @@
+-- 012_login_attempts.down.sql
+-- Rollback login attempts
+
+DROP TABLE IF EXISTS login_attempts;
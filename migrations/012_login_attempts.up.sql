@@ -0,0 +1,16 @@
+-- 012_login_attempts.up.sql
+-- Sign-in attempts against existing accounts, successful or not, for the
+-- login history API. Attempts for unknown emails aren't recorded.
+
+CREATE TABLE login_attempts (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    success BOOLEAN NOT NULL,
+    failure_reason VARCHAR(50),
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_login_attempts_user ON login_attempts (user_id, created_at DESC);
+CREATE INDEX idx_login_attempts_created ON login_attempts (created_at);
@@ -0,0 +1,4 @@
+-- 013_password_history.down.sql
+-- Rollback password history
+
+DROP TABLE IF EXISTS password_history;
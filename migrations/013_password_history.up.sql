@@ -0,0 +1,18 @@
+-- 013_password_history.up.sql
+-- Previous password hashes per user, so recent passwords can't be reused.
+-- Only the newest PASSWORD_HISTORY_SIZE entries per user are kept.
+
+CREATE TABLE password_history (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_password_history_user ON password_history (user_id, created_at DESC);
+
+-- Seed with current passwords so the first change is checked too
+INSERT INTO password_history (id, user_id, password_hash, created_at)
+SELECT uuid_generate_v4(), id, password_hash, updated_at
+FROM users
+WHERE password_hash IS NOT NULL AND password_hash <> '';
@@ -0,0 +1,6 @@
+-- 014_profiles.down.sql
+-- Rollback typed profiles
+
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS profile_id;
+DROP TABLE IF EXISTS profile_roles;
+DROP TABLE IF EXISTS user_profiles;
@@ -0,0 +1,26 @@
+-- 014_profiles.up.sql
+-- Typed profiles: one login identity can act as a customer and as a partner,
+-- each profile with its own roles. Refresh tokens remember the profile they
+-- were issued for, so refreshing keeps the selected profile.
+
+CREATE TABLE user_profiles (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    profile_type user_type NOT NULL,
+    display_name VARCHAR(200) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT user_profiles_type_unique UNIQUE (user_id, profile_type),
+    CONSTRAINT user_profiles_type_check CHECK (profile_type IN ('customer', 'partner'))
+);
+
+CREATE TABLE profile_roles (
+    profile_id UUID NOT NULL REFERENCES user_profiles(id) ON DELETE CASCADE,
+    role_id UUID NOT NULL REFERENCES roles(id),
+    PRIMARY KEY (profile_id, role_id)
+);
+
+CREATE INDEX idx_profile_roles_role ON profile_roles (role_id);
+
+ALTER TABLE refresh_tokens
+    ADD COLUMN profile_id UUID REFERENCES user_profiles(id) ON DELETE CASCADE;
@@ -0,0 +1,10 @@
+-- 015_api_keys.down.sql
+-- Rollback partner API keys
+
+DELETE FROM roles WHERE name = 'partner' AND organization_id IS NULL;
+DELETE FROM permissions WHERE resource = 'portal';
+DROP INDEX IF EXISTS idx_webhooks_owner;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS owner_id;
+DROP TABLE IF EXISTS api_key_usage;
+DROP TABLE IF EXISTS api_keys;
+DROP TYPE IF EXISTS api_key_environment;
@@ -0,0 +1,49 @@
+-- 015_api_keys.up.sql
+-- Partner self-service: API keys for sandbox and production, their daily
+-- usage, and webhooks owned by a partner instead of the operators.
+
+CREATE TYPE api_key_environment AS ENUM ('sandbox', 'production');
+
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY,
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    environment api_key_environment NOT NULL,
+    prefix VARCHAR(32) NOT NULL UNIQUE,
+    secret_hash VARCHAR(64) NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    rotated_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_api_keys_owner ON api_keys (owner_id, created_at);
+
+CREATE TABLE api_key_usage (
+    api_key_id UUID NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (api_key_id, day)
+);
+
+-- NULL for webhooks registered by operators, which receive every event
+ALTER TABLE webhooks
+    ADD COLUMN owner_id UUID REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_webhooks_owner ON webhooks (owner_id) WHERE owner_id IS NOT NULL;
+
+INSERT INTO permissions (id, resource, action, description) VALUES
+    (uuid_generate_v4(), 'portal', 'read', 'View own API keys, usage and webhooks'),
+    (uuid_generate_v4(), 'portal', 'write', 'Manage own API keys and webhooks')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO roles (id, name, description) VALUES
+    (uuid_generate_v4(), 'partner', 'Partner developer portal access')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'partner' AND r.organization_id IS NULL AND p.resource = 'portal'
+ON CONFLICT DO NOTHING;
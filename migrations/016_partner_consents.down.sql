@@ -0,0 +1,4 @@
+-- 016_partner_consents.down.sql
+-- Rollback partner consents
+
+DROP TABLE IF EXISTS partner_consents;
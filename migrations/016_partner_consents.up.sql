@@ -0,0 +1,23 @@
+-- 016_partner_consents.up.sql
+-- Customers' consent to partners acting on their data. At most one active
+-- consent per customer and partner; revoked ones are kept for audit.
+
+CREATE TABLE partner_consents (
+    id UUID PRIMARY KEY,
+    customer_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    partner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    scopes TEXT[] NOT NULL,
+    granted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ,
+
+    CONSTRAINT partner_consents_not_self CHECK (customer_id <> partner_id)
+);
+
+-- Index for the authorization check, which also keeps one active consent
+-- per pair
+CREATE UNIQUE INDEX idx_partner_consents_active
+    ON partner_consents (customer_id, partner_id) WHERE revoked_at IS NULL;
+
+-- Index for a partner's list of consenting customers
+CREATE INDEX idx_partner_consents_partner ON partner_consents (partner_id, granted_at DESC);
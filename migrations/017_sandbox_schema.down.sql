@@ -0,0 +1,4 @@
+-- 017_sandbox_schema.down.sql
+-- Rollback the sandbox schema, with all sandbox data
+
+DROP SCHEMA IF EXISTS sandbox CASCADE;
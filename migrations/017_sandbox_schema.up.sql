@@ -0,0 +1,6 @@
+-- 017_sandbox_schema.up.sql
+-- Schema for sandbox data. Its tables are created by running the
+-- migrations again with it first on the search path
+-- (make migrate-sandbox-up), so it always has the same layout as public.
+
+CREATE SCHEMA IF NOT EXISTS sandbox;
@@ -0,0 +1,35 @@
+-- 018_action_tokens.down.sql
+-- Rollback action tokens, moving outstanding ones back to the tables they
+-- came from
+
+ALTER TABLE known_devices
+    ADD COLUMN confirmation_token_hash VARCHAR(255),
+    ADD COLUMN confirmation_expires_at TIMESTAMPTZ,
+    ADD COLUMN pending_network VARCHAR(64);
+
+UPDATE known_devices d SET
+    confirmation_token_hash = t.token_hash,
+    confirmation_expires_at = t.expires_at,
+    pending_network = t.payload->>'network'
+FROM action_tokens t
+WHERE t.purpose = 'confirm_login' AND t.subject_id = d.id
+    AND t.used_at IS NULL AND t.revoked_at IS NULL;
+
+CREATE UNIQUE INDEX idx_known_devices_confirmation_token ON known_devices (confirmation_token_hash)
+    WHERE confirmation_token_hash IS NOT NULL;
+
+ALTER TABLE invitations ADD COLUMN token_hash VARCHAR(64);
+
+UPDATE invitations i SET token_hash = t.token_hash
+FROM action_tokens t
+WHERE t.purpose = 'accept_invitation' AND t.subject_id = i.id
+    AND t.used_at IS NULL AND t.revoked_at IS NULL;
+
+-- Invitations without an outstanding token get a hash no token matches
+UPDATE invitations SET token_hash = 'none:' || id::text WHERE token_hash IS NULL;
+
+ALTER TABLE invitations
+    ALTER COLUMN token_hash SET NOT NULL,
+    ADD CONSTRAINT invitations_token_hash_unique UNIQUE (token_hash);
+
+DROP TABLE IF EXISTS action_tokens;
@@ -0,0 +1,49 @@
+-- 018_action_tokens.up.sql
+-- Single-use action tokens shared by every emailed link (invitations, login
+-- confirmations, email verification), replacing the token columns each of
+-- those tables had.
+--
+-- phase: contract
+
+CREATE TABLE action_tokens (
+    id UUID PRIMARY KEY,
+    purpose VARCHAR(50) NOT NULL,
+    subject_id UUID NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    token_hash VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT action_tokens_token_hash_unique UNIQUE (token_hash)
+);
+
+-- Index for superseding a subject's outstanding tokens
+CREATE INDEX idx_action_tokens_subject ON action_tokens (purpose, subject_id)
+    WHERE used_at IS NULL AND revoked_at IS NULL;
+
+-- Index for cleanup
+CREATE INDEX idx_action_tokens_expires ON action_tokens (expires_at);
+
+-- Carry over outstanding tokens so links already sent keep working
+INSERT INTO action_tokens (id, purpose, subject_id, token_hash, expires_at, created_at)
+SELECT uuid_generate_v4(), 'accept_invitation', id, token_hash, expires_at, updated_at
+FROM invitations
+WHERE status = 'pending';
+
+INSERT INTO action_tokens (id, purpose, subject_id, payload, token_hash, expires_at)
+SELECT uuid_generate_v4(), 'confirm_login', id,
+    CASE WHEN pending_network IS NULL THEN '{}'::jsonb
+         ELSE jsonb_build_object('network', pending_network) END,
+    confirmation_token_hash, confirmation_expires_at
+FROM known_devices
+WHERE confirmation_token_hash IS NOT NULL AND confirmation_expires_at IS NOT NULL;
+
+ALTER TABLE invitations DROP COLUMN token_hash;
+
+DROP INDEX IF EXISTS idx_known_devices_confirmation_token;
+ALTER TABLE known_devices
+    DROP COLUMN confirmation_token_hash,
+    DROP COLUMN confirmation_expires_at,
+    DROP COLUMN pending_network;
@@ -0,0 +1,4 @@
+-- 019_token_scopes.down.sql
+-- Rollback refresh token scopes
+
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS scope;
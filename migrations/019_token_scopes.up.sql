@@ -0,0 +1,7 @@
+-- 019_token_scopes.up.sql
+-- Refresh tokens remember the OpenID Connect scope they were issued with, so
+-- refreshing keeps the claims the client may read from userinfo. Empty is
+-- unrestricted, as for every token issued before.
+
+ALTER TABLE refresh_tokens
+    ADD COLUMN scope TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,9 @@
+-- 020_column_encryption.down.sql
+-- Rollback column encryption. Decrypt the secrets first (aegisctl
+-- reencrypt without COLUMN_ENCRYPTION_KEY), or they stay unreadable.
+
+DROP INDEX IF EXISTS idx_webhooks_secret_key_id;
+
+ALTER TABLE webhooks DROP COLUMN IF EXISTS secret_key_id;
+
+ALTER TABLE webhooks ALTER COLUMN secret TYPE VARCHAR(255);
@@ -0,0 +1,12 @@
+-- 020_column_encryption.up.sql
+-- Webhook signing secrets can be stored encrypted. secret_key_id is the ID
+-- of the key the secret is encrypted with, or empty for a secret stored in
+-- the clear, as every existing one is.
+
+ALTER TABLE webhooks ALTER COLUMN secret TYPE TEXT;
+
+ALTER TABLE webhooks
+    ADD COLUMN secret_key_id VARCHAR(8) NOT NULL DEFAULT '';
+
+-- Index for finding rows still on an old key
+CREATE INDEX idx_webhooks_secret_key_id ON webhooks (secret_key_id);
@@ -0,0 +1,6 @@
+-- 021_role_expiry.down.sql
+-- Rollback role assignment expiry; assignments still pending expiry become
+-- permanent
+
+DROP INDEX IF EXISTS idx_user_roles_expires_at;
+ALTER TABLE user_roles DROP COLUMN IF EXISTS expires_at;
@@ -0,0 +1,11 @@
+-- 021_role_expiry.up.sql
+-- Role assignments can lapse: a user holds a role with an expires_at only
+-- until then, e.g. for on-call access. Expired rows no longer grant anything
+-- and are deleted by a background job, which records the end in
+-- user_roles_history through the existing trigger.
+
+ALTER TABLE user_roles
+    ADD COLUMN expires_at TIMESTAMPTZ;
+
+CREATE INDEX idx_user_roles_expires_at ON user_roles (expires_at)
+    WHERE expires_at IS NOT NULL;
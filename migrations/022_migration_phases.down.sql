@@ -0,0 +1,4 @@
+-- 022_migration_phases.down.sql
+-- Rollback migration phase records
+
+DROP TABLE IF EXISTS schema_migration_phases;
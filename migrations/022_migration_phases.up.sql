@@ -0,0 +1,12 @@
+-- 022_migration_phases.up.sql
+-- The phase each migration was applied in, recorded by aegisctl migrate
+-- next to golang-migrate's schema_migrations. /readyz reads the newest
+-- contract migration from it to tell whether the schema has already moved
+-- past what a binary can run against.
+
+CREATE TABLE schema_migration_phases (
+    version BIGINT PRIMARY KEY,
+    name TEXT NOT NULL,
+    phase TEXT NOT NULL CHECK (phase IN ('expand', 'contract')),
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,6 @@
+-- 023_permission_deny.down.sql
+-- Rollback permission denials; denied permissions are removed rather than
+-- turned into grants
+
+DELETE FROM role_permissions WHERE deny;
+ALTER TABLE role_permissions DROP COLUMN IF EXISTS deny;
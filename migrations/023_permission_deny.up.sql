@@ -0,0 +1,6 @@
+-- 023_permission_deny.up.sql
+-- A role can deny a permission instead of granting it. Denials override
+-- grants from the same or any other role, so a carve-out such as
+-- users:delete out of users:* doesn't need the roles restructured.
+
+ALTER TABLE role_permissions ADD COLUMN deny BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,4 @@
+-- 024_resource_grants.down.sql
+-- Rollback resource instance grants
+
+DROP TABLE IF EXISTS resource_grants;
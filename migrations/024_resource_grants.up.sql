@@ -0,0 +1,26 @@
+-- 024_resource_grants.up.sql
+-- Permissions on single resource instances (projects:write on project 123),
+-- granted to a user or to every member of a group. The action "*" grants
+-- every action on the instance, which is how owners are recorded.
+
+CREATE TABLE resource_grants (
+    id UUID PRIMARY KEY,
+    resource VARCHAR(50) NOT NULL,
+    resource_id VARCHAR(255) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    group_id UUID REFERENCES groups(id) ON DELETE CASCADE,
+    granted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT resource_grants_one_subject CHECK ((user_id IS NULL) <> (group_id IS NULL))
+);
+
+-- Indexes for the authorization check, which also keep grants unique
+CREATE UNIQUE INDEX idx_resource_grants_user
+    ON resource_grants (user_id, resource, resource_id, action) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_resource_grants_group
+    ON resource_grants (group_id, resource, resource_id, action) WHERE group_id IS NOT NULL;
+
+-- Index for listing who has access to an instance
+CREATE INDEX idx_resource_grants_instance ON resource_grants (resource, resource_id);
@@ -0,0 +1,9 @@
+-- 025_support_lookups.down.sql
+-- Rollback support lookups
+
+DELETE FROM permissions WHERE resource = 'support' AND action IN ('lookup', 'audit');
+
+DROP INDEX IF EXISTS idx_login_attempts_ip;
+DROP INDEX IF EXISTS idx_refresh_tokens_ip;
+
+DROP TABLE IF EXISTS support_lookups;
@@ -0,0 +1,29 @@
+-- 025_support_lookups.up.sql
+-- Audit trail of the predefined lookups support staff run instead of
+-- querying the database: who ran which query with which parameters, why,
+-- and how many rows it returned. Records outlive the actor's account.
+
+CREATE TABLE support_lookups (
+    id UUID PRIMARY KEY,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    query VARCHAR(50) NOT NULL,
+    params JSONB NOT NULL DEFAULT '{}',
+    reason TEXT NOT NULL,
+    result_count INTEGER NOT NULL,
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Indexes for audit queries
+CREATE INDEX idx_support_lookups_actor ON support_lookups (actor_id, created_at DESC);
+CREATE INDEX idx_support_lookups_created ON support_lookups (created_at DESC);
+
+-- Indexes for the lookups by IP address
+CREATE INDEX idx_refresh_tokens_ip ON refresh_tokens (ip_address, created_at DESC);
+CREATE INDEX idx_login_attempts_ip ON login_attempts (ip_address, created_at DESC);
+
+INSERT INTO permissions (id, resource, action, description) VALUES
+    (uuid_generate_v4(), 'support', 'lookup', 'Run predefined support lookups'),
+    (uuid_generate_v4(), 'support', 'audit', 'View the support lookup audit trail')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,4 @@
+-- 026_org_member_permissions.down.sql
+-- Rollback organization member permissions
+
+DELETE FROM permissions WHERE resource = 'members' AND action IN ('read', 'invite', 'suspend', 'remove');
@@ -0,0 +1,9 @@
+-- 026_org_member_permissions.up.sql
+-- Permissions organization-scoped roles grant to let org admins manage members
+
+INSERT INTO permissions (id, resource, action, description) VALUES
+    (uuid_generate_v4(), 'members', 'read', 'List members of the organization'),
+    (uuid_generate_v4(), 'members', 'invite', 'Invite users into the organization'),
+    (uuid_generate_v4(), 'members', 'suspend', 'Suspend, reactivate and sign out organization members'),
+    (uuid_generate_v4(), 'members', 'remove', 'Remove members from the organization')
+ON CONFLICT DO NOTHING;
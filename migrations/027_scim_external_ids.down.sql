@@ -0,0 +1,4 @@
+-- 027_scim_external_ids.down.sql
+-- Rollback SCIM external IDs
+
+DROP TABLE IF EXISTS scim_external_ids;
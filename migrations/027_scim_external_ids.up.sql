@@ -0,0 +1,13 @@
+-- 027_scim_external_ids.up.sql
+-- IDs identity providers know users and groups provisioned over SCIM by.
+-- Rows aren't tied to the resources: users are only soft-deleted, and a
+-- provider may reuse an ID once the resource is gone.
+
+CREATE TABLE scim_external_ids (
+    resource_type VARCHAR(20) NOT NULL CHECK (resource_type IN ('User', 'Group')),
+    resource_id UUID NOT NULL,
+    external_id TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (resource_type, resource_id),
+    UNIQUE (resource_type, external_id)
+);
@@ -0,0 +1,4 @@
+-- 028_ldap_group_roles.down.sql
+-- Rollback LDAP group role mappings
+
+DROP TABLE IF EXISTS ldap_group_roles;
@@ -0,0 +1,13 @@
+-- 028_ldap_group_roles.up.sql
+-- Roles the members of LDAP groups get when they sign in with their
+-- directory password. Group DNs are stored lowercase.
+
+CREATE TABLE ldap_group_roles (
+    id UUID PRIMARY KEY,
+    group_dn VARCHAR(500) NOT NULL,
+    role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (group_dn, role_id)
+);
+
+CREATE INDEX idx_ldap_group_roles_role ON ldap_group_roles (role_id);
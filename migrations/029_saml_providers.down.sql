@@ -0,0 +1,7 @@
+-- 029_saml_providers.down.sql
+-- Rollback SAML identity providers
+
+DELETE FROM permissions WHERE resource = 'saml_providers' AND action IN ('read', 'write', 'delete');
+
+DROP TABLE IF EXISTS saml_group_roles;
+DROP TABLE IF EXISTS saml_providers;
@@ -0,0 +1,40 @@
+-- 029_saml_providers.up.sql
+-- SAML identity providers users sign in through, and the roles the groups
+-- their assertions list grant.
+
+CREATE TABLE saml_providers (
+    id UUID PRIMARY KEY,
+    slug VARCHAR(63) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    entity_id VARCHAR(500) NOT NULL,
+    sso_url TEXT NOT NULL,
+    certificate TEXT NOT NULL,
+    email_attribute VARCHAR(255) NOT NULL DEFAULT '',
+    name_attribute VARCHAR(255) NOT NULL DEFAULT '',
+    groups_attribute VARCHAR(255) NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_saml_providers_updated_at
+    BEFORE UPDATE ON saml_providers
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE saml_group_roles (
+    id UUID PRIMARY KEY,
+    provider_id UUID NOT NULL REFERENCES saml_providers(id) ON DELETE CASCADE,
+    group_name VARCHAR(500) NOT NULL,
+    role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (provider_id, group_name, role_id)
+);
+
+CREATE INDEX idx_saml_group_roles_role ON saml_group_roles (role_id);
+
+INSERT INTO permissions (id, resource, action, description) VALUES
+    (uuid_generate_v4(), 'saml_providers', 'read', 'View SAML identity providers'),
+    (uuid_generate_v4(), 'saml_providers', 'write', 'Configure SAML identity providers'),
+    (uuid_generate_v4(), 'saml_providers', 'delete', 'Remove SAML identity providers')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,4 @@
+-- 030_device_authorizations.down.sql
+-- Rollback device authorizations
+
+DROP TABLE IF EXISTS device_authorizations;
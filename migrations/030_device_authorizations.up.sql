@@ -0,0 +1,21 @@
+-- 030_device_authorizations.up.sql
+-- Pending OAuth 2.0 device authorization grants: devices such as CLIs
+-- waiting for a user to approve them by user code. Only a hash of the
+-- device code is stored; rows are deleted once the device has its tokens.
+
+CREATE TABLE device_authorizations (
+    id UUID PRIMARY KEY,
+    device_code_hash VARCHAR(64) NOT NULL UNIQUE,
+    user_code VARCHAR(8) NOT NULL UNIQUE,
+    client_id VARCHAR(100) NOT NULL,
+    scope TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    interval_seconds INTEGER NOT NULL,
+    last_polled_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for the cleanup job
+CREATE INDEX idx_device_authorizations_expires ON device_authorizations (expires_at);
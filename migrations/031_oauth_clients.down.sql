@@ -0,0 +1,7 @@
+-- 031_oauth_clients.down.sql
+-- Rollback OAuth clients
+
+DELETE FROM permissions WHERE resource = 'oauth_clients' AND action IN ('read', 'write', 'delete');
+
+DROP TABLE IF EXISTS oauth_client_roles;
+DROP TABLE IF EXISTS oauth_clients;
@@ -0,0 +1,33 @@
+-- 031_oauth_clients.up.sql
+-- OAuth clients that get access tokens of their own with the client
+-- credentials grant, and the roles their tokens carry the permissions of.
+
+CREATE TABLE oauth_clients (
+    id UUID PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    secret_hash VARCHAR(64) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_oauth_clients_updated_at
+    BEFORE UPDATE ON oauth_clients
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE oauth_client_roles (
+    client_id UUID NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+    role_id UUID NOT NULL REFERENCES roles(id),
+    PRIMARY KEY (client_id, role_id)
+);
+
+CREATE INDEX idx_oauth_client_roles_role ON oauth_client_roles (role_id);
+
+INSERT INTO permissions (id, resource, action, description) VALUES
+    (uuid_generate_v4(), 'oauth_clients', 'read', 'View OAuth clients'),
+    (uuid_generate_v4(), 'oauth_clients', 'write', 'Register OAuth clients and rotate their secrets'),
+    (uuid_generate_v4(), 'oauth_clients', 'delete', 'Remove OAuth clients')
+ON CONFLICT DO NOTHING;
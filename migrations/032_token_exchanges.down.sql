@@ -0,0 +1,6 @@
+-- 032_token_exchanges.down.sql
+-- Rollback token exchanges
+
+DELETE FROM permissions WHERE resource = 'tokens' AND action = 'exchange';
+
+DROP TABLE IF EXISTS token_exchanges;
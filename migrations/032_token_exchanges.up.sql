@@ -0,0 +1,24 @@
+-- 032_token_exchanges.up.sql
+-- Audit of OAuth clients exchanging users' access tokens for delegation
+-- tokens. Rows outlive the client, so client_id isn't a foreign key.
+
+CREATE TABLE token_exchanges (
+    id UUID PRIMARY KEY,
+    client_id UUID NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    audience TEXT[] NOT NULL,
+    permissions TEXT[] NOT NULL,
+    subject_token_id VARCHAR(64) NOT NULL,
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- Indexes for audit queries
+CREATE INDEX idx_token_exchanges_user ON token_exchanges (user_id, created_at DESC);
+CREATE INDEX idx_token_exchanges_client ON token_exchanges (client_id, created_at DESC);
+
+INSERT INTO permissions (id, resource, action, description) VALUES
+    (uuid_generate_v4(), 'tokens', 'exchange', 'Exchange users'' access tokens for delegation tokens')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,6 @@
+-- 033_opaque_access_tokens.down.sql
+-- Rollback opaque access tokens
+
+DELETE FROM permissions WHERE resource = 'tokens' AND action = 'introspect';
+
+DROP TABLE IF EXISTS opaque_access_tokens;
@@ -0,0 +1,19 @@
+-- 033_opaque_access_tokens.up.sql
+-- Claims of opaque access tokens, kept server-side by the token's hash.
+-- user_id is a client's ID for client tokens, so it isn't a foreign key.
+
+CREATE TABLE opaque_access_tokens (
+    token_hash VARCHAR(64) PRIMARY KEY,
+    user_id UUID NOT NULL,
+    claims JSONB NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- Index for revoking a user's tokens
+CREATE INDEX idx_opaque_access_tokens_user ON opaque_access_tokens (user_id);
+-- Index for cleanup
+CREATE INDEX idx_opaque_access_tokens_expires ON opaque_access_tokens (expires_at);
+
+INSERT INTO permissions (id, resource, action, description) VALUES
+    (uuid_generate_v4(), 'tokens', 'introspect', 'Introspect access tokens')
+ON CONFLICT DO NOTHING;
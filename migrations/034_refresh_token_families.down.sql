@@ -0,0 +1,11 @@
+-- 034_refresh_token_families.down.sql
+-- Rollback refresh token families
+
+DROP INDEX IF EXISTS idx_refresh_tokens_family;
+
+ALTER TABLE refresh_tokens
+    DROP CONSTRAINT refresh_tokens_replaced_by_id_fkey,
+    ADD CONSTRAINT refresh_tokens_replaced_by_id_fkey
+        FOREIGN KEY (replaced_by_id) REFERENCES refresh_tokens(id);
+
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS family_id;
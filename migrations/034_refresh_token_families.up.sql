@@ -0,0 +1,16 @@
+-- 034_refresh_token_families.up.sql
+-- Refresh tokens belong to a family: the token issued at sign-in and every
+-- token rotated from it, each linked to its replacement by replaced_by_id.
+-- Tokens issued before, and by the previous version during a rollout, have
+-- no family_id and are each a family of their own. A replacement deleted
+-- first (after a TTL change) unlinks the token it replaced.
+
+ALTER TABLE refresh_tokens ADD COLUMN family_id UUID;
+
+ALTER TABLE refresh_tokens
+    DROP CONSTRAINT refresh_tokens_replaced_by_id_fkey,
+    ADD CONSTRAINT refresh_tokens_replaced_by_id_fkey
+        FOREIGN KEY (replaced_by_id) REFERENCES refresh_tokens(id) ON DELETE SET NULL;
+
+-- Index for revoking and listing a family
+CREATE INDEX idx_refresh_tokens_family ON refresh_tokens (family_id, created_at);
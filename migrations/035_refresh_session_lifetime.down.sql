@@ -0,0 +1,4 @@
+-- 035_refresh_session_lifetime.down.sql
+-- Rollback refresh session start times
+
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS session_started_at;
@@ -0,0 +1,6 @@
+-- 035_refresh_session_lifetime.up.sql
+-- Refresh tokens carry when their session started, copied on rotation, so
+-- a maximum session lifetime holds however often the session refreshes.
+-- Tokens without it count from their own creation.
+
+ALTER TABLE refresh_tokens ADD COLUMN session_started_at TIMESTAMPTZ;
@@ -0,0 +1,4 @@
+-- 036_refresh_token_remember_me.down.sql
+-- Rollback refresh token remember-me flags
+
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS remember_me;
@@ -0,0 +1,6 @@
+-- 036_refresh_token_remember_me.up.sql
+-- Refresh tokens record whether the user asked to be remembered, which
+-- picks their TTL and is kept on rotation. Tokens without it were issued
+-- with the long TTL.
+
+ALTER TABLE refresh_tokens ADD COLUMN remember_me BOOLEAN;
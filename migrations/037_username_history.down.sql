@@ -0,0 +1,4 @@
+-- 037_username_history.down.sql
+-- Rollback username history
+
+DROP TABLE IF EXISTS username_history;
@@ -0,0 +1,17 @@
+-- 037_username_history.up.sql
+-- Usernames users have moved away from, so a former name is reserved for
+-- its holder for USERNAME_RESERVATION_PERIOD and lookups by it can still
+-- find them.
+
+CREATE TABLE username_history (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    username VARCHAR(50) NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    reserved_until TIMESTAMPTZ NOT NULL
+);
+
+-- Index for the change cooldown
+CREATE INDEX idx_username_history_user ON username_history (user_id, changed_at DESC);
+-- Index for reservations and former-name lookups
+CREATE INDEX idx_username_history_username ON username_history (username, changed_at DESC);
@@ -0,0 +1,4 @@
+-- 038_account_deletions.down.sql
+-- Rollback scheduled account deletions
+
+DROP TABLE IF EXISTS account_deletions;
@@ -0,0 +1,12 @@
+-- 038_account_deletions.up.sql
+-- Deletions users schedule for their own account, carried out by a
+-- background job once the grace period ends unless they cancel.
+
+CREATE TABLE account_deletions (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    requested_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delete_after TIMESTAMPTZ NOT NULL
+);
+
+-- Index for finding due deletions
+CREATE INDEX idx_account_deletions_due ON account_deletions (delete_after);
@@ -0,0 +1,4 @@
+-- 039_notification_preferences.down.sql
+-- Rollback notification preferences
+
+DROP TABLE IF EXISTS notification_preferences;
@@ -0,0 +1,13 @@
+-- 039_notification_preferences.up.sql
+-- Users' choices of which notifications they get over which channel. A
+-- missing row means the notification is sent.
+
+CREATE TABLE notification_preferences (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    category VARCHAR(50) NOT NULL,
+    channel VARCHAR(20) NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (user_id, category, channel)
+);
@@ -0,0 +1,21 @@
+-- 040_user_locale.down.sql
+-- Rollback user locale and time zone
+
+CREATE OR REPLACE FUNCTION record_user_history()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO users_history (
+        user_id, email, phone, username, full_name, user_type, status,
+        email_verified, phone_verified, attributes, created_at, updated_at, deleted_at, version
+    ) VALUES (
+        NEW.id, NEW.email, NEW.phone, NEW.username, NEW.full_name, NEW.user_type, NEW.status,
+        NEW.email_verified, NEW.phone_verified, NEW.attributes, NEW.created_at, NEW.updated_at, NEW.deleted_at, NEW.version
+    );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE users_history DROP COLUMN IF EXISTS timezone;
+ALTER TABLE users_history DROP COLUMN IF EXISTS locale;
+ALTER TABLE users DROP COLUMN IF EXISTS timezone;
+ALTER TABLE users DROP COLUMN IF EXISTS locale;
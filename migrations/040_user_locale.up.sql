@@ -0,0 +1,26 @@
+-- 040_user_locale.up.sql
+-- Users' locale (a BCP 47 language tag) and time zone (an IANA name), for
+-- formatting. NULL until the user sets them.
+
+ALTER TABLE users ADD COLUMN locale VARCHAR(35);
+ALTER TABLE users ADD COLUMN timezone VARCHAR(64);
+
+-- Historize them along with the rest of the user
+ALTER TABLE users_history ADD COLUMN locale VARCHAR(35);
+ALTER TABLE users_history ADD COLUMN timezone VARCHAR(64);
+
+CREATE OR REPLACE FUNCTION record_user_history()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO users_history (
+        user_id, email, phone, username, full_name, user_type, status,
+        email_verified, phone_verified, attributes, locale, timezone,
+        created_at, updated_at, deleted_at, version
+    ) VALUES (
+        NEW.id, NEW.email, NEW.phone, NEW.username, NEW.full_name, NEW.user_type, NEW.status,
+        NEW.email_verified, NEW.phone_verified, NEW.attributes, NEW.locale, NEW.timezone,
+        NEW.created_at, NEW.updated_at, NEW.deleted_at, NEW.version
+    );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,6 @@
+-- 041_federated_identities.down.sql
+-- Rollback federated identity links
+
+ALTER TABLE saml_providers DROP COLUMN IF EXISTS email_domains;
+
+DROP TABLE IF EXISTS federated_identities;
@@ -0,0 +1,19 @@
+-- 041_federated_identities.up.sql
+-- Which account each directory entry or identity provider subject signs
+-- in to, so sign-ins follow the link rather than whatever account has the
+-- asserted email, and the email domains each SAML provider may assert.
+
+CREATE TABLE federated_identities (
+    id UUID PRIMARY KEY,
+    -- "ldap", or "saml:" and the provider's ID
+    provider VARCHAR(100) NOT NULL,
+    -- The entry's DN or the assertion's NameID
+    subject VARCHAR(1000) NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (provider, subject)
+);
+
+CREATE INDEX idx_federated_identities_user ON federated_identities (user_id);
+
+ALTER TABLE saml_providers ADD COLUMN email_domains TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,4 @@
+-- 042_users_keyset.down.sql
+-- Rollback the user keyset index
+
+DROP INDEX IF EXISTS idx_users_created_id;
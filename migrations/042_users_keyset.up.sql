@@ -0,0 +1,5 @@
+-- 042_users_keyset.up.sql
+-- Index for paging through users newest first by (created_at, id), as
+-- user streams do, rather than by offset.
+
+CREATE INDEX idx_users_created_id ON users (created_at DESC, id DESC);
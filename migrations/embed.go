@@ -0,0 +1,8 @@
+// Package migrations embeds the up migrations, so a binary knows which
+// schema versions it was built for.
+package migrations
+
+import "embed"
+
+//go:embed *.up.sql
+var FS embed.FS
@@ -0,0 +1,109 @@
+package authmiddleware
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/mvaleed/aegis/internal/auth"
+)
+
+// Verifier verifies access tokens.
+type Verifier interface {
+	Verify(ctx context.Context, token string) (*Claims, error)
+}
+
+// Validation holds the checks common to every verifier.
+type Validation struct {
+	// Issuer and Audience, when set, must match the token's iss and aud
+	// ("mvaleed" and JWT_AUDIENCE on aegis). Delegation tokens carry the
+	// audience their client asked for instead.
+	Issuer   string
+	Audience string
+
+	// Leeway tolerates clock drift with aegis (default 5s).
+	Leeway time.Duration
+}
+
+func (v Validation) parserOptions(methods []string) []jwt.ParserOption {
+	leeway := v.Leeway
+	if leeway <= 0 {
+		leeway = auth.DefaultJWTConfig().Leeway
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithLeeway(leeway),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	}
+	if v.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(v.Issuer))
+	}
+	if v.Audience != "" {
+		opts = append(opts, jwt.WithAudience(v.Audience))
+	}
+	return opts
+}
+
+// parse verifies token and converts its claims.
+func parse(token string, keyFunc jwt.Keyfunc, opts []jwt.ParserOption) (*Claims, error) {
+	claims := &auth.Claims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, keyFunc, opts...)
+	if err != nil || !parsed.Valid {
+		return nil, ErrInvalidToken
+	}
+	return fromToken(claims), nil
+}
+
+// HMACConfig configures an HMACVerifier.
+type HMACConfig struct {
+	Validation
+
+	// Secret is aegis's JWT_SECRET_KEY.
+	Secret string
+
+	// PreviousSecret is the secret before the last rotation. Tokens signed
+	// with it stay valid until they expire, as they do on aegis.
+	PreviousSecret string
+}
+
+// HMACVerifier verifies tokens signed with a shared secret, which is how
+// aegis signs them.
+type HMACVerifier struct {
+	keys atomic.Pointer[[]jwt.VerificationKey]
+	opts []jwt.ParserOption
+}
+
+// NewHMACVerifier returns a verifier for tokens signed with cfg's secrets.
+func NewHMACVerifier(cfg HMACConfig) *HMACVerifier {
+	v := &HMACVerifier{opts: cfg.parserOptions([]string{"HS256", "HS384", "HS512"})}
+
+	keys := []jwt.VerificationKey{[]byte(cfg.Secret)}
+	if cfg.PreviousSecret != "" {
+		keys = append(keys, []byte(cfg.PreviousSecret))
+	}
+	v.keys.Store(&keys)
+
+	return v
+}
+
+// SetSecret rotates the secret; the replaced one keeps verifying until the
+// next rotation.
+func (v *HMACVerifier) SetSecret(secret string) {
+	old := *v.keys.Load()
+	if string(old[0].([]byte)) == secret {
+		return
+	}
+	keys := []jwt.VerificationKey{[]byte(secret), old[0]}
+	v.keys.Store(&keys)
+}
+
+func (v *HMACVerifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	keys := *v.keys.Load()
+	return parse(token, func(*jwt.Token) (any, error) {
+		return jwt.VerificationKeySet{Keys: keys}, nil
+	}, v.opts)
+}
@@ -0,0 +1,140 @@
+// Package authz lets Go services behind aegis authenticate requests with
+// aegis access tokens and check permissions without a call per request.
+//
+// It wraps pkg/authmiddleware for SDK users, adding a verifier that asks
+// aegis through a client.Client. aegis signs access tokens with HS256 and
+// publishes no JWKS, so there are no public keys to check them against:
+// local verification needs the shared HMAC secret (JWT_SECRET_KEY) deployed
+// to the service, and services that mustn't hold it verify through the
+// ValidateToken RPC instead. Either way permissions are read from the
+// token, as the aegis server itself does.
+package authz
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+
+	userv1 "github.com/mvaleed/aegis/api/proto/user/v1"
+	"github.com/mvaleed/aegis/pkg/authmiddleware"
+	"github.com/mvaleed/aegis/pkg/client"
+)
+
+// ErrInvalidToken is returned for tokens that are malformed, expired,
+// wrongly signed or rejected by the server.
+var ErrInvalidToken = authmiddleware.ErrInvalidToken
+
+type (
+	// Principal is the subject of a verified access token.
+	Principal = authmiddleware.Claims
+
+	// Organization is a membership with the permissions granted by roles
+	// scoped to it.
+	Organization = authmiddleware.Organization
+
+	// Verifier verifies access tokens.
+	Verifier = authmiddleware.Verifier
+
+	// LocalConfig configures NewLocalVerifier with aegis's HMAC secret.
+	LocalConfig = authmiddleware.HMACConfig
+)
+
+// FromContext returns the principal the middleware authenticated, if any.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	return authmiddleware.FromContext(ctx)
+}
+
+// Middleware authenticates requests with the bearer token in the
+// Authorization header, verified by v: a local verifier, holding the
+// shared secret, or a RemoteVerifier; see authmiddleware.Middleware.
+func Middleware(v Verifier) func(http.Handler) http.Handler {
+	return authmiddleware.Middleware(v)
+}
+
+// Require answers 403 unless the authenticated principal holds
+// resource:action. It goes after Middleware.
+func Require(resource, action string) func(http.Handler) http.Handler {
+	return authmiddleware.RequirePermission(resource, action)
+}
+
+// UnaryServerInterceptor authenticates gRPC calls; methods in public are
+// let through unauthenticated.
+func UnaryServerInterceptor(v Verifier, public ...string) grpc.UnaryServerInterceptor {
+	return authmiddleware.UnaryServerInterceptor(v, public...)
+}
+
+// CheckPermission returns PermissionDenied unless the principal in ctx
+// holds resource:action, for use in gRPC handlers.
+func CheckPermission(ctx context.Context, resource, action string) error {
+	return authmiddleware.CheckPermission(ctx, resource, action)
+}
+
+// NewLocalVerifier returns a verifier for tokens signed with cfg's secrets,
+// which must be aegis's JWT_SECRET_KEY (and the previous one during a
+// rotation); anyone holding them can mint tokens. It can't tell whether an impersonation session has since been ended; use
+// a RemoteVerifier where that matters.
+func NewLocalVerifier(cfg LocalConfig) *authmiddleware.HMACVerifier {
+	return authmiddleware.NewHMACVerifier(cfg)
+}
+
+// RemoteVerifier verifies tokens with the ValidateToken RPC, so ended
+// impersonation sessions are caught, at the cost of a call per token.
+type RemoteVerifier struct {
+	client *client.Client
+}
+
+// NewRemoteVerifier returns a verifier calling aegis through c.
+func NewRemoteVerifier(c *client.Client) *RemoteVerifier {
+	return &RemoteVerifier{client: c}
+}
+
+func (v *RemoteVerifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	resp, err := v.client.ValidateToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.GetValid() {
+		return nil, ErrInvalidToken
+	}
+
+	userID, err := uuid.Parse(resp.GetUserId())
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	p := &Principal{
+		UserID:      userID,
+		Email:       resp.GetEmail(),
+		UserType:    userTypeName(resp.GetUserType()),
+		Permissions: resp.GetPermissions(),
+		Denied:      resp.GetDeniedPermissions(),
+		Groups:      resp.GetGroups(),
+	}
+	for _, org := range resp.GetOrganizations() {
+		orgID, err := uuid.Parse(org.GetOrganizationId())
+		if err != nil {
+			continue
+		}
+		p.Organizations = append(p.Organizations, Organization{
+			ID:          orgID,
+			Permissions: org.GetPermissions(),
+			Denied:      org.GetDeniedPermissions(),
+		})
+	}
+	if actorID, err := uuid.Parse(resp.GetActorId()); err == nil {
+		p.ActorID = &actorID
+	}
+
+	return p, nil
+}
+
+// userTypeName maps USER_TYPE_ADMIN to "admin", as tokens carry it.
+func userTypeName(t userv1.UserType) string {
+	if t == userv1.UserType_USER_TYPE_UNSPECIFIED {
+		return ""
+	}
+	return strings.ToLower(strings.TrimPrefix(t.String(), "USER_TYPE_"))
+}
@@ -0,0 +1,155 @@
+// Package client is a Go SDK for the aegis gRPC API.
+//
+// A Client signs in (or resumes a session from a refresh token), attaches
+// the access token to every call and refreshes it before it expires or when
+// the server rejects it. Its typed methods retry idempotent calls on
+// UNAVAILABLE with exponential backoff; the generated service clients it
+// exposes share the connection and the token handling, but not the retries.
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	userv1 "github.com/mvaleed/aegis/api/proto/user/v1"
+)
+
+// ErrNotSignedIn is returned by calls that need a session when the client
+// has none.
+var ErrNotSignedIn = errors.New("client: not signed in")
+
+// Config configures a Client.
+type Config struct {
+	// Target is the gRPC server address, e.g. "aegis:9090".
+	Target string
+
+	// TLS secures the connection; nil dials in plaintext, for local
+	// development and in-cluster sidecars.
+	TLS *tls.Config
+
+	// Email and Password sign in when dialing. Alternatively, RefreshToken
+	// resumes an existing session. With neither, the client calls
+	// anonymously until Login.
+	Email        string
+	Password     string
+	RefreshToken string
+
+	// RefreshBefore is how long before the access token expires it's
+	// refreshed (default 30s).
+	RefreshBefore time.Duration
+
+	// Retry governs retries of idempotent calls on UNAVAILABLE.
+	Retry RetryPolicy
+
+	// DialOptions are appended to the client's own.
+	DialOptions []grpc.DialOption
+}
+
+// RetryPolicy configures exponential backoff between attempts. The
+// defaults match the retry policy the server publishes in its service
+// config.
+type RetryPolicy struct {
+	MaxAttempts    int           // Including the first; default 4, 1 disables retries
+	InitialBackoff time.Duration // Default 100ms
+	MaxBackoff     time.Duration // Default 1s
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 4
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = 100 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = time.Second
+	}
+	return p
+}
+
+// Client is an aegis API client. It's safe for concurrent use.
+type Client struct {
+	conn   *grpc.ClientConn
+	tokens *tokenSource
+	retry  RetryPolicy
+
+	// Generated service clients, for calls without a typed method
+	Users         userv1.UserServiceClient
+	Auth          userv1.AuthServiceClient
+	RBAC          userv1.RBACServiceClient
+	Organizations userv1.OrganizationServiceClient
+	Groups        userv1.GroupServiceClient
+	Events        userv1.EventServiceClient
+}
+
+// Dial connects to the aegis server and, when the config carries
+// credentials, signs in.
+func Dial(ctx context.Context, cfg Config) (*Client, error) {
+	if cfg.Target == "" {
+		return nil, errors.New("client: target is required")
+	}
+	if cfg.RefreshBefore <= 0 {
+		cfg.RefreshBefore = 30 * time.Second
+	}
+
+	c := &Client{retry: cfg.Retry.withDefaults()}
+
+	creds := insecure.NewCredentials()
+	if cfg.TLS != nil {
+		creds = credentials.NewTLS(cfg.TLS)
+	}
+
+	opts := append([]grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(c.unaryAuth),
+		grpc.WithChainStreamInterceptor(c.streamAuth),
+	}, cfg.DialOptions...)
+
+	conn, err := grpc.NewClient(cfg.Target, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("client: dial %s: %w", cfg.Target, err)
+	}
+
+	c.conn = conn
+	c.Users = userv1.NewUserServiceClient(conn)
+	c.Auth = userv1.NewAuthServiceClient(conn)
+	c.RBAC = userv1.NewRBACServiceClient(conn)
+	c.Organizations = userv1.NewOrganizationServiceClient(conn)
+	c.Groups = userv1.NewGroupServiceClient(conn)
+	c.Events = userv1.NewEventServiceClient(conn)
+	c.tokens = newTokenSource(c.Auth, cfg.RefreshBefore)
+
+	switch {
+	case cfg.Email != "":
+		if _, err := c.Login(ctx, cfg.Email, cfg.Password); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	case cfg.RefreshToken != "":
+		c.tokens.set("", cfg.RefreshToken, 0)
+		if _, err := c.tokens.token(ctx); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// Close closes the connection. It doesn't end the session; see Logout.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// RefreshToken returns the session's current refresh token, so it can be
+// stored and the session resumed later. Refreshes rotate it.
+func (c *Client) RefreshToken() string {
+	return c.tokens.refreshToken()
+}
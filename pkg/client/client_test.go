@@ -0,0 +1,101 @@
+package client_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/mvaleed/aegis/internal/service/servicetest"
+	grpcTransport "github.com/mvaleed/aegis/internal/transport/grpc"
+	"github.com/mvaleed/aegis/pkg/client"
+)
+
+func TestLoginThenRefresh(t *testing.T) {
+	const email, password = "ada@example.com", "correct horse battery"
+
+	authn, err := servicetest.NewAuth(email, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := serve(t, authn)
+
+	ctx := context.Background()
+	c, err := client.Dial(ctx, client.Config{
+		Target:   "passthrough:///bufnet",
+		Email:    email,
+		Password: password,
+		// Longer than the access token lives, so every call refreshes first
+		RefreshBefore: time.Hour,
+		DialOptions: []grpc.DialOption{
+			grpc.WithContextDialer(dialer(target)),
+			grpc.WithUserAgent("client-test"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer c.Close()
+
+	signedIn := c.RefreshToken()
+	if signedIn == "" {
+		t.Fatal("no refresh token after login")
+	}
+
+	if _, err := c.ValidateToken(ctx, "not-a-token"); err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+
+	refreshed := c.RefreshToken()
+	if refreshed == "" || refreshed == signedIn {
+		t.Fatalf("refresh token not rotated: %q", refreshed)
+	}
+	if old := authn.Tokens.Get(signedIn); old == nil || old.ReplacedByID == nil {
+		t.Fatal("login's refresh token not replaced")
+	} else if !strings.HasPrefix(old.UserAgent, "client-test") {
+		t.Fatalf("login recorded user agent %q", old.UserAgent)
+	}
+	if current := authn.Tokens.Get(refreshed); !strings.HasPrefix(current.UserAgent, "client-test") {
+		t.Fatalf("refresh recorded user agent %q", current.UserAgent)
+	}
+
+	resumed, err := client.Dial(ctx, client.Config{
+		Target:       "passthrough:///bufnet",
+		RefreshToken: refreshed,
+		DialOptions:  []grpc.DialOption{grpc.WithContextDialer(dialer(target))},
+	})
+	if err != nil {
+		t.Fatalf("Dial with refresh token: %v", err)
+	}
+	defer resumed.Close()
+
+	if resumed.RefreshToken() == refreshed {
+		t.Fatal("resuming didn't rotate the refresh token")
+	}
+}
+
+// serve starts a gRPC server with authn's auth service.
+func serve(t *testing.T, authn *servicetest.Auth) *bufconn.Listener {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := grpcTransport.NewServer(nil, authn.Service, nil, nil, nil, nil, nil, nil, nil, nil, authn.JWT, false, nil, logger)
+
+	listener := bufconn.Listen(1 << 20)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.GracefulStop)
+
+	return listener
+}
+
+func dialer(listener *bufconn.Listener) func(context.Context, string) (net.Conn, error) {
+	return func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}
+}
@@ -0,0 +1,179 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	userv1 "github.com/mvaleed/aegis/api/proto/user/v1"
+)
+
+// Login signs in and makes the new session the client's.
+func (c *Client) Login(ctx context.Context, email, password string) (*userv1.User, error) {
+	resp, err := c.Auth.Login(ctx, &userv1.LoginRequest{Email: email, Password: password})
+	if err != nil {
+		return nil, err
+	}
+	c.tokens.set(resp.GetAccessToken(), resp.GetRefreshToken(), resp.GetExpiresIn())
+	return resp.GetUser(), nil
+}
+
+// Logout revokes the session's refresh token and forgets the session.
+func (c *Client) Logout(ctx context.Context) error {
+	refresh := c.tokens.refreshToken()
+	if refresh == "" {
+		return ErrNotSignedIn
+	}
+	if _, err := c.Auth.Logout(ctx, &userv1.LogoutRequest{RefreshToken: refresh}); err != nil {
+		return err
+	}
+	c.tokens.set("", "", 0)
+	return nil
+}
+
+// ValidateToken asks the server whether an access token is valid and who
+// it was issued to. Unlike local validation, it sees ended impersonation
+// sessions.
+func (c *Client) ValidateToken(ctx context.Context, accessToken string) (*userv1.ValidateTokenResponse, error) {
+	return call(ctx, c, true, func(ctx context.Context) (*userv1.ValidateTokenResponse, error) {
+		return c.Auth.ValidateToken(ctx, &userv1.ValidateTokenRequest{AccessToken: accessToken})
+	})
+}
+
+// Users
+
+// CreateUser registers a user. Retries carry the same idempotency key, so
+// the user is created once.
+func (c *Client) CreateUser(ctx context.Context, req *userv1.CreateUserRequest) (*userv1.User, error) {
+	ctx = metadata.AppendToOutgoingContext(ctx, "idempotency-key", uuid.NewString())
+	resp, err := call(ctx, c, true, func(ctx context.Context) (*userv1.CreateUserResponse, error) {
+		return c.Users.CreateUser(ctx, req)
+	})
+	return resp.GetUser(), err
+}
+
+func (c *Client) GetUser(ctx context.Context, id uuid.UUID) (*userv1.User, error) {
+	resp, err := call(ctx, c, true, func(ctx context.Context) (*userv1.GetUserResponse, error) {
+		return c.Users.GetUser(ctx, &userv1.GetUserRequest{Id: id.String()})
+	})
+	return resp.GetUser(), err
+}
+
+func (c *Client) GetUserByEmail(ctx context.Context, email string) (*userv1.User, error) {
+	resp, err := call(ctx, c, true, func(ctx context.Context) (*userv1.GetUserResponse, error) {
+		return c.Users.GetUserByEmail(ctx, &userv1.GetUserByEmailRequest{Email: email})
+	})
+	return resp.GetUser(), err
+}
+
+func (c *Client) ListUsers(ctx context.Context, req *userv1.ListUsersRequest) (*userv1.ListUsersResponse, error) {
+	return call(ctx, c, true, func(ctx context.Context) (*userv1.ListUsersResponse, error) {
+		return c.Users.ListUsers(ctx, req)
+	})
+}
+
+func (c *Client) ActivateUser(ctx context.Context, id uuid.UUID) error {
+	return exec(ctx, c, true, func(ctx context.Context) (*emptypb.Empty, error) {
+		return c.Users.ActivateUser(ctx, &userv1.ActivateUserRequest{Id: id.String()})
+	})
+}
+
+func (c *Client) SuspendUser(ctx context.Context, id uuid.UUID) error {
+	return exec(ctx, c, false, func(ctx context.Context) (*emptypb.Empty, error) {
+		return c.Users.SuspendUser(ctx, &userv1.SuspendUserRequest{Id: id.String()})
+	})
+}
+
+// Roles and permissions
+
+// CheckPermission reports whether the user may perform action on resource.
+func (c *Client) CheckPermission(ctx context.Context, userID uuid.UUID, resource, action string) (bool, error) {
+	return c.CheckResourcePermission(ctx, userID, resource, "", action)
+}
+
+// CheckResourcePermission is CheckPermission for one instance of resource,
+// so grants on it count as well as the user's roles.
+func (c *Client) CheckResourcePermission(ctx context.Context, userID uuid.UUID, resource, resourceID, action string) (bool, error) {
+	resp, err := call(ctx, c, true, func(ctx context.Context) (*userv1.CheckPermissionResponse, error) {
+		return c.RBAC.CheckPermission(ctx, &userv1.CheckPermissionRequest{
+			UserId:     userID.String(),
+			Resource:   resource,
+			Action:     action,
+			ResourceId: resourceID,
+		})
+	})
+	return resp.GetHasPermission(), err
+}
+
+func (c *Client) GetRole(ctx context.Context, id uuid.UUID) (*userv1.Role, error) {
+	resp, err := call(ctx, c, true, func(ctx context.Context) (*userv1.GetRoleResponse, error) {
+		return c.RBAC.GetRole(ctx, &userv1.GetRoleRequest{Id: id.String()})
+	})
+	return resp.GetRole(), err
+}
+
+// AssignRole gives the user a role until expiresAt; the zero time keeps it
+// until removed.
+func (c *Client) AssignRole(ctx context.Context, userID, roleID uuid.UUID, expiresAt time.Time) error {
+	req := &userv1.AssignRoleRequest{UserId: userID.String(), RoleId: roleID.String()}
+	if !expiresAt.IsZero() {
+		req.ExpiresAt = timestamppb.New(expiresAt)
+	}
+	return exec(ctx, c, true, func(ctx context.Context) (*emptypb.Empty, error) {
+		return c.RBAC.AssignRole(ctx, req)
+	})
+}
+
+func (c *Client) RemoveRole(ctx context.Context, userID, roleID uuid.UUID) error {
+	return exec(ctx, c, true, func(ctx context.Context) (*emptypb.Empty, error) {
+		return c.RBAC.RemoveRole(ctx, &userv1.RemoveRoleRequest{UserId: userID.String(), RoleId: roleID.String()})
+	})
+}
+
+// Organizations and groups
+
+func (c *Client) GetOrganization(ctx context.Context, id uuid.UUID) (*userv1.Organization, error) {
+	resp, err := call(ctx, c, true, func(ctx context.Context) (*userv1.GetOrganizationResponse, error) {
+		return c.Organizations.GetOrganization(ctx, &userv1.GetOrganizationRequest{Id: id.String()})
+	})
+	return resp.GetOrganization(), err
+}
+
+func (c *Client) AddMember(ctx context.Context, orgID, userID uuid.UUID) error {
+	return exec(ctx, c, true, func(ctx context.Context) (*emptypb.Empty, error) {
+		return c.Organizations.AddMember(ctx, &userv1.AddMemberRequest{OrganizationId: orgID.String(), UserId: userID.String()})
+	})
+}
+
+func (c *Client) RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error {
+	return exec(ctx, c, true, func(ctx context.Context) (*emptypb.Empty, error) {
+		return c.Organizations.RemoveMember(ctx, &userv1.RemoveMemberRequest{OrganizationId: orgID.String(), UserId: userID.String()})
+	})
+}
+
+// ListMembers lists an organization's members; page starts at 1.
+func (c *Client) ListMembers(ctx context.Context, orgID uuid.UUID, page, pageSize int32) (*userv1.ListMembersResponse, error) {
+	return call(ctx, c, true, func(ctx context.Context) (*userv1.ListMembersResponse, error) {
+		return c.Organizations.ListMembers(ctx, &userv1.ListMembersRequest{
+			OrganizationId: orgID.String(),
+			Page:           page,
+			PageSize:       pageSize,
+		})
+	})
+}
+
+func (c *Client) AddGroupMember(ctx context.Context, groupID, userID uuid.UUID) error {
+	return exec(ctx, c, true, func(ctx context.Context) (*emptypb.Empty, error) {
+		return c.Groups.AddGroupMember(ctx, &userv1.AddGroupMemberRequest{GroupId: groupID.String(), UserId: userID.String()})
+	})
+}
+
+func (c *Client) RemoveGroupMember(ctx context.Context, groupID, userID uuid.UUID) error {
+	return exec(ctx, c, true, func(ctx context.Context) (*emptypb.Empty, error) {
+		return c.Groups.RemoveGroupMember(ctx, &userv1.RemoveGroupMemberRequest{GroupId: groupID.String(), UserId: userID.String()})
+	})
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// TokenSource returns the client's session as an oauth2.TokenSource, so
+// libraries built on golang.org/x/oauth2 can call aegis-protected services
+// with it: oauth2.NewClient for HTTP, or grpc's oauth.TokenSource
+// credentials. Tokens come from the session started by Dial or Login and
+// are refreshed like the client's own; ctx bounds the refresh calls.
+//
+// The tokens are a user's, signed in with Config.Email and Config.Password
+// or a stored RefreshToken. Services acting for themselves rather than a
+// user can instead register as OAuth clients and get tokens of their own
+// with ClientCredentialsTokenSource.
+func (c *Client) TokenSource(ctx context.Context) oauth2.TokenSource {
+	return &sessionTokenSource{ctx: ctx, tokens: c.tokens}
+}
+
+type sessionTokenSource struct {
+	ctx    context.Context
+	tokens *tokenSource
+}
+
+func (s *sessionTokenSource) Token() (*oauth2.Token, error) {
+	tok, err := s.tokens.current(s.ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tok.AccessToken == "" {
+		return nil, ErrNotSignedIn
+	}
+	return tok, nil
+}
+
+// ClientCredentialsTokenSource returns an oauth2.TokenSource of tokens of
+// an OAuth client's own, for services acting for themselves rather than a
+// user. It posts clientID and clientSecret to the client credentials grant
+// at baseURL's /oauth/token, baseURL being aegis's HTTP address (e.g.
+// "https://aegis.example.com"), not the gRPC target. scopes, if any, narrow
+// the tokens to some of the permissions the client's roles grant. Tokens
+// are reused until they expire, there being no refresh token; ctx bounds
+// the token requests.
+func ClientCredentialsTokenSource(ctx context.Context, baseURL, clientID, clientSecret string, scopes ...string) oauth2.TokenSource {
+	cfg := clientcredentials.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TokenURL:     strings.TrimSuffix(baseURL, "/") + "/oauth/token",
+		Scopes:       scopes,
+		AuthStyle:    oauth2.AuthStyleInParams,
+	}
+	return cfg.TokenSource(ctx)
+}
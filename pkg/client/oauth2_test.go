@@ -0,0 +1,58 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mvaleed/aegis/pkg/client"
+)
+
+func TestClientCredentialsTokenSource(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/oauth/token" {
+			t.Errorf("path %q", r.URL.Path)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+			return
+		}
+		for field, want := range map[string]string{
+			"grant_type":    "client_credentials",
+			"client_id":     "billing",
+			"client_secret": "s3cret",
+			"scope":         "users:read invoices:write",
+		} {
+			if got := r.PostForm.Get(field); got != want {
+				t.Errorf("%s = %q, want %q", field, got, want)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "client-token",
+			"token_type":   "Bearer",
+			"expires_in":   900,
+			"scope":        "users:read invoices:write",
+		})
+	}))
+	defer server.Close()
+
+	source := client.ClientCredentialsTokenSource(context.Background(), server.URL+"/", "billing", "s3cret", "users:read", "invoices:write")
+	for range 2 {
+		token, err := source.Token()
+		if err != nil {
+			t.Fatalf("Token: %v", err)
+		}
+		if token.AccessToken != "client-token" || token.Type() != "Bearer" {
+			t.Fatalf("token %+v", token)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("%d token requests, want the token reused", requests)
+	}
+}
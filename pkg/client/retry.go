@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// call runs fn, refreshing the access token once if the server rejects it,
+// and retrying UNAVAILABLE with backoff when the call is idempotent.
+// Rejected tokens are retried whatever the call, since the server refused
+// it before doing anything.
+func call[T any](ctx context.Context, c *Client, idempotent bool, fn func(context.Context) (T, error)) (T, error) {
+	backoff := c.retry.InitialBackoff
+	refreshed := false
+
+	for attempt := 1; ; attempt++ {
+		token, err := c.tokens.token(ctx)
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+
+		resp, err := fn(ctx)
+		switch status.Code(err) {
+		case codes.OK:
+			return resp, nil
+		case codes.Unauthenticated:
+			if refreshed || !c.tokens.expire(token) {
+				return resp, err
+			}
+			refreshed = true
+			attempt--
+			continue
+		case codes.Unavailable:
+			if !idempotent || attempt >= c.retry.MaxAttempts {
+				return resp, err
+			}
+		default:
+			return resp, err
+		}
+
+		// Full jitter, so clients recovering from the same outage don't
+		// retry in lockstep
+		timer := time.NewTimer(rand.N(backoff) + 1)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, ctx.Err()
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, c.retry.MaxBackoff)
+	}
+}
+
+// exec is call for methods without a useful response.
+func exec[T any](ctx context.Context, c *Client, idempotent bool, fn func(context.Context) (T, error)) error {
+	_, err := call(ctx, c, idempotent, fn)
+	return err
+}
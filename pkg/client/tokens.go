@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	userv1 "github.com/mvaleed/aegis/api/proto/user/v1"
+)
+
+// tokenSource holds the session's tokens and refreshes the access token
+// when it's about to expire. Refreshes are serialized, so concurrent calls
+// share one.
+type tokenSource struct {
+	auth   userv1.AuthServiceClient
+	before time.Duration
+
+	mu      sync.Mutex
+	access  string
+	refresh string
+	expiry  time.Time
+}
+
+func newTokenSource(auth userv1.AuthServiceClient, before time.Duration) *tokenSource {
+	return &tokenSource{auth: auth, before: before}
+}
+
+// set replaces the session's tokens. expiresIn is in seconds.
+func (t *tokenSource) set(access, refresh string, expiresIn int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.access = access
+	t.refresh = refresh
+	t.expiry = time.Now().Add(time.Duration(expiresIn) * time.Second)
+}
+
+// token returns a valid access token, refreshing it first if needed. It
+// returns "" without a session.
+func (t *tokenSource) token(ctx context.Context) (string, error) {
+	tok, err := t.current(ctx)
+	if err != nil {
+		return "", err
+	}
+	return tok.AccessToken, nil
+}
+
+// current returns the session's tokens, refreshing the access token first
+// if needed.
+func (t *tokenSource) current(ctx context.Context) (*oauth2.Token, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.refresh != "" && !time.Now().Add(t.before).Before(t.expiry) {
+		resp, err := t.auth.RefreshToken(ctx, &userv1.RefreshTokenRequest{RefreshToken: t.refresh})
+		if err != nil {
+			return nil, err
+		}
+
+		t.access = resp.GetAccessToken()
+		t.refresh = resp.GetRefreshToken()
+		t.expiry = time.Now().Add(time.Duration(resp.GetExpiresIn()) * time.Second)
+	}
+
+	// The refresh token stays here: refreshing it elsewhere would rotate
+	// it from under the session
+	return &oauth2.Token{
+		AccessToken: t.access,
+		TokenType:   "Bearer",
+		Expiry:      t.expiry,
+	}, nil
+}
+
+// expire forces a refresh before the next call, unless access has already
+// been replaced by another caller.
+func (t *tokenSource) expire(access string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.refresh == "" {
+		return false
+	}
+	if t.access == access {
+		t.expiry = time.Time{}
+	}
+	return true
+}
+
+func (t *tokenSource) refreshToken() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.refresh
+}
+
+// unauthenticatedMethods are called without an access token. Refreshing
+// from them would recurse.
+var unauthenticatedMethods = map[string]bool{
+	"/user.v1.AuthService/Login":        true,
+	"/user.v1.AuthService/RefreshToken": true,
+}
+
+// withToken attaches the access token to the outgoing metadata.
+func (c *Client) withToken(ctx context.Context, method string) (context.Context, error) {
+	if unauthenticatedMethods[method] {
+		return ctx, nil
+	}
+	token, err := c.tokens.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		return ctx, nil
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token), nil
+}
+
+func (c *Client) unaryAuth(
+	ctx context.Context,
+	method string,
+	req, reply any,
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	ctx, err := c.withToken(ctx, method)
+	if err != nil {
+		return err
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+func (c *Client) streamAuth(
+	ctx context.Context,
+	desc *grpc.StreamDesc,
+	cc *grpc.ClientConn,
+	method string,
+	streamer grpc.Streamer,
+	opts ...grpc.CallOption,
+) (grpc.ClientStream, error) {
+	ctx, err := c.withToken(ctx, method)
+	if err != nil {
+		return nil, err
+	}
+	return streamer(ctx, desc, cc, method, opts...)
+}